@@ -7,25 +7,221 @@ package client
 import (
 	"context"
 	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 
 	pb "github.com/agntcy/slim-otel/channelmanager/internal/channelmanager"
 )
 
+// EventType is the type of a channel event
+type EventType string
+
+const (
+	// EventChannelCreated reports a channel created or adopted by the channel manager
+	EventChannelCreated EventType = "channel-created"
+	// EventChannelDeleted reports a deleted channel
+	EventChannelDeleted EventType = "channel-deleted"
+	// EventParticipantJoined reports a participant invited to a channel
+	EventParticipantJoined EventType = "participant-joined"
+	// EventParticipantLeft reports a participant removed from a channel
+	EventParticipantLeft EventType = "participant-left"
+	// EventChannelFrozen reports a channel frozen, or frozen again
+	EventChannelFrozen EventType = "channel-frozen"
+	// EventChannelUnfrozen reports a channel unfrozen
+	EventChannelUnfrozen EventType = "channel-unfrozen"
+	// EventApprovalRequested reports a participant awaiting the approval of
+	// its addition to a protected channel
+	EventApprovalRequested EventType = "approval-requested"
+	// EventApprovalRejected reports the addition of a participant to a
+	// protected channel rejected or withdrawn
+	EventApprovalRejected EventType = "approval-rejected"
+	// EventChannelExpired reports a channel deleted once its time to live
+	// elapsed, after EventChannelDeleted
+	EventChannelExpired EventType = "channel-expired"
+)
+
+// Event is a change of a channel reported by WatchChannels
+type Event struct {
+	Type    EventType
+	Channel string
+	// Participant is empty for the channel events
+	Participant string
+	Time        time.Time
+}
+
+// InviteStatus is the status of the invitation of a channel participant
+type InviteStatus string
+
+const (
+	// InvitePending reports a participant being invited
+	InvitePending InviteStatus = "pending"
+	// InviteJoined reports a participant that accepted the invitation
+	InviteJoined InviteStatus = "joined"
+	// InviteAwaitingApproval reports a participant whose addition to a
+	// protected channel awaits an approval
+	InviteAwaitingApproval InviteStatus = "awaiting-approval"
+	// InviteFailed reports a participant whose invitation failed, retried
+	// in the background until all the attempts failed
+	InviteFailed InviteStatus = "failed"
+)
+
+// RouteStatus is the status of the route set by the channel manager towards
+// a channel participant
+type RouteStatus string
+
+const (
+	// RouteUnknown reports a route the channel manager did not set, e.g. to
+	// a participant that joined on its own
+	RouteUnknown RouteStatus = "unknown"
+	// RoutePending reports a route being set
+	RoutePending RouteStatus = "pending"
+	// RouteSet reports a route set on the connection of the channel manager
+	RouteSet RouteStatus = "set"
+	// RouteFailed reports a route that could not be set
+	RouteFailed RouteStatus = "failed"
+	// RouteUnmanaged reports that the channel manager does not set the routes
+	RouteUnmanaged RouteStatus = "unmanaged"
+)
+
+// Participant is a participant of a channel reported by GetChannel
+type Participant struct {
+	Name   string
+	Status InviteStatus
+	// Route is the status of the route to the participant, RouteAttempts
+	// the attempts made to set it and RouteError the error of the last
+	// failed attempt
+	Route         RouteStatus
+	RouteAttempts uint32
+	RouteError    string
+}
+
+// ParticipantInvite is the invitation of a participant sent by the channel
+// manager, reported by ListParticipantsDetailed
+type ParticipantInvite struct {
+	Participant string
+	// Status is InvitePending, InviteJoined or InviteFailed
+	Status InviteStatus
+	// Attempts is the number of attempts made to invite the participant
+	Attempts uint32
+	// Err is the error of the last failed attempt, empty if none
+	Err string
+	// NextRetry is the time of the next attempt, zero if not retried
+	NextRetry time.Time
+}
+
+// ParticipantList are the participants of a channel reported by
+// ListParticipantsDetailed
+type ParticipantList struct {
+	Participants []string
+	// AwaitingApproval are the participants whose addition to the protected
+	// channel awaits an approval
+	AwaitingApproval []string
+	// Invites are the invitations sent by the channel manager to the channel
+	Invites []ParticipantInvite
+}
+
+// ParticipantResult is the result of a participant of AddParticipants or
+// RemoveParticipants
+type ParticipantResult struct {
+	Participant string
+	// Err is nil if the participant was added or removed
+	Err error
+	// PendingApproval reports an addition to a protected channel awaiting
+	// an approval
+	PendingApproval bool
+}
+
+// ChannelDetails are the details of a channel reported by GetChannel
+type ChannelDetails struct {
+	Name       string
+	MlsEnabled bool
+	SessionID  uint32
+	// Created and LastActivity are zero if unknown
+	Created      time.Time
+	LastActivity time.Time
+	Participants []Participant
+	// retransmission settings of the group session
+	MaxRetries    uint32
+	RetryInterval time.Duration
+	// Frozen reports a channel whose publications are paused by
+	// FreezeChannel, since FrozenSince and for FreezeReason
+	Frozen       bool
+	FreezeReason string
+	FrozenSince  time.Time
+	// Expires is the time the channel is deleted, zero if it has no time to
+	// live
+	Expires time.Time
+}
+
 // Client provides a high-level interface to the Channel Manager service.
 type Client struct {
 	conn   *grpc.ClientConn
 	client pb.ChannelManagerServiceClient
 }
 
+// Option configures the connection of the client
+type Option func(*options)
+
+// options of the connection to the channel manager
+type options struct {
+	tls       bool
+	caFile    string
+	certFile  string
+	keyFile   string
+	authToken string
+}
+
+// WithTLS connects to the channel manager over TLS, verifying its certificate
+// against the CA certificates of caFile in PEM format, or against the system
+// roots if caFile is empty.
+func WithTLS(caFile string) Option {
+	return func(o *options) {
+		o.tls = true
+		o.caFile = caFile
+	}
+}
+
+// WithClientCertificate presents the certificate and private key files in PEM
+// format to the channel manager (mTLS). It implies WithTLS.
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(o *options) {
+		o.tls = true
+		o.certFile = certFile
+		o.keyFile = keyFile
+	}
+}
+
+// WithAuthToken sends the bearer token expected by the channel manager with
+// every call
+func WithAuthToken(token string) Option {
+	return func(o *options) {
+		o.authToken = token
+	}
+}
+
 // New creates a new Channel Manager client connected to the specified address.
-func New(address string) (*Client, error) {
-	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func New(address string, opts ...Option) (*Client, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	dialOpts, err := o.dialOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient(address, dialOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to channel manager: %w", err)
 	}
@@ -36,6 +232,60 @@ func New(address string) (*Client, error) {
 	}, nil
 }
 
+// dialOptions returns the gRPC dial options of the connection
+func (o *options) dialOptions() ([]grpc.DialOption, error) {
+	var dialOpts []grpc.DialOption
+
+	if !o.tls {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	} else {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if o.caFile != "" {
+			pem, err := os.ReadFile(o.caFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificate found in the CA file %s", o.caFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if o.certFile != "" || o.keyFile != "" {
+			cert, err := tls.LoadX509KeyPair(o.certFile, o.keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load the client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	}
+
+	if o.authToken != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(bearerToken{token: o.authToken, secure: o.tls}))
+	}
+
+	return dialOpts, nil
+}
+
+// bearerToken sends a bearer token in the authorization metadata of the calls
+type bearerToken struct {
+	token  string
+	secure bool
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials
+func (t bearerToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials. The
+// token is also sent over plaintext connections, e.g. to a local channel
+// manager, when TLS is not enabled.
+func (t bearerToken) RequireTransportSecurity() bool {
+	return t.secure
+}
+
 // Close closes the underlying gRPC connection.
 func (c *Client) Close() error {
 	if c.conn != nil {
@@ -44,16 +294,46 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// SessionSettings are the retransmission settings of the group session of a
+// channel created with CreateChannelWithSettings, e.g. more retries over a
+// lossy WAN link. The unset settings use the session defaults of the channel
+// manager.
+type SessionSettings struct {
+	// MaxRetries is unset if nil
+	MaxRetries *uint32
+	// RetryInterval is unset if 0
+	RetryInterval time.Duration
+	// TTL is the time after which the channel is deleted, e.g. for an
+	// ephemeral debugging channel. The channel does not expire if 0
+	TTL time.Duration
+}
+
 // CreateChannel creates a new channel with the specified name and MLS setting.
 func (c *Client) CreateChannel(ctx context.Context, channelName string, mlsEnabled bool) error {
+	return c.CreateChannelWithSettings(ctx, channelName, mlsEnabled, SessionSettings{})
+}
+
+// CreateChannelWithSettings creates a new channel with the specified name,
+// MLS setting and session retransmission settings.
+func (c *Client) CreateChannelWithSettings(
+	ctx context.Context, channelName string, mlsEnabled bool, settings SessionSettings,
+) error {
+	create := &pb.CreateChannelRequest{
+		ChannelName: channelName,
+		MlsEnabled:  mlsEnabled,
+		MaxRetries:  settings.MaxRetries,
+	}
+	if settings.RetryInterval > 0 {
+		retryIntervalMs := uint64(settings.RetryInterval.Milliseconds()) //nolint:gosec // the interval is positive
+		create.RetryIntervalMs = &retryIntervalMs
+	}
+	if settings.TTL > 0 {
+		ttlMs := uint64(settings.TTL.Milliseconds()) //nolint:gosec // the TTL is positive
+		create.TtlMs = &ttlMs
+	}
 	req := &pb.ControlRequest{
-		MgsId: generateMessageID(),
-		Payload: &pb.ControlRequest_CreateChannelRequest{
-			CreateChannelRequest: &pb.CreateChannelRequest{
-				ChannelName: channelName,
-				MlsEnabled:  mlsEnabled,
-			},
-		},
+		MgsId:   generateMessageID(),
+		Payload: &pb.ControlRequest_CreateChannelRequest{CreateChannelRequest: create},
 	}
 
 	return c.sendCommand(ctx, req)
@@ -75,6 +355,16 @@ func (c *Client) DeleteChannel(ctx context.Context, channelName string) error {
 
 // AddParticipant adds a participant to the specified channel.
 func (c *Client) AddParticipant(ctx context.Context, channelName, participantName string) error {
+	_, err := c.RequestParticipant(ctx, channelName, participantName)
+	return err
+}
+
+// RequestParticipant adds a participant to the specified channel, and
+// reports whether the addition awaits an approval because the channel is
+// protected. The parked addition is resolved with ResolveApproval.
+func (c *Client) RequestParticipant(
+	ctx context.Context, channelName, participantName string,
+) (pendingApproval bool, err error) {
 	req := &pb.ControlRequest{
 		MgsId: generateMessageID(),
 		Payload: &pb.ControlRequest_AddParticipantRequest{
@@ -85,6 +375,102 @@ func (c *Client) AddParticipant(ctx context.Context, channelName, participantNam
 		},
 	}
 
+	resp, err := c.sendCommandWithResponse(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	cmdResp, err := commandResult(resp)
+	if err != nil {
+		return false, err
+	}
+	return cmdResp.GetPendingApproval(), nil
+}
+
+// AddParticipants adds several participants to the specified channel with
+// one command, e.g. a fleet of collectors, and returns the result of each
+// participant. The error is only set if the command failed as a whole.
+func (c *Client) AddParticipants(
+	ctx context.Context, channelName string, participantNames []string,
+) ([]ParticipantResult, error) {
+	req := &pb.ControlRequest{
+		MgsId: generateMessageID(),
+		Payload: &pb.ControlRequest_AddParticipantsRequest{
+			AddParticipantsRequest: &pb.AddParticipantsRequest{
+				ChannelName:     channelName,
+				ParticipantName: participantNames,
+			},
+		},
+	}
+
+	return c.sendParticipantsCommand(ctx, req)
+}
+
+// RemoveParticipants removes several participants from the specified
+// channel with one command, and returns the result of each participant. The
+// error is only set if the command failed as a whole.
+func (c *Client) RemoveParticipants(
+	ctx context.Context, channelName string, participantNames []string,
+) ([]ParticipantResult, error) {
+	req := &pb.ControlRequest{
+		MgsId: generateMessageID(),
+		Payload: &pb.ControlRequest_RemoveParticipantsRequest{
+			RemoveParticipantsRequest: &pb.RemoveParticipantsRequest{
+				ChannelName:     channelName,
+				ParticipantName: participantNames,
+			},
+		},
+	}
+
+	return c.sendParticipantsCommand(ctx, req)
+}
+
+// sendParticipantsCommand sends a bulk participants command and converts the
+// results of its participants
+func (c *Client) sendParticipantsCommand(ctx context.Context, req *pb.ControlRequest) ([]ParticipantResult, error) {
+	resp, err := c.sendCommandWithResponse(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = commandResult(resp); err != nil {
+		return nil, err
+	}
+
+	payload, ok := resp.Payload.(*pb.ControlResponse_ParticipantsResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response type")
+	}
+	results := make([]ParticipantResult, 0, len(payload.ParticipantsResponse.Result))
+	for _, result := range payload.ParticipantsResponse.Result {
+		converted := ParticipantResult{
+			Participant:     result.ParticipantName,
+			PendingApproval: result.PendingApproval,
+		}
+		if !result.Success {
+			converted.Err = errors.New(result.GetErrorMsg())
+		}
+		results = append(results, converted)
+	}
+	return results, nil
+}
+
+// ResolveApproval approves or rejects the parked addition of a participant
+// to a protected channel. The approved participant is invited, the reason
+// is logged by the channel manager.
+func (c *Client) ResolveApproval(
+	ctx context.Context, channelName, participantName string, approved bool, reason string,
+) error {
+	req := &pb.ControlRequest{
+		MgsId: generateMessageID(),
+		Payload: &pb.ControlRequest_ResolveApprovalRequest{
+			ResolveApprovalRequest: &pb.ResolveApprovalRequest{
+				ChannelName:     channelName,
+				ParticipantName: participantName,
+				Approved:        approved,
+				Reason:          reason,
+			},
+		},
+	}
+
 	return c.sendCommand(ctx, req)
 }
 
@@ -103,6 +489,76 @@ func (c *Client) DeleteParticipant(ctx context.Context, channelName, participant
 	return c.sendCommand(ctx, req)
 }
 
+// UpdateChannel changes the participants of the specified channel to
+// participants, inviting the new ones and removing the others. When
+// mlsEnabled is not nil and differs from the channel setting, the channel
+// session is recreated with the new setting and the participants invited
+// again.
+func (c *Client) UpdateChannel(
+	ctx context.Context, channelName string, participants []string, mlsEnabled *bool,
+) error {
+	req := &pb.ControlRequest{
+		MgsId: generateMessageID(),
+		Payload: &pb.ControlRequest_UpdateChannelRequest{
+			UpdateChannelRequest: &pb.UpdateChannelRequest{
+				ChannelName:     channelName,
+				ParticipantName: participants,
+				MlsEnabled:      mlsEnabled,
+			},
+		},
+	}
+
+	return c.sendCommand(ctx, req)
+}
+
+// AdoptChannel registers a channel created by another participant, which
+// must invite the channel manager to it within timeout. A zero timeout uses
+// the default of the channel manager.
+func (c *Client) AdoptChannel(ctx context.Context, channelName string, timeout time.Duration) error {
+	req := &pb.ControlRequest{
+		MgsId: generateMessageID(),
+		Payload: &pb.ControlRequest_AdoptChannelRequest{
+			AdoptChannelRequest: &pb.AdoptChannelRequest{
+				ChannelName: channelName,
+				TimeoutMs:   uint32(timeout.Milliseconds()), //nolint:gosec // timeouts are far below the limit
+			},
+		},
+	}
+
+	return c.sendCommand(ctx, req)
+}
+
+// FreezeChannel pauses the publications on the specified channel, e.g.
+// during an incident of the backends downstream of its receivers. The
+// participants stay in the channel until UnfreezeChannel resumes them.
+func (c *Client) FreezeChannel(ctx context.Context, channelName, reason string) error {
+	req := &pb.ControlRequest{
+		MgsId: generateMessageID(),
+		Payload: &pb.ControlRequest_FreezeChannelRequest{
+			FreezeChannelRequest: &pb.FreezeChannelRequest{
+				ChannelName: channelName,
+				Reason:      reason,
+			},
+		},
+	}
+
+	return c.sendCommand(ctx, req)
+}
+
+// UnfreezeChannel resumes the publications on the specified frozen channel.
+func (c *Client) UnfreezeChannel(ctx context.Context, channelName string) error {
+	req := &pb.ControlRequest{
+		MgsId: generateMessageID(),
+		Payload: &pb.ControlRequest_UnfreezeChannelRequest{
+			UnfreezeChannelRequest: &pb.UnfreezeChannelRequest{
+				ChannelName: channelName,
+			},
+		},
+	}
+
+	return c.sendCommand(ctx, req)
+}
+
 // ListChannels returns a list of all channels.
 func (c *Client) ListChannels(ctx context.Context) ([]string, error) {
 	req := &pb.ControlRequest{
@@ -126,6 +582,26 @@ func (c *Client) ListChannels(ctx context.Context) ([]string, error) {
 
 // ListParticipants returns a list of participants in the specified channel.
 func (c *Client) ListParticipants(ctx context.Context, channelName string) ([]string, error) {
+	participants, _, err := c.ListParticipantsAwaitingApproval(ctx, channelName)
+	return participants, err
+}
+
+// ListParticipantsAwaitingApproval returns the participants in the specified
+// channel, and the participants whose addition to it awaits an approval.
+func (c *Client) ListParticipantsAwaitingApproval(
+	ctx context.Context, channelName string,
+) (participants, awaitingApproval []string, err error) {
+	list, err := c.ListParticipantsDetailed(ctx, channelName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list.Participants, list.AwaitingApproval, nil
+}
+
+// ListParticipantsDetailed returns the participants in the specified
+// channel, the participants whose addition to it awaits an approval, and the
+// state of the invitations sent to it.
+func (c *Client) ListParticipantsDetailed(ctx context.Context, channelName string) (*ParticipantList, error) {
 	req := &pb.ControlRequest{
 		MgsId: generateMessageID(),
 		Payload: &pb.ControlRequest_ListParticipantsRequest{
@@ -140,13 +616,254 @@ func (c *Client) ListParticipants(ctx context.Context, channelName string) ([]st
 		return nil, err
 	}
 
-	if payload, ok := resp.Payload.(*pb.ControlResponse_ListParticipantsResponse); ok {
-		return payload.ListParticipantsResponse.ParticipantName, nil
+	payload, ok := resp.Payload.(*pb.ControlResponse_ListParticipantsResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response type")
+	}
+
+	list := payload.ListParticipantsResponse
+	invites := make([]ParticipantInvite, 0, len(list.Invite))
+	for _, invite := range list.Invite {
+		invites = append(invites, ParticipantInvite{
+			Participant: invite.ParticipantName,
+			Status:      inviteState(invite.State),
+			Attempts:    invite.Attempts,
+			Err:         invite.Error,
+			NextRetry:   unixTime(invite.NextRetryUnixNano),
+		})
+	}
+	return &ParticipantList{
+		Participants:     list.ParticipantName,
+		AwaitingApproval: list.AwaitingApproval,
+		Invites:          invites,
+	}, nil
+}
+
+// GetChannel returns the details of the specified channel.
+func (c *Client) GetChannel(ctx context.Context, channelName string) (*ChannelDetails, error) {
+	req := &pb.ControlRequest{
+		MgsId: generateMessageID(),
+		Payload: &pb.ControlRequest_GetChannelRequest{
+			GetChannelRequest: &pb.GetChannelRequest{
+				ChannelName: channelName,
+			},
+		},
+	}
+
+	resp, err := c.sendCommandWithResponse(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, ok := resp.Payload.(*pb.ControlResponse_GetChannelResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response type")
+	}
+
+	details := payload.GetChannelResponse
+	participants := make([]Participant, 0, len(details.Participant))
+	for _, participant := range details.Participant {
+		participants = append(participants, Participant{
+			Name:          participant.ParticipantName,
+			Status:        inviteStatus(participant.Status),
+			Route:         routeStatus(participant.RouteStatus),
+			RouteAttempts: participant.RouteAttempts,
+			RouteError:    participant.RouteError,
+		})
+	}
+	return &ChannelDetails{
+		Name:         details.ChannelName,
+		MlsEnabled:   details.MlsEnabled,
+		SessionID:    details.SessionId,
+		Created:      unixTime(details.CreatedUnixNano),
+		LastActivity: unixTime(details.LastActivityUnixNano),
+		Participants: participants,
+
+		MaxRetries:    details.MaxRetries,
+		RetryInterval: time.Duration(details.RetryIntervalMs) * time.Millisecond,
+
+		Frozen:       details.Frozen,
+		FreezeReason: details.FreezeReason,
+		FrozenSince:  unixTime(details.FrozenUnixNano),
+		Expires:      unixTime(details.ExpiresUnixNano),
+	}, nil
+}
+
+// inviteStatus converts the protobuf participant status
+func inviteStatus(s pb.ParticipantStatus_Status) InviteStatus {
+	switch s {
+	case pb.ParticipantStatus_PENDING:
+		return InvitePending
+	case pb.ParticipantStatus_JOINED:
+		return InviteJoined
+	case pb.ParticipantStatus_AWAITING_APPROVAL:
+		return InviteAwaitingApproval
+	case pb.ParticipantStatus_FAILED:
+		return InviteFailed
+	default:
+		return InviteStatus(s.String())
+	}
+}
+
+// inviteState converts the protobuf invitation state
+func inviteState(s pb.ParticipantInvite_State) InviteStatus {
+	switch s {
+	case pb.ParticipantInvite_INVITE_PENDING:
+		return InvitePending
+	case pb.ParticipantInvite_INVITED:
+		return InviteJoined
+	case pb.ParticipantInvite_INVITE_FAILED:
+		return InviteFailed
+	default:
+		return InviteStatus(s.String())
+	}
+}
+
+// routeStatus converts the protobuf route status
+func routeStatus(s pb.ParticipantStatus_RouteStatus) RouteStatus {
+	switch s {
+	case pb.ParticipantStatus_ROUTE_STATUS_UNSPECIFIED:
+		return RouteUnknown
+	case pb.ParticipantStatus_ROUTE_PENDING:
+		return RoutePending
+	case pb.ParticipantStatus_ROUTE_SET:
+		return RouteSet
+	case pb.ParticipantStatus_ROUTE_FAILED:
+		return RouteFailed
+	case pb.ParticipantStatus_ROUTE_UNMANAGED:
+		return RouteUnmanaged
+	default:
+		return RouteStatus(s.String())
+	}
+}
+
+// unixTime converts nanoseconds since the Unix epoch, the zero time for 0
+func unixTime(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// AuditRoutes returns the routes set by the channel manager towards names
+// that are not a participant of any channel. When cleanup is set, the
+// orphaned routes are removed and the removed ones are returned as well.
+func (c *Client) AuditRoutes(ctx context.Context, cleanup bool) (orphaned, removed []string, err error) {
+	req := &pb.ControlRequest{
+		MgsId: generateMessageID(),
+		Payload: &pb.ControlRequest_AuditRoutesRequest{
+			AuditRoutesRequest: &pb.AuditRoutesRequest{
+				Cleanup: cleanup,
+			},
+		},
+	}
+
+	resp, err := c.sendCommandWithResponse(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch payload := resp.Payload.(type) {
+	case *pb.ControlResponse_AuditRoutesResponse:
+		return payload.AuditRoutesResponse.OrphanedRoute, payload.AuditRoutesResponse.RemovedRoute, nil
+	case *pb.ControlResponse_CommandResponse:
+		return nil, nil, fmt.Errorf("command failed: %s", payload.CommandResponse.GetErrorMsg())
+	}
+
+	return nil, nil, fmt.Errorf("unexpected response type")
+}
+
+// DrainParticipant removes a participant from all the channels, after
+// notifying it on each channel and waiting gracePeriod so that it can flush
+// its data. A zero grace period uses the default of the channel manager. It
+// returns the channels the participant was removed from, and an error listing
+// the channels it could not be removed from, if any.
+func (c *Client) DrainParticipant(
+	ctx context.Context, participantName string, gracePeriod time.Duration,
+) ([]string, error) {
+	req := &pb.ControlRequest{
+		MgsId: generateMessageID(),
+		Payload: &pb.ControlRequest_DrainParticipantRequest{
+			DrainParticipantRequest: &pb.DrainParticipantRequest{
+				ParticipantName: participantName,
+				GracePeriodMs:   uint32(gracePeriod.Milliseconds()), //nolint:gosec // grace periods are far below the limit
+			},
+		},
+	}
+
+	resp, err := c.sendCommandWithResponse(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch payload := resp.Payload.(type) {
+	case *pb.ControlResponse_DrainParticipantResponse:
+		drained := payload.DrainParticipantResponse.DrainedChannel
+		if failed := payload.DrainParticipantResponse.FailedChannel; len(failed) > 0 {
+			return drained, fmt.Errorf("failed to drain participant from channels %s", strings.Join(failed, ", "))
+		}
+		return drained, nil
+	case *pb.ControlResponse_CommandResponse:
+		return nil, fmt.Errorf("command failed: %s", payload.CommandResponse.GetErrorMsg())
 	}
 
 	return nil, fmt.Errorf("unexpected response type")
 }
 
+// WatchChannels calls handler for each change of the channel, or of all the
+// channels if channelName is empty, until ctx is canceled, the stream fails
+// or handler returns an error. It returns nil when ctx is canceled.
+func (c *Client) WatchChannels(ctx context.Context, channelName string, handler func(Event) error) error {
+	stream, err := c.client.WatchChannels(ctx, &pb.WatchChannelsRequest{ChannelName: channelName})
+	if err != nil {
+		return fmt.Errorf("failed to watch channels: %w", err)
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to receive channel event: %w", err)
+		}
+		if err := handler(Event{
+			Type:        eventType(event.Type),
+			Channel:     event.ChannelName,
+			Participant: event.ParticipantName,
+			Time:        time.Unix(0, event.TimestampUnixNano),
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+// eventType converts the protobuf event type
+func eventType(t pb.ChannelEvent_Type) EventType {
+	switch t {
+	case pb.ChannelEvent_CHANNEL_CREATED:
+		return EventChannelCreated
+	case pb.ChannelEvent_CHANNEL_DELETED:
+		return EventChannelDeleted
+	case pb.ChannelEvent_PARTICIPANT_JOINED:
+		return EventParticipantJoined
+	case pb.ChannelEvent_PARTICIPANT_LEFT:
+		return EventParticipantLeft
+	case pb.ChannelEvent_CHANNEL_FROZEN:
+		return EventChannelFrozen
+	case pb.ChannelEvent_CHANNEL_UNFROZEN:
+		return EventChannelUnfrozen
+	case pb.ChannelEvent_APPROVAL_REQUESTED:
+		return EventApprovalRequested
+	case pb.ChannelEvent_APPROVAL_REJECTED:
+		return EventApprovalRejected
+	case pb.ChannelEvent_CHANNEL_EXPIRED:
+		return EventChannelExpired
+	default:
+		return EventType(t.String())
+	}
+}
+
 // sendCommand sends a command and returns an error if the command failed.
 func (c *Client) sendCommand(ctx context.Context, req *pb.ControlRequest) error {
 	// Add timeout if not already set
@@ -161,18 +878,25 @@ func (c *Client) sendCommand(ctx context.Context, req *pb.ControlRequest) error
 		return fmt.Errorf("failed to send command: %w", err)
 	}
 
-	// Check response
-	if cmdResp, ok := resp.Payload.(*pb.ControlResponse_CommandResponse); ok {
-		if !cmdResp.CommandResponse.Success {
-			errMsg := "unknown error"
-			if cmdResp.CommandResponse.ErrorMsg != nil {
-				errMsg = *cmdResp.CommandResponse.ErrorMsg
-			}
-			return fmt.Errorf("command failed: %s", errMsg)
+	_, err = commandResult(resp)
+	return err
+}
+
+// commandResult returns the command response of resp, nil if resp has another
+// payload, or an error if the command failed.
+func commandResult(resp *pb.ControlResponse) (*pb.CommandResponse, error) {
+	cmdResp, ok := resp.Payload.(*pb.ControlResponse_CommandResponse)
+	if !ok {
+		return nil, nil
+	}
+	if !cmdResp.CommandResponse.Success {
+		errMsg := "unknown error"
+		if cmdResp.CommandResponse.ErrorMsg != nil {
+			errMsg = *cmdResp.CommandResponse.ErrorMsg
 		}
+		return nil, fmt.Errorf("command failed: %s", errMsg)
 	}
-
-	return nil
+	return cmdResp.CommandResponse, nil
 }
 
 // sendCommandWithResponse sends a command and returns the response.
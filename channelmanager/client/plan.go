@@ -0,0 +1,248 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChannelSpec is the desired state of a channel, in the format of the
+// channels of the channel manager configuration file
+type ChannelSpec struct {
+	Name         string   `yaml:"name"`
+	Participants []string `yaml:"participants"`
+	MlsEnabled   bool     `yaml:"mls-enabled"`
+}
+
+// ActionType is the type of a change of an execution plan
+type ActionType string
+
+const (
+	// ActionCreateChannel creates a channel
+	ActionCreateChannel ActionType = "create-channel"
+	// ActionRecreateChannel recreates a channel to change its MLS setting,
+	// its participants must be invited again
+	ActionRecreateChannel ActionType = "recreate-channel"
+	// ActionInviteParticipant invites a participant to a channel
+	ActionInviteParticipant ActionType = "invite-participant"
+	// ActionRemoveParticipant removes a participant from a channel
+	ActionRemoveParticipant ActionType = "remove-participant"
+	// ActionDeleteChannel deletes a channel
+	ActionDeleteChannel ActionType = "delete-channel"
+)
+
+// Action is a change of an execution plan
+type Action struct {
+	Type    ActionType
+	Channel string
+	// Participant is empty for the channel actions
+	Participant string
+	// MlsEnabled is the MLS setting of the created or recreated channels
+	MlsEnabled bool
+}
+
+// String describes the action
+func (a Action) String() string {
+	switch a.Type {
+	case ActionCreateChannel:
+		return fmt.Sprintf("+ create channel %s (mls: %t)", a.Channel, a.MlsEnabled)
+	case ActionRecreateChannel:
+		return fmt.Sprintf("~ recreate channel %s (mls: %t -> %t)", a.Channel, !a.MlsEnabled, a.MlsEnabled)
+	case ActionInviteParticipant:
+		return fmt.Sprintf("+ invite %s to channel %s", a.Participant, a.Channel)
+	case ActionRemoveParticipant:
+		return fmt.Sprintf("- remove %s from channel %s", a.Participant, a.Channel)
+	case ActionDeleteChannel:
+		return fmt.Sprintf("- delete channel %s", a.Channel)
+	default:
+		return fmt.Sprintf("? %s %s %s", a.Type, a.Channel, a.Participant)
+	}
+}
+
+// LoadChannelSpecs reads the desired channels from the channels section of a
+// YAML file, e.g. the channel manager configuration file
+func LoadChannelSpecs(path string) ([]ChannelSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the desired channels: %w", err)
+	}
+
+	var file struct {
+		Channels []ChannelSpec `yaml:"channels"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse the desired channels: %w", err)
+	}
+
+	seen := make(map[string]struct{}, len(file.Channels))
+	for i, channel := range file.Channels {
+		name, err := canonicalName(channel.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid channel at index %d: %w", i, err)
+		}
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("duplicate channel %s", name)
+		}
+		seen[name] = struct{}{}
+		for _, participant := range channel.Participants {
+			if _, err := canonicalName(participant); err != nil {
+				return nil, fmt.Errorf("invalid participant of channel %s: %w", name, err)
+			}
+		}
+	}
+	return file.Channels, nil
+}
+
+// Plan compares the desired channels with the channels of the channel
+// manager and returns the changes that would reconcile them, without
+// applying any. The channels that are not desired are deleted.
+func (c *Client) Plan(ctx context.Context, desired []ChannelSpec) ([]Action, error) {
+	names, err := c.ListChannels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list channels: %w", err)
+	}
+
+	live := make([]ChannelSpec, 0, len(names))
+	for _, name := range names {
+		details, err := c.GetChannel(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get channel %s: %w", name, err)
+		}
+		channel := ChannelSpec{Name: details.Name, MlsEnabled: details.MlsEnabled}
+		for _, participant := range details.Participants {
+			channel.Participants = append(channel.Participants, participant.Name)
+		}
+		live = append(live, channel)
+	}
+
+	return diffChannels(live, desired)
+}
+
+// Apply applies the actions of a plan in order and returns the actions
+// applied. It stops at the first failure, returning the actions applied
+// before it. The recreated channels are deleted and created again.
+func (c *Client) Apply(ctx context.Context, actions []Action) ([]Action, error) {
+	applied := make([]Action, 0, len(actions))
+	for _, action := range actions {
+		if err := c.applyAction(ctx, action); err != nil {
+			return applied, fmt.Errorf("failed to apply %q: %w", action.String(), err)
+		}
+		applied = append(applied, action)
+	}
+	return applied, nil
+}
+
+// applyAction issues the commands of an action
+func (c *Client) applyAction(ctx context.Context, action Action) error {
+	switch action.Type {
+	case ActionCreateChannel:
+		return c.CreateChannel(ctx, action.Channel, action.MlsEnabled)
+	case ActionRecreateChannel:
+		if err := c.DeleteChannel(ctx, action.Channel); err != nil {
+			return err
+		}
+		return c.CreateChannel(ctx, action.Channel, action.MlsEnabled)
+	case ActionInviteParticipant:
+		return c.AddParticipant(ctx, action.Channel, action.Participant)
+	case ActionRemoveParticipant:
+		return c.DeleteParticipant(ctx, action.Channel, action.Participant)
+	case ActionDeleteChannel:
+		return c.DeleteChannel(ctx, action.Channel)
+	default:
+		return fmt.Errorf("unknown action type %s", action.Type)
+	}
+}
+
+// diffChannels returns the changes turning the live channels into the
+// desired ones: the channels are created first, then updated in name order,
+// and the channels that are not desired are deleted last
+func diffChannels(live, desired []ChannelSpec) ([]Action, error) {
+	liveByName, err := channelsByName(live)
+	if err != nil {
+		return nil, err
+	}
+	desiredByName, err := channelsByName(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := make([]Action, 0)
+	for _, name := range slices.Sorted(maps.Keys(desiredByName)) {
+		want := desiredByName[name]
+		have, exists := liveByName[name]
+		switch {
+		case !exists:
+			actions = append(actions, Action{Type: ActionCreateChannel, Channel: name, MlsEnabled: want.MlsEnabled})
+			have = ChannelSpec{}
+		case have.MlsEnabled != want.MlsEnabled:
+			// the participants are not kept by the new session
+			actions = append(actions, Action{Type: ActionRecreateChannel, Channel: name, MlsEnabled: want.MlsEnabled})
+			have = ChannelSpec{}
+		}
+
+		for _, participant := range want.Participants {
+			if !slices.Contains(have.Participants, participant) {
+				actions = append(actions, Action{Type: ActionInviteParticipant, Channel: name, Participant: participant})
+			}
+		}
+		for _, participant := range have.Participants {
+			if !slices.Contains(want.Participants, participant) {
+				actions = append(actions, Action{Type: ActionRemoveParticipant, Channel: name, Participant: participant})
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(liveByName)) {
+		if _, ok := desiredByName[name]; !ok {
+			actions = append(actions, Action{Type: ActionDeleteChannel, Channel: name})
+		}
+	}
+	return actions, nil
+}
+
+// channelsByName indexes the channels by canonical name, with their
+// participants canonical, sorted and deduplicated
+func channelsByName(channels []ChannelSpec) (map[string]ChannelSpec, error) {
+	byName := make(map[string]ChannelSpec, len(channels))
+	for _, channel := range channels {
+		name, err := canonicalName(channel.Name)
+		if err != nil {
+			return nil, err
+		}
+		participants := make([]string, 0, len(channel.Participants))
+		for _, participant := range channel.Participants {
+			participantName, err := canonicalName(participant)
+			if err != nil {
+				return nil, err
+			}
+			participants = append(participants, participantName)
+		}
+		slices.Sort(participants)
+		byName[name] = ChannelSpec{
+			Name:         name,
+			Participants: slices.Compact(participants),
+			MlsEnabled:   channel.MlsEnabled,
+		}
+	}
+	return byName, nil
+}
+
+// canonicalName returns the 'org/namespace/app-or-stream' form of a name.
+// The names reported by the channel manager may carry the ID of the
+// participant instance as a fourth component, which is ignored.
+func canonicalName(name string) (string, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) < 3 || slices.Contains(parts[:3], "") {
+		return "", errors.New("names must be in the format organization/namespace/app-or-stream, got: " + name)
+	}
+	return strings.Join(parts[:3], "/"), nil
+}
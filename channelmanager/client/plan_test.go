@@ -0,0 +1,213 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	pb "github.com/agntcy/slim-otel/channelmanager/internal/channelmanager"
+)
+
+func TestDiffChannels(t *testing.T) {
+	const (
+		channel  = "agntcy/otel/channel"
+		receiver = "agntcy/otel/receiver"
+		exporter = "agntcy/otel/exporter"
+	)
+
+	tests := []struct {
+		name    string
+		live    []ChannelSpec
+		desired []ChannelSpec
+		want    []Action
+	}{
+		{
+			name:    "no changes",
+			live:    []ChannelSpec{{Name: channel, Participants: []string{receiver + "/1234"}}},
+			desired: []ChannelSpec{{Name: channel, Participants: []string{receiver}}},
+			want:    []Action{},
+		},
+		{
+			name:    "new channel",
+			desired: []ChannelSpec{{Name: channel, Participants: []string{receiver}, MlsEnabled: true}},
+			want: []Action{
+				{Type: ActionCreateChannel, Channel: channel, MlsEnabled: true},
+				{Type: ActionInviteParticipant, Channel: channel, Participant: receiver},
+			},
+		},
+		{
+			name:    "participants changed",
+			live:    []ChannelSpec{{Name: channel, Participants: []string{receiver}}},
+			desired: []ChannelSpec{{Name: channel, Participants: []string{exporter}}},
+			want: []Action{
+				{Type: ActionInviteParticipant, Channel: channel, Participant: exporter},
+				{Type: ActionRemoveParticipant, Channel: channel, Participant: receiver},
+			},
+		},
+		{
+			name:    "MLS changed",
+			live:    []ChannelSpec{{Name: channel, Participants: []string{receiver}}},
+			desired: []ChannelSpec{{Name: channel, Participants: []string{receiver}, MlsEnabled: true}},
+			want: []Action{
+				{Type: ActionRecreateChannel, Channel: channel, MlsEnabled: true},
+				{Type: ActionInviteParticipant, Channel: channel, Participant: receiver},
+			},
+		},
+		{
+			name: "channel not desired",
+			live: []ChannelSpec{{Name: channel, Participants: []string{receiver}}},
+			want: []Action{{Type: ActionDeleteChannel, Channel: channel}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions, err := diffChannels(tt.live, tt.desired)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, actions)
+		})
+	}
+
+	t.Run("invalid name", func(t *testing.T) {
+		_, err := diffChannels(nil, []ChannelSpec{{Name: "channel"}})
+		assert.ErrorContains(t, err, "organization/namespace/app-or-stream")
+	})
+}
+
+func TestLoadChannelSpecs(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "desired.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("channel manager configuration", func(t *testing.T) {
+		specs, err := LoadChannelSpecs(write(t, `
+manager:
+  local-name: agntcy/otel/channel-manager
+channels:
+  - name: agntcy/otel/channel
+    mls-enabled: true
+    participants:
+      - agntcy/otel/receiver
+`))
+		require.NoError(t, err)
+		assert.Equal(t, []ChannelSpec{{
+			Name:         "agntcy/otel/channel",
+			Participants: []string{"agntcy/otel/receiver"},
+			MlsEnabled:   true,
+		}}, specs)
+	})
+
+	t.Run("duplicate channel", func(t *testing.T) {
+		_, err := LoadChannelSpecs(write(t, `
+channels:
+  - name: agntcy/otel/channel
+  - name: agntcy/otel/channel
+`))
+		assert.ErrorContains(t, err, "duplicate channel agntcy/otel/channel")
+	})
+
+	t.Run("invalid participant", func(t *testing.T) {
+		_, err := LoadChannelSpecs(write(t, `
+channels:
+  - name: agntcy/otel/channel
+    participants: [receiver]
+`))
+		assert.ErrorContains(t, err, "invalid participant of channel agntcy/otel/channel")
+	})
+}
+
+// fakeService records the commands sent by the client and fails the
+// commands on the channel failing
+type fakeService struct {
+	pb.ChannelManagerServiceClient
+	commands []string
+	failing  string
+}
+
+func (f *fakeService) Command(
+	_ context.Context, req *pb.ControlRequest, _ ...grpc.CallOption,
+) (*pb.ControlResponse, error) {
+	var command, channel string
+	switch payload := req.Payload.(type) {
+	case *pb.ControlRequest_CreateChannelRequest:
+		command = fmt.Sprintf("create %s mls=%t", payload.CreateChannelRequest.ChannelName,
+			payload.CreateChannelRequest.MlsEnabled)
+		channel = payload.CreateChannelRequest.ChannelName
+	case *pb.ControlRequest_DeleteChannelRequest:
+		command = "delete " + payload.DeleteChannelRequest.ChannelName
+		channel = payload.DeleteChannelRequest.ChannelName
+	case *pb.ControlRequest_AddParticipantRequest:
+		command = "add " + payload.AddParticipantRequest.ParticipantName
+		channel = payload.AddParticipantRequest.ChannelName
+	case *pb.ControlRequest_DeleteParticipantRequest:
+		command = "remove " + payload.DeleteParticipantRequest.ParticipantName
+		channel = payload.DeleteParticipantRequest.ChannelName
+	}
+	f.commands = append(f.commands, command)
+
+	resp := &pb.CommandResponse{Success: channel != f.failing}
+	if !resp.Success {
+		msg := "channel unavailable"
+		resp.ErrorMsg = &msg
+	}
+	return &pb.ControlResponse{
+		MgsId:   req.MgsId,
+		Payload: &pb.ControlResponse_CommandResponse{CommandResponse: resp},
+	}, nil
+}
+
+func TestClient_Apply(t *testing.T) {
+	const (
+		channel  = "agntcy/otel/channel"
+		other    = "agntcy/otel/other-channel"
+		receiver = "agntcy/otel/receiver"
+		exporter = "agntcy/otel/exporter"
+	)
+	actions := []Action{
+		{Type: ActionCreateChannel, Channel: channel, MlsEnabled: true},
+		{Type: ActionInviteParticipant, Channel: channel, Participant: receiver},
+		{Type: ActionRecreateChannel, Channel: other},
+		{Type: ActionRemoveParticipant, Channel: other, Participant: exporter},
+		{Type: ActionDeleteChannel, Channel: "agntcy/otel/old-channel"},
+	}
+
+	t.Run("applies the actions in order", func(t *testing.T) {
+		service := &fakeService{}
+		c := &Client{client: service}
+
+		applied, err := c.Apply(t.Context(), actions)
+		require.NoError(t, err)
+		assert.Equal(t, actions, applied)
+		assert.Equal(t, []string{
+			"create agntcy/otel/channel mls=true",
+			"add agntcy/otel/receiver",
+			"delete agntcy/otel/other-channel",
+			"create agntcy/otel/other-channel mls=false",
+			"remove agntcy/otel/exporter",
+			"delete agntcy/otel/old-channel",
+		}, service.commands)
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		service := &fakeService{failing: other}
+		c := &Client{client: service}
+
+		applied, err := c.Apply(t.Context(), actions)
+		require.ErrorContains(t, err, "channel unavailable")
+		assert.ErrorContains(t, err, "recreate channel agntcy/otel/other-channel")
+		assert.Equal(t, actions[:2], applied)
+		assert.Len(t, service.commands, 3)
+	})
+}
@@ -0,0 +1,49 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	channelmanager "github.com/agntcy/slim-otel/channelmanager/internal/channelmanager"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// startAdminServer serves the log level on address until ctx is done, see
+// channelmanager.LevelHandler
+func startAdminServer(ctx context.Context, address string, level zap.AtomicLevel) error {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on admin address %s: %w", address, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(channelmanager.LogLevelPath, channelmanager.LevelHandler(level, logger))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	logger.Info("Starting admin server", zap.String("address", address))
+	go func() {
+		if err := server.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Admin server failed", zap.Error(err))
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	return nil
+}
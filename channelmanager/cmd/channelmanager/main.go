@@ -23,14 +23,17 @@ import (
 )
 
 type channelManagerApp struct {
-	cfg      *channelmanager.Config
-	app      *slim.App
-	connID   uint64
-	channels *slimcommon.SessionsList
+	cfg       *channelmanager.Config
+	app       slimcommon.App
+	connID    uint64
+	channels  *slimcommon.SessionsList
+	routes    *channelmanager.RouteTable
+	telemetry *channelmanager.Telemetry
 }
 
 func main() {
-	// Initialize zap logger
+	// Initialize zap logger, replaced by the configured one once the
+	// configuration is loaded
 	logger, err := zap.NewProduction()
 	if err != nil {
 		logger.Fatal("Failed to initialize zap logger", zap.Error(err))
@@ -40,9 +43,6 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Add logger to context
-	ctx = slimcommon.InitContextWithLogger(ctx, logger)
-
 	// Parse command-line flags
 	configfile := flag.String("config-file", "config.yaml", "Path to configuration file")
 	flag.Parse()
@@ -57,23 +57,57 @@ func main() {
 		logger.Fatal("Invalid configuration", zap.Error(validateErr))
 	}
 
+	configured, level, err := cfg.Manager.Logging.NewLogger()
+	if err != nil {
+		logger.Fatal("Failed to initialize the logger", zap.Error(err))
+	}
+	logger = configured
+	defer func() { _ = logger.Sync() }()
+
+	// Add logger to context
+	ctx = slimcommon.InitContextWithLogger(ctx, logger)
+
+	// serve the log level to change it without a restart
+	if cfg.Manager.AdminAddress != "" {
+		if adminErr := startAdminServer(ctx, cfg.Manager.AdminAddress, level); adminErr != nil {
+			logger.Fatal("Failed to start the admin server", zap.Error(adminErr))
+		}
+	}
+
 	// connect to slim and start the local app
-	connID, err := slimcommon.InitAndConnect(*cfg.Manager.ConnectionConfig)
+	connID, err := slimcommon.NewConnector().Connect(*cfg.Manager.ConnectionConfig)
 
 	if err != nil {
 		logger.Fatal("Failed to connect to SLIM server", zap.Error(err))
 	}
-	app, err := slimcommon.CreateApp(cfg.Manager.LocalName, cfg.Manager.SharedSecret, connID, slim.DirectionNone)
+	// the leave requests are received on the channels
+	direction := slim.DirectionNone
+	if cfg.Manager.LeaveRequests {
+		direction = slim.DirectionBidirectional
+	}
+	app, err := slimcommon.CreateApp(cfg.Manager.LocalName, cfg.Manager.SharedSecret, connID, direction)
 	if err != nil {
 		logger.Fatal("Failed to create or connect app", zap.Error(err))
 	}
 	defer app.Destroy()
 
+	// expose the control plane metrics if configured
+	meterProvider, err := startMetricsServer(ctx, cfg.Manager.MetricsAddress)
+	if err != nil {
+		logger.Fatal("Failed to start metrics server", zap.Error(err))
+	}
+	telemetry, err := channelmanager.NewTelemetry(meterProvider)
+	if err != nil {
+		logger.Fatal("Failed to create telemetry", zap.Error(err))
+	}
+
 	manager := &channelManagerApp{
-		cfg:      cfg,
-		app:      app,
-		connID:   connID,
-		channels: slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		cfg:       cfg,
+		app:       app,
+		connID:    connID,
+		channels:  slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		routes:    channelmanager.NewRouteTable(),
+		telemetry: telemetry,
 	}
 
 	// Set up signal handling for Ctrl+C
@@ -90,7 +124,67 @@ func main() {
 		logger.Fatal("Failed to create sessions from the config file", zap.Error(createErr))
 	}
 
-	server := channelmanager.NewChannelManagerServer(manager.app, manager.connID, manager.channels)
+	opts := []channelmanager.ServerOption{
+		channelmanager.WithTelemetry(manager.telemetry),
+		channelmanager.WithLocalName(cfg.Manager.LocalName),
+		channelmanager.WithRoutes(manager.routes),
+		channelmanager.WithRouteSettings(cfg.Manager.Routes),
+		channelmanager.WithInviteSettings(cfg.Manager.Invites),
+		channelmanager.WithChannelTTLs(cfg.Channels),
+		channelmanager.WithSessionDefaults(cfg.Manager.SessionDefaults),
+		channelmanager.WithNamespaces(cfg.Manager.NamespaceDefaults, cfg.Manager.Namespaces),
+	}
+	if cfg.Manager.AdoptTimeout > 0 {
+		opts = append(opts, channelmanager.WithAdoptTimeout(cfg.Manager.AdoptTimeout))
+	}
+	if cfg.Manager.StateFile != "" {
+		opts = append(opts, channelmanager.WithStateStore(channelmanager.NewFileStateStore(cfg.Manager.StateFile)))
+	}
+	if cfg.Manager.Approval != nil {
+		// without webhook the requests are only reported to the clients
+		var approver channelmanager.Approver
+		if cfg.Manager.Approval.WebhookURL != "" {
+			approver = channelmanager.NewWebhookApprover(cfg.Manager.Approval)
+		}
+		opts = append(opts, channelmanager.WithApproval(approver, cfg.Manager.Approval.ProtectedChannels))
+	}
+	server := channelmanager.NewChannelManagerServer(manager.app, manager.connID, manager.channels, opts...)
+
+	// recreate the channels created through the service before the restart
+	if restoreErr := server.Restore(ctx); restoreErr != nil {
+		logger.Fatal("Failed to restore the channels", zap.Error(restoreErr))
+	}
+
+	if cfg.Manager.LeaveRequests {
+		go server.ServeLeaveRequests(ctx)
+	}
+
+	// the channels with a time to live are deleted once it elapses
+	go server.ServeExpiry(ctx)
+
+	// the failed invitations are retried in the background
+	go server.ServeInviteRetries(ctx)
+
+	// the configuration file is the source of truth of the channels
+	if cfg.Manager.ReconcileInterval > 0 {
+		go server.ServeReconciliation(ctx, cfg.Manager.ReconcileInterval, func() ([]channelmanager.ChannelConfig, error) {
+			desired, loadErr := channelmanager.LoadConfig(*configfile)
+			if loadErr != nil {
+				return nil, loadErr
+			}
+			return desired.Channels, nil
+		})
+	}
+
+	// the SlimChannel resources of the cluster are the source of truth of
+	// the channels
+	if cfg.Manager.Operator != nil {
+		kubeClient, kubeErr := channelmanager.NewKubeClient(cfg.Manager.Operator)
+		if kubeErr != nil {
+			logger.Fatal("Failed to create the Kubernetes client", zap.Error(kubeErr))
+		}
+		go server.ServeOperator(ctx, kubeClient, cfg.Manager.Operator.ResyncInterval)
+	}
 
 	// Create gRPC server
 	lis, err := net.Listen("tcp", cfg.Manager.GRPCAddress)
@@ -98,10 +192,28 @@ func main() {
 		logger.Fatal("Failed to listen on gRPC address", zap.String("address", cfg.Manager.GRPCAddress), zap.Error(err))
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcOpts, err := cfg.Manager.GRPCServerOptions()
+	if err != nil {
+		logger.Fatal("Failed to configure the gRPC server", zap.Error(err))
+	}
+	grpcServer := grpc.NewServer(grpcOpts...)
 	channelmanager.RegisterChannelManagerServiceServer(grpcServer, server)
 
-	logger.Info("Starting gRPC server", zap.String("address", cfg.Manager.GRPCAddress))
+	// report the readiness to the probes through the standard health service
+	localName, err := slimcommon.SplitID(cfg.Manager.LocalName)
+	if err != nil {
+		logger.Fatal("Invalid local name", zap.String("local_name", cfg.Manager.LocalName), zap.Error(err))
+	}
+	health := channelmanager.NewHealth(channelmanager.SLIMReadiness(
+		slimcommon.NewConnector(), cfg.Manager.ConnectionConfig.Address, manager.app, localName, manager.connID))
+	health.Register(grpcServer)
+	go health.Serve(ctx, cfg.Manager.HealthCheckInterval)
+
+	logger.Info("Starting gRPC server",
+		zap.String("address", cfg.Manager.GRPCAddress),
+		zap.Bool("tls", cfg.Manager.ServiceTLS != nil),
+		zap.Bool("mtls", cfg.Manager.ServiceTLS != nil && cfg.Manager.ServiceTLS.ClientCAFile != ""),
+		zap.Bool("auth", cfg.Manager.ServiceAuthToken != ""))
 
 	// Start gRPC server in a goroutine
 	go func() {
@@ -139,18 +251,15 @@ func (cm *channelManagerApp) createSessions(
 			return fmt.Errorf("failed to parse channel name: %w", err)
 		}
 
-		// setup standard session config
-		interval := time.Millisecond * 1000
-		maxRetries := uint32(10)
-		sessionConfig := slim.SessionConfig{
-			SessionType: slim.SessionTypeGroup,
-			EnableMls:   config.MlsEnabled,
-			MaxRetries:  &maxRetries,
-			Interval:    &interval,
-			Metadata:    make(map[string]string),
-		}
+		// the channel settings override the manager defaults
+		sessionConfig := channelmanager.ChannelSessionConfig(config.MlsEnabled,
+			config.Session.WithDefaults(cm.cfg.Manager.SessionDefaults))
+		config.Policy().AddToMetadata(sessionConfig.Metadata)
+		sessionConfig.Metadata[slimcommon.MetadataInitiator] = cm.cfg.Manager.LocalName
 
+		start := time.Now()
 		session, err := cm.app.CreateSessionAndWait(sessionConfig, channel)
+		cm.telemetry.RecordCreateSession(ctx, channel.String(), start, err)
 		if err != nil {
 			return fmt.Errorf("failed to create the session: %w", err)
 		}
@@ -160,10 +269,14 @@ func (cm *channelManagerApp) createSessions(
 			if parseErr != nil {
 				return fmt.Errorf("failed to parse participant name %s for channel %s: %w", participant, config.Name, parseErr)
 			}
-			if routeErr := cm.app.SetRoute(participantName, cm.connID); routeErr != nil {
+			routeErr := cm.routes.Set(ctx, cm.app, cm.connID, participantName, cm.cfg.Manager.Routes)
+			if routeErr != nil {
 				return fmt.Errorf("failed to set route for participant %s for channel %s: %w", participant, config.Name, routeErr)
 			}
-			if inviteErr := session.InviteAndWait(participantName); inviteErr != nil {
+			inviteStart := time.Now()
+			inviteErr := session.InviteAndWait(participantName)
+			cm.telemetry.RecordInvite(ctx, channel.String(), inviteStart, inviteErr)
+			if inviteErr != nil {
 				return fmt.Errorf("failed to invite participant %s for channel %s: %w", participant, config.Name, inviteErr)
 			}
 		}
@@ -0,0 +1,52 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agntcy/slim-otel/channelmanager/internal/channelmanager"
+)
+
+// TestMetricsServer tests that the metrics documented in the README are
+// served on /metrics with their Prometheus names
+func TestMetricsServer(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	meterProvider, err := startMetricsServer(t.Context(), address)
+	require.NoError(t, err)
+	telemetry, err := channelmanager.NewTelemetry(meterProvider)
+	require.NoError(t, err)
+	telemetry.RecordCreateSession(t.Context(), "agntcy/otel/channel", time.Now(), nil)
+	telemetry.RecordInvite(t.Context(), "agntcy/otel/channel", time.Now(), errors.New("boom"))
+
+	var body string
+	require.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + address + "/metrics") //nolint:noctx // test request
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		body = string(data)
+		return err == nil && resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Contains(t, body,
+		`channelmanager_create_session_duration_seconds_count{channel="agntcy/otel/channel",otel_scope_name=`)
+	assert.Contains(t, body, `outcome="success"`)
+	assert.Contains(t, body, "channelmanager_invite_duration_seconds_bucket{")
+	assert.Contains(t, body, `outcome="failure"`)
+}
@@ -0,0 +1,593 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"flag"
+	"math"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/agntcy/slim-otel/channelmanager/client"
+)
+
+// commandTimeout is the timeout of a command sent to the channel manager
+const commandTimeout = 10 * time.Second
+
+// command is a cmctl command, e.g. "channel create"
+type command struct {
+	name string
+	// args are the positional arguments and the flags in the usage
+	args        string
+	description string
+	run         func(c *cli, args []string)
+}
+
+// commands are the cmctl commands, in the order of the usage
+var commands = []command{
+	{"channel list", "", "List the channels of the channel manager", runChannelList},
+	{"channel get", "<channel>", "Show the details of a channel and the status of its participants", runChannelGet},
+	{"channel create", "<channel> [-disable-mls] [-ttl duration]", "Create a channel, with MLS unless disabled", runChannelCreate},
+	{"channel delete", "<channel>", "Delete a channel", runChannelDelete},
+	{"channel update", "<channel> -participants <names> [-mls <bool>]",
+		"Set the participants and MLS setting of a channel", runChannelUpdate},
+	{"channel adopt", "<channel>", "Register a channel created by another participant", runChannelAdopt},
+	{"channel freeze", "<channel> [-reason <text>]", "Pause the publications on a channel, keeping its participants",
+		runChannelFreeze},
+	{"channel unfreeze", "<channel>", "Resume the publications on a frozen channel", runChannelFreeze},
+	{"channel watch", "[channel]", "Print the changes of all channels, or of a channel, until interrupted",
+		runChannelWatch},
+	{"participant list", "<channel>", "List the participants of a channel", runParticipantList},
+	{"participant add", "<channel> <participant>...", "Add participants to a channel", runParticipantAdd},
+	{"participant remove", "<channel> <participant>...", "Remove participants from a channel", runParticipantRemove},
+	{"participant approve", "<channel> <participant>", "Approve the addition of a participant to a protected channel",
+		runParticipantApproval},
+	{"participant reject", "<channel> <participant> [-reason <text>]",
+		"Reject the addition of a participant to a protected channel", runParticipantApproval},
+	{"participant drain", "<participant> [-grace-period <duration>]",
+		"Notify a participant and remove it from all channels", runParticipantDrain},
+	{"routes audit", "", "Report the routes to names that are not a participant of any channel", runRoutesAudit},
+	{"routes cleanup", "", "Remove the routes reported by routes audit", runRoutesAudit},
+	{"diff", "-f <file>", "Print the changes reconciling the channels with a file, not applied", runPlan},
+	{"apply", "-f <file>", "Apply the changes reconciling the channels with a file, and print them", runPlan},
+	{"context list", "", "List the contexts of the configuration file", runContextList},
+	{"context use", "<name>", "Set the current context", runContextUse},
+	{"context set", "<name> [connection options]", "Add a context, or update the options set of a context", runContextSet},
+	{"context delete", "<name>", "Delete a context", runContextDelete},
+}
+
+// legacyCommands maps the commands of the previous versions to the
+// subcommands, still accepted with a deprecation warning
+var legacyCommands = map[string]string{
+	"list-channels":      "channel list",
+	"get-channel":        "channel get",
+	"create-channel":     "channel create",
+	"delete-channel":     "channel delete",
+	"update-channel":     "channel update",
+	"adopt-channel":      "channel adopt",
+	"watch-channels":     "channel watch",
+	"list-participants":  "participant list",
+	"add-participant":    "participant add",
+	"delete-participant": "participant remove",
+	"drain":              "participant drain",
+	"audit-routes":       "routes audit",
+	"cleanup-routes":     "routes cleanup",
+}
+
+// findCommand returns the command named by the first arguments, and the
+// arguments following its name
+func findCommand(args []string) (*command, []string) {
+	for i := range commands {
+		name := strings.Fields(commands[i].name)
+		if len(args) >= len(name) && strings.Join(args[:len(name)], " ") == commands[i].name {
+			return &commands[i], args[len(name):]
+		}
+	}
+	return nil, nil
+}
+
+// cli holds the state shared by the commands
+type cli struct {
+	command *command
+	logger  *zap.Logger
+	out     *printer
+
+	configPath  string
+	contextName string
+	flags       *connectionFlags
+
+	client *client.Client
+}
+
+// usageError logs an invalid command line and exits with exitUsage
+func (c *cli) usageError(msg string, fields ...zap.Field) {
+	usageError(c.logger, msg, fields...)
+}
+
+// parseArgs parses the flags of the command, before or after its positional
+// arguments, and returns the positional arguments. It exits with exitUsage
+// unless there are between minArgs and maxArgs of them.
+func (c *cli) parseArgs(flags *flag.FlagSet, args []string, minArgs, maxArgs int) []string {
+	if flags == nil {
+		flags = c.flagSet()
+	}
+	var positional []string
+	for {
+		_ = flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) < minArgs || len(positional) > maxArgs {
+		c.usageError("Invalid arguments, expected: cmctl "+strings.TrimSpace(c.command.name+" "+c.command.args),
+			zap.Strings("args", positional))
+	}
+	return positional
+}
+
+// flagSet returns the flags of the command
+func (c *cli) flagSet() *flag.FlagSet {
+	return flag.NewFlagSet(c.command.name, flag.ExitOnError)
+}
+
+// loadConfig loads the configuration file, or exits with exitFailure
+func (c *cli) loadConfig() *cliConfig {
+	cfg, err := loadConfig(c.configPath)
+	if err != nil {
+		c.logger.Fatal("Failed to load the configuration", zap.Error(err))
+	}
+	return cfg
+}
+
+// saveConfig saves the configuration file, or exits with exitFailure
+func (c *cli) saveConfig(cfg *cliConfig) {
+	if err := cfg.save(c.configPath); err != nil {
+		c.logger.Fatal("Failed to save the configuration", zap.Error(err))
+	}
+}
+
+// connect returns the client of the channel manager of the context,
+// connecting on the first call
+func (c *cli) connect() *client.Client {
+	if c.client != nil {
+		return c.client
+	}
+	resolved, err := resolveContext(c.loadConfig(), c.contextName, c.flags)
+	if err != nil {
+		c.usageError("Invalid context", zap.Error(err))
+	}
+	c.client, err = client.New(resolved.Server, resolved.clientOptions()...)
+	if err != nil {
+		c.logger.Fatal("Failed to connect to server", zap.String("address", resolved.Server), zap.Error(err))
+	}
+	return c.client
+}
+
+// close closes the client, if connected
+func (c *cli) close() {
+	if c.client == nil {
+		return
+	}
+	if err := c.client.Close(); err != nil {
+		c.logger.Error("Failed to close client", zap.Error(err))
+	}
+}
+
+// timeout returns the context of a command sent to the channel manager
+func (c *cli) timeout() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), commandTimeout)
+}
+
+func runChannelList(c *cli, args []string) {
+	c.parseArgs(nil, args, 0, 0)
+	ctx, cancel := c.timeout()
+	defer cancel()
+	channels, err := c.connect().ListChannels(ctx)
+	if err != nil {
+		c.logger.Fatal("Failed to list channels", zap.Error(err))
+	}
+	c.out.print(&channelList{Channels: nonNil(channels)})
+}
+
+func runChannelGet(c *cli, args []string) {
+	channelName := c.parseArgs(nil, args, 1, 1)[0]
+	ctx, cancel := c.timeout()
+	defer cancel()
+	details, err := c.connect().GetChannel(ctx, channelName)
+	if err != nil {
+		c.logger.Fatal("Failed to get channel", zap.Error(err))
+	}
+	c.out.print(newChannelDetails(details))
+}
+
+func runChannelCreate(c *cli, args []string) {
+	flags := c.flagSet()
+	disableMls := flags.Bool("disable-mls", false, "create the channel without MLS")
+	maxRetries := flags.Int("max-retries", -1,
+		"maximum number of retransmissions of a message, the channel manager default if negative")
+	retryInterval := flags.Duration("retry-interval", 0,
+		"interval between the retransmissions, the channel manager default if 0")
+	ttl := flags.Duration("ttl", 0, "time after which the channel is deleted, no expiry if 0")
+	channelName := c.parseArgs(flags, args, 1, 1)[0]
+	if *retryInterval < 0 || *maxRetries > math.MaxUint32 {
+		c.logger.Fatal("Invalid retransmission settings",
+			zap.Int("max_retries", *maxRetries), zap.Duration("retry_interval", *retryInterval))
+	}
+	if *ttl < 0 {
+		c.logger.Fatal("Invalid time to live", zap.Duration("ttl", *ttl))
+	}
+	settings := client.SessionSettings{RetryInterval: *retryInterval, TTL: *ttl}
+	if *maxRetries >= 0 {
+		retries := uint32(*maxRetries) //nolint:gosec // checked above
+		settings.MaxRetries = &retries
+	}
+	ctx, cancel := c.timeout()
+	defer cancel()
+	if err := c.connect().CreateChannelWithSettings(ctx, channelName, !*disableMls, settings); err != nil {
+		c.logger.Fatal("Failed to create channel", zap.Error(err))
+	}
+	c.out.print(&commandResult{Command: c.command.name, Channel: channelName, message: "Channel created successfully"})
+}
+
+func runChannelDelete(c *cli, args []string) {
+	channelName := c.parseArgs(nil, args, 1, 1)[0]
+	ctx, cancel := c.timeout()
+	defer cancel()
+	if err := c.connect().DeleteChannel(ctx, channelName); err != nil {
+		c.logger.Fatal("Failed to delete channel", zap.Error(err))
+	}
+	c.out.print(&commandResult{Command: c.command.name, Channel: channelName, message: "Channel deleted successfully"})
+}
+
+func runChannelUpdate(c *cli, args []string) {
+	flags := c.flagSet()
+	updateParticipants := flags.String("participants", "", "comma-separated participants of the channel")
+	updateMls := flags.String("mls", "", "MLS setting of the channel")
+	channelName := c.parseArgs(flags, args, 1, 1)[0]
+
+	participantsSet := false
+	flags.Visit(func(f *flag.Flag) {
+		participantsSet = participantsSet || f.Name == "participants"
+	})
+	if !participantsSet {
+		c.usageError("Participants are required for channel update command, the others are removed")
+	}
+	participants := []string{}
+	for _, participant := range strings.Split(*updateParticipants, ",") {
+		if participant = strings.TrimSpace(participant); participant != "" {
+			participants = append(participants, participant)
+		}
+	}
+	var mlsEnabled *bool
+	if *updateMls != "" {
+		mls, err := strconv.ParseBool(*updateMls)
+		if err != nil {
+			c.usageError("Invalid MLS setting", zap.String("mls", *updateMls), zap.Error(err))
+		}
+		mlsEnabled = &mls
+	}
+
+	ctx, cancel := c.timeout()
+	defer cancel()
+	if err := c.connect().UpdateChannel(ctx, channelName, participants, mlsEnabled); err != nil {
+		c.logger.Fatal("Failed to update channel", zap.Error(err))
+	}
+	c.out.print(&commandResult{
+		Command:      c.command.name,
+		Channel:      channelName,
+		Participants: participants,
+		message:      "Channel updated successfully",
+	})
+}
+
+func runChannelAdopt(c *cli, args []string) {
+	channelName := c.parseArgs(nil, args, 1, 1)[0]
+	ctx, cancel := c.timeout()
+	defer cancel()
+	if err := c.connect().AdoptChannel(ctx, channelName, 0); err != nil {
+		c.logger.Fatal("Failed to adopt channel", zap.Error(err))
+	}
+	c.out.print(&commandResult{Command: c.command.name, Channel: channelName, message: "Channel adopted successfully"})
+}
+
+// runChannelFreeze runs channel freeze and channel unfreeze
+func runChannelFreeze(c *cli, args []string) {
+	freeze := c.command.name == "channel freeze"
+	flags := c.flagSet()
+	var reason *string
+	if freeze {
+		reason = flags.String("reason", "", "reason of the freeze reported to the participants, e.g. an incident ID")
+	}
+	channelName := c.parseArgs(flags, args, 1, 1)[0]
+	ctx, cancel := c.timeout()
+	defer cancel()
+	if freeze {
+		if err := c.connect().FreezeChannel(ctx, channelName, *reason); err != nil {
+			c.logger.Fatal("Failed to freeze channel", zap.Error(err))
+		}
+		c.out.print(&commandResult{Command: c.command.name, Channel: channelName, message: "Channel frozen successfully"})
+		return
+	}
+	if err := c.connect().UnfreezeChannel(ctx, channelName); err != nil {
+		c.logger.Fatal("Failed to unfreeze channel", zap.Error(err))
+	}
+	c.out.print(&commandResult{Command: c.command.name, Channel: channelName, message: "Channel unfrozen successfully"})
+}
+
+// runChannelWatch runs until interrupted
+func runChannelWatch(c *cli, args []string) {
+	var channelName string
+	if positional := c.parseArgs(nil, args, 0, 1); len(positional) > 0 {
+		channelName = positional[0]
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	c.out.header("TIME", "TYPE", "CHANNEL", "PARTICIPANT")
+	err := c.connect().WatchChannels(ctx, channelName, func(event client.Event) error {
+		c.out.print(&channelEvent{
+			Type:        string(event.Type),
+			Channel:     event.Channel,
+			Participant: event.Participant,
+			Time:        event.Time,
+		})
+		return nil
+	})
+	if err != nil {
+		c.logger.Fatal("Failed to watch channels", zap.Error(err))
+	}
+}
+
+func runParticipantList(c *cli, args []string) {
+	channelName := c.parseArgs(nil, args, 1, 1)[0]
+	ctx, cancel := c.timeout()
+	defer cancel()
+	list, err := c.connect().ListParticipantsDetailed(ctx, channelName)
+	if err != nil {
+		c.logger.Fatal("Failed to list participants", zap.Error(err))
+	}
+	c.out.print(newParticipantList(channelName, list))
+}
+
+func runParticipantAdd(c *cli, args []string) {
+	positional := c.parseArgs(nil, args, 2, math.MaxInt)
+	ctx, cancel := c.timeout()
+	defer cancel()
+	if len(positional) > 2 {
+		results, err := c.connect().AddParticipants(ctx, positional[0], positional[1:])
+		if err != nil {
+			c.logger.Fatal("Failed to add participants", zap.Error(err))
+		}
+		c.printParticipantResults(positional[0], "Participant added successfully", results)
+		return
+	}
+	pending, err := c.connect().RequestParticipant(ctx, positional[0], positional[1])
+	if err != nil {
+		c.logger.Fatal("Failed to add participant", zap.Error(err))
+	}
+	message := "Participant added successfully"
+	if pending {
+		message = "Participant awaiting approval"
+	}
+	c.out.print(&commandResult{
+		Command:         c.command.name,
+		Channel:         positional[0],
+		Participant:     positional[1],
+		PendingApproval: pending,
+		message:         message,
+	})
+}
+
+// printParticipantResults prints the results of a bulk participant command,
+// and exits with exitFailure if a participant failed
+func (c *cli) printParticipantResults(channelName, message string, results []client.ParticipantResult) {
+	r := &participantResults{Command: c.command.name, Channel: channelName, message: message}
+	failed := false
+	for _, result := range results {
+		entry := participantResultEntry{Participant: result.Participant, Status: participantOK}
+		switch {
+		case result.Err != nil:
+			entry.Status, entry.Error = participantFailed, result.Err.Error()
+			failed = true
+		case result.PendingApproval:
+			entry.Status = string(client.InviteAwaitingApproval)
+		}
+		r.Results = append(r.Results, entry)
+	}
+	c.out.print(r)
+	if failed {
+		os.Exit(exitFailure)
+	}
+}
+
+// runParticipantApproval runs participant approve and participant reject
+func runParticipantApproval(c *cli, args []string) {
+	approve := c.command.name == "participant approve"
+	flags := c.flagSet()
+	reason := flags.String("reason", "", "reason of the decision logged by the channel manager, e.g. a ticket ID")
+	positional := c.parseArgs(flags, args, 2, 2)
+	ctx, cancel := c.timeout()
+	defer cancel()
+	if err := c.connect().ResolveApproval(ctx, positional[0], positional[1], approve, *reason); err != nil {
+		c.logger.Fatal("Failed to resolve approval", zap.Error(err))
+	}
+	message := "Participant approved and added"
+	if !approve {
+		message = "Participant rejected"
+	}
+	c.out.print(&commandResult{
+		Command:     c.command.name,
+		Channel:     positional[0],
+		Participant: positional[1],
+		message:     message,
+	})
+}
+
+func runParticipantRemove(c *cli, args []string) {
+	positional := c.parseArgs(nil, args, 2, math.MaxInt)
+	ctx, cancel := c.timeout()
+	defer cancel()
+	if len(positional) > 2 {
+		results, err := c.connect().RemoveParticipants(ctx, positional[0], positional[1:])
+		if err != nil {
+			c.logger.Fatal("Failed to remove participants", zap.Error(err))
+		}
+		c.printParticipantResults(positional[0], "Participant deleted successfully", results)
+		return
+	}
+	if err := c.connect().DeleteParticipant(ctx, positional[0], positional[1]); err != nil {
+		c.logger.Fatal("Failed to delete participant", zap.Error(err))
+	}
+	c.out.print(&commandResult{
+		Command:     c.command.name,
+		Channel:     positional[0],
+		Participant: positional[1],
+		message:     "Participant deleted successfully",
+	})
+}
+
+func runParticipantDrain(c *cli, args []string) {
+	flags := c.flagSet()
+	// -participant is the participant of the legacy drain command
+	participant := flags.String("participant", "", "participant to remove from all channels")
+	gracePeriod := flags.Duration("grace-period", 0, "time left to the participant to flush its data")
+	positional := c.parseArgs(flags, args, 0, 1)
+	if len(positional) > 0 {
+		*participant = positional[0]
+	}
+	if *participant == "" {
+		c.usageError("Participant name is required for participant drain command")
+	}
+
+	// leave the channel manager the time to wait for the grace period
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout+*gracePeriod)
+	defer cancel()
+	drained, err := c.connect().DrainParticipant(ctx, *participant, *gracePeriod)
+	if err != nil {
+		c.logger.Fatal("Failed to drain participant", zap.Strings("drained", drained), zap.Error(err))
+	}
+	c.out.print(&drainResult{Participant: *participant, Channels: nonNil(drained)})
+}
+
+// runRoutesAudit runs routes audit, and routes cleanup removing the routes
+func runRoutesAudit(c *cli, args []string) {
+	c.parseArgs(nil, args, 0, 0)
+	ctx, cancel := c.timeout()
+	defer cancel()
+	orphaned, removed, err := c.connect().AuditRoutes(ctx, c.command.name == "routes cleanup")
+	if err != nil {
+		c.logger.Fatal("Failed to audit routes", zap.Error(err))
+	}
+	c.out.print(&routesAudit{Orphaned: nonNil(orphaned), Removed: nonNil(removed)})
+}
+
+// runPlan runs diff, printing the plan, and apply, applying it
+func runPlan(c *cli, args []string) {
+	flags := c.flagSet()
+	planFile := flags.String("f", "", "YAML file listing the desired channels")
+	c.parseArgs(flags, args, 0, 0)
+	if *planFile == "" {
+		c.usageError("Desired channels file is required for " + c.command.name + " command")
+	}
+	desired, err := client.LoadChannelSpecs(*planFile)
+	if err != nil {
+		c.logger.Fatal("Failed to load desired channels", zap.Error(err))
+	}
+	ctx, cancel := c.timeout()
+	defer cancel()
+	actions, err := c.connect().Plan(ctx, desired)
+	if err != nil {
+		c.logger.Fatal("Failed to compute plan", zap.Error(err))
+	}
+	// the plan is printed for review, nothing is applied
+	if c.command.name == "diff" {
+		c.out.print(newPlan(actions))
+		return
+	}
+
+	// each command of the plan has its own timeout
+	applyCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	applied, err := c.connect().Apply(applyCtx, actions)
+	c.out.print(newPlan(applied))
+	if err != nil {
+		c.logger.Fatal("Failed to apply plan",
+			zap.Int("applied", len(applied)),
+			zap.Int("actions", len(actions)),
+			zap.Error(err))
+	}
+}
+
+func runContextList(c *cli, args []string) {
+	c.parseArgs(nil, args, 0, 0)
+	c.out.print(newContextList(c.loadConfig()))
+}
+
+func runContextUse(c *cli, args []string) {
+	name := c.parseArgs(nil, args, 1, 1)[0]
+	cfg := c.loadConfig()
+	if _, err := cfg.context(name); err != nil {
+		c.usageError("Invalid context", zap.Error(err))
+	}
+	cfg.CurrentContext = name
+	c.saveConfig(cfg)
+	c.out.print(newContextList(cfg))
+}
+
+// runContextSet adds a context, or updates the settings set on the command
+// line of an existing context
+func runContextSet(c *cli, args []string) {
+	flags := c.flagSet()
+	settings := &connectionFlags{}
+	flags.StringVar(&settings.server, "server", defaultServerAddress, "gRPC server address")
+	flags.BoolVar(&settings.tls, "tls", false, "connect over TLS")
+	flags.StringVar(&settings.caFile, "ca-file", "", "CA certificates verifying the server")
+	flags.StringVar(&settings.certFile, "cert-file", "", "client certificate for mTLS")
+	flags.StringVar(&settings.keyFile, "key-file", "", "client private key for mTLS")
+	flags.StringVar(&settings.token, "token", "", "authentication token")
+	name := c.parseArgs(flags, args, 1, 1)[0]
+	settings.set = visited(flags)
+
+	cfg := c.loadConfig()
+	updated := cliContext{Name: name, Server: defaultServerAddress}
+	if existing, err := cfg.context(name); err == nil {
+		updated = *existing
+	}
+	settings.apply(&updated)
+	cfg.setContext(updated)
+	// the first context is the current one
+	if cfg.CurrentContext == "" {
+		cfg.CurrentContext = name
+	}
+	c.saveConfig(cfg)
+	c.out.print(newContextList(cfg))
+}
+
+func runContextDelete(c *cli, args []string) {
+	name := c.parseArgs(nil, args, 1, 1)[0]
+	cfg := c.loadConfig()
+	if err := cfg.deleteContext(name); err != nil {
+		c.usageError("Invalid context", zap.Error(err))
+	}
+	c.saveConfig(cfg)
+	c.out.print(newContextList(cfg))
+}
+
+// visited returns the names of the flags set on the command line
+func visited(flags *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
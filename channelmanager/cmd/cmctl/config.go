@@ -0,0 +1,201 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/agntcy/slim-otel/channelmanager/client"
+)
+
+// defaultServerAddress is the channel manager address without flag nor
+// context
+const defaultServerAddress = "localhost:46358"
+
+// cliConfig is the cmctl configuration file, ~/.cmctl/config by default,
+// holding the named contexts of the channel managers
+type cliConfig struct {
+	CurrentContext string       `yaml:"current-context,omitempty"`
+	Contexts       []cliContext `yaml:"contexts"`
+}
+
+// cliContext is a named channel manager and the credentials to connect to
+// it
+type cliContext struct {
+	Name     string `yaml:"name"`
+	Server   string `yaml:"server"`
+	TLS      bool   `yaml:"tls,omitempty"`
+	CAFile   string `yaml:"ca-file,omitempty"`
+	CertFile string `yaml:"cert-file,omitempty"`
+	KeyFile  string `yaml:"key-file,omitempty"`
+	Token    string `yaml:"token,omitempty"`
+}
+
+// defaultConfigPath returns $CMCTL_CONFIG, or ~/.cmctl/config
+func defaultConfigPath() string {
+	if path := os.Getenv("CMCTL_CONFIG"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".cmctl", "config")
+}
+
+// loadConfig reads the configuration file, an empty configuration if it
+// does not exist
+func loadConfig(path string) (*cliConfig, error) {
+	cfg := &cliConfig{}
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// save writes the configuration file, readable by the user only since it
+// may hold tokens
+func (c *cliConfig) save(path string) error {
+	if path == "" {
+		return errors.New("no configuration file, set CMCTL_CONFIG or -config")
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode the configuration: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create the directory of %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// context returns the context name
+func (c *cliConfig) context(name string) (*cliContext, error) {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			return &c.Contexts[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown context %q", name)
+}
+
+// setContext adds the context, or replaces the context with the same name
+func (c *cliConfig) setContext(context cliContext) {
+	if existing, err := c.context(context.Name); err == nil {
+		*existing = context
+		return
+	}
+	c.Contexts = append(c.Contexts, context)
+}
+
+// deleteContext removes the context name, and unsets it if current
+func (c *cliConfig) deleteContext(name string) error {
+	if _, err := c.context(name); err != nil {
+		return err
+	}
+	c.Contexts = slices.DeleteFunc(c.Contexts, func(context cliContext) bool {
+		return context.Name == name
+	})
+	if c.CurrentContext == name {
+		c.CurrentContext = ""
+	}
+	return nil
+}
+
+// connectionFlags are the connection flags of the command line, overriding
+// the context when set
+type connectionFlags struct {
+	server   string
+	tls      bool
+	caFile   string
+	certFile string
+	keyFile  string
+	token    string
+
+	// set holds the names of the flags set on the command line
+	set map[string]bool
+}
+
+// apply sets the flags set on the command line in context
+func (f *connectionFlags) apply(context *cliContext) {
+	if f.set["server"] {
+		context.Server = f.server
+	}
+	if f.set["tls"] {
+		context.TLS = f.tls
+	}
+	if f.set["ca-file"] {
+		context.CAFile = f.caFile
+	}
+	if f.set["cert-file"] {
+		context.CertFile = f.certFile
+	}
+	if f.set["key-file"] {
+		context.KeyFile = f.keyFile
+	}
+	if f.set["token"] {
+		context.Token = f.token
+	}
+}
+
+// resolveContext returns the connection settings: the flags set on the
+// command line, then the context name or the current context, then the
+// CMCTL_AUTH_TOKEN environment variable and the default address
+func resolveContext(cfg *cliConfig, name string, flags *connectionFlags) (cliContext, error) {
+	resolved := cliContext{}
+	if name == "" {
+		name = cfg.CurrentContext
+	}
+	if name != "" {
+		context, err := cfg.context(name)
+		if err != nil {
+			return cliContext{}, err
+		}
+		resolved = *context
+	}
+	flags.apply(&resolved)
+
+	if resolved.Server == "" {
+		resolved.Server = defaultServerAddress
+	}
+	if resolved.Token == "" {
+		resolved.Token = os.Getenv("CMCTL_AUTH_TOKEN")
+	}
+	return resolved, nil
+}
+
+// clientOptions returns the options of the client connecting with the
+// context
+func (c *cliContext) clientOptions() []client.Option {
+	var opts []client.Option
+	if c.TLS || c.CAFile != "" {
+		opts = append(opts, client.WithTLS(c.CAFile))
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		opts = append(opts, client.WithClientCertificate(c.CertFile, c.KeyFile))
+	}
+	if c.Token != "" {
+		opts = append(opts, client.WithAuthToken(c.Token))
+	}
+	return opts
+}
@@ -4,35 +4,52 @@
 package main
 
 import (
-	"context"
 	"flag"
 	"fmt"
-	"time"
+	"os"
+	"strings"
 
 	"go.uber.org/zap"
-
-	"github.com/agntcy/slim-otel/channelmanager/client"
 )
 
 func printUsage() {
 	fmt.Println("cmctl - Channel Manager Control Tool")
 	fmt.Println("\nUsage:")
-	fmt.Println("  cmctl <command> [channel] [participant] [options]")
+	fmt.Println("  cmctl [options] <command> [arguments] [command options]")
 	fmt.Println("\nAvailable commands:")
-	fmt.Println("  list-channels              List all channels")
-	fmt.Println("  list-participants          List participants in a channel")
-	fmt.Println("  create-channel             Create a new channel (MLS enabled)")
-	fmt.Println("  delete-channel             Delete a channel")
-	fmt.Println("  add-participant            Add participant to channel")
-	fmt.Println("  delete-participant         Remove participant from channel")
+	for _, cmd := range commands {
+		fmt.Printf("  %-20s %s\n", cmd.name, cmd.description)
+		if cmd.args != "" {
+			fmt.Printf("  %-20s   cmctl %s %s\n", "", cmd.name, cmd.args)
+		}
+	}
 	fmt.Println("\nOptions:")
+	fmt.Println("  -context <name>            Context of the configuration file (default: its current context)")
+	fmt.Println("  -config <file>             File holding the contexts (default: $CMCTL_CONFIG or ~/.cmctl/config)")
 	fmt.Println("  -server <address>          gRPC server address (default: localhost:46358)")
+	fmt.Println("  -tls                       Connect over TLS")
+	fmt.Println("  -ca-file <file>            CA certificates verifying the server (default: system roots, implies -tls)")
+	fmt.Println("  -cert-file <file>          Client certificate for mTLS (implies -tls)")
+	fmt.Println("  -key-file <file>           Client private key for mTLS")
+	fmt.Println("  -token <token>             Authentication token (default: $CMCTL_AUTH_TOKEN)")
+	fmt.Println("  -output <json|yaml|table>  Print the results on stdout in this format (default: log lines)")
+	fmt.Println("\nThe connection options, from -server to -token, override the settings of the context.")
+	fmt.Println("context set takes them after the context name to store them in the context.")
 	fmt.Println("\nExamples:")
-	fmt.Println("  cmctl list-channels")
-	fmt.Println("  cmctl create-channel agntcy/ns/channel")
-	fmt.Println("  cmctl list-participants agntcy/ns/channel")
-	fmt.Println("  cmctl add-participant agntcy/ns/channel agntcy/ns/participant")
-	fmt.Println("  cmctl delete-channel agntcy/ns/channel")
+	fmt.Println("  cmctl context set prod -server cm.example.com:46358 -tls -token a-long-random-token")
+	fmt.Println("  cmctl context use prod")
+	fmt.Println("  cmctl channel list")
+	fmt.Println("  cmctl -output json channel list")
+	fmt.Println("  cmctl -context staging channel create agntcy/ns/channel")
+	fmt.Println("  cmctl channel get agntcy/ns/channel")
+	fmt.Println("  cmctl channel update agntcy/ns/channel -participants agntcy/ns/p1,agntcy/ns/p2")
+	fmt.Println("  cmctl channel watch agntcy/ns/channel")
+	fmt.Println("  cmctl participant add agntcy/ns/channel agntcy/ns/collector-1 agntcy/ns/collector-2")
+	fmt.Println("  cmctl participant reject agntcy/prod/channel agntcy/ns/participant -reason CHG-1234")
+	fmt.Println("  cmctl participant drain agntcy/ns/participant -grace-period 5s")
+	fmt.Println("  cmctl routes audit")
+	fmt.Println("  cmctl diff -f desired.yaml")
+	fmt.Println("  cmctl apply -f desired.yaml")
 	fmt.Println()
 }
 
@@ -48,124 +65,73 @@ func main() {
 	flag.Usage = printUsage
 
 	// Parse command-line flags
-	serverAddr := flag.String("server", "localhost:46358", "gRPC server address")
+	contextName := flag.String("context", "", "context of the configuration file")
+	configPath := flag.String("config", defaultConfigPath(), "configuration file holding the contexts")
+	connection := &connectionFlags{}
+	flag.StringVar(&connection.server, "server", defaultServerAddress, "gRPC server address")
+	flag.BoolVar(&connection.tls, "tls", false, "connect over TLS")
+	flag.StringVar(&connection.caFile, "ca-file", "", "CA certificates verifying the server")
+	flag.StringVar(&connection.certFile, "cert-file", "", "client certificate for mTLS")
+	flag.StringVar(&connection.keyFile, "key-file", "", "client private key for mTLS")
+	flag.StringVar(&connection.token, "token", "", "authentication token")
+	output := flag.String("output", "", "output format of the results: json, yaml or table")
 	flag.Parse()
+	connection.set = visited(flag.CommandLine)
 
-	// Parse positional arguments
-	args := flag.Args()
-
-	var command, channelName, participantName string
-
-	// First positional argument is the command
-	if len(args) > 0 {
-		command = args[0]
-		args = args[1:]
-	}
-
-	// Second positional argument is the channel name
-	if len(args) > 0 {
-		channelName = args[0]
-		args = args[1:]
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		usageError(logger, "Invalid output format", zap.Error(err))
 	}
-
-	// Third positional argument is the participant name
-	if len(args) > 0 {
-		participantName = args[0]
+	// only the errors are logged, on stderr, when the results are printed
+	if format != formatLog {
+		logger = logger.WithOptions(zap.IncreaseLevel(zap.ErrorLevel))
 	}
 
 	// Check if command is provided
-	if command == "" {
+	args := flag.Args()
+	if len(args) == 0 {
 		printUsage()
 		return
 	}
 
-	// Connect to the channel manager using the client library
-	cmClient, err := client.New(*serverAddr)
-	if err != nil {
-		logger.Fatal("Failed to connect to server", zap.String("address", *serverAddr), zap.Error(err))
+	if name, ok := legacyCommands[args[0]]; ok {
+		logger.Warn("Deprecated command, use the subcommand instead",
+			zap.String("command", args[0]),
+			zap.String("subcommand", name))
+		args = append(strings.Fields(name), args[1:]...)
+	}
+	cmd, args := findCommand(args)
+	if cmd == nil {
+		printUsage()
+		usageError(logger, "Unknown command", zap.String("command", strings.Join(flag.Args(), " ")))
 	}
-	defer func() {
-		if closeErr := cmClient.Close(); closeErr != nil {
-			logger.Error("Failed to close client", zap.Error(closeErr))
-		}
-	}()
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	c := &cli{
+		command:     cmd,
+		logger:      logger,
+		out:         newPrinter(format, logger, os.Stdout),
+		configPath:  *configPath,
+		contextName: *contextName,
+		flags:       connection,
+	}
+	defer c.close()
 
 	// Execute the command
-	logger.Info("Executing command", zap.String("command", command))
-
-	switch command {
-	case "create-channel":
-		if channelName == "" {
-			logger.Fatal("Channel name is required for create-channel command")
-		}
-		err = cmClient.CreateChannel(ctx, channelName, true)
-		if err != nil {
-			logger.Fatal("Failed to create channel", zap.Error(err))
-		}
-		logger.Info("Channel created successfully", zap.String("channel", channelName))
-
-	case "delete-channel":
-		if channelName == "" {
-			logger.Fatal("Channel name is required for delete-channel command")
-		}
-		err = cmClient.DeleteChannel(ctx, channelName)
-		if err != nil {
-			logger.Fatal("Failed to delete channel", zap.Error(err))
-		}
-		logger.Info("Channel deleted successfully", zap.String("channel", channelName))
-
-	case "add-participant":
-		if channelName == "" || participantName == "" {
-			logger.Fatal("Channel name and participant name are required for add-participant command")
-		}
-		err = cmClient.AddParticipant(ctx, channelName, participantName)
-		if err != nil {
-			logger.Fatal("Failed to add participant", zap.Error(err))
-		}
-		logger.Info("Participant added successfully",
-			zap.String("channel", channelName),
-			zap.String("participant", participantName))
-
-	case "delete-participant":
-		if channelName == "" || participantName == "" {
-			logger.Fatal("Channel name and participant name are required for delete-participant command")
-		}
-		err = cmClient.DeleteParticipant(ctx, channelName, participantName)
-		if err != nil {
-			logger.Fatal("Failed to delete participant", zap.Error(err))
-		}
-		logger.Info("Participant deleted successfully",
-			zap.String("channel", channelName),
-			zap.String("participant", participantName))
-
-	case "list-channels":
-		channels, err := cmClient.ListChannels(ctx)
-		if err != nil {
-			logger.Fatal("Failed to list channels", zap.Error(err))
-		}
-		logger.Info("Channels",
-			zap.Int("count", len(channels)),
-			zap.Strings("channels", channels))
+	logger.Info("Executing command", zap.String("command", cmd.name))
+	cmd.run(c, args)
+}
 
-	case "list-participants":
-		if channelName == "" {
-			logger.Fatal("Channel name is required for list-participants command")
-		}
-		participants, err := cmClient.ListParticipants(ctx, channelName)
-		if err != nil {
-			logger.Fatal("Failed to list participants", zap.Error(err))
-		}
-		logger.Info("Participants",
-			zap.String("channel", channelName),
-			zap.Int("count", len(participants)),
-			zap.Strings("participants", participants))
+// usageError logs an invalid command line and exits with exitUsage
+func usageError(logger *zap.Logger, msg string, fields ...zap.Field) {
+	logger.Error(msg, fields...)
+	os.Exit(exitUsage)
+}
 
-	default:
-		printUsage()
-		logger.Fatal("Unknown command", zap.String("command", command))
+// nonNil returns values, or an empty slice if nil, so that the empty lists
+// are printed as such rather than null
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
 	}
+	return values
 }
@@ -0,0 +1,606 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/agntcy/slim-otel/channelmanager/client"
+)
+
+const (
+	// exitFailure is the exit code of a command that failed
+	exitFailure = 1
+	// exitUsage is the exit code of an invalid command line, as for the
+	// flag parsing errors
+	exitUsage = 2
+)
+
+// outputFormat is the format of the results printed on stdout
+type outputFormat string
+
+const (
+	// formatLog logs the results, the default
+	formatLog   outputFormat = ""
+	formatJSON  outputFormat = "json"
+	formatYAML  outputFormat = "yaml"
+	formatTable outputFormat = "table"
+)
+
+// parseOutputFormat parses the -output flag
+func parseOutputFormat(value string) (outputFormat, error) {
+	switch format := outputFormat(value); format {
+	case formatLog, formatJSON, formatYAML, formatTable:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown output format %q, expected json, yaml or table", value)
+	}
+}
+
+// result is the result of a command
+type result interface {
+	// log logs the result, without -output
+	log(logger *zap.Logger)
+	// writeTable writes the result as a table
+	writeTable(w io.Writer)
+}
+
+// printer prints the results of the commands in the output format. The
+// results printed one after another, e.g. the events of channel watch,
+// are separate JSON lines or YAML documents.
+type printer struct {
+	format outputFormat
+	logger *zap.Logger
+	out    io.Writer
+
+	json *json.Encoder
+	yaml *yaml.Encoder
+}
+
+// newPrinter creates a printer writing to out, or logging the results with
+// logger for formatLog
+func newPrinter(format outputFormat, logger *zap.Logger, out io.Writer) *printer {
+	p := &printer{format: format, logger: logger, out: out}
+	switch format {
+	case formatJSON:
+		p.json = json.NewEncoder(out)
+	case formatYAML:
+		p.yaml = yaml.NewEncoder(out)
+		p.yaml.SetIndent(2)
+	}
+	return p
+}
+
+// print prints a result
+func (p *printer) print(r result) {
+	var err error
+	switch p.format {
+	case formatJSON:
+		err = p.json.Encode(r)
+	case formatYAML:
+		err = p.yaml.Encode(r)
+	case formatTable:
+		w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+		r.writeTable(w)
+		err = w.Flush()
+	default:
+		r.log(p.logger)
+	}
+	if err != nil {
+		p.logger.Error("Failed to print the result", zap.Error(err))
+		os.Exit(exitFailure)
+	}
+}
+
+// header prints the header of the rows of the table format, for the
+// results printed one after another
+func (p *printer) header(columns ...string) {
+	if p.format != formatTable {
+		return
+	}
+	fmt.Fprintln(p.out, strings.Join(columns, "  "))
+}
+
+// commandResult is the result of a command changing a channel
+type commandResult struct {
+	Command      string   `json:"command" yaml:"command"`
+	Channel      string   `json:"channel" yaml:"channel"`
+	Participant  string   `json:"participant,omitempty" yaml:"participant,omitempty"`
+	Participants []string `json:"participants,omitempty" yaml:"participants,omitempty"`
+	// PendingApproval reports an addition to a protected channel awaiting
+	// an approval
+	PendingApproval bool `json:"pendingApproval,omitempty" yaml:"pendingApproval,omitempty"`
+	// message logged without -output
+	message string
+}
+
+func (r *commandResult) log(logger *zap.Logger) {
+	fields := []zap.Field{zap.String("channel", r.Channel)}
+	if r.Participant != "" {
+		fields = append(fields, zap.String("participant", r.Participant))
+	}
+	if r.Participants != nil {
+		fields = append(fields, zap.Strings("participants", r.Participants))
+	}
+	logger.Info(r.message, fields...)
+}
+
+func (r *commandResult) writeTable(w io.Writer) {
+	fmt.Fprintln(w, "COMMAND\tCHANNEL\tPARTICIPANT")
+	participant := r.Participant
+	if r.Participants != nil {
+		participant = strings.Join(r.Participants, ",")
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\n", r.Command, r.Channel, participant)
+}
+
+// channelList is the result of channel list
+type channelList struct {
+	Channels []string `json:"channels" yaml:"channels"`
+}
+
+func (r *channelList) log(logger *zap.Logger) {
+	logger.Info("Channels",
+		zap.Int("count", len(r.Channels)),
+		zap.Strings("channels", r.Channels))
+}
+
+func (r *channelList) writeTable(w io.Writer) {
+	fmt.Fprintln(w, "CHANNEL")
+	for _, channel := range r.Channels {
+		fmt.Fprintln(w, channel)
+	}
+}
+
+// participantList is the result of participant list
+type participantList struct {
+	Channel      string   `json:"channel" yaml:"channel"`
+	Participants []string `json:"participants" yaml:"participants"`
+	// AwaitingApproval are the participants whose addition to a protected
+	// channel awaits an approval
+	AwaitingApproval []string `json:"awaitingApproval,omitempty" yaml:"awaitingApproval,omitempty"`
+	// Invites are the invitations sent by the channel manager to the channel
+	Invites []participantInvite `json:"invites,omitempty" yaml:"invites,omitempty"`
+}
+
+// participantInvite is an invitation of participantList
+type participantInvite struct {
+	Participant string     `json:"participant" yaml:"participant"`
+	Status      string     `json:"status" yaml:"status"`
+	Attempts    uint32     `json:"attempts" yaml:"attempts"`
+	Error       string     `json:"error,omitempty" yaml:"error,omitempty"`
+	NextRetry   *time.Time `json:"nextRetry,omitempty" yaml:"next-retry,omitempty"`
+}
+
+// newParticipantList converts the participants returned by the client
+func newParticipantList(channel string, list *client.ParticipantList) *participantList {
+	r := &participantList{
+		Channel:          channel,
+		Participants:     nonNil(list.Participants),
+		AwaitingApproval: list.AwaitingApproval,
+	}
+	for _, invite := range list.Invites {
+		entry := participantInvite{
+			Participant: invite.Participant,
+			Status:      string(invite.Status),
+			Attempts:    invite.Attempts,
+			Error:       invite.Err,
+		}
+		if !invite.NextRetry.IsZero() {
+			entry.NextRetry = &invite.NextRetry
+		}
+		r.Invites = append(r.Invites, entry)
+	}
+	return r
+}
+
+// unjoinedInvites returns the invitations in progress or failed
+func (r *participantList) unjoinedInvites() []participantInvite {
+	invites := make([]participantInvite, 0)
+	for _, invite := range r.Invites {
+		if invite.Status != string(client.InviteJoined) {
+			invites = append(invites, invite)
+		}
+	}
+	return invites
+}
+
+func (r *participantList) log(logger *zap.Logger) {
+	fields := []zap.Field{
+		zap.String("channel", r.Channel),
+		zap.Int("count", len(r.Participants)),
+		zap.Strings("participants", r.Participants),
+	}
+	if len(r.AwaitingApproval) > 0 {
+		fields = append(fields, zap.Strings("awaitingApproval", r.AwaitingApproval))
+	}
+	logger.Info("Participants", fields...)
+	for _, invite := range r.unjoinedInvites() {
+		logger.Info("Invitation",
+			zap.String("participant", invite.Participant),
+			zap.String("status", invite.Status),
+			zap.Uint32("attempts", invite.Attempts),
+			zap.String("error", invite.Error),
+			zap.Time("next_retry", ptrValue(invite.NextRetry)))
+	}
+}
+
+// writeTable adds STATUS, ATTEMPTS, NEXT RETRY and ERROR columns when
+// participants await an approval or invitations are in progress or failed
+func (r *participantList) writeTable(w io.Writer) {
+	invites := r.unjoinedInvites()
+	if len(r.AwaitingApproval) == 0 && len(invites) == 0 {
+		fmt.Fprintln(w, "PARTICIPANT")
+		for _, participant := range r.Participants {
+			fmt.Fprintln(w, participant)
+		}
+		return
+	}
+	fmt.Fprintln(w, "PARTICIPANT\tSTATUS\tATTEMPTS\tNEXT RETRY\tERROR")
+	for _, participant := range r.Participants {
+		fmt.Fprintf(w, "%s\t%s\t-\t-\t-\n", participant, "member")
+	}
+	for _, participant := range r.AwaitingApproval {
+		fmt.Fprintf(w, "%s\t%s\t-\t-\t-\n", participant, client.InviteAwaitingApproval)
+	}
+	for _, invite := range invites {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", invite.Participant, invite.Status, invite.Attempts,
+			formatTime(invite.NextRetry), formatValue(invite.Error))
+	}
+}
+
+// participantResults is the result of participant add and participant
+// remove with several participants
+type participantResults struct {
+	Command string                   `json:"command" yaml:"command"`
+	Channel string                   `json:"channel" yaml:"channel"`
+	Results []participantResultEntry `json:"results" yaml:"results"`
+	// message logged without -output for the participants that succeeded
+	message string
+}
+
+// Statuses of participantResultEntry, besides awaiting-approval
+const (
+	participantOK     = "ok"
+	participantFailed = "failed"
+)
+
+// participantResultEntry is the result of a participant
+type participantResultEntry struct {
+	Participant string `json:"participant" yaml:"participant"`
+	Status      string `json:"status" yaml:"status"`
+	Error       string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func (r *participantResults) log(logger *zap.Logger) {
+	for _, result := range r.Results {
+		fields := []zap.Field{
+			zap.String("channel", r.Channel),
+			zap.String("participant", result.Participant),
+			zap.String("status", result.Status),
+		}
+		switch result.Status {
+		case participantFailed:
+			logger.Error("Participant failed", append(fields, zap.String("error", result.Error))...)
+		case string(client.InviteAwaitingApproval):
+			logger.Info("Participant awaiting approval", fields...)
+		default:
+			logger.Info(r.message, fields...)
+		}
+	}
+}
+
+func (r *participantResults) writeTable(w io.Writer) {
+	fmt.Fprintln(w, "COMMAND\tCHANNEL\tPARTICIPANT\tSTATUS\tERROR")
+	for _, result := range r.Results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Command, r.Channel, result.Participant, result.Status, result.Error)
+	}
+}
+
+// participantStatus is a participant of the channel details
+type participantStatus struct {
+	Name          string `json:"name" yaml:"name"`
+	Status        string `json:"status" yaml:"status"`
+	Route         string `json:"route" yaml:"route"`
+	RouteAttempts uint32 `json:"routeAttempts,omitempty" yaml:"route-attempts,omitempty"`
+	RouteError    string `json:"routeError,omitempty" yaml:"route-error,omitempty"`
+}
+
+// channelDetails is the result of channel get
+type channelDetails struct {
+	Channel       string              `json:"channel" yaml:"channel"`
+	MlsEnabled    bool                `json:"mlsEnabled" yaml:"mls-enabled"`
+	SessionID     uint32              `json:"sessionId" yaml:"session-id"`
+	Created       *time.Time          `json:"created,omitempty" yaml:"created,omitempty"`
+	LastActivity  *time.Time          `json:"lastActivity,omitempty" yaml:"last-activity,omitempty"`
+	MaxRetries    uint32              `json:"maxRetries" yaml:"max-retries"`
+	RetryInterval string              `json:"retryInterval" yaml:"retry-interval"`
+	Frozen        bool                `json:"frozen" yaml:"frozen"`
+	FreezeReason  string              `json:"freezeReason,omitempty" yaml:"freeze-reason,omitempty"`
+	FrozenSince   *time.Time          `json:"frozenSince,omitempty" yaml:"frozen-since,omitempty"`
+	Expires       *time.Time          `json:"expires,omitempty" yaml:"expires,omitempty"`
+	Participants  []participantStatus `json:"participants" yaml:"participants"`
+
+	details *client.ChannelDetails
+}
+
+// newChannelDetails converts the details returned by the client
+func newChannelDetails(details *client.ChannelDetails) *channelDetails {
+	r := &channelDetails{
+		Channel:       details.Name,
+		MlsEnabled:    details.MlsEnabled,
+		SessionID:     details.SessionID,
+		MaxRetries:    details.MaxRetries,
+		RetryInterval: details.RetryInterval.String(),
+		Frozen:        details.Frozen,
+		FreezeReason:  details.FreezeReason,
+		Participants:  make([]participantStatus, 0, len(details.Participants)),
+		details:       details,
+	}
+	// the zero times are unknown
+	if !details.Created.IsZero() {
+		r.Created = &details.Created
+	}
+	if !details.LastActivity.IsZero() {
+		r.LastActivity = &details.LastActivity
+	}
+	if !details.FrozenSince.IsZero() {
+		r.FrozenSince = &details.FrozenSince
+	}
+	if !details.Expires.IsZero() {
+		r.Expires = &details.Expires
+	}
+	for _, participant := range details.Participants {
+		r.Participants = append(r.Participants, participantStatus{
+			Name:          participant.Name,
+			Status:        string(participant.Status),
+			Route:         string(participant.Route),
+			RouteAttempts: participant.RouteAttempts,
+			RouteError:    participant.RouteError,
+		})
+	}
+	return r
+}
+
+func (r *channelDetails) log(logger *zap.Logger) {
+	logger.Info("Channel",
+		zap.String("channel", r.details.Name),
+		zap.Bool("mls_enabled", r.details.MlsEnabled),
+		zap.Uint32("session_id", r.details.SessionID),
+		zap.Time("created", r.details.Created),
+		zap.Time("last_activity", r.details.LastActivity),
+		zap.Uint32("max_retries", r.details.MaxRetries),
+		zap.Duration("retry_interval", r.details.RetryInterval),
+		zap.Bool("frozen", r.details.Frozen),
+		zap.String("freeze_reason", r.details.FreezeReason),
+		zap.Time("expires", r.details.Expires))
+	for _, participant := range r.details.Participants {
+		logger.Info("Participant",
+			zap.String("participant", participant.Name),
+			zap.String("status", string(participant.Status)),
+			zap.String("route", string(participant.Route)),
+			zap.Uint32("route_attempts", participant.RouteAttempts),
+			zap.String("route_error", participant.RouteError))
+	}
+}
+
+func (r *channelDetails) writeTable(w io.Writer) {
+	fmt.Fprintf(w, "CHANNEL\t%s\n", r.Channel)
+	fmt.Fprintf(w, "MLS ENABLED\t%t\n", r.MlsEnabled)
+	fmt.Fprintf(w, "SESSION ID\t%d\n", r.SessionID)
+	fmt.Fprintf(w, "CREATED\t%s\n", formatTime(r.Created))
+	fmt.Fprintf(w, "LAST ACTIVITY\t%s\n", formatTime(r.LastActivity))
+	fmt.Fprintf(w, "MAX RETRIES\t%d\n", r.MaxRetries)
+	fmt.Fprintf(w, "RETRY INTERVAL\t%s\n", r.RetryInterval)
+	fmt.Fprintf(w, "EXPIRES\t%s\n", formatTime(r.Expires))
+	fmt.Fprintf(w, "FROZEN\t%t\n", r.Frozen)
+	if r.Frozen {
+		fmt.Fprintf(w, "FROZEN SINCE\t%s\n", formatTime(r.FrozenSince))
+		fmt.Fprintf(w, "FREEZE REASON\t%s\n", r.FreezeReason)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "PARTICIPANT\tSTATUS\tROUTE\tROUTE ERROR")
+	for _, participant := range r.Participants {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", participant.Name, participant.Status, participant.Route,
+			formatValue(participant.RouteError))
+	}
+}
+
+// formatTime formats a time of the table output, "-" if unknown
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// ptrValue returns the time pointed to by t, the zero time if nil
+func ptrValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// formatValue formats a value of the table output, "-" if empty
+func formatValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// routesAudit is the result of routes audit and routes cleanup
+type routesAudit struct {
+	Orphaned []string `json:"orphaned" yaml:"orphaned"`
+	Removed  []string `json:"removed" yaml:"removed"`
+}
+
+func (r *routesAudit) log(logger *zap.Logger) {
+	logger.Info("Orphaned routes",
+		zap.Int("count", len(r.Orphaned)),
+		zap.Strings("orphaned", r.Orphaned),
+		zap.Strings("removed", r.Removed))
+}
+
+func (r *routesAudit) writeTable(w io.Writer) {
+	fmt.Fprintln(w, "ROUTE\tREMOVED")
+	for _, route := range r.Orphaned {
+		removed := false
+		for _, name := range r.Removed {
+			removed = removed || name == route
+		}
+		fmt.Fprintf(w, "%s\t%t\n", route, removed)
+	}
+}
+
+// drainResult is the result of participant drain
+type drainResult struct {
+	Participant string   `json:"participant" yaml:"participant"`
+	Channels    []string `json:"channels" yaml:"channels"`
+}
+
+func (r *drainResult) log(logger *zap.Logger) {
+	logger.Info("Participant drained successfully",
+		zap.String("participant", r.Participant),
+		zap.Strings("channels", r.Channels))
+}
+
+func (r *drainResult) writeTable(w io.Writer) {
+	fmt.Fprintln(w, "PARTICIPANT\tCHANNEL")
+	for _, channel := range r.Channels {
+		fmt.Fprintf(w, "%s\t%s\n", r.Participant, channel)
+	}
+}
+
+// planAction is an action of the diff result
+type planAction struct {
+	Type        string `json:"type" yaml:"type"`
+	Channel     string `json:"channel" yaml:"channel"`
+	Participant string `json:"participant,omitempty" yaml:"participant,omitempty"`
+	MlsEnabled  bool   `json:"mlsEnabled,omitempty" yaml:"mls-enabled,omitempty"`
+}
+
+// plan is the result of diff and apply
+type plan struct {
+	Actions []planAction `json:"actions" yaml:"actions"`
+
+	actions []client.Action
+}
+
+// newPlan converts the actions returned by the client
+func newPlan(actions []client.Action) *plan {
+	r := &plan{Actions: make([]planAction, 0, len(actions)), actions: actions}
+	for _, action := range actions {
+		r.Actions = append(r.Actions, planAction{
+			Type:        string(action.Type),
+			Channel:     action.Channel,
+			Participant: action.Participant,
+			MlsEnabled:  action.MlsEnabled,
+		})
+	}
+	return r
+}
+
+// log prints the plan for review, as a diff rather than log lines
+func (r *plan) log(*zap.Logger) {
+	if len(r.actions) == 0 {
+		fmt.Println("No changes, the channels match the desired state")
+	}
+	for _, action := range r.actions {
+		fmt.Println(action)
+	}
+}
+
+func (r *plan) writeTable(w io.Writer) {
+	fmt.Fprintln(w, "ACTION\tCHANNEL\tPARTICIPANT")
+	for _, action := range r.Actions {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", action.Type, action.Channel, action.Participant)
+	}
+}
+
+// channelEvent is an event printed by channel watch
+type channelEvent struct {
+	Type        string    `json:"type" yaml:"type"`
+	Channel     string    `json:"channel" yaml:"channel"`
+	Participant string    `json:"participant,omitempty" yaml:"participant,omitempty"`
+	Time        time.Time `json:"time" yaml:"time"`
+}
+
+func (r *channelEvent) log(logger *zap.Logger) {
+	logger.Info("Channel event",
+		zap.String("type", r.Type),
+		zap.String("channel", r.Channel),
+		zap.String("participant", r.Participant),
+		zap.Time("time", r.Time))
+}
+
+// writeTable writes the event as a row without header, the events being
+// printed as they are received
+func (r *channelEvent) writeTable(w io.Writer) {
+	participant := r.Participant
+	if participant == "" {
+		participant = "-"
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Time.Format(time.RFC3339), r.Type, r.Channel, participant)
+}
+
+// contextSummary is a context of the context results, without its token
+type contextSummary struct {
+	Name    string `json:"name" yaml:"name"`
+	Server  string `json:"server" yaml:"server"`
+	TLS     bool   `json:"tls" yaml:"tls"`
+	Current bool   `json:"current" yaml:"current"`
+}
+
+// contextList is the result of the context commands
+type contextList struct {
+	CurrentContext string           `json:"currentContext" yaml:"current-context"`
+	Contexts       []contextSummary `json:"contexts" yaml:"contexts"`
+}
+
+// newContextList lists the contexts of the configuration
+func newContextList(cfg *cliConfig) *contextList {
+	r := &contextList{CurrentContext: cfg.CurrentContext, Contexts: make([]contextSummary, 0, len(cfg.Contexts))}
+	for _, context := range cfg.Contexts {
+		r.Contexts = append(r.Contexts, contextSummary{
+			Name:    context.Name,
+			Server:  context.Server,
+			TLS:     context.TLS || context.CAFile != "" || context.CertFile != "",
+			Current: context.Name == cfg.CurrentContext,
+		})
+	}
+	return r
+}
+
+func (r *contextList) log(logger *zap.Logger) {
+	logger.Info("Contexts", zap.String("current", r.CurrentContext), zap.Int("count", len(r.Contexts)))
+	for _, context := range r.Contexts {
+		logger.Info("Context",
+			zap.String("name", context.Name),
+			zap.String("server", context.Server),
+			zap.Bool("tls", context.TLS),
+			zap.Bool("current", context.Current))
+	}
+}
+
+func (r *contextList) writeTable(w io.Writer) {
+	fmt.Fprintln(w, "CURRENT\tNAME\tSERVER\tTLS")
+	for _, context := range r.Contexts {
+		current := ""
+		if context.Current {
+			current = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", current, context.Name, context.Server, context.TLS)
+	}
+}
@@ -0,0 +1,334 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// defaultWebhookTimeout is the timeout of the calls to the approval webhook
+// when the configuration does not set it
+const defaultWebhookTimeout = 10 * time.Second
+
+// ApprovalConfig defines the channels whose new participants must be
+// approved by an external workflow, e.g. for the environments with
+// change-control requirements
+type ApprovalConfig struct {
+	// Channels whose AddParticipant requests are parked until approved, as
+	// patterns of their organization/namespace/channel names matched with
+	// path.Match, e.g. agntcy/prod/*
+	ProtectedChannels []string `yaml:"protected-channels"`
+
+	// URL the approval requests are posted to as JSON. The requests are only
+	// reported by ListParticipants, GetChannel and WatchChannels if empty
+	// (optional)
+	WebhookURL string `yaml:"webhook-url"`
+
+	// Bearer token sent to the webhook (optional)
+	WebhookToken string `yaml:"webhook-token"`
+
+	// Timeout of the calls to the webhook, 10s if 0 (optional)
+	WebhookTimeout time.Duration `yaml:"webhook-timeout"`
+}
+
+// Validate checks if the approval configuration is valid
+func (cfg *ApprovalConfig) Validate() error {
+	if len(cfg.ProtectedChannels) == 0 {
+		return errors.New("at least one protected channel must be specified")
+	}
+	for _, pattern := range cfg.ProtectedChannels {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid protected channel pattern %q: %w", pattern, err)
+		}
+	}
+	if cfg.WebhookURL != "" {
+		u, err := url.Parse(cfg.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook URL %q", cfg.WebhookURL)
+		}
+	}
+	if cfg.WebhookTimeout < 0 {
+		return errors.New("webhook timeout cannot be negative")
+	}
+	return nil
+}
+
+// ApprovalRequest is the addition of a participant to a protected channel,
+// parked until approved
+type ApprovalRequest struct {
+	// Channel and Participant are in organization/namespace/app format
+	Channel     string    `json:"channel"`
+	Participant string    `json:"participant"`
+	RequestedAt time.Time `json:"requestedAt"`
+}
+
+// Approver submits the AddParticipant requests of the protected channels to
+// an external approval workflow. The workflow resolves each request with the
+// ResolveApproval command.
+type Approver interface {
+	// RequestApproval submits a request, which is dropped if it fails
+	RequestApproval(ctx context.Context, req ApprovalRequest) error
+}
+
+// WebhookApprover posts the approval requests as JSON to an HTTP endpoint
+type WebhookApprover struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+// NewWebhookApprover creates a WebhookApprover calling the webhook of the
+// approval configuration
+func NewWebhookApprover(cfg *ApprovalConfig) *WebhookApprover {
+	timeout := cfg.WebhookTimeout
+	if timeout == 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &WebhookApprover{
+		url:        cfg.WebhookURL,
+		token:      cfg.WebhookToken,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// RequestApproval posts req to the webhook, which must answer with a 2xx
+// status once it recorded the request
+func (a *WebhookApprover) RequestApproval(ctx context.Context, req ApprovalRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode the approval request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if a.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+a.token)
+	}
+
+	resp, err := a.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("approval webhook: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// approvals holds the names of the participants whose addition is parked,
+// by channel then by participant ID. They are not persisted, the requests
+// are made again after a restart.
+type approvals struct {
+	mutex    sync.Mutex
+	channels map[string]map[string]string
+}
+
+// newApprovals creates an empty approvals
+func newApprovals() *approvals {
+	return &approvals{channels: make(map[string]map[string]string)}
+}
+
+// park records the addition of a participant identified by id and
+// displayed as name, replacing a request already parked for it
+func (a *approvals) park(channel, id, name string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.channels[channel] == nil {
+		a.channels[channel] = make(map[string]string)
+	}
+	a.channels[channel][id] = name
+}
+
+// take removes the parked addition of a participant, and reports whether
+// there was one
+func (a *approvals) take(channel, id string) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if _, ok := a.channels[channel][id]; !ok {
+		return false
+	}
+	delete(a.channels[channel], id)
+	if len(a.channels[channel]) == 0 {
+		delete(a.channels, channel)
+	}
+	return true
+}
+
+// names returns the names of the participants awaiting the approval of
+// their addition to a channel, sorted by ID
+func (a *approvals) names(channel string) []string {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	parked := a.channels[channel]
+	names := make([]string, 0, len(parked))
+	for _, id := range slices.Sorted(maps.Keys(parked)) {
+		names = append(names, parked[id])
+	}
+	return names
+}
+
+// drop forgets the parked additions of a deleted channel
+func (a *approvals) drop(channel string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	delete(a.channels, channel)
+}
+
+// WithApproval parks the AddParticipant requests of the channels matching
+// the protected patterns until they are approved with the ResolveApproval
+// command. The requests are submitted to approver, unless nil.
+func WithApproval(approver Approver, protectedChannels []string) ServerOption {
+	return func(s *Server) {
+		s.approver = approver
+		s.protectedChannels = protectedChannels
+	}
+}
+
+// requiresApproval reports whether the new participants of channel must be
+// approved
+func (s *Server) requiresApproval(channel *slim.Name) bool {
+	name := slimcommon.JoinID(channel)
+	for _, pattern := range s.protectedChannels {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNoNewParticipant returns an error if participants lists a participant
+// that is not in the protected channel yet. The new participants must be
+// added with AddParticipant, to be approved.
+func checkNoNewParticipant(session slimcommon.Session, channel *slim.Name, participants []*slim.Name) error {
+	current, err := session.ParticipantsList()
+	if err != nil {
+		return fmt.Errorf("failed to list participants for channel %s: %v", channel.String(), err)
+	}
+	members := make(map[string]struct{}, len(current))
+	for _, name := range current {
+		members[slimcommon.JoinID(name)] = struct{}{}
+	}
+	for _, participant := range participants {
+		if _, ok := members[slimcommon.JoinID(participant)]; !ok {
+			return fmt.Errorf("participant %s must be approved to join the protected channel %s, add it with AddParticipant",
+				slimcommon.JoinID(participant), channel.String())
+		}
+	}
+	return nil
+}
+
+// parkParticipant parks the addition of a participant to a protected channel
+// and submits it to the approver
+func (s *Server) parkParticipant(ctx context.Context, channel, participant *slim.Name) error {
+	channelStr := channel.String()
+	participantID := slimcommon.JoinID(participant)
+	request := ApprovalRequest{
+		Channel:     slimcommon.JoinID(channel),
+		Participant: participantID,
+		RequestedAt: time.Now(),
+	}
+
+	s.approvals.park(channelStr, participantID, participant.String())
+	if s.approver != nil {
+		if err := s.approver.RequestApproval(ctx, request); err != nil {
+			s.approvals.take(channelStr, participantID)
+			return fmt.Errorf("failed to request the approval of participant %s: %w", participantID, err)
+		}
+	}
+	s.events.publish(ChannelEvent_APPROVAL_REQUESTED, channelStr, participant.String())
+
+	slimcommon.LoggerFromContextOrDefault(ctx).Info("Participant awaiting approval",
+		zap.String("channel", channelStr),
+		zap.String("participant", participantID))
+	return nil
+}
+
+// handleResolveApproval invites the participant whose parked addition is
+// approved, or forgets it if rejected
+func (s *Server) handleResolveApproval(
+	ctx context.Context, msgID uint64, req *ResolveApprovalRequest,
+) (*ControlResponse, error) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	channel, err := slimcommon.SplitID(req.ChannelName)
+	if err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("invalid channel name: %s", req.ChannelName))
+	}
+	participant, err := slimcommon.SplitID(req.ParticipantName)
+	if err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("invalid participant name: %s", req.ParticipantName))
+	}
+
+	channelStr := channel.String()
+	participantID := slimcommon.JoinID(participant)
+	if !s.approvals.take(channelStr, participantID) {
+		return s.errorResponse(msgID,
+			fmt.Sprintf("no approval pending for participant %s on channel %s", participantID, channelStr))
+	}
+
+	if !req.Approved {
+		s.events.publish(ChannelEvent_APPROVAL_REJECTED, channelStr, participant.String())
+		logger.Info("Participant rejected",
+			zap.String("channel", channelStr),
+			zap.String("participant", participantID),
+			zap.String("reason", req.Reason))
+		return s.successResponse(msgID)
+	}
+
+	session, err := s.channels.GetSessionByName(ctx, channelStr)
+	if err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("failed to get channel %s: %v", channelStr, err))
+	}
+	// the quota may have been reached while the request was parked
+	if err = s.checkParticipantCapacity(session, req.ChannelName); err != nil {
+		return s.errorResponse(msgID, err.Error())
+	}
+	if err = s.invite(ctx, session, channel, participant); err != nil {
+		return s.errorResponse(msgID, err.Error())
+	}
+	s.saveState(ctx, s.state.addParticipant(slimcommon.JoinID(channel), participantID))
+
+	logger.Info("Participant approved and added",
+		zap.String("channel", channelStr),
+		zap.String("participant", participantID),
+		zap.String("reason", req.Reason))
+	return s.successResponse(msgID)
+}
+
+// pendingApprovalResponse creates the success response of a command that
+// takes effect once approved
+func (s *Server) pendingApprovalResponse(msgID uint64) (*ControlResponse, error) {
+	return &ControlResponse{
+		MgsId: msgID,
+		Payload: &ControlResponse_CommandResponse{
+			CommandResponse: &CommandResponse{
+				MsgId:           msgID,
+				Success:         true,
+				PendingApproval: true,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,214 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agntcy/slim-otel/internal/testutil"
+)
+
+const protectedChannel = "agntcy/prod/channel"
+
+func resolveApproval(channel, participant string, approved bool, reason string) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 15,
+		Payload: &ControlRequest_ResolveApprovalRequest{
+			ResolveApprovalRequest: &ResolveApprovalRequest{
+				ChannelName:     channel,
+				ParticipantName: participant,
+				Approved:        approved,
+				Reason:          reason,
+			},
+		},
+	}
+}
+
+// fakeApprover records the approval requests, and fails them with err
+type fakeApprover struct {
+	requests []ApprovalRequest
+	err      error
+}
+
+func (a *fakeApprover) RequestApproval(_ context.Context, req ApprovalRequest) error {
+	a.requests = append(a.requests, req)
+	return a.err
+}
+
+// newApprovalServer creates a Server protecting the agntcy/prod channels
+func newApprovalServer(approver Approver) (*Server, *testutil.FakeApp) {
+	s, app := newTestServer()
+	WithApproval(approver, []string{"agntcy/prod/*"})(s)
+	return s, app
+}
+
+// TestServer_Approval tests the approval of the participants of the
+// protected channels
+func TestServer_Approval(t *testing.T) {
+	listAwaiting := func(t *testing.T, s *Server) []string {
+		t.Helper()
+		resp, err := s.Command(t.Context(), listParticipants(protectedChannel))
+		require.NoError(t, err)
+		payload, ok := resp.Payload.(*ControlResponse_ListParticipantsResponse)
+		require.True(t, ok, "unexpected response payload %T", resp.Payload)
+		return payload.ListParticipantsResponse.AwaitingApproval
+	}
+
+	t.Run("approve", func(t *testing.T) {
+		approver := &fakeApprover{}
+		s, app := newApprovalServer(approver)
+		events, unsubscribe := s.events.subscribe()
+		defer unsubscribe()
+		require.True(t, command(t, s, createChannel(protectedChannel, false)).Success)
+
+		resp := command(t, s, addParticipant(protectedChannel, testParticipant))
+		assert.True(t, resp.Success)
+		assert.True(t, resp.PendingApproval)
+		require.Len(t, approver.requests, 1)
+		assert.Equal(t, protectedChannel, approver.requests[0].Channel)
+		assert.Equal(t, testParticipant, approver.requests[0].Participant)
+		assert.Empty(t, app.Routes(), "the participant is not invited")
+		assert.Equal(t, []string{testParticipant}, listAwaiting(t, s))
+
+		details, err := s.Command(t.Context(), getChannel(protectedChannel))
+		require.NoError(t, err)
+		participants := details.GetGetChannelResponse().Participant
+		require.Len(t, participants, 1)
+		assert.Equal(t, testParticipant, participants[0].ParticipantName)
+		assert.Equal(t, ParticipantStatus_AWAITING_APPROVAL, participants[0].Status)
+
+		resp = command(t, s, resolveApproval(protectedChannel, testParticipant, true, "CHG-1234"))
+		assert.True(t, resp.Success)
+		assert.False(t, resp.PendingApproval)
+		assert.Equal(t, []string{testParticipant}, app.Routes())
+		assert.Empty(t, listAwaiting(t, s))
+
+		// the approval is resolved once
+		resp = command(t, s, resolveApproval(protectedChannel, testParticipant, true, ""))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "no approval pending")
+
+		var types []ChannelEvent_Type
+		for range 3 {
+			types = append(types, (<-events).Type)
+		}
+		assert.Equal(t, []ChannelEvent_Type{
+			ChannelEvent_CHANNEL_CREATED, ChannelEvent_APPROVAL_REQUESTED, ChannelEvent_PARTICIPANT_JOINED,
+		}, types)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		s, _ := newApprovalServer(nil)
+		events, unsubscribe := s.events.subscribe()
+		defer unsubscribe()
+		require.True(t, command(t, s, createChannel(protectedChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(protectedChannel, testParticipant)).PendingApproval)
+
+		assert.True(t, command(t, s, resolveApproval(protectedChannel, testParticipant, false, "not allowed")).Success)
+		assert.Empty(t, listAwaiting(t, s))
+		assert.Equal(t, ChannelEvent_CHANNEL_CREATED, (<-events).Type)
+		assert.Equal(t, ChannelEvent_APPROVAL_REQUESTED, (<-events).Type)
+		assert.Equal(t, ChannelEvent_APPROVAL_REJECTED, (<-events).Type)
+	})
+
+	t.Run("withdraw", func(t *testing.T) {
+		s, _ := newApprovalServer(nil)
+		require.True(t, command(t, s, createChannel(protectedChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(protectedChannel, testParticipant)).PendingApproval)
+
+		assert.True(t, command(t, s, deleteParticipant(protectedChannel, testParticipant)).Success)
+		assert.Empty(t, listAwaiting(t, s))
+		assert.False(t, command(t, s, resolveApproval(protectedChannel, testParticipant, true, "")).Success)
+	})
+
+	t.Run("deleted channel", func(t *testing.T) {
+		s, _ := newApprovalServer(nil)
+		require.True(t, command(t, s, createChannel(protectedChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(protectedChannel, testParticipant)).PendingApproval)
+
+		require.True(t, command(t, s, deleteChannel(protectedChannel)).Success)
+		assert.False(t, command(t, s, resolveApproval(protectedChannel, testParticipant, true, "")).Success)
+	})
+
+	t.Run("approver fails", func(t *testing.T) {
+		s, _ := newApprovalServer(&fakeApprover{err: errors.New("unreachable")})
+		require.True(t, command(t, s, createChannel(protectedChannel, false)).Success)
+
+		resp := command(t, s, addParticipant(protectedChannel, testParticipant))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "unreachable")
+		assert.Empty(t, listAwaiting(t, s))
+	})
+
+	t.Run("unprotected channel", func(t *testing.T) {
+		approver := &fakeApprover{}
+		s, _ := newApprovalServer(approver)
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+
+		resp := command(t, s, addParticipant(testChannel, testParticipant))
+		assert.True(t, resp.Success)
+		assert.False(t, resp.PendingApproval)
+		assert.Empty(t, approver.requests)
+	})
+
+	t.Run("update cannot add participants", func(t *testing.T) {
+		s, _ := newApprovalServer(nil)
+		require.True(t, command(t, s, createChannel(protectedChannel, false)).Success)
+
+		resp := command(t, s, updateChannel(protectedChannel, []string{testParticipant}, nil))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "must be approved")
+
+		require.True(t, command(t, s, addParticipant(protectedChannel, testParticipant)).PendingApproval)
+		require.True(t, command(t, s, resolveApproval(protectedChannel, testParticipant, true, "")).Success)
+		assert.True(t, command(t, s, updateChannel(protectedChannel, []string{testParticipant}, nil)).Success)
+	})
+}
+
+func TestWebhookApprover(t *testing.T) {
+	var received ApprovalRequest
+	var authorization string
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("change freeze\n"))
+	}))
+	defer server.Close()
+
+	approver := NewWebhookApprover(&ApprovalConfig{WebhookURL: server.URL, WebhookToken: "secret"})
+	req := ApprovalRequest{Channel: protectedChannel, Participant: testParticipant, RequestedAt: time.Unix(1, 0).UTC()}
+	require.NoError(t, approver.RequestApproval(t.Context(), req))
+	assert.Equal(t, req, received)
+	assert.Equal(t, "Bearer secret", authorization)
+
+	status = http.StatusForbidden
+	err := approver.RequestApproval(t.Context(), req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403 Forbidden: change freeze")
+}
+
+func TestApprovalConfig_Validate(t *testing.T) {
+	valid := ApprovalConfig{ProtectedChannels: []string{"agntcy/prod/*"}, WebhookURL: "https://approvals.example.com"}
+	require.NoError(t, valid.Validate())
+
+	for name, cfg := range map[string]ApprovalConfig{
+		"no protected channel": {},
+		"invalid pattern":      {ProtectedChannels: []string{"agntcy/[prod"}},
+		"invalid webhook URL":  {ProtectedChannels: []string{"agntcy/prod/*"}, WebhookURL: "approvals.example.com"},
+		"negative timeout":     {ProtectedChannels: []string{"agntcy/prod/*"}, WebhookTimeout: -time.Second},
+	} {
+		assert.Error(t, cfg.Validate(), name)
+	}
+}
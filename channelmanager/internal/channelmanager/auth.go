@@ -0,0 +1,302 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authorizationHeader is the gRPC metadata key holding the bearer token
+const authorizationHeader = "authorization"
+
+// ServiceTLSConfig defines the TLS configuration of the gRPC service
+type ServiceTLSConfig struct {
+	// Server certificate and private key files in PEM format
+	CertFile string `yaml:"cert-file"`
+	KeyFile  string `yaml:"key-file"`
+
+	// CA certificates file in PEM format used to verify the client
+	// certificates. When set, the clients must present a certificate (mTLS).
+	ClientCAFile string `yaml:"client-ca-file"`
+}
+
+// Validate checks if the TLS configuration is valid
+func (cfg *ServiceTLSConfig) Validate() error {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return errors.New("cert file and key file must be specified")
+	}
+	return nil
+}
+
+// credentials loads the certificates and returns the server transport credentials
+func (cfg *ServiceTLSConfig) credentials() (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the server certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in the client CA file %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// GRPCServerOptions returns the options of the gRPC server securing the
+// service with the configured TLS and authentication token, if any
+func (cfg *ManagerConfig) GRPCServerOptions() ([]grpc.ServerOption, error) {
+	var opts []grpc.ServerOption
+
+	if cfg.ServiceTLS != nil {
+		creds, err := cfg.ServiceTLS.credentials()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	if bindings := cfg.roleBindings(); len(bindings) > 0 {
+		auth := &namespaceAuth{admin: tokenAuth(cfg.ServiceAuthToken), bindings: bindings}
+		opts = append(opts,
+			grpc.UnaryInterceptor(auth.unary),
+			grpc.StreamInterceptor(auth.stream))
+	} else if cfg.ServiceAuthToken != "" {
+		auth := tokenAuth(cfg.ServiceAuthToken)
+		opts = append(opts,
+			grpc.UnaryInterceptor(auth.unary),
+			grpc.StreamInterceptor(auth.stream))
+	}
+
+	return opts, nil
+}
+
+// roleBindings returns the roles granted to each token of the namespace
+// bindings, by namespace
+func (cfg *ManagerConfig) roleBindings() map[string]map[string]string {
+	bindings := make(map[string]map[string]string)
+	for _, namespace := range cfg.Namespaces {
+		for _, binding := range namespace.Bindings {
+			if bindings[binding.Token] == nil {
+				bindings[binding.Token] = make(map[string]string)
+			}
+			bindings[binding.Token][namespace.Name] = binding.Role
+		}
+	}
+	return bindings
+}
+
+// tokenAuth rejects the calls that do not carry the bearer token, except
+// the health checks of the probes
+type tokenAuth string
+
+// check returns an Unauthenticated status if the incoming metadata of ctx
+// does not hold the bearer token
+func (a tokenAuth) check(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get(authorizationHeader) {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(a)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid authentication token")
+}
+
+func (a tokenAuth) unary(
+	ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (any, error) {
+	if isHealthMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	if err := a.check(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a tokenAuth) stream(
+	srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	if isHealthMethod(info.FullMethod) {
+		return handler(srv, stream)
+	}
+	if err := a.check(stream.Context()); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// principal holds the roles of an authenticated client by namespace. A nil
+// principal, e.g. the admin token or no authentication, is allowed
+// everything.
+type principal struct {
+	roles map[string]string
+}
+
+// principalKey is the context key of the principal of a call
+type principalKey struct{}
+
+// principalFromContext returns the principal of the call, nil if the caller
+// is allowed everything
+func principalFromContext(ctx context.Context) *principal {
+	p, _ := ctx.Value(principalKey{}).(*principal)
+	return p
+}
+
+// allows reports whether the principal may read, or write if write is set,
+// the channels of namespace
+func (p *principal) allows(namespace string, write bool) bool {
+	if p == nil {
+		return true
+	}
+	role := p.roles[namespace]
+	return role == RoleAdmin || (!write && role == RoleViewer)
+}
+
+// namespaceAuth accepts the admin token, allowed everything, and the tokens
+// bound to the roles of namespaces, which are stored in the context of the
+// call as its principal
+type namespaceAuth struct {
+	admin tokenAuth
+	// roles by namespace, by token
+	bindings map[string]map[string]string
+}
+
+// authenticate returns ctx with the principal of the bearer token of the
+// incoming metadata, or an Unauthenticated status if the token is unknown
+func (a *namespaceAuth) authenticate(ctx context.Context) (context.Context, error) {
+	if a.admin != "" && a.admin.check(ctx) == nil {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get(authorizationHeader) {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if !ok {
+			continue
+		}
+		// every binding is compared to not leak which tokens exist
+		var roles map[string]string
+		for bound, boundRoles := range a.bindings {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(bound)) == 1 {
+				roles = boundRoles
+			}
+		}
+		if roles != nil {
+			return context.WithValue(ctx, principalKey{}, &principal{roles: roles}), nil
+		}
+	}
+	return nil, status.Error(codes.Unauthenticated, "missing or invalid authentication token")
+}
+
+func (a *namespaceAuth) unary(
+	ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (any, error) {
+	if isHealthMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	ctx, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *namespaceAuth) stream(
+	srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	if isHealthMethod(info.FullMethod) {
+		return handler(srv, stream)
+	}
+	ctx, err := a.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &principalStream{ServerStream: stream, ctx: ctx})
+}
+
+// principalStream is a server stream carrying the principal in its context
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorize returns a PermissionDenied status if the principal of the call
+// may not run the command of req. The channels listed are filtered by the
+// command itself, the commands that are not about a channel, e.g. the
+// routes audit, require the admin token.
+func authorize(ctx context.Context, req *ControlRequest) error {
+	p := principalFromContext(ctx)
+	if p == nil {
+		return nil
+	}
+
+	var channel string
+	write := true
+	switch payload := req.Payload.(type) {
+	case *ControlRequest_CreateChannelRequest:
+		channel = payload.CreateChannelRequest.ChannelName
+	case *ControlRequest_DeleteChannelRequest:
+		channel = payload.DeleteChannelRequest.ChannelName
+	case *ControlRequest_AddParticipantRequest:
+		channel = payload.AddParticipantRequest.ChannelName
+	case *ControlRequest_DeleteParticipantRequest:
+		channel = payload.DeleteParticipantRequest.ChannelName
+	case *ControlRequest_AddParticipantsRequest:
+		channel = payload.AddParticipantsRequest.ChannelName
+	case *ControlRequest_RemoveParticipantsRequest:
+		channel = payload.RemoveParticipantsRequest.ChannelName
+	case *ControlRequest_AdoptChannelRequest:
+		channel = payload.AdoptChannelRequest.ChannelName
+	case *ControlRequest_UpdateChannelRequest:
+		channel = payload.UpdateChannelRequest.ChannelName
+	case *ControlRequest_FreezeChannelRequest:
+		channel = payload.FreezeChannelRequest.ChannelName
+	case *ControlRequest_UnfreezeChannelRequest:
+		channel = payload.UnfreezeChannelRequest.ChannelName
+	case *ControlRequest_ListParticipantsRequest:
+		channel, write = payload.ListParticipantsRequest.ChannelName, false
+	case *ControlRequest_GetChannelRequest:
+		channel, write = payload.GetChannelRequest.ChannelName, false
+	case *ControlRequest_ListChannelRequest:
+		return nil
+	default:
+		return status.Error(codes.PermissionDenied, "the command requires the admin token")
+	}
+
+	if !p.allows(namespaceOf(channel), write) {
+		return status.Error(codes.PermissionDenied,
+			fmt.Sprintf("the command is not allowed on the channels of namespace %s", namespaceOf(channel)))
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTokenAuth(t *testing.T) {
+	auth := tokenAuth("secret-token")
+
+	tests := []struct {
+		name   string
+		header []string
+		wantOK bool
+	}{
+		{name: "valid token", header: []string{"Bearer secret-token"}, wantOK: true},
+		{name: "one valid token", header: []string{"Bearer other", "Bearer secret-token"}, wantOK: true},
+		{name: "missing token"},
+		{name: "invalid token", header: []string{"Bearer other"}},
+		{name: "missing scheme", header: []string{"secret-token"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := t.Context()
+			if tt.header != nil {
+				ctx = metadata.NewIncomingContext(ctx, metadata.MD{authorizationHeader: tt.header})
+			}
+
+			called := false
+			_, err := auth.unary(ctx, nil, &grpc.UnaryServerInfo{}, func(context.Context, any) (any, error) {
+				called = true
+				return nil, nil
+			})
+			assert.Equal(t, tt.wantOK, called)
+			if tt.wantOK {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, codes.Unauthenticated, status.Code(err))
+			}
+		})
+	}
+}
+
+func TestManagerConfig_GRPCServerOptions(t *testing.T) {
+	t.Run("plaintext without authentication", func(t *testing.T) {
+		cfg := &ManagerConfig{}
+		opts, err := cfg.GRPCServerOptions()
+		require.NoError(t, err)
+		assert.Empty(t, opts)
+	})
+
+	t.Run("authentication token", func(t *testing.T) {
+		cfg := &ManagerConfig{ServiceAuthToken: "secret-token"}
+		opts, err := cfg.GRPCServerOptions()
+		require.NoError(t, err)
+		assert.Len(t, opts, 2)
+	})
+
+	t.Run("namespace bindings", func(t *testing.T) {
+		cfg := &ManagerConfig{Namespaces: []NamespaceConfig{
+			{Name: "agntcy/team-a", Bindings: []RoleBinding{{Token: "team-a-token", Role: RoleAdmin}}},
+		}}
+		opts, err := cfg.GRPCServerOptions()
+		require.NoError(t, err)
+		assert.Len(t, opts, 2)
+	})
+
+	t.Run("missing certificate", func(t *testing.T) {
+		dir := t.TempDir()
+		cfg := &ManagerConfig{ServiceTLS: &ServiceTLSConfig{
+			CertFile: filepath.Join(dir, "server.pem"),
+			KeyFile:  filepath.Join(dir, "server-key.pem"),
+		}}
+		_, err := cfg.GRPCServerOptions()
+		assert.ErrorContains(t, err, "failed to load the server certificate")
+	})
+}
+
+func TestServiceTLSConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&ServiceTLSConfig{CertFile: "server.pem", KeyFile: "server-key.pem"}).Validate())
+	assert.ErrorContains(t, (&ServiceTLSConfig{CertFile: "server.pem"}).Validate(),
+		"cert file and key file must be specified")
+	assert.ErrorContains(t, (&ServiceTLSConfig{ClientCAFile: "ca.pem"}).Validate(),
+		"cert file and key file must be specified")
+}
+
+func TestNamespaceAuth(t *testing.T) {
+	auth := &namespaceAuth{
+		admin: tokenAuth("admin-token"),
+		bindings: map[string]map[string]string{
+			"team-a-token": {"agntcy/team-a": RoleAdmin},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		header        []string
+		wantOK        bool
+		wantPrincipal bool
+	}{
+		{name: "admin token", header: []string{"Bearer admin-token"}, wantOK: true},
+		{name: "bound token", header: []string{"Bearer team-a-token"}, wantOK: true, wantPrincipal: true},
+		{name: "missing token"},
+		{name: "unknown token", header: []string{"Bearer other"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := t.Context()
+			if tt.header != nil {
+				ctx = metadata.NewIncomingContext(ctx, metadata.MD{authorizationHeader: tt.header})
+			}
+
+			var got *principal
+			called := false
+			_, err := auth.unary(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+				called = true
+				got = principalFromContext(ctx)
+				return nil, nil
+			})
+			assert.Equal(t, tt.wantOK, called)
+			if !tt.wantOK {
+				assert.Equal(t, codes.Unauthenticated, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantPrincipal {
+				require.NotNil(t, got)
+				assert.Equal(t, RoleAdmin, got.roles["agntcy/team-a"])
+			} else {
+				assert.Nil(t, got)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	ctx := context.WithValue(t.Context(), principalKey{}, &principal{roles: map[string]string{
+		"agntcy/team-a": RoleAdmin,
+		"agntcy/team-b": RoleViewer,
+	}})
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		req    *ControlRequest
+		wantOK bool
+	}{
+		{name: "admin token", ctx: t.Context(), req: auditRoutes(false), wantOK: true},
+		{name: "namespace admin", ctx: ctx, req: createChannel("agntcy/team-a/traces", false), wantOK: true},
+		{name: "namespace viewer reads", ctx: ctx, req: listParticipants("agntcy/team-b/traces"), wantOK: true},
+		{name: "namespace viewer writes", ctx: ctx, req: deleteChannel("agntcy/team-b/traces")},
+		{name: "other namespace", ctx: ctx, req: listParticipants("agntcy/team-c/traces")},
+		{name: "list channels", ctx: ctx, req: listChannels(), wantOK: true},
+		{name: "routes audit", ctx: ctx, req: auditRoutes(false)},
+		{name: "namespace admin bulk", ctx: ctx, req: addParticipants("agntcy/team-a/traces", testParticipant), wantOK: true},
+		{name: "namespace viewer bulk", ctx: ctx, req: removeParticipants("agntcy/team-b/traces", testParticipant)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorize(tt.ctx, tt.req)
+			if tt.wantOK {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, codes.PermissionDenied, status.Code(err))
+			}
+		})
+	}
+}
+
+func TestServer_CommandPrincipal(t *testing.T) {
+	s, app := newTestServer()
+	require.True(t, command(t, s, createChannel("agntcy/team-a/traces", false)).Success)
+	require.True(t, command(t, s, createChannel("agntcy/team-b/traces", false)).Success)
+
+	ctx := context.WithValue(t.Context(), principalKey{}, &principal{roles: map[string]string{
+		"agntcy/team-a": RoleViewer,
+	}})
+
+	resp, err := s.Command(ctx, listChannels())
+	require.NoError(t, err)
+	payload, ok := resp.Payload.(*ControlResponse_ListChannelResponse)
+	require.True(t, ok)
+	assert.Equal(t, []string{"agntcy/team-a/traces"}, payload.ListChannelResponse.ChannelName)
+
+	_, err = s.Command(ctx, deleteChannel("agntcy/team-a/traces"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Len(t, app.Sessions(), 2)
+}
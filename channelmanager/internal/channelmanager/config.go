@@ -7,9 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
 	"github.com/agntcy/slim-otel/slimconfig"
 )
 
@@ -30,11 +34,110 @@ type ManagerConfig struct {
 	// gRPC service address to listen for commands
 	GRPCAddress string `yaml:"service-address"`
 
+	// TLS configuration of the gRPC service, plaintext if not set (optional)
+	ServiceTLS *ServiceTLSConfig `yaml:"service-tls"`
+
+	// Bearer token the gRPC clients must send in the authorization metadata,
+	// no authentication if empty (optional)
+	ServiceAuthToken string `yaml:"service-auth-token"`
+
 	// Local name for the channel manager in SLIM
 	LocalName string `yaml:"local-name"`
 
 	// Shared secret for MLS and identity provider
 	SharedSecret string `yaml:"shared-secret"`
+
+	// Address of the HTTP endpoint exposing the Prometheus metrics (optional)
+	MetricsAddress string `yaml:"metrics-address"`
+
+	// JSON file where the channels created through the service are persisted
+	// to be restored on restart (optional)
+	StateFile string `yaml:"state-file"`
+
+	// Remove the participants that request to leave a channel, e.g. the
+	// receivers drained before a shutdown. The channel manager then receives
+	// the messages of all its channels (optional)
+	LeaveRequests bool `yaml:"leave-requests"`
+
+	// Interval at which the channels are reconciled with the channels of the
+	// configuration file, read again each time: missing channels are created,
+	// the other channels deleted and the participants fixed. Disabled if 0
+	// (optional)
+	ReconcileInterval time.Duration `yaml:"reconcile-interval"`
+
+	// Retransmission settings of the group sessions of the channels, which
+	// each channel may override (optional)
+	SessionDefaults SessionSettings `yaml:"session-defaults"`
+
+	// Establishment of the routes to the participants before they are
+	// invited (optional)
+	Routes RouteSettings `yaml:"routes"`
+
+	// Retries of the failed invitations of the participants (optional)
+	Invites InviteSettings `yaml:"invites"`
+
+	// Time to wait for the invitation to an adopted channel when the request
+	// does not set it, 5s if 0 (optional)
+	AdoptTimeout time.Duration `yaml:"adopt-timeout"`
+
+	// Interval at which the readiness reported by the gRPC health service is
+	// checked, 10s if 0 (optional)
+	HealthCheckInterval time.Duration `yaml:"health-check-interval"`
+
+	// Level, encoding and sampling of the logs (optional)
+	Logging LoggingConfig `yaml:"logging"`
+
+	// Address of the HTTP endpoint serving the log level on /log/level, to
+	// change it without a restart. Disabled if empty (optional)
+	AdminAddress string `yaml:"admin-address"`
+
+	// Quotas and policies of the namespaces, which each namespace may
+	// override (optional)
+	NamespaceDefaults NamespaceSettings `yaml:"namespace-defaults"`
+
+	// Namespaces of the channels served to different teams, with their
+	// quotas, policies and role bindings (optional)
+	Namespaces []NamespaceConfig `yaml:"namespaces"`
+
+	// Reconcile the channels with the SlimChannel resources of a Kubernetes
+	// cluster, which then declare all the channels. Disabled if not set
+	// (optional)
+	Operator *OperatorConfig `yaml:"operator"`
+
+	// Channels whose new participants must be approved by an external
+	// workflow before they are invited. Disabled if not set (optional)
+	Approval *ApprovalConfig `yaml:"approval"`
+}
+
+// OperatorConfig defines the Kubernetes cluster watched in operator mode
+type OperatorConfig struct {
+	// Kubernetes namespace of the SlimChannel resources, all the namespaces
+	// if empty (optional)
+	Namespace string `yaml:"namespace"`
+
+	// URL of the Kubernetes API server, the in-cluster API server with the
+	// service account of the pod if empty (optional)
+	APIServer string `yaml:"api-server"`
+
+	// File of the bearer token sent to the API server, re-read on every
+	// request (optional)
+	TokenFile string `yaml:"token-file"`
+
+	// CA certificate of the API server, the system roots if empty (optional)
+	CAFile string `yaml:"ca-file"`
+
+	// Interval at which the channels are reconciled with the resources
+	// besides their changes, to fix the drift of the channels. 30s if 0
+	// (optional)
+	ResyncInterval time.Duration `yaml:"resync-interval"`
+}
+
+// Validate checks if the operator configuration is valid
+func (cfg *OperatorConfig) Validate() error {
+	if cfg.ResyncInterval < 0 {
+		return errors.New("resync interval cannot be negative")
+	}
+	return nil
 }
 
 // ChannelConfig defines configuration for a single channel
@@ -47,6 +150,110 @@ type ChannelConfig struct {
 
 	// Flag to enable or disable MLS for this channel
 	MlsEnabled bool `yaml:"mls-enabled"`
+
+	// Maximum size in bytes of a message published on the channel (optional)
+	MaxMessageSize int `yaml:"max-message-size"`
+
+	// Maximum number of messages per second each exporter publishes on the channel (optional)
+	MaxMessageRate float64 `yaml:"max-message-rate"`
+
+	// Severity the receivers apply to the log records without severity (optional)
+	DefaultLogSeverity string `yaml:"default-log-severity"`
+
+	// Signals carried by the channel: traces, metrics or logs. Exporters of
+	// other signals refuse to join it and receivers drop their messages. Any
+	// signal if empty (optional)
+	Signals []string `yaml:"signals"`
+
+	// Retransmission settings of the group session, overriding the manager
+	// session defaults (optional)
+	Session SessionSettings `yaml:"session"`
+
+	// Time after which the channel is deleted, counted from its creation.
+	// The channel does not expire if 0 (optional)
+	TTL time.Duration `yaml:"ttl"`
+}
+
+const (
+	// defaultMaxRetries is the number of retransmissions of a message on the
+	// group sessions when neither the channel nor the manager sets it
+	defaultMaxRetries = uint32(10)
+	// defaultRetryInterval is the interval between the retransmissions when
+	// neither the channel nor the manager sets it
+	defaultRetryInterval = time.Second
+)
+
+// SessionSettings are the retransmission settings of the group session of a
+// channel. The unset settings fall back to the manager session defaults.
+type SessionSettings struct {
+	// Maximum number of retransmissions of a message, 10 if not set (optional)
+	MaxRetries *uint32 `yaml:"max-retries"`
+
+	// Interval between the retransmissions, 1s if 0 (optional)
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Validate checks if the session settings are valid
+func (cfg *SessionSettings) Validate() error {
+	if cfg.Interval < 0 {
+		return errors.New("retransmission interval cannot be negative")
+	}
+	return nil
+}
+
+// WithDefaults returns the settings with the unset ones taken from defaults
+func (cfg SessionSettings) WithDefaults(defaults SessionSettings) SessionSettings {
+	if cfg.MaxRetries == nil {
+		cfg.MaxRetries = defaults.MaxRetries
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = defaults.Interval
+	}
+	return cfg
+}
+
+// ChannelSessionConfig returns the configuration of the group session of a
+// channel with the retransmission settings, the built-in defaults applying
+// to the unset ones
+func ChannelSessionConfig(mlsEnabled bool, settings SessionSettings) slim.SessionConfig {
+	maxRetries := defaultMaxRetries
+	if settings.MaxRetries != nil {
+		maxRetries = *settings.MaxRetries
+	}
+	interval := defaultRetryInterval
+	if settings.Interval > 0 {
+		interval = settings.Interval
+	}
+	return slim.SessionConfig{
+		SessionType: slim.SessionTypeGroup,
+		EnableMls:   mlsEnabled,
+		MaxRetries:  &maxRetries,
+		Interval:    &interval,
+		Metadata:    make(map[string]string),
+	}
+}
+
+// Policy returns the policy of the channel advertised to the participants
+func (cfg *ChannelConfig) Policy() slimcommon.ChannelPolicy {
+	policy := slimcommon.ChannelPolicy{
+		MaxMessageSize:     cfg.MaxMessageSize,
+		MaxMessageRate:     cfg.MaxMessageRate,
+		DefaultLogSeverity: cfg.DefaultLogSeverity,
+	}
+	for _, name := range cfg.Signals {
+		// invalid signals are rejected by Validate
+		if signal, err := slimcommon.ParseSignal(name); err == nil {
+			policy.Signals = append(policy.Signals, signal)
+		}
+	}
+	return policy
+}
+
+// participantSignal returns the signal a participant is named for by the
+// naming convention of the exporters, e.g. traces for
+// agntcy/otel/exporter-traces, and false if its name carries no signal
+func participantSignal(participant string) (slimconfig.SignalType, bool) {
+	return slimcommon.ExtractSignalType(participant, slimcommon.DefaultSignalNameTemplate)
 }
 
 // Validate checks if the configuration is valid
@@ -63,6 +270,29 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
+	return cfg.validateNamespaces()
+}
+
+// validateNamespaces checks that the channels of the configuration comply
+// with the quotas and policies of their namespace
+func (cfg *Config) validateNamespaces() error {
+	namespaces := newNamespaces(cfg.Manager.NamespaceDefaults, cfg.Manager.Namespaces)
+	counts := make(map[string]int)
+	for i, channel := range cfg.Channels {
+		namespace := namespaceOf(channel.Name)
+		settings := namespaces.settings(channel.Name)
+		counts[namespace]++
+		if settings.MaxChannels > 0 && counts[namespace] > settings.MaxChannels {
+			return fmt.Errorf("namespace %s has more than %d channels", namespace, settings.MaxChannels)
+		}
+		if settings.MaxParticipants > 0 && len(channel.Participants) > settings.MaxParticipants {
+			return fmt.Errorf("channel %d has more than the %d participants allowed by namespace %s",
+				i, settings.MaxParticipants, namespace)
+		}
+		if settings.mlsRequired() && !channel.MlsEnabled {
+			return fmt.Errorf("channel %d must enable MLS, which namespace %s requires", i, namespace)
+		}
+	}
 	return nil
 }
 
@@ -84,6 +314,71 @@ func (cfg *ManagerConfig) Validate() error {
 		return errors.New("shared secret cannot be empty")
 	}
 
+	if cfg.ServiceTLS != nil {
+		if err := cfg.ServiceTLS.Validate(); err != nil {
+			return fmt.Errorf("invalid service TLS config: %w", err)
+		}
+	}
+
+	if cfg.ReconcileInterval < 0 {
+		return errors.New("reconcile interval cannot be negative")
+	}
+
+	if err := cfg.SessionDefaults.Validate(); err != nil {
+		return fmt.Errorf("invalid session defaults: %w", err)
+	}
+
+	if err := cfg.Routes.Validate(); err != nil {
+		return fmt.Errorf("invalid routes config: %w", err)
+	}
+
+	if err := cfg.Invites.Validate(); err != nil {
+		return fmt.Errorf("invalid invites config: %w", err)
+	}
+
+	if cfg.AdoptTimeout < 0 {
+		return errors.New("adopt timeout cannot be negative")
+	}
+
+	if cfg.HealthCheckInterval < 0 {
+		return errors.New("health check interval cannot be negative")
+	}
+
+	if err := cfg.Logging.Validate(); err != nil {
+		return fmt.Errorf("invalid logging config: %w", err)
+	}
+
+	if err := cfg.NamespaceDefaults.Validate(); err != nil {
+		return fmt.Errorf("invalid namespace defaults: %w", err)
+	}
+
+	names := make(map[string]bool, len(cfg.Namespaces))
+	for i := range cfg.Namespaces {
+		if err := cfg.Namespaces[i].Validate(); err != nil {
+			return fmt.Errorf("invalid namespace %d: %w", i, err)
+		}
+		if names[cfg.Namespaces[i].Name] {
+			return fmt.Errorf("duplicate namespace %s", cfg.Namespaces[i].Name)
+		}
+		names[cfg.Namespaces[i].Name] = true
+	}
+
+	if cfg.Operator != nil {
+		if err := cfg.Operator.Validate(); err != nil {
+			return fmt.Errorf("invalid operator config: %w", err)
+		}
+		// both would delete the channels the other one declares
+		if cfg.ReconcileInterval > 0 {
+			return errors.New("operator mode and reconcile interval cannot be set together")
+		}
+	}
+
+	if cfg.Approval != nil {
+		if err := cfg.Approval.Validate(); err != nil {
+			return fmt.Errorf("invalid approval config: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -97,6 +392,58 @@ func (cfg *ChannelConfig) Validate() error {
 		return errors.New("at least one participant must be specified")
 	}
 
+	if cfg.MaxMessageSize < 0 {
+		return errors.New("max message size cannot be negative")
+	}
+
+	if cfg.MaxMessageRate < 0 {
+		return errors.New("max message rate cannot be negative")
+	}
+
+	if cfg.DefaultLogSeverity != "" {
+		if _, err := slimcommon.ParseLogSeverity(cfg.DefaultLogSeverity); err != nil {
+			return err
+		}
+	}
+
+	if err := cfg.Session.Validate(); err != nil {
+		return fmt.Errorf("invalid session settings: %w", err)
+	}
+
+	if cfg.TTL < 0 {
+		return errors.New("ttl cannot be negative")
+	}
+
+	return cfg.validateSignals()
+}
+
+// validateSignals checks the signals of the channel, and that the
+// participants named for a signal by the naming convention are named for
+// one of them
+func (cfg *ChannelConfig) validateSignals() error {
+	if len(cfg.Signals) == 0 {
+		return nil
+	}
+
+	var signals []slimconfig.SignalType
+	for _, name := range cfg.Signals {
+		signal, err := slimcommon.ParseSignal(name)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(signals, signal) {
+			return fmt.Errorf("duplicate signal %s", signal)
+		}
+		signals = append(signals, signal)
+	}
+
+	policy := slimcommon.ChannelPolicy{Signals: signals}
+	for _, participant := range cfg.Participants {
+		if signal, ok := participantSignal(participant); ok && !policy.CarriesSignal(signal) {
+			return fmt.Errorf("participant %s is named for %s, which the channel does not carry", participant, signal)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,91 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestChannelConfig_Signals(t *testing.T) {
+	tests := []struct {
+		name         string
+		signals      []string
+		participants []string
+		errorMsg     string
+	}{
+		{
+			name:         "any signal",
+			participants: []string{"agntcy/otel/exporter-traces", "agntcy/otel/exporter-logs"},
+		},
+		{
+			name:         "participants named for the signals",
+			signals:      []string{"traces", "logs"},
+			participants: []string{"agntcy/otel/exporter-traces", "agntcy/otel/exporter-logs", "agntcy/otel/receiver"},
+		},
+		{
+			name:         "invalid signal",
+			signals:      []string{"profiles"},
+			participants: []string{"agntcy/otel/receiver"},
+			errorMsg:     `invalid signal "profiles"`,
+		},
+		{
+			name:         "duplicate signal",
+			signals:      []string{"logs", "logs"},
+			participants: []string{"agntcy/otel/receiver"},
+			errorMsg:     "duplicate signal logs",
+		},
+		{
+			name:         "participant named for another signal",
+			signals:      []string{"traces"},
+			participants: []string{"agntcy/otel/exporter-metrics", "agntcy/otel/receiver"},
+			errorMsg:     "participant agntcy/otel/exporter-metrics is named for metrics",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := ChannelConfig{Name: "agntcy/otel/channel", Participants: tt.participants, Signals: tt.signals}
+			err := cfg.Validate()
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestChannelConfig_PolicySignals(t *testing.T) {
+	cfg := ChannelConfig{Signals: []string{"metrics", "logs"}}
+	assert.Equal(t, []slimconfig.SignalType{slimconfig.SignalMetrics, slimconfig.SignalLogs}, cfg.Policy().Signals)
+	assert.Empty(t, (&ChannelConfig{}).Policy().Signals)
+}
+
+func TestManagerConfig_Operator(t *testing.T) {
+	newConfig := func() *ManagerConfig {
+		return &ManagerConfig{
+			ConnectionConfig: &slimconfig.ConnectionConfig{Address: "http://localhost:46357"},
+			LocalName:        "agntcy/otel/channel-manager",
+			SharedSecret:     "secret",
+			Operator:         &OperatorConfig{Namespace: "observability"},
+		}
+	}
+
+	require.NoError(t, newConfig().Validate())
+
+	cfg := newConfig()
+	cfg.Operator.ResyncInterval = -time.Second
+	require.ErrorContains(t, cfg.Validate(), "resync interval cannot be negative")
+
+	cfg = newConfig()
+	cfg.ReconcileInterval = time.Minute
+	require.ErrorContains(t, cfg.Validate(), "operator mode and reconcile interval cannot be set together")
+}
@@ -0,0 +1,277 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// channelActivity is the activity of a channel tracked by the channel manager
+type channelActivity struct {
+	created      time.Time
+	lastActivity time.Time
+	// participants being invited, by ID
+	pending map[string]string
+	// time the channel was frozen, zero if it is not frozen
+	frozen time.Time
+	// reason of the freeze
+	freezeReason string
+}
+
+// channelRegistry tracks the activity of the channels, which SLIM does not
+// report, for the channel details
+type channelRegistry struct {
+	mutex    sync.Mutex
+	channels map[string]*channelActivity
+}
+
+// newChannelRegistry creates a channelRegistry tracking the channels of the
+// list, e.g. created from the configuration file, as created now
+func newChannelRegistry(channels *slimcommon.SessionsList) *channelRegistry {
+	r := &channelRegistry{channels: make(map[string]*channelActivity)}
+	for _, channel := range channels.ListSessionNames(context.Background()) {
+		r.created(channel)
+	}
+	return r
+}
+
+// created tracks a new channel
+func (r *channelRegistry) created(channel string) {
+	now := time.Now()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.channels[channel] = &channelActivity{created: now, lastActivity: now, pending: make(map[string]string)}
+}
+
+// deleted forgets a channel
+func (r *channelRegistry) deleted(channel string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.channels, channel)
+}
+
+// touch records an activity on a tracked channel
+func (r *channelRegistry) touch(channel string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if activity, ok := r.channels[channel]; ok {
+		activity.lastActivity = time.Now()
+	}
+}
+
+// inviting records the pending invitation of a participant identified by id
+// and displayed as name
+func (r *channelRegistry) inviting(channel, id, name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if activity, ok := r.channels[channel]; ok {
+		activity.pending[id] = name
+		activity.lastActivity = time.Now()
+	}
+}
+
+// invited records the end of the invitation of a participant, whether it
+// joined the channel or not
+func (r *channelRegistry) invited(channel, id string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if activity, ok := r.channels[channel]; ok {
+		delete(activity.pending, id)
+		activity.lastActivity = time.Now()
+	}
+}
+
+// activity returns a copy of the activity of a channel, the zero value if the
+// channel is not tracked
+func (r *channelRegistry) activity(channel string) channelActivity {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	activity, ok := r.channels[channel]
+	if !ok {
+		return channelActivity{}
+	}
+	return channelActivity{
+		created:      activity.created,
+		lastActivity: activity.lastActivity,
+		pending:      maps.Clone(activity.pending),
+		frozen:       activity.frozen,
+		freezeReason: activity.freezeReason,
+	}
+}
+
+// setFrozen records that a tracked channel is frozen with reason, or
+// unfrozen, keeping the time of the freeze if it was frozen already
+func (r *channelRegistry) setFrozen(channel string, frozen bool, reason string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	activity, ok := r.channels[channel]
+	if !ok {
+		return
+	}
+	activity.lastActivity = time.Now()
+	if !frozen {
+		activity.frozen, activity.freezeReason = time.Time{}, ""
+		return
+	}
+	if activity.frozen.IsZero() {
+		activity.frozen = activity.lastActivity
+	}
+	activity.freezeReason = reason
+}
+
+// restoreFrozen records that a tracked channel has been frozen since the
+// given time, e.g. the channel recreated with a new session
+func (r *channelRegistry) restoreFrozen(channel string, since time.Time, reason string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if activity, ok := r.channels[channel]; ok {
+		activity.frozen, activity.freezeReason = since, reason
+	}
+}
+
+// handleGetChannel returns the details of a channel. The participants being
+// invited are pending, the other participants of the session joined, the
+// failed invitations failed, and the parked additions await an approval.
+func (s *Server) handleGetChannel(
+	ctx context.Context, msgID uint64, req *GetChannelRequest,
+) (*ControlResponse, error) {
+	channel, err := slimcommon.SplitID(req.ChannelName)
+	if err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("invalid channel name: %s", req.ChannelName))
+	}
+
+	channelStr := channel.String()
+
+	session, err := s.channels.GetSessionByName(ctx, channelStr)
+	if err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("failed to get channel %s: %v", channelStr, err))
+	}
+
+	sessionID, err := session.SessionId()
+	if err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("failed to get the session of channel %s: %v", channelStr, err))
+	}
+
+	config, err := session.SessionConfig()
+	if err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("failed to get the configuration of channel %s: %v", channelStr, err))
+	}
+
+	participants, err := session.ParticipantsList()
+	if err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("failed to list participants for channel %s: %v", channelStr, err))
+	}
+
+	activity := s.registry.activity(channelStr)
+
+	statuses := make([]*ParticipantStatus, 0, len(participants)+len(activity.pending))
+	joined := make(map[string]struct{}, len(participants))
+	for _, participant := range participants {
+		// the invitation may complete before the registry is updated
+		delete(activity.pending, slimcommon.JoinID(participant))
+		joined[slimcommon.JoinID(participant)] = struct{}{}
+		statuses = append(statuses, s.participantStatus(participant, ParticipantStatus_JOINED))
+	}
+	for _, id := range slices.Sorted(maps.Keys(activity.pending)) {
+		name, splitErr := slimcommon.SplitID(id)
+		if splitErr != nil {
+			continue
+		}
+		statuses = append(statuses, s.participantStatus(name, ParticipantStatus_PENDING))
+	}
+	for _, name := range s.invites.failedNames(channelStr) {
+		id := slimcommon.JoinID(name)
+		_, member := joined[id]
+		if _, retrying := activity.pending[id]; retrying || member {
+			continue
+		}
+		statuses = append(statuses, s.participantStatus(name, ParticipantStatus_FAILED))
+	}
+	for _, name := range s.approvals.names(channelStr) {
+		// the route is set once the addition is approved
+		statuses = append(statuses, &ParticipantStatus{
+			ParticipantName: name,
+			Status:          ParticipantStatus_AWAITING_APPROVAL,
+		})
+	}
+
+	slimcommon.LoggerFromContextOrDefault(ctx).Info("Getting channel",
+		zap.String("channel", channelStr),
+		zap.Int("participants", len(statuses)))
+
+	var maxRetries uint32
+	if config.MaxRetries != nil {
+		maxRetries = *config.MaxRetries
+	}
+	var retryInterval time.Duration
+	if config.Interval != nil {
+		retryInterval = *config.Interval
+	}
+
+	return s.getChannelResponse(msgID, &GetChannelResponse{
+		MsgId:                msgID,
+		ChannelName:          channelStr,
+		MlsEnabled:           config.EnableMls,
+		SessionId:            sessionID,
+		CreatedUnixNano:      unixNano(activity.created),
+		LastActivityUnixNano: unixNano(activity.lastActivity),
+		Participant:          statuses,
+		MaxRetries:           maxRetries,
+		RetryIntervalMs:      uint64(retryInterval.Milliseconds()),
+		Frozen:               !activity.frozen.IsZero(),
+		FreezeReason:         activity.freezeReason,
+		FrozenUnixNano:       unixNano(activity.frozen),
+		ExpiresUnixNano:      unixNano(s.expiry.deadline(channelStr)),
+	})
+}
+
+// participantStatus returns the status of a participant of a channel with
+// the state of its route
+func (s *Server) participantStatus(
+	participant *slim.Name, status ParticipantStatus_Status,
+) *ParticipantStatus {
+	result := &ParticipantStatus{ParticipantName: participant.String(), Status: status}
+	if s.routeSettings.Disabled {
+		result.RouteStatus = ParticipantStatus_ROUTE_UNMANAGED
+		return result
+	}
+	route := s.routes.State(participant)
+	result.RouteStatus = route.Status
+	result.RouteAttempts = uint32(route.Attempts) //nolint:gosec // bounded by the route settings
+	if route.Err != nil {
+		result.RouteError = route.Err.Error()
+	}
+	return result
+}
+
+// unixNano returns t in nanoseconds since the Unix epoch, 0 for the zero time
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// getChannelResponse creates a get channel response
+func (s *Server) getChannelResponse(
+	msgID uint64, details *GetChannelResponse,
+) (*ControlResponse, error) {
+	return &ControlResponse{
+		MgsId: msgID,
+		Payload: &ControlResponse_GetChannelResponse{
+			GetChannelResponse: details,
+		},
+	}, nil
+}
@@ -0,0 +1,136 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func getChannel(channel string) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 12,
+		Payload: &ControlRequest_GetChannelRequest{
+			GetChannelRequest: &GetChannelRequest{ChannelName: channel},
+		},
+	}
+}
+
+// TestServer_GetChannel tests the details of a channel
+func TestServer_GetChannel(t *testing.T) {
+	const exporter = "agntcy/otel/exporter"
+
+	get := func(t *testing.T, s *Server, channel string) *GetChannelResponse {
+		t.Helper()
+		resp, err := s.Command(t.Context(), getChannel(channel))
+		require.NoError(t, err)
+		payload, ok := resp.Payload.(*ControlResponse_GetChannelResponse)
+		require.True(t, ok, "unexpected response payload %T", resp.Payload)
+		return payload.GetChannelResponse
+	}
+
+	t.Run("channel details", func(t *testing.T) {
+		s, app := newTestServer()
+		before := time.Now().UnixNano()
+		require.True(t, command(t, s, createChannel(testChannel, true)).Success)
+		created := get(t, s, testChannel)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+
+		details := get(t, s, testChannel)
+		assert.Equal(t, uint64(12), details.MsgId)
+		assert.Equal(t, testChannel, details.ChannelName)
+		assert.True(t, details.MlsEnabled)
+		id, err := app.SessionByName(testChannel).SessionId()
+		require.NoError(t, err)
+		assert.Equal(t, id, details.SessionId)
+		assert.GreaterOrEqual(t, details.CreatedUnixNano, before)
+		assert.Greater(t, details.LastActivityUnixNano, created.LastActivityUnixNano,
+			"the invitation is an activity")
+		require.Len(t, details.Participant, 1)
+		assert.Equal(t, testParticipant, details.Participant[0].ParticipantName)
+		assert.Equal(t, ParticipantStatus_JOINED, details.Participant[0].Status)
+		assert.Equal(t, uint32(10), details.MaxRetries)
+		assert.Equal(t, uint64(1000), details.RetryIntervalMs)
+	})
+
+	t.Run("session defaults", func(t *testing.T) {
+		maxRetries := uint32(3)
+		s := NewChannelManagerServer(testutil.NewFakeApp(), 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+			WithSessionDefaults(SessionSettings{MaxRetries: &maxRetries, Interval: 250 * time.Millisecond}))
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+
+		details := get(t, s, testChannel)
+		assert.Equal(t, uint32(3), details.MaxRetries)
+		assert.Equal(t, uint64(250), details.RetryIntervalMs)
+	})
+
+	t.Run("pending invitation", func(t *testing.T) {
+		s, _ := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		// the invitation of the exporter is in progress
+		s.registry.inviting(testChannel, exporter, exporter)
+
+		details := get(t, s, testChannel)
+		assert.Equal(t, []*ParticipantStatus{
+			{
+				ParticipantName: testParticipant,
+				Status:          ParticipantStatus_JOINED,
+				RouteStatus:     ParticipantStatus_ROUTE_SET,
+				RouteAttempts:   1,
+			},
+			{ParticipantName: exporter, Status: ParticipantStatus_PENDING},
+		}, details.Participant)
+
+		s.registry.invited(testChannel, exporter)
+		assert.Len(t, get(t, s, testChannel).Participant, 1)
+	})
+
+	t.Run("failed invitation", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		app.SessionByName(testChannel).InviteErr = assert.AnError
+		require.False(t, command(t, s, addParticipant(testChannel, exporter)).Success)
+
+		participants := get(t, s, testChannel).Participant
+		require.Len(t, participants, 1)
+		assert.Equal(t, ParticipantStatus_FAILED, participants[0].Status)
+	})
+
+	t.Run("recreated channel", func(t *testing.T) {
+		s, _ := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		created := get(t, s, testChannel).CreatedUnixNano
+
+		require.True(t, command(t, s, deleteChannel(testChannel)).Success)
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		assert.Greater(t, get(t, s, testChannel).CreatedUnixNano, created)
+	})
+
+	t.Run("channel of the configuration file", func(t *testing.T) {
+		app := testutil.NewFakeApp()
+		channels := slimcommon.NewSessionsList(slimconfig.SignalUnknown)
+		require.NoError(t, channels.AddSession(t.Context(), testutil.NewFakeSession(1, testChannel)))
+		s := NewChannelManagerServer(app, 1, channels)
+
+		details := get(t, s, testChannel)
+		assert.NotZero(t, details.CreatedUnixNano)
+		assert.Equal(t, uint32(1), details.SessionId)
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		s, _ := newTestServer()
+
+		resp := command(t, s, getChannel(testChannel))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "failed to get channel")
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// defaultDrainGracePeriod is the time left to a drained participant to flush
+// its data when the request does not set it
+const defaultDrainGracePeriod = time.Second
+
+// drainedChannel is a channel the drained participant is removed from
+type drainedChannel struct {
+	name    *slim.Name
+	session slimcommon.Session
+}
+
+// handleDrainParticipant removes a participant from all the channels. The
+// participant is notified on each channel first, then removed once the grace
+// period elapsed, so that it can flush the data it holds.
+func (s *Server) handleDrainParticipant(
+	ctx context.Context, msgID uint64, req *DrainParticipantRequest,
+) (*ControlResponse, error) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	participant, err := slimcommon.SplitID(req.ParticipantName)
+	if err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("invalid participant name: %s", req.ParticipantName))
+	}
+	participantStr := slimcommon.JoinID(participant)
+
+	gracePeriod := defaultDrainGracePeriod
+	if req.GracePeriodMs > 0 {
+		gracePeriod = time.Duration(req.GracePeriodMs) * time.Millisecond
+	}
+
+	// the drained participant is not invited again by the retries
+	s.invites.forgetParticipant(participantStr)
+
+	drained := make([]string, 0)
+	failed := make([]string, 0)
+
+	channels := make([]drainedChannel, 0)
+	for _, channelStr := range s.channels.ListSessionNames(ctx) {
+		session, err := s.channels.GetSessionByName(ctx, channelStr)
+		if err != nil {
+			// the channel was deleted in the meantime
+			continue
+		}
+		channel, err := session.Destination()
+		if err != nil {
+			logger.Warn("Failed to get the channel name", zap.String("channel", channelStr), zap.Error(err))
+			failed = append(failed, channelStr)
+			continue
+		}
+		member, err := hasParticipant(session, participantStr)
+		if err != nil {
+			logger.Warn("Failed to list the channel participants", zap.String("channel", channelStr), zap.Error(err))
+			failed = append(failed, channelStr)
+			continue
+		}
+		if !member {
+			continue
+		}
+
+		// the participant is removed even if it could not be notified
+		if err := notifyDrain(session, participantStr); err != nil {
+			logger.Warn("Failed to notify the drained participant",
+				zap.String("channel", channelStr),
+				zap.String("participant", participantStr),
+				zap.Error(err))
+		}
+		channels = append(channels, drainedChannel{name: channel, session: session})
+	}
+
+	if len(channels) > 0 {
+		select {
+		case <-time.After(gracePeriod):
+		case <-ctx.Done():
+		}
+	}
+
+	for _, channel := range channels {
+		if err := s.removeParticipant(ctx, channel.session, channel.name, participant); err != nil {
+			logger.Warn("Failed to remove the drained participant", zap.Error(err))
+			failed = append(failed, channel.name.String())
+			continue
+		}
+		drained = append(drained, channel.name.String())
+	}
+
+	logger.Info("Drained participant",
+		zap.String("participant", participantStr),
+		zap.Strings("drained", drained),
+		zap.Strings("failed", failed))
+
+	return s.drainParticipantResponse(msgID, drained, failed)
+}
+
+// hasParticipant reports whether participant is a participant of the channel
+func hasParticipant(session slimcommon.Session, participant string) (bool, error) {
+	names, err := session.ParticipantsList()
+	if err != nil {
+		return false, err
+	}
+	for _, name := range names {
+		if slimcommon.JoinID(name) == participant {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// notifyDrain publishes the drain notification of participant on the channel
+func notifyDrain(session slimcommon.Session, participant string) error {
+	payloadType := slimcommon.PayloadTypeDrain
+	metadata := map[string]string{slimcommon.MetadataDrainParticipant: participant}
+	return session.PublishAndWait([]byte{}, &payloadType, &metadata)
+}
+
+// drainParticipantResponse creates a drain participant response
+func (s *Server) drainParticipantResponse(
+	msgID uint64, drained, failed []string,
+) (*ControlResponse, error) {
+	return &ControlResponse{
+		MgsId: msgID,
+		Payload: &ControlResponse_DrainParticipantResponse{
+			DrainParticipantResponse: &DrainParticipantResponse{
+				MsgId:          msgID,
+				DrainedChannel: drained,
+				FailedChannel:  failed,
+			},
+		},
+	}, nil
+}
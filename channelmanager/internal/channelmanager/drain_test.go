@@ -0,0 +1,106 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+func drainParticipant(participant string) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 9,
+		Payload: &ControlRequest_DrainParticipantRequest{
+			DrainParticipantRequest: &DrainParticipantRequest{ParticipantName: participant, GracePeriodMs: 1},
+		},
+	}
+}
+
+// TestServer_DrainParticipant tests the removal of a participant from all channels
+func TestServer_DrainParticipant(t *testing.T) {
+	const otherChannel = "agntcy/otel/other-channel"
+	const unrelatedChannel = "agntcy/otel/unrelated-channel"
+
+	drain := func(t *testing.T, s *Server, participant string) *DrainParticipantResponse {
+		t.Helper()
+		resp, err := s.Command(t.Context(), drainParticipant(participant))
+		require.NoError(t, err)
+		payload, ok := resp.Payload.(*ControlResponse_DrainParticipantResponse)
+		require.True(t, ok, "unexpected response payload %T", resp.Payload)
+		return payload.DrainParticipantResponse
+	}
+
+	t.Run("drain participant", func(t *testing.T) {
+		s, app := newTestServer()
+		for _, channel := range []string{testChannel, otherChannel, unrelatedChannel} {
+			require.True(t, command(t, s, createChannel(channel, false)).Success)
+		}
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		require.True(t, command(t, s, addParticipant(otherChannel, testParticipant)).Success)
+		require.True(t, command(t, s, addParticipant(unrelatedChannel, "agntcy/otel/exporter")).Success)
+
+		resp := drain(t, s, testParticipant)
+		assert.ElementsMatch(t, []string{testChannel, otherChannel}, resp.DrainedChannel)
+		assert.Empty(t, resp.FailedChannel)
+
+		for _, channel := range []string{testChannel, otherChannel} {
+			session := app.SessionByName(channel)
+			assert.Empty(t, session.Participants())
+
+			published := session.PublishedMessages()
+			require.Len(t, published, 1, "the participant is notified on %s", channel)
+			assert.Equal(t, slimcommon.PayloadTypeDrain, published[0].Context.PayloadType)
+			assert.Equal(t, testParticipant, published[0].Context.Metadata[slimcommon.MetadataDrainParticipant])
+		}
+		unrelated := app.SessionByName(unrelatedChannel)
+		assert.Equal(t, []string{"agntcy/otel/exporter"}, unrelated.Participants())
+		assert.Empty(t, unrelated.PublishedMessages())
+	})
+
+	t.Run("participant of no channel", func(t *testing.T) {
+		s, _ := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+
+		resp := drain(t, s, testParticipant)
+		assert.Empty(t, resp.DrainedChannel)
+		assert.Empty(t, resp.FailedChannel)
+	})
+
+	t.Run("notification fails", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		app.SessionByName(testChannel).PublishErr = errors.New("boom")
+
+		resp := drain(t, s, testParticipant)
+		assert.Equal(t, []string{testChannel}, resp.DrainedChannel, "the participant is removed anyway")
+		assert.Empty(t, app.SessionByName(testChannel).Participants())
+	})
+
+	t.Run("remove fails", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, createChannel(otherChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		require.True(t, command(t, s, addParticipant(otherChannel, testParticipant)).Success)
+		app.SessionByName(testChannel).RemoveErr = errors.New("boom")
+
+		resp := drain(t, s, testParticipant)
+		assert.Equal(t, []string{otherChannel}, resp.DrainedChannel)
+		assert.Equal(t, []string{testChannel}, resp.FailedChannel)
+	})
+
+	t.Run("invalid participant name", func(t *testing.T) {
+		s, _ := newTestServer()
+
+		resp := command(t, s, drainParticipant("invalid"))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "invalid participant name")
+	})
+}
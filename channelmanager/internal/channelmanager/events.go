@@ -0,0 +1,122 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// watcherBufferSize is the number of events buffered for each watcher. A
+// watcher that falls behind by more events is disconnected.
+const watcherBufferSize = 64
+
+// eventBroker fans out the channel events to the watchers
+type eventBroker struct {
+	mutex    sync.Mutex
+	watchers map[chan *ChannelEvent]struct{}
+}
+
+// newEventBroker creates an eventBroker without watchers
+func newEventBroker() *eventBroker {
+	return &eventBroker{watchers: make(map[chan *ChannelEvent]struct{})}
+}
+
+// subscribe registers a watcher and returns its events along with the
+// function that unregisters it. The events channel is closed if the watcher
+// does not keep up with the events.
+func (b *eventBroker) subscribe() (<-chan *ChannelEvent, func()) {
+	events := make(chan *ChannelEvent, watcherBufferSize)
+
+	b.mutex.Lock()
+	b.watchers[events] = struct{}{}
+	b.mutex.Unlock()
+
+	return events, func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		if _, ok := b.watchers[events]; ok {
+			delete(b.watchers, events)
+			close(events)
+		}
+	}
+}
+
+// publish sends an event to every watcher without blocking, disconnecting
+// the watchers whose buffer is full
+func (b *eventBroker) publish(eventType ChannelEvent_Type, channel, participant string) {
+	event := &ChannelEvent{
+		Type:              eventType,
+		ChannelName:       channel,
+		ParticipantName:   participant,
+		TimestampUnixNano: time.Now().UnixNano(),
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for events := range b.watchers {
+		select {
+		case events <- event:
+		default:
+			delete(b.watchers, events)
+			close(events)
+		}
+	}
+}
+
+// WatchChannels streams the events of the channels managed by the channel
+// manager, or of a single channel if the request names one, until the
+// client cancels the stream
+func (s *Server) WatchChannels(req *WatchChannelsRequest, stream grpc.ServerStreamingServer[ChannelEvent]) error {
+	ctx := stream.Context()
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	filter := ""
+	if req.ChannelName != "" {
+		channel, err := slimcommon.SplitID(req.ChannelName)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, fmt.Sprintf("invalid channel name: %s", req.ChannelName))
+		}
+		filter = channel.String()
+	}
+
+	// the clients bound to namespaces only watch their channels
+	p := principalFromContext(ctx)
+	if filter != "" && !p.allows(namespaceOf(filter), false) {
+		return status.Error(codes.PermissionDenied,
+			fmt.Sprintf("watching the channels of namespace %s is not allowed", namespaceOf(filter)))
+	}
+
+	events, unsubscribe := s.events.subscribe()
+	defer unsubscribe()
+
+	logger.Info("Watching channels", zap.String("channel", filter))
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "watcher disconnected for not keeping up with the events")
+			}
+			if filter != "" && event.ChannelName != filter {
+				continue
+			}
+			if !p.allows(namespaceOf(event.ChannelName), false) {
+				continue
+			}
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeEventStream is a ChannelEvent server stream forwarding the events sent
+// to it on a channel
+type fakeEventStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *ChannelEvent
+}
+
+func (f *fakeEventStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeEventStream) Send(event *ChannelEvent) error {
+	f.events <- event
+	return nil
+}
+
+// watchers returns the number of watchers subscribed to the server events
+func watchers(s *Server) int {
+	s.events.mutex.Lock()
+	defer s.events.mutex.Unlock()
+	return len(s.events.watchers)
+}
+
+// watch starts watching the events of channel and returns the stream the
+// events are sent to, along with the result of WatchChannels
+func watch(t *testing.T, s *Server, channel string) (*fakeEventStream, <-chan error) {
+	ctx, cancel := context.WithCancel(t.Context())
+	t.Cleanup(cancel)
+
+	stream := &fakeEventStream{ctx: ctx, events: make(chan *ChannelEvent, 16)}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.WatchChannels(&WatchChannelsRequest{ChannelName: channel}, stream)
+	}()
+	require.Eventually(t, func() bool { return watchers(s) == 1 }, 5*time.Second, 10*time.Millisecond)
+	return stream, done
+}
+
+// nextEvent returns the next event sent on the stream
+func nextEvent(t *testing.T, stream *fakeEventStream) *ChannelEvent {
+	t.Helper()
+	select {
+	case event := <-stream.events:
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event received")
+		return nil
+	}
+}
+
+func TestEventBroker_SlowWatcher(t *testing.T) {
+	broker := newEventBroker()
+	events, unsubscribe := broker.subscribe()
+	defer unsubscribe()
+
+	for range watcherBufferSize + 1 {
+		broker.publish(ChannelEvent_CHANNEL_CREATED, testChannel, "")
+	}
+
+	count := 0
+	for range events {
+		count++
+	}
+	assert.Equal(t, watcherBufferSize, count, "the events channel is closed once the buffer is full")
+	assert.Empty(t, broker.watchers)
+}
+
+func TestServer_WatchChannels(t *testing.T) {
+	t.Run("all channels", func(t *testing.T) {
+		s, _ := newTestServer()
+		stream, done := watch(t, s, "")
+
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		require.True(t, command(t, s, deleteParticipant(testChannel, testParticipant)).Success)
+		require.True(t, command(t, s, deleteChannel(testChannel)).Success)
+
+		for _, want := range []struct {
+			eventType   ChannelEvent_Type
+			participant string
+		}{
+			{ChannelEvent_CHANNEL_CREATED, ""},
+			{ChannelEvent_PARTICIPANT_JOINED, testParticipant},
+			{ChannelEvent_PARTICIPANT_LEFT, testParticipant},
+			{ChannelEvent_CHANNEL_DELETED, ""},
+		} {
+			event := nextEvent(t, stream)
+			assert.Equal(t, want.eventType, event.Type)
+			assert.Equal(t, testChannel, event.ChannelName)
+			assert.Equal(t, want.participant, event.ParticipantName)
+			assert.Positive(t, event.TimestampUnixNano)
+		}
+
+		select {
+		case err := <-done:
+			require.FailNow(t, "the watch ended early", "error: %v", err)
+		default:
+		}
+	})
+
+	t.Run("single channel", func(t *testing.T) {
+		s, _ := newTestServer()
+		stream, _ := watch(t, s, testChannel)
+
+		require.True(t, command(t, s, createChannel("agntcy/otel/other", false)).Success)
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+
+		event := nextEvent(t, stream)
+		assert.Equal(t, ChannelEvent_CHANNEL_CREATED, event.Type)
+		assert.Equal(t, testChannel, event.ChannelName)
+	})
+
+	t.Run("failed commands are not reported", func(t *testing.T) {
+		s, _ := newTestServer()
+		stream, _ := watch(t, s, "")
+
+		require.False(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+
+		assert.Equal(t, ChannelEvent_CHANNEL_CREATED, nextEvent(t, stream).Type)
+	})
+
+	t.Run("canceled by the client", func(t *testing.T) {
+		s, _ := newTestServer()
+		ctx, cancel := context.WithCancel(t.Context())
+		stream := &fakeEventStream{ctx: ctx, events: make(chan *ChannelEvent, 16)}
+		done := make(chan error, 1)
+		go func() {
+			done <- s.WatchChannels(&WatchChannelsRequest{}, stream)
+		}()
+		require.Eventually(t, func() bool { return watchers(s) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+		cancel()
+		require.NoError(t, <-done)
+		assert.Zero(t, watchers(s))
+	})
+
+	t.Run("invalid channel name", func(t *testing.T) {
+		s, _ := newTestServer()
+		err := s.WatchChannels(&WatchChannelsRequest{ChannelName: "invalid"}, &fakeEventStream{ctx: t.Context()})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,148 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// expiryCheckInterval is the period at which the expired channels are
+// looked up
+const expiryCheckInterval = time.Second
+
+// channelExpiry tracks the time each channel with a time to live expires,
+// and the channels of the configuration file that expired, so that the
+// reconciliation does not recreate them
+type channelExpiry struct {
+	mutex     sync.Mutex
+	deadlines map[string]time.Time
+	expired   map[string]struct{}
+}
+
+// newChannelExpiry creates a channelExpiry without any channel
+func newChannelExpiry() *channelExpiry {
+	return &channelExpiry{
+		deadlines: make(map[string]time.Time),
+		expired:   make(map[string]struct{}),
+	}
+}
+
+// set records that channel expires at deadline
+func (e *channelExpiry) set(channel string, deadline time.Time) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.deadlines[channel] = deadline
+	delete(e.expired, channel)
+}
+
+// forget forgets the deadline of a deleted channel, and that a channel
+// created again expired
+func (e *channelExpiry) forget(channel string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	delete(e.deadlines, channel)
+	delete(e.expired, channel)
+}
+
+// deadline returns the time channel expires, the zero time if it does not
+func (e *channelExpiry) deadline(channel string) time.Time {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.deadlines[channel]
+}
+
+// due returns the channels expired at now, sorted by name
+func (e *channelExpiry) due(now time.Time) []string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	channels := make([]string, 0)
+	for _, channel := range slices.Sorted(maps.Keys(e.deadlines)) {
+		if !now.Before(e.deadlines[channel]) {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// markExpired records that channel expired
+func (e *channelExpiry) markExpired(channel string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.expired[channel] = struct{}{}
+}
+
+// hasExpired reports whether channel expired
+func (e *channelExpiry) hasExpired(channel string) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	_, ok := e.expired[channel]
+	return ok
+}
+
+// WithChannelTTLs sets the expiry of the channels of the configuration file
+// with a time to live, counted from now
+func WithChannelTTLs(channels []ChannelConfig) ServerOption {
+	return func(s *Server) {
+		now := time.Now()
+		for _, config := range channels {
+			if config.TTL <= 0 {
+				continue
+			}
+			channel, err := slimcommon.SplitID(config.Name)
+			if err != nil {
+				continue
+			}
+			s.expiry.set(channel.String(), now.Add(config.TTL))
+		}
+	}
+}
+
+// ServeExpiry deletes the channels whose time to live elapsed, until ctx is
+// done
+func (s *Server) ServeExpiry(ctx context.Context) {
+	ticker := time.NewTicker(expiryCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.expireChannels(ctx, now)
+		}
+	}
+}
+
+// expireChannels deletes the channels expired at now and notifies the
+// watchers. A channel that cannot be deleted is retried at the next check.
+func (s *Server) expireChannels(ctx context.Context, now time.Time) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	for _, channelStr := range s.expiry.due(now) {
+		channel, err := slimcommon.SplitID(channelStr)
+		if err != nil {
+			s.expiry.forget(channelStr)
+			continue
+		}
+		if _, err := s.channels.GetSessionByName(ctx, channelStr); err != nil {
+			// the channel was deleted in the meantime
+			s.expiry.forget(channelStr)
+			continue
+		}
+
+		if err := s.deleteChannel(ctx, channel); err != nil {
+			logger.Warn("Failed to delete expired channel", zap.String("channel", channelStr), zap.Error(err))
+			continue
+		}
+		s.expiry.markExpired(channelStr)
+		s.events.publish(ChannelEvent_CHANNEL_EXPIRED, channelStr, "")
+		logger.Info("Deleted expired channel", zap.String("channel", channelStr))
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// createChannelWithTTL returns a create channel request with a time to live
+func createChannelWithTTL(name string, ttl time.Duration) *ControlRequest {
+	req := createChannel(name, false)
+	ttlMs := uint64(ttl.Milliseconds())
+	req.GetCreateChannelRequest().TtlMs = &ttlMs
+	return req
+}
+
+// TestServer_ChannelExpiry tests the deletion of the channels whose time to
+// live elapsed
+func TestServer_ChannelExpiry(t *testing.T) {
+	t.Run("expire channel", func(t *testing.T) {
+		s, app := newTestServer()
+		stream, _ := watch(t, s, "")
+		before := time.Now()
+		require.True(t, command(t, s, createChannelWithTTL(testChannel, time.Minute)).Success)
+		require.True(t, command(t, s, createChannel("agntcy/otel/other", false)).Success)
+
+		resp, err := s.Command(t.Context(), getChannel(testChannel))
+		require.NoError(t, err)
+		expires := time.Unix(0, resp.GetGetChannelResponse().ExpiresUnixNano)
+		assert.WithinRange(t, expires, before.Add(time.Minute), time.Now().Add(time.Minute))
+
+		s.expireChannels(t.Context(), expires.Add(-time.Millisecond))
+		assert.NotNil(t, app.SessionByName(testChannel), "the channel has not expired yet")
+
+		s.expireChannels(t.Context(), expires)
+		assert.Nil(t, app.SessionByName(testChannel))
+		assert.NotNil(t, app.SessionByName("agntcy/otel/other"), "a channel without ttl does not expire")
+		assert.True(t, s.expiry.hasExpired(testChannel))
+		assert.Empty(t, s.expiry.due(expires.Add(time.Hour)))
+
+		for _, want := range []ChannelEvent_Type{
+			ChannelEvent_CHANNEL_CREATED,
+			ChannelEvent_CHANNEL_CREATED,
+			ChannelEvent_CHANNEL_DELETED,
+			ChannelEvent_CHANNEL_EXPIRED,
+		} {
+			assert.Equal(t, want, nextEvent(t, stream).Type)
+		}
+	})
+
+	t.Run("deleted channel", func(t *testing.T) {
+		s, _ := newTestServer()
+		require.True(t, command(t, s, createChannelWithTTL(testChannel, time.Minute)).Success)
+		require.True(t, command(t, s, deleteChannel(testChannel)).Success)
+
+		assert.True(t, s.expiry.deadline(testChannel).IsZero())
+		s.expireChannels(t.Context(), time.Now().Add(time.Hour))
+		assert.False(t, s.expiry.hasExpired(testChannel))
+	})
+
+	t.Run("recreated channel", func(t *testing.T) {
+		s, _ := newTestServer()
+		require.True(t, command(t, s, createChannelWithTTL(testChannel, time.Minute)).Success)
+		s.expireChannels(t.Context(), time.Now().Add(time.Hour))
+		require.True(t, s.expiry.hasExpired(testChannel))
+
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		assert.False(t, s.expiry.hasExpired(testChannel))
+		assert.True(t, s.expiry.deadline(testChannel).IsZero())
+	})
+
+	t.Run("configured channel", func(t *testing.T) {
+		app := testutil.NewFakeApp()
+		channels := []ChannelConfig{
+			{Name: testChannel, Participants: []string{testParticipant}, TTL: time.Minute},
+			{Name: "agntcy/otel/other", Participants: []string{testParticipant}},
+		}
+		s := NewChannelManagerServer(app, 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+			WithChannelTTLs(channels))
+		require.NoError(t, s.Reconcile(t.Context(), channels))
+		assert.False(t, s.expiry.deadline(testChannel).IsZero())
+		assert.True(t, s.expiry.deadline("agntcy/otel/other").IsZero())
+
+		s.expireChannels(t.Context(), time.Now().Add(time.Hour))
+		assert.Nil(t, app.SessionByName(testChannel))
+
+		// the reconciliation does not recreate the expired channel
+		require.NoError(t, s.Reconcile(t.Context(), channels))
+		assert.Nil(t, app.SessionByName(testChannel))
+		assert.NotNil(t, app.SessionByName("agntcy/otel/other"))
+	})
+
+	t.Run("invalid ttl", func(t *testing.T) {
+		s, _ := newTestServer()
+		req := createChannel(testChannel, false)
+		ttlMs := uint64(1) << 63
+		req.GetCreateChannelRequest().TtlMs = &ttlMs
+
+		resp := command(t, s, req)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "invalid time to live")
+	})
+}
+
+// TestServer_RestoreExpiry tests the restoration of the channels with a time
+// to live
+func TestServer_RestoreExpiry(t *testing.T) {
+	dir := t.TempDir()
+	_, _, store := newStateServer(dir)
+	expires := time.Now().Add(time.Hour)
+	require.NoError(t, store.Save([]ChannelState{
+		{Name: testChannel, ExpiresUnixNano: expires.UnixNano(), Participants: []string{}},
+		{Name: "agntcy/otel/expired", ExpiresUnixNano: time.Now().Add(-time.Minute).UnixNano(), Participants: []string{}},
+	}))
+
+	s, app, _ := newStateServer(dir)
+	require.NoError(t, s.Restore(t.Context()))
+
+	assert.NotNil(t, app.SessionByName(testChannel))
+	assert.Equal(t, expires.UnixNano(), s.expiry.deadline(testChannel).UnixNano())
+	assert.Nil(t, app.SessionByName("agntcy/otel/expired"), "the expired channel is dropped")
+
+	channels, err := store.Load()
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	assert.Equal(t, testChannel, channels[0].Name)
+}
+
+func TestChannelConfig_ValidateTTL(t *testing.T) {
+	cfg := ChannelConfig{Name: testChannel, Participants: []string{testParticipant}, TTL: -time.Second}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ttl cannot be negative")
+}
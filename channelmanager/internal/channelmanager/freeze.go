@@ -0,0 +1,84 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// handleFreezeChannel pauses the publications on a channel: the participants
+// are notified on the channel and stay in it. Freezing a frozen channel
+// notifies the participants again and updates the reason.
+func (s *Server) handleFreezeChannel(
+	ctx context.Context, msgID uint64, req *FreezeChannelRequest,
+) (*ControlResponse, error) {
+	return s.setFrozen(ctx, msgID, req.ChannelName, true, req.Reason)
+}
+
+// handleUnfreezeChannel resumes the publications on a frozen channel
+func (s *Server) handleUnfreezeChannel(
+	ctx context.Context, msgID uint64, req *UnfreezeChannelRequest,
+) (*ControlResponse, error) {
+	return s.setFrozen(ctx, msgID, req.ChannelName, false, "")
+}
+
+// setFrozen notifies the participants of a channel that it is frozen or
+// unfrozen, then records its new state. The state is unchanged if the
+// participants could not be notified.
+func (s *Server) setFrozen(
+	ctx context.Context, msgID uint64, channelName string, frozen bool, reason string,
+) (*ControlResponse, error) {
+	channel, err := slimcommon.SplitID(channelName)
+	if err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("invalid channel name: %s", channelName))
+	}
+
+	channelStr := channel.String()
+
+	session, err := s.channels.GetSessionByName(ctx, channelStr)
+	if err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("failed to get channel %s: %v", channelStr, err))
+	}
+
+	if err := notifyFreeze(session, frozen, reason); err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("failed to notify the participants of channel %s: %v", channelStr, err))
+	}
+	s.registry.setFrozen(channelStr, frozen, reason)
+
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	if frozen {
+		s.events.publish(ChannelEvent_CHANNEL_FROZEN, channelStr, "")
+		logger.Info("Froze channel", zap.String("channel", channelStr), zap.String("reason", reason))
+	} else {
+		s.events.publish(ChannelEvent_CHANNEL_UNFROZEN, channelStr, "")
+		logger.Info("Unfroze channel", zap.String("channel", channelStr))
+	}
+	return s.successResponse(msgID)
+}
+
+// notifyFreeze publishes the freeze notification of the channel
+func notifyFreeze(session slimcommon.Session, frozen bool, reason string) error {
+	payloadType := slimcommon.PayloadTypeFreeze
+	metadata := slimcommon.FreezeMetadata(frozen, reason)
+	return session.PublishAndWait([]byte{}, &payloadType, &metadata)
+}
+
+// notifyFrozen publishes the freeze notification of a frozen channel again,
+// so that the participant that just joined it does not publish on it. The
+// other participants ignore the notification of a channel already frozen.
+func (s *Server) notifyFrozen(ctx context.Context, session slimcommon.Session, channel string) {
+	activity := s.registry.activity(channel)
+	if activity.frozen.IsZero() {
+		return
+	}
+	if err := notifyFreeze(session, true, activity.freezeReason); err != nil {
+		slimcommon.LoggerFromContextOrDefault(ctx).Warn("Failed to notify the new participant of the frozen channel",
+			zap.String("channel", channel), zap.Error(err))
+	}
+}
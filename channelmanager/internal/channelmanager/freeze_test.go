@@ -0,0 +1,120 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+func freezeChannel(channel, reason string) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 13,
+		Payload: &ControlRequest_FreezeChannelRequest{
+			FreezeChannelRequest: &FreezeChannelRequest{ChannelName: channel, Reason: reason},
+		},
+	}
+}
+
+func unfreezeChannel(channel string) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 14,
+		Payload: &ControlRequest_UnfreezeChannelRequest{
+			UnfreezeChannelRequest: &UnfreezeChannelRequest{ChannelName: channel},
+		},
+	}
+}
+
+// TestServer_FreezeChannel tests the freeze and unfreeze of a channel
+func TestServer_FreezeChannel(t *testing.T) {
+	get := func(t *testing.T, s *Server) *GetChannelResponse {
+		t.Helper()
+		resp, err := s.Command(t.Context(), getChannel(testChannel))
+		require.NoError(t, err)
+		payload, ok := resp.Payload.(*ControlResponse_GetChannelResponse)
+		require.True(t, ok, "unexpected response payload %T", resp.Payload)
+		return payload.GetChannelResponse
+	}
+
+	t.Run("freeze and unfreeze", func(t *testing.T) {
+		s, app := newTestServer()
+		events, unsubscribe := s.events.subscribe()
+		defer unsubscribe()
+		require.True(t, command(t, s, createChannel(testChannel, true)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+
+		require.True(t, command(t, s, freezeChannel(testChannel, "INC-42")).Success)
+		session := app.SessionByName(testChannel)
+		published := session.PublishedMessages()
+		require.Len(t, published, 1)
+		assert.Equal(t, slimcommon.PayloadTypeFreeze, published[0].Context.PayloadType)
+		assert.Equal(t, slimcommon.FreezeMetadata(true, "INC-42"), published[0].Context.Metadata)
+
+		details := get(t, s)
+		assert.True(t, details.Frozen)
+		assert.Equal(t, "INC-42", details.FreezeReason)
+		assert.NotZero(t, details.FrozenUnixNano)
+		assert.Equal(t, []string{testParticipant}, session.Participants(), "the participants are kept")
+
+		require.True(t, command(t, s, unfreezeChannel(testChannel)).Success)
+		published = session.PublishedMessages()
+		require.Len(t, published, 2)
+		assert.Equal(t, slimcommon.FreezeMetadata(false, ""), published[1].Context.Metadata)
+		details = get(t, s)
+		assert.False(t, details.Frozen)
+		assert.Empty(t, details.FreezeReason)
+		assert.Zero(t, details.FrozenUnixNano)
+
+		var types []ChannelEvent_Type
+		for range 4 {
+			types = append(types, (<-events).Type)
+		}
+		assert.Equal(t, []ChannelEvent_Type{
+			ChannelEvent_CHANNEL_CREATED, ChannelEvent_PARTICIPANT_JOINED,
+			ChannelEvent_CHANNEL_FROZEN, ChannelEvent_CHANNEL_UNFROZEN,
+		}, types)
+	})
+
+	t.Run("new participants are notified", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, freezeChannel(testChannel, "")).Success)
+		frozenSince := get(t, s).FrozenUnixNano
+
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		published := app.SessionByName(testChannel).PublishedMessages()
+		require.Len(t, published, 2)
+		assert.Equal(t, slimcommon.FreezeMetadata(true, ""), published[1].Context.Metadata)
+
+		// the freeze survives the recreation of the session
+		mls := true
+		require.True(t, command(t, s, updateChannel(testChannel, []string{testParticipant}, &mls)).Success)
+		published = app.SessionByName(testChannel).PublishedMessages()
+		require.Len(t, published, 1)
+		assert.Equal(t, slimcommon.PayloadTypeFreeze, published[0].Context.PayloadType)
+		assert.Equal(t, frozenSince, get(t, s).FrozenUnixNano)
+	})
+
+	t.Run("notification fails", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		app.SessionByName(testChannel).PublishErr = errors.New("boom")
+
+		resp := command(t, s, freezeChannel(testChannel, ""))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "failed to notify the participants")
+		assert.False(t, get(t, s).Frozen)
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		s, _ := newTestServer()
+		assert.False(t, command(t, s, freezeChannel(testChannel, "")).Success)
+		assert.False(t, command(t, s, unfreezeChannel("invalid")).Success)
+	})
+}
@@ -0,0 +1,143 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+const (
+	// ReadinessService is the name of the health service reporting whether
+	// the channel manager can serve the commands, e.g. for a readiness
+	// probe. The ChannelManagerService name reports the same status, and the
+	// empty name the liveness of the process.
+	ReadinessService = "readiness"
+
+	// defaultHealthCheckInterval is the interval of the readiness checks
+	// when the configuration does not set it
+	defaultHealthCheckInterval = 10 * time.Second
+)
+
+// healthMethodPrefix is the prefix of the methods of the gRPC health
+// service, which the probes call without authentication
+var healthMethodPrefix = "/" + healthpb.Health_ServiceDesc.ServiceName + "/"
+
+// ReadinessCheck returns an error when the channel manager cannot serve the
+// commands
+type ReadinessCheck func(ctx context.Context) error
+
+// SLIMReadiness returns a check failing when the connection to the SLIM
+// endpoint at address is down, or when the app can no longer subscribe to
+// its name through the connection. Subscribing again to the name of the app
+// keeps its existing subscription.
+func SLIMReadiness(
+	connector slimcommon.Connector, address string, app slimcommon.App, name *slim.Name, connID uint64,
+) ReadinessCheck {
+	return func(context.Context) error {
+		if !connector.Connected(address) {
+			return fmt.Errorf("connection to the SLIM endpoint %s is down", address)
+		}
+		if err := app.Subscribe(name, connID); err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", name.String(), err)
+		}
+		return nil
+	}
+}
+
+// Health implements the standard grpc.health.v1.Health service. The process
+// is reported serving as soon as the service is registered, and ready once
+// all the readiness checks pass.
+type Health struct {
+	server *health.Server
+	checks []ReadinessCheck
+}
+
+// NewHealth creates the health service running checks, not ready until
+// the first checks pass
+func NewHealth(checks ...ReadinessCheck) *Health {
+	h := &Health{
+		server: health.NewServer(),
+		checks: checks,
+	}
+	h.setReady(false)
+	return h
+}
+
+// Register registers the health service on the gRPC server
+func (h *Health) Register(server *grpc.Server) {
+	healthpb.RegisterHealthServer(server, h.server)
+}
+
+// Serve runs the readiness checks now and then every interval, 10s if 0,
+// until ctx is done. The services are then reported not serving, so that
+// the probes stop routing traffic to the channel manager while it shuts down.
+func (h *Health) Serve(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// the status changes are logged, and the first failure
+	ready, checked := false, false
+	for {
+		err := h.check(ctx)
+		switch {
+		case err != nil && (ready || !checked):
+			logger.Warn("Channel manager not ready", zap.Error(err))
+		case err == nil && !ready:
+			logger.Info("Channel manager ready")
+		}
+		ready, checked = err == nil, true
+		h.setReady(ready)
+
+		select {
+		case <-ctx.Done():
+			h.server.Shutdown()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check runs all the readiness checks and joins their errors
+func (h *Health) check(ctx context.Context) error {
+	var errs []error
+	for _, check := range h.checks {
+		if err := check(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setReady sets the status of the readiness services
+func (h *Health) setReady(ready bool) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if ready {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	h.server.SetServingStatus(ReadinessService, status)
+	h.server.SetServingStatus(ChannelManagerService_ServiceDesc.ServiceName, status)
+}
+
+// isHealthMethod reports whether the full gRPC method name is a method of
+// the health service
+func isHealthMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, healthMethodPrefix)
+}
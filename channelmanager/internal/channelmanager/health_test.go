@@ -0,0 +1,105 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// healthStatus returns the status of the health service name
+func healthStatus(t *testing.T, h *Health, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := h.server.Check(t.Context(), &healthpb.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+	return resp.Status
+}
+
+func TestSLIMReadiness(t *testing.T) {
+	const address = "http://127.0.0.1:46357"
+	connector := testutil.NewFakeConnector()
+	connID, err := connector.Connect(slimconfig.ConnectionConfig{Address: address})
+	require.NoError(t, err)
+	app := testutil.NewFakeApp()
+	name, err := slimcommon.SplitID("agntcy/otel/channel-manager")
+	require.NoError(t, err)
+	check := SLIMReadiness(connector, address, app, name, connID)
+
+	require.NoError(t, check(t.Context()))
+	assert.Equal(t, []uint64{connID}, app.Subscriptions())
+
+	app.SubscribeErr = assert.AnError
+	require.ErrorIs(t, check(t.Context()), assert.AnError)
+
+	app.SubscribeErr = nil
+	connector.SetDown(address, true)
+	require.ErrorContains(t, check(t.Context()), "is down")
+}
+
+func TestHealth_Serve(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	h := NewHealth(func(context.Context) error {
+		if failing.Load() {
+			return assert.AnError
+		}
+		return nil
+	})
+
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, healthStatus(t, h, ""), "the process is alive")
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, healthStatus(t, h, ReadinessService))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		h.Serve(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	failing.Store(false)
+	require.Eventually(t, func() bool {
+		return healthStatus(t, h, ReadinessService) == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING,
+		healthStatus(t, h, ChannelManagerService_ServiceDesc.ServiceName))
+
+	failing.Store(true)
+	require.Eventually(t, func() bool {
+		return healthStatus(t, h, ReadinessService) == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, healthStatus(t, h, ""),
+		"the services are not serving after the shutdown")
+
+	_, err := h.server.Check(t.Context(), &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestTokenAuth_HealthChecks(t *testing.T) {
+	auth := tokenAuth("secret-token")
+
+	called := false
+	info := &grpc.UnaryServerInfo{FullMethod: healthpb.Health_Check_FullMethodName}
+	_, err := auth.unary(t.Context(), nil, info, func(context.Context, any) (any, error) {
+		called = true
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.True(t, called, "the probes do not send the token")
+}
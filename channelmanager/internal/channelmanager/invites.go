@@ -0,0 +1,336 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+const (
+	// defaultInviteAttempts is the number of attempts to invite a participant
+	// when the invite settings do not set it
+	defaultInviteAttempts = 10
+	// defaultInviteRetryInterval is the interval before the first retry of a
+	// failed invitation when the invite settings do not set it
+	defaultInviteRetryInterval = 5 * time.Second
+	// defaultInviteMaxRetryInterval bounds the interval between the retries
+	// of a failed invitation when the invite settings do not set it
+	defaultInviteMaxRetryInterval = 5 * time.Minute
+	// inviteCheckInterval is the period at which the failed invitations due
+	// for a retry are looked up
+	inviteCheckInterval = time.Second
+)
+
+// InviteSettings are the settings of the retries of the failed invitations,
+// made in the background after the command that added the participant failed
+type InviteSettings struct {
+	// Do not retry the failed invitations (optional)
+	DisableRetries bool `yaml:"disable-retries"`
+
+	// Number of attempts to invite a participant, including the first one,
+	// 10 if 0 (optional)
+	MaxAttempts int `yaml:"max-attempts"`
+
+	// Interval before the first retry, doubled after each failed retry, 5s
+	// if 0 (optional)
+	RetryInterval time.Duration `yaml:"retry-interval"`
+
+	// Maximum interval between two retries, 5m if 0 (optional)
+	MaxRetryInterval time.Duration `yaml:"max-retry-interval"`
+}
+
+// Validate checks if the invite settings are valid
+func (cfg *InviteSettings) Validate() error {
+	if cfg.MaxAttempts < 0 {
+		return errors.New("max attempts cannot be negative")
+	}
+	if cfg.RetryInterval < 0 {
+		return errors.New("retry interval cannot be negative")
+	}
+	if cfg.MaxRetryInterval < 0 {
+		return errors.New("max retry interval cannot be negative")
+	}
+	return nil
+}
+
+// attempts returns the number of attempts to invite a participant
+func (cfg *InviteSettings) attempts() int {
+	if cfg.MaxAttempts > 0 {
+		return cfg.MaxAttempts
+	}
+	return defaultInviteAttempts
+}
+
+// retryInterval returns the interval before the first retry of an invitation
+func (cfg *InviteSettings) retryInterval() time.Duration {
+	if cfg.RetryInterval > 0 {
+		return cfg.RetryInterval
+	}
+	return defaultInviteRetryInterval
+}
+
+// maxRetryInterval returns the maximum interval between two retries
+func (cfg *InviteSettings) maxRetryInterval() time.Duration {
+	if cfg.MaxRetryInterval > 0 {
+		return cfg.MaxRetryInterval
+	}
+	return defaultInviteMaxRetryInterval
+}
+
+// inviteEntry is the invitation of a participant to a channel
+type inviteEntry struct {
+	name     *slim.Name
+	state    ParticipantInvite_State
+	attempts int
+	err      error
+	// time of the next retry, zero if the invitation is not retried
+	nextRetry time.Time
+	// interval before the next retry
+	interval time.Duration
+}
+
+// dueInvite is a failed invitation due for a retry
+type dueInvite struct {
+	channel     string
+	participant *slim.Name
+}
+
+// inviteTracker tracks the invitations sent by the channel manager, by
+// channel and participant ID, so that the failed ones are retried instead of
+// being forgotten
+type inviteTracker struct {
+	mutex    sync.Mutex
+	channels map[string]map[string]*inviteEntry
+}
+
+// newInviteTracker creates an inviteTracker without any invitation
+func newInviteTracker() *inviteTracker {
+	return &inviteTracker{channels: make(map[string]map[string]*inviteEntry)}
+}
+
+// entry returns the invitation of participant to channel, created if needed.
+// It must be called with the mutex held.
+func (t *inviteTracker) entry(channel string, participant *slim.Name) *inviteEntry {
+	invites, ok := t.channels[channel]
+	if !ok {
+		invites = make(map[string]*inviteEntry)
+		t.channels[channel] = invites
+	}
+	id := slimcommon.JoinID(participant)
+	entry, ok := invites[id]
+	if !ok {
+		entry = &inviteEntry{name: participant}
+		invites[id] = entry
+	}
+	return entry
+}
+
+// start records a new invitation of participant to channel, resetting the
+// attempts of a previous one
+func (t *inviteTracker) start(channel string, participant *slim.Name) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	*t.entry(channel, participant) = inviteEntry{
+		name:     participant,
+		state:    ParticipantInvite_INVITE_PENDING,
+		attempts: 1,
+	}
+}
+
+// retrying records a new attempt of a failed invitation
+func (t *inviteTracker) retrying(channel string, participant *slim.Name) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	entry := t.entry(channel, participant)
+	entry.state = ParticipantInvite_INVITE_PENDING
+	entry.attempts++
+	entry.nextRetry = time.Time{}
+}
+
+// joined records that participant accepted the invitation to channel
+func (t *inviteTracker) joined(channel string, participant *slim.Name) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	entry := t.entry(channel, participant)
+	entry.state = ParticipantInvite_INVITED
+	entry.err, entry.nextRetry, entry.interval = nil, time.Time{}, 0
+}
+
+// failed records that the invitation of participant to channel failed with
+// err and schedules its retry as configured by settings. It reports whether
+// the invitation is retried.
+func (t *inviteTracker) failed(channel string, participant *slim.Name, err error, settings InviteSettings) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	entry := t.entry(channel, participant)
+	entry.state = ParticipantInvite_INVITE_FAILED
+	entry.err = err
+	entry.nextRetry = time.Time{}
+	if settings.DisableRetries || entry.attempts >= settings.attempts() {
+		return false
+	}
+	if entry.interval == 0 {
+		entry.interval = settings.retryInterval()
+	} else {
+		entry.interval = min(2*entry.interval, settings.maxRetryInterval())
+	}
+	entry.nextRetry = time.Now().Add(entry.interval)
+	return true
+}
+
+// forget forgets the invitation of the participant identified by id to
+// channel, and reports whether it had failed
+func (t *inviteTracker) forget(channel, id string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	entry, ok := t.channels[channel][id]
+	if !ok {
+		return false
+	}
+	delete(t.channels[channel], id)
+	return entry.state == ParticipantInvite_INVITE_FAILED
+}
+
+// forgetParticipant forgets the invitations of the participant identified by
+// id to all the channels
+func (t *inviteTracker) forgetParticipant(id string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, invites := range t.channels {
+		delete(invites, id)
+	}
+}
+
+// forgetChannel forgets the invitations to a deleted channel
+func (t *inviteTracker) forgetChannel(channel string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.channels, channel)
+}
+
+// failedNames returns the participants whose invitation to channel failed,
+// sorted by ID
+func (t *inviteTracker) failedNames(channel string) []*slim.Name {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	invites := t.channels[channel]
+	names := make([]*slim.Name, 0)
+	for _, id := range slices.Sorted(maps.Keys(invites)) {
+		if invites[id].state == ParticipantInvite_INVITE_FAILED {
+			names = append(names, invites[id].name)
+		}
+	}
+	return names
+}
+
+// list returns the invitations to channel sorted by participant ID
+func (t *inviteTracker) list(channel string) []*ParticipantInvite {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	invites := t.channels[channel]
+	result := make([]*ParticipantInvite, 0, len(invites))
+	for _, id := range slices.Sorted(maps.Keys(invites)) {
+		entry := invites[id]
+		invite := &ParticipantInvite{
+			ParticipantName:   entry.name.String(),
+			State:             entry.state,
+			Attempts:          uint32(entry.attempts), //nolint:gosec // bounded by the invite settings
+			NextRetryUnixNano: unixNano(entry.nextRetry),
+		}
+		if entry.err != nil {
+			invite.Error = entry.err.Error()
+		}
+		result = append(result, invite)
+	}
+	return result
+}
+
+// due returns the failed invitations whose retry is due at now, sorted by
+// channel and participant
+func (t *inviteTracker) due(now time.Time) []dueInvite {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	invites := make([]dueInvite, 0)
+	for _, channel := range slices.Sorted(maps.Keys(t.channels)) {
+		for _, id := range slices.Sorted(maps.Keys(t.channels[channel])) {
+			entry := t.channels[channel][id]
+			if entry.state == ParticipantInvite_INVITE_FAILED && !entry.nextRetry.IsZero() &&
+				!now.Before(entry.nextRetry) {
+				invites = append(invites, dueInvite{channel: channel, participant: entry.name})
+			}
+		}
+	}
+	return invites
+}
+
+// WithInviteSettings sets how the failed invitations are retried
+func WithInviteSettings(settings InviteSettings) ServerOption {
+	return func(s *Server) {
+		s.inviteSettings = settings
+	}
+}
+
+// ServeInviteRetries retries the failed invitations as they become due, until
+// ctx is done
+func (s *Server) ServeInviteRetries(ctx context.Context) {
+	ticker := time.NewTicker(inviteCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.retryInvites(ctx, now)
+		}
+	}
+}
+
+// retryInvites retries the failed invitations due at now. The invitations to
+// the deleted channels are forgotten, and those of the participants that
+// joined in the meantime are completed.
+func (s *Server) retryInvites(ctx context.Context, now time.Time) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	for _, due := range s.invites.due(now) {
+		participantID := slimcommon.JoinID(due.participant)
+		session, err := s.channels.GetSessionByName(ctx, due.channel)
+		if err != nil {
+			s.invites.forget(due.channel, participantID)
+			continue
+		}
+		channel, err := slimcommon.SplitID(due.channel)
+		if err != nil {
+			s.invites.forget(due.channel, participantID)
+			continue
+		}
+
+		member, err := hasParticipant(session, participantID)
+		if err != nil {
+			logger.Warn("Failed to list the channel participants", zap.String("channel", due.channel), zap.Error(err))
+			continue
+		}
+		if member {
+			s.invites.joined(due.channel, due.participant)
+			continue
+		}
+
+		s.invites.retrying(due.channel, due.participant)
+		if err := s.sendInvite(ctx, session, channel, due.participant); err != nil {
+			continue
+		}
+		s.saveState(ctx, s.state.addParticipant(slimcommon.JoinID(channel), participantID))
+		logger.Info("Participant added after a retry",
+			zap.String("channel", due.channel),
+			zap.String("participant", participantID))
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// newInviteServer creates a Server backed by a fake SLIM app retrying the
+// failed invitations as configured by settings
+func newInviteServer(settings InviteSettings) (*Server, *testutil.FakeApp) {
+	app := testutil.NewFakeApp()
+	s := NewChannelManagerServer(app, 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		WithInviteSettings(settings))
+	return s, app
+}
+
+// listInvites returns the invitations reported by ListParticipants
+func listInvites(t *testing.T, s *Server, channel string) []*ParticipantInvite {
+	t.Helper()
+	resp, err := s.Command(t.Context(), listParticipants(channel))
+	require.NoError(t, err)
+	payload, ok := resp.Payload.(*ControlResponse_ListParticipantsResponse)
+	require.True(t, ok, "unexpected response payload %T", resp.Payload)
+	return payload.ListParticipantsResponse.Invite
+}
+
+// TestServer_InviteRetries tests the tracking and the retries of the
+// invitations
+func TestServer_InviteRetries(t *testing.T) {
+	t.Run("retry failed invitation", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		session := app.SessionByName(testChannel)
+		session.InviteErr = assert.AnError
+
+		before := time.Now()
+		resp := command(t, s, addParticipant(testChannel, testParticipant))
+		assert.False(t, resp.Success)
+		invites := listInvites(t, s, testChannel)
+		require.Len(t, invites, 1)
+		assert.Equal(t, testParticipant, invites[0].ParticipantName)
+		assert.Equal(t, ParticipantInvite_INVITE_FAILED, invites[0].State)
+		assert.Equal(t, uint32(1), invites[0].Attempts)
+		assert.Contains(t, invites[0].Error, assert.AnError.Error())
+		nextRetry := time.Unix(0, invites[0].NextRetryUnixNano)
+		assert.WithinRange(t, nextRetry, before.Add(defaultInviteRetryInterval),
+			time.Now().Add(defaultInviteRetryInterval))
+
+		// nothing is retried before the retry is due
+		s.retryInvites(t.Context(), nextRetry.Add(-time.Millisecond))
+		assert.Equal(t, uint32(1), listInvites(t, s, testChannel)[0].Attempts)
+
+		session.InviteErr = nil
+		s.retryInvites(t.Context(), nextRetry)
+		assert.Equal(t, []string{testParticipant}, session.Participants())
+		invites = listInvites(t, s, testChannel)
+		require.Len(t, invites, 1)
+		assert.Equal(t, ParticipantInvite_INVITED, invites[0].State)
+		assert.Equal(t, uint32(2), invites[0].Attempts)
+		assert.Empty(t, invites[0].Error)
+		assert.Zero(t, invites[0].NextRetryUnixNano)
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		s, app := newInviteServer(InviteSettings{
+			MaxAttempts:      4,
+			RetryInterval:    time.Second,
+			MaxRetryInterval: 3 * time.Second,
+		})
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		app.SessionByName(testChannel).InviteErr = assert.AnError
+		require.False(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+
+		for _, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+			invite := listInvites(t, s, testChannel)[0]
+			nextRetry := time.Unix(0, invite.NextRetryUnixNano)
+			assert.WithinDuration(t, time.Now().Add(want), nextRetry, 500*time.Millisecond)
+			s.retryInvites(t.Context(), nextRetry)
+		}
+
+		// all the attempts failed
+		invite := listInvites(t, s, testChannel)[0]
+		assert.Equal(t, ParticipantInvite_INVITE_FAILED, invite.State)
+		assert.Equal(t, uint32(4), invite.Attempts)
+		assert.Zero(t, invite.NextRetryUnixNano)
+		assert.Empty(t, s.invites.due(time.Now().Add(time.Hour)))
+	})
+
+	t.Run("retries disabled", func(t *testing.T) {
+		s, app := newInviteServer(InviteSettings{DisableRetries: true})
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		app.SessionByName(testChannel).InviteErr = assert.AnError
+		require.False(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+
+		invite := listInvites(t, s, testChannel)[0]
+		assert.Equal(t, ParticipantInvite_INVITE_FAILED, invite.State)
+		assert.Zero(t, invite.NextRetryUnixNano)
+	})
+
+	t.Run("withdraw failed invitation", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		session := app.SessionByName(testChannel)
+		session.InviteErr = assert.AnError
+		require.False(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+
+		require.True(t, command(t, s, deleteParticipant(testChannel, testParticipant)).Success)
+		assert.Empty(t, listInvites(t, s, testChannel))
+
+		session.InviteErr = nil
+		s.retryInvites(t.Context(), time.Now().Add(time.Hour))
+		assert.Empty(t, session.Participants())
+	})
+
+	t.Run("participant joined in the meantime", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		session := app.SessionByName(testChannel)
+		session.InviteErr = assert.AnError
+		require.False(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+
+		session.InviteErr = nil
+		name, err := slimcommon.SplitID(testParticipant)
+		require.NoError(t, err)
+		require.NoError(t, session.InviteAndWait(name))
+
+		s.retryInvites(t.Context(), time.Now().Add(time.Hour))
+		invite := listInvites(t, s, testChannel)[0]
+		assert.Equal(t, ParticipantInvite_INVITED, invite.State)
+		assert.Equal(t, uint32(1), invite.Attempts, "the participant is not invited again")
+	})
+
+	t.Run("deleted channel", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		app.SessionByName(testChannel).InviteErr = assert.AnError
+		require.False(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+
+		require.True(t, command(t, s, deleteChannel(testChannel)).Success)
+		assert.Empty(t, s.invites.due(time.Now().Add(time.Hour)))
+	})
+
+	t.Run("persisted channel", func(t *testing.T) {
+		s, app, store := newStateServer(t.TempDir())
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		session := app.SessionByName(testChannel)
+		session.InviteErr = assert.AnError
+		require.False(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+
+		session.InviteErr = nil
+		s.retryInvites(t.Context(), time.Now().Add(time.Hour))
+		channels, err := store.Load()
+		require.NoError(t, err)
+		require.Len(t, channels, 1)
+		assert.Equal(t, []string{testParticipant}, channels[0].Participants)
+	})
+}
+
+func TestInviteSettings_Validate(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		settings InviteSettings
+		err      string
+	}{
+		{name: "defaults"},
+		{name: "negative attempts", settings: InviteSettings{MaxAttempts: -1}, err: "max attempts cannot be negative"},
+		{
+			name:     "negative interval",
+			settings: InviteSettings{RetryInterval: -time.Second},
+			err:      "retry interval cannot be negative",
+		},
+		{
+			name:     "negative max interval",
+			settings: InviteSettings{MaxRetryInterval: -time.Second},
+			err:      "max retry interval cannot be negative",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.settings.Validate()
+			if tt.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
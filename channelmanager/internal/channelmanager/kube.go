@@ -0,0 +1,217 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	// SlimChannelGroup is the API group of the SlimChannel resources
+	SlimChannelGroup = "slim.agntcy.org"
+	// SlimChannelVersion is the API version of the SlimChannel resources
+	SlimChannelVersion = "v1alpha1"
+	// slimChannelResource is the plural name of the SlimChannel resources
+	slimChannelResource = "slimchannels"
+
+	// serviceAccountDir holds the token and the CA certificate of the
+	// service account of the pod
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+	// maxErrorBody is the maximum size of the body of an error response
+	// reported in the error
+	maxErrorBody = 1024
+)
+
+// SlimChannelClient lists and watches the SlimChannel resources and writes
+// their status
+type SlimChannelClient interface {
+	// List returns the SlimChannel resources
+	List(ctx context.Context) (*SlimChannelList, error)
+
+	// Watch passes the changes of the resources after resourceVersion to
+	// handle until it returns true, the watch ends or ctx is done
+	Watch(ctx context.Context, resourceVersion string, handle func(SlimChannelEvent) bool) error
+
+	// UpdateStatus replaces the status of a resource with its Status
+	UpdateStatus(ctx context.Context, channel *SlimChannel) error
+}
+
+// KubeClient is a SlimChannelClient calling the REST API of the Kubernetes
+// API server
+type KubeClient struct {
+	baseURL    string
+	namespace  string
+	tokenFile  string
+	httpClient *http.Client
+}
+
+// NewKubeClient creates a KubeClient for the operator configuration. Without
+// API server, the in-cluster API server is called with the credentials of
+// the service account of the pod.
+func NewKubeClient(cfg *OperatorConfig) (*KubeClient, error) {
+	server, tokenFile, caFile := cfg.APIServer, cfg.TokenFile, cfg.CAFile
+	if server == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, errors.New("no API server configured and not running in a Kubernetes cluster")
+		}
+		server = "https://" + net.JoinHostPort(host, port)
+		if tokenFile == "" {
+			tokenFile = path.Join(serviceAccountDir, "token")
+		}
+		if caFile == "" {
+			caFile = path.Join(serviceAccountDir, "ca.crt")
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificate found in %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &KubeClient{
+		baseURL:    strings.TrimSuffix(server, "/"),
+		namespace:  cfg.Namespace,
+		tokenFile:  tokenFile,
+		httpClient: &http.Client{Transport: transport},
+	}, nil
+}
+
+// resourcePath returns the path of the SlimChannel resources of namespace,
+// of all the namespaces if empty
+func resourcePath(namespace string) string {
+	p := "/apis/" + SlimChannelGroup + "/" + SlimChannelVersion
+	if namespace != "" {
+		p += "/namespaces/" + url.PathEscape(namespace)
+	}
+	return p + "/" + slimChannelResource
+}
+
+// List returns the SlimChannel resources of the namespace of the client
+func (c *KubeClient) List(ctx context.Context) (*SlimChannelList, error) {
+	resp, err := c.do(ctx, http.MethodGet, resourcePath(c.namespace), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	list := &SlimChannelList{}
+	if err := json.NewDecoder(resp.Body).Decode(list); err != nil {
+		return nil, fmt.Errorf("failed to decode the SlimChannel list: %w", err)
+	}
+	return list, nil
+}
+
+// Watch passes the changes of the SlimChannel resources of the namespace of
+// the client to handle
+func (c *KubeClient) Watch(ctx context.Context, resourceVersion string, handle func(SlimChannelEvent) bool) error {
+	query := url.Values{"watch": {"true"}, "allowWatchBookmarks": {"true"}}
+	if resourceVersion != "" {
+		query.Set("resourceVersion", resourceVersion)
+	}
+	resp, err := c.do(ctx, http.MethodGet, resourcePath(c.namespace)+"?"+query.Encode(), "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event struct {
+			Type   string          `json:"type"`
+			Object json.RawMessage `json:"object"`
+		}
+		if err := decoder.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to decode the SlimChannel watch event: %w", err)
+		}
+
+		decoded := SlimChannelEvent{Type: event.Type}
+		if event.Type == WatchEventError {
+			// the object is a Status, e.g. when the resource version expired
+			var status struct {
+				Message string `json:"message"`
+			}
+			_ = json.Unmarshal(event.Object, &status)
+			return fmt.Errorf("SlimChannel watch failed: %s", status.Message)
+		}
+		if err := json.Unmarshal(event.Object, &decoded.Object); err != nil {
+			return fmt.Errorf("failed to decode the SlimChannel of the watch event: %w", err)
+		}
+		if handle(decoded) {
+			return nil
+		}
+	}
+}
+
+// UpdateStatus replaces the status of a SlimChannel with a merge patch of
+// its status subresource
+func (c *KubeClient) UpdateStatus(ctx context.Context, channel *SlimChannel) error {
+	body, err := json.Marshal(map[string]any{"status": channel.Status})
+	if err != nil {
+		return fmt.Errorf("failed to encode the SlimChannel status: %w", err)
+	}
+	p := resourcePath(channel.Metadata.Namespace) + "/" + url.PathEscape(channel.Metadata.Name) + "/status"
+	resp, err := c.do(ctx, http.MethodPatch, p, "application/merge-patch+json", body)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// do sends a request to the API server, with the token of the token file
+// if any, and returns the response if successful
+func (c *KubeClient) do(ctx context.Context, method, p, contentType string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+p, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	// the token is read on every request, the projected tokens are rotated
+	if c.tokenFile != "" {
+		token, readErr := os.ReadFile(c.tokenFile)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read the token: %w", readErr)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("%s %s: %s: %s", method, p, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,95 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubeClient(t *testing.T) {
+	const collection = "/apis/slim.agntcy.org/v1alpha1/namespaces/observability/slimchannels"
+
+	var patch []byte
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+collection, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		if r.URL.Query().Get("watch") == "true" {
+			assert.Equal(t, "42", r.URL.Query().Get("resourceVersion"))
+			enc := json.NewEncoder(w)
+			_ = enc.Encode(map[string]any{"type": WatchEventBookmark, "object": map[string]any{}})
+			_ = enc.Encode(map[string]any{"type": WatchEventDeleted, "object": slimChannel("traces", testChannel)})
+			return
+		}
+		_, _ = io.WriteString(w, `{"metadata":{"resourceVersion":"42"},"items":[{
+			"metadata":{"name":"traces","namespace":"observability","generation":3},
+			"spec":{"channel":"agntcy/otel/channel","participants":["agntcy/otel/receiver"],
+				"session":{"maxRetries":5,"interval":"500ms"}}}]}`)
+	})
+	mux.HandleFunc("PATCH "+collection+"/traces/status", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/merge-patch+json", r.Header.Get("Content-Type"))
+		patch, _ = io.ReadAll(r.Body)
+		_, _ = io.WriteString(w, "{}")
+	})
+	mux.HandleFunc("PATCH "+collection+"/missing/status", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"message":"slimchannels.slim.agntcy.org \"missing\" not found"}`, http.StatusNotFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("test-token\n"), 0o600))
+	client, err := NewKubeClient(&OperatorConfig{
+		Namespace: "observability",
+		APIServer: server.URL,
+		TokenFile: tokenFile,
+	})
+	require.NoError(t, err)
+
+	list, err := client.List(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "42", list.Metadata.ResourceVersion)
+	require.Len(t, list.Items, 1)
+	resource := list.Items[0]
+	assert.Equal(t, int64(3), resource.Metadata.Generation)
+	cfg, err := resource.channelConfig()
+	require.NoError(t, err)
+	assert.Equal(t, testChannel, cfg.Name)
+	assert.Equal(t, uint32(5), *cfg.Session.MaxRetries)
+	assert.Equal(t, "500ms", cfg.Session.Interval.String())
+
+	var events []string
+	require.NoError(t, client.Watch(t.Context(), "42", func(event SlimChannelEvent) bool {
+		events = append(events, event.Type)
+		return false
+	}))
+	assert.Equal(t, []string{WatchEventBookmark, WatchEventDeleted}, events)
+
+	resource.setReady(notReady(ReasonInvalidSpec, "invalid"))
+	require.NoError(t, client.UpdateStatus(t.Context(), &resource))
+	var body struct {
+		Status SlimChannelStatus `json:"status"`
+	}
+	require.NoError(t, json.Unmarshal(patch, &body))
+	assert.Equal(t, resource.Status, body.Status)
+
+	resource.Metadata.Name = "missing"
+	err = client.UpdateStatus(t.Context(), &resource)
+	require.ErrorContains(t, err, "404 Not Found")
+	assert.ErrorContains(t, err, `\"missing\" not found`)
+}
+
+func TestNewKubeClient_NotInCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	_, err := NewKubeClient(&OperatorConfig{})
+	require.ErrorContains(t, err, "not running in a Kubernetes cluster")
+}
@@ -0,0 +1,86 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// leaveCheckInterval is the period at which the channels without a reader
+// of the leave requests are looked up
+const leaveCheckInterval = time.Second
+
+// ServeLeaveRequests removes the participants that request to leave a
+// channel, e.g. a receiver draining itself before it shuts down. It reads
+// the messages of every channel until ctx is done, discarding the messages
+// that are not leave requests. The app must be able to receive messages.
+func (s *Server) ServeLeaveRequests(ctx context.Context) {
+	served := make(map[string]struct{})
+	done := make(chan string)
+
+	ticker := time.NewTicker(leaveCheckInterval)
+	defer ticker.Stop()
+	for {
+		for _, channelStr := range s.channels.ListSessionNames(ctx) {
+			if _, ok := served[channelStr]; ok {
+				continue
+			}
+			session, err := s.channels.GetSessionByName(ctx, channelStr)
+			if err != nil {
+				// the channel was deleted in the meantime
+				continue
+			}
+			served[channelStr] = struct{}{}
+			go func() {
+				s.serveLeaveRequests(ctx, session)
+				select {
+				case done <- channelStr:
+				case <-ctx.Done():
+				}
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case channelStr := <-done:
+			delete(served, channelStr)
+		case <-ticker.C:
+		}
+	}
+}
+
+// serveLeaveRequests removes the senders of the leave requests published on
+// the channel of session, until the session is closed or ctx is done
+func (s *Server) serveLeaveRequests(ctx context.Context, session slimcommon.Session) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	channel, err := session.Destination()
+	if err != nil {
+		logger.Warn("Failed to get the channel name", zap.Error(err))
+		return
+	}
+
+	for msg := range slimcommon.ReceiveMessages(ctx, session) {
+		s.registry.touch(channel.String())
+
+		if msg.Context.PayloadType != slimcommon.PayloadTypeLeave || msg.Context.SourceName == nil {
+			continue
+		}
+
+		participant := msg.Context.SourceName
+		if err := s.removeParticipant(ctx, session, channel, participant); err != nil {
+			logger.Warn("Failed to remove the leaving participant", zap.Error(err))
+			continue
+		}
+		logger.Info("Participant left",
+			zap.String("channel", channel.String()),
+			zap.String("participant", slimcommon.JoinID(participant)))
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+func leaveRequest(t *testing.T, participant string) slim.ReceivedMessage {
+	t.Helper()
+	name, err := slimcommon.SplitID(participant)
+	require.NoError(t, err)
+
+	msg := slim.ReceivedMessage{}
+	msg.Context.PayloadType = slimcommon.PayloadTypeLeave
+	msg.Context.SourceName = name
+	return msg
+}
+
+// TestServer_ServeLeaveRequests tests the removal of the participants that
+// request to leave a channel
+func TestServer_ServeLeaveRequests(t *testing.T) {
+	const exporter = "agntcy/otel/exporter"
+
+	s, app, store := newStateServer(t.TempDir())
+	require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+	require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+	require.True(t, command(t, s, addParticipant(testChannel, exporter)).Success)
+	session := app.SessionByName(testChannel)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		s.ServeLeaveRequests(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// the other messages are discarded
+	session.Deliver([]byte("telemetry"))
+	session.DeliverMessage(leaveRequest(t, testParticipant))
+
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{exporter}, session.Participants())
+	}, 5*time.Second, 10*time.Millisecond)
+
+	states, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []ChannelState{{Name: testChannel, Participants: []string{exporter}}}, states)
+
+	// the channels created later are served too
+	const otherChannel = "agntcy/otel/other-channel"
+	require.True(t, command(t, s, createChannel(otherChannel, false)).Success)
+	require.True(t, command(t, s, addParticipant(otherChannel, testParticipant)).Success)
+	app.SessionByName(otherChannel).DeliverMessage(leaveRequest(t, testParticipant))
+
+	assert.Eventually(t, func() bool {
+		return len(app.SessionByName(otherChannel).Participants()) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
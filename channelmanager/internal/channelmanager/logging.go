@@ -0,0 +1,154 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// defaultSamplingInitial and defaultSamplingThereafter are the sampling
+	// rates of the zap production logger
+	defaultSamplingInitial    = 100
+	defaultSamplingThereafter = 100
+	// defaultSamplingTick is the period of the sampling counters
+	defaultSamplingTick = time.Second
+
+	// LogLevelPath is the path of the admin endpoint changing the log level
+	LogLevelPath = "/log/level"
+)
+
+// LoggingConfig defines the logger of the channel manager
+type LoggingConfig struct {
+	// Minimum level of the logged entries: debug, info, warn or error, info
+	// if empty (optional)
+	Level string `yaml:"level"`
+
+	// Encoding of the entries: json or console, json if empty (optional)
+	Encoding string `yaml:"encoding"`
+
+	// Sampling of the repeated entries (optional)
+	Sampling SamplingConfig `yaml:"sampling"`
+}
+
+// SamplingConfig throttles the entries logged with the same level and
+// message: every tick, the first Initial entries are logged, then one every
+// Thereafter entries
+type SamplingConfig struct {
+	// Log all the entries (optional)
+	Disabled bool `yaml:"disabled"`
+
+	// Number of entries logged each tick before sampling, 100 if 0 (optional)
+	Initial int `yaml:"initial"`
+
+	// Sampling rate after the initial entries, 100 if 0 (optional)
+	Thereafter int `yaml:"thereafter"`
+
+	// Period of the sampling, 1s if 0 (optional)
+	Tick time.Duration `yaml:"tick"`
+}
+
+// Validate checks if the logging configuration is valid
+func (cfg *LoggingConfig) Validate() error {
+	if _, err := cfg.level(); err != nil {
+		return err
+	}
+
+	switch cfg.Encoding {
+	case "", "json", "console":
+	default:
+		return fmt.Errorf("invalid log encoding %q, must be json or console", cfg.Encoding)
+	}
+
+	if cfg.Sampling.Initial < 0 || cfg.Sampling.Thereafter < 0 {
+		return errors.New("log sampling rates cannot be negative")
+	}
+	if cfg.Sampling.Tick < 0 {
+		return errors.New("log sampling tick cannot be negative")
+	}
+	return nil
+}
+
+// level returns the configured log level
+func (cfg *LoggingConfig) level() (zapcore.Level, error) {
+	if cfg.Level == "" {
+		return zapcore.InfoLevel, nil
+	}
+	level, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return level, fmt.Errorf("invalid log level: %w", err)
+	}
+	return level, nil
+}
+
+// NewLogger builds the logger of the configuration. The returned level
+// changes the level of the logger while it runs, see LevelHandler.
+func (cfg *LoggingConfig) NewLogger() (*zap.Logger, zap.AtomicLevel, error) {
+	level, err := cfg.level()
+	if err != nil {
+		return nil, zap.AtomicLevel{}, err
+	}
+
+	zapConfig := zap.NewProductionConfig()
+	zapConfig.Level = zap.NewAtomicLevelAt(level)
+	if cfg.Encoding != "" {
+		zapConfig.Encoding = cfg.Encoding
+	}
+	if cfg.Encoding == "console" {
+		zapConfig.EncoderConfig = zap.NewDevelopmentEncoderConfig()
+	}
+
+	// the sampler of the zap config has a fixed tick, it is set as an option
+	zapConfig.Sampling = nil
+	var opts []zap.Option
+	if !cfg.Sampling.Disabled {
+		initial, thereafter, tick := cfg.sampling()
+		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewSamplerWithOptions(core, tick, initial, thereafter)
+		}))
+	}
+
+	logger, err := zapConfig.Build(opts...)
+	if err != nil {
+		return nil, zap.AtomicLevel{}, fmt.Errorf("failed to build the logger: %w", err)
+	}
+	return logger, zapConfig.Level, nil
+}
+
+// sampling returns the sampling rates and tick, with the defaults applied
+func (cfg *LoggingConfig) sampling() (int, int, time.Duration) {
+	initial, thereafter, tick := cfg.Sampling.Initial, cfg.Sampling.Thereafter, cfg.Sampling.Tick
+	if initial == 0 {
+		initial = defaultSamplingInitial
+	}
+	if thereafter == 0 {
+		thereafter = defaultSamplingThereafter
+	}
+	if tick == 0 {
+		tick = defaultSamplingTick
+	}
+	return initial, thereafter, tick
+}
+
+// LevelHandler serves the log level: GET returns it and PUT changes it, e.g.
+// with the body {"level":"debug"}, to debug an incident without a restart.
+// The changes are logged with logger.
+func LevelHandler(level zap.AtomicLevel, logger *zap.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		previous := level.Level()
+		level.ServeHTTP(w, r)
+		if current := level.Level(); current != previous {
+			logger.Warn("Log level changed",
+				zap.Stringer("previous", previous),
+				zap.Stringer("level", current),
+				zap.String("remote_address", r.RemoteAddr))
+		}
+	})
+}
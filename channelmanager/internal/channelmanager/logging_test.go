@@ -0,0 +1,106 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggingConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   LoggingConfig
+		errorMsg string
+	}{
+		{name: "defaults"},
+		{name: "debug console", config: LoggingConfig{Level: "debug", Encoding: "console"}},
+		{name: "invalid level", config: LoggingConfig{Level: "verbose"}, errorMsg: "invalid log level"},
+		{name: "invalid encoding", config: LoggingConfig{Encoding: "logfmt"}, errorMsg: `invalid log encoding "logfmt"`},
+		{
+			name:     "negative sampling rate",
+			config:   LoggingConfig{Sampling: SamplingConfig{Thereafter: -1}},
+			errorMsg: "log sampling rates cannot be negative",
+		},
+		{
+			name:     "negative sampling tick",
+			config:   LoggingConfig{Sampling: SamplingConfig{Tick: -time.Second}},
+			errorMsg: "log sampling tick cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLoggingConfig_NewLogger(t *testing.T) {
+	cfg := LoggingConfig{Level: "warn", Encoding: "console", Sampling: SamplingConfig{Disabled: true}}
+	logger, level, err := cfg.NewLogger()
+	require.NoError(t, err)
+	assert.Equal(t, zapcore.WarnLevel, level.Level())
+	assert.False(t, logger.Core().Enabled(zapcore.InfoLevel))
+
+	// the level changes the level of the running logger
+	level.SetLevel(zapcore.DebugLevel)
+	assert.True(t, logger.Core().Enabled(zapcore.DebugLevel))
+
+	_, _, err = (&LoggingConfig{Level: "verbose"}).NewLogger()
+	require.Error(t, err)
+}
+
+func TestLoggingConfig_Sampling(t *testing.T) {
+	initial, thereafter, tick := (&LoggingConfig{}).sampling()
+	assert.Equal(t, 100, initial)
+	assert.Equal(t, 100, thereafter)
+	assert.Equal(t, time.Second, tick)
+
+	cfg := LoggingConfig{Sampling: SamplingConfig{Initial: 10, Thereafter: 1000, Tick: time.Minute}}
+	initial, thereafter, tick = cfg.sampling()
+	assert.Equal(t, 10, initial)
+	assert.Equal(t, 1000, thereafter)
+	assert.Equal(t, time.Minute, tick)
+}
+
+func TestLevelHandler(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	handler := LevelHandler(level, zap.New(core))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, LogLevelPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"level":"info"}`, rec.Body.String())
+	assert.Zero(t, logs.Len())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, LogLevelPath, strings.NewReader(`{"level":"debug"}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "Log level changed", logs.All()[0].Message)
+	assert.Equal(t, "info", logs.All()[0].ContextMap()["previous"])
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, LogLevelPath, strings.NewReader(`{"level":"verbose"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+}
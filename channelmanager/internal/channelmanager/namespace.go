@@ -0,0 +1,186 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// RoleAdmin allows every command on the channels of a namespace
+	RoleAdmin = "admin"
+	// RoleViewer allows the commands that only read the channels of a
+	// namespace: list and get
+	RoleViewer = "viewer"
+)
+
+// NamespaceSettings are the quotas and policies of the channels of a
+// namespace. The unset settings fall back to the manager namespace defaults.
+type NamespaceSettings struct {
+	// Maximum number of channels in the namespace, no limit if 0 (optional)
+	MaxChannels int `yaml:"max-channels"`
+
+	// Maximum number of participants of each channel of the namespace, no
+	// limit if 0 (optional)
+	MaxParticipants int `yaml:"max-participants"`
+
+	// Create the channels of the namespace with MLS whatever the request,
+	// and refuse to disable it (optional)
+	MlsRequired *bool `yaml:"mls-required"`
+}
+
+// Validate checks if the namespace settings are valid
+func (cfg *NamespaceSettings) Validate() error {
+	if cfg.MaxChannels < 0 {
+		return errors.New("max channels cannot be negative")
+	}
+	if cfg.MaxParticipants < 0 {
+		return errors.New("max participants cannot be negative")
+	}
+	return nil
+}
+
+// WithDefaults returns the settings with the unset ones taken from defaults
+func (cfg NamespaceSettings) WithDefaults(defaults NamespaceSettings) NamespaceSettings {
+	if cfg.MaxChannels == 0 {
+		cfg.MaxChannels = defaults.MaxChannels
+	}
+	if cfg.MaxParticipants == 0 {
+		cfg.MaxParticipants = defaults.MaxParticipants
+	}
+	if cfg.MlsRequired == nil {
+		cfg.MlsRequired = defaults.MlsRequired
+	}
+	return cfg
+}
+
+// mlsRequired reports whether the channels of the namespace must use MLS
+func (cfg NamespaceSettings) mlsRequired() bool {
+	return cfg.MlsRequired != nil && *cfg.MlsRequired
+}
+
+// NamespaceConfig defines a tenant of the channel manager, owning the
+// channels whose name starts with its organization and namespace
+type NamespaceConfig struct {
+	// Organization and namespace of the channels, e.g. agntcy/team-a
+	Name string `yaml:"name"`
+
+	// Quotas and policies of the channels, overriding the manager namespace
+	// defaults (optional)
+	Settings NamespaceSettings `yaml:",inline"`
+
+	// Tokens allowed to manage the channels of the namespace (optional)
+	Bindings []RoleBinding `yaml:"bindings"`
+}
+
+// RoleBinding grants a role on the channels of a namespace to the gRPC
+// clients sending a bearer token
+type RoleBinding struct {
+	// Bearer token the clients send in the authorization metadata
+	Token string `yaml:"token"`
+
+	// Role granted to the clients: admin or viewer
+	Role string `yaml:"role"`
+}
+
+// Validate checks if the namespace configuration is valid
+func (cfg *NamespaceConfig) Validate() error {
+	parts := strings.Split(cfg.Name, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("namespace name must be in the format organization/namespace, got: %s", cfg.Name)
+	}
+
+	if err := cfg.Settings.Validate(); err != nil {
+		return err
+	}
+
+	for i, binding := range cfg.Bindings {
+		if binding.Token == "" {
+			return fmt.Errorf("token of binding %d cannot be empty", i)
+		}
+		if binding.Role != RoleAdmin && binding.Role != RoleViewer {
+			return fmt.Errorf("invalid role '%s' for binding %d", binding.Role, i)
+		}
+	}
+	return nil
+}
+
+// namespaceOf returns the organization and namespace of a channel name,
+// e.g. agntcy/team-a for agntcy/team-a/traces
+func namespaceOf(channel string) string {
+	parts := strings.SplitN(channel, "/", 3)
+	if len(parts) < 2 {
+		return channel
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+// namespaces holds the settings of the namespaces, with the defaults
+// applied. A nil namespaces has no quota nor policy.
+type namespaces struct {
+	defaults NamespaceSettings
+	byName   map[string]NamespaceSettings
+}
+
+// newNamespaces returns the namespaces of the configuration
+func newNamespaces(defaults NamespaceSettings, configs []NamespaceConfig) *namespaces {
+	n := &namespaces{defaults: defaults, byName: make(map[string]NamespaceSettings, len(configs))}
+	for _, cfg := range configs {
+		n.byName[cfg.Name] = cfg.Settings.WithDefaults(defaults)
+	}
+	return n
+}
+
+// settings returns the settings of the namespace of a channel
+func (n *namespaces) settings(channel string) NamespaceSettings {
+	if n == nil {
+		return NamespaceSettings{}
+	}
+	if settings, ok := n.byName[namespaceOf(channel)]; ok {
+		return settings
+	}
+	return n.defaults
+}
+
+// WithNamespaces enforces the quotas and policies of the namespaces on the
+// channels created, adopted and updated through the service. The
+// namespaces that are not configured get the defaults.
+func WithNamespaces(defaults NamespaceSettings, configs []NamespaceConfig) ServerOption {
+	return func(s *Server) {
+		s.namespaces = newNamespaces(defaults, configs)
+	}
+}
+
+// checkChannelQuota returns an error if the namespace of channel cannot
+// hold another channel
+func (s *Server) checkChannelQuota(ctx context.Context, channel string) error {
+	limit := s.namespaces.settings(channel).MaxChannels
+	if limit == 0 {
+		return nil
+	}
+	namespace := namespaceOf(channel)
+	count := 0
+	for _, name := range s.channels.ListSessionNames(ctx) {
+		if namespaceOf(name) == namespace {
+			count++
+		}
+	}
+	if count >= limit {
+		return fmt.Errorf("namespace %s reached its quota of %d channels", namespace, limit)
+	}
+	return nil
+}
+
+// checkParticipantQuota returns an error if a channel with count
+// participants exceeds the quota of its namespace
+func (s *Server) checkParticipantQuota(channel string, count int) error {
+	limit := s.namespaces.settings(channel).MaxParticipants
+	if limit > 0 && count > limit {
+		return fmt.Errorf("namespace %s allows at most %d participants per channel", namespaceOf(channel), limit)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+const (
+	teamA        = "agntcy/team-a"
+	teamAChannel = "agntcy/team-a/traces"
+	teamBChannel = "agntcy/team-b/traces"
+)
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+// newNamespaceServer creates a Server backed by a fake SLIM app enforcing
+// the namespaces
+func newNamespaceServer(defaults NamespaceSettings, configs ...NamespaceConfig) (*Server, *testutil.FakeApp) {
+	app := testutil.NewFakeApp()
+	return NewChannelManagerServer(app, 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		WithNamespaces(defaults, configs)), app
+}
+
+func TestNamespaceOf(t *testing.T) {
+	assert.Equal(t, teamA, namespaceOf(teamAChannel))
+	assert.Equal(t, teamA, namespaceOf(teamA))
+	assert.Equal(t, "invalid", namespaceOf("invalid"))
+}
+
+func TestNamespaceSettings_WithDefaults(t *testing.T) {
+	defaults := NamespaceSettings{MaxChannels: 10, MaxParticipants: 20, MlsRequired: boolPtr(true)}
+
+	assert.Equal(t, defaults, NamespaceSettings{}.WithDefaults(defaults))
+
+	settings := NamespaceSettings{MaxChannels: 2, MlsRequired: boolPtr(false)}.WithDefaults(defaults)
+	assert.Equal(t, 2, settings.MaxChannels)
+	assert.Equal(t, 20, settings.MaxParticipants)
+	assert.False(t, settings.mlsRequired())
+}
+
+func TestNamespaceConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  NamespaceConfig
+		wantErr string
+	}{
+		{
+			name: "valid",
+			config: NamespaceConfig{Name: teamA, Bindings: []RoleBinding{
+				{Token: "admin-token", Role: RoleAdmin},
+				{Token: "viewer-token", Role: RoleViewer},
+			}},
+		},
+		{name: "missing namespace", config: NamespaceConfig{Name: "agntcy"}, wantErr: "organization/namespace"},
+		{name: "channel name", config: NamespaceConfig{Name: teamAChannel}, wantErr: "organization/namespace"},
+		{name: "empty part", config: NamespaceConfig{Name: "agntcy/"}, wantErr: "organization/namespace"},
+		{
+			name:    "negative max channels",
+			config:  NamespaceConfig{Name: teamA, Settings: NamespaceSettings{MaxChannels: -1}},
+			wantErr: "max channels cannot be negative",
+		},
+		{
+			name:    "negative max participants",
+			config:  NamespaceConfig{Name: teamA, Settings: NamespaceSettings{MaxParticipants: -1}},
+			wantErr: "max participants cannot be negative",
+		},
+		{
+			name:    "empty token",
+			config:  NamespaceConfig{Name: teamA, Bindings: []RoleBinding{{Role: RoleAdmin}}},
+			wantErr: "token of binding 0 cannot be empty",
+		},
+		{
+			name:    "invalid role",
+			config:  NamespaceConfig{Name: teamA, Bindings: []RoleBinding{{Token: "token", Role: "owner"}}},
+			wantErr: "invalid role 'owner' for binding 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestServer_NamespaceQuotas tests the quotas of the namespaces
+func TestServer_NamespaceQuotas(t *testing.T) {
+	t.Run("max channels", func(t *testing.T) {
+		s, app := newNamespaceServer(NamespaceSettings{MaxChannels: 1},
+			NamespaceConfig{Name: teamA, Settings: NamespaceSettings{MaxChannels: 2}})
+
+		require.True(t, command(t, s, createChannel(teamAChannel, false)).Success)
+		require.True(t, command(t, s, createChannel("agntcy/team-a/logs", false)).Success)
+		resp := command(t, s, createChannel("agntcy/team-a/metrics", false))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "namespace agntcy/team-a reached its quota of 2 channels")
+
+		// the other namespaces get the defaults
+		require.True(t, command(t, s, createChannel(teamBChannel, false)).Success)
+		resp = command(t, s, createChannel("agntcy/team-b/logs", false))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "namespace agntcy/team-b reached its quota of 1 channels")
+
+		resp = command(t, s, adoptChannel("agntcy/team-b/metrics", 50))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "reached its quota")
+
+		assert.Len(t, app.Sessions(), 3)
+	})
+
+	t.Run("max participants", func(t *testing.T) {
+		s, app := newNamespaceServer(NamespaceSettings{MaxParticipants: 1})
+		require.True(t, command(t, s, createChannel(teamAChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(teamAChannel, testParticipant)).Success)
+
+		resp := command(t, s, addParticipant(teamAChannel, "agntcy/team-a/receiver-2"))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "at most 1 participants per channel")
+
+		resp = command(t, s, updateChannel(teamAChannel, []string{testParticipant, "agntcy/team-a/receiver-2"}, nil))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "at most 1 participants per channel")
+
+		assert.Equal(t, []string{testParticipant}, app.SessionByName(teamAChannel).Participants())
+	})
+}
+
+// TestServer_NamespaceMlsPolicy tests the MLS policy of the namespaces
+func TestServer_NamespaceMlsPolicy(t *testing.T) {
+	t.Run("channels are created with MLS", func(t *testing.T) {
+		s, app := newNamespaceServer(NamespaceSettings{},
+			NamespaceConfig{Name: teamA, Settings: NamespaceSettings{MlsRequired: boolPtr(true)}})
+
+		require.True(t, command(t, s, createChannel(teamAChannel, false)).Success)
+		assert.True(t, app.SessionByName(teamAChannel).Config.EnableMls)
+
+		require.True(t, command(t, s, createChannel(teamBChannel, false)).Success)
+		assert.False(t, app.SessionByName(teamBChannel).Config.EnableMls)
+	})
+
+	t.Run("MLS cannot be disabled", func(t *testing.T) {
+		s, app := newNamespaceServer(NamespaceSettings{MlsRequired: boolPtr(true)})
+		require.True(t, command(t, s, createChannel(teamAChannel, true)).Success)
+		session := app.SessionByName(teamAChannel)
+
+		resp := command(t, s, updateChannel(teamAChannel, nil, boolPtr(false)))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "requires MLS")
+		assert.Same(t, session, app.SessionByName(teamAChannel))
+	})
+
+	t.Run("channels without MLS are not adopted", func(t *testing.T) {
+		s, app := newNamespaceServer(NamespaceSettings{MlsRequired: boolPtr(true)})
+		app.Invite(testutil.NewFakeSession(1, teamAChannel))
+
+		resp := command(t, s, adoptChannel(teamAChannel, 1000))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "does not use MLS")
+		assert.Equal(t, []uint32{1}, app.DeletedSessions())
+	})
+}
+
+func TestConfig_ValidateNamespaces(t *testing.T) {
+	newConfig := func(channels ...ChannelConfig) *Config {
+		return &Config{
+			Manager: ManagerConfig{
+				NamespaceDefaults: NamespaceSettings{MaxParticipants: 1},
+				Namespaces: []NamespaceConfig{
+					{Name: teamA, Settings: NamespaceSettings{MaxChannels: 1, MlsRequired: boolPtr(true)}},
+				},
+			},
+			Channels: channels,
+		}
+	}
+
+	assert.NoError(t, newConfig(
+		ChannelConfig{Name: teamAChannel, Participants: []string{testParticipant}, MlsEnabled: true},
+		ChannelConfig{Name: teamBChannel, Participants: []string{testParticipant}},
+	).validateNamespaces())
+
+	assert.ErrorContains(t, newConfig(
+		ChannelConfig{Name: teamAChannel, Participants: []string{testParticipant}, MlsEnabled: true},
+		ChannelConfig{Name: "agntcy/team-a/logs", Participants: []string{testParticipant}, MlsEnabled: true},
+	).validateNamespaces(), "namespace agntcy/team-a has more than 1 channels")
+
+	assert.ErrorContains(t, newConfig(
+		ChannelConfig{Name: teamAChannel, Participants: []string{testParticipant}},
+	).validateNamespaces(), "channel 0 must enable MLS")
+
+	assert.ErrorContains(t, newConfig(
+		ChannelConfig{Name: teamBChannel, Participants: []string{testParticipant, "agntcy/team-b/receiver"}},
+	).validateNamespaces(), "channel 0 has more than the 1 participants allowed by namespace agntcy/team-b")
+}
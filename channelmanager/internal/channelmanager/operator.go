@@ -0,0 +1,334 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+const (
+	// defaultResyncInterval is the interval of the full reconciliation of
+	// the SlimChannel resources when the configuration does not set it
+	defaultResyncInterval = 30 * time.Second
+	// operatorRetryDelay is the wait before listing or watching the
+	// resources again after a failure
+	operatorRetryDelay = 5 * time.Second
+
+	// ConditionReady is the type of the condition reporting whether the
+	// channel of a SlimChannel matches its spec
+	ConditionReady = "Ready"
+
+	// ReasonReconciled reports that the channel matches the spec
+	ReasonReconciled = "Reconciled"
+	// ReasonInvalidSpec reports a spec that cannot be reconciled, the
+	// channel is then left unchanged
+	ReasonInvalidSpec = "InvalidSpec"
+	// ReasonConflict reports a channel already declared by another resource
+	ReasonConflict = "Conflict"
+	// ReasonReconcileFailed reports a failure to create the channel or to
+	// fix its participants, retried at the next reconciliation
+	ReasonReconcileFailed = "ReconcileFailed"
+
+	// WatchEventAdded, WatchEventModified, WatchEventDeleted, WatchEventBookmark
+	// and WatchEventError are the types of the watch events
+	WatchEventAdded    = "ADDED"
+	WatchEventModified = "MODIFIED"
+	WatchEventDeleted  = "DELETED"
+	WatchEventBookmark = "BOOKMARK"
+	WatchEventError    = "ERROR"
+)
+
+// SlimChannel is a Kubernetes custom resource declaring a channel and its
+// participants, see deploy/slimchannel-crd.yaml
+type SlimChannel struct {
+	Metadata ObjectMeta        `json:"metadata"`
+	Spec     SlimChannelSpec   `json:"spec"`
+	Status   SlimChannelStatus `json:"status,omitzero"`
+}
+
+// ObjectMeta holds the metadata of a resource used by the operator
+type ObjectMeta struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	Generation      int64  `json:"generation,omitempty"`
+}
+
+// SlimChannelSpec is the desired channel, with the settings of ChannelConfig
+type SlimChannelSpec struct {
+	// Channel name in SLIM format
+	Channel string `json:"channel"`
+
+	// Participants to invite to the channel
+	Participants []string `json:"participants"`
+
+	// Enable MLS on the channel (optional)
+	MlsEnabled bool `json:"mlsEnabled,omitempty"`
+
+	// Maximum size in bytes of a message published on the channel (optional)
+	MaxMessageSize int `json:"maxMessageSize,omitempty"`
+
+	// Maximum number of messages per second each exporter publishes on the
+	// channel (optional)
+	MaxMessageRate float64 `json:"maxMessageRate,omitempty"`
+
+	// Severity the receivers apply to the log records without severity (optional)
+	DefaultLogSeverity string `json:"defaultLogSeverity,omitempty"`
+
+	// Signals carried by the channel, any signal if empty (optional)
+	Signals []string `json:"signals,omitempty"`
+
+	// Retransmission settings of the group session, overriding the manager
+	// session defaults (optional)
+	Session *SlimChannelSession `json:"session,omitempty"`
+}
+
+// SlimChannelSession are the retransmission settings of a SlimChannel
+type SlimChannelSession struct {
+	// Maximum number of retransmissions of a message (optional)
+	MaxRetries *uint32 `json:"maxRetries,omitempty"`
+
+	// Interval between the retransmissions, e.g. 500ms (optional)
+	Interval string `json:"interval,omitempty"`
+}
+
+// SlimChannelStatus is the status written back by the operator
+type SlimChannelStatus struct {
+	// Generation of the spec last reconciled
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions of the resource, the Ready condition
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// Condition is a standard Kubernetes status condition
+type Condition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime"`
+	Reason             string `json:"reason"`
+	Message            string `json:"message"`
+}
+
+// SlimChannelList is a list of SlimChannel resources
+type SlimChannelList struct {
+	Metadata ObjectMeta    `json:"metadata"`
+	Items    []SlimChannel `json:"items"`
+}
+
+// SlimChannelEvent is a change of a SlimChannel resource
+type SlimChannelEvent struct {
+	Type   string
+	Object SlimChannel
+}
+
+// channelConfig returns the channel configuration of the spec, validated
+func (c *SlimChannel) channelConfig() (ChannelConfig, error) {
+	cfg := ChannelConfig{
+		Name:               c.Spec.Channel,
+		Participants:       c.Spec.Participants,
+		MlsEnabled:         c.Spec.MlsEnabled,
+		MaxMessageSize:     c.Spec.MaxMessageSize,
+		MaxMessageRate:     c.Spec.MaxMessageRate,
+		DefaultLogSeverity: c.Spec.DefaultLogSeverity,
+		Signals:            c.Spec.Signals,
+	}
+	if c.Spec.Session != nil {
+		cfg.Session.MaxRetries = c.Spec.Session.MaxRetries
+		if c.Spec.Session.Interval != "" {
+			interval, err := time.ParseDuration(c.Spec.Session.Interval)
+			if err != nil {
+				return cfg, fmt.Errorf("invalid retransmission interval: %w", err)
+			}
+			cfg.Session.Interval = interval
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// key returns the namespace and name of the resource
+func (c *SlimChannel) key() string {
+	return c.Metadata.Namespace + "/" + c.Metadata.Name
+}
+
+// ServeOperator reconciles the channels with the SlimChannel resources
+// listed by client until ctx is done: on every change of the specs, and
+// every resync interval to fix the drift of the channels. The channels not
+// declared by a resource are deleted. The Ready condition of each resource
+// reports the outcome of its reconciliation.
+func (s *Server) ServeOperator(ctx context.Context, client SlimChannelClient, resync time.Duration) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	if resync <= 0 {
+		resync = defaultResyncInterval
+	}
+	logger.Info("Reconciling the channels with the SlimChannel resources", zap.Duration("resync_interval", resync))
+
+	for {
+		list, err := client.List(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Warn("Failed to list the SlimChannel resources", zap.Error(err))
+			if !sleep(ctx, operatorRetryDelay) {
+				return
+			}
+			continue
+		}
+
+		s.ReconcileResources(ctx, client, list.Items)
+
+		// wait for a change of a spec, or the resync
+		generations := make(map[string]int64, len(list.Items))
+		for i := range list.Items {
+			generations[list.Items[i].key()] = list.Items[i].Metadata.Generation
+		}
+		watchCtx, cancel := context.WithTimeout(ctx, resync)
+		err = client.Watch(watchCtx, list.Metadata.ResourceVersion, func(event SlimChannelEvent) bool {
+			switch event.Type {
+			case WatchEventBookmark:
+				return false
+			case WatchEventModified:
+				// the status updates do not change the generation
+				return event.Object.Metadata.Generation != generations[event.Object.key()]
+			default:
+				return true
+			}
+		})
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			logger.Warn("Failed to watch the SlimChannel resources", zap.Error(err))
+			if !sleep(ctx, operatorRetryDelay) {
+				return
+			}
+		}
+	}
+}
+
+// ReconcileResources reconciles the channels with the SlimChannel resources,
+// deleting the channels no resource declares, then updates the status of the
+// resources whose Ready condition changed. The channel of an invalid spec is
+// left unchanged.
+func (s *Server) ReconcileResources(ctx context.Context, client SlimChannelClient, resources []SlimChannel) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	wanted := make(map[string]struct{}, len(resources))
+	owners := make(map[string]string, len(resources))
+	conditions := make([]Condition, len(resources))
+	for i := range resources {
+		conditions[i] = s.reconcileResource(ctx, &resources[i], wanted, owners)
+	}
+	if err := s.deleteUndesired(ctx, wanted); err != nil {
+		logger.Warn("Failed to delete the channels not declared by a SlimChannel", zap.Error(err))
+	}
+
+	for i := range resources {
+		resource := &resources[i]
+		if !resource.setReady(conditions[i]) {
+			continue
+		}
+		if err := client.UpdateStatus(ctx, resource); err != nil {
+			logger.Warn("Failed to update the status of the SlimChannel",
+				zap.String("resource", resource.key()), zap.Error(err))
+		}
+	}
+}
+
+// reconcileResource reconciles the channel of a resource and returns its
+// Ready condition. The channel is added to wanted, with the resource as its
+// owner, as soon as its name is valid, so that it is not deleted.
+func (s *Server) reconcileResource(
+	ctx context.Context, resource *SlimChannel, wanted map[string]struct{}, owners map[string]string,
+) Condition {
+	channel, err := slimcommon.SplitID(resource.Spec.Channel)
+	if err != nil {
+		return notReady(ReasonInvalidSpec, fmt.Sprintf("invalid channel name: %s", resource.Spec.Channel))
+	}
+	channelStr := channel.String()
+	if owner, ok := owners[channelStr]; ok {
+		return notReady(ReasonConflict, fmt.Sprintf("channel %s is declared by %s", resource.Spec.Channel, owner))
+	}
+	wanted[channelStr] = struct{}{}
+	owners[channelStr] = resource.key()
+
+	desired, err := resource.channelConfig()
+	if err != nil {
+		return notReady(ReasonInvalidSpec, err.Error())
+	}
+	if err := s.checkParticipantQuota(desired.Name, len(desired.Participants)); err != nil {
+		return notReady(ReasonInvalidSpec, err.Error())
+	}
+	desired.MlsEnabled = desired.MlsEnabled || s.namespaces.settings(desired.Name).mlsRequired()
+	if _, existsErr := s.channels.GetSessionByName(ctx, channelStr); existsErr != nil {
+		if err := s.checkChannelQuota(ctx, desired.Name); err != nil {
+			return notReady(ReasonReconcileFailed, err.Error())
+		}
+	}
+
+	if err := s.reconcileChannel(ctx, channel, &desired); err != nil {
+		return notReady(ReasonReconcileFailed, err.Error())
+	}
+	return Condition{
+		Type:    ConditionReady,
+		Status:  "True",
+		Reason:  ReasonReconciled,
+		Message: fmt.Sprintf("channel %s has %d participants", resource.Spec.Channel, len(desired.Participants)),
+	}
+}
+
+// notReady returns a Ready condition that is false
+func notReady(reason, message string) Condition {
+	return Condition{Type: ConditionReady, Status: "False", Reason: reason, Message: message}
+}
+
+// setReady sets the Ready condition of the status for the generation of the
+// resource, keeping its transition time if its status is unchanged, and
+// returns whether the status changed
+func (c *SlimChannel) setReady(ready Condition) bool {
+	ready.ObservedGeneration = c.Metadata.Generation
+	idx := slices.IndexFunc(c.Status.Conditions, func(condition Condition) bool {
+		return condition.Type == ConditionReady
+	})
+
+	if idx >= 0 {
+		previous := c.Status.Conditions[idx]
+		ready.LastTransitionTime = previous.LastTransitionTime
+		if previous.Status != ready.Status || ready.LastTransitionTime == "" {
+			ready.LastTransitionTime = time.Now().UTC().Format(time.RFC3339)
+		}
+		if previous == ready && c.Status.ObservedGeneration == c.Metadata.Generation {
+			return false
+		}
+		c.Status.Conditions[idx] = ready
+	} else {
+		ready.LastTransitionTime = time.Now().UTC().Format(time.RFC3339)
+		c.Status.Conditions = append(c.Status.Conditions, ready)
+	}
+	c.Status.ObservedGeneration = c.Metadata.Generation
+	return true
+}
+
+// sleep waits for d and returns false if ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// fakeSlimChannels is a SlimChannelClient holding the resources in memory
+type fakeSlimChannels struct {
+	mutex   sync.Mutex
+	items   []SlimChannel
+	version int
+	events  chan SlimChannelEvent
+	updates []SlimChannel
+}
+
+func newFakeSlimChannels(items ...SlimChannel) *fakeSlimChannels {
+	return &fakeSlimChannels{items: items, events: make(chan SlimChannelEvent, 10)}
+}
+
+func (f *fakeSlimChannels) List(context.Context) (*SlimChannelList, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	list := &SlimChannelList{Metadata: ObjectMeta{ResourceVersion: strconv.Itoa(f.version)}}
+	for _, item := range f.items {
+		item.Status.Conditions = slices.Clone(item.Status.Conditions)
+		list.Items = append(list.Items, item)
+	}
+	return list, nil
+}
+
+func (f *fakeSlimChannels) Watch(ctx context.Context, _ string, handle func(SlimChannelEvent) bool) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-f.events:
+			if handle(event) {
+				return nil
+			}
+		}
+	}
+}
+
+func (f *fakeSlimChannels) UpdateStatus(_ context.Context, channel *SlimChannel) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.updates = append(f.updates, *channel)
+	for i := range f.items {
+		if f.items[i].key() == channel.key() {
+			f.items[i].Status = channel.Status
+			f.items[i].Status.Conditions = slices.Clone(channel.Status.Conditions)
+		}
+	}
+	return nil
+}
+
+// apply adds or replaces a resource, bumping its generation, and sends the
+// watch event
+func (f *fakeSlimChannels) apply(channel SlimChannel) {
+	f.mutex.Lock()
+	f.version++
+	eventType := WatchEventAdded
+	channel.Metadata.Generation = 1
+	for i := range f.items {
+		if f.items[i].key() == channel.key() {
+			eventType = WatchEventModified
+			channel.Metadata.Generation = f.items[i].Metadata.Generation + 1
+			channel.Status = f.items[i].Status
+			f.items = slices.Delete(f.items, i, i+1)
+			break
+		}
+	}
+	f.items = append(f.items, channel)
+	f.mutex.Unlock()
+	f.events <- SlimChannelEvent{Type: eventType, Object: channel}
+}
+
+// statusUpdates returns the resources whose status was updated
+func (f *fakeSlimChannels) statusUpdates() []SlimChannel {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return slices.Clone(f.updates)
+}
+
+// slimChannel returns a SlimChannel resource of the default namespace
+func slimChannel(name, channel string, participants ...string) SlimChannel {
+	return SlimChannel{
+		Metadata: ObjectMeta{Name: name, Namespace: "default", Generation: 1},
+		Spec:     SlimChannelSpec{Channel: channel, Participants: participants},
+	}
+}
+
+// readyCondition returns the Ready condition of a resource
+func readyCondition(t *testing.T, channel SlimChannel) Condition {
+	t.Helper()
+	require.Len(t, channel.Status.Conditions, 1)
+	condition := channel.Status.Conditions[0]
+	assert.Equal(t, ConditionReady, condition.Type)
+	assert.NotEmpty(t, condition.LastTransitionTime)
+	return condition
+}
+
+func TestServer_ReconcileResources(t *testing.T) {
+	const other = "agntcy/otel/other-channel"
+
+	t.Run("creates the channels and reports them ready", func(t *testing.T) {
+		s, app := newTestServer()
+		resource := slimChannel("traces", testChannel, testParticipant)
+		resource.Spec.MlsEnabled = true
+		resource.Spec.Session = &SlimChannelSession{Interval: "250ms"}
+		client := newFakeSlimChannels(resource)
+
+		list, err := client.List(t.Context())
+		require.NoError(t, err)
+		s.ReconcileResources(t.Context(), client, list.Items)
+
+		session := app.SessionByName(testChannel)
+		require.NotNil(t, session)
+		assert.True(t, session.Config.EnableMls)
+		assert.Equal(t, 250*time.Millisecond, *session.Config.Interval)
+		assert.Equal(t, []string{testParticipant}, session.Participants())
+
+		updates := client.statusUpdates()
+		require.Len(t, updates, 1)
+		condition := readyCondition(t, updates[0])
+		assert.Equal(t, "True", condition.Status)
+		assert.Equal(t, ReasonReconciled, condition.Reason)
+		assert.Equal(t, int64(1), condition.ObservedGeneration)
+		assert.Equal(t, int64(1), updates[0].Status.ObservedGeneration)
+
+		// the status is only updated when it changes
+		list, err = client.List(t.Context())
+		require.NoError(t, err)
+		s.ReconcileResources(t.Context(), client, list.Items)
+		assert.Len(t, client.statusUpdates(), 1)
+	})
+
+	t.Run("keeps the channel of an invalid spec", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		client := newFakeSlimChannels()
+
+		s.ReconcileResources(t.Context(), client, []SlimChannel{slimChannel("traces", testChannel)})
+
+		assert.NotNil(t, app.SessionByName(testChannel))
+		assert.Empty(t, app.DeletedSessions())
+		updates := client.statusUpdates()
+		require.Len(t, updates, 1)
+		condition := readyCondition(t, updates[0])
+		assert.Equal(t, "False", condition.Status)
+		assert.Equal(t, ReasonInvalidSpec, condition.Reason)
+		assert.Contains(t, condition.Message, "at least one participant must be specified")
+	})
+
+	t.Run("reports the conflicts", func(t *testing.T) {
+		s, app := newTestServer()
+		client := newFakeSlimChannels()
+
+		s.ReconcileResources(t.Context(), client, []SlimChannel{
+			slimChannel("first", testChannel, testParticipant),
+			slimChannel("second", testChannel, "agntcy/otel/exporter"),
+		})
+
+		assert.Equal(t, []string{testParticipant}, app.SessionByName(testChannel).Participants())
+		updates := client.statusUpdates()
+		require.Len(t, updates, 2)
+		assert.Equal(t, "True", readyCondition(t, updates[0]).Status)
+		condition := readyCondition(t, updates[1])
+		assert.Equal(t, ReasonConflict, condition.Reason)
+		assert.Contains(t, condition.Message, "is declared by default/first")
+	})
+
+	t.Run("deletes the undeclared channels", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(other, false)).Success)
+
+		s.ReconcileResources(t.Context(), newFakeSlimChannels(), []SlimChannel{
+			slimChannel("traces", testChannel, testParticipant),
+		})
+
+		assert.Equal(t, []string{testChannel}, s.channels.ListSessionNames(t.Context()))
+		assert.Len(t, app.DeletedSessions(), 1)
+	})
+
+	t.Run("enforces the namespace policies", func(t *testing.T) {
+		app := testutil.NewFakeApp()
+		s := NewChannelManagerServer(app, 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+			WithNamespaces(NamespaceSettings{MaxParticipants: 1, MlsRequired: boolPtr(true)}, nil))
+		client := newFakeSlimChannels()
+
+		s.ReconcileResources(t.Context(), client, []SlimChannel{
+			slimChannel("traces", testChannel, testParticipant),
+			slimChannel("logs", other, testParticipant, "agntcy/otel/exporter"),
+		})
+
+		assert.True(t, app.SessionByName(testChannel).Config.EnableMls, "the namespace requires MLS")
+		assert.Nil(t, app.SessionByName(other))
+		updates := client.statusUpdates()
+		require.Len(t, updates, 2)
+		condition := readyCondition(t, updates[1])
+		assert.Equal(t, ReasonInvalidSpec, condition.Reason)
+		assert.Contains(t, condition.Message, "allows at most 1 participants per channel")
+	})
+
+	t.Run("reports the failures", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		app.SessionByName(testChannel).InviteErr = assert.AnError
+		client := newFakeSlimChannels()
+
+		s.ReconcileResources(t.Context(), client, []SlimChannel{slimChannel("traces", testChannel, testParticipant)})
+
+		updates := client.statusUpdates()
+		require.Len(t, updates, 1)
+		condition := readyCondition(t, updates[0])
+		assert.Equal(t, "False", condition.Status)
+		assert.Equal(t, ReasonReconcileFailed, condition.Reason)
+	})
+}
+
+func TestSlimChannel_SetReady(t *testing.T) {
+	resource := slimChannel("traces", testChannel, testParticipant)
+
+	require.True(t, resource.setReady(notReady(ReasonReconcileFailed, "failed")))
+	transition := resource.Status.Conditions[0].LastTransitionTime
+
+	// same status, another reason
+	require.True(t, resource.setReady(notReady(ReasonInvalidSpec, "invalid")))
+	assert.Equal(t, transition, resource.Status.Conditions[0].LastTransitionTime)
+	assert.False(t, resource.setReady(notReady(ReasonInvalidSpec, "invalid")))
+
+	// new generation
+	resource.Metadata.Generation = 2
+	require.True(t, resource.setReady(notReady(ReasonInvalidSpec, "invalid")))
+	assert.Equal(t, int64(2), resource.Status.ObservedGeneration)
+	assert.Len(t, resource.Status.Conditions, 1)
+}
+
+func TestServer_ServeOperator(t *testing.T) {
+	s, app := newTestServer()
+	client := newFakeSlimChannels()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		s.ServeOperator(ctx, client, time.Minute)
+		close(done)
+	}()
+
+	// a new resource is reconciled on its event, before the resync
+	client.apply(slimChannel("traces", testChannel, testParticipant))
+	require.Eventually(t, func() bool {
+		session := app.SessionByName(testChannel)
+		return session != nil && len(session.Participants()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// and so is a change of its spec
+	client.apply(slimChannel("traces", testChannel, testParticipant, "agntcy/otel/exporter"))
+	require.Eventually(t, func() bool {
+		return len(app.SessionByName(testChannel).Participants()) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		updates := client.statusUpdates()
+		return len(updates) == 2 && updates[1].Status.ObservedGeneration == 2
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+}
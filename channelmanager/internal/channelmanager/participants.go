@@ -0,0 +1,114 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// handleAddParticipants adds several participants to a channel, one after
+// the other. A participant that cannot be added is reported in its result
+// and does not stop the others.
+func (s *Server) handleAddParticipants(
+	ctx context.Context, msgID uint64, req *AddParticipantsRequest,
+) (*ControlResponse, error) {
+	channel, session, err := s.bulkChannel(ctx, req.ChannelName, req.ParticipantName)
+	if err != nil {
+		return s.errorResponse(msgID, err.Error())
+	}
+
+	results := make([]*ParticipantResult, 0, len(req.ParticipantName))
+	for _, participant := range req.ParticipantName {
+		pending, addErr := s.addParticipant(ctx, session, channel, participant)
+		results = append(results, participantResult(participant, pending, addErr))
+	}
+
+	logParticipantResults(ctx, "Participants added", channel, results)
+	return s.participantsResponse(msgID, results)
+}
+
+// handleRemoveParticipants removes several participants from a channel, one
+// after the other. A participant that cannot be removed is reported in its
+// result and does not stop the others.
+func (s *Server) handleRemoveParticipants(
+	ctx context.Context, msgID uint64, req *RemoveParticipantsRequest,
+) (*ControlResponse, error) {
+	channel, session, err := s.bulkChannel(ctx, req.ChannelName, req.ParticipantName)
+	if err != nil {
+		return s.errorResponse(msgID, err.Error())
+	}
+
+	results := make([]*ParticipantResult, 0, len(req.ParticipantName))
+	for _, participant := range req.ParticipantName {
+		deleteErr := s.deleteParticipant(ctx, session, channel, participant)
+		results = append(results, participantResult(participant, false, deleteErr))
+	}
+
+	logParticipantResults(ctx, "Participants removed", channel, results)
+	return s.participantsResponse(msgID, results)
+}
+
+// bulkChannel returns the channel and the session of a bulk command, or an
+// error failing the whole command
+func (s *Server) bulkChannel(
+	ctx context.Context, channelName string, participants []string,
+) (*slim.Name, slimcommon.Session, error) {
+	channel, err := slimcommon.SplitID(channelName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid channel name: %s", channelName)
+	}
+	if len(participants) == 0 {
+		return nil, nil, errors.New("at least one participant is required")
+	}
+	session, err := s.channels.GetSessionByName(ctx, channel.String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get channel %s: %v", channel.String(), err)
+	}
+	return channel, session, nil
+}
+
+// participantResult creates the result of a participant of a bulk command
+func participantResult(participant string, pendingApproval bool, err error) *ParticipantResult {
+	result := &ParticipantResult{ParticipantName: participant, Success: err == nil, PendingApproval: pendingApproval}
+	if err != nil {
+		errMsg := err.Error()
+		result.ErrorMsg = &errMsg
+		result.PendingApproval = false
+	}
+	return result
+}
+
+// logParticipantResults logs the participants a bulk command failed for
+func logParticipantResults(ctx context.Context, msg string, channel *slim.Name, results []*ParticipantResult) {
+	failed := make([]string, 0)
+	for _, result := range results {
+		if !result.Success {
+			failed = append(failed, result.ParticipantName)
+		}
+	}
+	slimcommon.LoggerFromContextOrDefault(ctx).Info(msg,
+		zap.String("channel", channel.String()),
+		zap.Int("count", len(results)-len(failed)),
+		zap.Strings("failed", failed))
+}
+
+// participantsResponse creates the response of a bulk command
+func (s *Server) participantsResponse(msgID uint64, results []*ParticipantResult) (*ControlResponse, error) {
+	return &ControlResponse{
+		MgsId: msgID,
+		Payload: &ControlResponse_ParticipantsResponse{
+			ParticipantsResponse: &ParticipantsResponse{
+				MsgId:  msgID,
+				Result: results,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,109 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addParticipants(channel string, participants ...string) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 16,
+		Payload: &ControlRequest_AddParticipantsRequest{
+			AddParticipantsRequest: &AddParticipantsRequest{ChannelName: channel, ParticipantName: participants},
+		},
+	}
+}
+
+func removeParticipants(channel string, participants ...string) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 17,
+		Payload: &ControlRequest_RemoveParticipantsRequest{
+			RemoveParticipantsRequest: &RemoveParticipantsRequest{ChannelName: channel, ParticipantName: participants},
+		},
+	}
+}
+
+// participantResults sends a bulk command and returns the results of its
+// participants
+func participantResults(t *testing.T, s *Server, req *ControlRequest) []*ParticipantResult {
+	t.Helper()
+	resp, err := s.Command(t.Context(), req)
+	require.NoError(t, err)
+	payload, ok := resp.Payload.(*ControlResponse_ParticipantsResponse)
+	require.True(t, ok, "unexpected response payload %T", resp.Payload)
+	assert.Equal(t, req.MgsId, payload.ParticipantsResponse.MsgId)
+	return payload.ParticipantsResponse.Result
+}
+
+// TestServer_BulkParticipants tests the AddParticipants and
+// RemoveParticipants commands
+func TestServer_BulkParticipants(t *testing.T) {
+	const (
+		receiver1 = "agntcy/otel/receiver-1"
+		receiver2 = "agntcy/otel/receiver-2"
+	)
+
+	t.Run("add and remove", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+
+		results := participantResults(t, s, addParticipants(testChannel, receiver1, "invalid", receiver2))
+		require.Len(t, results, 3)
+		assert.True(t, results[0].Success)
+		assert.False(t, results[1].Success)
+		assert.Equal(t, "invalid", results[1].ParticipantName)
+		assert.Contains(t, results[1].GetErrorMsg(), "invalid participant name")
+		assert.True(t, results[2].Success)
+		assert.Equal(t, []string{receiver1, receiver2}, app.SessionByName(testChannel).Participants())
+
+		results = participantResults(t, s, removeParticipants(testChannel, receiver1, receiver2))
+		require.Len(t, results, 2)
+		assert.True(t, results[0].Success)
+		assert.True(t, results[1].Success)
+		assert.Empty(t, app.SessionByName(testChannel).Participants())
+	})
+
+	t.Run("quota", func(t *testing.T) {
+		s, _ := newNamespaceServer(NamespaceSettings{MaxParticipants: 1})
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+
+		results := participantResults(t, s, addParticipants(testChannel, receiver1, receiver2))
+		assert.True(t, results[0].Success)
+		assert.False(t, results[1].Success, "the quota applies to each participant")
+	})
+
+	t.Run("protected channel", func(t *testing.T) {
+		s, _ := newApprovalServer(nil)
+		require.True(t, command(t, s, createChannel(protectedChannel, false)).Success)
+
+		results := participantResults(t, s, addParticipants(protectedChannel, receiver1, receiver2))
+		assert.True(t, results[0].Success)
+		assert.True(t, results[0].PendingApproval)
+		assert.True(t, results[1].PendingApproval)
+
+		results = participantResults(t, s, removeParticipants(protectedChannel, receiver1))
+		assert.True(t, results[0].Success, "the addition is withdrawn")
+	})
+
+	t.Run("command fails", func(t *testing.T) {
+		s, _ := newTestServer()
+
+		resp := command(t, s, addParticipants(testChannel, receiver1))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "failed to get channel")
+
+		resp = command(t, s, removeParticipants("invalid", receiver1))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "invalid channel name")
+
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		resp = command(t, s, addParticipants(testChannel))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "at least one participant")
+	})
+}
@@ -0,0 +1,151 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// ServeReconciliation reconciles the channels with the desired channels
+// returned by load, e.g. read from the configuration file, every interval
+// until ctx is done. A round is skipped when load fails, the channels are
+// then left unchanged.
+func (s *Server) ServeReconciliation(
+	ctx context.Context, interval time.Duration, load func() ([]ChannelConfig, error),
+) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	logger.Info("Reconciling the channels with the configuration", zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		desired, err := load()
+		if err != nil {
+			logger.Warn("Skipping the reconciliation, failed to load the desired channels", zap.Error(err))
+			continue
+		}
+		if err := s.Reconcile(ctx, desired); err != nil {
+			logger.Warn("Failed to reconcile the channels", zap.Error(err))
+		}
+	}
+}
+
+// Reconcile makes the channels match the desired channels: the missing
+// channels are created, the channels that are not desired are deleted, and
+// the MLS setting and the participants of the other channels are fixed. It
+// goes on after a failure and returns all the errors.
+func (s *Server) Reconcile(ctx context.Context, desired []ChannelConfig) error {
+	var errs []error
+
+	wanted := make(map[string]struct{}, len(desired))
+	for i := range desired {
+		channel, err := slimcommon.SplitID(desired[i].Name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid channel name: %s", desired[i].Name))
+			continue
+		}
+		wanted[channel.String()] = struct{}{}
+		if err := s.reconcileChannel(ctx, channel, &desired[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	errs = append(errs, s.deleteUndesired(ctx, wanted))
+	return errors.Join(errs...)
+}
+
+// deleteUndesired deletes the channels whose name is not in wanted. It goes
+// on after a failure and returns all the errors.
+func (s *Server) deleteUndesired(ctx context.Context, wanted map[string]struct{}) error {
+	var errs []error
+	for _, channelStr := range s.channels.ListSessionNames(ctx) {
+		if _, ok := wanted[channelStr]; ok {
+			continue
+		}
+		channel, err := slimcommon.SplitID(channelStr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid channel name: %s", channelStr))
+			continue
+		}
+		if err := s.deleteChannel(ctx, channel); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		slimcommon.LoggerFromContextOrDefault(ctx).Info("Deleted channel not in the configuration",
+			zap.String("channel", channelStr))
+	}
+	return errors.Join(errs...)
+}
+
+// reconcileChannel creates a desired channel if missing, recreates it if its
+// MLS setting changed, then reconciles its participants
+func (s *Server) reconcileChannel(ctx context.Context, channel *slim.Name, desired *ChannelConfig) error {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	channelStr := channel.String()
+
+	participants := make([]*slim.Name, 0, len(desired.Participants))
+	for _, participant := range desired.Participants {
+		name, err := slimcommon.SplitID(participant)
+		if err != nil {
+			return fmt.Errorf("invalid participant name %s for channel %s", participant, channelStr)
+		}
+		participants = append(participants, name)
+	}
+
+	session, err := s.channels.GetSessionByName(ctx, channelStr)
+	if err != nil {
+		// an expired channel stays deleted until the channel manager restarts
+		if s.expiry.hasExpired(channelStr) {
+			return nil
+		}
+		config := ChannelSessionConfig(desired.MlsEnabled, desired.Session.WithDefaults(s.sessionDefaults))
+		desired.Policy().AddToMetadata(config.Metadata)
+		if session, err = s.openChannel(ctx, channel, config); err != nil {
+			return err
+		}
+		if desired.TTL > 0 {
+			s.expiry.set(channelStr, time.Now().Add(desired.TTL))
+		}
+		logger.Info("Created missing channel", zap.String("channel", channelStr))
+	} else {
+		config, err := session.SessionConfig()
+		if err != nil {
+			return fmt.Errorf("failed to get the configuration of channel %s: %v", channelStr, err)
+		}
+		if config.EnableMls != desired.MlsEnabled {
+			config.EnableMls = desired.MlsEnabled
+			if session, err = s.recreateChannel(ctx, session, channel, config); err != nil {
+				return err
+			}
+			s.saveState(ctx, s.state.recreateChannel(slimcommon.JoinID(channel), config.EnableMls))
+			logger.Info("Recreated channel", zap.String("channel", channelStr), zap.Bool("mls_enabled", config.EnableMls))
+		}
+	}
+
+	invited, removed, err := s.reconcileParticipants(ctx, session, channel, participants)
+	if err != nil {
+		return err
+	}
+	if len(invited) > 0 || len(removed) > 0 {
+		logger.Info("Reconciled the participants of the channel",
+			zap.String("channel", channelStr),
+			zap.Strings("invited", invited),
+			zap.Strings("removed", removed))
+	}
+	return nil
+}
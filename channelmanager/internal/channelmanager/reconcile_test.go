@@ -0,0 +1,145 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// TestServer_Reconcile tests the reconciliation of the channels with the
+// configuration
+func TestServer_Reconcile(t *testing.T) {
+	const (
+		exporter = "agntcy/otel/exporter"
+		other    = "agntcy/otel/other-channel"
+	)
+
+	t.Run("creates the missing channels", func(t *testing.T) {
+		s, app := newTestServer()
+
+		err := s.Reconcile(t.Context(), []ChannelConfig{{
+			Name:           testChannel,
+			Participants:   []string{testParticipant},
+			MlsEnabled:     true,
+			MaxMessageSize: 1024,
+		}})
+		require.NoError(t, err)
+
+		session := app.SessionByName(testChannel)
+		require.NotNil(t, session)
+		assert.True(t, session.Config.EnableMls)
+		assert.Equal(t, "1024", session.Config.Metadata[slimcommon.MetadataMaxMessageSize])
+		assert.Equal(t, []string{testParticipant}, session.Participants())
+	})
+
+	t.Run("overrides the session defaults", func(t *testing.T) {
+		maxRetries := uint32(3)
+		app := testutil.NewFakeApp()
+		s := NewChannelManagerServer(app, 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+			WithSessionDefaults(SessionSettings{MaxRetries: &maxRetries, Interval: 250 * time.Millisecond}))
+
+		err := s.Reconcile(t.Context(), []ChannelConfig{{
+			Name:         testChannel,
+			Participants: []string{testParticipant},
+			Session:      SessionSettings{Interval: 2 * time.Second},
+		}})
+		require.NoError(t, err)
+
+		config := app.SessionByName(testChannel).Config
+		assert.Equal(t, uint32(3), *config.MaxRetries, "the unset settings are the defaults")
+		assert.Equal(t, 2*time.Second, *config.Interval)
+	})
+
+	t.Run("fixes the participant drift", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		session := app.SessionByName(testChannel)
+
+		err := s.Reconcile(t.Context(), []ChannelConfig{{Name: testChannel, Participants: []string{exporter}}})
+		require.NoError(t, err)
+
+		assert.Same(t, session, app.SessionByName(testChannel), "the session is kept")
+		assert.Equal(t, []string{exporter}, session.Participants())
+	})
+
+	t.Run("recreates the channels with another MLS setting", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+
+		err := s.Reconcile(t.Context(), []ChannelConfig{{
+			Name:         testChannel,
+			Participants: []string{testParticipant},
+			MlsEnabled:   true,
+		}})
+		require.NoError(t, err)
+
+		assert.Len(t, app.DeletedSessions(), 1)
+		session := app.SessionByName(testChannel)
+		assert.True(t, session.Config.EnableMls)
+		assert.Equal(t, []string{testParticipant}, session.Participants())
+	})
+
+	t.Run("deletes the channels not in the configuration", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, createChannel(other, false)).Success)
+
+		err := s.Reconcile(t.Context(), []ChannelConfig{{Name: testChannel, Participants: []string{testParticipant}}})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{testChannel}, s.channels.ListSessionNames(t.Context()))
+		assert.Len(t, app.DeletedSessions(), 1)
+	})
+
+	t.Run("goes on after a failure", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		app.SessionByName(testChannel).InviteErr = assert.AnError
+
+		err := s.Reconcile(t.Context(), []ChannelConfig{
+			{Name: testChannel, Participants: []string{testParticipant}},
+			{Name: other, Participants: []string{testParticipant}},
+		})
+		require.ErrorIs(t, err, assert.AnError)
+
+		assert.Equal(t, []string{testParticipant}, app.SessionByName(other).Participants())
+	})
+}
+
+func TestServer_ServeReconciliation(t *testing.T) {
+	s, app := newTestServer()
+
+	var loads atomic.Int32
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		s.ServeReconciliation(ctx, 10*time.Millisecond, func() ([]ChannelConfig, error) {
+			// the first round is skipped
+			if loads.Add(1) == 1 {
+				return nil, assert.AnError
+			}
+			return []ChannelConfig{{Name: testChannel, Participants: []string{testParticipant}}}, nil
+		})
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		session := app.SessionByName(testChannel)
+		return session != nil && len(session.Participants()) == 1
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+}
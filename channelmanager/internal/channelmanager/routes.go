@@ -0,0 +1,202 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+const (
+	// defaultRouteAttempts is the number of attempts to set a route when the
+	// route settings do not set it
+	defaultRouteAttempts = 3
+	// defaultRouteRetryInterval is the interval before the first retry of a
+	// route when the route settings do not set it
+	defaultRouteRetryInterval = 500 * time.Millisecond
+)
+
+// RouteSettings are the settings of the routes set by the channel manager
+// towards the participants before they are invited, on the connection to
+// the SLIM node
+type RouteSettings struct {
+	// Do not set the routes to the participants, e.g. set by the SLIM control
+	// plane (optional)
+	Disabled bool `yaml:"disabled"`
+
+	// Number of attempts to set a route before the invitation fails, 3 if 0
+	// (optional)
+	MaxAttempts int `yaml:"max-attempts"`
+
+	// Interval before the first retry of a route, doubled after each failed
+	// retry, 500ms if 0 (optional)
+	RetryInterval time.Duration `yaml:"retry-interval"`
+}
+
+// Validate checks if the route settings are valid
+func (cfg *RouteSettings) Validate() error {
+	if cfg.MaxAttempts < 0 {
+		return errors.New("max attempts cannot be negative")
+	}
+	if cfg.RetryInterval < 0 {
+		return errors.New("retry interval cannot be negative")
+	}
+	return nil
+}
+
+// attempts returns the number of attempts to set a route
+func (cfg *RouteSettings) attempts() int {
+	if cfg.MaxAttempts > 0 {
+		return cfg.MaxAttempts
+	}
+	return defaultRouteAttempts
+}
+
+// retryInterval returns the interval before the first retry of a route
+func (cfg *RouteSettings) retryInterval() time.Duration {
+	if cfg.RetryInterval > 0 {
+		return cfg.RetryInterval
+	}
+	return defaultRouteRetryInterval
+}
+
+// RouteState is the state of the route to a participant
+type RouteState struct {
+	Status ParticipantStatus_RouteStatus
+	// Attempts is the number of attempts made to set the route
+	Attempts int
+	// Err is the error of the last failed attempt, nil if none
+	Err error
+}
+
+// routeEntry is a route recorded by the RouteTable
+type routeEntry struct {
+	name  *slim.Name
+	state RouteState
+	// whether the route has been set once, it then remains on the SLIM node
+	// even if setting it again fails
+	set bool
+}
+
+// RouteTable records the routes set by the channel manager on the SLIM node.
+// The SLIM bindings do not expose the routes of an app, so the table is the
+// reference used to find the orphaned routes.
+type RouteTable struct {
+	mutex  sync.Mutex
+	routes map[string]*routeEntry
+}
+
+// NewRouteTable creates an empty RouteTable
+func NewRouteTable() *RouteTable {
+	return &RouteTable{routes: make(map[string]*routeEntry)}
+}
+
+// Set sets the route to name on the connection connID of app, retrying the
+// failed attempts with a doubling interval as configured by settings, and
+// records the state of the route along the way. Nothing is set when the
+// routes are disabled.
+func (t *RouteTable) Set(
+	ctx context.Context, app slimcommon.App, connID uint64, name *slim.Name, settings RouteSettings,
+) error {
+	if settings.Disabled {
+		return nil
+	}
+
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	interval := settings.retryInterval()
+	var err error
+	for attempt := 1; ; attempt++ {
+		t.update(name, RouteState{Status: ParticipantStatus_ROUTE_PENDING, Attempts: attempt, Err: err})
+		if err = app.SetRoute(name, connID); err == nil {
+			t.update(name, RouteState{Status: ParticipantStatus_ROUTE_SET, Attempts: attempt})
+			return nil
+		}
+		if attempt >= settings.attempts() {
+			t.update(name, RouteState{Status: ParticipantStatus_ROUTE_FAILED, Attempts: attempt, Err: err})
+			return fmt.Errorf("failed to set route for participant %s after %d attempts: %w",
+				slimcommon.JoinID(name), attempt, err)
+		}
+
+		logger.Warn("Failed to set route, retrying",
+			zap.String("participant", slimcommon.JoinID(name)),
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", interval),
+			zap.Error(err))
+		select {
+		case <-ctx.Done():
+			t.update(name, RouteState{Status: ParticipantStatus_ROUTE_FAILED, Attempts: attempt, Err: err})
+			return fmt.Errorf("failed to set route for participant %s: %w", slimcommon.JoinID(name), ctx.Err())
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+}
+
+// update records the state of the route to name. A route set once stays set
+// when setting it again fails, as it remains on the SLIM node.
+func (t *RouteTable) update(name *slim.Name, state RouteState) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	id := slimcommon.JoinID(name)
+	entry, ok := t.routes[id]
+	if !ok {
+		entry = &routeEntry{name: name}
+		t.routes[id] = entry
+	}
+	if state.Status == ParticipantStatus_ROUTE_SET {
+		entry.set = true
+	}
+	if entry.set && state.Status != ParticipantStatus_ROUTE_PENDING {
+		state.Status = ParticipantStatus_ROUTE_SET
+	}
+	entry.state = state
+}
+
+// Add records a route to name set without the table
+func (t *RouteTable) Add(name *slim.Name) {
+	t.update(name, RouteState{Status: ParticipantStatus_ROUTE_SET, Attempts: 1})
+}
+
+// Remove forgets the route to name
+func (t *RouteTable) Remove(name *slim.Name) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.routes, slimcommon.JoinID(name))
+}
+
+// State returns the state of the route to name, with the
+// ROUTE_STATUS_UNSPECIFIED status if the channel manager did not set it
+func (t *RouteTable) State(name *slim.Name) RouteState {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if entry, ok := t.routes[slimcommon.JoinID(name)]; ok {
+		return entry.state
+	}
+	return RouteState{}
+}
+
+// List returns the routes set on the SLIM node sorted by name
+func (t *RouteTable) List() []*slim.Name {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	names := make([]*slim.Name, 0, len(t.routes))
+	for _, id := range slices.Sorted(maps.Keys(t.routes)) {
+		if t.routes[id].set {
+			names = append(names, t.routes[id].name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,137 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// fastRoutes retries the routes without waiting long
+var fastRoutes = RouteSettings{MaxAttempts: 3, RetryInterval: time.Millisecond}
+
+func TestRouteTable_Set(t *testing.T) {
+	participant, err := slimcommon.SplitID(testParticipant)
+	require.NoError(t, err)
+
+	t.Run("retried", func(t *testing.T) {
+		app := testutil.NewFakeApp()
+		app.SetRouteErr = errors.New("no connection")
+		app.SetRouteFailures = 2
+		routes := NewRouteTable()
+
+		require.NoError(t, routes.Set(t.Context(), app, 1, participant, fastRoutes))
+		assert.Equal(t, []string{testParticipant}, app.Routes())
+		assert.Equal(t, RouteState{Status: ParticipantStatus_ROUTE_SET, Attempts: 3}, routes.State(participant))
+		assert.Len(t, routes.List(), 1)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		app := testutil.NewFakeApp()
+		app.SetRouteErr = errors.New("no connection")
+		routes := NewRouteTable()
+
+		err := routes.Set(t.Context(), app, 1, participant, fastRoutes)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "after 3 attempts")
+		state := routes.State(participant)
+		assert.Equal(t, ParticipantStatus_ROUTE_FAILED, state.Status)
+		assert.Equal(t, 3, state.Attempts)
+		assert.Equal(t, app.SetRouteErr, state.Err)
+		assert.Empty(t, routes.List(), "the audit only considers the routes set")
+
+		// a route set once remains on the node
+		routes.Add(participant)
+		require.Error(t, routes.Set(t.Context(), app, 1, participant, fastRoutes))
+		assert.Equal(t, ParticipantStatus_ROUTE_SET, routes.State(participant).Status)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		app := testutil.NewFakeApp()
+		app.SetRouteErr = errors.New("no connection")
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		err := NewRouteTable().Set(ctx, app, 1, participant, RouteSettings{MaxAttempts: 3, RetryInterval: time.Hour})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		app := testutil.NewFakeApp()
+		routes := NewRouteTable()
+
+		require.NoError(t, routes.Set(t.Context(), app, 1, participant, RouteSettings{Disabled: true}))
+		assert.Empty(t, app.Routes())
+		assert.Equal(t, RouteState{}, routes.State(participant))
+	})
+}
+
+// TestServer_RouteStatus tests the route status of the participants reported
+// in the channel details
+func TestServer_RouteStatus(t *testing.T) {
+	routeStatus := func(t *testing.T, s *Server) *ParticipantStatus {
+		t.Helper()
+		resp, err := s.Command(t.Context(), getChannel(testChannel))
+		require.NoError(t, err)
+		participants := resp.GetGetChannelResponse().Participant
+		require.Len(t, participants, 1)
+		return participants[0]
+	}
+
+	t.Run("retried", func(t *testing.T) {
+		app := testutil.NewFakeApp()
+		s := NewChannelManagerServer(app, 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+			WithRouteSettings(fastRoutes))
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		app.SetRouteErr = errors.New("no connection")
+		app.SetRouteFailures = 1
+
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		status := routeStatus(t, s)
+		assert.Equal(t, ParticipantStatus_JOINED, status.Status)
+		assert.Equal(t, ParticipantStatus_ROUTE_SET, status.RouteStatus)
+		assert.Equal(t, uint32(2), status.RouteAttempts)
+		assert.Empty(t, status.RouteError)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		app := testutil.NewFakeApp()
+		s := NewChannelManagerServer(app, 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+			WithRouteSettings(fastRoutes))
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		app.SetRouteErr = errors.New("no connection")
+
+		resp := command(t, s, addParticipant(testChannel, testParticipant))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "no connection")
+		assert.Empty(t, app.SessionByName(testChannel).Participants(), "the participant is not invited")
+	})
+
+	t.Run("unmanaged", func(t *testing.T) {
+		app := testutil.NewFakeApp()
+		s := NewChannelManagerServer(app, 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+			WithRouteSettings(RouteSettings{Disabled: true}))
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		assert.Empty(t, app.Routes())
+		assert.Equal(t, ParticipantStatus_ROUTE_UNMANAGED, routeStatus(t, s).RouteStatus)
+	})
+}
+
+func TestRouteSettings_Validate(t *testing.T) {
+	require.NoError(t, fastRoutes.Validate())
+	require.NoError(t, (&RouteSettings{}).Validate())
+	assert.Error(t, (&RouteSettings{MaxAttempts: -1}).Validate())
+	assert.Error(t, (&RouteSettings{RetryInterval: -time.Second}).Validate())
+}
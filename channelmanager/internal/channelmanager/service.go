@@ -6,6 +6,9 @@ package channelmanager
 import (
 	"context"
 	"fmt"
+	"math"
+	"slices"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -14,21 +17,137 @@ import (
 	slimcommon "github.com/agntcy/slim-otel/internal/slim"
 )
 
+// defaultAdoptTimeout is the time to wait for the invitation to an adopted
+// channel when the request does not set it
+const defaultAdoptTimeout = 5 * time.Second
+
 // Server implements the ChannelManagerService gRPC service
 type Server struct {
 	UnimplementedChannelManagerServiceServer
-	app      *slim.App
-	connID   uint64
-	channels *slimcommon.SessionsList
+	app       slimcommon.App
+	connID    uint64
+	channels  *slimcommon.SessionsList
+	telemetry *Telemetry
+	routes    *RouteTable
+	// establishment of the routes to the participants before they are invited
+	routeSettings RouteSettings
+	// state of the channels created through the service, nil if not persisted
+	state *channelStates
+	// events of the channels streamed to the watchers
+	events *eventBroker
+	// activity of the channels reported in their details
+	registry *channelRegistry
+	// deadlines of the channels with a time to live
+	expiry *channelExpiry
+	// invitations sent to the participants, the failed ones being retried
+	invites *inviteTracker
+	// retries of the failed invitations
+	inviteSettings InviteSettings
+	// serializes the adoptions, which listen for the invitations of the app
+	adoptMutex sync.Mutex
+	// held for reading while a route is set and the participant invited, and
+	// for writing by the routes audit so that a route being set is not
+	// reported as orphaned
+	routesMutex sync.RWMutex
+	// retransmission settings of the channels that do not override them
+	sessionDefaults SessionSettings
+	// time to wait for the invitation to an adopted channel by default
+	adoptTimeout time.Duration
+	// quotas and policies of the namespaces, nil if not enforced
+	namespaces *namespaces
+	// patterns of the channels whose new participants must be approved
+	protectedChannels []string
+	// submits the approval requests, nil if they are only reported
+	approver Approver
+	// additions of participants awaiting an approval
+	approvals *approvals
+	// name of the channel manager advertised as the initiator of the
+	// channels it creates, empty if not advertised
+	localName string
+}
+
+// ServerOption applies a configuration option to the Server
+type ServerOption func(*Server)
+
+// WithTelemetry sets the instruments used to report the control plane latencies
+func WithTelemetry(telemetry *Telemetry) ServerOption {
+	return func(s *Server) {
+		s.telemetry = telemetry
+	}
+}
+
+// WithLocalName sets the name of the channel manager, advertised to the
+// participants as the initiator of the channels it creates
+func WithLocalName(name string) ServerOption {
+	return func(s *Server) {
+		s.localName = name
+	}
+}
+
+// WithRoutes sets the table of the routes set by the channel manager, e.g.
+// filled while creating the channels of the configuration file
+func WithRoutes(routes *RouteTable) ServerOption {
+	return func(s *Server) {
+		s.routes = routes
+	}
+}
+
+// WithRouteSettings sets how the routes to the participants are set before
+// they are invited
+func WithRouteSettings(settings RouteSettings) ServerOption {
+	return func(s *Server) {
+		s.routeSettings = settings
+	}
+}
+
+// WithStateStore persists the channels created through the service, and their
+// participants, to store. See Restore to recreate them.
+func WithStateStore(store StateStore) ServerOption {
+	return func(s *Server) {
+		s.state = newChannelStates(store)
+	}
+}
+
+// WithSessionDefaults sets the retransmission settings of the group sessions
+// of the channels created through the service
+func WithSessionDefaults(defaults SessionSettings) ServerOption {
+	return func(s *Server) {
+		s.sessionDefaults = defaults
+	}
+}
+
+// WithAdoptTimeout sets the time to wait for the invitation to an adopted
+// channel when the request does not set it
+func WithAdoptTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.adoptTimeout = timeout
+	}
 }
 
 // NewChannelManagerServer creates a new Server instance
-func NewChannelManagerServer(app *slim.App, connID uint64, channels *slimcommon.SessionsList) *Server {
-	return &Server{
-		app:      app,
-		connID:   connID,
-		channels: channels,
+func NewChannelManagerServer(
+	app slimcommon.App,
+	connID uint64,
+	channels *slimcommon.SessionsList,
+	opts ...ServerOption,
+) *Server {
+	s := &Server{
+		app:       app,
+		connID:    connID,
+		channels:  channels,
+		routes:    NewRouteTable(),
+		events:    newEventBroker(),
+		registry:  newChannelRegistry(channels),
+		expiry:    newChannelExpiry(),
+		invites:   newInviteTracker(),
+		approvals: newApprovals(),
+
+		adoptTimeout: defaultAdoptTimeout,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Command handles incoming control messages
@@ -36,6 +155,10 @@ func (s *Server) Command(ctx context.Context, req *ControlRequest) (*ControlResp
 	logger := slimcommon.LoggerFromContextOrDefault(ctx)
 	logger.Info("Received command", zap.Uint64("msg_id", req.MgsId))
 
+	if err := authorize(ctx, req); err != nil {
+		return nil, err
+	}
+
 	switch payload := req.Payload.(type) {
 	case *ControlRequest_CreateChannelRequest:
 		return s.handleCreateChannel(ctx, req.MgsId, payload.CreateChannelRequest)
@@ -49,6 +172,26 @@ func (s *Server) Command(ctx context.Context, req *ControlRequest) (*ControlResp
 		return s.handleListChannels(ctx, req.MgsId, payload.ListChannelRequest)
 	case *ControlRequest_ListParticipantsRequest:
 		return s.handleListParticipants(ctx, req.MgsId, payload.ListParticipantsRequest)
+	case *ControlRequest_AdoptChannelRequest:
+		return s.handleAdoptChannel(ctx, req.MgsId, payload.AdoptChannelRequest)
+	case *ControlRequest_AuditRoutesRequest:
+		return s.handleAuditRoutes(ctx, req.MgsId, payload.AuditRoutesRequest)
+	case *ControlRequest_DrainParticipantRequest:
+		return s.handleDrainParticipant(ctx, req.MgsId, payload.DrainParticipantRequest)
+	case *ControlRequest_UpdateChannelRequest:
+		return s.handleUpdateChannel(ctx, req.MgsId, payload.UpdateChannelRequest)
+	case *ControlRequest_GetChannelRequest:
+		return s.handleGetChannel(ctx, req.MgsId, payload.GetChannelRequest)
+	case *ControlRequest_FreezeChannelRequest:
+		return s.handleFreezeChannel(ctx, req.MgsId, payload.FreezeChannelRequest)
+	case *ControlRequest_UnfreezeChannelRequest:
+		return s.handleUnfreezeChannel(ctx, req.MgsId, payload.UnfreezeChannelRequest)
+	case *ControlRequest_ResolveApprovalRequest:
+		return s.handleResolveApproval(ctx, req.MgsId, payload.ResolveApprovalRequest)
+	case *ControlRequest_AddParticipantsRequest:
+		return s.handleAddParticipants(ctx, req.MgsId, payload.AddParticipantsRequest)
+	case *ControlRequest_RemoveParticipantsRequest:
+		return s.handleRemoveParticipants(ctx, req.MgsId, payload.RemoveParticipantsRequest)
 	default:
 		return s.errorResponse(req.MgsId, "unknown command type")
 	}
@@ -69,31 +212,108 @@ func (s *Server) handleCreateChannel(
 		return s.errorResponse(msgID, fmt.Sprintf("channel %s already exists", channelStr))
 	}
 
-	// create a new session for the channel
-	interval := time.Millisecond * 1000
-	maxRetries := uint32(10)
-	sessionConfig := slim.SessionConfig{
-		SessionType: slim.SessionTypeGroup,
-		EnableMls:   req.MlsEnabled,
-		MaxRetries:  &maxRetries,
-		Interval:    &interval,
-		Metadata:    make(map[string]string),
+	if err := s.checkChannelQuota(ctx, req.ChannelName); err != nil {
+		return s.errorResponse(msgID, err.Error())
 	}
+	mlsEnabled := req.MlsEnabled || s.namespaces.settings(req.ChannelName).mlsRequired()
 
-	session, err := s.app.CreateSessionAndWait(sessionConfig, channel)
+	settings, err := requestSessionSettings(req)
 	if err != nil {
-		return s.errorResponse(msgID, fmt.Sprintf("failed to create channel %s", channelStr))
+		return s.errorResponse(msgID, err.Error())
 	}
-
-	err = s.channels.AddSession(ctx, session)
+	ttl, err := requestTTL(req)
 	if err != nil {
-		_ = s.app.DeleteSessionAndWait(session)
-		return s.errorResponse(msgID, fmt.Sprintf("failed to complete channel %s creation ", channelStr))
+		return s.errorResponse(msgID, err.Error())
+	}
+
+	// create a new session for the channel
+	if _, err := s.createChannel(ctx, channel, mlsEnabled, settings); err != nil {
+		return s.errorResponse(msgID, err.Error())
+	}
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+		s.expiry.set(channelStr, expires)
+	} else {
+		s.expiry.forget(channelStr)
 	}
+	s.saveState(ctx, s.state.addChannel(slimcommon.JoinID(channel), mlsEnabled, settings, expires))
 
-	slimcommon.LoggerFromContextOrDefault(ctx).Info("Created channel", zap.String("channel", channelStr))
+	slimcommon.LoggerFromContextOrDefault(ctx).Info("Created channel",
+		zap.String("channel", channelStr),
+		zap.Duration("ttl", ttl))
 	return s.successResponse(msgID)
+}
 
+// createChannel creates the group session of a channel with the session
+// settings, the session defaults applying to the unset ones, see openChannel
+func (s *Server) createChannel(
+	ctx context.Context, channel *slim.Name, mlsEnabled bool, settings SessionSettings,
+) (slimcommon.Session, error) {
+	return s.openChannel(ctx, channel, ChannelSessionConfig(mlsEnabled, settings.WithDefaults(s.sessionDefaults)))
+}
+
+// requestSessionSettings returns the session settings set by a create
+// channel request
+func requestSessionSettings(req *CreateChannelRequest) (SessionSettings, error) {
+	settings := SessionSettings{MaxRetries: req.MaxRetries}
+	if req.RetryIntervalMs != nil {
+		if *req.RetryIntervalMs > uint64(math.MaxInt64/int64(time.Millisecond)) {
+			return SessionSettings{}, fmt.Errorf("invalid retry interval: %d ms", *req.RetryIntervalMs)
+		}
+		settings.Interval = time.Duration(*req.RetryIntervalMs) * time.Millisecond //nolint:gosec // checked above
+	}
+	return settings, nil
+}
+
+// requestTTL returns the time to live set by a create channel request, 0 if
+// the channel does not expire
+func requestTTL(req *CreateChannelRequest) (time.Duration, error) {
+	if req.TtlMs == nil {
+		return 0, nil
+	}
+	if *req.TtlMs > uint64(math.MaxInt64/int64(time.Millisecond)) {
+		return 0, fmt.Errorf("invalid time to live: %d ms", *req.TtlMs)
+	}
+	return time.Duration(*req.TtlMs) * time.Millisecond, nil //nolint:gosec // checked above
+}
+
+// openChannel creates the group session of a channel with config and adds it
+// to the channels list. The channel manager is advertised as the initiator
+// of the session when its name is set.
+func (s *Server) openChannel(
+	ctx context.Context, channel *slim.Name, config slim.SessionConfig,
+) (slimcommon.Session, error) {
+	channelStr := channel.String()
+	if s.localName != "" {
+		if config.Metadata == nil {
+			config.Metadata = make(map[string]string)
+		}
+		config.Metadata[slimcommon.MetadataInitiator] = s.localName
+	}
+
+	start := time.Now()
+	session, err := s.app.CreateSessionAndWait(config, channel)
+	s.telemetry.RecordCreateSession(ctx, channelStr, start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create channel %s", channelStr)
+	}
+
+	if err := s.channels.AddSession(ctx, session); err != nil {
+		_ = s.app.DeleteSessionAndWait(session)
+		return nil, fmt.Errorf("failed to complete channel %s creation ", channelStr)
+	}
+	s.registry.created(channelStr)
+	s.events.publish(ChannelEvent_CHANNEL_CREATED, channelStr, "")
+	return session, nil
+}
+
+// saveState logs the failure to persist the state of the channels. The
+// command itself succeeded, the change is lost on restart only.
+func (s *Server) saveState(ctx context.Context, err error) {
+	if err != nil {
+		slimcommon.LoggerFromContextOrDefault(ctx).Warn("Failed to save the channels state", zap.Error(err))
+	}
 }
 
 // handleDeleteChannel deletes a channel
@@ -105,22 +325,37 @@ func (s *Server) handleDeleteChannel(
 		return s.errorResponse(msgID, fmt.Sprintf("invalid channel name: %s", req.ChannelName))
 	}
 
+	if err = s.deleteChannel(ctx, channel); err != nil {
+		return s.errorResponse(msgID, err.Error())
+	}
+
+	slimcommon.LoggerFromContextOrDefault(ctx).Info("Deleted channel", zap.String("channel", channel.String()))
+	return s.successResponse(msgID)
+}
+
+// deleteChannel deletes the group session of a channel and forgets it
+func (s *Server) deleteChannel(ctx context.Context, channel *slim.Name) error {
 	channelStr := channel.String()
 
 	session, err := s.channels.RemoveSessionByName(ctx, channelStr)
 	if err != nil {
-		return s.errorResponse(msgID, fmt.Sprintf("failed to delete channel %s: %v", channelStr, err))
+		return fmt.Errorf("failed to delete channel %s: %v", channelStr, err)
 	}
 
 	if err = s.app.DeleteSessionAndWait(session); err != nil {
-		return s.errorResponse(msgID, fmt.Sprintf("failed to delete channel %s: %v", channelStr, err))
+		return fmt.Errorf("failed to delete channel %s: %v", channelStr, err)
 	}
-
-	slimcommon.LoggerFromContextOrDefault(ctx).Info("Deleted channel", zap.String("channel", channelStr))
-	return s.successResponse(msgID)
+	s.saveState(ctx, s.state.removeChannel(slimcommon.JoinID(channel)))
+	s.registry.deleted(channelStr)
+	s.expiry.forget(channelStr)
+	s.invites.forgetChannel(channelStr)
+	s.approvals.drop(channelStr)
+	s.events.publish(ChannelEvent_CHANNEL_DELETED, channelStr, "")
+	return nil
 }
 
-// handleAddParticipant adds a participant to a channel
+// handleAddParticipant adds a participant to a channel. The participant of
+// a protected channel is only invited once approved.
 func (s *Server) handleAddParticipant(
 	ctx context.Context, msgID uint64, req *AddParticipantRequest,
 ) (*ControlResponse, error) {
@@ -136,29 +371,97 @@ func (s *Server) handleAddParticipant(
 		return s.errorResponse(msgID, fmt.Sprintf("failed to get channel %s: %v", channelStr, err))
 	}
 
-	participantName, err := slimcommon.SplitID(req.ParticipantName)
+	pending, err := s.addParticipant(ctx, session, channel, req.ParticipantName)
+	if err != nil {
+		return s.errorResponse(msgID, err.Error())
+	}
+	if pending {
+		return s.pendingApprovalResponse(msgID)
+	}
+	return s.successResponse(msgID)
+}
+
+// addParticipant invites a participant to the channel of session, or parks
+// its addition until approved if the channel is protected
+func (s *Server) addParticipant(
+	ctx context.Context, session slimcommon.Session, channel *slim.Name, participantName string,
+) (pendingApproval bool, err error) {
+	participant, err := slimcommon.SplitID(participantName)
 	if err != nil {
-		return s.errorResponse(msgID, fmt.Sprintf("invalid participant name: %s", req.ParticipantName))
+		return false, fmt.Errorf("invalid participant name: %s", participantName)
+	}
+
+	if err = s.checkParticipantCapacity(session, slimcommon.JoinID(channel)); err != nil {
+		return false, err
 	}
 
-	if err = s.app.SetRoute(participantName, s.connID); err != nil {
-		return s.errorResponse(msgID, fmt.Sprintf("failed to set route for participant %s: %v", req.ParticipantName, err))
+	if s.requiresApproval(channel) {
+		return true, s.parkParticipant(ctx, channel, participant)
 	}
 
-	if err = session.InviteAndWait(participantName); err != nil {
-		return s.errorResponse(
-			msgID,
-			fmt.Sprintf("failed to invite participant %s to channel %s: %v",
-				req.ParticipantName, channelStr, err))
+	if err = s.invite(ctx, session, channel, participant); err != nil {
+		return false, err
 	}
+	s.saveState(ctx, s.state.addParticipant(slimcommon.JoinID(channel), slimcommon.JoinID(participant)))
 
 	slimcommon.LoggerFromContextOrDefault(ctx).Info("Participant added",
-		zap.String("channel", channelStr),
-		zap.String("participant", req.ParticipantName))
-	return s.successResponse(msgID)
+		zap.String("channel", channel.String()),
+		zap.String("participant", participantName))
+	return false, nil
+}
+
+// checkParticipantCapacity checks that the namespace quota of the channel of
+// session allows one more participant
+func (s *Server) checkParticipantCapacity(session slimcommon.Session, channelName string) error {
+	if s.namespaces.settings(channelName).MaxParticipants == 0 {
+		return nil
+	}
+	current, err := session.ParticipantsList()
+	if err != nil {
+		return fmt.Errorf("failed to list participants for channel %s: %v", channelName, err)
+	}
+	return s.checkParticipantQuota(channelName, len(current)+1)
+}
+
+// invite sets the route to a participant and invites it to the channel. A
+// failed invitation is retried in the background, see ServeInviteRetries.
+func (s *Server) invite(ctx context.Context, session slimcommon.Session, channel, participant *slim.Name) error {
+	s.invites.start(channel.String(), participant)
+	return s.sendInvite(ctx, session, channel, participant)
 }
 
-// handleDeleteParticipant removes a participant from a channel
+// sendInvite makes an attempt to set the route to a participant and invite
+// it to the channel, and records its outcome in the invite tracker
+func (s *Server) sendInvite(ctx context.Context, session slimcommon.Session, channel, participant *slim.Name) error {
+	s.routesMutex.RLock()
+	defer s.routesMutex.RUnlock()
+
+	participantID := slimcommon.JoinID(participant)
+	s.registry.inviting(channel.String(), participantID, participant.String())
+	if err := s.routes.Set(ctx, s.app, s.connID, participant, s.routeSettings); err != nil {
+		s.registry.invited(channel.String(), participantID)
+		s.inviteFailed(ctx, channel, participant, err)
+		return err
+	}
+
+	start := time.Now()
+	err := session.InviteAndWait(participant)
+	s.telemetry.RecordInvite(ctx, channel.String(), start, err)
+	s.registry.invited(channel.String(), participantID)
+	if err != nil {
+		err = fmt.Errorf("failed to invite participant %s to channel %s: %w",
+			slimcommon.JoinID(participant), channel.String(), err)
+		s.inviteFailed(ctx, channel, participant, err)
+		return err
+	}
+	s.invites.joined(channel.String(), participant)
+	s.events.publish(ChannelEvent_PARTICIPANT_JOINED, channel.String(), participant.String())
+	s.notifyFrozen(ctx, session, channel.String())
+	return nil
+}
+
+// handleDeleteParticipant removes a participant from a channel, or withdraws
+// the addition of the participant awaiting an approval
 func (s *Server) handleDeleteParticipant(
 	ctx context.Context, msgID uint64, req *DeleteParticipantRequest,
 ) (*ControlResponse, error) {
@@ -174,29 +477,167 @@ func (s *Server) handleDeleteParticipant(
 		return s.errorResponse(msgID, fmt.Sprintf("failed to get channel %s: %v", channelStr, err))
 	}
 
-	participantName, err := slimcommon.SplitID(req.ParticipantName)
+	if err = s.deleteParticipant(ctx, session, channel, req.ParticipantName); err != nil {
+		return s.errorResponse(msgID, err.Error())
+	}
+	return s.successResponse(msgID)
+}
+
+// inviteFailed records a failed invitation and logs when it is retried
+func (s *Server) inviteFailed(ctx context.Context, channel, participant *slim.Name, err error) {
+	if s.invites.failed(channel.String(), participant, err, s.inviteSettings) {
+		slimcommon.LoggerFromContextOrDefault(ctx).Warn("Failed to invite participant, retrying in the background",
+			zap.String("channel", channel.String()),
+			zap.String("participant", slimcommon.JoinID(participant)),
+			zap.Error(err))
+	}
+}
+
+// deleteParticipant removes a participant from the channel of session, or
+// withdraws its addition awaiting an approval or its failed invitation
+func (s *Server) deleteParticipant(
+	ctx context.Context, session slimcommon.Session, channel *slim.Name, participantName string,
+) error {
+	participant, err := slimcommon.SplitID(participantName)
 	if err != nil {
-		return s.errorResponse(msgID, fmt.Sprintf("invalid participant name: %s", req.ParticipantName))
+		return fmt.Errorf("invalid participant name: %s", participantName)
+	}
+
+	channelStr := channel.String()
+	if s.approvals.take(channelStr, slimcommon.JoinID(participant)) {
+		s.events.publish(ChannelEvent_APPROVAL_REJECTED, channelStr, participant.String())
+		slimcommon.LoggerFromContextOrDefault(ctx).Info("Participant approval withdrawn",
+			zap.String("channel", channelStr),
+			zap.String("participant", participantName))
+		return nil
+	}
+	if s.invites.forget(channelStr, slimcommon.JoinID(participant)) {
+		s.saveState(ctx, s.state.removeParticipant(slimcommon.JoinID(channel), slimcommon.JoinID(participant)))
+		slimcommon.LoggerFromContextOrDefault(ctx).Info("Failed invitation withdrawn",
+			zap.String("channel", channelStr),
+			zap.String("participant", participantName))
+		return nil
 	}
 
-	if err = session.RemoveAndWait(participantName); err != nil {
-		return s.errorResponse(
-			msgID,
-			fmt.Sprintf("failed to remove participant %s from channel %s: %v",
-				req.ParticipantName, channelStr, err))
+	if err = s.removeParticipant(ctx, session, channel, participant); err != nil {
+		return err
 	}
 
 	slimcommon.LoggerFromContextOrDefault(ctx).Info("Participant deleted",
 		zap.String("channel", channelStr),
-		zap.String("participant", req.ParticipantName))
-	return s.successResponse(msgID)
+		zap.String("participant", participantName))
+	return nil
+}
+
+// removeParticipant removes a participant from the channel
+func (s *Server) removeParticipant(
+	ctx context.Context, session slimcommon.Session, channel, participant *slim.Name,
+) error {
+	if err := session.RemoveAndWait(participant); err != nil {
+		return fmt.Errorf("failed to remove participant %s from channel %s: %v",
+			slimcommon.JoinID(participant), channel.String(), err)
+	}
+	s.saveState(ctx, s.state.removeParticipant(slimcommon.JoinID(channel), slimcommon.JoinID(participant)))
+	s.invites.forget(channel.String(), slimcommon.JoinID(participant))
+	s.registry.touch(channel.String())
+	s.events.publish(ChannelEvent_PARTICIPANT_LEFT, channel.String(), participant.String())
+	return nil
+}
+
+// handleAdoptChannel registers a channel created by another participant.
+// The creator invites the channel manager to the channel, the session is
+// then managed like the channels created by the channel manager, except
+// that only the creator can change its participants.
+func (s *Server) handleAdoptChannel(
+	ctx context.Context, msgID uint64, req *AdoptChannelRequest,
+) (*ControlResponse, error) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	channel, err := slimcommon.SplitID(req.ChannelName)
+	if err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("invalid channel name: %s", req.ChannelName))
+	}
+
+	channelStr := channel.String()
+	if _, existsErr := s.channels.GetSessionByName(ctx, channelStr); existsErr == nil {
+		return s.errorResponse(msgID, fmt.Sprintf("channel %s already exists", channelStr))
+	}
+
+	if err := s.checkChannelQuota(ctx, req.ChannelName); err != nil {
+		return s.errorResponse(msgID, err.Error())
+	}
+
+	timeout := s.adoptTimeout
+	if req.TimeoutMs > 0 {
+		timeout = time.Duration(req.TimeoutMs) * time.Millisecond
+	}
+
+	s.adoptMutex.Lock()
+	defer s.adoptMutex.Unlock()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 || ctx.Err() != nil {
+			return s.errorResponse(msgID,
+				fmt.Sprintf("no invitation to channel %s received within %s", channelStr, timeout))
+		}
+
+		session, listenErr := s.app.ListenForSession(&remaining)
+		if listenErr != nil {
+			// timeout waiting for a session, checked above
+			continue
+		}
+
+		invitedTo := ""
+		if name, nameErr := session.Destination(); nameErr == nil {
+			invitedTo = name.String()
+		}
+		if invitedTo != channelStr {
+			logger.Warn("Rejecting invitation to a channel that is not being adopted",
+				zap.String("channel", channelStr),
+				zap.String("invited_to", invitedTo))
+			_ = s.app.DeleteSessionAndWait(session)
+			continue
+		}
+
+		// the creator chose the session config, MLS cannot be enabled afterwards
+		if s.namespaces.settings(req.ChannelName).mlsRequired() {
+			if config, configErr := session.SessionConfig(); configErr != nil || !config.EnableMls {
+				_ = s.app.DeleteSessionAndWait(session)
+				return s.errorResponse(msgID,
+					fmt.Sprintf("channel %s does not use MLS, which its namespace requires", channelStr))
+			}
+		}
+
+		if err = s.channels.AddSession(ctx, session); err != nil {
+			_ = s.app.DeleteSessionAndWait(session)
+			return s.errorResponse(msgID, fmt.Sprintf("failed to adopt channel %s: %v", channelStr, err))
+		}
+		s.registry.created(channelStr)
+		s.events.publish(ChannelEvent_CHANNEL_CREATED, channelStr, "")
+
+		policy := slimcommon.ChannelPolicy{}
+		if metadata, mdErr := session.Metadata(); mdErr == nil {
+			policy, _ = slimcommon.PolicyFromMetadata(metadata)
+		}
+		logger.Info("Adopted channel",
+			zap.String("channel", channelStr),
+			zap.Int("max_message_size", policy.MaxMessageSize),
+			zap.Float64("max_message_rate", policy.MaxMessageRate))
+		return s.successResponse(msgID)
+	}
 }
 
 // handleListChannels returns a list of all channels
 func (s *Server) handleListChannels(
 	ctx context.Context, msgID uint64, _ *ListChannelsRequest,
 ) (*ControlResponse, error) {
-	channels := s.channels.ListSessionNames(ctx)
+	// the clients bound to namespaces only see their channels
+	p := principalFromContext(ctx)
+	channels := slices.DeleteFunc(s.channels.ListSessionNames(ctx), func(channel string) bool {
+		return !p.allows(namespaceOf(channel), false)
+	})
 
 	slimcommon.LoggerFromContextOrDefault(ctx).Info("Listing channels",
 		zap.Int("count", len(channels)))
@@ -230,11 +671,71 @@ func (s *Server) handleListParticipants(
 		participantNames = append(participantNames, participant.String())
 	}
 
+	awaiting := s.approvals.names(channelStr)
+	invites := s.invites.list(channelStr)
+
 	slimcommon.LoggerFromContextOrDefault(ctx).Info("Listing participants",
 		zap.String("channel", channelStr),
-		zap.Int("count", len(participantNames)))
+		zap.Int("count", len(participantNames)),
+		zap.Int("awaiting_approval", len(awaiting)),
+		zap.Int("invites", len(invites)))
 
-	return s.listParticipantResponse(msgID, participantNames)
+	return s.listParticipantResponse(msgID, participantNames, awaiting, invites)
+}
+
+// handleAuditRoutes reports the routes set by the channel manager towards
+// names that are not a participant of any channel, and removes them from the
+// SLIM node when cleanup is requested
+func (s *Server) handleAuditRoutes(
+	ctx context.Context, msgID uint64, req *AuditRoutesRequest,
+) (*ControlResponse, error) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	s.routesMutex.Lock()
+	defer s.routesMutex.Unlock()
+
+	participants := make(map[string]struct{})
+	for _, channelStr := range s.channels.ListSessionNames(ctx) {
+		session, err := s.channels.GetSessionByName(ctx, channelStr)
+		if err != nil {
+			// the channel was deleted in the meantime
+			continue
+		}
+		names, err := session.ParticipantsList()
+		if err != nil {
+			return s.errorResponse(msgID, fmt.Sprintf("failed to list participants for channel %s: %v", channelStr, err))
+		}
+		for _, name := range names {
+			participants[slimcommon.JoinID(name)] = struct{}{}
+		}
+	}
+
+	routes := s.routes.List()
+	orphaned := make([]string, 0)
+	removed := make([]string, 0)
+	for _, route := range routes {
+		routeStr := slimcommon.JoinID(route)
+		if _, ok := participants[routeStr]; ok {
+			continue
+		}
+		orphaned = append(orphaned, routeStr)
+		if !req.Cleanup {
+			continue
+		}
+		if err := s.app.RemoveRoute(route, s.connID); err != nil {
+			logger.Warn("Failed to remove orphaned route", zap.String("route", routeStr), zap.Error(err))
+			continue
+		}
+		s.routes.Remove(route)
+		removed = append(removed, routeStr)
+	}
+
+	logger.Info("Audited routes",
+		zap.Int("routes", len(routes)),
+		zap.Strings("orphaned", orphaned),
+		zap.Strings("removed", removed))
+
+	return s.auditRoutesResponse(msgID, orphaned, removed)
 }
 
 // listChannelResponse creates a list channels response
@@ -254,14 +755,32 @@ func (s *Server) listChannelResponse(
 
 // listParticipantResponse creates a list participants response
 func (s *Server) listParticipantResponse(
-	msgID uint64, participantNames []string,
+	msgID uint64, participantNames, awaitingApproval []string, invites []*ParticipantInvite,
 ) (*ControlResponse, error) {
 	return &ControlResponse{
 		MgsId: msgID,
 		Payload: &ControlResponse_ListParticipantsResponse{
 			ListParticipantsResponse: &ListParticipantsResponse{
-				MsgId:           msgID,
-				ParticipantName: participantNames,
+				MsgId:            msgID,
+				ParticipantName:  participantNames,
+				AwaitingApproval: awaitingApproval,
+				Invite:           invites,
+			},
+		},
+	}, nil
+}
+
+// auditRoutesResponse creates an audit routes response
+func (s *Server) auditRoutesResponse(
+	msgID uint64, orphaned, removed []string,
+) (*ControlResponse, error) {
+	return &ControlResponse{
+		MgsId: msgID,
+		Payload: &ControlResponse_AuditRoutesResponse{
+			AuditRoutesResponse: &AuditRoutesResponse{
+				MsgId:         msgID,
+				OrphanedRoute: orphaned,
+				RemovedRoute:  removed,
 			},
 		},
 	}, nil
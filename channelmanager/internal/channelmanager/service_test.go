@@ -0,0 +1,660 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+const (
+	testChannel     = "agntcy/otel/channel"
+	testParticipant = "agntcy/otel/receiver"
+)
+
+// newTestServer creates a Server backed by a fake SLIM app
+func newTestServer() (*Server, *testutil.FakeApp) {
+	app := testutil.NewFakeApp()
+	return NewChannelManagerServer(app, 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown)), app
+}
+
+// command sends a request to the server and returns the CommandResponse payload
+func command(t *testing.T, s *Server, req *ControlRequest) *CommandResponse {
+	t.Helper()
+	resp, err := s.Command(t.Context(), req)
+	require.NoError(t, err)
+	require.Equal(t, req.MgsId, resp.MgsId)
+	payload, ok := resp.Payload.(*ControlResponse_CommandResponse)
+	require.True(t, ok, "unexpected response payload %T", resp.Payload)
+	return payload.CommandResponse
+}
+
+func createChannel(name string, mls bool) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 1,
+		Payload: &ControlRequest_CreateChannelRequest{
+			CreateChannelRequest: &CreateChannelRequest{ChannelName: name, MlsEnabled: mls},
+		},
+	}
+}
+
+func deleteChannel(name string) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 2,
+		Payload: &ControlRequest_DeleteChannelRequest{
+			DeleteChannelRequest: &DeleteChannelRequest{ChannelName: name},
+		},
+	}
+}
+
+func addParticipant(channel, participant string) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 3,
+		Payload: &ControlRequest_AddParticipantRequest{
+			AddParticipantRequest: &AddParticipantRequest{ChannelName: channel, ParticipantName: participant},
+		},
+	}
+}
+
+func deleteParticipant(channel, participant string) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 4,
+		Payload: &ControlRequest_DeleteParticipantRequest{
+			DeleteParticipantRequest: &DeleteParticipantRequest{ChannelName: channel, ParticipantName: participant},
+		},
+	}
+}
+
+func listChannels() *ControlRequest {
+	return &ControlRequest{
+		MgsId: 5,
+		Payload: &ControlRequest_ListChannelRequest{
+			ListChannelRequest: &ListChannelsRequest{},
+		},
+	}
+}
+
+func listParticipants(channel string) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 6,
+		Payload: &ControlRequest_ListParticipantsRequest{
+			ListParticipantsRequest: &ListParticipantsRequest{ChannelName: channel},
+		},
+	}
+}
+
+func adoptChannel(channel string, timeoutMs uint32) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 7,
+		Payload: &ControlRequest_AdoptChannelRequest{
+			AdoptChannelRequest: &AdoptChannelRequest{ChannelName: channel, TimeoutMs: timeoutMs},
+		},
+	}
+}
+
+func auditRoutes(cleanup bool) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 8,
+		Payload: &ControlRequest_AuditRoutesRequest{
+			AuditRoutesRequest: &AuditRoutesRequest{Cleanup: cleanup},
+		},
+	}
+}
+
+// TestServer_CreateChannel tests the create channel command
+func TestServer_CreateChannel(t *testing.T) {
+	t.Run("create channel", func(t *testing.T) {
+		s, app := newTestServer()
+
+		resp := command(t, s, createChannel(testChannel, true))
+		assert.True(t, resp.Success)
+		assert.Nil(t, resp.ErrorMsg)
+
+		session := app.SessionByName(testChannel)
+		require.NotNil(t, session)
+		assert.True(t, session.Config.EnableMls)
+	})
+
+	t.Run("session settings", func(t *testing.T) {
+		maxRetries := uint32(2)
+		defaults := SessionSettings{MaxRetries: &maxRetries, Interval: 2 * time.Second}
+		app := testutil.NewFakeApp()
+		s := NewChannelManagerServer(app, 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+			WithSessionDefaults(defaults))
+
+		req := createChannel(testChannel, false)
+		retryIntervalMs := uint64(100)
+		req.GetCreateChannelRequest().RetryIntervalMs = &retryIntervalMs
+		require.True(t, command(t, s, req).Success)
+
+		session := app.SessionByName(testChannel)
+		require.NotNil(t, session)
+		assert.Equal(t, 100*time.Millisecond, *session.Config.Interval)
+		assert.Equal(t, maxRetries, *session.Config.MaxRetries, "the unset settings use the session defaults")
+
+		req = createChannel("agntcy/otel/other", false)
+		retryIntervalMs = math.MaxUint64
+		req.GetCreateChannelRequest().RetryIntervalMs = &retryIntervalMs
+		resp := command(t, s, req)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "invalid retry interval")
+	})
+
+	t.Run("initiator", func(t *testing.T) {
+		app := testutil.NewFakeApp()
+		s := NewChannelManagerServer(app, 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+			WithLocalName("agntcy/otel/channel-manager"))
+
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		session := app.SessionByName(testChannel)
+		require.NotNil(t, session)
+		initiator, err := slimcommon.SessionInitiator(session)
+		require.NoError(t, err)
+		assert.Equal(t, "agntcy/otel/channel-manager", initiator)
+	})
+
+	t.Run("invalid channel name", func(t *testing.T) {
+		s, app := newTestServer()
+
+		resp := command(t, s, createChannel("invalid", false))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "invalid channel name")
+		assert.Empty(t, app.Sessions())
+	})
+
+	t.Run("channel already exists", func(t *testing.T) {
+		s, app := newTestServer()
+
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		resp := command(t, s, createChannel(testChannel, false))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "already exists")
+		assert.Len(t, app.Sessions(), 1)
+	})
+
+	t.Run("session creation fails", func(t *testing.T) {
+		s, app := newTestServer()
+		app.CreateSessionErr = errors.New("boom")
+
+		resp := command(t, s, createChannel(testChannel, false))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "failed to create channel")
+	})
+}
+
+// TestServer_Telemetry tests the latency histograms of the control plane operations
+func TestServer_Telemetry(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	telemetry, err := NewTelemetry(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+
+	app := testutil.NewFakeApp()
+	s := NewChannelManagerServer(app, 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		WithTelemetry(telemetry))
+
+	require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+	require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+	app.SessionByName(testChannel).InviteErr = errors.New("boom")
+	require.False(t, command(t, s, addParticipant(testChannel, "agntcy/otel/exporter")).Success)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	// count the recorded operations by metric and outcome
+	counts := make(map[string]uint64)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		histogram, ok := m.Data.(metricdata.Histogram[float64])
+		require.True(t, ok)
+		for _, dp := range histogram.DataPoints {
+			channel, _ := dp.Attributes.Value("channel")
+			assert.Equal(t, testChannel, channel.AsString())
+			outcome, _ := dp.Attributes.Value("outcome")
+			counts[m.Name+"/"+outcome.AsString()] += dp.Count
+		}
+	}
+
+	assert.Equal(t, map[string]uint64{
+		metricCreateSessionDuration + "/" + outcomeSuccess: 1,
+		metricInviteDuration + "/" + outcomeSuccess:        1,
+		metricInviteDuration + "/" + outcomeFailure:        1,
+	}, counts)
+}
+
+// TestServer_DeleteChannel tests the delete channel command
+func TestServer_DeleteChannel(t *testing.T) {
+	t.Run("delete channel", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		session := app.SessionByName(testChannel)
+
+		resp := command(t, s, deleteChannel(testChannel))
+		assert.True(t, resp.Success)
+		assert.True(t, session.Closed())
+		assert.Empty(t, app.Sessions())
+	})
+
+	t.Run("delete unknown channel", func(t *testing.T) {
+		s, _ := newTestServer()
+
+		resp := command(t, s, deleteChannel(testChannel))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "not found")
+	})
+
+	t.Run("invalid channel name", func(t *testing.T) {
+		s, _ := newTestServer()
+
+		resp := command(t, s, deleteChannel("invalid"))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "invalid channel name")
+	})
+
+	t.Run("session deletion fails", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		app.DeleteSessionErr = errors.New("boom")
+
+		resp := command(t, s, deleteChannel(testChannel))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "boom")
+	})
+}
+
+// TestServer_AddParticipant tests the add participant command
+func TestServer_AddParticipant(t *testing.T) {
+	t.Run("add participant", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+
+		resp := command(t, s, addParticipant(testChannel, testParticipant))
+		assert.True(t, resp.Success)
+		assert.Equal(t, []string{testParticipant}, app.Routes())
+		assert.Equal(t, []string{testParticipant}, app.SessionByName(testChannel).Participants())
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		s, _ := newTestServer()
+
+		resp := command(t, s, addParticipant(testChannel, testParticipant))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "failed to get channel")
+	})
+
+	t.Run("invalid channel name", func(t *testing.T) {
+		s, _ := newTestServer()
+
+		resp := command(t, s, addParticipant("invalid", testParticipant))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "invalid channel name")
+	})
+
+	t.Run("invalid participant name", func(t *testing.T) {
+		s, _ := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+
+		resp := command(t, s, addParticipant(testChannel, "invalid"))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "invalid participant name")
+	})
+
+	t.Run("set route fails", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		app.SetRouteErr = errors.New("no route")
+
+		resp := command(t, s, addParticipant(testChannel, testParticipant))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "failed to set route")
+		assert.Empty(t, app.SessionByName(testChannel).Participants())
+	})
+
+	t.Run("invite fails", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		app.SessionByName(testChannel).InviteErr = errors.New("unreachable")
+
+		resp := command(t, s, addParticipant(testChannel, testParticipant))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "failed to invite participant")
+		assert.Contains(t, resp.GetErrorMsg(), "unreachable")
+	})
+}
+
+// TestServer_DeleteParticipant tests the delete participant command
+func TestServer_DeleteParticipant(t *testing.T) {
+	t.Run("delete participant", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+
+		resp := command(t, s, deleteParticipant(testChannel, testParticipant))
+		assert.True(t, resp.Success)
+		assert.Empty(t, app.SessionByName(testChannel).Participants())
+	})
+
+	t.Run("unknown participant", func(t *testing.T) {
+		s, _ := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+
+		resp := command(t, s, deleteParticipant(testChannel, testParticipant))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "failed to remove participant")
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		s, _ := newTestServer()
+
+		resp := command(t, s, deleteParticipant(testChannel, testParticipant))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "failed to get channel")
+	})
+
+	t.Run("invalid participant name", func(t *testing.T) {
+		s, _ := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+
+		resp := command(t, s, deleteParticipant(testChannel, "invalid"))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "invalid participant name")
+	})
+
+	t.Run("remove fails", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		app.SessionByName(testChannel).RemoveErr = errors.New("boom")
+
+		resp := command(t, s, deleteParticipant(testChannel, testParticipant))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "boom")
+	})
+}
+
+// TestServer_ListChannels tests the list channels command
+func TestServer_ListChannels(t *testing.T) {
+	t.Run("empty list", func(t *testing.T) {
+		s, _ := newTestServer()
+
+		resp, err := s.Command(t.Context(), listChannels())
+		require.NoError(t, err)
+		payload, ok := resp.Payload.(*ControlResponse_ListChannelResponse)
+		require.True(t, ok)
+		assert.Empty(t, payload.ListChannelResponse.ChannelName)
+	})
+
+	t.Run("list created channels", func(t *testing.T) {
+		s, _ := newTestServer()
+		require.True(t, command(t, s, createChannel("agntcy/otel/channel-1", false)).Success)
+		require.True(t, command(t, s, createChannel("agntcy/otel/channel-2", false)).Success)
+
+		resp, err := s.Command(t.Context(), listChannels())
+		require.NoError(t, err)
+		payload, ok := resp.Payload.(*ControlResponse_ListChannelResponse)
+		require.True(t, ok)
+		assert.Equal(t, resp.MgsId, payload.ListChannelResponse.MsgId)
+		assert.ElementsMatch(t,
+			[]string{"agntcy/otel/channel-1", "agntcy/otel/channel-2"},
+			payload.ListChannelResponse.ChannelName)
+	})
+}
+
+// TestServer_ListParticipants tests the list participants command
+func TestServer_ListParticipants(t *testing.T) {
+	t.Run("list participants", func(t *testing.T) {
+		s, _ := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, "agntcy/otel/receiver-1")).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, "agntcy/otel/receiver-2")).Success)
+
+		resp, err := s.Command(t.Context(), listParticipants(testChannel))
+		require.NoError(t, err)
+		payload, ok := resp.Payload.(*ControlResponse_ListParticipantsResponse)
+		require.True(t, ok)
+		assert.ElementsMatch(t,
+			[]string{"agntcy/otel/receiver-1", "agntcy/otel/receiver-2"},
+			payload.ListParticipantsResponse.ParticipantName)
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		s, _ := newTestServer()
+
+		resp := command(t, s, listParticipants(testChannel))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "failed to get channel")
+	})
+
+	t.Run("participants list fails", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		app.SessionByName(testChannel).ParticipantsErr = errors.New("boom")
+
+		resp := command(t, s, listParticipants(testChannel))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "failed to list participants")
+	})
+}
+
+// TestServer_AdoptChannel tests the adopt channel command
+func TestServer_AdoptChannel(t *testing.T) {
+	t.Run("adopt invited channel", func(t *testing.T) {
+		s, app := newTestServer()
+		other := testutil.NewFakeSession(1, "agntcy/otel/other")
+		session := testutil.NewFakeSession(2, testChannel)
+		name, err := slimcommon.SplitID(testParticipant)
+		require.NoError(t, err)
+		require.NoError(t, session.InviteAndWait(name))
+		app.Invite(other)
+		app.Invite(session)
+
+		resp := command(t, s, adoptChannel(testChannel, 1000))
+		assert.True(t, resp.Success)
+		assert.Equal(t, []uint32{1}, app.DeletedSessions(), "invitations to other channels are rejected")
+
+		listResp, err := s.Command(t.Context(), listChannels())
+		require.NoError(t, err)
+		payload, ok := listResp.Payload.(*ControlResponse_ListChannelResponse)
+		require.True(t, ok)
+		assert.Equal(t, []string{testChannel}, payload.ListChannelResponse.ChannelName)
+
+		participantsResp, err := s.Command(t.Context(), listParticipants(testChannel))
+		require.NoError(t, err)
+		participants, ok := participantsResp.Payload.(*ControlResponse_ListParticipantsResponse)
+		require.True(t, ok)
+		assert.Equal(t, []string{testParticipant}, participants.ListParticipantsResponse.ParticipantName)
+	})
+
+	t.Run("no invitation", func(t *testing.T) {
+		s, _ := newTestServer()
+
+		resp := command(t, s, adoptChannel(testChannel, 50))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "no invitation to channel")
+	})
+
+	t.Run("channel already exists", func(t *testing.T) {
+		s, _ := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+
+		resp := command(t, s, adoptChannel(testChannel, 50))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "already exists")
+	})
+
+	t.Run("invalid channel name", func(t *testing.T) {
+		s, _ := newTestServer()
+
+		resp := command(t, s, adoptChannel("invalid", 50))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "invalid channel name")
+	})
+}
+
+// TestServer_AuditRoutes tests the audit of the orphaned routes
+func TestServer_AuditRoutes(t *testing.T) {
+	audit := func(t *testing.T, s *Server, cleanup bool) *AuditRoutesResponse {
+		t.Helper()
+		resp, err := s.Command(t.Context(), auditRoutes(cleanup))
+		require.NoError(t, err)
+		payload, ok := resp.Payload.(*ControlResponse_AuditRoutesResponse)
+		require.True(t, ok, "unexpected response payload %T", resp.Payload)
+		return payload.AuditRoutesResponse
+	}
+
+	t.Run("routes of removed participants", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, "agntcy/otel/exporter")).Success)
+
+		resp := audit(t, s, false)
+		assert.Empty(t, resp.OrphanedRoute)
+
+		require.True(t, command(t, s, deleteParticipant(testChannel, testParticipant)).Success)
+
+		// without cleanup the routes are only reported
+		resp = audit(t, s, false)
+		assert.Equal(t, []string{testParticipant}, resp.OrphanedRoute)
+		assert.Empty(t, resp.RemovedRoute)
+		assert.Contains(t, app.Routes(), testParticipant)
+
+		resp = audit(t, s, true)
+		assert.Equal(t, []string{testParticipant}, resp.OrphanedRoute)
+		assert.Equal(t, []string{testParticipant}, resp.RemovedRoute)
+		assert.Equal(t, []string{"agntcy/otel/exporter"}, app.Routes())
+
+		assert.Empty(t, audit(t, s, false).OrphanedRoute)
+	})
+
+	t.Run("routes of deleted channels", func(t *testing.T) {
+		routes := NewRouteTable()
+		app := testutil.NewFakeApp()
+		s := NewChannelManagerServer(app, 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown), WithRoutes(routes))
+		name, err := slimcommon.SplitID(testParticipant)
+		require.NoError(t, err)
+		// route set while creating the channels of the configuration file
+		routes.Add(name)
+
+		resp := audit(t, s, false)
+		assert.Equal(t, []string{testParticipant}, resp.OrphanedRoute)
+	})
+
+	t.Run("failed removal", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		require.True(t, command(t, s, deleteChannel(testChannel)).Success)
+		app.RemoveRouteErr = errors.New("boom")
+
+		resp := audit(t, s, true)
+		assert.Equal(t, []string{testParticipant}, resp.OrphanedRoute)
+		assert.Empty(t, resp.RemovedRoute)
+		assert.Equal(t, []string{testParticipant}, audit(t, s, false).OrphanedRoute, "the route is reported again")
+	})
+
+	t.Run("participants list failure", func(t *testing.T) {
+		s, app := newTestServer()
+		app.NewSession = func(session *testutil.FakeSession) {
+			session.ParticipantsErr = errors.New("boom")
+		}
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+
+		resp := command(t, s, auditRoutes(true))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "failed to list participants")
+	})
+}
+
+// TestServer_UnknownCommand tests a request without payload
+func TestServer_UnknownCommand(t *testing.T) {
+	s, _ := newTestServer()
+
+	resp := command(t, s, &ControlRequest{MgsId: 42})
+	assert.False(t, resp.Success)
+	assert.Equal(t, "unknown command type", resp.GetErrorMsg())
+	assert.Equal(t, uint64(42), resp.MsgId)
+}
+
+// TestServer_ConcurrentCommands tests concurrent access to the server
+func TestServer_ConcurrentCommands(t *testing.T) {
+	t.Run("concurrent creation of distinct channels", func(t *testing.T) {
+		s, app := newTestServer()
+		var wg sync.WaitGroup
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+				resp, err := s.Command(t.Context(), createChannel(name, false))
+				assert.NoError(t, err)
+				assert.True(t, resp.GetCommandResponse().GetSuccess())
+			}(fmt.Sprintf("agntcy/otel/channel-%d", i))
+		}
+		wg.Wait()
+
+		assert.Len(t, app.Sessions(), 10)
+	})
+
+	t.Run("concurrent creation of the same channel", func(t *testing.T) {
+		s, app := newTestServer()
+		var wg sync.WaitGroup
+		var mutex sync.Mutex
+		succeeded := 0
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := s.Command(t.Context(), createChannel(testChannel, false))
+				assert.NoError(t, err)
+				if resp.GetCommandResponse().GetSuccess() {
+					mutex.Lock()
+					succeeded++
+					mutex.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		// only one creation succeeds, duplicated sessions are deleted
+		assert.Equal(t, 1, succeeded)
+		assert.Len(t, app.Sessions(), 1)
+	})
+
+	t.Run("concurrent participant operations", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		var wg sync.WaitGroup
+
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			participant := fmt.Sprintf("agntcy/otel/receiver-%d", i)
+			go func() {
+				defer wg.Done()
+				_, err := s.Command(t.Context(), addParticipant(testChannel, participant))
+				assert.NoError(t, err)
+			}()
+			go func() {
+				defer wg.Done()
+				_, err := s.Command(t.Context(), listParticipants(testChannel))
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, app.SessionByName(testChannel).Participants(), 10)
+	})
+}
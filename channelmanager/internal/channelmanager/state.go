@@ -0,0 +1,328 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// ChannelState is the persisted state of a channel created through the
+// service
+type ChannelState struct {
+	// Channel name in SLIM format
+	Name string `json:"name"`
+
+	// Flag to enable or disable MLS for this channel
+	MlsEnabled bool `json:"mls-enabled"`
+
+	// Participants invited to the channel
+	Participants []string `json:"participants"`
+
+	// Retransmission settings of the group session set when the channel was
+	// created, the session defaults of the manager if not set
+	MaxRetries      *uint32 `json:"max-retries,omitempty"`
+	RetryIntervalMs uint64  `json:"retry-interval-ms,omitempty"`
+
+	// Time the channel expires, in nanoseconds since the Unix epoch, 0 if it
+	// has no time to live
+	ExpiresUnixNano int64 `json:"expires-unix-nano,omitempty"`
+}
+
+// sessionSettings returns the retransmission settings of the channel
+func (c *ChannelState) sessionSettings() SessionSettings {
+	return SessionSettings{
+		MaxRetries: c.MaxRetries,
+		Interval:   time.Duration(c.RetryIntervalMs) * time.Millisecond, //nolint:gosec // persisted from a duration
+	}
+}
+
+// StateStore persists the channels created through the service, so that
+// they are recreated when the channel manager restarts
+type StateStore interface {
+	// Load returns the persisted channels
+	Load() ([]ChannelState, error)
+	// Save replaces the persisted channels
+	Save(channels []ChannelState) error
+}
+
+// FileStateStore is a StateStore keeping the channels in a JSON file
+type FileStateStore struct {
+	path string
+}
+
+// NewFileStateStore creates a FileStateStore writing to path
+func NewFileStateStore(path string) *FileStateStore {
+	return &FileStateStore{path: path}
+}
+
+// Load reads the channels from the file, none if the file does not exist yet
+func (f *FileStateStore) Load() ([]ChannelState, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var channels []ChannelState
+	if err := json.Unmarshal(data, &channels); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	return channels, nil
+}
+
+// Save writes the channels to a temporary file renamed over the state file,
+// so that a crash never leaves a partially written state
+func (f *FileStateStore) Save(channels []ChannelState) error {
+	data, err := json.MarshalIndent(channels, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+// channelStates tracks the state of the channels created through the service
+// and saves it to the store on every change. A nil channelStates tracks
+// nothing.
+type channelStates struct {
+	mutex    sync.Mutex
+	store    StateStore
+	channels map[string]*ChannelState
+}
+
+// newChannelStates creates a channelStates saving to store
+func newChannelStates(store StateStore) *channelStates {
+	return &channelStates{
+		store:    store,
+		channels: make(map[string]*ChannelState),
+	}
+}
+
+// load reads the persisted channels and tracks them
+func (c *channelStates) load() ([]ChannelState, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	channels, err := c.store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, channel := range channels {
+		c.channels[channel.Name] = &ChannelState{
+			Name:            channel.Name,
+			MlsEnabled:      channel.MlsEnabled,
+			Participants:    slices.Clone(channel.Participants),
+			MaxRetries:      channel.MaxRetries,
+			RetryIntervalMs: channel.RetryIntervalMs,
+			ExpiresUnixNano: channel.ExpiresUnixNano,
+		}
+	}
+	return channels, nil
+}
+
+// addChannel tracks a new channel created with the given session settings,
+// expiring at expires unless zero
+func (c *channelStates) addChannel(
+	name string, mlsEnabled bool, settings SessionSettings, expires time.Time,
+) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.channels[name] = &ChannelState{
+		Name:            name,
+		MlsEnabled:      mlsEnabled,
+		MaxRetries:      settings.MaxRetries,
+		RetryIntervalMs: uint64(settings.Interval.Milliseconds()), //nolint:gosec // intervals are positive
+		ExpiresUnixNano: unixNano(expires),
+	}
+	return c.save()
+}
+
+// recreateChannel records the new MLS setting of a tracked channel recreated
+// without participants
+func (c *channelStates) recreateChannel(name string, mlsEnabled bool) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	state, ok := c.channels[name]
+	if !ok {
+		return nil
+	}
+	state.MlsEnabled = mlsEnabled
+	state.Participants = nil
+	return c.save()
+}
+
+// removeChannel forgets a channel
+func (c *channelStates) removeChannel(name string) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.channels[name]; !ok {
+		return nil
+	}
+	delete(c.channels, name)
+	return c.save()
+}
+
+// addParticipant records a participant of a tracked channel, the participants
+// of the other channels are not persisted
+func (c *channelStates) addParticipant(channel, participant string) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	state, ok := c.channels[channel]
+	if !ok || slices.Contains(state.Participants, participant) {
+		return nil
+	}
+	state.Participants = append(state.Participants, participant)
+	return c.save()
+}
+
+// removeParticipant forgets a participant of a tracked channel
+func (c *channelStates) removeParticipant(channel, participant string) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	state, ok := c.channels[channel]
+	if !ok || !slices.Contains(state.Participants, participant) {
+		return nil
+	}
+	state.Participants = slices.DeleteFunc(state.Participants, func(p string) bool { return p == participant })
+	return c.save()
+}
+
+// save writes the tracked channels, sorted by name, to the store. It must be
+// called with the mutex held.
+func (c *channelStates) save() error {
+	channels := make([]ChannelState, 0, len(c.channels))
+	for _, name := range slices.Sorted(maps.Keys(c.channels)) {
+		state := *c.channels[name]
+		state.Participants = slices.Clone(state.Participants)
+		channels = append(channels, state)
+	}
+	return c.store.Save(channels)
+}
+
+// Restore recreates the channels persisted in the state store and invites
+// their participants again. Channels that already exist, e.g. created from
+// the configuration file, are kept and only the missing participants are
+// invited. The channels and participants that cannot be restored are logged
+// and kept in the store for the next restart.
+func (s *Server) Restore(ctx context.Context) error {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	channels, err := s.state.load()
+	if err != nil {
+		return fmt.Errorf("failed to load the channels state: %w", err)
+	}
+
+	for _, state := range channels {
+		channel, err := slimcommon.SplitID(state.Name)
+		if err != nil {
+			logger.Warn("Skipping invalid persisted channel", zap.String("channel", state.Name), zap.Error(err))
+			continue
+		}
+
+		// the channels that expired while the channel manager was stopped
+		// are not recreated
+		if state.ExpiresUnixNano != 0 {
+			expires := time.Unix(0, state.ExpiresUnixNano)
+			if !time.Now().Before(expires) {
+				s.saveState(ctx, s.state.removeChannel(state.Name))
+				logger.Info("Dropped expired channel", zap.String("channel", state.Name))
+				continue
+			}
+			s.expiry.set(channel.String(), expires)
+		}
+
+		session, err := s.channels.GetSessionByName(ctx, channel.String())
+		if err != nil {
+			session, err = s.createChannel(ctx, channel, state.MlsEnabled, state.sessionSettings())
+			if err != nil {
+				logger.Warn("Failed to restore channel", zap.String("channel", state.Name), zap.Error(err))
+				continue
+			}
+		}
+
+		invited := make(map[string]struct{})
+		if participants, listErr := session.ParticipantsList(); listErr == nil {
+			for _, participant := range participants {
+				invited[slimcommon.JoinID(participant)] = struct{}{}
+			}
+		}
+
+		for _, participant := range state.Participants {
+			if _, ok := invited[participant]; ok {
+				continue
+			}
+			participantName, err := slimcommon.SplitID(participant)
+			if err != nil {
+				logger.Warn("Skipping invalid persisted participant",
+					zap.String("channel", state.Name), zap.String("participant", participant), zap.Error(err))
+				continue
+			}
+			if err := s.invite(ctx, session, channel, participantName); err != nil {
+				logger.Warn("Failed to restore participant",
+					zap.String("channel", state.Name), zap.String("participant", participant), zap.Error(err))
+			}
+		}
+
+		logger.Info("Restored channel",
+			zap.String("channel", state.Name),
+			zap.Strings("participants", state.Participants))
+	}
+	return nil
+}
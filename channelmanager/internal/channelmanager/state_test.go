@@ -0,0 +1,186 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// newStateServer creates a Server backed by a fake SLIM app persisting its
+// channels to the state file in dir
+func newStateServer(dir string) (*Server, *testutil.FakeApp, *FileStateStore) {
+	app := testutil.NewFakeApp()
+	store := NewFileStateStore(filepath.Join(dir, "state.json"))
+	s := NewChannelManagerServer(app, 1, slimcommon.NewSessionsList(slimconfig.SignalUnknown), WithStateStore(store))
+	return s, app, store
+}
+
+func TestFileStateStore(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		store := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+		channels, err := store.Load()
+		require.NoError(t, err)
+		assert.Empty(t, channels)
+	})
+
+	t.Run("save and load", func(t *testing.T) {
+		store := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+		channels := []ChannelState{
+			{Name: testChannel, MlsEnabled: true, Participants: []string{testParticipant}},
+			{Name: "agntcy/otel/other", Participants: []string{}},
+		}
+		require.NoError(t, store.Save(channels))
+
+		loaded, err := store.Load()
+		require.NoError(t, err)
+		assert.Equal(t, channels, loaded)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "state.json")
+		require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+		_, err := NewFileStateStore(path).Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse state file")
+	})
+}
+
+func TestServer_PersistChannels(t *testing.T) {
+	s, _, store := newStateServer(t.TempDir())
+	const other = "agntcy/otel/exporter"
+
+	require.True(t, command(t, s, createChannel(testChannel, true)).Success)
+	require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+	require.True(t, command(t, s, addParticipant(testChannel, other)).Success)
+
+	channels, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []ChannelState{
+		{Name: testChannel, MlsEnabled: true, Participants: []string{testParticipant, other}},
+	}, channels)
+
+	require.True(t, command(t, s, deleteParticipant(testChannel, testParticipant)).Success)
+	channels, err = store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{other}, channels[0].Participants)
+
+	require.True(t, command(t, s, deleteChannel(testChannel)).Success)
+	channels, err = store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, channels)
+}
+
+func TestServer_Restore(t *testing.T) {
+	t.Run("recreate channels", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _, _ := newStateServer(dir)
+		require.True(t, command(t, s, createChannel(testChannel, true)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+
+		// a new channel manager restores the channel
+		restarted, app, _ := newStateServer(dir)
+		require.NoError(t, restarted.Restore(t.Context()))
+
+		session := app.SessionByName(testChannel)
+		require.NotNil(t, session)
+		assert.True(t, session.Config.EnableMls)
+		assert.Equal(t, []string{testParticipant}, session.Participants())
+		assert.Equal(t, []string{testParticipant}, app.Routes())
+		assert.Len(t, restarted.routes.List(), 1)
+	})
+
+	t.Run("session settings", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _, store := newStateServer(dir)
+		req := createChannel(testChannel, false)
+		maxRetries, retryIntervalMs := uint32(30), uint64(5000)
+		req.GetCreateChannelRequest().MaxRetries = &maxRetries
+		req.GetCreateChannelRequest().RetryIntervalMs = &retryIntervalMs
+		require.True(t, command(t, s, req).Success)
+
+		channels, err := store.Load()
+		require.NoError(t, err)
+		assert.Equal(t, []ChannelState{
+			{Name: testChannel, MaxRetries: &maxRetries, RetryIntervalMs: retryIntervalMs},
+		}, channels)
+
+		restarted, app, _ := newStateServer(dir)
+		require.NoError(t, restarted.Restore(t.Context()))
+
+		session := app.SessionByName(testChannel)
+		require.NotNil(t, session)
+		assert.Equal(t, maxRetries, *session.Config.MaxRetries)
+		assert.Equal(t, 5*time.Second, *session.Config.Interval)
+	})
+
+	t.Run("existing channel", func(t *testing.T) {
+		dir := t.TempDir()
+		_, _, store := newStateServer(dir)
+		const other = "agntcy/otel/exporter"
+		require.NoError(t, store.Save([]ChannelState{
+			{Name: testChannel, Participants: []string{testParticipant, other}},
+		}))
+
+		// the channel of the configuration file is created before the restore
+		s, app, _ := newStateServer(dir)
+		channel, err := slimcommon.SplitID(testChannel)
+		require.NoError(t, err)
+		participant, err := slimcommon.SplitID(testParticipant)
+		require.NoError(t, err)
+		session, err := s.createChannel(t.Context(), channel, false, SessionSettings{})
+		require.NoError(t, err)
+		require.NoError(t, s.invite(t.Context(), session, channel, participant))
+		require.NoError(t, s.Restore(t.Context()))
+
+		assert.Len(t, app.Sessions(), 1)
+		assert.Equal(t, []string{testParticipant, other}, app.SessionByName(testChannel).Participants())
+	})
+
+	t.Run("invite fails", func(t *testing.T) {
+		dir := t.TempDir()
+		_, _, store := newStateServer(dir)
+		require.NoError(t, store.Save([]ChannelState{
+			{Name: testChannel, Participants: []string{testParticipant}},
+		}))
+
+		s, app, _ := newStateServer(dir)
+		app.NewSession = func(session *testutil.FakeSession) {
+			session.InviteErr = errors.New("unreachable")
+		}
+		require.NoError(t, s.Restore(t.Context()))
+
+		assert.NotNil(t, app.SessionByName(testChannel))
+		channels, err := store.Load()
+		require.NoError(t, err)
+		assert.Equal(t, []string{testParticipant}, channels[0].Participants, "kept for the next restart")
+	})
+
+	t.Run("without state store", func(t *testing.T) {
+		s, app := newTestServer()
+		require.NoError(t, s.Restore(t.Context()))
+		assert.Empty(t, app.Sessions())
+	})
+
+	t.Run("invalid state file", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "state.json"), []byte("{"), 0o600))
+
+		s, _, _ := newStateServer(dir)
+		err := s.Restore(t.Context())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to load the channels state")
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// scopeName is the instrumentation scope of the channel manager telemetry
+	scopeName = "github.com/agntcy/slim-otel/channelmanager"
+
+	metricCreateSessionDuration = "channelmanager_create_session_duration"
+	metricInviteDuration        = "channelmanager_invite_duration"
+
+	outcomeSuccess = "success"
+	outcomeFailure = "failure"
+)
+
+// latencyBuckets are the histogram boundaries, in seconds, of the control
+// plane operations: sessions and invites usually complete within a few
+// retry intervals (1s each) but may take up to the 10 retries
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Telemetry holds the instruments used to report the latency of the SLIM
+// control plane operations performed by the channel manager. All methods are
+// safe to call on a nil receiver, in which case nothing is recorded.
+type Telemetry struct {
+	createSessionDuration metric.Float64Histogram
+	inviteDuration        metric.Float64Histogram
+}
+
+// NewTelemetry creates the channel manager instruments from the meter provider
+func NewTelemetry(meterProvider metric.MeterProvider) (*Telemetry, error) {
+	meter := meterProvider.Meter(scopeName)
+	t := &Telemetry{}
+
+	var errs, err error
+	t.createSessionDuration, err = meter.Float64Histogram(metricCreateSessionDuration,
+		metric.WithDescription("Latency of the creation of the SLIM session backing a channel"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(latencyBuckets...))
+	errs = errors.Join(errs, err)
+
+	t.inviteDuration, err = meter.Float64Histogram(metricInviteDuration,
+		metric.WithDescription("Latency of the invitation of a participant to a channel"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(latencyBuckets...))
+	errs = errors.Join(errs, err)
+	if errs != nil {
+		return nil, errs
+	}
+
+	return t, nil
+}
+
+// RecordCreateSession records the duration of a CreateSessionAndWait call for channel
+func (t *Telemetry) RecordCreateSession(ctx context.Context, channel string, start time.Time, err error) {
+	if t == nil {
+		return
+	}
+	t.createSessionDuration.Record(ctx, time.Since(start).Seconds(), operationAttributes(channel, err))
+}
+
+// RecordInvite records the duration of an InviteAndWait call for channel
+func (t *Telemetry) RecordInvite(ctx context.Context, channel string, start time.Time, err error) {
+	if t == nil {
+		return
+	}
+	t.inviteDuration.Record(ctx, time.Since(start).Seconds(), operationAttributes(channel, err))
+}
+
+// operationAttributes returns the attributes identifying the channel and the
+// outcome of an operation
+func operationAttributes(channel string, err error) metric.MeasurementOption {
+	outcome := outcomeSuccess
+	if err != nil {
+		outcome = outcomeFailure
+	}
+	return metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("channel", channel),
+		attribute.String("outcome", outcome),
+	))
+}
@@ -0,0 +1,161 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// handleUpdateChannel reconciles a channel with the requested participants
+// and MLS setting. The session is kept unless MLS changes.
+func (s *Server) handleUpdateChannel(
+	ctx context.Context, msgID uint64, req *UpdateChannelRequest,
+) (*ControlResponse, error) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	channel, err := slimcommon.SplitID(req.ChannelName)
+	if err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("invalid channel name: %s", req.ChannelName))
+	}
+
+	channelStr := channel.String()
+
+	session, err := s.channels.GetSessionByName(ctx, channelStr)
+	if err != nil {
+		return s.errorResponse(msgID, fmt.Sprintf("failed to get channel %s: %v", channelStr, err))
+	}
+
+	participants := make([]*slim.Name, 0, len(req.ParticipantName))
+	for _, participant := range req.ParticipantName {
+		name, err := slimcommon.SplitID(participant)
+		if err != nil {
+			return s.errorResponse(msgID, fmt.Sprintf("invalid participant name: %s", participant))
+		}
+		participants = append(participants, name)
+	}
+
+	if err := s.checkParticipantQuota(req.ChannelName, len(participants)); err != nil {
+		return s.errorResponse(msgID, err.Error())
+	}
+	if req.MlsEnabled != nil && !*req.MlsEnabled && s.namespaces.settings(req.ChannelName).mlsRequired() {
+		return s.errorResponse(msgID, fmt.Sprintf("the namespace of channel %s requires MLS", channelStr))
+	}
+	if s.requiresApproval(channel) {
+		if err := checkNoNewParticipant(session, channel, participants); err != nil {
+			return s.errorResponse(msgID, err.Error())
+		}
+	}
+
+	if req.MlsEnabled != nil {
+		config, err := session.SessionConfig()
+		if err != nil {
+			return s.errorResponse(msgID, fmt.Sprintf("failed to get the configuration of channel %s: %v", channelStr, err))
+		}
+		if config.EnableMls != *req.MlsEnabled {
+			config.EnableMls = *req.MlsEnabled
+			session, err = s.recreateChannel(ctx, session, channel, config)
+			if err != nil {
+				return s.errorResponse(msgID, err.Error())
+			}
+			s.saveState(ctx, s.state.recreateChannel(slimcommon.JoinID(channel), config.EnableMls))
+			logger.Info("Recreated channel", zap.String("channel", channelStr), zap.Bool("mls_enabled", config.EnableMls))
+		}
+	}
+
+	invited, removed, err := s.reconcileParticipants(ctx, session, channel, participants)
+	if err != nil {
+		return s.errorResponse(msgID, err.Error())
+	}
+
+	logger.Info("Updated channel",
+		zap.String("channel", channelStr),
+		zap.Strings("invited", invited),
+		zap.Strings("removed", removed))
+	return s.successResponse(msgID)
+}
+
+// recreateChannel replaces the group session of a channel with a new one
+// created with config. The participants must be invited again.
+func (s *Server) recreateChannel(
+	ctx context.Context, session slimcommon.Session, channel *slim.Name, config slim.SessionConfig,
+) (slimcommon.Session, error) {
+	channelStr := channel.String()
+	// the participants invited again are notified of the freeze
+	activity := s.registry.activity(channelStr)
+
+	if _, err := s.channels.RemoveSessionByName(ctx, channelStr); err != nil {
+		return nil, fmt.Errorf("failed to delete channel %s: %v", channelStr, err)
+	}
+	if err := s.app.DeleteSessionAndWait(session); err != nil {
+		return nil, fmt.Errorf("failed to delete channel %s: %v", channelStr, err)
+	}
+	s.registry.deleted(channelStr)
+	s.events.publish(ChannelEvent_CHANNEL_DELETED, channelStr, "")
+
+	session, err := s.openChannel(ctx, channel, config)
+	if err == nil && !activity.frozen.IsZero() {
+		s.registry.restoreFrozen(channelStr, activity.frozen, activity.freezeReason)
+	}
+	return session, err
+}
+
+// reconcileParticipants invites the participants that are not in the
+// channel yet, then removes the participants of the channel that are not
+// listed. It returns the invited and removed participants.
+func (s *Server) reconcileParticipants(
+	ctx context.Context, session slimcommon.Session, channel *slim.Name, participants []*slim.Name,
+) (invited, removed []string, err error) {
+	current, err := session.ParticipantsList()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list participants for channel %s: %v", channel.String(), err)
+	}
+
+	members := make(map[string]struct{}, len(current))
+	for _, name := range current {
+		members[slimcommon.JoinID(name)] = struct{}{}
+	}
+
+	wanted := make(map[string]struct{}, len(participants))
+	invited = make([]string, 0)
+	for _, participant := range participants {
+		participantStr := slimcommon.JoinID(participant)
+		wanted[participantStr] = struct{}{}
+		if _, ok := members[participantStr]; ok {
+			continue
+		}
+		if err := s.invite(ctx, session, channel, participant); err != nil {
+			return invited, nil, err
+		}
+		s.saveState(ctx, s.state.addParticipant(slimcommon.JoinID(channel), participantStr))
+		members[participantStr] = struct{}{}
+		invited = append(invited, participantStr)
+	}
+
+	removed = make([]string, 0)
+	for _, name := range current {
+		if _, ok := wanted[slimcommon.JoinID(name)]; ok {
+			continue
+		}
+		if err := s.removeParticipant(ctx, session, channel, name); err != nil {
+			return invited, removed, err
+		}
+		removed = append(removed, slimcommon.JoinID(name))
+	}
+
+	// the failed invitations of the participants no longer listed are not
+	// retried
+	for _, name := range s.invites.failedNames(channel.String()) {
+		if _, ok := wanted[slimcommon.JoinID(name)]; !ok {
+			s.invites.forget(channel.String(), slimcommon.JoinID(name))
+		}
+	}
+
+	return invited, removed, nil
+}
@@ -0,0 +1,125 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package channelmanager
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func updateChannel(channel string, participants []string, mls *bool) *ControlRequest {
+	return &ControlRequest{
+		MgsId: 10,
+		Payload: &ControlRequest_UpdateChannelRequest{
+			UpdateChannelRequest: &UpdateChannelRequest{
+				ChannelName:     channel,
+				ParticipantName: participants,
+				MlsEnabled:      mls,
+			},
+		},
+	}
+}
+
+// TestServer_UpdateChannel tests the reconciliation of a channel
+func TestServer_UpdateChannel(t *testing.T) {
+	const (
+		exporter  = "agntcy/otel/exporter"
+		receiver2 = "agntcy/otel/receiver-2"
+	)
+
+	t.Run("reconcile participants", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, exporter)).Success)
+		session := app.SessionByName(testChannel)
+
+		resp := command(t, s, updateChannel(testChannel, []string{exporter, receiver2}, nil))
+		assert.True(t, resp.Success)
+
+		assert.Same(t, session, app.SessionByName(testChannel), "the session is kept")
+		assert.ElementsMatch(t, []string{exporter, receiver2}, session.Participants())
+	})
+
+	t.Run("remove all participants", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+
+		resp := command(t, s, updateChannel(testChannel, nil, nil))
+		assert.True(t, resp.Success)
+		assert.Empty(t, app.SessionByName(testChannel).Participants())
+	})
+
+	t.Run("unchanged MLS setting", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, true)).Success)
+		session := app.SessionByName(testChannel)
+
+		mls := true
+		resp := command(t, s, updateChannel(testChannel, []string{testParticipant}, &mls))
+		assert.True(t, resp.Success)
+		assert.Same(t, session, app.SessionByName(testChannel))
+		assert.Empty(t, app.DeletedSessions())
+	})
+
+	t.Run("toggle MLS", func(t *testing.T) {
+		s, app, store := newStateServer(t.TempDir())
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, exporter)).Success)
+		old := app.SessionByName(testChannel)
+
+		mls := true
+		resp := command(t, s, updateChannel(testChannel, []string{testParticipant}, &mls))
+		assert.True(t, resp.Success)
+
+		session := app.SessionByName(testChannel)
+		require.NotNil(t, session)
+		assert.NotSame(t, old, session, "the session is recreated")
+		assert.True(t, session.Config.EnableMls)
+		assert.Equal(t, []string{testParticipant}, session.Participants())
+		assert.Len(t, app.DeletedSessions(), 1)
+
+		states, err := store.Load()
+		require.NoError(t, err)
+		assert.Equal(t, []ChannelState{{Name: testChannel, MlsEnabled: true, Participants: []string{testParticipant}}}, states)
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		s, _ := newTestServer()
+
+		resp := command(t, s, updateChannel(testChannel, []string{testParticipant}, nil))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "failed to get channel")
+	})
+
+	t.Run("invalid participant name", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+
+		resp := command(t, s, updateChannel(testChannel, []string{"invalid"}, nil))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "invalid participant name")
+		assert.Equal(t, []string{testParticipant}, app.SessionByName(testChannel).Participants(),
+			"the channel is not changed")
+	})
+
+	t.Run("invite fails", func(t *testing.T) {
+		s, app := newTestServer()
+		require.True(t, command(t, s, createChannel(testChannel, false)).Success)
+		require.True(t, command(t, s, addParticipant(testChannel, testParticipant)).Success)
+		app.SessionByName(testChannel).InviteErr = errors.New("boom")
+
+		resp := command(t, s, updateChannel(testChannel, []string{exporter}, nil))
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.GetErrorMsg(), "boom")
+		assert.Equal(t, []string{testParticipant}, app.SessionByName(testChannel).Participants(),
+			"the participants are not removed when an invitation fails")
+	})
+}
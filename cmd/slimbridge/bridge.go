@@ -0,0 +1,237 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/receiver"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+
+	"github.com/agntcy/slim-otel/exporter/slimexporter"
+	"github.com/agntcy/slim-otel/receiver/slimreceiver"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// bridgeConfig holds the slimbridge flags
+type bridgeConfig struct {
+	slimAddress  string
+	sharedSecret string
+
+	// OTLP to SLIM
+	grpcEndpoint   string
+	httpEndpoint   string
+	exporterName   string
+	tracesChannel  string
+	metricsChannel string
+	logsChannel    string
+	participants   string
+
+	// SLIM to OTLP
+	receiverName    string
+	forwardEndpoint string
+	forwardInsecure bool
+}
+
+// outbound reports whether the telemetry received on OTLP is forwarded to SLIM
+func (cfg *bridgeConfig) outbound() bool {
+	return cfg.grpcEndpoint != "" || cfg.httpEndpoint != ""
+}
+
+// inbound reports whether the telemetry received from SLIM is forwarded to OTLP
+func (cfg *bridgeConfig) inbound() bool {
+	return cfg.receiverName != ""
+}
+
+// validate checks the flags, the SLIM components validating the rest
+func (cfg *bridgeConfig) validate() error {
+	if !cfg.outbound() && !cfg.inbound() {
+		return errors.New("at least one of the OTLP servers or the receiver name must be set")
+	}
+	if cfg.inbound() && cfg.forwardEndpoint == "" {
+		return errors.New("the OTLP forward endpoint is required with a receiver name")
+	}
+	if cfg.outbound() && cfg.exporterName == "" {
+		return errors.New("the exporter name is required with an OTLP server")
+	}
+	if (cfg.tracesChannel != "" || cfg.metricsChannel != "" || cfg.logsChannel != "") && cfg.participants == "" {
+		return errors.New("at least one participant must be invited to the channels")
+	}
+	return nil
+}
+
+// channels returns the channels the exporters create
+func (cfg *bridgeConfig) channels() []slimexporter.ChannelsConfig {
+	participants := strings.Split(cfg.participants, ",")
+	var channels []slimexporter.ChannelsConfig
+	for signal, name := range map[slimconfig.SignalType]string{
+		slimconfig.SignalTraces:  cfg.tracesChannel,
+		slimconfig.SignalMetrics: cfg.metricsChannel,
+		slimconfig.SignalLogs:    cfg.logsChannel,
+	} {
+		if name != "" {
+			channels = append(channels, slimexporter.ChannelsConfig{
+				ChannelName:  name,
+				Signal:       string(signal),
+				Participants: participants,
+			})
+		}
+	}
+	return channels
+}
+
+// host is the component host of the bridge, without extensions
+type host struct{}
+
+func (host) GetExtensions() map[component.ID]component.Component {
+	return nil
+}
+
+// telemetrySettings returns the telemetry settings of the SLIM components,
+// logging with logger under name
+func telemetrySettings(logger *zap.Logger, name string) component.TelemetrySettings {
+	return component.TelemetrySettings{
+		Logger:         logger.Named(name),
+		TracerProvider: tracenoop.NewTracerProvider(),
+		MeterProvider:  metricnoop.NewMeterProvider(),
+	}
+}
+
+// run starts the bridge and blocks until ctx is done
+func run(ctx context.Context, logger *zap.Logger, cfg bridgeConfig) (err error) {
+	var components []component.Component
+	defer func() {
+		// shutdown in the reverse order, the servers before the exporters
+		for i := len(components) - 1; i >= 0; i-- {
+			err = errors.Join(err, components[i].Shutdown(context.Background()))
+		}
+	}()
+	start := func(c component.Component) error {
+		if err := c.Start(ctx, host{}); err != nil {
+			return err
+		}
+		components = append(components, c)
+		return nil
+	}
+
+	if cfg.inbound() {
+		forwarder, err := newOTLPForwarder(cfg.forwardEndpoint, cfg.forwardInsecure)
+		if err != nil {
+			return err
+		}
+		components = append(components, forwarder)
+		receivers, err := newReceivers(ctx, logger, cfg, forwarder)
+		if err != nil {
+			return err
+		}
+		for _, rcv := range receivers {
+			if err := start(rcv); err != nil {
+				return fmt.Errorf("failed to start the receiver: %w", err)
+			}
+		}
+		logger.Info("Forwarding from SLIM to OTLP",
+			zap.String("receiver", cfg.receiverName), zap.String("endpoint", cfg.forwardEndpoint))
+	}
+
+	if cfg.outbound() {
+		exporters, err := newExporters(ctx, logger, cfg)
+		if err != nil {
+			return err
+		}
+		for _, exp := range []component.Component{exporters.traces, exporters.metrics, exporters.logs} {
+			if err := start(exp); err != nil {
+				return fmt.Errorf("failed to start the exporter: %w", err)
+			}
+		}
+		server := newOTLPServer(logger, cfg.grpcEndpoint, cfg.httpEndpoint, exporters.traces, exporters.metrics,
+			exporters.logs)
+		if err := start(server); err != nil {
+			return err
+		}
+	}
+
+	<-ctx.Done()
+	logger.Info("Bridge stopping")
+	return nil
+}
+
+// exporters are the SLIM exporters of the signals
+type exporters struct {
+	traces  exporter.Traces
+	metrics exporter.Metrics
+	logs    exporter.Logs
+}
+
+// newExporters creates the SLIM exporters publishing the telemetry received
+// on OTLP
+func newExporters(ctx context.Context, logger *zap.Logger, cfg bridgeConfig) (*exporters, error) {
+	factory := slimexporter.NewFactory()
+	ecfg := factory.CreateDefaultConfig().(*slimexporter.Config)
+	ecfg.ConnectionConfig = &slimconfig.ConnectionConfig{Address: cfg.slimAddress}
+	ecfg.SharedSecret = slimconfig.Opaque(cfg.sharedSecret)
+	// the name of each signal is derived from the exporter name
+	ecfg.ExporterName = cfg.exporterName
+	ecfg.Channels = cfg.channels()
+
+	set := exporter.Settings{
+		ID:                component.NewID(factory.Type()),
+		TelemetrySettings: telemetrySettings(logger, "exporter"),
+		BuildInfo:         component.NewDefaultBuildInfo(),
+	}
+
+	var exps exporters
+	var err error
+	if exps.traces, err = factory.CreateTraces(ctx, set, ecfg); err != nil {
+		return nil, fmt.Errorf("failed to create the traces exporter: %w", err)
+	}
+	if exps.metrics, err = factory.CreateMetrics(ctx, set, ecfg); err != nil {
+		return nil, fmt.Errorf("failed to create the metrics exporter: %w", err)
+	}
+	if exps.logs, err = factory.CreateLogs(ctx, set, ecfg); err != nil {
+		return nil, fmt.Errorf("failed to create the logs exporter: %w", err)
+	}
+	return &exps, nil
+}
+
+// newReceivers creates the SLIM receiver forwarding the telemetry of the
+// signals to forwarder. The receivers of the signals share a single SLIM app.
+func newReceivers(
+	ctx context.Context,
+	logger *zap.Logger,
+	cfg bridgeConfig,
+	forwarder *otlpForwarder,
+) ([]component.Component, error) {
+	factory := slimreceiver.NewFactory()
+	rcfg := factory.CreateDefaultConfig().(*slimreceiver.Config)
+	rcfg.ConnectionConfig = &slimconfig.ConnectionConfig{Address: cfg.slimAddress}
+	rcfg.ReceiverName = cfg.receiverName
+	rcfg.SharedSecret = slimconfig.Opaque(cfg.sharedSecret)
+
+	set := receiver.Settings{
+		ID:                component.NewID(factory.Type()),
+		TelemetrySettings: telemetrySettings(logger, "receiver"),
+		BuildInfo:         component.NewDefaultBuildInfo(),
+	}
+
+	traces, err := factory.CreateTraces(ctx, set, rcfg, forwarder.traces())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the traces receiver: %w", err)
+	}
+	metrics, err := factory.CreateMetrics(ctx, set, rcfg, forwarder.metrics())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the metrics receiver: %w", err)
+	}
+	logs, err := factory.CreateLogs(ctx, set, rcfg, forwarder.logs())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the logs receiver: %w", err)
+	}
+	return []component.Component{traces, metrics, logs}, nil
+}
@@ -0,0 +1,82 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// otlpForwarder forwards the telemetry received from SLIM to an OTLP gRPC
+// endpoint
+type otlpForwarder struct {
+	conn *grpc.ClientConn
+}
+
+// newOTLPForwarder creates a forwarder to the OTLP gRPC endpoint, the
+// connection being established on the first export
+func newOTLPForwarder(endpoint string, withoutTLS bool) (*otlpForwarder, error) {
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if withoutTLS {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP client for %s: %w", endpoint, err)
+	}
+	return &otlpForwarder{conn: conn}, nil
+}
+
+// Start implements component.Component
+func (f *otlpForwarder) Start(context.Context, component.Host) error {
+	return nil
+}
+
+// Shutdown closes the connection to the OTLP endpoint
+func (f *otlpForwarder) Shutdown(context.Context) error {
+	return f.conn.Close()
+}
+
+// traces returns the consumer forwarding the traces
+func (f *otlpForwarder) traces() consumer.Traces {
+	client := ptraceotlp.NewGRPCClient(f.conn)
+	next, _ := consumer.NewTraces(func(ctx context.Context, td ptrace.Traces) error {
+		_, err := client.Export(ctx, ptraceotlp.NewExportRequestFromTraces(td))
+		return err
+	})
+	return next
+}
+
+// metrics returns the consumer forwarding the metrics
+func (f *otlpForwarder) metrics() consumer.Metrics {
+	client := pmetricotlp.NewGRPCClient(f.conn)
+	next, _ := consumer.NewMetrics(func(ctx context.Context, md pmetric.Metrics) error {
+		_, err := client.Export(ctx, pmetricotlp.NewExportRequestFromMetrics(md))
+		return err
+	})
+	return next
+}
+
+// logs returns the consumer forwarding the logs
+func (f *otlpForwarder) logs() consumer.Logs {
+	client := plogotlp.NewGRPCClient(f.conn)
+	next, _ := consumer.NewLogs(func(ctx context.Context, ld plog.Logs) error {
+		_, err := client.Export(ctx, plogotlp.NewExportRequestFromLogs(ld))
+		return err
+	})
+	return next
+}
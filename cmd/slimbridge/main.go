@@ -0,0 +1,66 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+// slimbridge forwards the telemetry received on OTLP gRPC and HTTP to SLIM
+// channels, and the telemetry received from SLIM channels to an OTLP
+// endpoint, for the applications that cannot embed a collector.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	os.Exit(runMain(os.Args[1:]))
+}
+
+// runMain parses the flags and runs the bridge, it returns the process exit code
+func runMain(args []string) int {
+	cfg := bridgeConfig{}
+	fs := flag.NewFlagSet("slimbridge", flag.ExitOnError)
+	fs.StringVar(&cfg.slimAddress, "slim-address", "http://127.0.0.1:46357", "address of the SLIM node")
+	fs.StringVar(&cfg.sharedSecret, "shared-secret", "", "shared secret for MLS and identity provider (min 32 chars)")
+	fs.StringVar(&cfg.grpcEndpoint, "otlp-grpc-endpoint", "localhost:4317",
+		"address of the OTLP gRPC server forwarding to SLIM, empty disables it")
+	fs.StringVar(&cfg.httpEndpoint, "otlp-http-endpoint", "localhost:4318",
+		"address of the OTLP HTTP server forwarding to SLIM, empty disables it")
+	fs.StringVar(&cfg.exporterName, "exporter-name", "agntcy/otel/slimbridge",
+		"prefix of the SLIM names the telemetry is published with, suffixed by the signal")
+	fs.StringVar(&cfg.tracesChannel, "traces-channel", "",
+		"channel the traces are published to, empty waits for invitations")
+	fs.StringVar(&cfg.metricsChannel, "metrics-channel", "",
+		"channel the metrics are published to, empty waits for invitations")
+	fs.StringVar(&cfg.logsChannel, "logs-channel", "",
+		"channel the logs are published to, empty waits for invitations")
+	fs.StringVar(&cfg.participants, "participants", "", "comma-separated participants invited to the channels")
+	fs.StringVar(&cfg.receiverName, "receiver-name", "",
+		"SLIM name receiving the telemetry forwarded to the OTLP endpoint, empty disables it")
+	fs.StringVar(&cfg.forwardEndpoint, "otlp-forward-endpoint", "",
+		"OTLP gRPC endpoint the telemetry received from SLIM is forwarded to")
+	fs.BoolVar(&cfg.forwardInsecure, "otlp-forward-insecure", false, "forward to the OTLP endpoint without TLS")
+	_ = fs.Parse(args)
+
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	logger := zap.Must(zap.NewProduction())
+	defer logger.Sync() //nolint:errcheck
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, logger, cfg); err != nil {
+		logger.Error("Bridge failed", zap.Error(err))
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,257 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxRequestBytes is the maximum size of a decompressed OTLP HTTP request
+	maxRequestBytes = 16 << 20
+
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJSON     = "application/json"
+)
+
+// otlpServer receives the telemetry on OTLP gRPC and HTTP and passes it to
+// the consumers of the signals
+type otlpServer struct {
+	logger       *zap.Logger
+	grpcEndpoint string
+	httpEndpoint string
+
+	traces  consumer.Traces
+	metrics consumer.Metrics
+	logs    consumer.Logs
+
+	grpcServer *grpc.Server
+	httpServer *http.Server
+}
+
+// newOTLPServer creates the OTLP server listening on the gRPC and HTTP
+// endpoints, an empty endpoint disabling its protocol
+func newOTLPServer(
+	logger *zap.Logger,
+	grpcEndpoint string,
+	httpEndpoint string,
+	traces consumer.Traces,
+	metrics consumer.Metrics,
+	logs consumer.Logs,
+) *otlpServer {
+	return &otlpServer{
+		logger:       logger.Named("otlp"),
+		grpcEndpoint: grpcEndpoint,
+		httpEndpoint: httpEndpoint,
+		traces:       traces,
+		metrics:      metrics,
+		logs:         logs,
+	}
+}
+
+// Start listens on the endpoints and serves the requests in the background
+func (s *otlpServer) Start(_ context.Context, _ component.Host) error {
+	if s.grpcEndpoint != "" {
+		listener, err := net.Listen("tcp", s.grpcEndpoint)
+		if err != nil {
+			return fmt.Errorf("failed to listen on the OTLP gRPC endpoint %s: %w", s.grpcEndpoint, err)
+		}
+		s.grpcServer = grpc.NewServer()
+		ptraceotlp.RegisterGRPCServer(s.grpcServer, &tracesService{next: s.traces})
+		pmetricotlp.RegisterGRPCServer(s.grpcServer, &metricsService{next: s.metrics})
+		plogotlp.RegisterGRPCServer(s.grpcServer, &logsService{next: s.logs})
+		go func() {
+			if err := s.grpcServer.Serve(listener); err != nil {
+				s.logger.Error("OTLP gRPC server stopped", zap.Error(err))
+			}
+		}()
+		s.logger.Info("OTLP gRPC server started", zap.String("address", listener.Addr().String()))
+	}
+
+	if s.httpEndpoint != "" {
+		listener, err := net.Listen("tcp", s.httpEndpoint)
+		if err != nil {
+			return fmt.Errorf("failed to listen on the OTLP HTTP endpoint %s: %w", s.httpEndpoint, err)
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/v1/traces", exportHandler(s.logger, ptraceotlp.NewExportRequest,
+			func(ctx context.Context, request ptraceotlp.ExportRequest) error {
+				return s.traces.ConsumeTraces(ctx, request.Traces())
+			}))
+		mux.HandleFunc("/v1/metrics", exportHandler(s.logger, pmetricotlp.NewExportRequest,
+			func(ctx context.Context, request pmetricotlp.ExportRequest) error {
+				return s.metrics.ConsumeMetrics(ctx, request.Metrics())
+			}))
+		mux.HandleFunc("/v1/logs", exportHandler(s.logger, plogotlp.NewExportRequest,
+			func(ctx context.Context, request plogotlp.ExportRequest) error {
+				return s.logs.ConsumeLogs(ctx, request.Logs())
+			}))
+		s.httpServer = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.logger.Error("OTLP HTTP server stopped", zap.Error(err))
+			}
+		}()
+		s.logger.Info("OTLP HTTP server started", zap.String("address", listener.Addr().String()))
+	}
+	return nil
+}
+
+// Shutdown stops the servers, waiting for the requests in progress
+func (s *otlpServer) Shutdown(ctx context.Context) error {
+	var err error
+	if s.httpServer != nil {
+		err = s.httpServer.Shutdown(ctx)
+	}
+	if s.grpcServer != nil {
+		s.grpcServer.GracefulStop()
+	}
+	return err
+}
+
+// exportStatus returns the gRPC status of a failed export, so that the
+// clients retry
+func exportStatus(err error) error {
+	return status.Error(codes.Unavailable, err.Error())
+}
+
+// tracesService is the OTLP gRPC traces service
+type tracesService struct {
+	ptraceotlp.UnimplementedGRPCServer
+	next consumer.Traces
+}
+
+func (s *tracesService) Export(
+	ctx context.Context,
+	request ptraceotlp.ExportRequest,
+) (ptraceotlp.ExportResponse, error) {
+	if err := s.next.ConsumeTraces(ctx, request.Traces()); err != nil {
+		return ptraceotlp.NewExportResponse(), exportStatus(err)
+	}
+	return ptraceotlp.NewExportResponse(), nil
+}
+
+// metricsService is the OTLP gRPC metrics service
+type metricsService struct {
+	pmetricotlp.UnimplementedGRPCServer
+	next consumer.Metrics
+}
+
+func (s *metricsService) Export(
+	ctx context.Context,
+	request pmetricotlp.ExportRequest,
+) (pmetricotlp.ExportResponse, error) {
+	if err := s.next.ConsumeMetrics(ctx, request.Metrics()); err != nil {
+		return pmetricotlp.NewExportResponse(), exportStatus(err)
+	}
+	return pmetricotlp.NewExportResponse(), nil
+}
+
+// logsService is the OTLP gRPC logs service
+type logsService struct {
+	plogotlp.UnimplementedGRPCServer
+	next consumer.Logs
+}
+
+func (s *logsService) Export(ctx context.Context, request plogotlp.ExportRequest) (plogotlp.ExportResponse, error) {
+	if err := s.next.ConsumeLogs(ctx, request.Logs()); err != nil {
+		return plogotlp.NewExportResponse(), exportStatus(err)
+	}
+	return plogotlp.NewExportResponse(), nil
+}
+
+// exportRequest is the OTLP export request of a signal
+type exportRequest interface {
+	UnmarshalProto(data []byte) error
+	UnmarshalJSON(data []byte) error
+}
+
+// exportHandler returns the OTLP HTTP handler decoding the protobuf or JSON
+// requests, optionally gzip compressed, and passing them to consume
+func exportHandler[R exportRequest](
+	logger *zap.Logger,
+	newRequest func() R,
+	consume func(context.Context, R) error,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		contentType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if contentType != contentTypeProtobuf && contentType != contentTypeJSON {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		body := io.Reader(req.Body)
+		switch req.Header.Get("Content-Encoding") {
+		case "", "identity":
+		case "gzip":
+			reader, err := gzip.NewReader(req.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			defer reader.Close()
+			body = reader
+		default:
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		data, err := io.ReadAll(io.LimitReader(body, maxRequestBytes+1))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(data) > maxRequestBytes {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		request := newRequest()
+		if contentType == contentTypeJSON {
+			err = request.UnmarshalJSON(data)
+		} else {
+			err = request.UnmarshalProto(data)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := consume(req.Context(), request); err != nil {
+			logger.Debug("Failed to forward the OTLP request", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		// the export responses without partial success are empty
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		if contentType == contentTypeJSON {
+			_, _ = w.Write([]byte("{}"))
+		}
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/receiver"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+
+	"github.com/agntcy/slim-otel/exporter/slimexporter"
+	"github.com/agntcy/slim-otel/receiver/slimreceiver"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// doctorAttribute is the resource attribute identifying the payload of a
+// doctor run
+const doctorAttribute = "slimotel.doctor.id"
+
+// doctorConfig holds the doctor flags
+type doctorConfig struct {
+	slimAddress  string
+	sharedSecret string
+	// prefix of the names of the exporter, the receiver and the channel
+	name    string
+	timeout time.Duration
+	verbose bool
+}
+
+// exporterName returns the name of the exporter app
+func (cfg *doctorConfig) exporterName() string {
+	return cfg.name + "-exporter"
+}
+
+// receiverName returns the name of the receiver app
+func (cfg *doctorConfig) receiverName() string {
+	return cfg.name + "-receiver"
+}
+
+// runDoctor parses the flags and runs the checks, it returns the process
+// exit code
+func runDoctor(args []string, stdout, stderr io.Writer) int {
+	cfg := doctorConfig{}
+	fs := flag.NewFlagSet("slimotel doctor", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&cfg.slimAddress, "slim-address", "http://127.0.0.1:46357", "address of the SLIM node")
+	fs.StringVar(&cfg.sharedSecret, "shared-secret", "", "shared secret for MLS and identity provider (min 32 chars)")
+	fs.StringVar(&cfg.name, "name", "agntcy/otel/slimotel-doctor",
+		"prefix of the SLIM names of the exporter, the receiver and the temporary channel")
+	fs.DurationVar(&cfg.timeout, "timeout", 30*time.Second,
+		"maximum time to wait for the receiver to join the channel and to get the payload")
+	fs.BoolVar(&cfg.verbose, "verbose", false, "log the activity of the exporter and the receiver to stderr")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	logger := zap.NewNop()
+	if cfg.verbose {
+		logger = zap.Must(zap.NewDevelopment(zap.ErrorOutput(zap.CombineWriteSyncers(os.Stderr))))
+		defer logger.Sync() //nolint:errcheck
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !runChecks(ctx, logger, cfg, stdout) {
+		return 1
+	}
+	return 0
+}
+
+// checklist prints the outcome of each check. Once a check failed, the
+// next ones are skipped.
+type checklist struct {
+	w      io.Writer
+	failed bool
+}
+
+// check runs fn unless a previous check failed and prints its outcome,
+// with the detail returned by fn if any. It returns false if the check
+// failed or was skipped.
+func (c *checklist) check(name string, fn func() (string, error)) bool {
+	if c.failed {
+		fmt.Fprintf(c.w, "[SKIP] %s\n", name)
+		return false
+	}
+	detail, err := fn()
+	switch {
+	case err != nil:
+		c.failed = true
+		fmt.Fprintf(c.w, "[FAIL] %s: %v\n", name, err)
+		return false
+	case detail != "":
+		fmt.Fprintf(c.w, "[ OK ] %s (%s)\n", name, detail)
+	default:
+		fmt.Fprintf(c.w, "[ OK ] %s\n", name)
+	}
+	return true
+}
+
+// runChecks creates a receiver and an exporter publishing to it on a
+// temporary channel, round-trips a payload between them and prints the
+// checklist to w. It returns false if a check failed.
+func runChecks(ctx context.Context, logger *zap.Logger, cfg doctorConfig, w io.Writer) bool {
+	checks := &checklist{w: w}
+	id, channel := newRunID(), ""
+	received := make(chan time.Time, 1)
+
+	var components []component.Component
+	defer func() {
+		// the exporter deletes the temporary channel when it shuts down
+		for i := len(components) - 1; i >= 0; i-- {
+			_ = components[i].Shutdown(context.Background())
+		}
+	}()
+
+	var rcfg *slimreceiver.Config
+	var ecfg *slimexporter.Config
+	checks.check("configuration is valid", func() (string, error) {
+		channel = fmt.Sprintf("%s-%s", cfg.name, id)
+		rcfg = receiverConfig(cfg)
+		ecfg = exporterConfig(cfg, channel)
+		if err := rcfg.Validate(); err != nil {
+			return "", fmt.Errorf("receiver: %w", err)
+		}
+		if err := ecfg.Validate(); err != nil {
+			return "", fmt.Errorf("exporter: %w", err)
+		}
+		return cfg.slimAddress, nil
+	})
+
+	checks.check("receiver connects and authenticates as "+cfg.receiverName(), func() (string, error) {
+		rcv, err := newDoctorReceiver(ctx, logger, rcfg, id, received)
+		if err != nil {
+			return "", err
+		}
+		if err := rcv.Start(ctx, host{}); err != nil {
+			return "", err
+		}
+		components = append(components, rcv)
+		return "", nil
+	})
+
+	var exp exporter.Traces
+	checks.check("exporter connects, authenticates as "+cfg.exporterName()+" and creates channel "+channel,
+		func() (string, error) {
+			var err error
+			if exp, err = newDoctorExporter(ctx, logger, ecfg); err != nil {
+				return "", err
+			}
+			if err := exp.Start(ctx, host{}); err != nil {
+				return "", err
+			}
+			components = append(components, exp)
+			return "invited " + cfg.receiverName(), nil
+		})
+
+	var sent time.Time
+	checks.check("exporter publishes a payload once the receiver joined", func() (string, error) {
+		start := time.Now()
+		if err := exp.ConsumeTraces(ctx, doctorTraces(id)); err != nil {
+			return "", err
+		}
+		sent = time.Now()
+		return "after " + sent.Sub(start).Round(time.Millisecond).String(), nil
+	})
+
+	return checks.check("receiver gets the payload", func() (string, error) {
+		timer := time.NewTimer(cfg.timeout)
+		defer timer.Stop()
+		select {
+		case at := <-received:
+			return "round trip " + at.Sub(sent).Round(time.Millisecond).String(), nil
+		case <-timer.C:
+			return "", fmt.Errorf("not received within %s, check that the receiver joined the channel, "+
+				"e.g. the routes of the SLIM node and the shared secret of both sides", cfg.timeout)
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	})
+}
+
+// newRunID returns a random ID identifying the channel and the payload of
+// a doctor run
+func newRunID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// receiverConfig returns the configuration of the receiver of the checks
+func receiverConfig(cfg doctorConfig) *slimreceiver.Config {
+	rcfg := slimreceiver.NewFactory().CreateDefaultConfig().(*slimreceiver.Config)
+	rcfg.ConnectionConfig = &slimconfig.ConnectionConfig{Address: cfg.slimAddress}
+	rcfg.ReceiverName = cfg.receiverName()
+	rcfg.SharedSecret = slimconfig.Opaque(cfg.sharedSecret)
+	return rcfg
+}
+
+// exporterConfig returns the configuration of the exporter of the checks,
+// creating channel and inviting the receiver to it. The first publication
+// waits for the receiver to join the channel.
+func exporterConfig(cfg doctorConfig, channel string) *slimexporter.Config {
+	ecfg := slimexporter.NewFactory().CreateDefaultConfig().(*slimexporter.Config)
+	ecfg.ConnectionConfig = &slimconfig.ConnectionConfig{Address: cfg.slimAddress}
+	ecfg.SharedSecret = slimconfig.Opaque(cfg.sharedSecret)
+	tracesName := cfg.exporterName()
+	ecfg.ExporterNames = &slimconfig.SignalNames{Traces: &tracesName}
+	ecfg.Channels = []slimexporter.ChannelsConfig{{
+		ChannelName:  channel,
+		Signal:       string(slimconfig.SignalTraces),
+		Participants: []string{cfg.receiverName()},
+	}}
+	ecfg.ReadinessTimeout = cfg.timeout
+	ecfg.SummaryInterval = 0
+	return ecfg
+}
+
+// newDoctorReceiver creates the traces receiver of the checks, sending the
+// time the payload of the run was received to received
+func newDoctorReceiver(
+	ctx context.Context,
+	logger *zap.Logger,
+	rcfg *slimreceiver.Config,
+	id string,
+	received chan<- time.Time,
+) (receiver.Traces, error) {
+	next, err := consumer.NewTraces(func(_ context.Context, td ptrace.Traces) error {
+		for i := range td.ResourceSpans().Len() {
+			value, ok := td.ResourceSpans().At(i).Resource().Attributes().Get(doctorAttribute)
+			if ok && value.Str() == id {
+				select {
+				case received <- time.Now():
+				default:
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	factory := slimreceiver.NewFactory()
+	set := receiver.Settings{
+		ID:                component.NewID(factory.Type()),
+		TelemetrySettings: telemetrySettings(logger, "receiver"),
+		BuildInfo:         component.NewDefaultBuildInfo(),
+	}
+	return factory.CreateTraces(ctx, set, rcfg, next)
+}
+
+// newDoctorExporter creates the traces exporter of the checks
+func newDoctorExporter(ctx context.Context, logger *zap.Logger, ecfg *slimexporter.Config) (exporter.Traces, error) {
+	factory := slimexporter.NewFactory()
+	set := exporter.Settings{
+		ID:                component.NewID(factory.Type()),
+		TelemetrySettings: telemetrySettings(logger, "exporter"),
+		BuildInfo:         component.NewDefaultBuildInfo(),
+	}
+	return factory.CreateTraces(ctx, set, ecfg)
+}
+
+// doctorTraces returns the payload of the run with the given id
+func doctorTraces(id string) ptrace.Traces {
+	td := ptrace.NewTraces()
+	resourceSpans := td.ResourceSpans().AppendEmpty()
+	resourceSpans.Resource().Attributes().PutStr("service.name", "slimotel-doctor")
+	resourceSpans.Resource().Attributes().PutStr(doctorAttribute, id)
+	span := resourceSpans.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetName("slimotel doctor")
+	now := time.Now()
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
+	span.SetEndTimestamp(pcommon.NewTimestampFromTime(now))
+	return td
+}
+
+// host is the component host of the checks, without extensions
+type host struct{}
+
+func (host) GetExtensions() map[component.ID]component.Component {
+	return nil
+}
+
+// telemetrySettings returns the telemetry settings of the SLIM components,
+// logging with logger under name
+func telemetrySettings(logger *zap.Logger, name string) component.TelemetrySettings {
+	return component.TelemetrySettings{
+		Logger:         logger.Named(name),
+		TracerProvider: tracenoop.NewTracerProvider(),
+		MeterProvider:  metricnoop.NewMeterProvider(),
+	}
+}
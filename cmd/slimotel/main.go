@@ -0,0 +1,48 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+// slimotel gathers the tools helping to set up the SLIM exporter and
+// receiver. The doctor command checks that they can exchange telemetry
+// through a SLIM node.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	os.Exit(runMain(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// runMain runs the command named by the first argument, it returns the
+// process exit code
+func runMain(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	switch args[0] {
+	case "doctor":
+		return runDoctor(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+		usage(stderr)
+		return 2
+	}
+}
+
+// usage prints the commands of slimotel
+func usage(w io.Writer) {
+	fmt.Fprintln(w, `Usage: slimotel <command> [flags]
+
+Commands:
+  doctor  check that the SLIM exporter and receiver exchange telemetry through a SLIM node
+
+Run "slimotel <command> -h" for the flags of a command.`)
+}
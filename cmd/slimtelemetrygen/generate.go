@@ -0,0 +1,214 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/exporter"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+
+	"github.com/agntcy/slim-otel/exporter/slimexporter"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// genReport is the final report of a generation
+type genReport struct {
+	Signal            string  `json:"signal"`
+	Duration          string  `json:"duration"`
+	MessagesSent      uint64  `json:"messages_sent"`
+	ItemsSent         uint64  `json:"items_sent"`
+	BytesSent         uint64  `json:"bytes_sent"`
+	SendFailures      uint64  `json:"send_failures"`
+	MessagesPerSecond float64 `json:"messages_per_second"`
+	BytesPerSecond    float64 `json:"bytes_per_second"`
+}
+
+// genCounters are the counters shared by the workers
+type genCounters struct {
+	messages atomic.Uint64
+	items    atomic.Uint64
+	bytes    atomic.Uint64
+	failures atomic.Uint64
+}
+
+// publishFunc generates a message and publishes it, returning the size of
+// its OTLP payload
+type publishFunc func(ctx context.Context) (int, error)
+
+// host is the component host of the exporter, without extensions
+type host struct{}
+
+func (host) GetExtensions() map[component.ID]component.Component {
+	return nil
+}
+
+// generate publishes the generated telemetry from the workers until the
+// duration elapses or ctx is done
+func generate(ctx context.Context, logger *zap.Logger, cfg genConfig) (*genReport, error) {
+	exp, publish, err := newPublisher(ctx, logger, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := exp.Start(ctx, host{}); err != nil {
+		return nil, fmt.Errorf("failed to start the exporter: %w", err)
+	}
+	defer func() {
+		if err := exp.Shutdown(context.Background()); err != nil {
+			logger.Warn("Failed to shutdown the exporter", zap.Error(err))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.duration)
+	defer cancel()
+
+	logger.Info("Generation started",
+		zap.String("signal", cfg.signal),
+		zap.Int("workers", cfg.workers),
+		zap.Float64("rate", cfg.rate),
+		zap.Int("batch_size", cfg.batchSize),
+		zap.Duration("duration", cfg.duration))
+
+	counters := &genCounters{}
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range cfg.workers {
+		wg.Go(func() {
+			runWorker(ctx, logger, cfg, publish, counters)
+		})
+	}
+
+	reportTicker := time.NewTicker(cfg.reportInterval)
+	defer reportTicker.Stop()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-reportTicker.C:
+			logger.Info("Generation progress",
+				zap.Duration("elapsed", time.Since(start).Round(time.Second)),
+				zap.Uint64("messages_sent", counters.messages.Load()),
+				zap.Uint64("bytes_sent", counters.bytes.Load()),
+				zap.Uint64("send_failures", counters.failures.Load()))
+		}
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	report := &genReport{
+		Signal:       cfg.signal,
+		Duration:     elapsed.Round(time.Second).String(),
+		MessagesSent: counters.messages.Load(),
+		ItemsSent:    counters.items.Load(),
+		BytesSent:    counters.bytes.Load(),
+		SendFailures: counters.failures.Load(),
+	}
+	report.MessagesPerSecond = float64(report.MessagesSent) / elapsed.Seconds()
+	report.BytesPerSecond = float64(report.BytesSent) / elapsed.Seconds()
+	return report, nil
+}
+
+// runWorker publishes at the configured rate, or as fast as possible, until
+// ctx is done
+func runWorker(ctx context.Context, logger *zap.Logger, cfg genConfig, publish publishFunc, counters *genCounters) {
+	var tick <-chan time.Time
+	if cfg.rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		if tick != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick:
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+
+		size, err := publish(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			counters.failures.Add(1)
+			logger.Debug("Failed to publish message", zap.Error(err))
+			continue
+		}
+		counters.messages.Add(1)
+		counters.items.Add(uint64(cfg.batchSize)) //nolint:gosec // the batch size is positive
+		counters.bytes.Add(uint64(size))          //nolint:gosec // sizes are positive
+	}
+}
+
+// newPublisher creates the SLIM exporter of the signal and the function
+// publishing the generated messages with it
+func newPublisher(ctx context.Context, logger *zap.Logger, cfg genConfig) (component.Component, publishFunc, error) {
+	factory := slimexporter.NewFactory()
+	ecfg := factory.CreateDefaultConfig().(*slimexporter.Config)
+	ecfg.ConnectionConfig = &slimconfig.ConnectionConfig{Address: cfg.slimAddress}
+	ecfg.SharedSecret = slimconfig.Opaque(cfg.sharedSecret)
+	ecfg.ReadinessTimeout = cfg.readinessTimeout
+	// the name of each signal is derived from the exporter name
+	ecfg.ExporterName = cfg.exporterName
+	if cfg.channel != "" {
+		ecfg.Channels = []slimexporter.ChannelsConfig{{
+			ChannelName:  cfg.channel,
+			Signal:       cfg.signal,
+			Participants: cfg.participantList(),
+		}}
+	}
+
+	set := exporter.Settings{
+		ID: component.NewID(factory.Type()),
+		TelemetrySettings: component.TelemetrySettings{
+			Logger:         logger.Named("exporter"),
+			TracerProvider: tracenoop.NewTracerProvider(),
+			MeterProvider:  metricnoop.NewMeterProvider(),
+		},
+		BuildInfo: component.NewDefaultBuildInfo(),
+	}
+
+	gen := newGenerator(cfg.serviceName, cfg.batchSize, cfg.payloadBytes)
+	switch slimconfig.SignalType(cfg.signal) {
+	case slimconfig.SignalTraces:
+		exp, err := factory.CreateTraces(ctx, set, ecfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create the exporter: %w", err)
+		}
+		return exp, func(ctx context.Context) (int, error) {
+			td := gen.traces()
+			return tracesSizer.TracesSize(td), exp.ConsumeTraces(ctx, td)
+		}, nil
+	case slimconfig.SignalMetrics:
+		exp, err := factory.CreateMetrics(ctx, set, ecfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create the exporter: %w", err)
+		}
+		return exp, func(ctx context.Context) (int, error) {
+			md := gen.metrics()
+			return metricsSizer.MetricsSize(md), exp.ConsumeMetrics(ctx, md)
+		}, nil
+	default:
+		exp, err := factory.CreateLogs(ctx, set, ecfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create the exporter: %w", err)
+		}
+		return exp, func(ctx context.Context) (int, error) {
+			ld := gen.logs()
+			return logsSizer.LogsSize(ld), exp.ConsumeLogs(ctx, ld)
+		}, nil
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	// scopeName is the instrumentation scope of the generated telemetry
+	scopeName = "github.com/agntcy/slim-otel/cmd/slimtelemetrygen"
+
+	// paddingAttribute is the attribute sizing the generated items
+	paddingAttribute = "slimtelemetrygen.padding"
+)
+
+var (
+	tracesSizer  = &ptrace.ProtoMarshaler{}
+	metricsSizer = &pmetric.ProtoMarshaler{}
+	logsSizer    = &plog.ProtoMarshaler{}
+)
+
+// generator generates the messages of batchSize items, each padded with
+// payloadBytes bytes
+type generator struct {
+	serviceName string
+	batchSize   int
+	padding     string
+}
+
+// newGenerator creates a generator
+func newGenerator(serviceName string, batchSize, payloadBytes int) *generator {
+	return &generator{
+		serviceName: serviceName,
+		batchSize:   batchSize,
+		padding:     strings.Repeat("x", payloadBytes),
+	}
+}
+
+// resource fills the generated resource and scope
+func (g *generator) resource(resource pcommon.Resource, scope pcommon.InstrumentationScope) {
+	resource.Attributes().PutStr("service.name", g.serviceName)
+	scope.SetName(scopeName)
+}
+
+// pad adds the padding attribute, if any, to attrs
+func (g *generator) pad(attrs pcommon.Map) {
+	if g.padding != "" {
+		attrs.PutStr(paddingAttribute, g.padding)
+	}
+}
+
+// traces generates a trace of batchSize spans
+func (g *generator) traces() ptrace.Traces {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	ss := rs.ScopeSpans().AppendEmpty()
+	g.resource(rs.Resource(), ss.Scope())
+
+	var traceID pcommon.TraceID
+	fillRandom(traceID[:])
+	now := time.Now()
+	for i := range g.batchSize {
+		span := ss.Spans().AppendEmpty()
+		span.SetTraceID(traceID)
+		var spanID pcommon.SpanID
+		fillRandom(spanID[:])
+		span.SetSpanID(spanID)
+		span.SetName("slimtelemetrygen-span")
+		span.SetKind(ptrace.SpanKindInternal)
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(now.Add(time.Millisecond)))
+		span.Attributes().PutInt("slimtelemetrygen.index", int64(i))
+		g.pad(span.Attributes())
+	}
+	return td
+}
+
+// metrics generates a gauge of batchSize data points
+func (g *generator) metrics() pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	sm := rm.ScopeMetrics().AppendEmpty()
+	g.resource(rm.Resource(), sm.Scope())
+
+	metric := sm.Metrics().AppendEmpty()
+	metric.SetName("slimtelemetrygen.value")
+	points := metric.SetEmptyGauge().DataPoints()
+	now := pcommon.NewTimestampFromTime(time.Now())
+	for i := range g.batchSize {
+		point := points.AppendEmpty()
+		point.SetTimestamp(now)
+		point.SetDoubleValue(rand.Float64()) //nolint:gosec // synthetic values
+		point.Attributes().PutInt("slimtelemetrygen.index", int64(i))
+		g.pad(point.Attributes())
+	}
+	return md
+}
+
+// logs generates batchSize log records
+func (g *generator) logs() plog.Logs {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	sl := rl.ScopeLogs().AppendEmpty()
+	g.resource(rl.Resource(), sl.Scope())
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	for i := range g.batchSize {
+		record := sl.LogRecords().AppendEmpty()
+		record.SetTimestamp(now)
+		record.SetSeverityNumber(plog.SeverityNumberInfo)
+		record.SetSeverityText("INFO")
+		record.Body().SetStr("slimtelemetrygen log record")
+		record.Attributes().PutInt("slimtelemetrygen.index", int64(i))
+		g.pad(record.Attributes())
+	}
+	return ld
+}
+
+// fillRandom fills b with random bytes, for the trace and span IDs
+func fillRandom(b []byte) {
+	for i := range b {
+		b[i] = byte(rand.IntN(256)) //nolint:gosec // synthetic IDs
+	}
+}
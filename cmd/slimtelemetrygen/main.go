@@ -0,0 +1,120 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+// slimtelemetrygen generates synthetic traces, metrics or logs at a
+// configurable rate and size and publishes them to SLIM channels with the
+// SLIM exporter, to load-test the exporter, the receivers and the SLIM nodes.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// genConfig holds the slimtelemetrygen flags
+type genConfig struct {
+	slimAddress      string
+	sharedSecret     string
+	exporterName     string
+	channel          string
+	participants     string
+	readinessTimeout time.Duration
+
+	signal       string
+	workers      int
+	rate         float64
+	duration     time.Duration
+	batchSize    int
+	payloadBytes int
+	serviceName  string
+
+	reportInterval time.Duration
+}
+
+// validate checks the flags, the SLIM exporter validating the rest
+func (cfg *genConfig) validate() error {
+	switch slimconfig.SignalType(cfg.signal) {
+	case slimconfig.SignalTraces, slimconfig.SignalMetrics, slimconfig.SignalLogs:
+	default:
+		return fmt.Errorf("invalid signal '%s', must be traces, metrics or logs", cfg.signal)
+	}
+	if cfg.workers <= 0 || cfg.batchSize <= 0 || cfg.duration <= 0 || cfg.reportInterval <= 0 {
+		return errors.New("workers, batch-size, duration and report-interval must be positive")
+	}
+	if cfg.rate < 0 || cfg.payloadBytes < 0 {
+		return errors.New("rate and payload-bytes cannot be negative")
+	}
+	if cfg.channel != "" && cfg.participants == "" {
+		return errors.New("at least one participant must be invited to the channel")
+	}
+	return nil
+}
+
+// participantList returns the participants invited to the channel
+func (cfg *genConfig) participantList() []string {
+	if cfg.participants == "" {
+		return nil
+	}
+	return strings.Split(cfg.participants, ",")
+}
+
+func main() {
+	os.Exit(runMain(os.Args[1:]))
+}
+
+// runMain parses the flags and generates the telemetry, it returns the
+// process exit code
+func runMain(args []string) int {
+	cfg := genConfig{}
+	fs := flag.NewFlagSet("slimtelemetrygen", flag.ExitOnError)
+	fs.StringVar(&cfg.slimAddress, "slim-address", "http://127.0.0.1:46357", "address of the SLIM node")
+	fs.StringVar(&cfg.sharedSecret, "shared-secret", "", "shared secret for MLS and identity provider (min 32 chars)")
+	fs.StringVar(&cfg.exporterName, "exporter-name", "agntcy/otel/slimtelemetrygen",
+		"prefix of the SLIM names the telemetry is published with, suffixed by the signal")
+	fs.StringVar(&cfg.channel, "channel", "", "channel the telemetry is published to, empty waits for invitations")
+	fs.StringVar(&cfg.participants, "participants", "", "comma-separated participants invited to the channel")
+	fs.DurationVar(&cfg.readinessTimeout, "readiness-timeout", 10*time.Second,
+		"maximum time to wait for the participants to join before publishing")
+	fs.StringVar(&cfg.signal, "signal", "traces", "signal generated: traces, metrics or logs")
+	fs.IntVar(&cfg.workers, "workers", 1, "number of workers publishing concurrently")
+	fs.Float64Var(&cfg.rate, "rate", 10, "messages published per second by each worker, 0 publishes as fast as possible")
+	fs.DurationVar(&cfg.duration, "duration", time.Minute, "duration of the generation")
+	fs.IntVar(&cfg.batchSize, "batch-size", 10, "spans, data points or log records per message")
+	fs.IntVar(&cfg.payloadBytes, "payload-bytes", 0, "size of the padding attribute added to each item")
+	fs.StringVar(&cfg.serviceName, "service-name", "slimtelemetrygen", "service.name of the generated resources")
+	fs.DurationVar(&cfg.reportInterval, "report-interval", 10*time.Second, "interval of the progress logs")
+	_ = fs.Parse(args)
+
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	logger := zap.Must(zap.NewProduction())
+	defer logger.Sync() //nolint:errcheck
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := generate(ctx, logger, cfg)
+	if err != nil {
+		logger.Error("Generation failed", zap.Error(err))
+		return 1
+	}
+
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+	return 0
+}
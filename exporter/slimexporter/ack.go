@@ -0,0 +1,220 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// pendingAck tracks the sessions that acknowledged a published message
+type pendingAck struct {
+	acked map[uint32]struct{}
+	// signaled, without blocking, every time a session acknowledges the message
+	notify chan struct{}
+}
+
+// ackTracker matches the acknowledgements received on the sessions with the
+// messages waiting for them
+type ackTracker struct {
+	// random prefix making the message IDs unique among the exporters
+	// sharing a channel
+	prefix string
+	next   atomic.Uint64
+
+	mutex   sync.Mutex
+	pending map[string]*pendingAck
+}
+
+// newAckTracker creates a tracker with no pending message
+func newAckTracker() *ackTracker {
+	return &ackTracker{
+		prefix:  strconv.FormatUint(rand.Uint64(), 16), //nolint:gosec // not used for security
+		pending: make(map[string]*pendingAck),
+	}
+}
+
+// register allocates a new message ID and starts tracking its acknowledgements
+func (t *ackTracker) register() string {
+	id := fmt.Sprintf("%s-%d", t.prefix, t.next.Add(1))
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.pending[id] = &pendingAck{
+		acked:  make(map[uint32]struct{}),
+		notify: make(chan struct{}, 1),
+	}
+	return id
+}
+
+// unregister stops tracking the acknowledgements of a message
+func (t *ackTracker) unregister(id string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.pending, id)
+}
+
+// acknowledge records the acknowledgement of message id received on the
+// session. Acknowledgements of unknown messages, e.g. sent to another
+// exporter of the channel, are ignored.
+func (t *ackTracker) acknowledge(id string, sessionID uint32) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	pending, ok := t.pending[id]
+	if !ok {
+		return
+	}
+	pending.acked[sessionID] = struct{}{}
+	select {
+	case pending.notify <- struct{}{}:
+	default:
+	}
+}
+
+// acknowledged reports whether all the sessions acknowledged message id
+func (t *ackTracker) acknowledged(id string, sessions []uint32) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	_, ok := t.pending[id]
+	return ok && len(t.unacknowledgedLocked(id, sessions)) == 0
+}
+
+// unacknowledged returns the sessions that did not acknowledge message id
+func (t *ackTracker) unacknowledged(id string, sessions []uint32) []uint32 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.unacknowledgedLocked(id, sessions)
+}
+
+// unacknowledgedLocked is unacknowledged with the lock held
+func (t *ackTracker) unacknowledgedLocked(id string, sessions []uint32) []uint32 {
+	pending, ok := t.pending[id]
+	if !ok {
+		return sessions
+	}
+	var missing []uint32
+	for _, sessionID := range sessions {
+		if _, ok := pending.acked[sessionID]; !ok {
+			missing = append(missing, sessionID)
+		}
+	}
+	return missing
+}
+
+// wait blocks until all the sessions acknowledged message id, the timeout
+// expires or ctx is done
+func (t *ackTracker) wait(ctx context.Context, id string, sessions []uint32, timeout time.Duration) error {
+	t.mutex.Lock()
+	pending, ok := t.pending[id]
+	t.mutex.Unlock()
+	if !ok {
+		return fmt.Errorf("message %s is not tracked", id)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for !t.acknowledged(id, sessions) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return fmt.Errorf("message %s not acknowledged within %s", id, timeout)
+		case <-pending.notify:
+		}
+	}
+	return nil
+}
+
+// readsMessages reports whether the exporter reads the messages received on
+// its sessions, which only carry acknowledgements, drain and freeze
+// notifications
+func (e *slimExporter) readsMessages() bool {
+	return e.acks != nil || e.config.DrainNotifications || e.config.FreezeNotifications
+}
+
+// readMessages reads the acknowledgements, drain and freeze notifications
+// received on the session until the session is closed or ctx is done. Any other
+// message is discarded.
+func (e *slimExporter) readMessages(ctx context.Context, session slimcommon.Session) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	sessionID, err := session.SessionId()
+	if err != nil {
+		logger.Error("Failed to get session ID, the session messages are not read", zap.Error(err))
+		return
+	}
+
+	// the messages are delivered until the session is closed or ctx is done
+	for msg := range slimcommon.ReceiveMessages(ctx, session) {
+		e.sessions.Touch(sessionID)
+
+		switch msg.Context.PayloadType {
+		case slimcommon.PayloadTypeAck:
+			if e.acks != nil {
+				e.acks.acknowledge(msg.Context.Metadata[slimcommon.MetadataMessageID], sessionID)
+			}
+		case slimcommon.PayloadTypeDrain:
+			if e.config.DrainNotifications {
+				e.handleDrainNotification(ctx, sessionID, session, msg)
+			}
+		case slimcommon.PayloadTypeFreeze:
+			if e.config.FreezeNotifications {
+				e.handleFreezeNotification(ctx, sessionID, msg)
+			}
+		}
+	}
+}
+
+// publishAndWaitAck publishes data to the target sessions (all of them if
+// targets is nil) with the given metadata and a new message ID, and waits
+// until each of them acknowledged it. It returns the sessions the message was
+// published to and the closed sessions, which are not waited for. The error
+// of a missing acknowledgement is reported for each session that did not
+// acknowledge the message, see slimcommon.FailedSessions.
+func (e *slimExporter) publishAndWaitAck(
+	ctx context.Context,
+	targets []string,
+	data []byte,
+	metadata map[string]string,
+) ([]uint32, []uint32, error) {
+	id := e.acks.register()
+	defer e.acks.unregister(id)
+
+	metadata[slimcommon.MetadataMessageID] = id
+	published, closedSessions, err := e.sessions.PublishToSessions(ctx, targets, data, metadata)
+	if err != nil {
+		return published, closedSessions, err
+	}
+
+	if err := e.acks.wait(ctx, id, published, e.config.AckTimeout); err != nil {
+		e.telemetry.recordAckTimeout(ctx)
+		var errs []error
+		for _, sessionID := range e.acks.unacknowledged(id, published) {
+			name, nameErr := e.sessions.GetSessionName(ctx, sessionID)
+			if nameErr != nil {
+				// removed while waiting for the acknowledgement
+				continue
+			}
+			errs = append(errs, &slimcommon.SessionError{Session: name, Err: err})
+		}
+		if len(errs) == 0 {
+			return published, closedSessions, err
+		}
+		return published, closedSessions, errors.Join(errs...)
+	}
+	return published, closedSessions, nil
+}
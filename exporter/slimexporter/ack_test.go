@@ -0,0 +1,102 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestAckTracker(t *testing.T) {
+	tracker := newAckTracker()
+
+	id := tracker.register()
+	assert.NotEqual(t, id, tracker.register(), "message IDs must be unique")
+
+	// acknowledgements of unknown messages are ignored
+	tracker.acknowledge("unknown", 1)
+
+	go func() {
+		tracker.acknowledge(id, 1)
+		tracker.acknowledge(id, 2)
+	}()
+	require.NoError(t, tracker.wait(t.Context(), id, []uint32{1, 2}, time.Second))
+
+	// nothing to wait for without sessions
+	other := tracker.register()
+	require.NoError(t, tracker.wait(t.Context(), other, nil, time.Second))
+
+	tracker.acknowledge(other, 1)
+	err := tracker.wait(t.Context(), other, []uint32{1, 2}, 50*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not acknowledged")
+	assert.Equal(t, []uint32{2}, tracker.unacknowledged(other, []uint32{1, 2}))
+
+	tracker.unregister(id)
+	require.Error(t, tracker.wait(t.Context(), id, []uint32{1}, time.Second))
+}
+
+func TestPublishData_Acknowledgements(t *testing.T) {
+	// ackingSession acknowledges every message published on it
+	ackingSession := func(id uint32, channel string) *testutil.FakeSession {
+		session := testutil.NewFakeSession(id, channel)
+		session.OnPublish = func(msg slim.ReceivedMessage) {
+			ack := slim.ReceivedMessage{}
+			ack.Context.PayloadType = slimcommon.PayloadTypeAck
+			ack.Context.Metadata = map[string]string{
+				slimcommon.MetadataMessageID: msg.Context.Metadata[slimcommon.MetadataMessageID],
+			}
+			session.DeliverMessage(ack)
+		}
+		return session
+	}
+
+	newExporter := func(t *testing.T, sessions ...*testutil.FakeSession) *slimExporter {
+		exporter := &slimExporter{
+			config:     &Config{AckTimeout: 200 * time.Millisecond},
+			signalType: slimconfig.SignalTraces,
+			sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+			acks:       newAckTracker(),
+		}
+		for _, session := range sessions {
+			require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+			go exporter.readMessages(t.Context(), session)
+			t.Cleanup(session.Close)
+		}
+		return exporter
+	}
+
+	t.Run("all sessions acknowledge", func(t *testing.T) {
+		session1 := ackingSession(1, "agntcy/otel/channel-1")
+		session2 := ackingSession(2, "agntcy/otel/channel-2")
+		exporter := newExporter(t, session1, session2)
+
+		require.NoError(t, exporter.publishData(t.Context(), nil, []byte("payload")))
+
+		for _, session := range []*testutil.FakeSession{session1, session2} {
+			published := session.PublishedMessages()
+			require.Len(t, published, 1)
+			assert.NotEmpty(t, published[0].Context.Metadata[slimcommon.MetadataMessageID])
+		}
+	})
+
+	t.Run("missing acknowledgement", func(t *testing.T) {
+		exporter := newExporter(t,
+			ackingSession(1, "agntcy/otel/channel-1"),
+			testutil.NewFakeSession(2, "agntcy/otel/channel-2"))
+
+		err := exporter.publishData(t.Context(), nil, []byte("payload"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not acknowledged")
+		assert.Equal(t, []string{"agntcy/otel/channel-2"}, slimcommon.FailedSessions(err))
+	})
+}
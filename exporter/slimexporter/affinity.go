@@ -0,0 +1,410 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"hash/fnv"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// affinityIndex returns the channel, among n, that data correlated to
+// traceID is published to. Data without trace ID goes to the first channel.
+func affinityIndex(traceID pcommon.TraceID, n int) int {
+	if traceID.IsEmpty() || n <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(traceID[:])
+	return int(h.Sum32() % uint32(n)) //nolint:gosec // n is the number of channels
+}
+
+// affinitySessions returns the names of the sessions of the channels
+// configured for the signal, in configuration order, or nil if channel
+// affinity is disabled or less than two channels are configured. Channels
+// dedicated to data types or with attribute matchers are left out. The same index designates the same
+// channel for every signal as long as the channels of each signal are listed
+// in the same order.
+func (e *slimExporter) affinitySessions(ctx context.Context) []string {
+	if !e.config.ChannelAffinity {
+		return nil
+	}
+
+	var names []string
+	for _, channel := range e.config.Channels {
+		if channel.Signal != string(e.signalType) || len(channel.DataTypes) > 0 || len(channel.Match) > 0 {
+			continue
+		}
+		name, err := slimcommon.SplitID(channel.destination())
+		if err != nil {
+			slimcommon.LoggerFromContextOrDefault(ctx).Debug("Ignoring channel for affinity",
+				zap.String("channel", channel.destination()), zap.Error(err))
+			continue
+		}
+		names = append(names, name.String())
+	}
+	if len(names) < 2 {
+		return nil
+	}
+	return names
+}
+
+// tracesPartition is the part of a batch published to the given sessions,
+// nil meaning all the sessions
+type tracesPartition struct {
+	sessions []string
+	data     ptrace.Traces
+}
+
+// metricsPartition is the metrics counterpart of tracesPartition
+type metricsPartition struct {
+	sessions []string
+	data     pmetric.Metrics
+}
+
+// logsPartition is the logs counterpart of tracesPartition
+type logsPartition struct {
+	sessions []string
+	data     plog.Logs
+}
+
+// tracesPartitions routes the resources of td overriding their channel to
+// it, then splits the rest as tracesRoutedPartitions does
+func (e *slimExporter) tracesPartitions(ctx context.Context, td ptrace.Traces) []tracesPartition {
+	partitions, rest := e.tracesOverridePartitions(ctx, td)
+	if len(partitions) > 0 && rest.ResourceSpans().Len() == 0 {
+		return partitions
+	}
+	return append(partitions, e.tracesRoutedPartitions(ctx, rest)...)
+}
+
+// tracesRoutedPartitions splits td by span kind when some kinds are routed
+// to dedicated channels, then by channel when channel affinity is enabled
+func (e *slimExporter) tracesRoutedPartitions(ctx context.Context, td ptrace.Traces) []tracesPartition {
+	routes := e.dataTypeRoutes(ctx)
+	if routes == nil {
+		return e.tracesAffinityPartitions(ctx, td, nil)
+	}
+
+	parts := partitionTracesBy(td, len(routes.sessions)+1, func(span ptrace.Span) int {
+		return routes.index(spanDataType(span.Kind()))
+	})
+	var partitions []tracesPartition
+	for i, session := range routes.sessions {
+		if parts[i].ResourceSpans().Len() > 0 {
+			partitions = append(partitions, tracesPartition{sessions: []string{session}, data: parts[i]})
+		}
+	}
+	if rest := parts[len(routes.sessions)]; rest.ResourceSpans().Len() > 0 {
+		partitions = append(partitions, e.tracesAffinityPartitions(ctx, rest, routes.otherSessions(ctx, e.sessions))...)
+	}
+	return partitions
+}
+
+// tracesAffinityPartitions splits td by channel when channel affinity is
+// enabled, publishing it to targets otherwise
+func (e *slimExporter) tracesAffinityPartitions(
+	ctx context.Context,
+	td ptrace.Traces,
+	targets []string,
+) []tracesPartition {
+	sessions := e.affinitySessions(ctx)
+	if sessions == nil {
+		return []tracesPartition{{sessions: targets, data: td}}
+	}
+
+	var partitions []tracesPartition
+	for i, part := range partitionTraces(td, len(sessions)) {
+		if part.ResourceSpans().Len() > 0 {
+			partitions = append(partitions, tracesPartition{sessions: sessions[i : i+1], data: part})
+		}
+	}
+	return partitions
+}
+
+// metricsPartitions is the metrics counterpart of tracesPartitions
+func (e *slimExporter) metricsPartitions(ctx context.Context, md pmetric.Metrics) []metricsPartition {
+	partitions, rest := e.metricsOverridePartitions(ctx, md)
+	if len(partitions) > 0 && rest.ResourceMetrics().Len() == 0 {
+		return partitions
+	}
+	return append(partitions, e.metricsRoutedPartitions(ctx, rest)...)
+}
+
+// metricsRoutedPartitions splits md by metric type when some types are
+// routed to dedicated channels, then by channel when channel affinity is enabled
+func (e *slimExporter) metricsRoutedPartitions(ctx context.Context, md pmetric.Metrics) []metricsPartition {
+	routes := e.dataTypeRoutes(ctx)
+	if routes == nil {
+		return e.metricsAffinityPartitions(ctx, md, nil)
+	}
+
+	parts := partitionMetricsByType(md, len(routes.sessions)+1, func(metric pmetric.Metric) int {
+		return routes.index(metricDataType(metric.Type()))
+	})
+	var partitions []metricsPartition
+	for i, session := range routes.sessions {
+		if parts[i].ResourceMetrics().Len() > 0 {
+			partitions = append(partitions, metricsPartition{sessions: []string{session}, data: parts[i]})
+		}
+	}
+	if rest := parts[len(routes.sessions)]; rest.ResourceMetrics().Len() > 0 {
+		partitions = append(partitions, e.metricsAffinityPartitions(ctx, rest, routes.otherSessions(ctx, e.sessions))...)
+	}
+	return partitions
+}
+
+// metricsAffinityPartitions splits md by channel when channel affinity is
+// enabled, publishing it to targets otherwise
+func (e *slimExporter) metricsAffinityPartitions(
+	ctx context.Context,
+	md pmetric.Metrics,
+	targets []string,
+) []metricsPartition {
+	sessions := e.affinitySessions(ctx)
+	if sessions == nil {
+		return []metricsPartition{{sessions: targets, data: md}}
+	}
+
+	var partitions []metricsPartition
+	for i, part := range partitionMetrics(md, len(sessions)) {
+		if part.ResourceMetrics().Len() > 0 {
+			partitions = append(partitions, metricsPartition{sessions: sessions[i : i+1], data: part})
+		}
+	}
+	return partitions
+}
+
+// logsPartitions is the logs counterpart of tracesPartitions
+func (e *slimExporter) logsPartitions(ctx context.Context, ld plog.Logs) []logsPartition {
+	partitions, rest := e.logsOverridePartitions(ctx, ld)
+	if len(partitions) > 0 && rest.ResourceLogs().Len() == 0 {
+		return partitions
+	}
+	return append(partitions, e.logsRoutedPartitions(ctx, rest)...)
+}
+
+// logsRoutedPartitions splits ld by attribute when some log records are
+// routed to channels with matchers, then by channel when channel affinity is enabled
+func (e *slimExporter) logsRoutedPartitions(ctx context.Context, ld plog.Logs) []logsPartition {
+	routes := e.logRoutes(ctx)
+	if routes == nil {
+		return e.logsAffinityPartitions(ctx, ld, nil)
+	}
+
+	parts := partitionLogsBy(ld, len(routes.sessions)+1, routes.index)
+	var partitions []logsPartition
+	for i, session := range routes.sessions {
+		if parts[i].ResourceLogs().Len() > 0 {
+			partitions = append(partitions, logsPartition{sessions: []string{session}, data: parts[i]})
+		}
+	}
+	if rest := parts[len(routes.sessions)]; rest.ResourceLogs().Len() > 0 {
+		others := unroutedSessions(ctx, e.sessions, routes.sessions)
+		partitions = append(partitions, e.logsAffinityPartitions(ctx, rest, others)...)
+	}
+	return partitions
+}
+
+// logsAffinityPartitions splits ld by channel when channel affinity is
+// enabled, publishing it to targets otherwise
+func (e *slimExporter) logsAffinityPartitions(
+	ctx context.Context,
+	ld plog.Logs,
+	targets []string,
+) []logsPartition {
+	sessions := e.affinitySessions(ctx)
+	if sessions == nil {
+		return []logsPartition{{sessions: targets, data: ld}}
+	}
+
+	var partitions []logsPartition
+	for i, part := range partitionLogs(ld, len(sessions)) {
+		if part.ResourceLogs().Len() > 0 {
+			partitions = append(partitions, logsPartition{sessions: sessions[i : i+1], data: part})
+		}
+	}
+	return partitions
+}
+
+// partitionTraces distributes the spans of td among n batches according to
+// their trace ID
+func partitionTraces(td ptrace.Traces, n int) []ptrace.Traces {
+	return partitionTracesBy(td, n, func(span ptrace.Span) int {
+		return affinityIndex(span.TraceID(), n)
+	})
+}
+
+// partitionTracesBy distributes the spans of td among n batches according to
+// the batch index returned for each span. The resources and scopes are copied
+// in every batch that holds some of their spans.
+func partitionTracesBy(td ptrace.Traces, n int, index func(ptrace.Span) int) []ptrace.Traces {
+	parts := make([]ptrace.Traces, n)
+	for i := range parts {
+		parts[i] = ptrace.NewTraces()
+	}
+
+	rss := td.ResourceSpans()
+	for i := range rss.Len() {
+		rs := rss.At(i)
+		resources := make(map[int]ptrace.ResourceSpans)
+		sss := rs.ScopeSpans()
+		for j := range sss.Len() {
+			ss := sss.At(j)
+			scopes := make(map[int]ptrace.ScopeSpans)
+			spans := ss.Spans()
+			for k := range spans.Len() {
+				span := spans.At(k)
+				p := index(span)
+				scope, ok := scopes[p]
+				if !ok {
+					resource, ok := resources[p]
+					if !ok {
+						resource = parts[p].ResourceSpans().AppendEmpty()
+						rs.Resource().CopyTo(resource.Resource())
+						resource.SetSchemaUrl(rs.SchemaUrl())
+						resources[p] = resource
+					}
+					scope = resource.ScopeSpans().AppendEmpty()
+					ss.Scope().CopyTo(scope.Scope())
+					scope.SetSchemaUrl(ss.SchemaUrl())
+					scopes[p] = scope
+				}
+				span.CopyTo(scope.Spans().AppendEmpty())
+			}
+		}
+	}
+	return parts
+}
+
+// partitionLogs distributes the log records of ld among n batches according
+// to their trace ID, see partitionTraces
+func partitionLogs(ld plog.Logs, n int) []plog.Logs {
+	return partitionLogsBy(ld, n, func(_ pcommon.Resource, record plog.LogRecord) int {
+		return affinityIndex(record.TraceID(), n)
+	})
+}
+
+// partitionMetrics distributes the data points of md among n batches
+// according to the trace ID of their first exemplar with a trace ID. Data
+// points without such exemplar, including all summary data points, go to
+// the first batch. The resources, scopes and metric descriptions are copied
+// in every batch that holds some of their data points.
+func partitionMetrics(md pmetric.Metrics, n int) []pmetric.Metrics {
+	parts := make([]pmetric.Metrics, n)
+	for i := range parts {
+		parts[i] = pmetric.NewMetrics()
+	}
+
+	rms := md.ResourceMetrics()
+	for i := range rms.Len() {
+		rm := rms.At(i)
+		resources := make(map[int]pmetric.ResourceMetrics)
+		sms := rm.ScopeMetrics()
+		for j := range sms.Len() {
+			sm := sms.At(j)
+			scopes := make(map[int]pmetric.ScopeMetrics)
+			metrics := sm.Metrics()
+			for k := range metrics.Len() {
+				metric := metrics.At(k)
+				targets := make(map[int]pmetric.Metric)
+
+				// target returns the copy of metric, without data points, in batch p
+				target := func(p int) pmetric.Metric {
+					if dest, ok := targets[p]; ok {
+						return dest
+					}
+					scope, ok := scopes[p]
+					if !ok {
+						resource, ok := resources[p]
+						if !ok {
+							resource = parts[p].ResourceMetrics().AppendEmpty()
+							rm.Resource().CopyTo(resource.Resource())
+							resource.SetSchemaUrl(rm.SchemaUrl())
+							resources[p] = resource
+						}
+						scope = resource.ScopeMetrics().AppendEmpty()
+						sm.Scope().CopyTo(scope.Scope())
+						scope.SetSchemaUrl(sm.SchemaUrl())
+						scopes[p] = scope
+					}
+					dest := scope.Metrics().AppendEmpty()
+					copyMetricDescription(metric, dest)
+					targets[p] = dest
+					return dest
+				}
+
+				switch metric.Type() {
+				case pmetric.MetricTypeGauge:
+					dps := metric.Gauge().DataPoints()
+					for d := range dps.Len() {
+						p := affinityIndex(exemplarTraceID(dps.At(d).Exemplars()), n)
+						dps.At(d).CopyTo(target(p).Gauge().DataPoints().AppendEmpty())
+					}
+				case pmetric.MetricTypeSum:
+					dps := metric.Sum().DataPoints()
+					for d := range dps.Len() {
+						p := affinityIndex(exemplarTraceID(dps.At(d).Exemplars()), n)
+						dps.At(d).CopyTo(target(p).Sum().DataPoints().AppendEmpty())
+					}
+				case pmetric.MetricTypeHistogram:
+					dps := metric.Histogram().DataPoints()
+					for d := range dps.Len() {
+						p := affinityIndex(exemplarTraceID(dps.At(d).Exemplars()), n)
+						dps.At(d).CopyTo(target(p).Histogram().DataPoints().AppendEmpty())
+					}
+				case pmetric.MetricTypeExponentialHistogram:
+					dps := metric.ExponentialHistogram().DataPoints()
+					for d := range dps.Len() {
+						p := affinityIndex(exemplarTraceID(dps.At(d).Exemplars()), n)
+						dps.At(d).CopyTo(target(p).ExponentialHistogram().DataPoints().AppendEmpty())
+					}
+				default:
+					// summaries have no exemplars
+					metric.CopyTo(target(0))
+				}
+			}
+		}
+	}
+	return parts
+}
+
+// copyMetricDescription copies the metric name, description, unit, metadata
+// and data type, without the data points
+func copyMetricDescription(src, dest pmetric.Metric) {
+	dest.SetName(src.Name())
+	dest.SetDescription(src.Description())
+	dest.SetUnit(src.Unit())
+	src.Metadata().CopyTo(dest.Metadata())
+
+	switch src.Type() {
+	case pmetric.MetricTypeGauge:
+		dest.SetEmptyGauge()
+	case pmetric.MetricTypeSum:
+		sum := dest.SetEmptySum()
+		sum.SetAggregationTemporality(src.Sum().AggregationTemporality())
+		sum.SetIsMonotonic(src.Sum().IsMonotonic())
+	case pmetric.MetricTypeHistogram:
+		dest.SetEmptyHistogram().SetAggregationTemporality(src.Histogram().AggregationTemporality())
+	case pmetric.MetricTypeExponentialHistogram:
+		dest.SetEmptyExponentialHistogram().SetAggregationTemporality(src.ExponentialHistogram().AggregationTemporality())
+	}
+}
+
+// exemplarTraceID returns the trace ID of the first exemplar that has one
+func exemplarTraceID(exemplars pmetric.ExemplarSlice) pcommon.TraceID {
+	for i := range exemplars.Len() {
+		if traceID := exemplars.At(i).TraceID(); !traceID.IsEmpty() {
+			return traceID
+		}
+	}
+	return pcommon.NewTraceIDEmpty()
+}
@@ -0,0 +1,209 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// traceIDs returns count distinct trace IDs
+func traceIDs(count int) []pcommon.TraceID {
+	ids := make([]pcommon.TraceID, count)
+	for i := range ids {
+		ids[i] = pcommon.TraceID{byte(i + 1), 0xab, byte(i * 7)}
+	}
+	return ids
+}
+
+func TestAffinityIndex(t *testing.T) {
+	assert.Equal(t, 0, affinityIndex(pcommon.NewTraceIDEmpty(), 4))
+	for _, id := range traceIDs(32) {
+		index := affinityIndex(id, 4)
+		assert.GreaterOrEqual(t, index, 0)
+		assert.Less(t, index, 4)
+		assert.Equal(t, index, affinityIndex(id, 4), "the index must be stable")
+		assert.Equal(t, 0, affinityIndex(id, 1))
+	}
+}
+
+func TestPartitionTraces(t *testing.T) {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "svc")
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("scope")
+	ids := traceIDs(16)
+	for _, id := range ids {
+		span := ss.Spans().AppendEmpty()
+		span.SetTraceID(id)
+	}
+	ss.Spans().AppendEmpty().SetName("no-trace-id")
+
+	parts := partitionTraces(td, 3)
+	require.Len(t, parts, 3)
+
+	total := 0
+	for p, part := range parts {
+		total += part.SpanCount()
+		for i := range part.ResourceSpans().Len() {
+			resource := part.ResourceSpans().At(i)
+			name, _ := resource.Resource().Attributes().Get("service.name")
+			assert.Equal(t, "svc", name.Str())
+			scope := resource.ScopeSpans().At(0)
+			assert.Equal(t, "scope", scope.Scope().Name())
+			for k := range scope.Spans().Len() {
+				assert.Equal(t, p, affinityIndex(scope.Spans().At(k).TraceID(), 3))
+			}
+		}
+	}
+	assert.Equal(t, td.SpanCount(), total)
+}
+
+func TestPartitionLogs(t *testing.T) {
+	ld := plog.NewLogs()
+	sl := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+	for _, id := range traceIDs(16) {
+		sl.LogRecords().AppendEmpty().SetTraceID(id)
+	}
+	sl.LogRecords().AppendEmpty().Body().SetStr("no trace id")
+
+	parts := partitionLogs(ld, 2)
+	require.Len(t, parts, 2)
+
+	total := 0
+	for p, part := range parts {
+		total += part.LogRecordCount()
+		for i := range part.ResourceLogs().Len() {
+			records := part.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords()
+			for k := range records.Len() {
+				assert.Equal(t, p, affinityIndex(records.At(k).TraceID(), 2))
+			}
+		}
+	}
+	assert.Equal(t, ld.LogRecordCount(), total)
+}
+
+func TestPartitionMetrics(t *testing.T) {
+	md := pmetric.NewMetrics()
+	sm := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+
+	sum := sm.Metrics().AppendEmpty()
+	sum.SetName("requests")
+	sum.SetUnit("1")
+	sum.SetEmptySum().SetIsMonotonic(true)
+	sum.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	for _, id := range traceIDs(16) {
+		dp := sum.Sum().DataPoints().AppendEmpty()
+		dp.Exemplars().AppendEmpty().SetTraceID(id)
+	}
+	sum.Sum().DataPoints().AppendEmpty().SetIntValue(1)
+
+	summary := sm.Metrics().AppendEmpty()
+	summary.SetName("latency")
+	summary.SetEmptySummary().DataPoints().AppendEmpty().SetCount(3)
+
+	parts := partitionMetrics(md, 2)
+	require.Len(t, parts, 2)
+
+	total := 0
+	for p, part := range parts {
+		total += part.DataPointCount()
+		metrics := part.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+		for i := range metrics.Len() {
+			metric := metrics.At(i)
+			switch metric.Type() {
+			case pmetric.MetricTypeSum:
+				assert.Equal(t, "requests", metric.Name())
+				assert.Equal(t, "1", metric.Unit())
+				assert.True(t, metric.Sum().IsMonotonic())
+				assert.Equal(t, pmetric.AggregationTemporalityCumulative, metric.Sum().AggregationTemporality())
+				dps := metric.Sum().DataPoints()
+				for d := range dps.Len() {
+					assert.Equal(t, p, affinityIndex(exemplarTraceID(dps.At(d).Exemplars()), 2))
+				}
+			case pmetric.MetricTypeSummary:
+				assert.Equal(t, 0, p, "summaries go to the first batch")
+			default:
+				t.Errorf("unexpected metric type %s", metric.Type())
+			}
+		}
+	}
+	assert.Equal(t, md.DataPointCount(), total)
+}
+
+func TestAffinitySessions(t *testing.T) {
+	channels := []ChannelsConfig{
+		{ChannelName: "agntcy/otel/traces-1", Signal: "traces"},
+		{ChannelName: "agntcy/otel/logs-1", Signal: "logs"},
+		{ChannelName: "agntcy/otel/traces-2", Signal: "traces"},
+	}
+
+	exporter := &slimExporter{
+		config:     &Config{Channels: channels},
+		signalType: slimconfig.SignalTraces,
+	}
+	assert.Nil(t, exporter.affinitySessions(t.Context()), "affinity is disabled")
+
+	exporter.config.ChannelAffinity = true
+	assert.Len(t, exporter.affinitySessions(t.Context()), 2)
+
+	exporter.signalType = slimconfig.SignalLogs
+	assert.Nil(t, exporter.affinitySessions(t.Context()), "a single logs channel")
+}
+
+func TestPushTraces_ChannelAffinity(t *testing.T) {
+	session1 := testutil.NewFakeSession(1, "agntcy/otel/traces-1")
+	session2 := testutil.NewFakeSession(2, "agntcy/otel/traces-2")
+	invited := testutil.NewFakeSession(3, "agntcy/otel/invited")
+
+	exporter := &slimExporter{
+		config: &Config{
+			ChannelAffinity: true,
+			Channels: []ChannelsConfig{
+				{ChannelName: "agntcy/otel/traces-1", Signal: "traces"},
+				{ChannelName: "agntcy/otel/traces-2", Signal: "traces"},
+			},
+		},
+		signalType: slimconfig.SignalTraces,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+	}
+	for _, session := range []*testutil.FakeSession{session1, session2, invited} {
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+	}
+
+	td := ptrace.NewTraces()
+	spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	for _, id := range traceIDs(16) {
+		spans.AppendEmpty().SetTraceID(id)
+	}
+	require.NoError(t, exporter.pushTraces(t.Context(), td))
+
+	unmarshaler := ptrace.ProtoUnmarshaler{}
+	total := 0
+	for p, session := range []*testutil.FakeSession{session1, session2} {
+		for _, msg := range session.PublishedMessages() {
+			received, err := unmarshaler.UnmarshalTraces(msg.Payload)
+			require.NoError(t, err)
+			total += received.SpanCount()
+
+			spans := received.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+			for k := range spans.Len() {
+				assert.Equal(t, p, affinityIndex(spans.At(k).TraceID(), 2))
+			}
+		}
+	}
+	assert.Equal(t, td.SpanCount(), total, "every span is published exactly once")
+	assert.Empty(t, invited.PublishedMessages(), "sessions outside the configured channels are skipped")
+}
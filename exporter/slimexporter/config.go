@@ -6,23 +6,153 @@ package slimexporter
 import (
 	"errors"
 	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
 
+	"go.opentelemetry.io/collector/component"
+
+	slim "github.com/agntcy/slim-bindings-go"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
 	"github.com/agntcy/slim-otel/slimconfig"
 )
 
+const (
+	// SessionTypeGroup creates a group session on the channel and invites all the participants
+	SessionTypeGroup = "group"
+	// SessionTypePointToPoint creates a session directly towards a single participant
+	SessionTypePointToPoint = "point-to-point"
+)
+
+// reservedMetadataPrefix is the prefix of the message metadata keys set by
+// the exporter itself, e.g. the signal or the publication time
+const reservedMetadataPrefix = "slim-otel."
+
 // Config defines configuration for the Slim exporter
 type Config struct {
+	// SLIM connection extension the apps of the exporter are created on.
+	// When set, the connection config, the shared secret and the auth are
+	// taken from the extension
+	Connection *component.ID `mapstructure:"connection"`
+
 	// Connection configuration for the SLIM server
 	ConnectionConfig *slimconfig.ConnectionConfig `mapstructure:"connection-config"`
 
 	// exporter names
 	ExporterNames *slimconfig.SignalNames `mapstructure:"exporter-names"`
 
+	// Base name the exporter names of the signals not set in ExporterNames
+	// are derived from with SignalNameTemplate
+	ExporterName string `mapstructure:"exporter-name"`
+
+	// Template deriving the exporter name of a signal from ExporterName, with
+	// the {name} and {signal} placeholders. Defaults to {name}-{signal}
+	SignalNameTemplate string `mapstructure:"signal-name-template"`
+
 	// Shared Secret
-	SharedSecret string `mapstructure:"shared-secret"`
+	SharedSecret slimconfig.Opaque `mapstructure:"shared-secret"`
+
+	// Identity of the exporter apps towards the other participants. When not
+	// set, the shared secret is used
+	Auth *slimconfig.IdentityConfig `mapstructure:"auth"`
 
 	// List of sessions/channels to create
 	Channels []ChannelsConfig `mapstructure:"channels"`
+
+	// Maximum size in bytes of a published message. Larger batches are split
+	// along resource boundaries. Zero disables the limit
+	MaxMessageBytes int `mapstructure:"max-message-bytes"`
+
+	// Encoding of the published payloads: otlp_proto (default) or otlp_json
+	Encoding string `mapstructure:"encoding"`
+
+	// Versioned envelope the published payloads are wrapped in
+	Envelope EnvelopeConfig `mapstructure:"envelope"`
+
+	// Maximum time to hold the first publications until all the configured
+	// participants joined their sessions. Zero publishes immediately
+	ReadinessTimeout time.Duration `mapstructure:"readiness-timeout"`
+
+	// Interval at which a summary of the publish activity of each channel is
+	// logged. Zero disables the summary
+	SummaryInterval time.Duration `mapstructure:"summary-interval"`
+
+	// Maximum time the shutdown waits for the exports in progress to complete
+	// before deleting the sessions. Zero does not wait
+	ShutdownTimeout time.Duration `mapstructure:"shutdown-timeout"`
+
+	// Maximum time to wait for the receivers to acknowledge a published
+	// message. Zero disables acknowledgements
+	AckTimeout time.Duration `mapstructure:"ack-timeout"`
+
+	// Stop publishing on a session as soon as a drain notification reports
+	// that it is about to be closed
+	DrainNotifications bool `mapstructure:"drain-notifications"`
+
+	// Pause the publications on a channel frozen by the channel manager
+	// until it is unfrozen
+	FreezeNotifications bool `mapstructure:"freeze-notifications"`
+
+	// Publish the batches without any span, data point or log record
+	// instead of skipping them
+	PublishEmptyBatches bool `mapstructure:"publish-empty-batches"`
+
+	// Maximum number of sessions a message is published to concurrently
+	PublishConcurrency int `mapstructure:"publish-concurrency"`
+
+	// Maximum number of publications in progress on a session across the
+	// concurrent exports, so that a slow session does not accumulate them.
+	// Zero does not bound them
+	MaxInFlightPerSession int `mapstructure:"max-in-flight-per-session"`
+
+	// Maximum time a publication to a session may take, so that a hung
+	// session does not block the exports. Zero only bounds the publications
+	// by the timeout of the export
+	PublishTimeout time.Duration `mapstructure:"publish-timeout"`
+
+	// Time after which a session the exporter was invited to is closed when
+	// nothing was published or received on it. The channels created by the
+	// exporter are kept. Zero keeps the idle sessions open
+	IdleTimeout time.Duration `mapstructure:"idle-timeout"`
+
+	// Number of consecutive publish failures after which a session is closed,
+	// so that a broken participant does not fail every export. Zero keeps
+	// the failing sessions open
+	MaxPublishFailures int `mapstructure:"max-publish-failures"`
+
+	// Route the data correlated to the same trace to the same channel, when
+	// several channels are configured for a signal
+	ChannelAffinity bool `mapstructure:"channel-affinity"`
+
+	// Local directory where the payloads that could not be published are saved
+	DeadLetter DeadLetterConfig `mapstructure:"dead-letter"`
+
+	// Static metadata attached to every published message, e.g. the
+	// collector instance or the environment
+	Metadata map[string]string `mapstructure:"metadata"`
+
+	// Add the ID of the exporter component, e.g. slim/pipeline-a, to the
+	// metadata of the published messages, so that the receivers can tell
+	// apart the pipelines of a collector publishing on the same channel
+	TagComponent bool `mapstructure:"tag-component"`
+
+	// Channels the exporter accepts invitations for. Empty accepts any channel
+	AllowedChannels []string `mapstructure:"allowed-channels"`
+
+	// Participants allowed to invite the exporter to a channel. Empty accepts
+	// any inviter. The inviter of a group session is the initiator it
+	// advertises, which is not authenticated: the check is advisory
+	AllowedInviters []string `mapstructure:"allowed-inviters"`
+
+	// Channels the resources can route their telemetry to with the
+	// slim.channel.override attribute. Empty disables the override
+	ChannelOverrideAllowlist []string `mapstructure:"channel-override-allowlist"`
+
+	// Address of the HTTP endpoint serving the health of the channels on
+	// /debug/channels, shared by the exporters of the signals. Empty disables it
+	DebugEndpoint string `mapstructure:"debug-endpoint"`
 }
 
 // ChannelsConfig defines configuration for SLIM channels
@@ -38,34 +168,242 @@ type ChannelsConfig struct {
 
 	// Flag to enable or disable MLS for these sessions
 	MlsEnabled bool `mapstructure:"mls-enabled"`
+
+	// Type of the SLIM session: group (default) or point-to-point
+	SessionType string `mapstructure:"session-type"`
+
+	// Metric types or span kinds published on this channel only. Empty
+	// publishes the data types that are not dedicated to another channel
+	DataTypes []string `mapstructure:"data-types"`
+
+	// Attribute matchers of the log records published on this channel only,
+	// all of them must match. Empty publishes the log records that do not
+	// match another channel
+	Match []AttributeMatcher `mapstructure:"match"`
+
+	// Time after which the receivers drop the messages published on this
+	// channel instead of consuming them. Zero never expires the messages
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// EnvelopeConfig defines the versioned envelope wrapping the published
+// payloads, which tells the receivers the schema version, encoding,
+// compression and producer of each payload
+type EnvelopeConfig struct {
+	// Wrap the payloads in the envelope. Only the SLIM receivers reading the
+	// envelope can decode the wrapped payloads
+	Enabled bool `mapstructure:"enabled"`
+
+	// Compression of the wrapped payloads: none (default) or gzip
+	Compression string `mapstructure:"compression"`
+}
+
+// Validate checks if the envelope configuration is valid
+func (cfg *EnvelopeConfig) Validate() error {
+	if err := slimcommon.ValidateCompression(cfg.Compression); err != nil {
+		return fmt.Errorf("envelope: %w", err)
+	}
+	if !cfg.Enabled && cfg.Compression != "" && cfg.Compression != slimcommon.CompressionNone {
+		return errors.New("envelope compression requires the envelope to be enabled")
+	}
+	return nil
+}
+
+// DeadLetterConfig defines where the payloads that could not be published are saved
+type DeadLetterConfig struct {
+	// Directory where the undeliverable payloads are written. Empty disables
+	// the dead-letter spool
+	Directory string `mapstructure:"directory"`
+
+	// Maximum total size in bytes of the files in the directory. Zero means no limit
+	MaxBytes int64 `mapstructure:"max-bytes"`
+}
+
+// Validate checks if the dead-letter configuration is valid
+func (cfg *DeadLetterConfig) Validate() error {
+	if cfg.MaxBytes < 0 {
+		return errors.New("dead-letter max bytes cannot be negative")
+	}
+	if cfg.MaxBytes > 0 && cfg.Directory == "" {
+		return errors.New("dead-letter max bytes requires a directory")
+	}
+	return nil
+}
+
+// destination returns the name of the session destination: the channel name
+// for group sessions, the participant for point-to-point sessions
+func (c *ChannelsConfig) destination() string {
+	if c.SessionType == SessionTypePointToPoint && len(c.Participants) > 0 {
+		return c.Participants[0]
+	}
+	return c.ChannelName
+}
+
+// slimSessionType returns the SLIM session type to use for the channel
+func (c *ChannelsConfig) slimSessionType() slim.SessionType {
+	if c.SessionType == SessionTypePointToPoint {
+		return slim.SessionTypePointToPoint
+	}
+	return slim.SessionTypeGroup
+}
+
+// identity returns the identity of the exporter apps, the auth config if set
+// and the shared secret otherwise
+func (cfg *Config) identity() slimconfig.IdentityConfig {
+	if cfg.Auth != nil {
+		return *cfg.Auth
+	}
+	return slimconfig.IdentityConfig{Type: "shared_secret", SharedSecret: cfg.SharedSecret}
+}
+
+// exporterName returns the name of the exporter app of signal: its name in
+// ExporterNames if set, or the name derived from ExporterName otherwise
+func (cfg *Config) exporterName(signal slimconfig.SignalType) (string, error) {
+	return slimcommon.ResolveSignalName(cfg.ExporterNames, cfg.ExporterName, cfg.SignalNameTemplate, signal)
+}
+
+// direction returns the direction of the exporter apps
+func (cfg *Config) direction() slim.Direction {
+	// acknowledgements, drain and freeze notifications are received back on
+	// the sessions
+	if cfg.AckTimeout > 0 || cfg.DrainNotifications || cfg.FreezeNotifications {
+		return slim.DirectionBidirectional
+	}
+	return slim.DirectionSend
 }
 
 // Validate checks if the exporter configuration is valid
 func (cfg *Config) Validate() error {
-	if cfg.SharedSecret == "" {
-		return errors.New("missing shared secret")
+	if cfg.Connection != nil {
+		if cfg.ConnectionConfig != nil || cfg.SharedSecret != "" || cfg.Auth != nil {
+			return errors.New("connection config, shared secret and auth cannot be set with a connection extension")
+		}
+	} else {
+		if cfg.Auth != nil {
+			if err := cfg.Auth.Validate(); err != nil {
+				return fmt.Errorf("invalid auth config: %w", err)
+			}
+		} else if cfg.SharedSecret == "" {
+			return errors.New("missing shared secret")
+		}
+
+		if cfg.ConnectionConfig == nil {
+			return errors.New("missing connection config")
+		}
+
+		if err := cfg.ConnectionConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid connection config: %w", err)
+		}
+	}
+
+	// expoter names must be set, or derived from the exporter name
+	if cfg.ExporterName == "" {
+		if cfg.ExporterNames == nil {
+			return errors.New("exporter names cannot be nil")
+		}
+		if cfg.ExporterNames.Metrics == nil || cfg.ExporterNames.Traces == nil || cfg.ExporterNames.Logs == nil {
+			return errors.New("exporter names cannot be nil")
+		}
+	}
+	if cfg.SignalNameTemplate != "" {
+		if err := slimcommon.ValidateSignalNameTemplate(cfg.SignalNameTemplate); err != nil {
+			return err
+		}
+	}
+	for _, signal := range []slimconfig.SignalType{
+		slimconfig.SignalTraces, slimconfig.SignalMetrics, slimconfig.SignalLogs,
+	} {
+		if _, err := cfg.exporterName(signal); err != nil {
+			return fmt.Errorf("invalid exporter name for %s: %w", signal, err)
+		}
 	}
 
-	if cfg.ConnectionConfig == nil {
-		return errors.New("missing connection config")
+	if cfg.MaxMessageBytes < 0 {
+		return errors.New("max message bytes cannot be negative")
 	}
 
-	if err := cfg.ConnectionConfig.Validate(); err != nil {
-		return fmt.Errorf("invalid connection config: %w", err)
+	if err := slimcommon.ValidateEncoding(cfg.Encoding); err != nil {
+		return err
 	}
 
-	// expoter names must be set
-	if cfg.ExporterNames == nil {
-		return errors.New("exporter names cannot be nil")
+	if err := cfg.Envelope.Validate(); err != nil {
+		return err
 	}
-	if cfg.ExporterNames.Metrics == nil || cfg.ExporterNames.Traces == nil || cfg.ExporterNames.Logs == nil {
-		return errors.New("exporter names cannot be nil")
+
+	if cfg.ReadinessTimeout < 0 {
+		return errors.New("readiness timeout cannot be negative")
+	}
+
+	if cfg.SummaryInterval < 0 {
+		return errors.New("summary interval cannot be negative")
+	}
+
+	if cfg.ShutdownTimeout < 0 {
+		return errors.New("shutdown timeout cannot be negative")
+	}
+
+	if cfg.AckTimeout < 0 {
+		return errors.New("ack timeout cannot be negative")
+	}
+
+	if cfg.PublishConcurrency < 0 {
+		return errors.New("publish concurrency cannot be negative")
+	}
+
+	if cfg.MaxInFlightPerSession < 0 {
+		return errors.New("max in-flight per session cannot be negative")
+	}
+
+	if cfg.PublishTimeout < 0 {
+		return errors.New("publish timeout cannot be negative")
+	}
+
+	if cfg.IdleTimeout < 0 {
+		return errors.New("idle timeout cannot be negative")
+	}
+
+	if cfg.MaxPublishFailures < 0 {
+		return errors.New("max publish failures cannot be negative")
+	}
+
+	if err := cfg.DeadLetter.Validate(); err != nil {
+		return err
+	}
+
+	for key := range cfg.Metadata {
+		if key == "" {
+			return errors.New("metadata keys cannot be empty")
+		}
+		if strings.HasPrefix(key, reservedMetadataPrefix) {
+			return fmt.Errorf("metadata key %s uses the reserved prefix %s", key, reservedMetadataPrefix)
+		}
+	}
+
+	if err := validatePatterns("allowed channel", cfg.AllowedChannels); err != nil {
+		return err
+	}
+	if err := validatePatterns("allowed inviter", cfg.AllowedInviters); err != nil {
+		return err
+	}
+	if err := validatePatterns("channel override", cfg.ChannelOverrideAllowlist); err != nil {
+		return err
 	}
 
 	// Validate each channel (the list can be empty)
 	for i, channel := range cfg.Channels {
-		if channel.ChannelName == "" {
-			return fmt.Errorf("channel name is required for channel %d", i)
+		// Validate session type
+		switch channel.SessionType {
+		case "", SessionTypeGroup:
+			if channel.ChannelName == "" {
+				return fmt.Errorf("channel name is required for channel %d", i)
+			}
+		case SessionTypePointToPoint:
+			// the session is created towards the participant, the channel name is not used
+			if len(channel.Participants) > 1 {
+				return fmt.Errorf("point-to-point channel %d must have exactly one participant", i)
+			}
+		default:
+			return fmt.Errorf("invalid session type '%s' for channel %d", channel.SessionType, i)
 		}
 		// At list one signal type must be specified
 		if channel.Signal == "" {
@@ -81,7 +419,70 @@ func (cfg *Config) Validate() error {
 		if len(channel.Participants) == 0 {
 			return fmt.Errorf("at least one participant must be specified for channel '%d'", i)
 		}
+		if channel.TTL < 0 {
+			return fmt.Errorf("ttl cannot be negative for channel %d", i)
+		}
+		if len(channel.Match) > 0 && channel.Signal != string(slimconfig.SignalLogs) {
+			return fmt.Errorf("attribute matchers are only supported for logs channels, channel %d", i)
+		}
+		for j := range channel.Match {
+			if err := channel.Match[j].Validate(); err != nil {
+				return fmt.Errorf("invalid matcher %d for channel %d: %w", j, i, err)
+			}
+		}
 	}
 
+	return validateDataTypes(cfg.Channels)
+}
+
+// validateDataTypes checks that the data types of each channel are valid for
+// its signal and that each data type is dedicated to a single channel
+func validateDataTypes(channels []ChannelsConfig) error {
+	// channel index of each data type, by signal
+	routed := make(map[string]map[string]int)
+	for i, channel := range channels {
+		if len(channel.DataTypes) == 0 {
+			continue
+		}
+		valid := dataTypesForSignal(channel.Signal)
+		if valid == nil {
+			return fmt.Errorf("data types are not supported for %s channel %d", channel.Signal, i)
+		}
+		if routed[channel.Signal] == nil {
+			routed[channel.Signal] = make(map[string]int)
+		}
+		for _, dataType := range channel.DataTypes {
+			if !slices.Contains(valid, dataType) {
+				return fmt.Errorf("invalid data type '%s' for %s channel %d", dataType, channel.Signal, i)
+			}
+			if other, ok := routed[channel.Signal][dataType]; ok && other != i {
+				return fmt.Errorf("data type '%s' is mapped to both channels %d and %d", dataType, other, i)
+			}
+			routed[channel.Signal][dataType] = i
+		}
+	}
 	return nil
 }
+
+// validatePatterns checks that every pattern is a valid name pattern
+func validatePatterns(kind string, patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return fmt.Errorf("%s cannot be empty", kind)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid %s pattern '%s': %w", kind, pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchesAny reports whether name matches at least one of the patterns
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -6,10 +6,16 @@ package slimexporter
 import (
 	"strings"
 	"testing"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
 
 	"github.com/agntcy/slim-otel/slimconfig"
 )
 
+// slimConnectionID is the ID of a SLIM connection extension
+var slimConnectionID = component.MustNewIDWithName("slim", "shared")
+
 // Helper function to create string pointers
 func strPtr(s string) *string {
 	return &s
@@ -83,6 +89,40 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "spire auth without shared secret",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("agntcy/test/exporter-metrics"),
+					Traces:  strPtr("agntcy/test/exporter-traces"),
+					Logs:    strPtr("agntcy/test/exporter-logs"),
+				},
+				Auth: &slimconfig.IdentityConfig{
+					Type:  "spire",
+					Spire: &slimconfig.SpireConfig{JwtAudiences: []string{"slim"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid auth config",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("agntcy/test/exporter-metrics"),
+					Traces:  strPtr("agntcy/test/exporter-traces"),
+					Logs:    strPtr("agntcy/test/exporter-logs"),
+				},
+				Auth: &slimconfig.IdentityConfig{Type: "static_jwt"},
+			},
+			wantErr: true,
+			errMsg:  "invalid auth config: static JWT configuration is required",
+		},
 		{
 			name: "missing shared secret",
 			config: &Config{
@@ -120,6 +160,32 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "missing connection config",
 		},
+		{
+			name: "connection extension",
+			config: &Config{
+				Connection: &slimConnectionID,
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "connection extension with a shared secret",
+			config: &Config{
+				Connection: &slimConnectionID,
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+			},
+			wantErr: true,
+			errMsg:  "cannot be set with a connection extension",
+		},
 		{
 			name: "nil exporter names",
 			config: &Config{
@@ -150,6 +216,45 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "exporter names cannot be nil",
 		},
+		{
+			name: "exporter names derived from the exporter name",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterName: "agntcy/test/exporter",
+				ExporterNames: &slimconfig.SignalNames{
+					Logs: strPtr("agntcy/test/log-shipper"),
+				},
+				SharedSecret: "test-secret",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid exporter name",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterName: "agntcy/exporter",
+				SharedSecret: "test-secret",
+			},
+			wantErr: true,
+			errMsg:  "invalid exporter name for traces",
+		},
+		{
+			name: "invalid signal name template",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterName:       "agntcy/test/exporter",
+				SignalNameTemplate: "{name}-traces",
+				SharedSecret:       "test-secret",
+			},
+			wantErr: true,
+			errMsg:  "must contain {name} and {signal} once",
+		},
 		{
 			name: "channel with missing channel name",
 			config: &Config{
@@ -299,85 +404,8 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid signal type",
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := tt.config.Validate()
-			if (err != nil) != tt.wantErr {
-				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if tt.wantErr && err != nil && tt.errMsg != "" {
-				if !strings.Contains(err.Error(), tt.errMsg) {
-					t.Errorf("Config.Validate() error = %v, expected to contain %v", err.Error(), tt.errMsg)
-				}
-			}
-		})
-	}
-}
-
-func TestConfig_Validate_DefaultValues(t *testing.T) {
-	// This test validates that the config structure is correct
-	// Default values are now set by the factory, not by Validate()
-	config := &Config{
-		ConnectionConfig: &slimconfig.ConnectionConfig{
-			Address: "http://localhost:46357",
-		},
-		ExporterNames: &slimconfig.SignalNames{
-			Metrics: strPtr("test/metrics"),
-			Traces:  strPtr("test/traces"),
-			Logs:    strPtr("test/logs"),
-		},
-		SharedSecret: "test-secret",
-		Channels:     []ChannelsConfig{},
-	}
-
-	err := config.Validate()
-	if err != nil {
-		t.Fatalf("Config.Validate() unexpected error = %v", err)
-	}
-}
-
-func TestConfig_Validate_PartialDefaults(t *testing.T) {
-	// This test validates that custom values work correctly
-	// Partial defaults are now set by the factory, not by Validate()
-	config := &Config{
-		ConnectionConfig: &slimconfig.ConnectionConfig{
-			Address: "http://custom:8080",
-		},
-		ExporterNames: &slimconfig.SignalNames{
-			Metrics: strPtr("custom/metrics"),
-			Traces:  strPtr("custom/traces"),
-			Logs:    strPtr("custom/logs"),
-		},
-		SharedSecret: "test-secret",
-		Channels:     []ChannelsConfig{},
-	}
-
-	err := config.Validate()
-	if err != nil {
-		t.Fatalf("Config.Validate() unexpected error = %v", err)
-	}
-
-	// Check that custom values are preserved
-	if config.ConnectionConfig.Address != "http://custom:8080" {
-		t.Errorf("ConnectionConfig.Address = %v, want http://custom:8080", config.ConnectionConfig.Address)
-	}
-	if *config.ExporterNames.Metrics != "custom/metrics" {
-		t.Errorf("ExporterNames.Metrics = %v, want custom/metrics", *config.ExporterNames.Metrics)
-	}
-}
-
-func TestConfig_Validate_MultipleChannelsWithError(t *testing.T) {
-	tests := []struct {
-		name    string
-		config  *Config
-		wantErr bool
-		errMsg  string
-	}{
 		{
-			name: "second channel has no channel name",
+			name: "valid point-to-point channel without channel name",
 			config: &Config{
 				ConnectionConfig: &slimconfig.ConnectionConfig{
 					Address: "http://localhost:46357",
@@ -390,22 +418,16 @@ func TestConfig_Validate_MultipleChannelsWithError(t *testing.T) {
 				SharedSecret: "test-secret",
 				Channels: []ChannelsConfig{
 					{
-						ChannelName:  "agntcy/test/channel1",
 						Signal:       "traces",
-						Participants: []string{"test/participant1"},
-					},
-					{
-						ChannelName:  "",
-						Signal:       "metrics",
-						Participants: []string{"test/participant2"},
+						Participants: []string{"agntcy/test/participant1"},
+						SessionType:  SessionTypePointToPoint,
 					},
 				},
 			},
-			wantErr: true,
-			errMsg:  "channel 1",
+			wantErr: false,
 		},
 		{
-			name: "second channel has no signal",
+			name: "point-to-point channel with several participants",
 			config: &Config{
 				ConnectionConfig: &slimconfig.ConnectionConfig{
 					Address: "http://localhost:46357",
@@ -418,22 +440,17 @@ func TestConfig_Validate_MultipleChannelsWithError(t *testing.T) {
 				SharedSecret: "test-secret",
 				Channels: []ChannelsConfig{
 					{
-						ChannelName:  "agntcy/test/channel1",
 						Signal:       "traces",
-						Participants: []string{"test/participant1"},
-					},
-					{
-						ChannelName:  "agntcy/test/channel2",
-						Signal:       "",
-						Participants: []string{"test/participant2"},
+						Participants: []string{"agntcy/test/participant1", "agntcy/test/participant2"},
+						SessionType:  SessionTypePointToPoint,
 					},
 				},
 			},
 			wantErr: true,
-			errMsg:  "channel 1",
+			errMsg:  "must have exactly one participant",
 		},
 		{
-			name: "third channel has no participants",
+			name: "invalid session type",
 			config: &Config{
 				ConnectionConfig: &slimconfig.ConnectionConfig{
 					Address: "http://localhost:46357",
@@ -446,24 +463,801 @@ func TestConfig_Validate_MultipleChannelsWithError(t *testing.T) {
 				SharedSecret: "test-secret",
 				Channels: []ChannelsConfig{
 					{
-						ChannelName:  "agntcy/test/channel1",
+						ChannelName:  "agntcy/test/channel",
 						Signal:       "traces",
-						Participants: []string{"test/participant1"},
-					},
-					{
-						ChannelName:  "agntcy/test/channel2",
-						Signal:       "metrics",
-						Participants: []string{"test/participant2"},
-					},
-					{
-						ChannelName:  "agntcy/test/channel3",
-						Signal:       "logs",
-						Participants: []string{},
+						Participants: []string{"agntcy/test/participant1"},
+						SessionType:  "broadcast",
 					},
 				},
 			},
 			wantErr: true,
-			errMsg:  "channel '2'",
+			errMsg:  "invalid session type",
+		},
+		{
+			name: "negative max message size",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret:    "test-secret",
+				MaxMessageBytes: -1,
+			},
+			wantErr: true,
+			errMsg:  "max message bytes cannot be negative",
+		},
+		{
+			name: "invalid encoding",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Encoding:     "otlp_xml",
+			},
+			wantErr: true,
+			errMsg:  `invalid encoding "otlp_xml"`,
+		},
+		{
+			name: "invalid envelope compression",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Envelope:     EnvelopeConfig{Enabled: true, Compression: "zstd"},
+			},
+			wantErr: true,
+			errMsg:  `envelope: invalid compression "zstd"`,
+		},
+		{
+			name: "envelope compression without envelope",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Envelope:     EnvelopeConfig{Compression: "gzip"},
+			},
+			wantErr: true,
+			errMsg:  "envelope compression requires the envelope to be enabled",
+		},
+		{
+			name: "valid config with mTLS connection",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "https://localhost:46357",
+					TLS: &slimconfig.TLSConfig{
+						CASource: &slimconfig.TLSCAConfig{
+							Path: strPtr("tests/certs/slim-node-ca-cert.pem"),
+						},
+						Source: &slimconfig.TLSCertKeySource{
+							CertFile: strPtr("tests/certs/exporter-cert.pem"),
+							KeyFile:  strPtr("tests/certs/exporter-key.pem"),
+						},
+					},
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+			},
+			wantErr: false,
+		},
+		{
+			name: "secure TLS config with http address",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+					TLS:     &slimconfig.TLSConfig{},
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+			},
+			wantErr: true,
+			errMsg:  "address must start with https://",
+		},
+		{
+			name: "TLS source without key file",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "https://localhost:46357",
+					TLS: &slimconfig.TLSConfig{
+						Source: &slimconfig.TLSCertKeySource{
+							CertFile: strPtr("tests/certs/exporter-cert.pem"),
+						},
+					},
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+			},
+			wantErr: true,
+			errMsg:  "key_file is required",
+		},
+		{
+			name: "negative readiness timeout",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret:     "test-secret",
+				ReadinessTimeout: -time.Second,
+			},
+			wantErr: true,
+			errMsg:  "readiness timeout cannot be negative",
+		},
+		{
+			name: "negative summary interval",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret:    "test-secret",
+				SummaryInterval: -time.Minute,
+			},
+			wantErr: true,
+			errMsg:  "summary interval cannot be negative",
+		},
+		{
+			name: "negative shutdown timeout",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret:    "test-secret",
+				ShutdownTimeout: -time.Minute,
+			},
+			wantErr: true,
+			errMsg:  "shutdown timeout cannot be negative",
+		},
+		{
+			name: "negative ack timeout",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				AckTimeout:   -time.Second,
+			},
+			wantErr: true,
+			errMsg:  "ack timeout cannot be negative",
+		},
+		{
+			name: "negative publish concurrency",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret:       "test-secret",
+				PublishConcurrency: -1,
+			},
+			wantErr: true,
+			errMsg:  "publish concurrency cannot be negative",
+		},
+		{
+			name: "negative max in-flight per session",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret:          "test-secret",
+				MaxInFlightPerSession: -1,
+			},
+			wantErr: true,
+			errMsg:  "max in-flight per session cannot be negative",
+		},
+		{
+			name: "negative publish timeout",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret:   "test-secret",
+				PublishTimeout: -time.Second,
+			},
+			wantErr: true,
+			errMsg:  "publish timeout cannot be negative",
+		},
+		{
+			name: "negative idle timeout",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				IdleTimeout:  -time.Second,
+			},
+			wantErr: true,
+			errMsg:  "idle timeout cannot be negative",
+		},
+		{
+			name: "negative max publish failures",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret:       "test-secret",
+				MaxPublishFailures: -1,
+			},
+			wantErr: true,
+			errMsg:  "max publish failures cannot be negative",
+		},
+		{
+			name: "dead-letter max bytes without directory",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				DeadLetter:   DeadLetterConfig{MaxBytes: 1024},
+			},
+			wantErr: true,
+			errMsg:  "dead-letter max bytes requires a directory",
+		},
+		{
+			name: "invalid allowed channel pattern",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret:    "test-secret",
+				AllowedChannels: []string{"agntcy/otel/["},
+			},
+			wantErr: true,
+			errMsg:  "invalid allowed channel pattern",
+		},
+		{
+			name: "reserved metadata key",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Metadata:     map[string]string{"environment": "prod", "slim-otel.signal": "logs"},
+			},
+			wantErr: true,
+			errMsg:  "metadata key slim-otel.signal uses the reserved prefix",
+		},
+		{
+			name: "empty allowed inviter",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret:    "test-secret",
+				AllowedInviters: []string{""},
+			},
+			wantErr: true,
+			errMsg:  "allowed inviter cannot be empty",
+		},
+		{
+			name: "valid channel override allowlist",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret:             "test-secret",
+				ChannelOverrideAllowlist: []string{"agntcy/otel/*"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid channel override pattern",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret:             "test-secret",
+				ChannelOverrideAllowlist: []string{"agntcy/otel/[a-"},
+			},
+			wantErr: true,
+			errMsg:  "invalid channel override pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && err != nil && tt.errMsg != "" {
+				if !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("Config.Validate() error = %v, expected to contain %v", err.Error(), tt.errMsg)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_ExporterName(t *testing.T) {
+	config := &Config{
+		ExporterName:       "agntcy/test/exporter",
+		SignalNameTemplate: "{signal}.{name}",
+		ExporterNames:      &slimconfig.SignalNames{Logs: strPtr("agntcy/test/log-shipper")},
+	}
+
+	name, err := config.exporterName(slimconfig.SignalTraces)
+	if err != nil || name != "agntcy/test/traces.exporter" {
+		t.Errorf("exporterName(traces) = %v, %v, want agntcy/test/traces.exporter", name, err)
+	}
+	name, err = config.exporterName(slimconfig.SignalLogs)
+	if err != nil || name != "agntcy/test/log-shipper" {
+		t.Errorf("exporterName(logs) = %v, %v, want the explicit name", name, err)
+	}
+}
+
+func TestConfig_Validate_DefaultValues(t *testing.T) {
+	// This test validates that the config structure is correct
+	// Default values are now set by the factory, not by Validate()
+	config := &Config{
+		ConnectionConfig: &slimconfig.ConnectionConfig{
+			Address: "http://localhost:46357",
+		},
+		ExporterNames: &slimconfig.SignalNames{
+			Metrics: strPtr("test/metrics"),
+			Traces:  strPtr("test/traces"),
+			Logs:    strPtr("test/logs"),
+		},
+		SharedSecret: "test-secret",
+		Channels:     []ChannelsConfig{},
+	}
+
+	err := config.Validate()
+	if err != nil {
+		t.Fatalf("Config.Validate() unexpected error = %v", err)
+	}
+}
+
+func TestConfig_Validate_PartialDefaults(t *testing.T) {
+	// This test validates that custom values work correctly
+	// Partial defaults are now set by the factory, not by Validate()
+	config := &Config{
+		ConnectionConfig: &slimconfig.ConnectionConfig{
+			Address: "http://custom:8080",
+		},
+		ExporterNames: &slimconfig.SignalNames{
+			Metrics: strPtr("custom/metrics"),
+			Traces:  strPtr("custom/traces"),
+			Logs:    strPtr("custom/logs"),
+		},
+		SharedSecret: "test-secret",
+		Channels:     []ChannelsConfig{},
+	}
+
+	err := config.Validate()
+	if err != nil {
+		t.Fatalf("Config.Validate() unexpected error = %v", err)
+	}
+
+	// Check that custom values are preserved
+	if config.ConnectionConfig.Address != "http://custom:8080" {
+		t.Errorf("ConnectionConfig.Address = %v, want http://custom:8080", config.ConnectionConfig.Address)
+	}
+	if *config.ExporterNames.Metrics != "custom/metrics" {
+		t.Errorf("ExporterNames.Metrics = %v, want custom/metrics", *config.ExporterNames.Metrics)
+	}
+}
+
+func TestConfig_Validate_MultipleChannelsWithError(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "second channel has no channel name",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Channels: []ChannelsConfig{
+					{
+						ChannelName:  "agntcy/test/channel1",
+						Signal:       "traces",
+						Participants: []string{"test/participant1"},
+					},
+					{
+						ChannelName:  "",
+						Signal:       "metrics",
+						Participants: []string{"test/participant2"},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "channel 1",
+		},
+		{
+			name: "second channel has no signal",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Channels: []ChannelsConfig{
+					{
+						ChannelName:  "agntcy/test/channel1",
+						Signal:       "traces",
+						Participants: []string{"test/participant1"},
+					},
+					{
+						ChannelName:  "agntcy/test/channel2",
+						Signal:       "",
+						Participants: []string{"test/participant2"},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "channel 1",
+		},
+		{
+			name: "third channel has no participants",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Channels: []ChannelsConfig{
+					{
+						ChannelName:  "agntcy/test/channel1",
+						Signal:       "traces",
+						Participants: []string{"test/participant1"},
+					},
+					{
+						ChannelName:  "agntcy/test/channel2",
+						Signal:       "metrics",
+						Participants: []string{"test/participant2"},
+					},
+					{
+						ChannelName:  "agntcy/test/channel3",
+						Signal:       "logs",
+						Participants: []string{},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "channel '2'",
+		},
+		{
+			name: "metric types split across channels",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Channels: []ChannelsConfig{
+					{
+						ChannelName:  "agntcy/test/histograms",
+						Signal:       "metrics",
+						Participants: []string{"test/participant1"},
+						DataTypes:    []string{"histogram", "exponential-histogram"},
+					},
+					{
+						ChannelName:  "agntcy/test/span-kinds",
+						Signal:       "traces",
+						Participants: []string{"test/participant2"},
+						DataTypes:    []string{"server", "client"},
+					},
+					{
+						ChannelName:  "agntcy/test/metrics",
+						Signal:       "metrics",
+						Participants: []string{"test/participant3"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "data type mapped to two channels",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Channels: []ChannelsConfig{
+					{
+						ChannelName:  "agntcy/test/histograms",
+						Signal:       "metrics",
+						Participants: []string{"test/participant1"},
+						DataTypes:    []string{"histogram"},
+					},
+					{
+						ChannelName:  "agntcy/test/all-histograms",
+						Signal:       "metrics",
+						Participants: []string{"test/participant2"},
+						DataTypes:    []string{"histogram", "exponential-histogram"},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "data type 'histogram' is mapped to both channels 0 and 1",
+		},
+		{
+			name: "same data type for different signals",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Channels: []ChannelsConfig{
+					{
+						ChannelName:  "agntcy/test/server-metrics",
+						Signal:       "metrics",
+						Participants: []string{"test/participant1"},
+						DataTypes:    []string{"sum"},
+					},
+					{
+						ChannelName:  "agntcy/test/server-spans",
+						Signal:       "traces",
+						Participants: []string{"test/participant2"},
+						DataTypes:    []string{"server"},
+					},
+					{
+						ChannelName:  "agntcy/test/client-spans",
+						Signal:       "traces",
+						Participants: []string{"test/participant3"},
+						DataTypes:    []string{"client", "consumer"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid metric type",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Channels: []ChannelsConfig{
+					{
+						ChannelName:  "agntcy/test/metrics",
+						Signal:       "metrics",
+						Participants: []string{"test/participant1"},
+						DataTypes:    []string{"server"},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid data type 'server' for metrics channel 0",
+		},
+		{
+			name: "data types for logs",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Channels: []ChannelsConfig{
+					{
+						ChannelName:  "agntcy/test/logs",
+						Signal:       "logs",
+						Participants: []string{"test/participant1"},
+						DataTypes:    []string{"error"},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "data types are not supported for logs channel 0",
+		},
+		{
+			name: "attribute matchers for metrics",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Channels: []ChannelsConfig{
+					{
+						ChannelName:  "agntcy/test/metrics",
+						Signal:       "metrics",
+						Participants: []string{"test/participant1"},
+						Match:        []AttributeMatcher{{Key: "k8s.container.name", Value: "nginx"}},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "attribute matchers are only supported for logs channels, channel 0",
+		},
+		{
+			name: "negative channel ttl",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Channels: []ChannelsConfig{
+					{
+						ChannelName:  "agntcy/test/metrics",
+						Signal:       "metrics",
+						Participants: []string{"test/participant1"},
+						TTL:          -time.Second,
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "ttl cannot be negative for channel 0",
+		},
+		{
+			name: "attribute matcher with value and prefix",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("test/metrics"),
+					Traces:  strPtr("test/traces"),
+					Logs:    strPtr("test/logs"),
+				},
+				SharedSecret: "test-secret",
+				Channels: []ChannelsConfig{
+					{
+						ChannelName:  "agntcy/test/logs",
+						Signal:       "logs",
+						Participants: []string{"test/participant1"},
+						Match:        []AttributeMatcher{{Key: "log.file.path", Value: "/var/log/a", Prefix: "/var/log/"}},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid matcher 0 for channel 0: attribute value and prefix cannot be both set",
 		},
 	}
 
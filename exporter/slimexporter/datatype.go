@@ -0,0 +1,173 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"slices"
+
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// metricDataTypes are the data types of the metrics channels, one per metric type
+var metricDataTypes = []string{"gauge", "sum", "histogram", "exponential-histogram", "summary"}
+
+// spanDataTypes are the data types of the traces channels, one per span kind
+var spanDataTypes = []string{"unspecified", "internal", "server", "client", "producer", "consumer"}
+
+// dataTypesForSignal returns the data types that can be routed for the
+// signal, nil if the signal cannot be split by data type
+func dataTypesForSignal(signal string) []string {
+	switch signal {
+	case string(slimconfig.SignalMetrics):
+		return metricDataTypes
+	case string(slimconfig.SignalTraces):
+		return spanDataTypes
+	default:
+		return nil
+	}
+}
+
+// metricDataType returns the data type of a metric type
+func metricDataType(metricType pmetric.MetricType) string {
+	switch metricType {
+	case pmetric.MetricTypeGauge:
+		return "gauge"
+	case pmetric.MetricTypeSum:
+		return "sum"
+	case pmetric.MetricTypeHistogram:
+		return "histogram"
+	case pmetric.MetricTypeExponentialHistogram:
+		return "exponential-histogram"
+	case pmetric.MetricTypeSummary:
+		return "summary"
+	default:
+		return ""
+	}
+}
+
+// spanDataType returns the data type of a span kind
+func spanDataType(kind ptrace.SpanKind) string {
+	switch kind {
+	case ptrace.SpanKindInternal:
+		return "internal"
+	case ptrace.SpanKindServer:
+		return "server"
+	case ptrace.SpanKindClient:
+		return "client"
+	case ptrace.SpanKindProducer:
+		return "producer"
+	case ptrace.SpanKindConsumer:
+		return "consumer"
+	default:
+		return "unspecified"
+	}
+}
+
+// dataTypeRoutes maps the data types of a signal to the sessions of the
+// channels dedicated to them
+type dataTypeRoutes struct {
+	// names of the sessions of the dedicated channels, in configuration order
+	sessions []string
+	// index in sessions of the channel of each routed data type
+	indexes map[string]int
+}
+
+// index returns the index in sessions of the channel dedicated to dataType,
+// or len(sessions) if the data type is not routed
+func (r *dataTypeRoutes) index(dataType string) int {
+	if i, ok := r.indexes[dataType]; ok {
+		return i
+	}
+	return len(r.sessions)
+}
+
+// otherSessions returns the names of the sessions that are not dedicated to
+// a data type, the ones that get the data types that are not routed
+func (r *dataTypeRoutes) otherSessions(ctx context.Context, sessions *slimcommon.SessionsList) []string {
+	return unroutedSessions(ctx, sessions, r.sessions)
+}
+
+// unroutedSessions returns the names of the sessions that are not in routed
+func unroutedSessions(ctx context.Context, sessions *slimcommon.SessionsList, routed []string) []string {
+	others := []string{}
+	for _, name := range sessions.ListSessionNames(ctx) {
+		if !slices.Contains(routed, name) {
+			others = append(others, name)
+		}
+	}
+	return others
+}
+
+// dataTypeRoutes returns the data type routes of the channels configured for
+// the signal, or nil if none of them is dedicated to data types
+func (e *slimExporter) dataTypeRoutes(ctx context.Context) *dataTypeRoutes {
+	var routes *dataTypeRoutes
+	for _, channel := range e.config.Channels {
+		if channel.Signal != string(e.signalType) || len(channel.DataTypes) == 0 {
+			continue
+		}
+		name, err := slimcommon.SplitID(channel.destination())
+		if err != nil {
+			slimcommon.LoggerFromContextOrDefault(ctx).Debug("Ignoring channel for data type routing",
+				zap.String("channel", channel.destination()), zap.Error(err))
+			continue
+		}
+		if routes == nil {
+			routes = &dataTypeRoutes{indexes: make(map[string]int)}
+		}
+		for _, dataType := range channel.DataTypes {
+			routes.indexes[dataType] = len(routes.sessions)
+		}
+		routes.sessions = append(routes.sessions, name.String())
+	}
+	return routes
+}
+
+// partitionMetricsByType distributes the metrics of md among n batches
+// according to the batch index returned for each metric. The resources and
+// scopes are copied in every batch that holds some of their metrics.
+func partitionMetricsByType(md pmetric.Metrics, n int, index func(pmetric.Metric) int) []pmetric.Metrics {
+	parts := make([]pmetric.Metrics, n)
+	for i := range parts {
+		parts[i] = pmetric.NewMetrics()
+	}
+
+	rms := md.ResourceMetrics()
+	for i := range rms.Len() {
+		rm := rms.At(i)
+		resources := make(map[int]pmetric.ResourceMetrics)
+		sms := rm.ScopeMetrics()
+		for j := range sms.Len() {
+			sm := sms.At(j)
+			scopes := make(map[int]pmetric.ScopeMetrics)
+			metrics := sm.Metrics()
+			for k := range metrics.Len() {
+				metric := metrics.At(k)
+				p := index(metric)
+				scope, ok := scopes[p]
+				if !ok {
+					resource, ok := resources[p]
+					if !ok {
+						resource = parts[p].ResourceMetrics().AppendEmpty()
+						rm.Resource().CopyTo(resource.Resource())
+						resource.SetSchemaUrl(rm.SchemaUrl())
+						resources[p] = resource
+					}
+					scope = resource.ScopeMetrics().AppendEmpty()
+					sm.Scope().CopyTo(scope.Scope())
+					scope.SetSchemaUrl(sm.SchemaUrl())
+					scopes[p] = scope
+				}
+				metric.CopyTo(scope.Metrics().AppendEmpty())
+			}
+		}
+	}
+	return parts
+}
@@ -0,0 +1,205 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// newTypedMetrics returns metrics with one metric of each given type
+func newTypedMetrics(types ...pmetric.MetricType) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "svc")
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("scope")
+	for _, metricType := range types {
+		metric := sm.Metrics().AppendEmpty()
+		metric.SetName(metricDataType(metricType))
+		switch metricType {
+		case pmetric.MetricTypeGauge:
+			metric.SetEmptyGauge().DataPoints().AppendEmpty().SetDoubleValue(1)
+		case pmetric.MetricTypeSum:
+			metric.SetEmptySum().DataPoints().AppendEmpty().SetIntValue(1)
+		case pmetric.MetricTypeHistogram:
+			metric.SetEmptyHistogram().DataPoints().AppendEmpty().SetCount(1)
+		case pmetric.MetricTypeExponentialHistogram:
+			metric.SetEmptyExponentialHistogram().DataPoints().AppendEmpty().SetCount(1)
+		case pmetric.MetricTypeSummary:
+			metric.SetEmptySummary().DataPoints().AppendEmpty().SetCount(1)
+		}
+	}
+	return md
+}
+
+// publishedMetricNames returns the names of the metrics published on session
+func publishedMetricNames(t *testing.T, session *testutil.FakeSession) []string {
+	unmarshaler := pmetric.ProtoUnmarshaler{}
+	var names []string
+	for _, msg := range session.PublishedMessages() {
+		md, err := unmarshaler.UnmarshalMetrics(msg.Payload)
+		require.NoError(t, err)
+		metrics := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+		for i := range metrics.Len() {
+			names = append(names, metrics.At(i).Name())
+		}
+	}
+	return names
+}
+
+func TestPartitionMetricsByType(t *testing.T) {
+	md := newTypedMetrics(pmetric.MetricTypeSum, pmetric.MetricTypeHistogram, pmetric.MetricTypeGauge)
+
+	parts := partitionMetricsByType(md, 2, func(metric pmetric.Metric) int {
+		if metric.Type() == pmetric.MetricTypeHistogram {
+			return 0
+		}
+		return 1
+	})
+	require.Len(t, parts, 2)
+
+	for p, names := range [][]string{{"histogram"}, {"sum", "gauge"}} {
+		resource := parts[p].ResourceMetrics().At(0)
+		name, _ := resource.Resource().Attributes().Get("service.name")
+		assert.Equal(t, "svc", name.Str())
+		scope := resource.ScopeMetrics().At(0)
+		assert.Equal(t, "scope", scope.Scope().Name())
+		require.Equal(t, len(names), scope.Metrics().Len())
+		for i, want := range names {
+			assert.Equal(t, want, scope.Metrics().At(i).Name())
+		}
+	}
+}
+
+func TestDataTypeRoutes(t *testing.T) {
+	exporter := &slimExporter{
+		config: &Config{Channels: []ChannelsConfig{
+			{ChannelName: "agntcy/otel/metrics", Signal: "metrics"},
+			{ChannelName: "agntcy/otel/histograms", Signal: "metrics", DataTypes: []string{"histogram", "summary"}},
+			{ChannelName: "agntcy/otel/server-spans", Signal: "traces", DataTypes: []string{"server"}},
+		}},
+		signalType: slimconfig.SignalMetrics,
+	}
+
+	routes := exporter.dataTypeRoutes(t.Context())
+	require.NotNil(t, routes)
+	assert.Len(t, routes.sessions, 1)
+	assert.Equal(t, 0, routes.index("histogram"))
+	assert.Equal(t, 0, routes.index("summary"))
+	assert.Equal(t, 1, routes.index("sum"), "data types that are not routed")
+
+	exporter.signalType = slimconfig.SignalLogs
+	assert.Nil(t, exporter.dataTypeRoutes(t.Context()), "no logs channel is dedicated to data types")
+}
+
+func TestPushMetrics_DataTypes(t *testing.T) {
+	histograms := testutil.NewFakeSession(1, "agntcy/otel/histograms")
+	metrics := testutil.NewFakeSession(2, "agntcy/otel/metrics")
+	invited := testutil.NewFakeSession(3, "agntcy/otel/invited")
+
+	exporter := &slimExporter{
+		config: &Config{
+			Channels: []ChannelsConfig{
+				{
+					ChannelName: "agntcy/otel/histograms",
+					Signal:      "metrics",
+					DataTypes:   []string{"histogram", "exponential-histogram"},
+				},
+				{ChannelName: "agntcy/otel/metrics", Signal: "metrics"},
+			},
+		},
+		signalType: slimconfig.SignalMetrics,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalMetrics),
+	}
+	for _, session := range []*testutil.FakeSession{histograms, metrics, invited} {
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+	}
+
+	md := newTypedMetrics(
+		pmetric.MetricTypeSum,
+		pmetric.MetricTypeHistogram,
+		pmetric.MetricTypeGauge,
+		pmetric.MetricTypeExponentialHistogram,
+	)
+	require.NoError(t, exporter.pushMetrics(t.Context(), md))
+
+	assert.Equal(t, []string{"histogram", "exponential-histogram"}, publishedMetricNames(t, histograms))
+	assert.Equal(t, []string{"sum", "gauge"}, publishedMetricNames(t, metrics))
+	assert.Equal(t, []string{"sum", "gauge"}, publishedMetricNames(t, invited),
+		"the sessions outside the configured channels get the data types that are not routed")
+}
+
+func TestPushTraces_DataTypesWithChannelAffinity(t *testing.T) {
+	server := testutil.NewFakeSession(1, "agntcy/otel/server-spans")
+	traces1 := testutil.NewFakeSession(2, "agntcy/otel/traces-1")
+	traces2 := testutil.NewFakeSession(3, "agntcy/otel/traces-2")
+
+	exporter := &slimExporter{
+		config: &Config{
+			ChannelAffinity: true,
+			Channels: []ChannelsConfig{
+				{ChannelName: "agntcy/otel/traces-1", Signal: "traces"},
+				{ChannelName: "agntcy/otel/server-spans", Signal: "traces", DataTypes: []string{"server"}},
+				{ChannelName: "agntcy/otel/traces-2", Signal: "traces"},
+			},
+		},
+		signalType: slimconfig.SignalTraces,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+	}
+	for _, session := range []*testutil.FakeSession{server, traces1, traces2} {
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+	}
+
+	td := ptrace.NewTraces()
+	spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	for i, id := range traceIDs(16) {
+		span := spans.AppendEmpty()
+		span.SetTraceID(id)
+		if i%2 == 0 {
+			span.SetKind(ptrace.SpanKindServer)
+		} else {
+			span.SetKind(ptrace.SpanKindClient)
+		}
+	}
+	require.NoError(t, exporter.pushTraces(t.Context(), td))
+
+	unmarshaler := ptrace.ProtoUnmarshaler{}
+	received := func(session *testutil.FakeSession) []ptrace.Span {
+		var spans []ptrace.Span
+		for _, msg := range session.PublishedMessages() {
+			td, err := unmarshaler.UnmarshalTraces(msg.Payload)
+			require.NoError(t, err)
+			published := td.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+			for k := range published.Len() {
+				spans = append(spans, published.At(k))
+			}
+		}
+		return spans
+	}
+
+	serverSpans := received(server)
+	assert.Len(t, serverSpans, 8)
+	for _, span := range serverSpans {
+		assert.Equal(t, ptrace.SpanKindServer, span.Kind())
+	}
+
+	total := 0
+	for p, session := range []*testutil.FakeSession{traces1, traces2} {
+		for _, span := range received(session) {
+			assert.Equal(t, ptrace.SpanKindClient, span.Kind())
+			assert.Equal(t, p, affinityIndex(span.TraceID(), 2))
+			total++
+		}
+	}
+	assert.Equal(t, 8, total, "the other spans are spread among the other channels")
+}
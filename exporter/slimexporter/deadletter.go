@@ -0,0 +1,135 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// deadLetterExt is the extension of the files written to the dead-letter directory
+const deadLetterExt = ".json"
+
+// errDeadLetterFull is returned when writing a payload would exceed the
+// dead-letter max-bytes
+var errDeadLetterFull = errors.New("dead-letter directory is full")
+
+// deadLetterEntry is the content of a dead-letter file. The payload is the
+// OTLP message as it would have been published, in the configured encoding,
+// so that it can be replayed as is to the sessions it could not be published to.
+type deadLetterEntry struct {
+	Signal   string    `json:"signal"`
+	Time     time.Time `json:"time"`
+	Reason   string    `json:"reason"`
+	Sessions []string  `json:"sessions"`
+	Payload  []byte    `json:"payload"`
+}
+
+// deadLetterSpool writes the undeliverable payloads to a local directory,
+// one file per payload
+type deadLetterSpool struct {
+	directory string
+	maxBytes  int64
+
+	mutex sync.Mutex
+	// total size of the files in the directory
+	size int64
+	// sequence number making the file names unique
+	seq uint64
+}
+
+// newDeadLetterSpool creates the dead-letter directory if needed and
+// accounts for the files already present
+func newDeadLetterSpool(cfg DeadLetterConfig) (*deadLetterSpool, error) {
+	if err := os.MkdirAll(cfg.Directory, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create the dead-letter directory: %w", err)
+	}
+
+	spool := &deadLetterSpool{directory: cfg.Directory, maxBytes: cfg.MaxBytes}
+	err := filepath.WalkDir(cfg.Directory, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), deadLetterExt) {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		spool.size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the dead-letter directory: %w", err)
+	}
+	return spool, nil
+}
+
+// write saves the entry to a new file. The file is written under a temporary
+// name and renamed, so that a partially written file is never replayed.
+func (s *deadLetterSpool) write(entry deadLetterEntry) (string, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.maxBytes > 0 && s.size+int64(len(data)) > s.maxBytes {
+		return "", errDeadLetterFull
+	}
+
+	s.seq++
+	name := fmt.Sprintf("%s-%d-%d%s", entry.Signal, entry.Time.UnixNano(), s.seq, deadLetterExt)
+	path := filepath.Join(s.directory, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+
+	s.size += int64(len(data))
+	return path, nil
+}
+
+// writeDeadLetter saves a payload that could not be published to the given
+// sessions. It returns nil once the payload is saved, so that the pipeline
+// does not retry it.
+func (e *slimExporter) writeDeadLetter(ctx context.Context, data []byte, sessions []string, reason string) error {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	entry := deadLetterEntry{
+		Signal:   string(e.signalType),
+		Time:     time.Now().UTC(),
+		Reason:   reason,
+		Sessions: sessions,
+		Payload:  data,
+	}
+	path, err := e.deadLetter.write(entry)
+	if err != nil {
+		return fmt.Errorf("failed to write the payload to the dead-letter directory (%s): %w", reason, err)
+	}
+
+	e.telemetry.recordDeadLetter(ctx, len(data))
+	logger.Warn("Payload could not be published, saved to the dead-letter directory",
+		zap.String("signal", string(e.signalType)),
+		zap.String("reason", reason),
+		zap.String("file", path),
+		zap.Int("size", len(data)))
+	return nil
+}
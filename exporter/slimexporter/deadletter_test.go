@@ -0,0 +1,141 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// readDeadLetters returns the entries written to the dead-letter directory
+func readDeadLetters(t *testing.T, dir string) []deadLetterEntry {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+deadLetterExt))
+	require.NoError(t, err)
+
+	entries := make([]deadLetterEntry, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		var entry deadLetterEntry
+		require.NoError(t, json.Unmarshal(data, &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestDeadLetterSpool(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dead-letter")
+
+	spool, err := newDeadLetterSpool(DeadLetterConfig{Directory: dir})
+	require.NoError(t, err)
+	_, err = spool.write(deadLetterEntry{Signal: "traces", Payload: []byte("payload")})
+	require.NoError(t, err)
+	size := spool.size
+	assert.Positive(t, size)
+
+	// the files already in the directory count towards max-bytes
+	spool, err = newDeadLetterSpool(DeadLetterConfig{Directory: dir, MaxBytes: 2*size - 1})
+	require.NoError(t, err)
+	assert.Equal(t, size, spool.size)
+	_, err = spool.write(deadLetterEntry{Signal: "traces", Payload: []byte("payload")})
+	require.ErrorIs(t, err, errDeadLetterFull)
+
+	entries := readDeadLetters(t, dir)
+	require.Len(t, entries, 1)
+	assert.Equal(t, []byte("payload"), entries[0].Payload)
+}
+
+func TestPublishData_DeadLetter(t *testing.T) {
+	newExporter := func(t *testing.T) (*slimExporter, string) {
+		dir := t.TempDir()
+		spool, err := newDeadLetterSpool(DeadLetterConfig{Directory: dir})
+		require.NoError(t, err)
+		return &slimExporter{
+			config:     &Config{},
+			signalType: slimconfig.SignalTraces,
+			sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+			deadLetter: spool,
+		}, dir
+	}
+
+	t.Run("no session", func(t *testing.T) {
+		exporter, dir := newExporter(t)
+		require.NoError(t, exporter.publishData(t.Context(), nil, []byte("payload")))
+
+		entries := readDeadLetters(t, dir)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "traces", entries[0].Signal)
+		assert.Equal(t, "no open session to publish to", entries[0].Reason)
+		assert.Equal(t, []byte("payload"), entries[0].Payload)
+	})
+
+	t.Run("all sessions closed", func(t *testing.T) {
+		exporter, dir := newExporter(t)
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel")
+		session.Close()
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+
+		require.NoError(t, exporter.publishData(t.Context(), nil, []byte("payload")))
+		assert.Len(t, readDeadLetters(t, dir), 1)
+		assert.Empty(t, exporter.sessions.ListSessions(t.Context()))
+	})
+
+	t.Run("publish error", func(t *testing.T) {
+		exporter, dir := newExporter(t)
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel")
+		session.PublishErr = errors.New("publish failure")
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+
+		require.NoError(t, exporter.publishData(t.Context(), nil, []byte("payload")))
+		entries := readDeadLetters(t, dir)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "session agntcy/otel/channel: publish failure", entries[0].Reason)
+		assert.Equal(t, []string{"agntcy/otel/channel"}, entries[0].Sessions)
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		exporter, dir := newExporter(t)
+		healthy := testutil.NewFakeSession(1, "agntcy/otel/channel-healthy")
+		broken := testutil.NewFakeSession(2, "agntcy/otel/channel-broken")
+		broken.PublishErr = errors.New("publish failure")
+		closed := testutil.NewFakeSession(3, "agntcy/otel/channel-closed")
+		closed.Close()
+		for _, session := range []*testutil.FakeSession{healthy, broken, closed} {
+			require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+		}
+
+		require.NoError(t, exporter.publishData(t.Context(), nil, []byte("payload")))
+		assert.Len(t, healthy.PublishedMessages(), 1)
+
+		// only the failed session is spooled, so that a replay does not
+		// publish the payload twice to the healthy one
+		entries := readDeadLetters(t, dir)
+		require.Len(t, entries, 1)
+		assert.Equal(t, []string{"agntcy/otel/channel-broken"}, entries[0].Sessions)
+
+		// the closed session is removed despite the failure
+		assert.ElementsMatch(t, []string{"agntcy/otel/channel-healthy", "agntcy/otel/channel-broken"},
+			exporter.sessions.ListSessionNames(t.Context()))
+	})
+
+	t.Run("published", func(t *testing.T) {
+		exporter, dir := newExporter(t)
+		require.NoError(t, exporter.sessions.AddSession(t.Context(),
+			testutil.NewFakeSession(1, "agntcy/otel/channel")))
+
+		require.NoError(t, exporter.publishData(t.Context(), nil, []byte("payload")))
+		assert.Empty(t, readDeadLetters(t, dir))
+	})
+}
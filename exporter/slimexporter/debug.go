@@ -0,0 +1,138 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// channelsDebugPath is the path of the channels health on the debug endpoint
+const channelsDebugPath = "/debug/channels"
+
+// debugServers are the debug endpoints by address, shared by the exporters
+// of the signals configured with the same debug-endpoint
+var (
+	debugServersMutex sync.Mutex
+	debugServers      = make(map[string]*debugServer)
+)
+
+// debugServer serves the health of the channels of the exporters registered
+// on its address
+type debugServer struct {
+	server *http.Server
+
+	mutex    sync.Mutex
+	trackers []*channelHealth
+}
+
+// channelsResponse is the response of the channels health path
+type channelsResponse struct {
+	Channels []ChannelHealth `json:"channels"`
+}
+
+// registerDebugEndpoint registers the health of the channels of an exporter
+// on the debug endpoint at address, started by the first exporter
+func registerDebugEndpoint(ctx context.Context, address string, health *channelHealth) error {
+	debugServersMutex.Lock()
+	defer debugServersMutex.Unlock()
+
+	if server, ok := debugServers[address]; ok {
+		server.add(health)
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on the debug endpoint %s: %w", address, err)
+	}
+
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	server := &debugServer{trackers: []*channelHealth{health}}
+	mux := http.NewServeMux()
+	mux.HandleFunc(channelsDebugPath, server.handleChannels)
+	server.server = &http.Server{Handler: mux, ReadHeaderTimeout: time.Second}
+	debugServers[address] = server
+
+	go func() {
+		if err := server.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Debug endpoint stopped", zap.Error(err))
+		}
+	}()
+
+	logger.Info("Debug endpoint started", zap.String("address", listener.Addr().String()))
+	return nil
+}
+
+// unregisterDebugEndpoint removes the health of the channels of an exporter
+// from the debug endpoint at address, stopped with the last exporter
+func unregisterDebugEndpoint(ctx context.Context, address string, health *channelHealth) {
+	debugServersMutex.Lock()
+	defer debugServersMutex.Unlock()
+
+	server, ok := debugServers[address]
+	if !ok || server.remove(health) > 0 {
+		return
+	}
+	delete(debugServers, address)
+	if err := server.server.Shutdown(ctx); err != nil {
+		slimcommon.LoggerFromContextOrDefault(ctx).Warn("Failed to stop the debug endpoint", zap.Error(err))
+	}
+}
+
+// add registers the health of the channels of an exporter
+func (s *debugServer) add(health *channelHealth) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.trackers = append(s.trackers, health)
+}
+
+// remove unregisters the health of the channels of an exporter and returns
+// the number of exporters left
+func (s *debugServer) remove(health *channelHealth) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.trackers = slices.DeleteFunc(s.trackers, func(tracker *channelHealth) bool {
+		return tracker == health
+	})
+	return len(s.trackers)
+}
+
+// handleChannels responds with the health of the channels of all the
+// exporters, sorted by signal and channel
+func (s *debugServer) handleChannels(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mutex.Lock()
+	trackers := slices.Clone(s.trackers)
+	s.mutex.Unlock()
+
+	response := channelsResponse{Channels: []ChannelHealth{}}
+	for _, tracker := range trackers {
+		response.Channels = append(response.Channels, tracker.snapshot(req.Context())...)
+	}
+	slices.SortFunc(response.Channels, func(a, b ChannelHealth) int {
+		return cmp.Or(cmp.Compare(a.Signal, b.Signal), cmp.Compare(a.Channel, b.Channel))
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slimcommon.LoggerFromContextOrDefault(req.Context()).Warn("Failed to write the channels health", zap.Error(err))
+	}
+}
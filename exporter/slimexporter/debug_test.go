@@ -0,0 +1,76 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// newTestHealth returns the health of a channel of the signal, failing if
+// failing is set
+func newTestHealth(t *testing.T, signal slimconfig.SignalType, channel string, failing bool) *channelHealth {
+	t.Helper()
+	sessions := slimcommon.NewSessionsList(signal)
+	health := newChannelHealth(t.Context(), signal, sessions, testutil.NewFakeConnector(), "", nil)
+	sessions.SetPublishObserver(health.observe)
+
+	session := testutil.NewFakeSession(1, channel)
+	if failing {
+		session.PublishErr = errors.New("boom")
+	}
+	require.NoError(t, sessions.AddSession(t.Context(), session))
+	_, _ = sessions.PublishToAll(t.Context(), []byte("data"))
+	return health
+}
+
+func TestDebugServer_HandleChannels(t *testing.T) {
+	server := &debugServer{trackers: []*channelHealth{
+		newTestHealth(t, slimconfig.SignalTraces, "agntcy/otel/channel-traces", true),
+		newTestHealth(t, slimconfig.SignalLogs, "agntcy/otel/channel-logs", false),
+	}}
+
+	rec := httptest.NewRecorder()
+	server.handleChannels(rec, httptest.NewRequest(http.MethodGet, channelsDebugPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var response channelsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Channels, 2)
+	assert.Equal(t, slimconfig.SignalLogs, response.Channels[0].Signal, "sorted by signal")
+	assert.Equal(t, ChannelHealthy, response.Channels[0].State)
+	assert.Equal(t, "agntcy/otel/channel-traces", response.Channels[1].Channel)
+	assert.Equal(t, ChannelDegraded, response.Channels[1].State)
+	assert.Equal(t, "boom", response.Channels[1].LastError)
+
+	rec = httptest.NewRecorder()
+	server.handleChannels(rec, httptest.NewRequest(http.MethodPost, channelsDebugPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestRegisterDebugEndpoint(t *testing.T) {
+	const address = "127.0.0.1:0"
+	traces := newTestHealth(t, slimconfig.SignalTraces, "agntcy/otel/channel-traces", false)
+	logs := newTestHealth(t, slimconfig.SignalLogs, "agntcy/otel/channel-logs", false)
+
+	require.NoError(t, registerDebugEndpoint(t.Context(), address, traces))
+	require.NoError(t, registerDebugEndpoint(t.Context(), address, logs), "the endpoint is shared")
+	assert.Len(t, debugServers[address].trackers, 2)
+
+	unregisterDebugEndpoint(t.Context(), address, traces)
+	assert.Contains(t, debugServers, address, "still used by the logs exporter")
+	unregisterDebugEndpoint(t.Context(), address, logs)
+	assert.NotContains(t, debugServers, address, "stopped with the last exporter")
+}
@@ -0,0 +1,57 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// handleDrainNotification removes the session from the publish rotation if
+// the drain notification reports that it is about to close, so that the
+// exporter stops publishing to a receiver that is going away. The session
+// closes when the drained participant closes it, or when it is the
+// destination of the point-to-point session. The session is read until it is
+// actually closed.
+func (e *slimExporter) handleDrainNotification(
+	ctx context.Context, sessionID uint32, session slimcommon.Session, msg slim.ReceivedMessage,
+) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	participant := msg.Context.Metadata[slimcommon.MetadataDrainParticipant]
+
+	if !sessionClosing(session, participant, msg.Context.Metadata) {
+		logger.Debug("Participant drained from the session",
+			zap.Uint32("session_id", sessionID),
+			zap.String("participant", participant))
+		return
+	}
+
+	if _, err := e.sessions.RemoveSessionByID(ctx, sessionID); err != nil {
+		// already removed, e.g. by a previous notification
+		return
+	}
+	e.telemetry.recordDrainedSession(ctx)
+	logger.Info("Removing draining session from the publish rotation",
+		zap.String("signal", string(e.signalType)),
+		zap.Uint32("session_id", sessionID),
+		zap.String("participant", participant))
+}
+
+// sessionClosing reports whether the session closes once participant is
+// drained
+func sessionClosing(session slimcommon.Session, participant string, metadata map[string]string) bool {
+	if metadata[slimcommon.MetadataDrainClosing] == "true" {
+		return true
+	}
+	config, err := session.SessionConfig()
+	if err != nil || config.SessionType != slim.SessionTypePointToPoint {
+		return false
+	}
+	destination, err := session.Destination()
+	return err == nil && slimcommon.JoinID(destination) == participant
+}
@@ -0,0 +1,121 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func drainNotification(participant string, closing bool) slim.ReceivedMessage {
+	msg := slim.ReceivedMessage{}
+	msg.Context.PayloadType = slimcommon.PayloadTypeDrain
+	msg.Context.Metadata = map[string]string{slimcommon.MetadataDrainParticipant: participant}
+	if closing {
+		msg.Context.Metadata[slimcommon.MetadataDrainClosing] = "true"
+	}
+	return msg
+}
+
+func TestHandleDrainNotification(t *testing.T) {
+	const receiver = "agntcy/otel/receiver"
+
+	pointToPoint := func(destination string) *testutil.FakeSession {
+		session := testutil.NewFakeSession(1, destination)
+		session.Config.SessionType = slim.SessionTypePointToPoint
+		return session
+	}
+
+	tests := []struct {
+		name        string
+		disabled    bool
+		session     *testutil.FakeSession
+		msg         slim.ReceivedMessage
+		wantRemoved bool
+	}{
+		{
+			name:        "closing session",
+			session:     testutil.NewFakeSession(1, "agntcy/otel/channel"),
+			msg:         drainNotification(receiver, true),
+			wantRemoved: true,
+		},
+		{
+			name:        "point-to-point session with the drained participant",
+			session:     pointToPoint(receiver),
+			msg:         drainNotification(receiver, false),
+			wantRemoved: true,
+		},
+		{
+			name:    "point-to-point session with another participant",
+			session: pointToPoint("agntcy/otel/other-receiver"),
+			msg:     drainNotification(receiver, false),
+		},
+		{
+			name:    "channel kept after the participant is removed",
+			session: testutil.NewFakeSession(1, "agntcy/otel/channel"),
+			msg:     drainNotification(receiver, false),
+		},
+		{
+			name:     "disabled",
+			disabled: true,
+			session:  testutil.NewFakeSession(1, "agntcy/otel/channel"),
+			msg:      drainNotification(receiver, true),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := &slimExporter{
+				config:     &Config{DrainNotifications: !tt.disabled},
+				signalType: slimconfig.SignalTraces,
+				sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+			}
+			require.NoError(t, exporter.sessions.AddSession(t.Context(), tt.session))
+
+			tt.session.DeliverMessage(tt.msg)
+			tt.session.Close()
+			// returns once the delivered message is handled and the session closed
+			exporter.readMessages(t.Context(), tt.session)
+
+			names := exporter.sessions.ListSessionNames(t.Context())
+			if tt.wantRemoved {
+				assert.Empty(t, names)
+			} else {
+				assert.Len(t, names, 1)
+			}
+		})
+	}
+}
+
+func TestPublishData_DrainedSession(t *testing.T) {
+	exporter := &slimExporter{
+		config:     &Config{DrainNotifications: true},
+		signalType: slimconfig.SignalTraces,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+	}
+	drained := testutil.NewFakeSession(1, "agntcy/otel/channel-1")
+	other := testutil.NewFakeSession(2, "agntcy/otel/channel-2")
+	for _, session := range []*testutil.FakeSession{drained, other} {
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+		go exporter.readMessages(t.Context(), session)
+		t.Cleanup(session.Close)
+	}
+
+	drained.DeliverMessage(drainNotification("agntcy/otel/receiver", true))
+	require.Eventually(t, func() bool {
+		return len(exporter.sessions.ListSessionNames(t.Context())) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, exporter.publishData(t.Context(), nil, []byte("payload")))
+	assert.Empty(t, drained.Published(), "no data published on the draining session")
+	assert.Len(t, other.Published(), 1)
+}
@@ -6,21 +6,25 @@ package slimexporter
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.uber.org/zap"
 
 	slim "github.com/agntcy/slim-bindings-go"
+	"github.com/agntcy/slim-otel/extension/slimconnection"
 	slimcommon "github.com/agntcy/slim-otel/internal/slim"
 	"github.com/agntcy/slim-otel/slimconfig"
 )
 
 const (
-	sessionTimeoutMs  = 1000
 	defaultMaxRetries = 10
 	defaultIntervalMs = 1000
 )
@@ -29,10 +33,39 @@ const (
 type slimExporter struct {
 	config     *Config
 	signalType slimconfig.SignalType
-	app        *slim.App
+	app        slimcommon.App
 	connID     uint64
 	sessions   *slimcommon.SessionsList
+	telemetry  *exporterTelemetry
+	summary    *publishSummary
 	cancelFunc context.CancelFunc
+	// set once the channels are ready to receive data, see waitForReady
+	ready atomic.Bool
+
+	// time of the next publication allowed by the channel rate limit
+	rateMutex   sync.Mutex
+	nextPublish time.Time
+
+	// pending acknowledgements, nil if acknowledgements are disabled
+	acks *ackTracker
+	// spool of the payloads that could not be published, nil if disabled
+	deadLetter *deadLetterSpool
+
+	// hooks called before publishing, see WithPublishHook
+	hooks []PublishHook
+
+	// ID of the exporter component, tagged on the messages with tag-component
+	componentID component.ID
+
+	// health of the channels, nil in the tests
+	health *channelHealth
+
+	// names of the sessions created for the configured channels, which are
+	// never reaped when idle
+	owned []string
+
+	// pushes in progress, drained on shutdown
+	pushes pushTracker
 }
 
 // createApp creates a new slim application and connects to the SLIM server
@@ -41,24 +74,27 @@ func CreateApp(
 	ctx context.Context,
 	cfg *Config,
 	signalType slimconfig.SignalType,
-) (*slim.App, uint64, error) {
+) (slimcommon.App, uint64, error) {
 	logger := slimcommon.LoggerFromContextOrDefault(ctx)
-	connID, err := slimcommon.InitAndConnect(*cfg.ConnectionConfig)
+	connID, err := slimcommon.NewConnector().Connect(*cfg.ConnectionConfig)
 	if err != nil {
 		return nil, 0, err
 	}
 
+	tlsConfig := cfg.ConnectionConfig.TLS
 	logger.Info("connected to SLIM server",
-		zap.String("endpoint", cfg.ConnectionConfig.Address),
+		slimcommon.EndpointField(cfg.ConnectionConfig.Address),
 		zap.Uint64("connection_id", connID),
+		zap.Bool("tls", tlsConfig != nil && !tlsConfig.Insecure),
+		zap.Bool("mtls", tlsConfig != nil && tlsConfig.Source != nil),
 	)
 
-	exporterName, err := cfg.ExporterNames.GetNameForSignal(string(signalType))
+	exporterName, err := cfg.exporterName(signalType)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	app, err := slimcommon.CreateApp(exporterName, cfg.SharedSecret, connID, slim.DirectionSend)
+	app, err := slimcommon.CreateAppWithIdentity(exporterName, cfg.identity(), connID, cfg.direction())
 	if err != nil {
 		return nil, 0, err
 	}
@@ -69,6 +105,42 @@ func CreateApp(
 	return app, connID, nil
 }
 
+// createApp creates the app of the exporter, on the connection of the SLIM
+// connection extension if the config references one
+func (e *slimExporter) createApp(ctx context.Context, host component.Host) error {
+	if e.config.Connection == nil {
+		app, connID, err := CreateApp(ctx, e.config, e.signalType)
+		if err != nil {
+			return fmt.Errorf("failed to create/connect app: %w", err)
+		}
+		e.app, e.connID = app, connID
+		return nil
+	}
+
+	conn, err := slimconnection.GetConnection(host, *e.config.Connection)
+	if err != nil {
+		return err
+	}
+	exporterName, err := e.config.exporterName(e.signalType)
+	if err != nil {
+		return err
+	}
+	app, err := conn.CreateApp(exporterName, e.config.direction())
+	if err != nil {
+		return fmt.Errorf("failed to create app: %w", err)
+	}
+	e.app, e.connID = app, conn.ConnID()
+	// the health of the channels follows the connection of the extension,
+	// it is checked once the exporter is started
+	e.health.address = conn.Address()
+
+	slimcommon.LoggerFromContextOrDefault(ctx).Info("created SLIM app",
+		zap.String("app_name", exporterName),
+		zap.String("signal", string(e.signalType)),
+		zap.String("connection", e.config.Connection.String()))
+	return nil
+}
+
 // createSessionAndInvite creates a session for the given channel and signal,
 // and invites the participants specified in the config
 func createSessionsAndInvite(
@@ -83,120 +155,301 @@ func createSessionsAndInvite(
 			continue
 		}
 
-		channel := config.ChannelName
-		name, err := slimcommon.SplitID(channel)
-		if err != nil {
-			return fmt.Errorf("failed to parse channel name: %w", err)
-		}
-
 		// setup standard session config
 		interval := time.Millisecond * defaultIntervalMs
 		sessionConfig := slim.SessionConfig{
-			SessionType: slim.SessionTypeGroup,
+			SessionType: config.slimSessionType(),
 			EnableMls:   config.MlsEnabled,
 			MaxRetries:  &[]uint32{defaultMaxRetries}[0],
 			Interval:    &interval,
 			Metadata:    make(map[string]string),
 		}
 
-		session, err := e.app.CreateSessionAndWait(sessionConfig, name)
-		if err != nil {
-			return fmt.Errorf("failed to create the session: %w", err)
+		var session slimcommon.Session
+		var err error
+		if sessionConfig.SessionType == slim.SessionTypePointToPoint {
+			session, err = createPointToPointSession(ctx, e, sessionConfig, config)
+		} else {
+			session, err = createGroupSession(ctx, e, sessionConfig, config)
 		}
-
-		logger.Info("Created session for channel",
-			zap.String("signal", string(e.signalType)),
-			zap.String("channel", channel))
-
-		for _, participant := range config.Participants {
-			participantName, parseErr := slimcommon.SplitID(participant)
-			if parseErr != nil {
-				return fmt.Errorf("failed to parse participant name %s for channel %s: %w", participant, channel, parseErr)
-			}
-			if routeErr := e.app.SetRoute(participantName, e.connID); routeErr != nil {
-				return fmt.Errorf("failed to set route for participant %s for channel %s: %w", participant, channel, routeErr)
-			}
-			if inviteErr := session.InviteAndWait(participantName); inviteErr != nil {
-				return fmt.Errorf("failed to invite participant %s for channel %s: %w", participant, channel, inviteErr)
-			}
+		if err != nil {
+			return err
 		}
 
 		// add session to the list
 		err = e.sessions.AddSession(ctx, session)
 		if err != nil {
-			return fmt.Errorf("failed to add session for channel %s: %w", channel, err)
+			return fmt.Errorf("failed to add session for channel %s: %w", config.ChannelName, err)
+		}
+		if config.TTL > 0 {
+			setSessionTTL(e.sessions, session, config.TTL)
 		}
 
 		logger.Info("Created session and invited participants",
 			zap.String("signal", string(e.signalType)),
-			zap.String("channel", channel),
+			zap.String("channel", config.ChannelName),
+			zap.String("session_type", sessionTypeName(sessionConfig.SessionType)),
 			zap.Strings("participants", config.Participants))
 	}
 
 	return nil
 }
 
+// setSessionTTL tells the receivers of the session to drop the messages
+// published on it once they are older than ttl
+func setSessionTTL(sessions *slimcommon.SessionsList, session slimcommon.Session, ttl time.Duration) {
+	destination, err := session.Destination()
+	if err != nil {
+		return
+	}
+	metadata := make(map[string]string)
+	slimcommon.AddTTL(metadata, ttl)
+	sessions.SetSessionMetadata(destination.String(), metadata)
+}
+
+// createGroupSession creates a group session on the channel and invites
+// all the participants
+func createGroupSession(
+	ctx context.Context,
+	e *slimExporter,
+	sessionConfig slim.SessionConfig,
+	config ChannelsConfig,
+) (slimcommon.Session, error) {
+	channel := config.ChannelName
+	name, err := slimcommon.SplitID(channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse channel name: %w", err)
+	}
+	// the name is validated with the config
+	if localName, nameErr := e.config.exporterName(e.signalType); nameErr == nil {
+		sessionConfig.Metadata[slimcommon.MetadataInitiator] = localName
+	}
+
+	session, err := e.app.CreateSessionAndWait(sessionConfig, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the session: %w", err)
+	}
+
+	slimcommon.LoggerFromContextOrDefault(ctx).Info("Created session for channel",
+		zap.String("signal", string(e.signalType)),
+		zap.String("channel", channel))
+
+	for _, participant := range config.Participants {
+		participantName, parseErr := slimcommon.SplitID(participant)
+		if parseErr != nil {
+			return nil, fmt.Errorf("failed to parse participant name %s for channel %s: %w", participant, channel, parseErr)
+		}
+		if routeErr := e.app.SetRoute(participantName, e.connID); routeErr != nil {
+			return nil, fmt.Errorf("failed to set route for participant %s for channel %s: %w", participant, channel, routeErr)
+		}
+		if inviteErr := session.InviteAndWait(participantName); inviteErr != nil {
+			return nil, fmt.Errorf("failed to invite participant %s for channel %s: %w", participant, channel, inviteErr)
+		}
+	}
+
+	return session, nil
+}
+
+// createPointToPointSession creates a session directly towards the single
+// participant of the channel, without any group membership
+func createPointToPointSession(
+	ctx context.Context,
+	e *slimExporter,
+	sessionConfig slim.SessionConfig,
+	config ChannelsConfig,
+) (slimcommon.Session, error) {
+	participant := config.Participants[0]
+	participantName, err := slimcommon.SplitID(participant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse participant name %s: %w", participant, err)
+	}
+	if err := e.app.SetRoute(participantName, e.connID); err != nil {
+		return nil, fmt.Errorf("failed to set route for participant %s: %w", participant, err)
+	}
+
+	session, err := e.app.CreateSessionAndWait(sessionConfig, participantName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the point-to-point session with %s: %w", participant, err)
+	}
+
+	slimcommon.LoggerFromContextOrDefault(ctx).Info("Created point-to-point session",
+		zap.String("signal", string(e.signalType)),
+		zap.String("participant", participant))
+
+	return session, nil
+}
+
+// sessionTypeName returns the configuration name of a SLIM session type
+func sessionTypeName(sessionType slim.SessionType) string {
+	if sessionType == slim.SessionTypePointToPoint {
+		return SessionTypePointToPoint
+	}
+	return SessionTypeGroup
+}
+
 // listenForSessions listens for all incoming sessions
 func listenForSessions(ctx context.Context, e *slimExporter) {
 	logger := slimcommon.LoggerFromContextOrDefault(ctx)
 	logger.Info("Listener started, waiting for incoming sessions...")
 
-	for {
-		select {
-		case <-ctx.Done():
-			logger.Info("Shutting down listener...")
-			return
+	for session := range slimcommon.ListenSessions(ctx, e.app) {
+		logger.Info("New session received",
+			zap.String("signal", string(e.signalType)))
 
-		default:
-			timeout := time.Millisecond * sessionTimeoutMs
-			session, err := e.app.ListenForSession(&timeout)
-			if err != nil {
-				// no error, this is just the timeout
-				continue
+		if err := acceptSession(e, session); err != nil {
+			logger.Warn("Rejecting session", zap.String("signal", string(e.signalType)), zap.Error(err))
+			if err := e.app.DeleteSessionAndWait(session); err != nil {
+				logger.Warn("Failed to delete rejected session", zap.Error(err))
 			}
+			continue
+		}
 
-			logger.Info("New session received",
-				zap.String("signal", string(e.signalType)))
+		// add session to the list
+		if err := e.sessions.AddSession(ctx, session); err != nil {
+			logger.Error("Failed to add session", zap.String("signal", string(e.signalType)), zap.Error(err))
+			continue
+		}
 
-			// add session to the list
-			err = e.sessions.AddSession(ctx, session)
-			if err != nil {
-				logger.Error("Failed to add session", zap.String("signal", string(e.signalType)), zap.Error(err))
-				continue
-			}
+		if e.readsMessages() {
+			go e.readMessages(ctx, session)
+		}
+	}
+	logger.Info("Shutting down listener...")
+}
+
+// acceptSession checks an incoming session against the signals advertised by
+// the channel, and the allowed channels and inviters. For point-to-point
+// sessions the inviter is the session destination, the peer authenticated
+// by SLIM. For group sessions it is the initiator advertised in the session
+// metadata, which the inviter sets itself: the bindings do not expose the
+// identity of the inviter, and the other participants of a channel did not
+// invite the exporter. The check of the group sessions is advisory.
+func acceptSession(e *slimExporter, session slimcommon.Session) error {
+	// an invalid policy is ignored like when publishing, see publishLimits
+	if policy, err := slimcommon.SessionPolicy(session); err == nil && !policy.CarriesSignal(e.signalType) {
+		return fmt.Errorf("the channel does not carry %s", e.signalType)
+	}
+
+	if len(e.config.AllowedChannels) == 0 && len(e.config.AllowedInviters) == 0 {
+		return nil
+	}
+
+	destination, err := session.Destination()
+	if err != nil {
+		return fmt.Errorf("failed to get session destination: %w", err)
+	}
+	channel := slimcommon.JoinID(destination)
+
+	if len(e.config.AllowedChannels) > 0 && !matchesAny(e.config.AllowedChannels, channel) {
+		return fmt.Errorf("channel %s is not allowed", channel)
+	}
+
+	if len(e.config.AllowedInviters) == 0 {
+		return nil
+	}
+	sessionConfig, err := session.SessionConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get session config: %w", err)
+	}
+	if sessionConfig.SessionType == slim.SessionTypePointToPoint {
+		if !matchesAny(e.config.AllowedInviters, channel) {
+			return fmt.Errorf("peer %s is not an allowed inviter", channel)
 		}
+		return nil
 	}
+
+	initiator, err := slimcommon.SessionInitiator(session)
+	if err != nil {
+		return fmt.Errorf("failed to get the initiator of channel %s: %w", channel, err)
+	}
+	if initiator == "" {
+		return fmt.Errorf("channel %s does not advertise its initiator", channel)
+	}
+	if !matchesAny(e.config.AllowedInviters, initiator) {
+		return fmt.Errorf("initiator %s of channel %s is not an allowed inviter", initiator, channel)
+	}
+	return nil
 }
 
 // newSlimExporter creates a new instance of the slim exporter
-func newSlimExporter(ctx context.Context, cfg *Config, signalType slimconfig.SignalType) (*slimExporter, error) {
-	app, connID, err := CreateApp(ctx, cfg, signalType)
+func newSlimExporter(
+	ctx context.Context,
+	set component.TelemetrySettings,
+	cfg *Config,
+	signalType slimconfig.SignalType,
+	hooks []PublishHook,
+	listeners []ChannelStateListener,
+) (*slimExporter, error) {
+	sessions := slimcommon.NewSessionsList(signalType)
+	telemetry, err := newExporterTelemetry(set, signalType, sessions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create/connect app: %w", err)
+		return nil, fmt.Errorf("failed to create exporter telemetry: %w", err)
 	}
 
 	slim := &slimExporter{
 		config:     cfg,
 		signalType: signalType,
-		app:        app,
-		connID:     connID,
-		sessions:   slimcommon.NewSessionsList(signalType),
+		sessions:   sessions,
+		telemetry:  telemetry,
+		hooks:      hooks,
+	}
+
+	if cfg.AckTimeout > 0 {
+		slim.acks = newAckTracker()
 	}
 
+	if cfg.DeadLetter.Directory != "" {
+		slim.deadLetter, err = newDeadLetterSpool(cfg.DeadLetter)
+		if err != nil {
+			_ = telemetry.shutdown()
+			return nil, err
+		}
+	}
+
+	sessions.SetPublishConcurrency(cfg.PublishConcurrency)
+	sessions.SetPublishTimeout(cfg.PublishTimeout)
+	sessions.SetMaxInFlightPerSession(cfg.MaxInFlightPerSession, func(_ string, wait time.Duration) {
+		telemetry.recordPublishWait(context.Background(), wait)
+	})
+	if cfg.MaxPublishFailures > 0 {
+		sessions.SetEvictionPolicy(cfg.MaxPublishFailures, slim.evictSession)
+	}
+
+	var address string
+	if cfg.ConnectionConfig != nil {
+		address = cfg.ConnectionConfig.Address
+	}
+	slim.health = newChannelHealth(ctx, signalType, sessions, slimcommon.NewConnector(), address, listeners)
+	observers := []slimcommon.PublishObserver{slim.health.observe}
+	if cfg.SummaryInterval > 0 {
+		slim.summary = newPublishSummary(signalType)
+		observers = append(observers, slim.summary.observe)
+	}
+	sessions.SetPublishObserver(func(sessionName string, size int, err error) {
+		for _, observe := range observers {
+			observe(sessionName, size, err)
+		}
+	})
+
 	return slim, nil
 }
 
 // start is invoked during service startup
-func (e *slimExporter) start(ctx context.Context, _ component.Host) error {
+func (e *slimExporter) start(ctx context.Context, host component.Host) error {
 	logger := slimcommon.LoggerFromContextOrDefault(ctx)
 	logger.Info("Starting Slim exporter",
 		zap.String("signal", string(e.signalType)))
 
+	if err := e.createApp(ctx, host); err != nil {
+		return err
+	}
+
 	// create all sessions defined in the config
 	err := createSessionsAndInvite(ctx, e)
 	if err != nil {
 		return err
 	}
+	e.owned = e.sessions.ListSessionNames(ctx)
 
 	// Create a background context for the listener goroutine
 	listenerCtx, cancel := context.WithCancel(context.Background())
@@ -204,85 +457,351 @@ func (e *slimExporter) start(ctx context.Context, _ component.Host) error {
 	listenerCtx = slimcommon.InitContextWithLogger(listenerCtx, logger)
 	e.cancelFunc = cancel
 
+	// read the messages received on the sessions created above
+	if e.readsMessages() {
+		for _, session := range e.sessions.ListSessions(ctx) {
+			go e.readMessages(listenerCtx, session)
+		}
+	}
+
 	// start to listen for incoming sessions
 	logger.Info("Start to listen for new sessions", zap.String("signal", string(e.signalType)))
 	go listenForSessions(listenerCtx, e)
 
+	// close the sessions nothing is published to or received from
+	if e.config.IdleTimeout > 0 {
+		go e.sessions.RunIdleReaper(listenerCtx, e.app, e.config.IdleTimeout, e.ownsSession,
+			func(string) { e.telemetry.recordIdleSession(listenerCtx) })
+	}
+
+	// periodically log the publish activity
+	if e.summary != nil {
+		go e.summary.run(listenerCtx, e.config.SummaryInterval)
+	}
+
+	// track the reconnections in the health of the channels
+	go e.health.run(listenerCtx, slimcommon.DefaultHealthCheckInterval)
+	if e.config.DebugEndpoint != "" {
+		if err := registerDebugEndpoint(ctx, e.config.DebugEndpoint, e.health); err != nil {
+			cancel()
+			return err
+		}
+	}
+
 	return nil
 }
 
+// ownsSession reports whether the session named name was created by the
+// exporter for one of its channels
+func (e *slimExporter) ownsSession(name string) bool {
+	return slices.Contains(e.owned, name)
+}
+
 // shutdown is invoked during service shutdown
 func (e *slimExporter) shutdown(ctx context.Context) error {
 	logger := slimcommon.LoggerFromContextOrDefault(ctx)
 	logger.Info("Shutting down Slim exporter", zap.String("signal", string(e.signalType)))
 
+	// let the pushes in progress complete before deleting the sessions
+	e.drainPushes(ctx)
+
 	// stop the receiver listener by canceling the background context
 	if e.cancelFunc != nil {
 		e.cancelFunc()
 	}
 
-	// remove all sessions
-	e.sessions.DeleteAll(ctx, e.app)
+	if e.config.DebugEndpoint != "" {
+		unregisterDebugEndpoint(ctx, e.config.DebugEndpoint, e.health)
+	}
+
+	// nothing to release if the exporter failed to create its app
+	if e.app != nil {
+		// remove all sessions
+		e.sessions.DeleteAll(ctx, e.app)
+
+		// destroy the app
+		e.app.Destroy()
+	}
 
-	// destroy the app
-	e.app.Destroy()
+	if err := e.telemetry.shutdown(); err != nil {
+		logger.Warn("Failed to unregister exporter telemetry", zap.Error(err))
+	}
 
 	return nil
 }
 
-// publishData sends data to all sessions and removes closed ones
-func (e *slimExporter) publishData(ctx context.Context, data []byte) error {
-	closedSessions, err := e.sessions.PublishToAll(ctx, data)
+// publishData sends data to the target sessions, or to all sessions if
+// targets is nil, and removes closed ones. When
+// acknowledgements are enabled, it returns once every session acknowledged data.
+// When the dead-letter spool is enabled, data that could not be published to
+// any session is saved to it.
+func (e *slimExporter) publishData(ctx context.Context, targets []string, data []byte) error {
+	return e.publishDataWithMetadata(ctx, targets, data, make(map[string]string))
+}
+
+// publishDataWithMetadata is publishData sending metadata with the data
+func (e *slimExporter) publishDataWithMetadata(
+	ctx context.Context,
+	targets []string,
+	data []byte,
+	metadata map[string]string,
+) error {
+	// the publication time lets the receivers measure the delivery latency
+	slimcommon.AddSentAt(metadata, time.Now())
+	// the signal lets the receivers report the data they have no consumer for
+	slimcommon.AddSignal(metadata, e.signalType)
+	if e.config.TagComponent {
+		slimcommon.AddComponent(metadata, e.componentID.String())
+	}
+
+	// the dead-letter spool keeps the raw payload, the envelope is only
+	// for the receivers
+	payload, err := e.wrapEnvelope(data, metadata)
 	if err != nil {
-		return err
+		return consumererror.NewPermanent(err)
+	}
+
+	var published, closedSessions []uint32
+	if e.acks != nil {
+		published, closedSessions, err = e.publishAndWaitAck(ctx, targets, payload, metadata)
+	} else {
+		published, closedSessions, err = e.sessions.PublishToSessions(ctx, targets, payload, metadata)
 	}
+	e.telemetry.recordClosedSessions(ctx, len(closedSessions))
 
-	// Remove closed sessions after iteration
+	// Remove closed sessions after iteration, even if the publication failed
+	// on other sessions
 	for _, id := range closedSessions {
 		slimcommon.LoggerFromContextOrDefault(ctx).Info("Removing closed session", zap.Uint32("session_id", id))
-		if _, err := e.sessions.RemoveSessionByID(ctx, id); err != nil {
-			return err
+		if _, removeErr := e.sessions.RemoveSessionByID(ctx, id); removeErr != nil {
+			return removeErr
+		}
+	}
+
+	if err != nil {
+		e.telemetry.recordPublishFailure(ctx)
+		if e.deadLetter != nil {
+			// only the sessions that failed are spooled, the payload
+			// published to the others is not replayed twice
+			failed := slimcommon.FailedSessions(err)
+			if len(failed) == 0 {
+				failed = e.sessions.ListSessionNames(ctx)
+			}
+			return e.writeDeadLetter(ctx, data, failed, err.Error())
 		}
+		return publishError(err)
+	}
+	e.telemetry.recordPublished(ctx, len(payload))
+
+	if len(published) == 0 && e.deadLetter != nil {
+		return e.writeDeadLetter(ctx, data, e.sessions.ListSessionNames(ctx), "no open session to publish to")
 	}
 
 	return nil
 }
 
+// wrapEnvelope wraps data in the versioned envelope if enabled, flagging it
+// in the metadata, and returns the payload to publish
+func (e *slimExporter) wrapEnvelope(data []byte, metadata map[string]string) ([]byte, error) {
+	if !e.config.Envelope.Enabled {
+		return data, nil
+	}
+	// the name is validated with the config
+	producer, _ := e.config.exporterName(e.signalType)
+	payload, err := slimcommon.MarshalEnvelope(slimcommon.Envelope{
+		Encoding:    e.config.Encoding,
+		Compression: e.config.Envelope.Compression,
+		Producer:    producer,
+		Payload:     data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap the payload in the envelope: %w", err)
+	}
+	slimcommon.AddEnvelope(metadata)
+	return payload, nil
+}
+
+// evictSession closes a session evicted from the sessions list after too
+// many consecutive publish failures, the list reports it as closed for
+// publishData to remove it
+func (e *slimExporter) evictSession(ctx context.Context, name string, session slimcommon.Session) {
+	e.telemetry.recordEvictedSession(ctx)
+	if err := e.app.DeleteSessionAndWait(session); err != nil {
+		slimcommon.LoggerFromContextOrDefault(ctx).Warn("Failed to delete evicted session",
+			zap.String("session_name", name), zap.Error(err))
+	}
+}
+
+// publishError marks the publish errors that retrying cannot fix, e.g.
+// authentication or MLS failures, as permanent so that the exporter helper
+// drops the data instead of retrying it
+func publishError(err error) error {
+	if slimcommon.IsPermanentError(err) {
+		return consumererror.NewPermanent(err)
+	}
+	return err
+}
+
+// publishMessage publishes a marshaled batch of count items to the target
+// sessions within the publish limits, warning when it still exceeds the max
+// message size because a single resource is too large
+func (e *slimExporter) publishMessage(
+	ctx context.Context,
+	targets []string,
+	message []byte,
+	count int,
+	limits slimcommon.ChannelPolicy,
+) error {
+	if limits.MaxMessageSize > 0 && len(message) > limits.MaxMessageSize {
+		slimcommon.LoggerFromContextOrDefault(ctx).Warn("Message exceeds max message size and cannot be split further",
+			zap.String("signal", string(e.signalType)),
+			zap.Int("size", len(message)),
+			zap.Int("max_message_bytes", limits.MaxMessageSize))
+	}
+
+	metadata, err := e.beforePublish(ctx, targets, message, count)
+	if err != nil {
+		return err
+	}
+
+	if err := e.waitForRate(ctx, limits.MaxMessageRate); err != nil {
+		return err
+	}
+
+	return e.publishDataWithMetadata(ctx, targets, message, metadata)
+}
+
+// skipEmptyBatch reports whether a batch of count items is not published
+// because it is empty, which the receivers would decode as any signal
+func (e *slimExporter) skipEmptyBatch(ctx context.Context, count int) bool {
+	if count > 0 || e.config.PublishEmptyBatches {
+		return false
+	}
+	e.telemetry.recordEmptyBatch(ctx)
+	slimcommon.LoggerFromContextOrDefault(ctx).Debug("Skipping empty batch",
+		zap.String("signal", string(e.signalType)))
+	return true
+}
+
 // pushTraces exports trace data
 func (e *slimExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
 	logger := slimcommon.LoggerFromContextOrDefault(ctx)
-	marshaler := ptrace.ProtoMarshaler{}
-	message, err := marshaler.MarshalTraces(td)
+	done, err := e.pushes.begin()
 	if err != nil {
-		logger.Error("Failed to marshal traces to OTLP format", zap.Error(err))
 		return err
 	}
+	defer done()
+
+	if e.skipEmptyBatch(ctx, td.SpanCount()) {
+		return nil
+	}
+	e.waitForReady(ctx)
 
-	return e.publishData(ctx, message)
+	var marshaler ptrace.Marshaler = &ptrace.ProtoMarshaler{}
+	split := splitTraces
+	if e.config.Encoding == slimcommon.EncodingJSON {
+		marshaler, split = &ptrace.JSONMarshaler{}, splitTracesJSON
+	}
+	limits := e.publishLimits(ctx)
+	for _, partition := range e.tracesPartitions(ctx, td) {
+		batches := split(partition.data, limits.MaxMessageSize)
+		if len(batches) > 1 {
+			e.telemetry.recordSplitBatch(ctx)
+		}
+		for _, batch := range batches {
+			message, err := marshaler.MarshalTraces(batch)
+			if err != nil {
+				logger.Error("Failed to marshal traces to OTLP format", zap.Error(err))
+				return consumererror.NewPermanent(err)
+			}
+
+			if err := e.publishMessage(ctx, partition.sessions, message, batch.SpanCount(), limits); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
 // pushMetrics exports metrics data
 func (e *slimExporter) pushMetrics(ctx context.Context, md pmetric.Metrics) error {
 	logger := slimcommon.LoggerFromContextOrDefault(ctx)
-	marshaler := pmetric.ProtoMarshaler{}
-	message, err := marshaler.MarshalMetrics(md)
+	done, err := e.pushes.begin()
 	if err != nil {
-		logger.Error("Failed to marshal metrics to OTLP format", zap.Error(err))
 		return err
 	}
+	defer done()
+
+	if e.skipEmptyBatch(ctx, md.DataPointCount()) {
+		return nil
+	}
+	e.waitForReady(ctx)
+
+	var marshaler pmetric.Marshaler = &pmetric.ProtoMarshaler{}
+	split := splitMetrics
+	if e.config.Encoding == slimcommon.EncodingJSON {
+		marshaler, split = &pmetric.JSONMarshaler{}, splitMetricsJSON
+	}
+	limits := e.publishLimits(ctx)
+	for _, partition := range e.metricsPartitions(ctx, md) {
+		batches := split(partition.data, limits.MaxMessageSize)
+		if len(batches) > 1 {
+			e.telemetry.recordSplitBatch(ctx)
+		}
+		for _, batch := range batches {
+			message, err := marshaler.MarshalMetrics(batch)
+			if err != nil {
+				logger.Error("Failed to marshal metrics to OTLP format", zap.Error(err))
+				return consumererror.NewPermanent(err)
+			}
+
+			if err := e.publishMessage(ctx, partition.sessions, message, batch.DataPointCount(), limits); err != nil {
+				return err
+			}
+		}
+	}
 
-	return e.publishData(ctx, message)
+	return nil
 }
 
 // pushLogs exports logs data
 func (e *slimExporter) pushLogs(ctx context.Context, ld plog.Logs) error {
 	logger := slimcommon.LoggerFromContextOrDefault(ctx)
-	marshaler := plog.ProtoMarshaler{}
-	message, err := marshaler.MarshalLogs(ld)
+	done, err := e.pushes.begin()
 	if err != nil {
-		logger.Error("Failed to marshal logs to OTLP format", zap.Error(err))
 		return err
 	}
+	defer done()
+
+	if e.skipEmptyBatch(ctx, ld.LogRecordCount()) {
+		return nil
+	}
+	e.waitForReady(ctx)
 
-	return e.publishData(ctx, message)
+	var marshaler plog.Marshaler = &plog.ProtoMarshaler{}
+	split := splitLogs
+	if e.config.Encoding == slimcommon.EncodingJSON {
+		marshaler, split = &plog.JSONMarshaler{}, splitLogsJSON
+	}
+	limits := e.publishLimits(ctx)
+	for _, partition := range e.logsPartitions(ctx, ld) {
+		batches := split(partition.data, limits.MaxMessageSize)
+		if len(batches) > 1 {
+			e.telemetry.recordSplitBatch(ctx)
+		}
+		for _, batch := range batches {
+			message, err := marshaler.MarshalLogs(batch)
+			if err != nil {
+				logger.Error("Failed to marshal logs to OTLP format", zap.Error(err))
+				return consumererror.NewPermanent(err)
+			}
+
+			if err := e.publishMessage(ctx, partition.sessions, message, batch.LogRecordCount(), limits); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
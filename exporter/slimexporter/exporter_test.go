@@ -4,13 +4,24 @@
 package slimexporter
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 
+	slim "github.com/agntcy/slim-bindings-go"
 	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
 	"github.com/agntcy/slim-otel/slimconfig"
 )
 
@@ -28,7 +39,7 @@ func TestSlimExporter_PublishData(t *testing.T) {
 		}
 
 		data := []byte("test trace data")
-		err := exporter.publishData(t.Context(), data)
+		err := exporter.publishData(t.Context(), nil, data)
 
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
@@ -46,13 +57,346 @@ func TestSlimExporter_PublishData(t *testing.T) {
 			sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
 		}
 
-		err := exporter.publishData(t.Context(), nil)
+		err := exporter.publishData(t.Context(), nil, nil)
 
 		// Should return error for nil data
 		if err == nil {
 			t.Error("expected error for nil data, got nil")
 		}
 	})
+
+	t.Run("permanent failures are not retried", func(t *testing.T) {
+		exporter := &slimExporter{
+			config:     &Config{},
+			signalType: slimconfig.SignalTraces,
+			sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+		}
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel-1")
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+
+		session.PublishErr = slim.NewSlimErrorAuthError("invalid token")
+		err := exporter.publishData(t.Context(), nil, []byte("data"))
+		require.Error(t, err)
+		assert.True(t, consumererror.IsPermanent(err))
+
+		session.PublishErr = slim.NewSlimErrorSendError("connection reset")
+		err = exporter.publishData(t.Context(), nil, []byte("data"))
+		require.Error(t, err)
+		assert.False(t, consumererror.IsPermanent(err))
+	})
+}
+
+// TestCreateSessionsAndInvite tests the creation of the configured sessions
+func TestCreateSessionsAndInvite(t *testing.T) {
+	newExporter := func(channels ...ChannelsConfig) (*slimExporter, *testutil.FakeApp) {
+		app := testutil.NewFakeApp()
+		return &slimExporter{
+			config:     &Config{Channels: channels},
+			signalType: slimconfig.SignalTraces,
+			app:        app,
+			sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+		}, app
+	}
+
+	t.Run("group session invites all participants", func(t *testing.T) {
+		exporter, app := newExporter(ChannelsConfig{
+			ChannelName:  "agntcy/otel/channel-traces",
+			Signal:       "traces",
+			Participants: []string{"agntcy/otel/receiver-1", "agntcy/otel/receiver-2"},
+			MlsEnabled:   true,
+		})
+
+		require.NoError(t, createSessionsAndInvite(t.Context(), exporter))
+
+		session := app.SessionByName("agntcy/otel/channel-traces")
+		require.NotNil(t, session)
+		assert.Equal(t, slim.SessionTypeGroup, session.Config.SessionType)
+		assert.True(t, session.Config.EnableMls)
+		assert.ElementsMatch(t, []string{"agntcy/otel/receiver-1", "agntcy/otel/receiver-2"}, session.Participants())
+		assert.Equal(t, []string{"agntcy/otel/channel-traces"}, exporter.sessions.ListSessionNames(t.Context()))
+	})
+
+	t.Run("point-to-point session targets the participant", func(t *testing.T) {
+		exporter, app := newExporter(ChannelsConfig{
+			Signal:       "traces",
+			Participants: []string{"agntcy/otel/receiver-1"},
+			SessionType:  SessionTypePointToPoint,
+		})
+
+		require.NoError(t, createSessionsAndInvite(t.Context(), exporter))
+
+		session := app.SessionByName("agntcy/otel/receiver-1")
+		require.NotNil(t, session)
+		assert.Equal(t, slim.SessionTypePointToPoint, session.Config.SessionType)
+		assert.Empty(t, session.Participants(), "no invite is needed for point-to-point sessions")
+		assert.Equal(t, []string{"agntcy/otel/receiver-1"}, app.Routes())
+		assert.Equal(t, []string{"agntcy/otel/receiver-1"}, exporter.sessions.ListSessionNames(t.Context()))
+	})
+
+	t.Run("ttl is sent with the messages of the channel", func(t *testing.T) {
+		exporter, app := newExporter(ChannelsConfig{
+			ChannelName:  "agntcy/otel/channel-traces",
+			Signal:       "traces",
+			Participants: []string{"agntcy/otel/receiver-1"},
+			TTL:          time.Minute,
+		})
+
+		require.NoError(t, createSessionsAndInvite(t.Context(), exporter))
+		require.NoError(t, exporter.publishData(t.Context(), nil, []byte("data")))
+
+		session := app.SessionByName("agntcy/otel/channel-traces")
+		require.NotNil(t, session)
+		published := session.PublishedMessages()
+		require.Len(t, published, 1)
+		ttl, ok := slimcommon.TTL(published[0].Context.Metadata)
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, ttl)
+	})
+
+	t.Run("channels of other signals are skipped", func(t *testing.T) {
+		exporter, app := newExporter(ChannelsConfig{
+			ChannelName:  "agntcy/otel/channel-logs",
+			Signal:       "logs",
+			Participants: []string{"agntcy/otel/receiver-1"},
+		})
+
+		require.NoError(t, createSessionsAndInvite(t.Context(), exporter))
+		assert.Empty(t, app.Sessions())
+	})
+}
+
+// TestListenForSessions_Allowlist tests that incoming sessions are filtered
+// by the allowed channels and inviters, and the signals of the channel
+func TestListenForSessions_Allowlist(t *testing.T) {
+	newSession := func(id uint32, channel, initiator string, participants ...string) *testutil.FakeSession {
+		session := testutil.NewFakeSession(id, channel)
+		session.Config.SessionType = slim.SessionTypeGroup
+		session.Config.Metadata = map[string]string{slimcommon.MetadataInitiator: initiator}
+		for _, p := range participants {
+			name, err := slimcommon.SplitID(p)
+			require.NoError(t, err)
+			require.NoError(t, session.InviteAndWait(name))
+		}
+		return session
+	}
+	newPeerSession := func(id uint32, peer string) *testutil.FakeSession {
+		session := testutil.NewFakeSession(id, peer)
+		session.Config.SessionType = slim.SessionTypePointToPoint
+		return session
+	}
+
+	app := testutil.NewFakeApp()
+	exporter := &slimExporter{
+		config: &Config{
+			AllowedChannels: []string{"agntcy/otel/*"},
+			AllowedInviters: []string{"agntcy/otel/channel-manager"},
+		},
+		signalType: slimconfig.SignalTraces,
+		app:        app,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+	}
+
+	app.Invite(newSession(1, "agntcy/otel/channel-1", "agntcy/otel/channel-manager", "agntcy/otel/receiver"))
+	app.Invite(newSession(2, "other/otel/channel-2", "agntcy/otel/channel-manager"))
+	app.Invite(newSession(3, "agntcy/otel/channel-3", "agntcy/otel/intruder"))
+	logsOnly := newSession(4, "agntcy/otel/channel-4", "agntcy/otel/channel-manager")
+	logsPolicy := slimcommon.ChannelPolicy{Signals: []slimconfig.SignalType{slimconfig.SignalLogs}}
+	logsPolicy.AddToMetadata(logsOnly.Config.Metadata)
+	app.Invite(logsOnly)
+	// an allowed participant of the channel does not make an untrusted
+	// initiator allowed
+	app.Invite(newSession(5, "agntcy/otel/channel-5", "agntcy/otel/intruder", "agntcy/otel/channel-manager"))
+	app.Invite(newSession(6, "agntcy/otel/channel-6", ""))
+	app.Invite(newPeerSession(7, "agntcy/otel/channel-manager"))
+	app.Invite(newPeerSession(8, "agntcy/otel/intruder"))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		listenForSessions(ctx, exporter)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(app.DeletedSessions()) == 6
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.ElementsMatch(t, []string{"agntcy/otel/channel-1", "agntcy/otel/channel-manager"},
+		exporter.sessions.ListSessionNames(t.Context()))
+	assert.ElementsMatch(t, []uint32{2, 3, 4, 5, 6, 8}, app.DeletedSessions())
+}
+
+// TestSlimExporter_IdleTimeout tests that the sessions the exporter was
+// invited to are closed when idle, unlike the channels it created
+func TestSlimExporter_IdleTimeout(t *testing.T) {
+	app := testutil.NewFakeApp()
+	exporter := &slimExporter{
+		config: &Config{
+			IdleTimeout: 100 * time.Millisecond,
+			Channels: []ChannelsConfig{{
+				ChannelName:  "agntcy/otel/channel-owned",
+				Signal:       "traces",
+				Participants: []string{"agntcy/otel/receiver-1"},
+			}},
+		},
+		signalType: slimconfig.SignalTraces,
+		app:        app,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+	}
+	require.NoError(t, createSessionsAndInvite(t.Context(), exporter))
+	exporter.owned = exporter.sessions.ListSessionNames(t.Context())
+
+	idle := testutil.NewFakeSession(100, "agntcy/otel/channel-idle")
+	active := testutil.NewFakeSession(101, "agntcy/otel/channel-active")
+	require.NoError(t, exporter.sessions.AddSession(t.Context(), idle))
+	require.NoError(t, exporter.sessions.AddSession(t.Context(), active))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go exporter.sessions.RunIdleReaper(ctx, app, exporter.config.IdleTimeout, exporter.ownsSession, nil)
+
+	// the messages published on the active session keep it open
+	require.Eventually(t, func() bool {
+		_, _, err := exporter.sessions.PublishToSessions(ctx, []string{"agntcy/otel/channel-active"}, []byte("data"), nil)
+		require.NoError(t, err)
+		return idle.Closed()
+	}, 5*time.Second, 20*time.Millisecond)
+
+	assert.False(t, active.Closed())
+	assert.False(t, app.SessionByName("agntcy/otel/channel-owned").Closed())
+	assert.ElementsMatch(t, []string{"agntcy/otel/channel-owned", "agntcy/otel/channel-active"},
+		exporter.sessions.ListSessionNames(t.Context()))
+}
+
+// fakeConnection is a SLIM connection extension creating fake apps
+type fakeConnection struct {
+	component.StartFunc
+	component.ShutdownFunc
+	app     *testutil.FakeApp
+	connID  uint64
+	address string
+	created []string
+}
+
+func (c *fakeConnection) ConnID() uint64 {
+	return c.connID
+}
+
+func (c *fakeConnection) Address() string {
+	return c.address
+}
+
+func (c *fakeConnection) CreateApp(localID string, _ slim.Direction) (slimcommon.App, error) {
+	c.created = append(c.created, localID)
+	return c.app, nil
+}
+
+// extensionsHost is a component.Host holding extensions
+type extensionsHost map[component.ID]component.Component
+
+func (h extensionsHost) GetExtensions() map[component.ID]component.Component {
+	return h
+}
+
+// TestSlimExporter_ConnectionExtension tests the exporter creating its app
+// on the connection of a SLIM connection extension
+func TestSlimExporter_ConnectionExtension(t *testing.T) {
+	id := component.MustNewIDWithName("slim", "shared")
+	conn := &fakeConnection{app: testutil.NewFakeApp(), connID: 7, address: "http://slim:46357"}
+	sessions := slimcommon.NewSessionsList(slimconfig.SignalTraces)
+	exporter := &slimExporter{
+		config: &Config{
+			Connection: &id,
+			ExporterNames: &slimconfig.SignalNames{
+				Metrics: strPtr("agntcy/otel/exporter-metrics"),
+				Traces:  strPtr("agntcy/otel/exporter-traces"),
+				Logs:    strPtr("agntcy/otel/exporter-logs"),
+			},
+		},
+		signalType: slimconfig.SignalTraces,
+		sessions:   sessions,
+		health: newChannelHealth(
+			t.Context(), slimconfig.SignalTraces, sessions, testutil.NewFakeConnector(), "", nil),
+	}
+
+	err := exporter.createApp(t.Context(), extensionsHost{})
+	require.ErrorContains(t, err, "extension slim/shared not found")
+
+	require.NoError(t, exporter.createApp(t.Context(), extensionsHost{id: conn}))
+	assert.Same(t, conn.app, exporter.app)
+	assert.Equal(t, uint64(7), exporter.connID)
+	assert.Equal(t, "http://slim:46357", exporter.health.address)
+	assert.Equal(t, []string{"agntcy/otel/exporter-traces"}, conn.created)
+}
+
+// TestSlimExporter_ConnectionPerExporter tests that exporters connected to
+// different endpoints in the same collector each hold their own connection
+// ID, used to route the participants of their channels
+func TestSlimExporter_ConnectionPerExporter(t *testing.T) {
+	newExporter := func(name string, conn *fakeConnection) (*slimExporter, extensionsHost) {
+		id := component.MustNewIDWithName("slim", name)
+		sessions := slimcommon.NewSessionsList(slimconfig.SignalTraces)
+		exporter := &slimExporter{
+			config: &Config{
+				Connection: &id,
+				ExporterNames: &slimconfig.SignalNames{
+					Metrics: strPtr("agntcy/otel/exporter-metrics-" + name),
+					Traces:  strPtr("agntcy/otel/exporter-traces-" + name),
+					Logs:    strPtr("agntcy/otel/exporter-logs-" + name),
+				},
+			},
+			signalType: slimconfig.SignalTraces,
+			sessions:   sessions,
+			health: newChannelHealth(
+				t.Context(), slimconfig.SignalTraces, sessions, testutil.NewFakeConnector(), "", nil),
+		}
+		return exporter, extensionsHost{id: conn}
+	}
+	connA := &fakeConnection{app: testutil.NewFakeApp(), connID: 1, address: "http://slim-a:46357"}
+	connB := &fakeConnection{app: testutil.NewFakeApp(), connID: 2, address: "http://slim-b:46357"}
+	first, hostA := newExporter("a", connA)
+	second, hostB := newExporter("b", connB)
+
+	require.NoError(t, first.createApp(t.Context(), hostA))
+	require.NoError(t, second.createApp(t.Context(), hostB))
+	assert.Equal(t, uint64(1), first.connID)
+	assert.Equal(t, uint64(2), second.connID)
+	assert.Equal(t, "http://slim-a:46357", first.health.address)
+	assert.Equal(t, "http://slim-b:46357", second.health.address)
+
+	require.NoError(t, first.sessions.AddSession(t.Context(), testutil.NewFakeSession(1, "agntcy/otel/traces")))
+	assert.Equal(t, []string{"agntcy/otel/traces"}, first.sessions.ListSessionNames(t.Context()))
+	assert.Empty(t, second.sessions.ListSessionNames(t.Context()))
+}
+
+// TestSlimExporter_MaxPublishFailures tests that a session failing every
+// publication is closed and removed instead of failing every export
+func TestSlimExporter_MaxPublishFailures(t *testing.T) {
+	app := testutil.NewFakeApp()
+	exporter := &slimExporter{
+		config:     &Config{MaxPublishFailures: 2},
+		signalType: slimconfig.SignalTraces,
+		app:        app,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+	}
+	exporter.sessions.SetEvictionPolicy(exporter.config.MaxPublishFailures, exporter.evictSession)
+
+	healthy := testutil.NewFakeSession(1, "agntcy/otel/channel-healthy")
+	broken := testutil.NewFakeSession(2, "agntcy/otel/channel-broken")
+	broken.PublishErr = errors.New("participant unreachable")
+	require.NoError(t, exporter.sessions.AddSession(t.Context(), healthy))
+	require.NoError(t, exporter.sessions.AddSession(t.Context(), broken))
+
+	require.Error(t, exporter.publishData(t.Context(), nil, []byte("data")))
+	require.NoError(t, exporter.publishData(t.Context(), nil, []byte("data")))
+	assert.Equal(t, []uint32{2}, app.DeletedSessions())
+	assert.True(t, broken.Closed())
+	assert.Equal(t, []string{"agntcy/otel/channel-healthy"}, exporter.sessions.ListSessionNames(t.Context()))
+
+	require.NoError(t, exporter.publishData(t.Context(), nil, []byte("data")))
+	assert.Len(t, healthy.PublishedMessages(), 3)
 }
 
 // TestSlimExporter_PushTraces tests the pushTraces method
@@ -102,6 +446,36 @@ func TestSlimExporter_PushTraces(t *testing.T) {
 	})
 }
 
+// TestSlimExporter_PushJSON tests that the payloads are marshaled and split
+// as OTLP/JSON with the otlp_json encoding
+func TestSlimExporter_PushJSON(t *testing.T) {
+	exporter := &slimExporter{
+		config:     &Config{Encoding: slimcommon.EncodingJSON},
+		signalType: slimconfig.SignalTraces,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+	}
+	session := testutil.NewFakeSession(1, "agntcy/otel/traces")
+	require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+
+	td := newTestTraces(6, 100)
+	whole, err := (&ptrace.JSONMarshaler{}).MarshalTraces(td)
+	require.NoError(t, err)
+	exporter.config.MaxMessageBytes = len(whole) / 2
+	require.NoError(t, exporter.pushTraces(t.Context(), td))
+
+	published := session.Published()
+	require.Greater(t, len(published), 1, "the batch is split to the JSON size")
+	spans := 0
+	for _, payload := range published {
+		assert.True(t, slimcommon.IsJSONPayload(payload))
+		assert.LessOrEqual(t, len(payload), exporter.config.MaxMessageBytes)
+		decoded, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(payload)
+		require.NoError(t, err)
+		spans += decoded.SpanCount()
+	}
+	assert.Equal(t, td.SpanCount(), spans)
+}
+
 // TestSlimExporter_PushMetrics tests the pushMetrics method
 func TestSlimExporter_PushMetrics(t *testing.T) {
 	t.Run("push empty metrics without panic", func(t *testing.T) {
@@ -147,3 +521,110 @@ func TestSlimExporter_PushLogs(t *testing.T) {
 		}
 	})
 }
+
+// TestSlimExporter_Telemetry tests the exporter self-telemetry
+func TestSlimExporter_Telemetry(t *testing.T) {
+	newExporter := func(t *testing.T) (*slimExporter, *componenttest.Telemetry) {
+		tt := componenttest.NewTelemetry()
+		t.Cleanup(func() { require.NoError(t, tt.Shutdown(context.Background())) })
+
+		sessions := slimcommon.NewSessionsList(slimconfig.SignalTraces)
+		telemetry, err := newExporterTelemetry(tt.NewTelemetrySettings(), slimconfig.SignalTraces, sessions)
+		require.NoError(t, err)
+
+		return &slimExporter{
+			signalType: slimconfig.SignalTraces,
+			sessions:   sessions,
+			telemetry:  telemetry,
+		}, tt
+	}
+
+	sumValue := func(t *testing.T, tt *componenttest.Telemetry, name string) int64 {
+		m, err := tt.GetMetric(name)
+		require.NoError(t, err)
+		sum, ok := m.Data.(metricdata.Sum[int64])
+		require.True(t, ok)
+		require.Len(t, sum.DataPoints, 1)
+		signal, _ := sum.DataPoints[0].Attributes.Value("signal")
+		assert.Equal(t, "traces", signal.AsString())
+		return sum.DataPoints[0].Value
+	}
+
+	t.Run("published bytes and active sessions", func(t *testing.T) {
+		exporter, tt := newExporter(t)
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), testutil.NewFakeSession(1, "agntcy/otel/channel-1")))
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), testutil.NewFakeSession(2, "agntcy/otel/channel-2")))
+
+		require.NoError(t, exporter.publishData(t.Context(), nil, []byte("0123456789")))
+		require.NoError(t, exporter.publishData(t.Context(), nil, []byte("01234")))
+
+		assert.Equal(t, int64(15), sumValue(t, tt, metricPublishedBytes))
+
+		m, err := tt.GetMetric(metricActiveSessions)
+		require.NoError(t, err)
+		gauge, ok := m.Data.(metricdata.Gauge[int64])
+		require.True(t, ok)
+		require.Len(t, gauge.DataPoints, 1)
+		assert.Equal(t, int64(2), gauge.DataPoints[0].Value)
+	})
+
+	t.Run("closed sessions are counted", func(t *testing.T) {
+		exporter, tt := newExporter(t)
+		closed := testutil.NewFakeSession(1, "agntcy/otel/channel-1")
+		closed.Close()
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), closed))
+
+		require.NoError(t, exporter.publishData(t.Context(), nil, []byte("data")))
+
+		assert.Equal(t, int64(1), sumValue(t, tt, metricClosedSessions))
+		assert.Empty(t, exporter.sessions.ListSessionNames(t.Context()))
+	})
+
+	t.Run("publish failures are counted", func(t *testing.T) {
+		exporter, tt := newExporter(t)
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel-1")
+		session.PublishErr = errors.New("boom")
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+
+		require.Error(t, exporter.publishData(t.Context(), nil, []byte("data")))
+
+		assert.Equal(t, int64(1), sumValue(t, tt, metricPublishFailures))
+		_, err := tt.GetMetric(metricPublishedBytes)
+		assert.Error(t, err)
+	})
+
+	t.Run("split batches are counted", func(t *testing.T) {
+		exporter, tt := newExporter(t)
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel-1")
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+
+		td := ptrace.NewTraces()
+		for range 3 {
+			td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+		}
+		marshaler := ptrace.ProtoMarshaler{}
+		exporter.config = &Config{MaxMessageBytes: marshaler.ResourceSpansSize(td.ResourceSpans().At(0)) + 2}
+
+		require.NoError(t, exporter.pushTraces(t.Context(), td))
+
+		assert.Equal(t, int64(1), sumValue(t, tt, metricSplitBatches))
+		assert.Len(t, session.Published(), 3)
+	})
+
+	t.Run("empty batches are skipped", func(t *testing.T) {
+		exporter, tt := newExporter(t)
+		exporter.config = &Config{}
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel-1")
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+
+		td := ptrace.NewTraces()
+		td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty()
+		require.NoError(t, exporter.pushTraces(t.Context(), td))
+		assert.Empty(t, session.Published())
+		assert.Equal(t, int64(1), sumValue(t, tt, metricEmptyBatches))
+
+		exporter.config.PublishEmptyBatches = true
+		require.NoError(t, exporter.pushTraces(t.Context(), td))
+		assert.Len(t, session.Published(), 1)
+	})
+}
@@ -6,6 +6,7 @@ package slimexporter
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/exporter"
@@ -21,26 +22,48 @@ const (
 
 	// The stability level of the exporter
 	stability = component.StabilityLevelDevelopment
+
+	// defaultSummaryInterval is the default interval of the publish summary logs
+	defaultSummaryInterval = time.Minute
+
+	// defaultShutdownTimeout is the default time the shutdown waits for the
+	// exports in progress
+	defaultShutdownTimeout = 5 * time.Second
 )
 
+// factory holds the options of the exporters created by NewFactory
+type factory struct {
+	hooks     []PublishHook
+	listeners []ChannelStateListener
+}
+
 // NewFactory creates a factory for the Slim exporter
-func NewFactory() exporter.Factory {
+func NewFactory(opts ...FactoryOption) exporter.Factory {
+	f := &factory{}
+	for _, opt := range opts {
+		opt(f)
+	}
+
 	return exporter.NewFactory(
 		component.MustNewType(TypeStr),
 		createDefaultConfig,
-		exporter.WithTraces(createTracesExporter, stability),
-		exporter.WithMetrics(createMetricsExporter, stability),
-		exporter.WithLogs(createLogsExporter, stability),
+		exporter.WithTraces(f.createTracesExporter, stability),
+		exporter.WithMetrics(f.createMetricsExporter, stability),
+		exporter.WithLogs(f.createLogsExporter, stability),
 	)
 }
 
 // createDefaultConfig creates the default configuration for the exporter
 func createDefaultConfig() component.Config {
-	return &Config{}
+	return &Config{
+		SummaryInterval:    defaultSummaryInterval,
+		ShutdownTimeout:    defaultShutdownTimeout,
+		PublishConcurrency: slimcommon.DefaultPublishConcurrency,
+	}
 }
 
 // createTracesExporter creates a trace exporter based on the config
-func createTracesExporter(
+func (f *factory) createTracesExporter(
 	ctx context.Context,
 	set exporter.Settings,
 	cfg component.Config,
@@ -52,10 +75,11 @@ func createTracesExporter(
 	}
 
 	ctx = slimcommon.InitContextWithLogger(ctx, set.Logger)
-	exp, err := newSlimExporter(ctx, exporterConfig, slimconfig.SignalTraces)
+	exp, err := newSlimExporter(ctx, set.TelemetrySettings, exporterConfig, slimconfig.SignalTraces, f.hooks, f.listeners)
 	if err != nil {
 		return nil, fmt.Errorf("error creating the exporter: %w", err)
 	}
+	exp.componentID = set.ID
 
 	return exporterhelper.NewTraces(
 		ctx,
@@ -68,7 +92,7 @@ func createTracesExporter(
 }
 
 // createMetricsExporter creates a metrics exporter based on the config
-func createMetricsExporter(
+func (f *factory) createMetricsExporter(
 	ctx context.Context,
 	set exporter.Settings,
 	cfg component.Config,
@@ -80,10 +104,11 @@ func createMetricsExporter(
 	}
 
 	ctx = slimcommon.InitContextWithLogger(ctx, set.Logger)
-	exp, err := newSlimExporter(ctx, exporterConfig, slimconfig.SignalMetrics)
+	exp, err := newSlimExporter(ctx, set.TelemetrySettings, exporterConfig, slimconfig.SignalMetrics, f.hooks, f.listeners)
 	if err != nil {
 		return nil, fmt.Errorf("error creating the exporter: %w", err)
 	}
+	exp.componentID = set.ID
 
 	return exporterhelper.NewMetrics(
 		ctx,
@@ -96,7 +121,7 @@ func createMetricsExporter(
 }
 
 // createLogsExporter creates a logs exporter based on the config
-func createLogsExporter(
+func (f *factory) createLogsExporter(
 	ctx context.Context,
 	set exporter.Settings,
 	cfg component.Config,
@@ -108,10 +133,11 @@ func createLogsExporter(
 	}
 
 	ctx = slimcommon.InitContextWithLogger(ctx, set.Logger)
-	exp, err := newSlimExporter(ctx, exporterConfig, slimconfig.SignalLogs)
+	exp, err := newSlimExporter(ctx, set.TelemetrySettings, exporterConfig, slimconfig.SignalLogs, f.hooks, f.listeners)
 	if err != nil {
 		return nil, fmt.Errorf("error creating the exporter: %w", err)
 	}
+	exp.componentID = set.ID
 
 	return exporterhelper.NewLogs(
 		ctx,
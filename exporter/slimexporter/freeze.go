@@ -0,0 +1,40 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// handleFreezeNotification pauses or resumes the publications on the session
+// of a channel frozen or unfrozen by the channel manager. The session stays
+// in the list, so that it is neither published to nor reaped while frozen.
+func (e *slimExporter) handleFreezeNotification(ctx context.Context, sessionID uint32, msg slim.ReceivedMessage) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	frozen, ok := slimcommon.MessageFrozen(msg.Context.Metadata)
+	if !ok {
+		logger.Warn("Ignoring freeze notification without state", zap.Uint32("session_id", sessionID))
+		return
+	}
+
+	if !e.sessions.SetFrozen(sessionID, frozen) {
+		// unchanged, e.g. notified again for a new participant
+		return
+	}
+	if frozen {
+		logger.Warn("Channel frozen, pausing the publications on the session",
+			zap.String("signal", string(e.signalType)),
+			zap.Uint32("session_id", sessionID),
+			zap.String("reason", msg.Context.Metadata[slimcommon.MetadataFreezeReason]))
+		return
+	}
+	logger.Info("Channel unfrozen, resuming the publications on the session",
+		zap.String("signal", string(e.signalType)),
+		zap.Uint32("session_id", sessionID))
+}
@@ -0,0 +1,79 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func freezeNotification(frozen bool) slim.ReceivedMessage {
+	msg := slim.ReceivedMessage{}
+	msg.Context.PayloadType = slimcommon.PayloadTypeFreeze
+	msg.Context.Metadata = slimcommon.FreezeMetadata(frozen, "backend incident")
+	return msg
+}
+
+func TestPublishData_FrozenSession(t *testing.T) {
+	exporter := &slimExporter{
+		config:     &Config{FreezeNotifications: true},
+		signalType: slimconfig.SignalTraces,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+	}
+	frozen := testutil.NewFakeSession(1, "agntcy/otel/channel-1")
+	other := testutil.NewFakeSession(2, "agntcy/otel/channel-2")
+	for _, session := range []*testutil.FakeSession{frozen, other} {
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+		go exporter.readMessages(t.Context(), session)
+		t.Cleanup(session.Close)
+	}
+
+	isFrozen := func() bool {
+		for _, info := range exporter.sessions.Snapshot() {
+			if info.ID == 1 {
+				return info.Frozen
+			}
+		}
+		return false
+	}
+
+	frozen.DeliverMessage(freezeNotification(true))
+	require.Eventually(t, isFrozen, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, exporter.publishData(t.Context(), nil, []byte("payload")))
+	assert.Empty(t, frozen.Published(), "no data published on the frozen session")
+	assert.Len(t, other.Published(), 1)
+	assert.Len(t, exporter.sessions.ListSessionNames(t.Context()), 2, "the frozen session is kept")
+
+	frozen.DeliverMessage(freezeNotification(false))
+	require.Eventually(t, func() bool { return !isFrozen() }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, exporter.publishData(t.Context(), nil, []byte("payload")))
+	assert.Len(t, frozen.Published(), 1)
+}
+
+func TestHandleFreezeNotification_Disabled(t *testing.T) {
+	exporter := &slimExporter{
+		config:     &Config{DrainNotifications: true},
+		signalType: slimconfig.SignalTraces,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+	}
+	session := testutil.NewFakeSession(1, "agntcy/otel/channel")
+	require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+
+	session.DeliverMessage(freezeNotification(true))
+	session.Close()
+	// returns once the delivered message is handled and the session closed
+	exporter.readMessages(t.Context(), session)
+
+	assert.False(t, exporter.sessions.Snapshot()[0].Frozen)
+}
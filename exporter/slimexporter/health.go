@@ -0,0 +1,272 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// ChannelState is the health state of a channel of the exporter
+type ChannelState string
+
+const (
+	// ChannelConnecting is the state of a channel nothing was published to
+	// yet, or while the connection to the SLIM node is re-established
+	ChannelConnecting ChannelState = "connecting"
+	// ChannelHealthy is the state of a channel whose last publication
+	// succeeded
+	ChannelHealthy ChannelState = "healthy"
+	// ChannelDegraded is the state of a channel whose last publications
+	// failed, less than brokenAfterFailures times in a row
+	ChannelDegraded ChannelState = "degraded"
+	// ChannelBroken is the state of a channel whose last
+	// brokenAfterFailures publications failed, or whose connection to the
+	// SLIM node was found down as many times in a row
+	ChannelBroken ChannelState = "broken"
+)
+
+// brokenAfterFailures is the number of consecutive publish failures or
+// failed reconnection checks after which a channel is broken
+const brokenAfterFailures = 3
+
+// ChannelHealth is the health of a channel of the exporter
+type ChannelHealth struct {
+	Signal  slimconfig.SignalType `json:"signal"`
+	Channel string                `json:"channel"`
+	State   ChannelState          `json:"state"`
+	// Since is the time of the last change of state
+	Since time.Time `json:"since"`
+	// ConsecutiveFailures is the number of publications that failed since
+	// the last success
+	ConsecutiveFailures int `json:"consecutive_failures"`
+	// LastError is the error of the last failure, empty once a publication
+	// succeeds
+	LastError string `json:"last_error,omitempty"`
+	// LastSuccess is the time of the last successful publication
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+}
+
+// ChannelStateListener is called with the health of a channel each time its
+// state changes, e.g. to report it as a component status event. It must not
+// block, since it is called from the publications.
+type ChannelStateListener func(health ChannelHealth)
+
+// WithChannelStateListener adds a listener notified of the changes of state
+// of the channels of the exporters
+func WithChannelStateListener(listener ChannelStateListener) FactoryOption {
+	return func(f *factory) {
+		f.listeners = append(f.listeners, listener)
+	}
+}
+
+// channelHealth tracks the health of the channels of an exporter from the
+// outcome of the publications and the state of the connection to the SLIM
+// node. All methods are safe to call on a nil receiver, in which case
+// nothing is tracked.
+type channelHealth struct {
+	signalType slimconfig.SignalType
+	sessions   *slimcommon.SessionsList
+	connector  slimcommon.Connector
+	address    string
+	logger     *zap.Logger
+	listeners  []ChannelStateListener
+
+	mutex    sync.Mutex
+	channels map[string]*ChannelHealth
+	// number of consecutive checks that found the connection down
+	reconnectAttempts int
+}
+
+// newChannelHealth creates the tracker of the channels of sessions, checking
+// the connection to the SLIM node at address with connector
+func newChannelHealth(
+	ctx context.Context,
+	signalType slimconfig.SignalType,
+	sessions *slimcommon.SessionsList,
+	connector slimcommon.Connector,
+	address string,
+	listeners []ChannelStateListener,
+) *channelHealth {
+	return &channelHealth{
+		signalType: signalType,
+		sessions:   sessions,
+		connector:  connector,
+		address:    address,
+		logger:     slimcommon.LoggerFromContextOrDefault(ctx),
+		listeners:  listeners,
+		channels:   make(map[string]*ChannelHealth),
+	}
+}
+
+// observe records the outcome of a publication, it is a slimcommon.PublishObserver
+func (h *channelHealth) observe(sessionName string, _ int, err error) {
+	if h == nil {
+		return
+	}
+	now := time.Now()
+
+	h.mutex.Lock()
+	channel := h.channel(sessionName, now)
+	state := ChannelHealthy
+	if err == nil {
+		channel.ConsecutiveFailures = 0
+		channel.LastError = ""
+		channel.LastSuccess = &now
+	} else {
+		channel.ConsecutiveFailures++
+		channel.LastError = err.Error()
+		state = ChannelDegraded
+		if channel.ConsecutiveFailures >= brokenAfterFailures {
+			state = ChannelBroken
+		}
+	}
+	changed := h.transition(channel, state, now)
+	h.mutex.Unlock()
+
+	h.notify(changed)
+}
+
+// checkConnection updates the state of all the channels from the connection
+// to the SLIM node: while it is down they are connecting, then broken after
+// brokenAfterFailures checks, and once it is back they are connecting until
+// a publication succeeds
+func (h *channelHealth) checkConnection(ctx context.Context) {
+	if h == nil {
+		return
+	}
+	connected := h.connector.Connected(h.address)
+	now := time.Now()
+
+	h.mutex.Lock()
+	h.sync(ctx, now)
+	var changed []ChannelHealth
+	switch {
+	case connected && h.reconnectAttempts > 0:
+		h.reconnectAttempts = 0
+		for _, channel := range h.channels {
+			channel.LastError = ""
+			changed = append(changed, h.transition(channel, ChannelConnecting, now)...)
+		}
+	case !connected:
+		h.reconnectAttempts++
+		state := ChannelConnecting
+		if h.reconnectAttempts >= brokenAfterFailures {
+			state = ChannelBroken
+		}
+		for _, channel := range h.channels {
+			channel.LastError = fmt.Sprintf("connection to the SLIM node %s is down", h.address)
+			changed = append(changed, h.transition(channel, state, now)...)
+		}
+	}
+	h.mutex.Unlock()
+
+	h.notify(changed)
+}
+
+// run checks the connection every interval until ctx is done
+func (h *channelHealth) run(ctx context.Context, interval time.Duration) {
+	if h == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.checkConnection(ctx)
+		}
+	}
+}
+
+// snapshot returns the health of the channels, sorted by name
+func (h *channelHealth) snapshot(ctx context.Context) []ChannelHealth {
+	if h == nil {
+		return nil
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.sync(ctx, time.Now())
+	health := make([]ChannelHealth, 0, len(h.channels))
+	for _, name := range slices.Sorted(maps.Keys(h.channels)) {
+		health = append(health, *h.channels[name])
+	}
+	return health
+}
+
+// sync tracks the sessions added since the last call and forgets the
+// removed ones. The caller must hold the mutex.
+func (h *channelHealth) sync(ctx context.Context, now time.Time) {
+	names := h.sessions.ListSessionNames(ctx)
+	for _, name := range names {
+		h.channel(name, now)
+	}
+	for name := range h.channels {
+		if !slices.Contains(names, name) {
+			delete(h.channels, name)
+		}
+	}
+}
+
+// channel returns the health of the channel name, connecting if it was not
+// tracked yet. The caller must hold the mutex.
+func (h *channelHealth) channel(name string, now time.Time) *ChannelHealth {
+	channel, ok := h.channels[name]
+	if !ok {
+		channel = &ChannelHealth{
+			Signal:  h.signalType,
+			Channel: name,
+			State:   ChannelConnecting,
+			Since:   now,
+		}
+		h.channels[name] = channel
+	}
+	return channel
+}
+
+// transition sets the state of the channel and returns its health if the
+// state changed. The caller must hold the mutex.
+func (h *channelHealth) transition(channel *ChannelHealth, state ChannelState, now time.Time) []ChannelHealth {
+	if channel.State == state {
+		return nil
+	}
+	channel.State = state
+	channel.Since = now
+	return []ChannelHealth{*channel}
+}
+
+// notify logs the changes of state and calls the listeners
+func (h *channelHealth) notify(changed []ChannelHealth) {
+	for _, health := range changed {
+		fields := []zap.Field{
+			zap.String("signal", string(health.Signal)),
+			zap.String("channel", health.Channel),
+			zap.String("state", string(health.State)),
+		}
+		switch health.State {
+		case ChannelDegraded, ChannelBroken:
+			h.logger.Warn("Channel unhealthy", append(fields,
+				zap.Int("consecutive_failures", health.ConsecutiveFailures),
+				zap.String("last_error", health.LastError))...)
+		default:
+			h.logger.Info("Channel state changed", fields...)
+		}
+		for _, listener := range h.listeners {
+			listener(health)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// channelStates returns the state of each channel of the snapshot
+func channelStates(health []ChannelHealth) map[string]ChannelState {
+	states := make(map[string]ChannelState, len(health))
+	for _, channel := range health {
+		states[channel.Channel] = channel.State
+	}
+	return states
+}
+
+func TestChannelHealth_Publications(t *testing.T) {
+	const address = "http://127.0.0.1:46357"
+	connector := testutil.NewFakeConnector()
+	_, err := connector.Connect(slimconfig.ConnectionConfig{Address: address})
+	require.NoError(t, err)
+
+	var changes []ChannelHealth
+	sessions := slimcommon.NewSessionsList(slimconfig.SignalTraces)
+	health := newChannelHealth(t.Context(), slimconfig.SignalTraces, sessions, connector, address,
+		[]ChannelStateListener{func(h ChannelHealth) { changes = append(changes, h) }})
+	sessions.SetPublishObserver(health.observe)
+
+	healthy := testutil.NewFakeSession(1, "agntcy/otel/channel-1")
+	failing := testutil.NewFakeSession(2, "agntcy/otel/channel-2")
+	require.NoError(t, sessions.AddSession(t.Context(), healthy))
+	require.NoError(t, sessions.AddSession(t.Context(), failing))
+
+	assert.Equal(t, map[string]ChannelState{
+		"agntcy/otel/channel-1": ChannelConnecting,
+		"agntcy/otel/channel-2": ChannelConnecting,
+	}, channelStates(health.snapshot(t.Context())), "nothing published yet")
+
+	_, err = sessions.PublishToAll(t.Context(), []byte("data"))
+	require.NoError(t, err)
+	assert.Equal(t, ChannelHealthy, channelStates(health.snapshot(t.Context()))["agntcy/otel/channel-2"])
+
+	failing.PublishErr = errors.New("boom")
+	_, _ = sessions.PublishToAll(t.Context(), []byte("data"))
+	snapshot := health.snapshot(t.Context())
+	assert.Equal(t, ChannelHealthy, snapshot[0].State)
+	assert.Equal(t, ChannelDegraded, snapshot[1].State)
+	assert.Equal(t, 1, snapshot[1].ConsecutiveFailures)
+	assert.Equal(t, "boom", snapshot[1].LastError)
+	assert.NotNil(t, snapshot[1].LastSuccess)
+
+	for range brokenAfterFailures - 1 {
+		_, _ = sessions.PublishToAll(t.Context(), []byte("data"))
+	}
+	assert.Equal(t, ChannelBroken, health.snapshot(t.Context())[1].State)
+
+	failing.PublishErr = nil
+	_, err = sessions.PublishToAll(t.Context(), []byte("data"))
+	require.NoError(t, err)
+	snapshot = health.snapshot(t.Context())
+	assert.Equal(t, ChannelHealthy, snapshot[1].State)
+	assert.Zero(t, snapshot[1].ConsecutiveFailures)
+	assert.Empty(t, snapshot[1].LastError)
+
+	var channel2 []ChannelState
+	for _, change := range changes {
+		if change.Channel == "agntcy/otel/channel-2" {
+			channel2 = append(channel2, change.State)
+		}
+	}
+	assert.Equal(t, []ChannelState{ChannelHealthy, ChannelDegraded, ChannelBroken, ChannelHealthy}, channel2,
+		"the listeners are notified of the changes of state only")
+
+	_, err = sessions.RemoveSessionByID(t.Context(), 2)
+	require.NoError(t, err)
+	assert.Len(t, health.snapshot(t.Context()), 1, "the removed channels are forgotten")
+}
+
+func TestChannelHealth_CheckConnection(t *testing.T) {
+	const address = "http://127.0.0.1:46357"
+	connector := testutil.NewFakeConnector()
+	_, err := connector.Connect(slimconfig.ConnectionConfig{Address: address})
+	require.NoError(t, err)
+
+	sessions := slimcommon.NewSessionsList(slimconfig.SignalLogs)
+	health := newChannelHealth(t.Context(), slimconfig.SignalLogs, sessions, connector, address, nil)
+	sessions.SetPublishObserver(health.observe)
+	require.NoError(t, sessions.AddSession(t.Context(), testutil.NewFakeSession(1, "agntcy/otel/channel")))
+	_, err = sessions.PublishToAll(t.Context(), []byte("data"))
+	require.NoError(t, err)
+
+	health.checkConnection(t.Context())
+	assert.Equal(t, ChannelHealthy, health.snapshot(t.Context())[0].State, "the connection is up")
+
+	connector.SetDown(address, true)
+	health.checkConnection(t.Context())
+	snapshot := health.snapshot(t.Context())
+	assert.Equal(t, ChannelConnecting, snapshot[0].State)
+	assert.Contains(t, snapshot[0].LastError, "is down")
+
+	for range brokenAfterFailures - 1 {
+		health.checkConnection(t.Context())
+	}
+	assert.Equal(t, ChannelBroken, health.snapshot(t.Context())[0].State)
+
+	connector.SetDown(address, false)
+	_, err = connector.Connect(slimconfig.ConnectionConfig{Address: address})
+	require.NoError(t, err)
+	health.checkConnection(t.Context())
+	assert.Equal(t, ChannelConnecting, health.snapshot(t.Context())[0].State,
+		"connecting until a publication succeeds")
+
+	_, err = sessions.PublishToAll(t.Context(), []byte("data"))
+	require.NoError(t, err)
+	assert.Equal(t, ChannelHealthy, health.snapshot(t.Context())[0].State)
+}
+
+func TestChannelHealth_Nil(t *testing.T) {
+	var health *channelHealth
+	health.observe("agntcy/otel/channel", 1, nil)
+	health.checkConnection(t.Context())
+	assert.Nil(t, health.snapshot(t.Context()))
+}
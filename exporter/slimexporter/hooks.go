@@ -0,0 +1,88 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"go.opentelemetry.io/collector/consumer/consumererror"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// PublishInfo describes a marshaled batch about to be published
+type PublishInfo struct {
+	// Signal of the data
+	Signal slimconfig.SignalType
+
+	// Names of the channels the batch is published to, nil for all the
+	// channels of the exporter
+	Channels []string
+
+	// Number of spans, metric data points or log records
+	Count int
+
+	// Size of the marshaled batch in bytes
+	Size int
+
+	// Metadata of the SLIM message carrying the batch, initialized with the
+	// metadata of the config. Hooks may add or change entries, they are sent
+	// with the message.
+	Metadata map[string]string
+}
+
+// PublishHook is called before each batch is published, e.g. to add
+// metadata or accounting logic. Returning an error vetoes the publish: the
+// batch and the rest of the data are dropped and the error is reported as
+// permanent, so the data is not retried. BeforePublish is called from the
+// exporter pipeline goroutines: it must be safe for concurrent use.
+type PublishHook interface {
+	BeforePublish(ctx context.Context, info *PublishInfo) error
+}
+
+// FactoryOption applies an option to the exporters created by the factory
+type FactoryOption func(*factory)
+
+// WithPublishHook adds a hook called before the exporters publish data
+func WithPublishHook(hook PublishHook) FactoryOption {
+	return func(f *factory) {
+		f.hooks = append(f.hooks, hook)
+	}
+}
+
+// beforePublish calls the publish hooks for a batch of count items published
+// to targets and returns the metadata to send with it, starting from the
+// static metadata of the config
+func (e *slimExporter) beforePublish(
+	ctx context.Context,
+	targets []string,
+	message []byte,
+	count int,
+) (map[string]string, error) {
+	metadata := make(map[string]string, len(e.config.Metadata))
+	maps.Copy(metadata, e.config.Metadata)
+	if len(e.hooks) == 0 {
+		return metadata, nil
+	}
+
+	info := &PublishInfo{
+		Signal:   e.signalType,
+		Channels: targets,
+		Count:    count,
+		Size:     len(message),
+		Metadata: metadata,
+	}
+	for _, hook := range e.hooks {
+		if err := hook.BeforePublish(ctx, info); err != nil {
+			return nil, consumererror.NewPermanent(fmt.Errorf("publish vetoed by hook: %w", err))
+		}
+	}
+
+	if info.Metadata == nil {
+		info.Metadata = make(map[string]string)
+	}
+	return info.Metadata, nil
+}
@@ -0,0 +1,157 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// hookFunc adapts a function to the PublishHook interface
+type hookFunc func(ctx context.Context, info *PublishInfo) error
+
+func (f hookFunc) BeforePublish(ctx context.Context, info *PublishInfo) error {
+	return f(ctx, info)
+}
+
+func TestPublishHooks(t *testing.T) {
+	newExporter := func(t *testing.T, hooks ...PublishHook) (*slimExporter, *testutil.FakeSession) {
+		exporter := &slimExporter{
+			config:     &Config{},
+			signalType: slimconfig.SignalTraces,
+			sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+			hooks:      hooks,
+		}
+		session := testutil.NewFakeSession(1, "agntcy/otel/traces")
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+		return exporter, session
+	}
+
+	t.Run("hooks see the batch and set metadata", func(t *testing.T) {
+		var mutex sync.Mutex
+		var infos []PublishInfo
+		record := hookFunc(func(_ context.Context, info *PublishInfo) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			infos = append(infos, *info)
+			info.Metadata["tenant"] = "acme"
+			return nil
+		})
+		override := hookFunc(func(_ context.Context, info *PublishInfo) error {
+			assert.Equal(t, "acme", info.Metadata["tenant"], "hooks run in registration order")
+			info.Metadata["tenant"] = "globex"
+			return nil
+		})
+		exporter, session := newExporter(t, record, override)
+
+		require.NoError(t, exporter.pushTraces(t.Context(), newTestTraces(3, 10)))
+
+		published := session.PublishedMessages()
+		require.Len(t, published, 1)
+		assert.Equal(t, "globex", published[0].Context.Metadata["tenant"])
+		assert.NotEmpty(t, published[0].Context.Metadata[slimcommon.MetadataSentAt],
+			"the exporter metadata is still set")
+		assert.Equal(t, "traces", published[0].Context.Metadata[slimcommon.MetadataSignal])
+
+		require.Len(t, infos, 1)
+		assert.Equal(t, slimconfig.SignalTraces, infos[0].Signal)
+		assert.Nil(t, infos[0].Channels)
+		assert.Equal(t, 3, infos[0].Count)
+		assert.Equal(t, len(published[0].Payload), infos[0].Size)
+	})
+
+	t.Run("hook error vetoes the publish", func(t *testing.T) {
+		veto := hookFunc(func(context.Context, *PublishInfo) error {
+			return errors.New("quota exceeded")
+		})
+		exporter, session := newExporter(t, veto)
+
+		err := exporter.pushTraces(t.Context(), newTestTraces(1, 10))
+		require.Error(t, err)
+		assert.True(t, consumererror.IsPermanent(err))
+		assert.ErrorContains(t, err, "quota exceeded")
+		assert.Empty(t, session.Published())
+	})
+
+	t.Run("no hooks", func(t *testing.T) {
+		exporter, session := newExporter(t)
+
+		require.NoError(t, exporter.pushTraces(t.Context(), newTestTraces(1, 10)))
+		assert.Len(t, session.Published(), 1)
+	})
+
+	t.Run("static metadata", func(t *testing.T) {
+		override := hookFunc(func(_ context.Context, info *PublishInfo) error {
+			assert.Equal(t, "prod", info.Metadata["environment"], "hooks see the static metadata")
+			info.Metadata["environment"] = "staging"
+			return nil
+		})
+		exporter, session := newExporter(t, override)
+		exporter.config.Metadata = map[string]string{"environment": "prod", "schema": "v2"}
+
+		require.NoError(t, exporter.pushTraces(t.Context(), newTestTraces(1, 10)))
+		require.NoError(t, exporter.pushTraces(t.Context(), newTestTraces(1, 10)))
+
+		for _, published := range session.PublishedMessages() {
+			assert.Equal(t, "staging", published.Context.Metadata["environment"])
+			assert.Equal(t, "v2", published.Context.Metadata["schema"])
+		}
+		assert.Equal(t, "prod", exporter.config.Metadata["environment"], "the config is not changed by the hooks")
+	})
+
+	t.Run("component tag", func(t *testing.T) {
+		exporter, session := newExporter(t)
+		exporter.componentID = component.MustNewIDWithName("slim", "pipeline-a")
+
+		require.NoError(t, exporter.pushTraces(t.Context(), newTestTraces(1, 10)))
+		assert.NotContains(t, session.PublishedMessages()[0].Context.Metadata, slimcommon.MetadataComponent,
+			"the component is only tagged when enabled")
+
+		exporter.config.TagComponent = true
+		require.NoError(t, exporter.pushTraces(t.Context(), newTestTraces(1, 10)))
+		assert.Equal(t, "slim/pipeline-a", session.PublishedMessages()[1].Context.Metadata[slimcommon.MetadataComponent])
+	})
+}
+
+func TestPublishEnvelope(t *testing.T) {
+	tracesName := "agntcy/otel/exporter-traces"
+	exporter := &slimExporter{
+		config: &Config{
+			ExporterNames: &slimconfig.SignalNames{Traces: &tracesName},
+			Encoding:      slimcommon.EncodingJSON,
+		},
+		signalType: slimconfig.SignalTraces,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+	}
+	session := testutil.NewFakeSession(1, "agntcy/otel/traces")
+	require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+
+	require.NoError(t, exporter.pushTraces(t.Context(), newTestTraces(1, 10)))
+	raw := session.PublishedMessages()[0]
+	assert.NotContains(t, raw.Context.Metadata, slimcommon.MetadataEnvelope, "the envelope is only used when enabled")
+
+	exporter.config.Envelope = EnvelopeConfig{Enabled: true, Compression: slimcommon.CompressionGzip}
+	require.NoError(t, exporter.pushTraces(t.Context(), newTestTraces(1, 10)))
+	wrapped := session.PublishedMessages()[1]
+	assert.True(t, slimcommon.HasEnvelope(wrapped.Context.Metadata))
+
+	env, err := slimcommon.UnmarshalEnvelope(wrapped.Payload)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(slimcommon.EnvelopeVersion), env.Version)
+	assert.Equal(t, slimcommon.EncodingJSON, env.Encoding)
+	assert.Equal(t, slimcommon.CompressionGzip, env.Compression)
+	assert.Equal(t, tracesName, env.Producer)
+	assert.JSONEq(t, string(raw.Payload), string(env.Payload))
+}
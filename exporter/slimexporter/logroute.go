@@ -0,0 +1,152 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// AttributeMatcher matches the log records by the value of an attribute of
+// the record or, if the record does not have it, of its resource
+type AttributeMatcher struct {
+	// Attribute key, e.g. log.file.path or k8s.container.name
+	Key string `mapstructure:"key"`
+
+	// Value the attribute must be equal to
+	Value string `mapstructure:"value"`
+
+	// Prefix the attribute must start with
+	Prefix string `mapstructure:"prefix"`
+}
+
+// Validate checks if the matcher is valid. A matcher without value nor
+// prefix matches the records having the attribute.
+func (m *AttributeMatcher) Validate() error {
+	if m.Key == "" {
+		return errors.New("attribute key cannot be empty")
+	}
+	if m.Value != "" && m.Prefix != "" {
+		return errors.New("attribute value and prefix cannot be both set")
+	}
+	return nil
+}
+
+// matches reports whether the record, or its resource, has the attribute
+// with the expected value
+func (m *AttributeMatcher) matches(resource pcommon.Resource, record plog.LogRecord) bool {
+	value, ok := record.Attributes().Get(m.Key)
+	if !ok {
+		if value, ok = resource.Attributes().Get(m.Key); !ok {
+			return false
+		}
+	}
+	switch {
+	case m.Value != "":
+		return value.AsString() == m.Value
+	case m.Prefix != "":
+		return strings.HasPrefix(value.AsString(), m.Prefix)
+	default:
+		return true
+	}
+}
+
+// logRoutes maps the log records to the sessions of the channels whose
+// attribute matchers they match
+type logRoutes struct {
+	// names of the sessions of the channels with matchers, in configuration order
+	sessions []string
+	// matchers of each session, all of them must match
+	matchers [][]AttributeMatcher
+}
+
+// index returns the index in sessions of the first channel whose matchers
+// all match the record, or len(sessions) if no channel matches
+func (r *logRoutes) index(resource pcommon.Resource, record plog.LogRecord) int {
+	for i, matchers := range r.matchers {
+		matched := true
+		for j := range matchers {
+			if !matchers[j].matches(resource, record) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return i
+		}
+	}
+	return len(r.sessions)
+}
+
+// logRoutes returns the routes of the logs channels with attribute matchers,
+// or nil if none of them has matchers
+func (e *slimExporter) logRoutes(ctx context.Context) *logRoutes {
+	var routes *logRoutes
+	for _, channel := range e.config.Channels {
+		if channel.Signal != string(e.signalType) || len(channel.Match) == 0 {
+			continue
+		}
+		name, err := slimcommon.SplitID(channel.destination())
+		if err != nil {
+			slimcommon.LoggerFromContextOrDefault(ctx).Debug("Ignoring channel for log routing",
+				zap.String("channel", channel.destination()), zap.Error(err))
+			continue
+		}
+		if routes == nil {
+			routes = &logRoutes{}
+		}
+		routes.sessions = append(routes.sessions, name.String())
+		routes.matchers = append(routes.matchers, channel.Match)
+	}
+	return routes
+}
+
+// partitionLogsBy distributes the log records of ld among n batches
+// according to the batch index returned for each record. The resources and
+// scopes are copied in every batch that holds some of their records.
+func partitionLogsBy(ld plog.Logs, n int, index func(pcommon.Resource, plog.LogRecord) int) []plog.Logs {
+	parts := make([]plog.Logs, n)
+	for i := range parts {
+		parts[i] = plog.NewLogs()
+	}
+
+	rls := ld.ResourceLogs()
+	for i := range rls.Len() {
+		rl := rls.At(i)
+		resources := make(map[int]plog.ResourceLogs)
+		sls := rl.ScopeLogs()
+		for j := range sls.Len() {
+			sl := sls.At(j)
+			scopes := make(map[int]plog.ScopeLogs)
+			records := sl.LogRecords()
+			for k := range records.Len() {
+				record := records.At(k)
+				p := index(rl.Resource(), record)
+				scope, ok := scopes[p]
+				if !ok {
+					resource, ok := resources[p]
+					if !ok {
+						resource = parts[p].ResourceLogs().AppendEmpty()
+						rl.Resource().CopyTo(resource.Resource())
+						resource.SetSchemaUrl(rl.SchemaUrl())
+						resources[p] = resource
+					}
+					scope = resource.ScopeLogs().AppendEmpty()
+					sl.Scope().CopyTo(scope.Scope())
+					scope.SetSchemaUrl(sl.SchemaUrl())
+					scopes[p] = scope
+				}
+				record.CopyTo(scope.LogRecords().AppendEmpty())
+			}
+		}
+	}
+	return parts
+}
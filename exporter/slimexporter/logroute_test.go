@@ -0,0 +1,120 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestAttributeMatcher(t *testing.T) {
+	resource := pcommon.NewResource()
+	resource.Attributes().PutStr("k8s.container.name", "nginx")
+	record := plog.NewLogRecord()
+	record.Attributes().PutStr("log.file.path", "/var/log/pods/nginx/0.log")
+	record.Attributes().PutInt("http.status_code", 503)
+
+	tests := []struct {
+		name    string
+		matcher AttributeMatcher
+		want    bool
+	}{
+		{name: "record attribute value", matcher: AttributeMatcher{Key: "http.status_code", Value: "503"}, want: true},
+		{
+			name:    "record attribute prefix",
+			matcher: AttributeMatcher{Key: "log.file.path", Prefix: "/var/log/pods/"},
+			want:    true,
+		},
+		{name: "resource attribute", matcher: AttributeMatcher{Key: "k8s.container.name", Value: "nginx"}, want: true},
+		{name: "attribute presence", matcher: AttributeMatcher{Key: "log.file.path"}, want: true},
+		{name: "other value", matcher: AttributeMatcher{Key: "k8s.container.name", Value: "envoy"}},
+		{name: "other prefix", matcher: AttributeMatcher{Key: "log.file.path", Prefix: "/var/log/syslog"}},
+		{name: "missing attribute", matcher: AttributeMatcher{Key: "service.name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.matcher.matches(resource, record))
+		})
+	}
+}
+
+func TestPushLogs_AttributeRoutes(t *testing.T) {
+	nginx := testutil.NewFakeSession(1, "agntcy/otel/nginx-logs")
+	pods := testutil.NewFakeSession(2, "agntcy/otel/pod-logs")
+	logs := testutil.NewFakeSession(3, "agntcy/otel/logs")
+
+	exporter := &slimExporter{
+		config: &Config{
+			Channels: []ChannelsConfig{
+				{
+					ChannelName: "agntcy/otel/nginx-logs",
+					Signal:      "logs",
+					Match: []AttributeMatcher{
+						{Key: "k8s.container.name", Value: "nginx"},
+						{Key: "log.file.path", Prefix: "/var/log/pods/"},
+					},
+				},
+				{
+					ChannelName: "agntcy/otel/pod-logs",
+					Signal:      "logs",
+					Match:       []AttributeMatcher{{Key: "log.file.path", Prefix: "/var/log/pods/"}},
+				},
+				{ChannelName: "agntcy/otel/logs", Signal: "logs"},
+			},
+		},
+		signalType: slimconfig.SignalLogs,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalLogs),
+	}
+	for _, session := range []*testutil.FakeSession{nginx, pods, logs} {
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+	}
+
+	ld := plog.NewLogs()
+	for _, container := range []string{"nginx", "envoy"} {
+		rl := ld.ResourceLogs().AppendEmpty()
+		rl.Resource().Attributes().PutStr("k8s.container.name", container)
+		records := rl.ScopeLogs().AppendEmpty().LogRecords()
+		for _, path := range []string{"/var/log/pods/" + container + "/0.log", "/var/log/syslog"} {
+			record := records.AppendEmpty()
+			record.Body().SetStr(container + " " + path)
+			record.Attributes().PutStr("log.file.path", path)
+		}
+	}
+	require.NoError(t, exporter.pushLogs(t.Context(), ld))
+
+	assert.Equal(t, []string{"nginx /var/log/pods/nginx/0.log"}, publishedLogBodies(t, nginx))
+	assert.Equal(t, []string{"envoy /var/log/pods/envoy/0.log"}, publishedLogBodies(t, pods),
+		"the records go to the first channel they match")
+	assert.Equal(t, []string{"nginx /var/log/syslog", "envoy /var/log/syslog"}, publishedLogBodies(t, logs),
+		"the channels without matchers get the records that match no channel")
+}
+
+// publishedLogBodies returns the bodies of the log records published on the session
+func publishedLogBodies(t *testing.T, session *testutil.FakeSession) []string {
+	t.Helper()
+	unmarshaler := plog.ProtoUnmarshaler{}
+	var bodies []string
+	for _, msg := range session.PublishedMessages() {
+		ld, err := unmarshaler.UnmarshalLogs(msg.Payload)
+		require.NoError(t, err)
+		for i := range ld.ResourceLogs().Len() {
+			sls := ld.ResourceLogs().At(i).ScopeLogs()
+			for j := range sls.Len() {
+				records := sls.At(j).LogRecords()
+				for k := range records.Len() {
+					bodies = append(bodies, records.At(k).Body().Str())
+				}
+			}
+		}
+	}
+	return bodies
+}
@@ -0,0 +1,189 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"slices"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// ChannelOverrideAttribute is the resource attribute naming the channel the
+// telemetry of the resource is published to, in the form org/namespace/service
+const ChannelOverrideAttribute = "slim.channel.override"
+
+// channelOverrides resolves the channels requested by the resources of a
+// batch to the sessions of the exporter
+type channelOverrides struct {
+	// channels the resources are allowed to route their telemetry to
+	allowed []string
+	// names of the sessions of the signal
+	sessions []string
+}
+
+// channelOverrides returns the channel overrides of the exporter, or nil if
+// they are disabled
+func (e *slimExporter) channelOverrides(ctx context.Context) *channelOverrides {
+	if len(e.config.ChannelOverrideAllowlist) == 0 {
+		return nil
+	}
+	return &channelOverrides{
+		allowed:  e.config.ChannelOverrideAllowlist,
+		sessions: e.sessions.ListSessionNames(ctx),
+	}
+}
+
+// session returns the name of the session the telemetry of resource is
+// published to, or "" if the resource does not override its channel or the
+// channel is not allowed or not joined, the telemetry being routed as usual
+func (o *channelOverrides) session(ctx context.Context, resource pcommon.Resource) string {
+	value, ok := resource.Attributes().Get(ChannelOverrideAttribute)
+	if !ok || value.AsString() == "" {
+		return ""
+	}
+	channel := value.AsString()
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	if !matchesAny(o.allowed, channel) {
+		logger.Debug("Ignoring channel override not in the allowlist", zap.String("channel", channel))
+		return ""
+	}
+	name, err := slimcommon.SplitID(channel)
+	if err != nil {
+		logger.Debug("Ignoring invalid channel override", zap.String("channel", channel), zap.Error(err))
+		return ""
+	}
+	if !slices.Contains(o.sessions, name.String()) {
+		logger.Debug("Ignoring channel override without session", zap.String("channel", channel))
+		return ""
+	}
+	return name.String()
+}
+
+// resourceSessions returns the session of each of the n resources, "" for
+// the resources routed as usual, or nil if no resource overrides its channel
+func (o *channelOverrides) resourceSessions(
+	ctx context.Context,
+	n int,
+	resource func(int) pcommon.Resource,
+) []string {
+	if o == nil {
+		return nil
+	}
+	sessions := make([]string, n)
+	overridden := false
+	for i := range n {
+		sessions[i] = o.session(ctx, resource(i))
+		overridden = overridden || sessions[i] != ""
+	}
+	if !overridden {
+		return nil
+	}
+	return sessions
+}
+
+// tracesOverridePartitions moves the resources of td overriding their
+// channel to partitions published to the session of the channel, returning
+// them along with the rest of td
+func (e *slimExporter) tracesOverridePartitions(
+	ctx context.Context,
+	td ptrace.Traces,
+) ([]tracesPartition, ptrace.Traces) {
+	rss := td.ResourceSpans()
+	sessions := e.channelOverrides(ctx).resourceSessions(ctx, rss.Len(), func(i int) pcommon.Resource {
+		return rss.At(i).Resource()
+	})
+	if sessions == nil {
+		return nil, td
+	}
+
+	var partitions []tracesPartition
+	indexes := make(map[string]int)
+	rest := ptrace.NewTraces()
+	for i, session := range sessions {
+		target := rest
+		if session != "" {
+			p, ok := indexes[session]
+			if !ok {
+				p = len(partitions)
+				indexes[session] = p
+				partitions = append(partitions, tracesPartition{sessions: []string{session}, data: ptrace.NewTraces()})
+			}
+			target = partitions[p].data
+		}
+		rss.At(i).CopyTo(target.ResourceSpans().AppendEmpty())
+	}
+	return partitions, rest
+}
+
+// metricsOverridePartitions is the metrics counterpart of
+// tracesOverridePartitions
+func (e *slimExporter) metricsOverridePartitions(
+	ctx context.Context,
+	md pmetric.Metrics,
+) ([]metricsPartition, pmetric.Metrics) {
+	rms := md.ResourceMetrics()
+	sessions := e.channelOverrides(ctx).resourceSessions(ctx, rms.Len(), func(i int) pcommon.Resource {
+		return rms.At(i).Resource()
+	})
+	if sessions == nil {
+		return nil, md
+	}
+
+	var partitions []metricsPartition
+	indexes := make(map[string]int)
+	rest := pmetric.NewMetrics()
+	for i, session := range sessions {
+		target := rest
+		if session != "" {
+			p, ok := indexes[session]
+			if !ok {
+				p = len(partitions)
+				indexes[session] = p
+				partitions = append(partitions, metricsPartition{sessions: []string{session}, data: pmetric.NewMetrics()})
+			}
+			target = partitions[p].data
+		}
+		rms.At(i).CopyTo(target.ResourceMetrics().AppendEmpty())
+	}
+	return partitions, rest
+}
+
+// logsOverridePartitions is the logs counterpart of tracesOverridePartitions
+func (e *slimExporter) logsOverridePartitions(
+	ctx context.Context,
+	ld plog.Logs,
+) ([]logsPartition, plog.Logs) {
+	rls := ld.ResourceLogs()
+	sessions := e.channelOverrides(ctx).resourceSessions(ctx, rls.Len(), func(i int) pcommon.Resource {
+		return rls.At(i).Resource()
+	})
+	if sessions == nil {
+		return nil, ld
+	}
+
+	var partitions []logsPartition
+	indexes := make(map[string]int)
+	rest := plog.NewLogs()
+	for i, session := range sessions {
+		target := rest
+		if session != "" {
+			p, ok := indexes[session]
+			if !ok {
+				p = len(partitions)
+				indexes[session] = p
+				partitions = append(partitions, logsPartition{sessions: []string{session}, data: plog.NewLogs()})
+			}
+			target = partitions[p].data
+		}
+		rls.At(i).CopyTo(target.ResourceLogs().AppendEmpty())
+	}
+	return partitions, rest
+}
@@ -0,0 +1,120 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestChannelOverrides_Session(t *testing.T) {
+	overrides := &channelOverrides{
+		allowed:  []string{"agntcy/tenants/*"},
+		sessions: []string{"agntcy/tenants/team-a", "agntcy/otel/traces"},
+	}
+
+	tests := []struct {
+		name     string
+		override string
+		want     string
+	}{
+		{name: "allowed channel", override: "agntcy/tenants/team-a", want: "agntcy/tenants/team-a"},
+		{name: "channel not in the allowlist", override: "agntcy/otel/traces"},
+		{name: "channel without session", override: "agntcy/tenants/team-b"},
+		{name: "empty override"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := pcommon.NewResource()
+			resource.Attributes().PutStr(ChannelOverrideAttribute, tt.override)
+			assert.Equal(t, tt.want, overrides.session(t.Context(), resource))
+		})
+	}
+
+	assert.Empty(t, overrides.session(t.Context(), pcommon.NewResource()), "no override")
+}
+
+func TestChannelOverrides_Disabled(t *testing.T) {
+	exporter := &slimExporter{
+		config:     &Config{},
+		signalType: slimconfig.SignalTraces,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+	}
+	assert.Nil(t, exporter.channelOverrides(t.Context()))
+
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().Resource().Attributes().PutStr(ChannelOverrideAttribute, "agntcy/otel/traces")
+	partitions, rest := exporter.tracesOverridePartitions(t.Context(), td)
+	assert.Empty(t, partitions)
+	assert.Equal(t, td, rest, "the batch is routed as usual")
+}
+
+// newOverrideExporter creates an exporter of the signal allowing the
+// overrides to the tenants channels, with a session for each channel
+func newOverrideExporter(
+	t *testing.T,
+	signal slimconfig.SignalType,
+	channels ...string,
+) (*slimExporter, []*testutil.FakeSession) {
+	t.Helper()
+	exporter := &slimExporter{
+		config:     &Config{ChannelOverrideAllowlist: []string{"agntcy/tenants/*"}},
+		signalType: signal,
+		sessions:   slimcommon.NewSessionsList(signal),
+	}
+	sessions := make([]*testutil.FakeSession, len(channels))
+	for i, channel := range channels {
+		sessions[i] = testutil.NewFakeSession(uint32(i+1), channel) //nolint:gosec // a few sessions
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), sessions[i]))
+	}
+	return exporter, sessions
+}
+
+func TestPushLogs_ChannelOverride(t *testing.T) {
+	exporter, sessions := newOverrideExporter(t, slimconfig.SignalLogs,
+		"agntcy/tenants/team-a", "agntcy/tenants/team-b", "agntcy/otel/logs")
+	teamA, teamB, logs := sessions[0], sessions[1], sessions[2]
+
+	ld := plog.NewLogs()
+	for _, override := range []string{"agntcy/tenants/team-a", "", "agntcy/otel/logs", "agntcy/tenants/team-a"} {
+		rl := ld.ResourceLogs().AppendEmpty()
+		if override != "" {
+			rl.Resource().Attributes().PutStr(ChannelOverrideAttribute, override)
+		}
+		rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("override " + override)
+	}
+	require.NoError(t, exporter.pushLogs(t.Context(), ld))
+
+	assert.Equal(t, []string{"override agntcy/tenants/team-a", "override agntcy/tenants/team-a"},
+		publishedLogBodies(t, teamA))
+	for _, session := range []*testutil.FakeSession{teamB, logs} {
+		assert.Equal(t, []string{"override ", "override agntcy/otel/logs"}, publishedLogBodies(t, session),
+			"the resources without allowed override are published to all the sessions")
+	}
+}
+
+func TestPushMetrics_ChannelOverride(t *testing.T) {
+	exporter, sessions := newOverrideExporter(t, slimconfig.SignalMetrics,
+		"agntcy/tenants/team-a", "agntcy/otel/metrics")
+
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr(ChannelOverrideAttribute, "agntcy/tenants/team-a")
+	rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty()
+	require.NoError(t, exporter.pushMetrics(t.Context(), md))
+
+	assert.Len(t, sessions[0].PublishedMessages(), 1)
+	assert.Empty(t, sessions[1].PublishedMessages(), "a fully overridden batch is only published to its channel")
+}
@@ -0,0 +1,79 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// publishLimits returns the limits to enforce when publishing: the strictest
+// between the exporter configuration and the policies advertised by the
+// channel manager in the metadata of the sessions. Since every message is
+// published to all the sessions, the strictest channel policy applies to all
+// the channels of the exporter.
+func (e *slimExporter) publishLimits(ctx context.Context) slimcommon.ChannelPolicy {
+	limits := slimcommon.ChannelPolicy{MaxMessageSize: e.config.MaxMessageBytes}
+
+	for _, session := range e.sessions.ListSessions(ctx) {
+		policy, err := slimcommon.SessionPolicy(session)
+		if err != nil {
+			slimcommon.LoggerFromContextOrDefault(ctx).Debug("Ignoring the channel policy",
+				zap.String("signal", string(e.signalType)), zap.Error(err))
+			continue
+		}
+		limits.MaxMessageSize = minLimit(limits.MaxMessageSize, policy.MaxMessageSize)
+		limits.MaxMessageRate = minLimit(limits.MaxMessageRate, policy.MaxMessageRate)
+	}
+
+	return limits
+}
+
+// minLimit returns the strictest of two limits, where zero means no limit
+func minLimit[T int | float64](a, b T) T {
+	switch {
+	case a <= 0:
+		return b
+	case b <= 0:
+		return a
+	default:
+		return min(a, b)
+	}
+}
+
+// waitForRate blocks until a message can be published without exceeding
+// rate messages per second. A non positive rate does not block.
+func (e *slimExporter) waitForRate(ctx context.Context, rate float64) error {
+	if rate <= 0 {
+		return nil
+	}
+
+	// reserve the next publication slot
+	e.rateMutex.Lock()
+	now := time.Now()
+	slot := e.nextPublish
+	if slot.Before(now) {
+		slot = now
+	}
+	e.nextPublish = slot.Add(time.Duration(float64(time.Second) / rate))
+	e.rateMutex.Unlock()
+
+	wait := slot.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestPublishLimits(t *testing.T) {
+	newSession := func(id uint32, channel string, policy slimcommon.ChannelPolicy) *testutil.FakeSession {
+		session := testutil.NewFakeSession(id, channel)
+		session.Config.Metadata = make(map[string]string)
+		policy.AddToMetadata(session.Config.Metadata)
+		return session
+	}
+
+	exporter := &slimExporter{
+		config:     &Config{MaxMessageBytes: 8192},
+		signalType: slimconfig.SignalTraces,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+	}
+	assert.Equal(t, slimcommon.ChannelPolicy{MaxMessageSize: 8192}, exporter.publishLimits(t.Context()))
+
+	require.NoError(t, exporter.sessions.AddSession(t.Context(),
+		newSession(1, "agntcy/otel/channel-1", slimcommon.ChannelPolicy{MaxMessageSize: 4096})))
+	require.NoError(t, exporter.sessions.AddSession(t.Context(),
+		newSession(2, "agntcy/otel/channel-2", slimcommon.ChannelPolicy{MaxMessageSize: 16384, MaxMessageRate: 10})))
+	require.NoError(t, exporter.sessions.AddSession(t.Context(),
+		newSession(3, "agntcy/otel/channel-3", slimcommon.ChannelPolicy{})))
+
+	// the strictest limits apply
+	assert.Equal(t, slimcommon.ChannelPolicy{MaxMessageSize: 4096, MaxMessageRate: 10}, exporter.publishLimits(t.Context()))
+}
+
+func TestWaitForRate(t *testing.T) {
+	exporter := &slimExporter{}
+
+	require.NoError(t, exporter.waitForRate(t.Context(), 0))
+
+	start := time.Now()
+	for range 3 {
+		require.NoError(t, exporter.waitForRate(t.Context(), 20))
+	}
+	// the first message is published immediately, the next ones every 50ms
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	exporter.nextPublish = time.Now().Add(time.Hour)
+	assert.ErrorIs(t, exporter.waitForRate(ctx, 20), context.Canceled)
+}
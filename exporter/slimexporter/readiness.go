@@ -0,0 +1,137 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// readinessPollInterval is how often the sessions are checked while waiting
+// for the channels to be ready
+const readinessPollInterval = 100 * time.Millisecond
+
+// waitForReady holds the first publications until the channels are ready, so
+// that data exported right after startup is not published to sessions that
+// nobody joined yet. The wait lasts at most readiness-timeout; once the
+// channels are ready or the timeout expired, publications are never held again.
+func (e *slimExporter) waitForReady(ctx context.Context) {
+	if e.config.ReadinessTimeout <= 0 || e.ready.Load() {
+		return
+	}
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	timer := time.NewTimer(e.config.ReadinessTimeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+
+	var err error
+	for !e.ready.Load() {
+		if err = e.sessionsReady(ctx); err == nil {
+			if e.ready.CompareAndSwap(false, true) {
+				logger.Info("Channels ready, start publishing", zap.String("signal", string(e.signalType)))
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			if e.ready.CompareAndSwap(false, true) {
+				logger.Warn("Channels not ready before the readiness timeout, publishing anyway",
+					zap.String("signal", string(e.signalType)),
+					zap.Duration("readiness_timeout", e.config.ReadinessTimeout),
+					zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sessionsReady returns nil if the exporter has at least one session and all
+// the participants configured for the channel of each session joined it. A
+// session that is not configured, e.g. accepted from an invite, must have at
+// least one participant other than the exporter itself. The error names the
+// participants missing from each session.
+func (e *slimExporter) sessionsReady(ctx context.Context) error {
+	localName, err := e.config.exporterName(e.signalType)
+	if err != nil {
+		return err
+	}
+
+	sessions := e.sessions.ListSessions(ctx)
+	if len(sessions) == 0 {
+		return errors.New("no session")
+	}
+
+	expected := e.expectedParticipants()
+	var errs []error
+	for _, session := range sessions {
+		destination, err := session.Destination()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get session destination: %w", err))
+			continue
+		}
+		name := slimcommon.JoinID(destination)
+		participants, err := session.ParticipantsList()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get participants of %s: %w", name, err))
+			continue
+		}
+		joined := make([]string, 0, len(participants))
+		for _, participant := range participants {
+			if id := slimcommon.JoinID(participant); id != localName {
+				joined = append(joined, id)
+			}
+		}
+
+		configured, ok := expected[name]
+		if !ok {
+			if len(joined) == 0 {
+				errs = append(errs, fmt.Errorf("no participant joined %s", name))
+			}
+			continue
+		}
+		var missing []string
+		for _, participant := range configured {
+			if !slices.Contains(joined, participant) {
+				missing = append(missing, participant)
+			}
+		}
+		if len(missing) > 0 {
+			errs = append(errs, fmt.Errorf("participants %s did not join %s", strings.Join(missing, ", "), name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// expectedParticipants returns the participants configured for the channels
+// of the signal, by session destination: the channel of a group session, the
+// participant of a point-to-point session
+func (e *slimExporter) expectedParticipants() map[string][]string {
+	expected := make(map[string][]string)
+	for _, config := range e.config.Channels {
+		if config.Signal != string(e.signalType) || len(config.Participants) == 0 {
+			continue
+		}
+		if config.slimSessionType() == slim.SessionTypePointToPoint {
+			expected[config.Participants[0]] = config.Participants[:1]
+			continue
+		}
+		expected[config.ChannelName] = config.Participants
+	}
+	return expected
+}
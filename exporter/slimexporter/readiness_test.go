@@ -0,0 +1,141 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func newReadinessExporter(timeout time.Duration) *slimExporter {
+	return &slimExporter{
+		config: &Config{
+			ExporterNames: &slimconfig.SignalNames{
+				Metrics: strPtr("agntcy/otel/exporter-metrics"),
+				Traces:  strPtr("agntcy/otel/exporter-traces"),
+				Logs:    strPtr("agntcy/otel/exporter-logs"),
+			},
+			ReadinessTimeout: timeout,
+		},
+		signalType: slimconfig.SignalTraces,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+	}
+}
+
+func TestSessionsReady(t *testing.T) {
+	exporter := newReadinessExporter(time.Second)
+	require.ErrorContains(t, exporter.sessionsReady(t.Context()), "no session")
+
+	session := testutil.NewFakeSession(1, "agntcy/otel/channel")
+	require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+	require.ErrorContains(t, exporter.sessionsReady(t.Context()), "no participant joined agntcy/otel/channel")
+
+	self, err := slimcommon.SplitID("agntcy/otel/exporter-traces")
+	require.NoError(t, err)
+	require.NoError(t, session.InviteAndWait(self))
+	require.Error(t, exporter.sessionsReady(t.Context()), "only the exporter itself")
+
+	receiver, err := slimcommon.SplitID("agntcy/otel/receiver")
+	require.NoError(t, err)
+	require.NoError(t, session.InviteAndWait(receiver))
+	require.NoError(t, exporter.sessionsReady(t.Context()))
+
+	// every session must have a remote participant
+	require.NoError(t, exporter.sessions.AddSession(t.Context(), testutil.NewFakeSession(2, "agntcy/otel/other")))
+	require.ErrorContains(t, exporter.sessionsReady(t.Context()), "no participant joined agntcy/otel/other")
+}
+
+func TestSessionsReady_ConfiguredParticipants(t *testing.T) {
+	exporter := newReadinessExporter(time.Second)
+	exporter.config.Channels = []ChannelsConfig{
+		{
+			ChannelName:  "agntcy/otel/channel",
+			Signal:       string(slimconfig.SignalTraces),
+			Participants: []string{"agntcy/otel/receiver-1", "agntcy/otel/receiver-2", "agntcy/otel/receiver-3"},
+		},
+		{
+			ChannelName:  "agntcy/otel/direct",
+			Signal:       string(slimconfig.SignalTraces),
+			Participants: []string{"agntcy/otel/receiver-4"},
+			SessionType:  SessionTypePointToPoint,
+		},
+		{
+			ChannelName:  "agntcy/otel/channel-logs",
+			Signal:       string(slimconfig.SignalLogs),
+			Participants: []string{"agntcy/otel/receiver-5"},
+		},
+	}
+	group := testutil.NewFakeSession(1, "agntcy/otel/channel")
+	direct := testutil.NewFakeSession(2, "agntcy/otel/receiver-4")
+	require.NoError(t, exporter.sessions.AddSession(t.Context(), group))
+	require.NoError(t, exporter.sessions.AddSession(t.Context(), direct))
+
+	join := func(session *testutil.FakeSession, id string) {
+		name, err := slimcommon.SplitID(id)
+		require.NoError(t, err)
+		require.NoError(t, session.InviteAndWait(name))
+	}
+	join(group, "agntcy/otel/receiver-1")
+	join(direct, "agntcy/otel/receiver-4")
+
+	// a single participant is not enough, the error names the missing ones
+	err := exporter.sessionsReady(t.Context())
+	require.ErrorContains(t, err,
+		"participants agntcy/otel/receiver-2, agntcy/otel/receiver-3 did not join agntcy/otel/channel")
+	assert.NotContains(t, err.Error(), "receiver-4")
+	assert.NotContains(t, err.Error(), "receiver-5")
+
+	join(group, "agntcy/otel/receiver-3")
+	require.ErrorContains(t, exporter.sessionsReady(t.Context()),
+		"participants agntcy/otel/receiver-2 did not join agntcy/otel/channel")
+
+	join(group, "agntcy/otel/receiver-2")
+	require.NoError(t, exporter.sessionsReady(t.Context()))
+}
+
+func TestWaitForReady(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		exporter := newReadinessExporter(0)
+		start := time.Now()
+		exporter.waitForReady(t.Context())
+		assert.Less(t, time.Since(start), readinessPollInterval)
+	})
+
+	t.Run("timeout opens the gate", func(t *testing.T) {
+		exporter := newReadinessExporter(50 * time.Millisecond)
+		exporter.waitForReady(t.Context())
+		assert.True(t, exporter.ready.Load())
+
+		// later publications are not held anymore
+		start := time.Now()
+		exporter.waitForReady(t.Context())
+		assert.Less(t, time.Since(start), readinessPollInterval)
+	})
+
+	t.Run("ready when a participant joins", func(t *testing.T) {
+		exporter := newReadinessExporter(time.Minute)
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel")
+		require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+
+		go func() {
+			time.Sleep(2 * readinessPollInterval)
+			receiver, err := slimcommon.SplitID("agntcy/otel/receiver")
+			if err == nil {
+				_ = session.InviteAndWait(receiver)
+			}
+		}()
+
+		start := time.Now()
+		exporter.waitForReady(t.Context())
+		assert.True(t, exporter.ready.Load())
+		assert.Less(t, time.Since(start), 10*time.Second)
+	})
+}
@@ -0,0 +1,92 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// errShuttingDown is returned by the pushes started once the exporter is
+// shutting down. It is not permanent, so that a persistent queue keeps the
+// data for the next start.
+var errShuttingDown = errors.New("the exporter is shutting down")
+
+// pushTracker tracks the pushes in progress, so that the shutdown lets them
+// complete before deleting the sessions. The zero value is ready to use.
+type pushTracker struct {
+	mutex    sync.Mutex
+	draining bool
+	inFlight int
+	// closed once draining and no push is in progress
+	idle chan struct{}
+}
+
+// begin registers a push and returns the function to call once it
+// completes, or errShuttingDown if the exporter is draining
+func (t *pushTracker) begin() (func(), error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.draining {
+		return nil, errShuttingDown
+	}
+	t.inFlight++
+	return t.end, nil
+}
+
+// end unregisters a push registered by begin
+func (t *pushTracker) end() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.inFlight--
+	if t.draining && t.inFlight == 0 {
+		close(t.idle)
+	}
+}
+
+// drain refuses the new pushes and waits for the pushes in progress to
+// complete, until timeout elapses or ctx is done. It returns the number of
+// pushes still in progress.
+func (t *pushTracker) drain(ctx context.Context, timeout time.Duration) int {
+	t.mutex.Lock()
+	if !t.draining {
+		t.draining = true
+		t.idle = make(chan struct{})
+		if t.inFlight == 0 {
+			close(t.idle)
+		}
+	}
+	idle := t.idle
+	t.mutex.Unlock()
+
+	timer := time.NewTimer(max(timeout, 0))
+	defer timer.Stop()
+	select {
+	case <-idle:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.inFlight
+}
+
+// drainPushes stops accepting the pushes and lets the pushes in progress
+// complete their publications within the shutdown timeout
+func (e *slimExporter) drainPushes(ctx context.Context) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	if remaining := e.pushes.drain(ctx, e.config.ShutdownTimeout); remaining > 0 {
+		logger.Warn("Shutting down with publications in progress",
+			zap.String("signal", string(e.signalType)),
+			zap.Int("pushes", remaining),
+			zap.Duration("shutdown_timeout", e.config.ShutdownTimeout))
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// TestSlimExporter_ShutdownDrainsPushes tests that the shutdown lets the
+// pushes in progress complete before deleting the sessions
+func TestSlimExporter_ShutdownDrainsPushes(t *testing.T) {
+	app := testutil.NewFakeApp()
+	exporter := &slimExporter{
+		config:     &Config{ShutdownTimeout: 5 * time.Second},
+		signalType: slimconfig.SignalTraces,
+		app:        app,
+		sessions:   slimcommon.NewSessionsList(slimconfig.SignalTraces),
+	}
+	release := make(chan struct{})
+	session := testutil.NewFakeSession(1, "agntcy/otel/channel")
+	session.OnPublish = func(slim.ReceivedMessage) { <-release }
+	require.NoError(t, exporter.sessions.AddSession(t.Context(), session))
+
+	pushed := make(chan error, 1)
+	go func() { pushed <- exporter.pushTraces(t.Context(), newTestTraces(1, 10)) }()
+	require.Eventually(t, func() bool {
+		return len(session.Published()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- exporter.shutdown(t.Context()) }()
+	require.Eventually(t, func() bool {
+		exporter.pushes.mutex.Lock()
+		defer exporter.pushes.mutex.Unlock()
+		return exporter.pushes.draining
+	}, 5*time.Second, 10*time.Millisecond)
+	require.ErrorIs(t, exporter.pushTraces(t.Context(), newTestTraces(1, 10)), errShuttingDown,
+		"the new pushes are refused")
+	assert.False(t, app.Destroyed(), "the app is kept until the pushes complete")
+
+	close(release)
+	require.NoError(t, <-pushed)
+	require.NoError(t, <-shutdown)
+	assert.True(t, app.Destroyed())
+	assert.Equal(t, []uint32{1}, app.DeletedSessions())
+}
+
+// TestPushTracker_Timeout tests that the drain gives up on the pushes that
+// do not complete within the shutdown timeout
+func TestPushTracker_Timeout(t *testing.T) {
+	var tracker pushTracker
+	done, err := tracker.begin()
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, tracker.drain(t.Context(), 20*time.Millisecond))
+	_, err = tracker.begin()
+	require.ErrorIs(t, err, errShuttingDown)
+
+	done()
+	assert.Zero(t, tracker.drain(t.Context(), 0))
+}
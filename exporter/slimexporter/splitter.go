@@ -0,0 +1,190 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"math/bits"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// indexRange is the half-open range [start, end) of resources put in a batch
+type indexRange struct {
+	start int
+	end   int
+}
+
+// splitTraces partitions td along ResourceSpans boundaries so that each
+// batch marshals to at most maxSize bytes and is a valid OTLP message on its
+// own. A ResourceSpans larger than maxSize is returned alone in its batch.
+// If maxSize is not positive or td already fits, td is returned as is.
+func splitTraces(td ptrace.Traces, maxSize int) []ptrace.Traces {
+	marshaler := ptrace.ProtoMarshaler{}
+	if maxSize <= 0 || marshaler.TracesSize(td) <= maxSize {
+		return []ptrace.Traces{td}
+	}
+
+	rss := td.ResourceSpans()
+	return tracesBatches(rss, partitionBySize(rss.Len(), func(i int) int {
+		return embeddedFieldSize(marshaler.ResourceSpansSize(rss.At(i)))
+	}, maxSize))
+}
+
+// splitTracesJSON is splitTraces for the OTLP/JSON encoding
+func splitTracesJSON(td ptrace.Traces, maxSize int) []ptrace.Traces {
+	marshaler := ptrace.JSONMarshaler{}
+	if maxSize <= 0 || jsonSize(marshaler.MarshalTraces(td)) <= maxSize {
+		return []ptrace.Traces{td}
+	}
+
+	rss := td.ResourceSpans()
+	envelope := len(`{"resourceSpans":[]}`)
+	return tracesBatches(rss, partitionBySize(rss.Len(), func(i int) int {
+		single := ptrace.NewTraces()
+		rss.At(i).CopyTo(single.ResourceSpans().AppendEmpty())
+		return jsonElementSize(jsonSize(marshaler.MarshalTraces(single)), envelope)
+	}, maxSize-envelope))
+}
+
+// tracesBatches returns a batch of the resources of each range
+func tracesBatches(rss ptrace.ResourceSpansSlice, ranges []indexRange) []ptrace.Traces {
+	batches := make([]ptrace.Traces, 0, len(ranges))
+	for _, r := range ranges {
+		batch := ptrace.NewTraces()
+		for i := r.start; i < r.end; i++ {
+			rss.At(i).CopyTo(batch.ResourceSpans().AppendEmpty())
+		}
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// splitMetrics partitions md along ResourceMetrics boundaries, see splitTraces
+func splitMetrics(md pmetric.Metrics, maxSize int) []pmetric.Metrics {
+	marshaler := pmetric.ProtoMarshaler{}
+	if maxSize <= 0 || marshaler.MetricsSize(md) <= maxSize {
+		return []pmetric.Metrics{md}
+	}
+
+	rms := md.ResourceMetrics()
+	return metricsBatches(rms, partitionBySize(rms.Len(), func(i int) int {
+		return embeddedFieldSize(marshaler.ResourceMetricsSize(rms.At(i)))
+	}, maxSize))
+}
+
+// splitMetricsJSON is splitMetrics for the OTLP/JSON encoding
+func splitMetricsJSON(md pmetric.Metrics, maxSize int) []pmetric.Metrics {
+	marshaler := pmetric.JSONMarshaler{}
+	if maxSize <= 0 || jsonSize(marshaler.MarshalMetrics(md)) <= maxSize {
+		return []pmetric.Metrics{md}
+	}
+
+	rms := md.ResourceMetrics()
+	envelope := len(`{"resourceMetrics":[]}`)
+	return metricsBatches(rms, partitionBySize(rms.Len(), func(i int) int {
+		single := pmetric.NewMetrics()
+		rms.At(i).CopyTo(single.ResourceMetrics().AppendEmpty())
+		return jsonElementSize(jsonSize(marshaler.MarshalMetrics(single)), envelope)
+	}, maxSize-envelope))
+}
+
+// metricsBatches returns a batch of the resources of each range
+func metricsBatches(rms pmetric.ResourceMetricsSlice, ranges []indexRange) []pmetric.Metrics {
+	batches := make([]pmetric.Metrics, 0, len(ranges))
+	for _, r := range ranges {
+		batch := pmetric.NewMetrics()
+		for i := r.start; i < r.end; i++ {
+			rms.At(i).CopyTo(batch.ResourceMetrics().AppendEmpty())
+		}
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// splitLogs partitions ld along ResourceLogs boundaries, see splitTraces
+func splitLogs(ld plog.Logs, maxSize int) []plog.Logs {
+	marshaler := plog.ProtoMarshaler{}
+	if maxSize <= 0 || marshaler.LogsSize(ld) <= maxSize {
+		return []plog.Logs{ld}
+	}
+
+	rls := ld.ResourceLogs()
+	return logsBatches(rls, partitionBySize(rls.Len(), func(i int) int {
+		return embeddedFieldSize(marshaler.ResourceLogsSize(rls.At(i)))
+	}, maxSize))
+}
+
+// splitLogsJSON is splitLogs for the OTLP/JSON encoding
+func splitLogsJSON(ld plog.Logs, maxSize int) []plog.Logs {
+	marshaler := plog.JSONMarshaler{}
+	if maxSize <= 0 || jsonSize(marshaler.MarshalLogs(ld)) <= maxSize {
+		return []plog.Logs{ld}
+	}
+
+	rls := ld.ResourceLogs()
+	envelope := len(`{"resourceLogs":[]}`)
+	return logsBatches(rls, partitionBySize(rls.Len(), func(i int) int {
+		single := plog.NewLogs()
+		rls.At(i).CopyTo(single.ResourceLogs().AppendEmpty())
+		return jsonElementSize(jsonSize(marshaler.MarshalLogs(single)), envelope)
+	}, maxSize-envelope))
+}
+
+// logsBatches returns a batch of the resources of each range
+func logsBatches(rls plog.ResourceLogsSlice, ranges []indexRange) []plog.Logs {
+	batches := make([]plog.Logs, 0, len(ranges))
+	for _, r := range ranges {
+		batch := plog.NewLogs()
+		for i := r.start; i < r.end; i++ {
+			rls.At(i).CopyTo(batch.ResourceLogs().AppendEmpty())
+		}
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// partitionBySize groups n consecutive resources, whose encoded sizes in a
+// batch are returned by sizeOf, into ranges whose encoded size does not
+// exceed maxSize. Every range holds at least one resource.
+func partitionBySize(n int, sizeOf func(i int) int, maxSize int) []indexRange {
+	var ranges []indexRange
+	start, total := 0, 0
+	for i := range n {
+		size := sizeOf(i)
+		if i > start && total+size > maxSize {
+			ranges = append(ranges, indexRange{start: start, end: i})
+			start, total = i, 0
+		}
+		total += size
+	}
+	if n > start {
+		ranges = append(ranges, indexRange{start: start, end: n})
+	}
+	return ranges
+}
+
+// embeddedFieldSize returns the encoded size of an embedded message of the
+// given size, including its one byte tag and its length prefix
+func embeddedFieldSize(size int) int {
+	varintLen := (bits.Len64(uint64(size)|1) + 6) / 7
+	return 1 + varintLen + size
+}
+
+// jsonSize returns the size of an OTLP/JSON payload, 0 if it failed to
+// marshal: the failure is then reported when the batch is marshaled
+func jsonSize(payload []byte, err error) int {
+	if err != nil {
+		return 0
+	}
+	return len(payload)
+}
+
+// jsonElementSize returns the size a resource adds to the array of an
+// OTLP/JSON payload, from the size of the payload holding it alone and the
+// size of its envelope, including the separating comma
+func jsonElementSize(singleSize, envelope int) int {
+	return singleSize - envelope + 1
+}
@@ -0,0 +1,204 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// newTestTraces creates traces with one span per resource, each span name
+// being padded to spanNameLen bytes
+func newTestTraces(resources, spanNameLen int) ptrace.Traces {
+	td := ptrace.NewTraces()
+	for i := range resources {
+		rs := td.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("service.name", fmt.Sprintf("service-%d", i))
+		span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetName(strings.Repeat("s", spanNameLen))
+	}
+	return td
+}
+
+func TestSplitTraces(t *testing.T) {
+	marshaler := ptrace.ProtoMarshaler{}
+
+	t.Run("no limit returns the input", func(t *testing.T) {
+		td := newTestTraces(5, 100)
+		batches := splitTraces(td, 0)
+		require.Len(t, batches, 1)
+		assert.Equal(t, td, batches[0])
+	})
+
+	t.Run("batch under the limit is not split", func(t *testing.T) {
+		td := newTestTraces(5, 100)
+		batches := splitTraces(td, marshaler.TracesSize(td))
+		require.Len(t, batches, 1)
+		assert.Equal(t, td, batches[0])
+	})
+
+	t.Run("split along resource boundaries", func(t *testing.T) {
+		td := newTestTraces(10, 100)
+		maxSize := marshaler.TracesSize(td) / 3
+
+		batches := splitTraces(td, maxSize)
+		require.Greater(t, len(batches), 1)
+
+		resources := 0
+		for _, batch := range batches {
+			message, err := marshaler.MarshalTraces(batch)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(message), maxSize)
+
+			// every batch must be a valid OTLP message on its own
+			unmarshaler := ptrace.ProtoUnmarshaler{}
+			decoded, err := unmarshaler.UnmarshalTraces(message)
+			require.NoError(t, err)
+			for i := range decoded.ResourceSpans().Len() {
+				name, _ := decoded.ResourceSpans().At(i).Resource().Attributes().Get("service.name")
+				assert.Equal(t, fmt.Sprintf("service-%d", resources), name.Str())
+				resources++
+			}
+		}
+		assert.Equal(t, 10, resources)
+		assert.Equal(t, 10, td.ResourceSpans().Len(), "input must not be modified")
+	})
+
+	t.Run("oversized resource is isolated", func(t *testing.T) {
+		td := newTestTraces(1, 10)
+		large := newTestTraces(1, 1000)
+		large.ResourceSpans().At(0).CopyTo(td.ResourceSpans().AppendEmpty())
+		newTestTraces(1, 10).ResourceSpans().At(0).CopyTo(td.ResourceSpans().AppendEmpty())
+
+		batches := splitTraces(td, 500)
+		require.Len(t, batches, 3)
+		assert.Equal(t, 1, batches[1].SpanCount())
+		assert.Greater(t, marshaler.TracesSize(batches[1]), 500)
+	})
+}
+
+func TestSplitMetrics(t *testing.T) {
+	md := pmetric.NewMetrics()
+	for i := range 10 {
+		rm := md.ResourceMetrics().AppendEmpty()
+		rm.Resource().Attributes().PutStr("service.name", fmt.Sprintf("service-%d", i))
+		m := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+		m.SetName(strings.Repeat("m", 100))
+		m.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(int64(i))
+	}
+
+	marshaler := pmetric.ProtoMarshaler{}
+	maxSize := marshaler.MetricsSize(md) / 4
+
+	batches := splitMetrics(md, maxSize)
+	require.Greater(t, len(batches), 1)
+
+	dataPoints := 0
+	for _, batch := range batches {
+		assert.LessOrEqual(t, marshaler.MetricsSize(batch), maxSize)
+		dataPoints += batch.DataPointCount()
+	}
+	assert.Equal(t, md.DataPointCount(), dataPoints)
+}
+
+func TestSplitLogs(t *testing.T) {
+	ld := plog.NewLogs()
+	for i := range 10 {
+		rl := ld.ResourceLogs().AppendEmpty()
+		rl.Resource().Attributes().PutStr("service.name", fmt.Sprintf("service-%d", i))
+		rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr(strings.Repeat("l", 100))
+	}
+
+	marshaler := plog.ProtoMarshaler{}
+	maxSize := marshaler.LogsSize(ld) / 4
+
+	batches := splitLogs(ld, maxSize)
+	require.Greater(t, len(batches), 1)
+
+	records := 0
+	for _, batch := range batches {
+		assert.LessOrEqual(t, marshaler.LogsSize(batch), maxSize)
+		records += batch.LogRecordCount()
+	}
+	assert.Equal(t, ld.LogRecordCount(), records)
+}
+
+func TestSplitJSON(t *testing.T) {
+	t.Run("traces", func(t *testing.T) {
+		td := newTestTraces(10, 100)
+		marshaler := ptrace.JSONMarshaler{}
+		message, err := marshaler.MarshalTraces(td)
+		require.NoError(t, err)
+		maxSize := len(message) / 3
+
+		assert.Len(t, splitTracesJSON(td, len(message)), 1)
+		batches := splitTracesJSON(td, maxSize)
+		require.Greater(t, len(batches), 1)
+
+		spans := 0
+		for _, batch := range batches {
+			message, err := marshaler.MarshalTraces(batch)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(message), maxSize)
+			decoded, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(message)
+			require.NoError(t, err)
+			spans += decoded.SpanCount()
+		}
+		assert.Equal(t, 10, spans)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		md := pmetric.NewMetrics()
+		for i := range 10 {
+			m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+			m.SetName(strings.Repeat("m", 100))
+			m.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(int64(i))
+		}
+		marshaler := pmetric.JSONMarshaler{}
+		message, err := marshaler.MarshalMetrics(md)
+		require.NoError(t, err)
+		maxSize := len(message) / 4
+
+		batches := splitMetricsJSON(md, maxSize)
+		require.Greater(t, len(batches), 1)
+		dataPoints := 0
+		for _, batch := range batches {
+			message, err := marshaler.MarshalMetrics(batch)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(message), maxSize)
+			dataPoints += batch.DataPointCount()
+		}
+		assert.Equal(t, 10, dataPoints)
+	})
+
+	t.Run("logs", func(t *testing.T) {
+		ld := plog.NewLogs()
+		for range 10 {
+			ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().
+				Body().SetStr(strings.Repeat("l", 100))
+		}
+		marshaler := plog.JSONMarshaler{}
+		message, err := marshaler.MarshalLogs(ld)
+		require.NoError(t, err)
+		maxSize := len(message) / 4
+
+		batches := splitLogsJSON(ld, maxSize)
+		require.Greater(t, len(batches), 1)
+		records := 0
+		for _, batch := range batches {
+			message, err := marshaler.MarshalLogs(batch)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(message), maxSize)
+			records += batch.LogRecordCount()
+		}
+		assert.Equal(t, 10, records)
+	})
+}
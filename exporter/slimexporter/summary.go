@@ -0,0 +1,101 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// channelActivity is the publish activity on a channel during an interval
+type channelActivity struct {
+	messages int
+	bytes    int
+	failures int
+}
+
+// publishSummary accumulates the publish activity of each channel and logs
+// it periodically at Info level, so that the exporter activity is visible
+// without logging every message
+type publishSummary struct {
+	signalType slimconfig.SignalType
+
+	mutex    sync.Mutex
+	channels map[string]*channelActivity
+}
+
+// newPublishSummary creates an empty summary for the signal
+func newPublishSummary(signalType slimconfig.SignalType) *publishSummary {
+	return &publishSummary{
+		signalType: signalType,
+		channels:   make(map[string]*channelActivity),
+	}
+}
+
+// observe records the outcome of a publication, it is a slimcommon.PublishObserver
+func (s *publishSummary) observe(sessionName string, size int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	activity, ok := s.channels[sessionName]
+	if !ok {
+		activity = &channelActivity{}
+		s.channels[sessionName] = activity
+	}
+	if err != nil {
+		activity.failures++
+		return
+	}
+	activity.messages++
+	activity.bytes += size
+}
+
+// reset returns the activity accumulated since the previous call
+func (s *publishSummary) reset() map[string]*channelActivity {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	channels := s.channels
+	s.channels = make(map[string]*channelActivity)
+	return channels
+}
+
+// log emits one Info entry per channel active since the previous call
+func (s *publishSummary) log(ctx context.Context, interval time.Duration) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	channels := s.reset()
+	for _, name := range slices.Sorted(maps.Keys(channels)) {
+		activity := channels[name]
+		logger.Info("Publish summary",
+			zap.String("signal", string(s.signalType)),
+			zap.String("channel", name),
+			zap.Duration("interval", interval),
+			zap.Int("messages", activity.messages),
+			zap.Int("bytes", activity.bytes),
+			zap.Int("failures", activity.failures))
+	}
+}
+
+// run logs the summary every interval until ctx is done
+func (s *publishSummary) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.log(ctx, interval)
+		}
+	}
+}
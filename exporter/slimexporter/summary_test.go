@@ -0,0 +1,63 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestPublishSummary(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	ctx := slimcommon.InitContextWithLogger(t.Context(), zap.New(core))
+
+	summary := newPublishSummary(slimconfig.SignalTraces)
+	sessions := slimcommon.NewSessionsList(slimconfig.SignalTraces)
+	sessions.SetPublishObserver(summary.observe)
+
+	healthy := testutil.NewFakeSession(1, "agntcy/otel/channel-1")
+	failing := testutil.NewFakeSession(2, "agntcy/otel/channel-2")
+	failing.PublishErr = errors.New("boom")
+	require.NoError(t, sessions.AddSession(ctx, healthy))
+
+	for range 3 {
+		_, err := sessions.PublishToAll(ctx, []byte("0123456789"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, sessions.AddSession(ctx, failing))
+	_, _ = sessions.PublishToAll(ctx, []byte("01234"))
+
+	// per-message logs are not emitted at Info level
+	assert.Zero(t, logs.FilterMessage("Published message").Len())
+
+	summary.log(ctx, time.Minute)
+	entries := logs.FilterMessage("Publish summary").AllUntimed()
+	require.Len(t, entries, 2)
+
+	healthyFields := entries[0].ContextMap()
+	assert.Equal(t, "agntcy/otel/channel-1", healthyFields["channel"])
+	assert.GreaterOrEqual(t, healthyFields["messages"], int64(3))
+	assert.GreaterOrEqual(t, healthyFields["bytes"], int64(30))
+	assert.Equal(t, int64(0), healthyFields["failures"])
+
+	failingFields := entries[1].ContextMap()
+	assert.Equal(t, "agntcy/otel/channel-2", failingFields["channel"])
+	assert.Equal(t, int64(0), failingFields["messages"])
+	assert.Equal(t, int64(1), failingFields["failures"])
+
+	// the activity is reset after each summary, idle channels are not logged
+	summary.log(ctx, time.Minute)
+	assert.Len(t, logs.FilterMessage("Publish summary").AllUntimed(), 2)
+}
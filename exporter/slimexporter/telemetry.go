@@ -0,0 +1,241 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimexporter
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+const (
+	// scopeName is the instrumentation scope of the exporter self-telemetry
+	scopeName = "github.com/agntcy/slim-otel/exporter/slimexporter"
+
+	metricPublishedBytes  = "otelcol_exporter_slim_published_bytes"
+	metricPublishFailures = "otelcol_exporter_slim_publish_failures"
+	metricClosedSessions  = "otelcol_exporter_slim_closed_sessions"
+	metricDrainedSessions = "otelcol_exporter_slim_drained_sessions"
+	metricIdleSessions    = "otelcol_exporter_slim_idle_sessions"
+	metricEvicted         = "otelcol_exporter_slim_evicted_sessions"
+	metricSplitBatches    = "otelcol_exporter_slim_split_batches"
+	metricEmptyBatches    = "otelcol_exporter_slim_empty_batches"
+	metricAckTimeouts     = "otelcol_exporter_slim_ack_timeouts"
+	metricDeadLetterBytes = "otelcol_exporter_slim_dead_letter_bytes"
+	metricActiveSessions  = "otelcol_exporter_slim_active_sessions"
+	metricPublishWaitTime = "otelcol_exporter_slim_publish_wait_time"
+)
+
+// exporterTelemetry holds the instruments used by the exporter to report its
+// own activity through the collector internal telemetry. Items sent and failed
+// (spans, metric points, log records) are already reported by exporterhelper.
+// All methods are safe to call on a nil receiver, in which case nothing is recorded.
+type exporterTelemetry struct {
+	attrs           metric.MeasurementOption
+	publishedBytes  metric.Int64Counter
+	publishFailures metric.Int64Counter
+	closedSessions  metric.Int64Counter
+	drainedSessions metric.Int64Counter
+	idleSessions    metric.Int64Counter
+	evicted         metric.Int64Counter
+	splitBatches    metric.Int64Counter
+	emptyBatches    metric.Int64Counter
+	ackTimeouts     metric.Int64Counter
+	deadLetterBytes metric.Int64Counter
+	publishWaitTime metric.Float64Histogram
+	activeSessions  metric.Int64ObservableGauge
+	registration    metric.Registration
+}
+
+// newExporterTelemetry creates the exporter instruments and registers the
+// callback reporting the number of active sessions
+func newExporterTelemetry(
+	set component.TelemetrySettings,
+	signalType slimconfig.SignalType,
+	sessions *slimcommon.SessionsList,
+) (*exporterTelemetry, error) {
+	meter := set.MeterProvider.Meter(scopeName)
+	t := &exporterTelemetry{
+		attrs: metric.WithAttributeSet(attribute.NewSet(attribute.String("signal", string(signalType)))),
+	}
+
+	var errs, err error
+	t.publishedBytes, err = meter.Int64Counter(metricPublishedBytes,
+		metric.WithDescription("Size of the OTLP payloads published to SLIM channels"),
+		metric.WithUnit("By"))
+	errs = errors.Join(errs, err)
+
+	t.publishFailures, err = meter.Int64Counter(metricPublishFailures,
+		metric.WithDescription("Number of payloads that could not be published to SLIM channels"),
+		metric.WithUnit("{payloads}"))
+	errs = errors.Join(errs, err)
+
+	t.closedSessions, err = meter.Int64Counter(metricClosedSessions,
+		metric.WithDescription("Number of sessions removed because they were closed by the remote side"),
+		metric.WithUnit("{sessions}"))
+	errs = errors.Join(errs, err)
+
+	t.drainedSessions, err = meter.Int64Counter(metricDrainedSessions,
+		metric.WithDescription("Number of sessions removed because a drain notification reported that they were about to close"),
+		metric.WithUnit("{sessions}"))
+	errs = errors.Join(errs, err)
+
+	t.idleSessions, err = meter.Int64Counter(metricIdleSessions,
+		metric.WithDescription("Number of sessions closed because they were idle for longer than the idle timeout"),
+		metric.WithUnit("{sessions}"))
+	errs = errors.Join(errs, err)
+	t.evicted, err = meter.Int64Counter(metricEvicted,
+		metric.WithDescription("Number of sessions closed after too many consecutive publish failures"),
+		metric.WithUnit("{sessions}"))
+	errs = errors.Join(errs, err)
+
+	t.splitBatches, err = meter.Int64Counter(metricSplitBatches,
+		metric.WithDescription("Number of batches split into several messages because they exceeded max-message-bytes"),
+		metric.WithUnit("{batches}"))
+	errs = errors.Join(errs, err)
+
+	t.emptyBatches, err = meter.Int64Counter(metricEmptyBatches,
+		metric.WithDescription("Number of batches without any item that were not published"),
+		metric.WithUnit("{batches}"))
+	errs = errors.Join(errs, err)
+
+	t.ackTimeouts, err = meter.Int64Counter(metricAckTimeouts,
+		metric.WithDescription("Number of payloads not acknowledged by the receivers within ack-timeout"),
+		metric.WithUnit("{payloads}"))
+	errs = errors.Join(errs, err)
+
+	t.deadLetterBytes, err = meter.Int64Counter(metricDeadLetterBytes,
+		metric.WithDescription("Size of the payloads that could not be published and were saved to the dead-letter directory"),
+		metric.WithUnit("By"))
+	errs = errors.Join(errs, err)
+
+	t.publishWaitTime, err = meter.Float64Histogram(metricPublishWaitTime,
+		metric.WithDescription("Time the publications waited for an in-flight slot of their session"),
+		metric.WithUnit("s"))
+	errs = errors.Join(errs, err)
+
+	t.activeSessions, err = meter.Int64ObservableGauge(metricActiveSessions,
+		metric.WithDescription("Number of SLIM sessions the exporter is currently publishing to"),
+		metric.WithUnit("{sessions}"))
+	errs = errors.Join(errs, err)
+	if errs != nil {
+		return nil, errs
+	}
+
+	t.registration, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(t.activeSessions, int64(len(sessions.ListSessionNames(ctx))), t.attrs)
+		return nil
+	}, t.activeSessions)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// recordPublished records a payload successfully published
+func (t *exporterTelemetry) recordPublished(ctx context.Context, size int) {
+	if t == nil {
+		return
+	}
+	t.publishedBytes.Add(ctx, int64(size), t.attrs)
+}
+
+// recordPublishFailure records a payload that could not be published
+func (t *exporterTelemetry) recordPublishFailure(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.publishFailures.Add(ctx, 1, t.attrs)
+}
+
+// recordClosedSessions records the removal of sessions closed by the remote side
+func (t *exporterTelemetry) recordClosedSessions(ctx context.Context, count int) {
+	if t == nil || count == 0 {
+		return
+	}
+	t.closedSessions.Add(ctx, int64(count), t.attrs)
+}
+
+// recordDrainedSession records the removal of a session about to be closed
+func (t *exporterTelemetry) recordDrainedSession(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.drainedSessions.Add(ctx, 1, t.attrs)
+}
+
+// recordIdleSession records the closing of a session idle for longer than the
+// idle timeout
+func (t *exporterTelemetry) recordIdleSession(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.idleSessions.Add(ctx, 1, t.attrs)
+}
+
+// recordEvictedSession records the closing of a session after too many
+// consecutive publish failures
+func (t *exporterTelemetry) recordEvictedSession(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.evicted.Add(ctx, 1, t.attrs)
+}
+
+// recordSplitBatch records a batch split into several messages
+func (t *exporterTelemetry) recordSplitBatch(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.splitBatches.Add(ctx, 1, t.attrs)
+}
+
+// recordEmptyBatch records a batch without any item that was not published
+func (t *exporterTelemetry) recordEmptyBatch(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.emptyBatches.Add(ctx, 1, t.attrs)
+}
+
+// recordAckTimeout records a payload not acknowledged in time
+func (t *exporterTelemetry) recordAckTimeout(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.ackTimeouts.Add(ctx, 1, t.attrs)
+}
+
+// recordDeadLetter records a payload saved to the dead-letter directory
+func (t *exporterTelemetry) recordDeadLetter(ctx context.Context, size int) {
+	if t == nil {
+		return
+	}
+	t.deadLetterBytes.Add(ctx, int64(size), t.attrs)
+}
+
+// recordPublishWait records the time a publication waited for an in-flight
+// slot of its session
+func (t *exporterTelemetry) recordPublishWait(ctx context.Context, wait time.Duration) {
+	if t == nil {
+		return
+	}
+	t.publishWaitTime.Record(ctx, wait.Seconds(), t.attrs)
+}
+
+// shutdown unregisters the active sessions callback
+func (t *exporterTelemetry) shutdown() error {
+	if t == nil || t.registration == nil {
+		return nil
+	}
+	return t.registration.Unregister()
+}
@@ -0,0 +1,54 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimconnection
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// Config defines configuration for the SLIM connection extension
+type Config struct {
+	// Connection configuration for the SLIM server
+	ConnectionConfig *slimconfig.ConnectionConfig `mapstructure:"connection-config"`
+
+	// Shared Secret
+	SharedSecret slimconfig.Opaque `mapstructure:"shared-secret"`
+
+	// Identity of the apps created on the connection towards the other
+	// participants. When not set, the shared secret is used
+	Auth *slimconfig.IdentityConfig `mapstructure:"auth"`
+}
+
+// identity returns the identity of the apps, the auth config if set and the
+// shared secret otherwise
+func (cfg *Config) identity() slimconfig.IdentityConfig {
+	if cfg.Auth != nil {
+		return *cfg.Auth
+	}
+	return slimconfig.IdentityConfig{Type: "shared_secret", SharedSecret: cfg.SharedSecret}
+}
+
+// Validate checks if the extension configuration is valid
+func (cfg *Config) Validate() error {
+	if cfg.Auth != nil {
+		if err := cfg.Auth.Validate(); err != nil {
+			return fmt.Errorf("invalid auth config: %w", err)
+		}
+	} else if cfg.SharedSecret == "" {
+		return errors.New("missing shared secret")
+	}
+
+	if cfg.ConnectionConfig == nil {
+		return errors.New("missing connection config")
+	}
+
+	if err := cfg.ConnectionConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid connection config: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimconnection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr string
+	}{
+		{
+			name: "shared secret",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{Address: "http://localhost:46357"},
+				SharedSecret:     "test-secret",
+			},
+		},
+		{
+			name: "auth",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{Address: "http://localhost:46357"},
+				Auth:             &slimconfig.IdentityConfig{Type: "shared_secret", SharedSecret: "auth-secret"},
+			},
+		},
+		{
+			name:    "missing shared secret",
+			config:  &Config{ConnectionConfig: &slimconfig.ConnectionConfig{Address: "http://localhost:46357"}},
+			wantErr: "missing shared secret",
+		},
+		{
+			name: "invalid auth",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{Address: "http://localhost:46357"},
+				Auth:             &slimconfig.IdentityConfig{Type: "unknown"},
+			},
+			wantErr: "invalid auth config",
+		},
+		{
+			name:    "missing connection config",
+			config:  &Config{SharedSecret: "test-secret"},
+			wantErr: "missing connection config",
+		},
+		{
+			name: "invalid connection config",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{},
+				SharedSecret:     "test-secret",
+			},
+			wantErr: "invalid connection config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Identity(t *testing.T) {
+	cfg := &Config{SharedSecret: "test-secret"}
+	assert.Equal(t, slimconfig.IdentityConfig{Type: "shared_secret", SharedSecret: "test-secret"}, cfg.identity())
+
+	auth := &slimconfig.IdentityConfig{Type: "static_jwt"}
+	cfg.Auth = auth
+	assert.Equal(t, *auth, cfg.identity())
+}
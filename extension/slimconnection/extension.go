@@ -0,0 +1,176 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimconnection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// Connection is the SLIM connection owned by the extension, shared by the
+// receivers and exporters referencing it by name. The apps created on it are
+// destroyed when the extension shuts down, if not before.
+type Connection interface {
+	// ConnID returns the id of the connection
+	ConnID() uint64
+	// Address returns the address of the SLIM server
+	Address() string
+	// CreateApp creates an app named localID, in the org/namespace/app
+	// format, with the identity of the extension and subscribes it to the
+	// connection
+	CreateApp(localID string, direction slim.Direction) (slimcommon.App, error)
+}
+
+// GetConnection returns the connection of the extension id of host, which
+// must be a SLIM connection extension
+func GetConnection(host component.Host, id component.ID) (Connection, error) {
+	ext, ok := host.GetExtensions()[id]
+	if !ok {
+		return nil, fmt.Errorf("extension %s not found", id)
+	}
+	conn, ok := ext.(Connection)
+	if !ok {
+		return nil, fmt.Errorf("extension %s is not a SLIM connection", id)
+	}
+	return conn, nil
+}
+
+// appCreator creates an app subscribed to a connection, see
+// slimcommon.CreateAppWithIdentity
+type appCreator func(
+	localID string, identity slimconfig.IdentityConfig, connID uint64, direction slim.Direction,
+) (slimcommon.App, error)
+
+// slimConnection implements the SLIM connection extension
+type slimConnection struct {
+	config    *Config
+	connector slimcommon.Connector
+	createApp appCreator
+	logger    *zap.Logger
+
+	mutex   sync.Mutex
+	started bool
+	connID  uint64
+	apps    map[*ownedApp]struct{}
+}
+
+// newSlimConnection creates the extension connecting with connector
+func newSlimConnection(ctx context.Context, cfg *Config, connector slimcommon.Connector) *slimConnection {
+	return &slimConnection{
+		config:    cfg,
+		connector: connector,
+		createApp: slimcommon.CreateAppWithIdentity,
+		logger:    slimcommon.LoggerFromContextOrDefault(ctx),
+		apps:      make(map[*ownedApp]struct{}),
+	}
+}
+
+// Start implements the component.Component interface, it connects to the
+// SLIM server
+func (c *slimConnection) Start(_ context.Context, _ component.Host) error {
+	connID, err := c.connector.Connect(*c.config.ConnectionConfig)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.started = true
+	c.connID = connID
+	c.mutex.Unlock()
+
+	tlsConfig := c.config.ConnectionConfig.TLS
+	c.logger.Info("connected to SLIM server",
+		slimcommon.EndpointField(c.config.ConnectionConfig.Address),
+		zap.Uint64("connection_id", connID),
+		zap.Bool("tls", tlsConfig != nil && !tlsConfig.Insecure),
+		zap.Bool("mtls", tlsConfig != nil && tlsConfig.Source != nil),
+	)
+	return nil
+}
+
+// Shutdown implements the component.Component interface, it destroys the
+// apps the receivers and exporters did not destroy
+func (c *slimConnection) Shutdown(_ context.Context) error {
+	c.mutex.Lock()
+	apps := c.apps
+	c.apps = make(map[*ownedApp]struct{})
+	c.started = false
+	c.mutex.Unlock()
+
+	if len(apps) > 0 {
+		c.logger.Info("Destroying the SLIM apps left on the connection", zap.Int("apps", len(apps)))
+	}
+	for app := range apps {
+		app.App.Destroy()
+	}
+	return nil
+}
+
+// ConnID implements Connection
+func (c *slimConnection) ConnID() uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.connID
+}
+
+// Address implements Connection
+func (c *slimConnection) Address() string {
+	return c.config.ConnectionConfig.Address
+}
+
+// CreateApp implements Connection
+func (c *slimConnection) CreateApp(localID string, direction slim.Direction) (slimcommon.App, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.started {
+		return nil, errors.New("the SLIM connection is not started")
+	}
+
+	app, err := c.createApp(localID, c.config.identity(), c.connID, direction)
+	if err != nil {
+		return nil, err
+	}
+	owned := &ownedApp{App: app, owner: c}
+	c.apps[owned] = struct{}{}
+
+	c.logger.Info("created SLIM app", zap.String("app_name", localID))
+	return owned, nil
+}
+
+// release forgets app, it reports whether the app was still owned by the
+// extension
+func (c *slimConnection) release(app *ownedApp) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.apps[app]; !ok {
+		return false
+	}
+	delete(c.apps, app)
+	return true
+}
+
+// ownedApp is an app created by the extension, destroyed at most once
+type ownedApp struct {
+	slimcommon.App
+	owner *slimConnection
+}
+
+// Destroy implements slimcommon.App, it does nothing if the app was
+// already destroyed, e.g. by the shutdown of the extension
+func (a *ownedApp) Destroy() {
+	if a.owner.release(a) {
+		a.App.Destroy()
+	}
+}
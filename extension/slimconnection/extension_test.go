@@ -0,0 +1,128 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimconnection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/extension"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// extensionsHost is a component.Host holding extensions
+type extensionsHost map[component.ID]component.Component
+
+func (h extensionsHost) GetExtensions() map[component.ID]component.Component {
+	return h
+}
+
+// newTestConnection creates the extension with fake connections and apps,
+// the apps created are returned by the apps function
+func newTestConnection(t *testing.T) (*slimConnection, *testutil.FakeConnector, func() []*testutil.FakeApp) {
+	t.Helper()
+	cfg := &Config{
+		ConnectionConfig: &slimconfig.ConnectionConfig{Address: "http://localhost:46357"},
+		SharedSecret:     "test-secret",
+	}
+	connector := testutil.NewFakeConnector()
+	c := newSlimConnection(t.Context(), cfg, connector)
+
+	var apps []*testutil.FakeApp
+	c.createApp = func(
+		_ string, identity slimconfig.IdentityConfig, _ uint64, _ slim.Direction,
+	) (slimcommon.App, error) {
+		assert.Equal(t, cfg.identity(), identity)
+		app := testutil.NewFakeApp()
+		apps = append(apps, app)
+		return app, nil
+	}
+	return c, connector, func() []*testutil.FakeApp { return apps }
+}
+
+func TestFactory_CreateExtension(t *testing.T) {
+	f := NewFactory()
+	assert.Equal(t, component.MustNewType(TypeStr), f.Type())
+
+	set := extension.Settings{
+		ID:                component.MustNewID(TypeStr),
+		TelemetrySettings: componenttest.NewNopTelemetrySettings(),
+	}
+
+	_, err := f.Create(t.Context(), set, f.CreateDefaultConfig())
+	require.ErrorContains(t, err, "invalid config")
+
+	ext, err := f.Create(t.Context(), set, &Config{
+		ConnectionConfig: &slimconfig.ConnectionConfig{Address: "http://localhost:46357"},
+		SharedSecret:     "test-secret",
+	})
+	require.NoError(t, err)
+	assert.Implements(t, (*Connection)(nil), ext)
+}
+
+func TestSlimConnection_Lifecycle(t *testing.T) {
+	c, connector, apps := newTestConnection(t)
+
+	_, err := c.CreateApp("agntcy/otel/exporter", slim.DirectionSend)
+	require.ErrorContains(t, err, "not started")
+
+	require.NoError(t, c.Start(t.Context(), componenttest.NewNopHost()))
+	assert.True(t, connector.Connected("http://localhost:46357"))
+	assert.Equal(t, uint64(1), c.ConnID())
+	assert.Equal(t, "http://localhost:46357", c.Address())
+
+	exporterApp, err := c.CreateApp("agntcy/otel/exporter", slim.DirectionSend)
+	require.NoError(t, err)
+	_, err = c.CreateApp("agntcy/otel/receiver", slim.DirectionRecv)
+	require.NoError(t, err)
+	require.Len(t, apps(), 2)
+
+	// the apps destroyed by their component are not destroyed again
+	exporterApp.Destroy()
+	assert.True(t, apps()[0].Destroyed())
+	assert.False(t, apps()[1].Destroyed())
+
+	require.NoError(t, c.Shutdown(t.Context()))
+	assert.True(t, apps()[1].Destroyed())
+	exporterApp.Destroy()
+
+	_, err = c.CreateApp("agntcy/otel/exporter", slim.DirectionSend)
+	assert.ErrorContains(t, err, "not started")
+}
+
+func TestSlimConnection_ConnectFailure(t *testing.T) {
+	c, connector, _ := newTestConnection(t)
+	connector.SetDown("http://localhost:46357", true)
+
+	assert.Error(t, c.Start(t.Context(), componenttest.NewNopHost()))
+	_, err := c.CreateApp("agntcy/otel/exporter", slim.DirectionSend)
+	assert.ErrorContains(t, err, "not started")
+}
+
+func TestGetConnection(t *testing.T) {
+	c, _, _ := newTestConnection(t)
+	id := component.MustNewIDWithName(TypeStr, "shared")
+	other := component.MustNewID("health_check")
+	host := extensionsHost{id: c, other: struct {
+		component.StartFunc
+		component.ShutdownFunc
+	}{}}
+
+	conn, err := GetConnection(host, id)
+	require.NoError(t, err)
+	assert.Same(t, c, conn)
+
+	_, err = GetConnection(host, component.MustNewID(TypeStr))
+	assert.ErrorContains(t, err, "extension slim not found")
+
+	_, err = GetConnection(host, other)
+	assert.ErrorContains(t, err, "is not a SLIM connection")
+}
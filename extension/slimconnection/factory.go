@@ -0,0 +1,49 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimconnection
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+const (
+	// TypeStr is the type of the extension
+	TypeStr = "slim"
+
+	// The stability level of the extension
+	stability = component.StabilityLevelDevelopment
+)
+
+// NewFactory creates a factory for the SLIM connection extension
+func NewFactory() extension.Factory {
+	return extension.NewFactory(
+		component.MustNewType(TypeStr),
+		createDefaultConfig,
+		createExtension,
+		stability,
+	)
+}
+
+// createDefaultConfig creates the default configuration for the extension
+func createDefaultConfig() component.Config {
+	return &Config{}
+}
+
+// createExtension creates the extension based on the config
+func createExtension(ctx context.Context, set extension.Settings, cfg component.Config) (extension.Extension, error) {
+	extensionConfig := cfg.(*Config)
+
+	if err := extensionConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	ctx = slimcommon.InitContextWithLogger(ctx, set.Logger)
+	return newSlimConnection(ctx, extensionConfig, slimcommon.NewConnector()), nil
+}
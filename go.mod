@@ -9,6 +9,7 @@ require (
 	github.com/agntcy/slim-otel/slimconfig v0.3.1
 	github.com/stretchr/testify v1.11.1
 	go.uber.org/zap v1.27.1
+	google.golang.org/protobuf v1.36.11
 )
 
 require (
@@ -0,0 +1,220 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package e2e
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+var (
+	tracesMarshaler  ptrace.ProtoMarshaler
+	metricsMarshaler pmetric.ProtoMarshaler
+	logsMarshaler    plog.ProtoMarshaler
+)
+
+// TestFidelity tests that the data of every signal is received byte for byte
+// as it was exported
+func TestFidelity(t *testing.T) {
+	h := newHarness(t)
+
+	td := tracesFixture(0)
+	wantTraces, err := tracesMarshaler.MarshalTraces(td)
+	require.NoError(t, err)
+	md := metricsFixture()
+	wantMetrics, err := metricsMarshaler.MarshalMetrics(md)
+	require.NoError(t, err)
+	ld := logsFixture()
+	wantLogs, err := logsMarshaler.MarshalLogs(ld)
+	require.NoError(t, err)
+
+	require.NoError(t, h.traces.ConsumeTraces(t.Context(), td))
+	require.NoError(t, h.metrics.ConsumeMetrics(t.Context(), md))
+	require.NoError(t, h.logs.ConsumeLogs(t.Context(), ld))
+
+	require.Eventually(t, func() bool {
+		return len(h.tracesSink.AllTraces()) == 1 &&
+			len(h.metricsSink.AllMetrics()) == 1 &&
+			len(h.logsSink.AllLogs()) == 1
+	}, deliveryTimeout, 10*time.Millisecond)
+
+	gotTraces, err := tracesMarshaler.MarshalTraces(h.tracesSink.AllTraces()[0])
+	require.NoError(t, err)
+	assert.Equal(t, wantTraces, gotTraces)
+	gotMetrics, err := metricsMarshaler.MarshalMetrics(h.metricsSink.AllMetrics()[0])
+	require.NoError(t, err)
+	assert.Equal(t, wantMetrics, gotMetrics)
+	gotLogs, err := logsMarshaler.MarshalLogs(h.logsSink.AllLogs()[0])
+	require.NoError(t, err)
+	assert.Equal(t, wantLogs, gotLogs)
+}
+
+// TestOrdering tests that the batches exported on a channel are consumed in
+// the order they were exported
+func TestOrdering(t *testing.T) {
+	const batches = 50
+	h := newHarness(t)
+
+	for i := range batches {
+		require.NoError(t, h.traces.ConsumeTraces(t.Context(), tracesFixture(i)))
+	}
+
+	require.Eventually(t, func() bool {
+		return len(h.tracesSink.AllTraces()) == batches
+	}, deliveryTimeout, 10*time.Millisecond)
+	for i, td := range h.tracesSink.AllTraces() {
+		span := td.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+		require.Equal(t, fmt.Sprintf("span-%d", i), span.Name(), "batch %d is out of order", i)
+	}
+}
+
+// TestAcknowledgements tests that an export with acknowledgements returns
+// once the receiver consumed the data
+func TestAcknowledgements(t *testing.T) {
+	h := newHarness(t, withAcknowledgements(deliveryTimeout))
+
+	require.NoError(t, h.traces.ConsumeTraces(t.Context(), tracesFixture(0)))
+	assert.Len(t, h.tracesSink.AllTraces(), 1, "the data must be consumed before the acknowledgement")
+}
+
+// TestShutdown_Exporter tests that shutting the exporters down closes their
+// channels for the receiver, which then shuts down cleanly
+func TestShutdown_Exporter(t *testing.T) {
+	h := newHarness(t)
+	require.NoError(t, h.traces.ConsumeTraces(t.Context(), tracesFixture(0)))
+	require.Eventually(t, func() bool {
+		return len(h.tracesSink.AllTraces()) == 1
+	}, deliveryTimeout, 10*time.Millisecond)
+
+	h.shutdownExporters()
+	for _, signal := range []slimconfig.SignalType{
+		slimconfig.SignalTraces, slimconfig.SignalMetrics, slimconfig.SignalLogs,
+	} {
+		assert.Empty(t, h.network.Members(channel(signal)), "channel %s must be closed", channel(signal))
+	}
+	h.shutdownReceiver()
+}
+
+// TestShutdown_Receiver tests that the exporters keep exporting once the
+// receiver left their channels
+func TestShutdown_Receiver(t *testing.T) {
+	h := newHarness(t)
+	h.shutdownReceiver()
+
+	for _, signal := range []slimconfig.SignalType{
+		slimconfig.SignalTraces, slimconfig.SignalMetrics, slimconfig.SignalLogs,
+	} {
+		assert.Equal(t, []string{exporterName + "-" + string(signal)}, h.network.Members(channel(signal)),
+			"the receiver must leave channel %s", channel(signal))
+	}
+	require.NoError(t, h.traces.ConsumeTraces(t.Context(), tracesFixture(0)))
+	assert.Empty(t, h.tracesSink.AllTraces())
+}
+
+// tracesFixture returns two resources of spans with attributes of every
+// type, the name of the first span being span-index
+func tracesFixture(index int) ptrace.Traces {
+	td := ptrace.NewTraces()
+	for r := range 2 {
+		rs := td.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("service.name", fmt.Sprintf("e2e-service-%d", r))
+		rs.SetSchemaUrl("https://opentelemetry.io/schemas/1.26.0")
+		ss := rs.ScopeSpans().AppendEmpty()
+		ss.Scope().SetName("e2e")
+		ss.Scope().SetVersion("1.0.0")
+		for s := range 3 {
+			span := ss.Spans().AppendEmpty()
+			span.SetName(fmt.Sprintf("span-%d", index+s))
+			span.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, byte(r)})
+			span.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, byte(s)})
+			span.SetKind(ptrace.SpanKindServer)
+			span.SetStartTimestamp(pcommon.Timestamp(1_700_000_000_000_000_000))
+			span.SetEndTimestamp(pcommon.Timestamp(1_700_000_000_500_000_000))
+			span.Status().SetCode(ptrace.StatusCodeOk)
+			attrs := span.Attributes()
+			attrs.PutStr("http.method", "GET")
+			attrs.PutInt("http.status_code", 200)
+			attrs.PutDouble("e2e.ratio", 0.5)
+			attrs.PutBool("e2e.flag", true)
+			attrs.PutEmptyBytes("e2e.bytes").FromRaw([]byte{0, 1, 2, 0xff})
+			attrs.PutEmptySlice("e2e.slice").AppendEmpty().SetStr("item")
+			attrs.PutEmptyMap("e2e.map").PutStr("key", "value")
+			event := span.Events().AppendEmpty()
+			event.SetName("e2e-event")
+			event.SetTimestamp(pcommon.Timestamp(1_700_000_000_250_000_000))
+		}
+	}
+	return td
+}
+
+// metricsFixture returns a gauge, a sum and a histogram
+func metricsFixture() pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "e2e-service")
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("e2e")
+
+	gauge := sm.Metrics().AppendEmpty()
+	gauge.SetName("e2e.gauge")
+	gauge.SetUnit("1")
+	dp := gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetDoubleValue(42.5)
+	dp.SetTimestamp(pcommon.Timestamp(1_700_000_000_000_000_000))
+	dp.Attributes().PutStr("host", "e2e")
+
+	sum := sm.Metrics().AppendEmpty()
+	sum.SetName("e2e.sum")
+	sum.SetEmptySum().SetIsMonotonic(true)
+	sum.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	sdp := sum.Sum().DataPoints().AppendEmpty()
+	sdp.SetIntValue(7)
+	sdp.SetStartTimestamp(pcommon.Timestamp(1_699_999_999_000_000_000))
+	sdp.SetTimestamp(pcommon.Timestamp(1_700_000_000_000_000_000))
+
+	histogram := sm.Metrics().AppendEmpty()
+	histogram.SetName("e2e.histogram")
+	histogram.SetEmptyHistogram().SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	hdp := histogram.Histogram().DataPoints().AppendEmpty()
+	hdp.SetCount(6)
+	hdp.SetSum(21)
+	hdp.ExplicitBounds().FromRaw([]float64{1, 5})
+	hdp.BucketCounts().FromRaw([]uint64{1, 3, 2})
+	hdp.SetTimestamp(pcommon.Timestamp(1_700_000_000_000_000_000))
+	return md
+}
+
+// logsFixture returns log records with a string and a structured body
+func logsFixture() plog.Logs {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "e2e-service")
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.Scope().SetName("e2e")
+
+	record := sl.LogRecords().AppendEmpty()
+	record.Body().SetStr("e2e log record")
+	record.SetSeverityNumber(plog.SeverityNumberWarn)
+	record.SetSeverityText("WARN")
+	record.SetTimestamp(pcommon.Timestamp(1_700_000_000_000_000_000))
+	record.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+
+	structured := sl.LogRecords().AppendEmpty()
+	body := structured.Body().SetEmptyMap()
+	body.PutStr("message", "structured")
+	body.PutInt("count", 3)
+	structured.SetSeverityNumber(plog.SeverityNumberInfo)
+	structured.Attributes().PutStr("e2e.attribute", "value")
+	return ld
+}
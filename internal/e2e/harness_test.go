@@ -0,0 +1,245 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/receiver"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	"github.com/agntcy/slim-otel/exporter/slimexporter"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/receiver/slimreceiver"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+const (
+	// exporterName is the prefix of the names of the exporter apps, suffixed
+	// by the signal
+	exporterName = "agntcy/otel/e2e-exporter"
+	// receiverName is the name of the receiver app
+	receiverName = "agntcy/otel/e2e-receiver"
+	// channelPrefix is the prefix of the channels, suffixed by the signal
+	channelPrefix = "agntcy/otel/e2e-"
+
+	// deliveryTimeout is the maximum wait for the delivery of the data
+	deliveryTimeout = 10 * time.Second
+)
+
+// connectionID is the ID of the SLIM connection extension of the harness
+var connectionID = component.MustNewIDWithName("slim", "e2e")
+
+// networkConnection is a SLIM connection extension creating the apps on a
+// testutil.FakeNetwork instead of a SLIM node
+type networkConnection struct {
+	component.StartFunc
+	component.ShutdownFunc
+	network *testutil.FakeNetwork
+}
+
+func (c *networkConnection) ConnID() uint64 {
+	return 1
+}
+
+func (c *networkConnection) Address() string {
+	return "http://e2e-network:46357"
+}
+
+func (c *networkConnection) CreateApp(localID string, _ slim.Direction) (slimcommon.App, error) {
+	return c.network.NewApp(localID), nil
+}
+
+// host is the component.Host of the harness, holding the connection extension
+type host struct {
+	extensions map[component.ID]component.Component
+}
+
+func (h *host) GetExtensions() map[component.ID]component.Component {
+	return h.extensions
+}
+
+// harness runs the slim exporters of the three signals and a slim receiver
+// in process, connected by an in-memory SLIM network: each exporter creates
+// the channel of its signal and invites the receiver, which passes the data
+// to the sinks
+type harness struct {
+	t       *testing.T
+	network *testutil.FakeNetwork
+	host    *host
+
+	traces  exporter.Traces
+	metrics exporter.Metrics
+	logs    exporter.Logs
+
+	receivers     []component.Component
+	tracesSink    *consumertest.TracesSink
+	metricsSink   *consumertest.MetricsSink
+	logsSink      *consumertest.LogsSink
+	receiverShut  bool
+	exportersShut bool
+}
+
+// harnessOption customizes the configuration of the components
+type harnessOption func(ecfg *slimexporter.Config, rcfg *slimreceiver.Config)
+
+// withAcknowledgements enables the acknowledgements of the messages
+func withAcknowledgements(timeout time.Duration) harnessOption {
+	return func(ecfg *slimexporter.Config, rcfg *slimreceiver.Config) {
+		ecfg.AckTimeout = timeout
+		rcfg.Acknowledgements = true
+	}
+}
+
+// newHarness starts the receiver, then the exporters so that the receiver
+// is invited to their channels, and waits for the receiver to join them. The
+// components are shut down at the end of the test.
+func newHarness(t *testing.T, opts ...harnessOption) *harness {
+	network := testutil.NewFakeNetwork()
+	h := &harness{
+		t:       t,
+		network: network,
+		host: &host{extensions: map[component.ID]component.Component{
+			connectionID: &networkConnection{network: network},
+		}},
+		tracesSink:  &consumertest.TracesSink{},
+		metricsSink: &consumertest.MetricsSink{},
+		logsSink:    &consumertest.LogsSink{},
+	}
+
+	ecfg := newExporterConfig()
+	rcfg := newReceiverConfig()
+	for _, opt := range opts {
+		opt(ecfg, rcfg)
+	}
+
+	h.startReceiver(rcfg)
+	h.startExporters(ecfg)
+	t.Cleanup(func() {
+		h.shutdownExporters()
+		h.shutdownReceiver()
+	})
+
+	for _, signal := range []slimconfig.SignalType{
+		slimconfig.SignalTraces, slimconfig.SignalMetrics, slimconfig.SignalLogs,
+	} {
+		require.Eventually(t, func() bool {
+			return len(network.Members(channel(signal))) == 2
+		}, deliveryTimeout, 10*time.Millisecond, "the receiver must join channel %s", channel(signal))
+	}
+	return h
+}
+
+// newExporterConfig returns the configuration of the exporters, publishing
+// each signal on its own channel with the receiver as participant
+func newExporterConfig() *slimexporter.Config {
+	cfg := slimexporter.NewFactory().CreateDefaultConfig().(*slimexporter.Config)
+	cfg.Connection = &connectionID
+	tracesName := exporterName + "-traces"
+	metricsName := exporterName + "-metrics"
+	logsName := exporterName + "-logs"
+	cfg.ExporterNames = &slimconfig.SignalNames{
+		Traces:  &tracesName,
+		Metrics: &metricsName,
+		Logs:    &logsName,
+	}
+	for _, signal := range []slimconfig.SignalType{
+		slimconfig.SignalTraces, slimconfig.SignalMetrics, slimconfig.SignalLogs,
+	} {
+		cfg.Channels = append(cfg.Channels, slimexporter.ChannelsConfig{
+			ChannelName:  channel(signal),
+			Signal:       string(signal),
+			Participants: []string{receiverName},
+		})
+	}
+	return cfg
+}
+
+// newReceiverConfig returns the configuration of the receiver
+func newReceiverConfig() *slimreceiver.Config {
+	cfg := slimreceiver.NewFactory().CreateDefaultConfig().(*slimreceiver.Config)
+	cfg.Connection = &connectionID
+	cfg.ReceiverName = receiverName
+	return cfg
+}
+
+// startReceiver creates the receiver of the three signals, which share the
+// same instance, and starts it
+func (h *harness) startReceiver(cfg *slimreceiver.Config) {
+	ctx := h.t.Context()
+	factory := slimreceiver.NewFactory()
+	set := receiver.Settings{
+		ID:                component.NewIDWithName(factory.Type(), "e2e"),
+		TelemetrySettings: componenttest.NewNopTelemetrySettings(),
+	}
+
+	traces, err := factory.CreateTraces(ctx, set, cfg, h.tracesSink)
+	require.NoError(h.t, err)
+	metrics, err := factory.CreateMetrics(ctx, set, cfg, h.metricsSink)
+	require.NoError(h.t, err)
+	logs, err := factory.CreateLogs(ctx, set, cfg, h.logsSink)
+	require.NoError(h.t, err)
+
+	h.receivers = []component.Component{traces, metrics, logs}
+	for _, r := range h.receivers {
+		require.NoError(h.t, r.Start(ctx, h.host))
+	}
+}
+
+// startExporters creates the exporters of the three signals and starts them
+func (h *harness) startExporters(cfg *slimexporter.Config) {
+	ctx := h.t.Context()
+	factory := slimexporter.NewFactory()
+	set := exporter.Settings{
+		ID:                component.NewIDWithName(factory.Type(), "e2e"),
+		TelemetrySettings: componenttest.NewNopTelemetrySettings(),
+	}
+
+	var err error
+	h.traces, err = factory.CreateTraces(ctx, set, cfg)
+	require.NoError(h.t, err)
+	h.metrics, err = factory.CreateMetrics(ctx, set, cfg)
+	require.NoError(h.t, err)
+	h.logs, err = factory.CreateLogs(ctx, set, cfg)
+	require.NoError(h.t, err)
+
+	for _, e := range []component.Component{h.traces, h.metrics, h.logs} {
+		require.NoError(h.t, e.Start(ctx, h.host))
+	}
+}
+
+// shutdownExporters shuts the exporters down, once
+func (h *harness) shutdownExporters() {
+	if h.exportersShut {
+		return
+	}
+	h.exportersShut = true
+	for _, e := range []component.Component{h.traces, h.metrics, h.logs} {
+		require.NoError(h.t, e.Shutdown(context.Background()))
+	}
+}
+
+// shutdownReceiver shuts the receiver down, once
+func (h *harness) shutdownReceiver() {
+	if h.receiverShut {
+		return
+	}
+	h.receiverShut = true
+	for _, r := range h.receivers {
+		require.NoError(h.t, r.Shutdown(context.Background()))
+	}
+}
+
+// channel returns the channel of a signal
+func channel(signal slimconfig.SignalType) string {
+	return channelPrefix + string(signal)
+}
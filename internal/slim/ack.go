@@ -0,0 +1,16 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+// Acknowledgement protocol between the exporter and the receiver. When
+// acknowledgements are enabled, the exporter sets a unique message ID in the
+// metadata of every published message; once the receiver consumed the
+// message, it publishes back on the same session an empty message of type
+// PayloadTypeAck carrying the same message ID in its metadata.
+const (
+	// PayloadTypeAck is the payload type of the acknowledgement messages
+	PayloadTypeAck = "slim-otel/ack"
+	// MetadataMessageID is the message metadata key holding the message ID
+	MetadataMessageID = "slim-otel.message-id"
+)
@@ -0,0 +1,114 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"fmt"
+	"time"
+
+	slim "github.com/agntcy/slim-bindings-go"
+)
+
+// SessionPublisher is the part of a session used to publish messages, e.g.
+// by SessionsList
+type SessionPublisher interface {
+	SessionId() (uint32, error) //nolint:revive // mirrors the SLIM bindings API
+	Destination() (*slim.Name, error)
+	PublishAndWait(data []byte, payloadType *string, metadata *map[string]string) error
+}
+
+// SessionListener is the part of an app used to accept the invitations to
+// the sessions of remote participants, see ListenSessions
+type SessionListener interface {
+	// ListenForSession waits for an incoming session up to timeout
+	ListenForSession(timeout *time.Duration) (Session, error)
+}
+
+// AppLifecycle is the part of an app used to manage its own sessions and
+// release it
+type AppLifecycle interface {
+	// CreateSessionAndWait creates a new session towards destination
+	CreateSessionAndWait(config slim.SessionConfig, destination *slim.Name) (Session, error)
+	// DeleteSessionAndWait closes the session and releases its resources
+	DeleteSessionAndWait(session Session) error
+	// Destroy releases the app
+	Destroy()
+}
+
+// App is the subset of the SLIM app API used by the exporter, the receiver
+// and the channel manager. It allows the SLIM bindings to be replaced with
+// a fake implementation in unit tests, see the testutil package.
+type App interface {
+	SessionListener
+	AppLifecycle
+	// SetRoute sets the route to reach name through the given connection
+	SetRoute(name *slim.Name, connID uint64) error
+	// RemoveRoute removes the route to name through the given connection
+	RemoveRoute(name *slim.Name, connID uint64) error
+	// Subscribe subscribes the app to name through the given connection
+	Subscribe(name *slim.Name, connID uint64) error
+}
+
+// Session is the subset of the SLIM session API used by the exporter, the
+// receiver and the channel manager. The sessions of the App wrap the
+// *slim.Session of the bindings, see ErrSessionClosed.
+type Session interface {
+	SessionPublisher
+	GetMessage(timeout *time.Duration) (slim.ReceivedMessage, error)
+	InviteAndWait(participant *slim.Name) error
+	RemoveAndWait(participant *slim.Name) error
+	ParticipantsList() ([]*slim.Name, error)
+	Metadata() (map[string]string, error)
+	SessionConfig() (slim.SessionConfig, error)
+}
+
+// slimApp adapts *slim.App to the App interface
+type slimApp struct {
+	app *slim.App
+}
+
+// NewApp wraps a SLIM bindings app into the App interface
+func NewApp(app *slim.App) App {
+	return &slimApp{app: app}
+}
+
+func (a *slimApp) CreateSessionAndWait(config slim.SessionConfig, destination *slim.Name) (Session, error) {
+	session, err := a.app.CreateSessionAndWait(config, destination)
+	if err != nil {
+		return nil, err
+	}
+	return &slimSession{Session: session}, nil
+}
+
+func (a *slimApp) DeleteSessionAndWait(session Session) error {
+	s, ok := session.(*slimSession)
+	if !ok {
+		return fmt.Errorf("unsupported session type %T", session)
+	}
+	return a.app.DeleteSessionAndWait(s.Session)
+}
+
+func (a *slimApp) ListenForSession(timeout *time.Duration) (Session, error) {
+	session, err := a.app.ListenForSession(timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &slimSession{Session: session}, nil
+}
+
+func (a *slimApp) SetRoute(name *slim.Name, connID uint64) error {
+	return a.app.SetRoute(name, connID)
+}
+
+func (a *slimApp) RemoveRoute(name *slim.Name, connID uint64) error {
+	return a.app.RemoveRoute(name, connID)
+}
+
+func (a *slimApp) Subscribe(name *slim.Name, connID uint64) error {
+	return a.app.Subscribe(name, &connID)
+}
+
+func (a *slimApp) Destroy() {
+	a.app.Destroy()
+}
@@ -6,63 +6,11 @@ package slimcommon
 import (
 	"fmt"
 	"strings"
-	"sync"
 
 	slim "github.com/agntcy/slim-bindings-go"
 	"github.com/agntcy/slim-otel/slimconfig"
 )
 
-// global variables for connection management
-var (
-	// connection must be established only once
-	mutex sync.Mutex
-	// true if connection is already established
-	connected bool
-	// the connection id is the same for all the applicaions
-	connID uint64
-)
-
-// InitAndConnect initializes the connection to the SLIM server if not already established.
-//
-// This function ensures thread-safe, single initialization of the SLIM crypto subsystem
-// and establishes a connection to the SLIM server. Subsequent calls return the existing
-// connection ID.
-//
-// Args:
-//
-//	endpoint: The SLIM server endpoint address
-//
-// Returns:
-//
-//	uint64: Connection ID for the established connection
-//	error: If initialization or connection fails
-func InitAndConnect(
-	cfg slimconfig.ConnectionConfig,
-) (uint64, error) {
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	// Initialize only once
-	if !connected {
-		// Initialize crypto subsystem (idempotent, safe to call multiple times)
-		slim.InitializeWithDefaults()
-
-		// Connect to SLIM server (returns connection ID)
-		config, err := cfg.ToSlimClientConfig()
-		if err != nil {
-			return 0, fmt.Errorf("failed to convert connection config: %w", err)
-		}
-		connIDValue, err := slim.GetGlobalService().Connect(config)
-		if err != nil {
-			return 0, fmt.Errorf("failed to connect to SLIM server: %w", err)
-		}
-
-		connected = true
-		connID = connIDValue
-	}
-	return connID, nil
-}
-
 // SplitID splits an ID of form organization/namespace/application (or channel).
 //
 // Args:
@@ -81,46 +29,77 @@ func SplitID(id string) (*slim.Name, error) {
 	return slim.NewName(parts[0], parts[1], parts[2]), nil
 }
 
+// JoinID returns the canonical 'org/namespace/app-or-stream' format of a
+// name, the inverse of SplitID.
+func JoinID(name *slim.Name) string {
+	return strings.Join(name.Components(), "/")
+}
+
 // CreateApp creates a SLIM app with shared secret authentication and subscribes it to a connection.
 //
+// Args:
+//
+//	localID: Local identity string (org/namespace/app format)
+//	secret: Shared secret for authentication (min 32 chars)
+//	connID: Connection ID to subscribe to
+//	direction: Direction for the app (Send, Receive, Bidirectional or None)
+//
+// Returns:
+//
+//	App: Created and subscribed app instance
+//	error: If creation or subscription fails
+func CreateApp(
+	localID string,
+	secret string,
+	connID uint64,
+	direction slim.Direction,
+) (App, error) {
+	return CreateAppWithIdentity(localID, slimconfig.IdentityConfig{
+		Type:         "shared_secret",
+		SharedSecret: slimconfig.Opaque(secret),
+	}, connID, direction)
+}
+
+// CreateAppWithIdentity creates a SLIM app and subscribes it to a connection.
+//
 // This function:
 //   - Parses the local identity string
-//   - Creates an app with shared secret identity provider and verifier
+//   - Creates an app with the identity provider and verifier of the identity
+//     configuration (shared secret, static JWT, JWT or SPIRE)
 //   - Subscribes the app to the specified connection
 //
 // Args:
 //
 //	localID: Local identity string (org/namespace/app format)
-//	secret: Shared secret for authentication (min 32 chars)
+//	identity: Identity configuration of the app
 //	connID: Connection ID to subscribe to
 //	direction: Direction for the app (Send, Receive, Bidirectional or None)
 //
 // Returns:
 //
-//	*slim.App: Created and subscribed app instance
+//	App: Created and subscribed app instance
 //	error: If creation or subscription fails
-func CreateApp(
+func CreateAppWithIdentity(
 	localID string,
-	secret string,
+	identity slimconfig.IdentityConfig,
 	connID uint64,
 	direction slim.Direction,
-) (*slim.App, error) {
+) (App, error) {
 	appName, err := SplitID(localID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid local ID: %w", err)
 	}
 
-	identityProvider := slim.IdentityProviderConfigSharedSecret{
-		Data: secret,
-		Id:   localID,
+	identityProvider, err := identity.ToIdentityProviderConfig(localID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity provider: %w", err)
 	}
 
-	identityVerifier := slim.IdentityVerifierConfigSharedSecret{
-		Data: secret,
-		Id:   localID,
+	identityVerifier, err := identity.ToIdentityVerifierConfig(localID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity verifier: %w", err)
 	}
 
-	// this is an exporter, so should not receive any incoming data
 	app, err := slim.GetGlobalService().CreateAppWithDirection(
 		appName, identityProvider, identityVerifier, direction)
 	if err != nil {
@@ -131,5 +110,5 @@ func CreateApp(
 		app.Destroy()
 		return nil, fmt.Errorf("subscribe failed: %w", err)
 	}
-	return app, nil
+	return NewApp(app), nil
 }
@@ -0,0 +1,23 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+// MetadataComponent is the message metadata key holding the ID of the
+// collector component that published the message, e.g. slim/pipeline-a, so
+// that the messages of the pipelines of a collector sharing a channel can be
+// told apart
+const MetadataComponent = "slim-otel.component"
+
+// AddComponent stores the ID of the publishing component in the message
+// metadata
+func AddComponent(metadata map[string]string, id string) {
+	metadata[MetadataComponent] = id
+}
+
+// MessageComponent returns the ID of the publishing component stored in the
+// message metadata, and false if the message does not carry one
+func MessageComponent(metadata map[string]string) (string, bool) {
+	id, ok := metadata[MetadataComponent]
+	return id, ok && id != ""
+}
@@ -0,0 +1,24 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageComponent(t *testing.T) {
+	metadata := make(map[string]string)
+	AddComponent(metadata, "slim/pipeline-a")
+
+	id, ok := MessageComponent(metadata)
+	assert.True(t, ok)
+	assert.Equal(t, "slim/pipeline-a", id)
+
+	_, ok = MessageComponent(nil)
+	assert.False(t, ok)
+	_, ok = MessageComponent(map[string]string{MetadataComponent: ""})
+	assert.False(t, ok)
+}
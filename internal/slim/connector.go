@@ -0,0 +1,53 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"fmt"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// Connector manages the connections to the SLIM endpoints. It allows the
+// connections to be replaced with a fake implementation in unit tests.
+type Connector interface {
+	// Connect connects to the endpoint of cfg, or returns the existing
+	// connection to it
+	Connect(cfg slimconfig.ConnectionConfig) (uint64, error)
+	// Connected reports whether the connection to the endpoint at address is up
+	Connected(address string) bool
+}
+
+// slimConnector connects through the global service of the SLIM bindings
+type slimConnector struct{}
+
+// NewConnector returns a Connector backed by the SLIM bindings
+func NewConnector() Connector {
+	return slimConnector{}
+}
+
+func (slimConnector) Connect(cfg slimconfig.ConnectionConfig) (uint64, error) {
+	// idempotent, safe to call multiple times
+	slim.InitializeWithDefaults()
+
+	service := slim.GetGlobalService()
+	if id := service.GetConnectionId(cfg.Address); id != nil {
+		return *id, nil
+	}
+
+	config, err := cfg.ToSlimClientConfig()
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert connection config: %w", err)
+	}
+	id, err := service.Connect(config)
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to SLIM server %s: %w", cfg.Address, err)
+	}
+	return id, nil
+}
+
+func (slimConnector) Connected(address string) bool {
+	return slim.GetGlobalService().GetConnectionId(address) != nil
+}
@@ -0,0 +1,31 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+// Drain protocol between the channel manager and the participants of its
+// channels. Before removing a participant from a channel, the channel
+// manager publishes on the channel an empty message of type
+// PayloadTypeDrain carrying the name of the participant in its metadata, so
+// that the participant can flush the data it holds for the channel. The
+// other participants ignore the message.
+//
+// A participant draining itself, e.g. before it shuts down, publishes on
+// each channel an empty message of type PayloadTypeLeave. The channel
+// manager removes the sender of the message from the channel, the other
+// participants ignore it. On the sessions it closes instead, e.g. the
+// channels it created, it publishes a drain notification naming itself with
+// MetadataDrainClosing set, so that the other participants stop publishing
+// on the session before it is closed.
+const (
+	// PayloadTypeDrain is the payload type of the drain notifications
+	PayloadTypeDrain = "slim-otel/drain"
+	// MetadataDrainParticipant is the message metadata key holding the name
+	// of the participant being drained
+	MetadataDrainParticipant = "slim-otel.drain-participant"
+	// MetadataDrainClosing is set to "true" in the drain notifications of
+	// the sessions closed once the participant is drained
+	MetadataDrainClosing = "slim-otel.drain-closing"
+	// PayloadTypeLeave is the payload type of the requests to leave a channel
+	PayloadTypeLeave = "slim-otel/leave"
+)
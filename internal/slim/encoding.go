@@ -0,0 +1,71 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+const (
+	// EncodingProto encodes the payloads as OTLP protobuf messages, the default
+	EncodingProto = "otlp_proto"
+	// EncodingJSON encodes the payloads as OTLP/JSON, readable on the wire and
+	// produced by agents without protobuf support
+	EncodingJSON = "otlp_json"
+)
+
+// ValidateEncoding checks that encoding is empty, for the default, or one of
+// the supported encodings
+func ValidateEncoding(encoding string) error {
+	switch encoding {
+	case "", EncodingProto, EncodingJSON:
+		return nil
+	default:
+		return fmt.Errorf("invalid encoding %q, must be %s or %s", encoding, EncodingProto, EncodingJSON)
+	}
+}
+
+// IsJSONPayload reports whether payload is OTLP/JSON rather than protobuf.
+// A JSON object starts with '{', which no OTLP protobuf message starts with:
+// it would be the start of a group, a wire type the OTLP messages do not use.
+func IsJSONPayload(payload []byte) bool {
+	trimmed := bytes.TrimLeft(payload, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// JSONPayloadSignal returns the signal of an OTLP/JSON payload from its first
+// top-level field, resourceSpans, resourceMetrics or resourceLogs, and false
+// if it has none of them. The JSON unmarshalers accept the payloads of the
+// other signals, whose fields they skip, so the signal must be known first.
+func JSONPayloadSignal(payload []byte) (slimconfig.SignalType, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return slimconfig.SignalUnknown, false
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return slimconfig.SignalUnknown, false
+		}
+		// the OTLP/JSON field names are lowerCamelCase, the protobuf ones are
+		// accepted as well
+		switch token {
+		case "resourceSpans", "resource_spans":
+			return slimconfig.SignalTraces, true
+		case "resourceMetrics", "resource_metrics":
+			return slimconfig.SignalMetrics, true
+		case "resourceLogs", "resource_logs":
+			return slimconfig.SignalLogs, true
+		}
+		var skipped json.RawMessage
+		if err := decoder.Decode(&skipped); err != nil {
+			return slimconfig.SignalUnknown, false
+		}
+	}
+	return slimconfig.SignalUnknown, false
+}
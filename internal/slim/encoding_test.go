@@ -0,0 +1,44 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestValidateEncoding(t *testing.T) {
+	require.NoError(t, ValidateEncoding(""))
+	require.NoError(t, ValidateEncoding(EncodingProto))
+	require.NoError(t, ValidateEncoding(EncodingJSON))
+	assert.ErrorContains(t, ValidateEncoding("otlp_xml"), `invalid encoding "otlp_xml"`)
+}
+
+func TestIsJSONPayload(t *testing.T) {
+	// a TracesData with an empty ResourceSpans, field 1 of wire type 2
+	assert.False(t, IsJSONPayload([]byte{0x0a, 0x00}))
+	assert.True(t, IsJSONPayload([]byte(`{"resourceSpans":[{"scopeSpans":[]}]}`)))
+	assert.True(t, IsJSONPayload([]byte("\n {}")))
+	assert.False(t, IsJSONPayload(nil))
+}
+
+func TestJSONPayloadSignal(t *testing.T) {
+	payload := `{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"body":{"stringValue":"log"}}]}]}]}`
+	signal, ok := JSONPayloadSignal([]byte(payload))
+	assert.True(t, ok)
+	assert.Equal(t, slimconfig.SignalLogs, signal)
+
+	signal, ok = JSONPayloadSignal([]byte(`{"extra":{"resourceSpans":[]},"resource_metrics":[]}`))
+	assert.True(t, ok, "the nested fields are skipped")
+	assert.Equal(t, slimconfig.SignalMetrics, signal)
+
+	for _, payload := range []string{`{}`, `[]`, `{"other":1}`, `{"other":{"resourceSpans"`} {
+		_, ok := JSONPayloadSignal([]byte(payload))
+		assert.False(t, ok, payload)
+	}
+}
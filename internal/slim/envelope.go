@@ -0,0 +1,219 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MetadataEnvelope is the message metadata key holding the version of the
+// envelope the payload is wrapped in, see envelope.proto. The payloads of
+// the messages without it are raw OTLP.
+const MetadataEnvelope = "slim-otel.envelope"
+
+// EnvelopeVersion is the version of the envelope schema written by the
+// exporter. The envelopes of newer versions are read as far as their fields
+// are known.
+const EnvelopeVersion = 1
+
+const (
+	// CompressionNone leaves the payload uncompressed, the default
+	CompressionNone = "none"
+	// CompressionGzip compresses the payload with gzip
+	CompressionGzip = "gzip"
+)
+
+// Field numbers of the envelope, see envelope.proto
+const (
+	envelopeFieldVersion     protowire.Number = 1
+	envelopeFieldEncoding    protowire.Number = 2
+	envelopeFieldCompression protowire.Number = 3
+	envelopeFieldProducer    protowire.Number = 4
+	envelopeFieldPayload     protowire.Number = 5
+)
+
+// Errors of the envelopes the receivers cannot read, check them with
+// errors.Is
+var (
+	// ErrMalformedEnvelope is returned for an envelope that is not a valid
+	// protobuf message or has no version
+	ErrMalformedEnvelope = errors.New("malformed envelope")
+	// ErrUnsupportedEncoding is returned for a payload encoded with an
+	// unknown encoding, e.g. by a newer exporter
+	ErrUnsupportedEncoding = errors.New("unsupported envelope encoding")
+	// ErrUnsupportedCompression is returned for a payload compressed with an
+	// unknown algorithm, e.g. by a newer exporter
+	ErrUnsupportedCompression = errors.New("unsupported envelope compression")
+)
+
+// Envelope is the versioned wrapper of an OTLP payload, telling the receivers
+// how the payload is encoded and compressed and who produced it
+type Envelope struct {
+	// Version of the envelope schema
+	Version uint32
+	// Encoding of the payload, EncodingProto or EncodingJSON
+	Encoding string
+	// Compression of the payload, CompressionNone or CompressionGzip
+	Compression string
+	// SLIM name of the exporter that produced the payload
+	Producer string
+	// Payload, uncompressed
+	Payload []byte
+}
+
+// ValidateCompression checks that compression is empty, for no compression,
+// or one of the supported algorithms
+func ValidateCompression(compression string) error {
+	switch compression {
+	case "", CompressionNone, CompressionGzip:
+		return nil
+	default:
+		return fmt.Errorf("invalid compression %q, must be %s or %s", compression, CompressionNone, CompressionGzip)
+	}
+}
+
+// AddEnvelope stores the envelope version in the message metadata, telling
+// the receivers that the payload is wrapped
+func AddEnvelope(metadata map[string]string) {
+	metadata[MetadataEnvelope] = strconv.Itoa(EnvelopeVersion)
+}
+
+// HasEnvelope reports whether the payload of the message whose metadata is
+// given is wrapped in an envelope
+func HasEnvelope(metadata map[string]string) bool {
+	_, ok := metadata[MetadataEnvelope]
+	return ok
+}
+
+// MarshalEnvelope compresses the payload of env and encodes env. The version
+// is set to EnvelopeVersion, empty encoding and compression to their
+// defaults.
+func MarshalEnvelope(env Envelope) ([]byte, error) {
+	if env.Encoding == "" {
+		env.Encoding = EncodingProto
+	}
+	if env.Compression == "" {
+		env.Compression = CompressionNone
+	}
+	payload, err := compress(env.Compression, env.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 0, len(payload)+len(env.Producer)+32)
+	b = protowire.AppendTag(b, envelopeFieldVersion, protowire.VarintType)
+	b = protowire.AppendVarint(b, EnvelopeVersion)
+	b = protowire.AppendTag(b, envelopeFieldEncoding, protowire.BytesType)
+	b = protowire.AppendString(b, env.Encoding)
+	b = protowire.AppendTag(b, envelopeFieldCompression, protowire.BytesType)
+	b = protowire.AppendString(b, env.Compression)
+	if env.Producer != "" {
+		b = protowire.AppendTag(b, envelopeFieldProducer, protowire.BytesType)
+		b = protowire.AppendString(b, env.Producer)
+	}
+	b = protowire.AppendTag(b, envelopeFieldPayload, protowire.BytesType)
+	b = protowire.AppendBytes(b, payload)
+	return b, nil
+}
+
+// UnmarshalEnvelope decodes an envelope and decompresses its payload. The
+// fields unknown to this version are skipped, so that the envelopes of newer
+// exporters are read as long as their encoding and compression are
+// supported.
+func UnmarshalEnvelope(data []byte) (Envelope, error) {
+	var env Envelope
+	var payload []byte
+	for len(data) > 0 {
+		number, wireType, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return Envelope{}, fmt.Errorf("%w: %w", ErrMalformedEnvelope, protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		switch {
+		case number == envelopeFieldVersion && wireType == protowire.VarintType:
+			var version uint64
+			version, n = protowire.ConsumeVarint(data)
+			env.Version = uint32(version)
+		case number == envelopeFieldEncoding && wireType == protowire.BytesType:
+			env.Encoding, n = protowire.ConsumeString(data)
+		case number == envelopeFieldCompression && wireType == protowire.BytesType:
+			env.Compression, n = protowire.ConsumeString(data)
+		case number == envelopeFieldProducer && wireType == protowire.BytesType:
+			env.Producer, n = protowire.ConsumeString(data)
+		case number == envelopeFieldPayload && wireType == protowire.BytesType:
+			payload, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(number, wireType, data)
+		}
+		if n < 0 {
+			return Envelope{}, fmt.Errorf("%w: %w", ErrMalformedEnvelope, protowire.ParseError(n))
+		}
+		data = data[n:]
+	}
+
+	if env.Version == 0 {
+		return Envelope{}, fmt.Errorf("%w: no version", ErrMalformedEnvelope)
+	}
+	switch env.Encoding {
+	case "", EncodingProto, EncodingJSON:
+	default:
+		return Envelope{}, fmt.Errorf("%w %q", ErrUnsupportedEncoding, env.Encoding)
+	}
+
+	var err error
+	env.Payload, err = decompress(env.Compression, payload)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return env, nil
+}
+
+// compress compresses payload with the given algorithm
+func compress(compression string, payload []byte) ([]byte, error) {
+	switch compression {
+	case "", CompressionNone:
+		return payload, nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedCompression, compression)
+	}
+}
+
+// decompress decompresses payload with the given algorithm
+func decompress(compression string, payload []byte) ([]byte, error) {
+	switch compression {
+	case "", CompressionNone:
+		return payload, nil
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedEnvelope, err)
+		}
+		defer r.Close()
+		decompressed, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedEnvelope, err)
+		}
+		return decompressed, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedCompression, compression)
+	}
+}
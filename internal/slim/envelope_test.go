@@ -0,0 +1,104 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	payload := []byte(`{"resourceSpans":[]}`)
+	for _, compression := range []string{"", CompressionNone, CompressionGzip} {
+		data, err := MarshalEnvelope(Envelope{
+			Encoding:    EncodingJSON,
+			Compression: compression,
+			Producer:    "agntcy/otel/exporter-traces",
+			Payload:     payload,
+		})
+		require.NoError(t, err)
+
+		env, err := UnmarshalEnvelope(data)
+		require.NoError(t, err, compression)
+		assert.Equal(t, uint32(EnvelopeVersion), env.Version)
+		assert.Equal(t, EncodingJSON, env.Encoding)
+		assert.Equal(t, "agntcy/otel/exporter-traces", env.Producer)
+		assert.Equal(t, payload, env.Payload)
+	}
+}
+
+func TestEnvelopeDefaults(t *testing.T) {
+	data, err := MarshalEnvelope(Envelope{Payload: []byte{0x0a, 0x00}})
+	require.NoError(t, err)
+
+	env, err := UnmarshalEnvelope(data)
+	require.NoError(t, err)
+	assert.Equal(t, EncodingProto, env.Encoding)
+	assert.Equal(t, CompressionNone, env.Compression)
+	assert.Empty(t, env.Producer)
+	assert.Equal(t, []byte{0x0a, 0x00}, env.Payload)
+}
+
+func TestEnvelopeNewerVersion(t *testing.T) {
+	// a newer exporter adds a field the receiver does not know
+	var data []byte
+	data = protowire.AppendTag(data, envelopeFieldVersion, protowire.VarintType)
+	data = protowire.AppendVarint(data, EnvelopeVersion+1)
+	data = protowire.AppendTag(data, 99, protowire.BytesType)
+	data = protowire.AppendString(data, "unknown")
+	data = protowire.AppendTag(data, envelopeFieldPayload, protowire.BytesType)
+	data = protowire.AppendBytes(data, []byte("payload"))
+
+	env, err := UnmarshalEnvelope(data)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(EnvelopeVersion+1), env.Version)
+	assert.Equal(t, []byte("payload"), env.Payload)
+}
+
+func TestEnvelopeRejected(t *testing.T) {
+	envelope := func(encoding, compression string) []byte {
+		var data []byte
+		data = protowire.AppendTag(data, envelopeFieldVersion, protowire.VarintType)
+		data = protowire.AppendVarint(data, EnvelopeVersion+1)
+		data = protowire.AppendTag(data, envelopeFieldEncoding, protowire.BytesType)
+		data = protowire.AppendString(data, encoding)
+		data = protowire.AppendTag(data, envelopeFieldCompression, protowire.BytesType)
+		data = protowire.AppendString(data, compression)
+		return data
+	}
+
+	_, err := UnmarshalEnvelope(envelope("otlp_arrow", CompressionNone))
+	require.ErrorIs(t, err, ErrUnsupportedEncoding)
+
+	_, err = UnmarshalEnvelope(envelope(EncodingProto, "zstd"))
+	require.ErrorIs(t, err, ErrUnsupportedCompression)
+
+	_, err = UnmarshalEnvelope(envelope(EncodingProto, CompressionGzip))
+	require.ErrorIs(t, err, ErrMalformedEnvelope, "payload is not gzip")
+
+	_, err = UnmarshalEnvelope([]byte{0xff})
+	require.ErrorIs(t, err, ErrMalformedEnvelope)
+
+	_, err = UnmarshalEnvelope(protowire.AppendString(
+		protowire.AppendTag(nil, envelopeFieldPayload, protowire.BytesType), "payload"))
+	require.ErrorIs(t, err, ErrMalformedEnvelope, "no version")
+}
+
+func TestEnvelopeMetadata(t *testing.T) {
+	metadata := make(map[string]string)
+	assert.False(t, HasEnvelope(metadata))
+	AddEnvelope(metadata)
+	assert.True(t, HasEnvelope(metadata))
+	assert.Equal(t, "1", metadata[MetadataEnvelope])
+}
+
+func TestValidateCompression(t *testing.T) {
+	require.NoError(t, ValidateCompression(""))
+	require.NoError(t, ValidateCompression(CompressionNone))
+	require.NoError(t, ValidateCompression(CompressionGzip))
+	require.Error(t, ValidateCompression("zstd"))
+}
@@ -0,0 +1,148 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	slim "github.com/agntcy/slim-bindings-go"
+)
+
+// Errors of the session lifecycle. The errors returned by the sessions of
+// the App wrap them, check them with errors.Is.
+var (
+	// ErrSessionClosed is returned once the session is closed or dropped
+	ErrSessionClosed = errors.New("session closed")
+	// ErrReceiveTimeout is returned by GetMessage when no message arrives in time
+	ErrReceiveTimeout = errors.New("receive timeout")
+	// ErrConnectionLost is returned when the connection to the SLIM node is lost
+	ErrConnectionLost = errors.New("connection lost")
+	// ErrPublishTimeout is returned when a publication does not complete
+	// within the publish timeout, see SetPublishTimeout
+	ErrPublishTimeout = errors.New("publish timeout")
+)
+
+// SessionError is the failure of an operation on a single session, e.g. one
+// of the publications of PublishToSessions
+type SessionError struct {
+	// Name of the session
+	Session string
+	Err     error
+}
+
+func (e *SessionError) Error() string {
+	return fmt.Sprintf("session %s: %v", e.Session, e.Err)
+}
+
+func (e *SessionError) Unwrap() error {
+	return e.Err
+}
+
+// FailedSessions returns the names of the sessions of the SessionErrors in
+// err, which may join the errors of several sessions
+func FailedSessions(err error) []string {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var names []string
+		for _, e := range joined.Unwrap() {
+			names = append(names, FailedSessions(e)...)
+		}
+		return names
+	}
+	var sessionErr *SessionError
+	if errors.As(err, &sessionErr) {
+		return []string{sessionErr.Session}
+	}
+	return nil
+}
+
+// wrapSessionError wraps the errors of the SLIM bindings with the matching
+// session lifecycle error. The bindings only tell them apart by their
+// message, so the matching is kept here.
+func wrapSessionError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "session closed"),
+		strings.Contains(msg, "session already closed or dropped"):
+		return fmt.Errorf("%w: %w", ErrSessionClosed, err)
+	case strings.Contains(msg, "receive timeout"),
+		errors.Is(err, slim.ErrSlimErrorTimeout):
+		return fmt.Errorf("%w: %w", ErrReceiveTimeout, err)
+	case strings.Contains(msg, "connection lost"),
+		strings.Contains(msg, "connection closed"),
+		strings.Contains(msg, "not connected"):
+		return fmt.Errorf("%w: %w", ErrConnectionLost, err)
+	default:
+		return err
+	}
+}
+
+// IsPermanentError reports whether the failure of an operation cannot be
+// fixed by retrying it: authentication and MLS failures, invalid names and
+// invalid configurations. Network failures, timeouts, cancellations and
+// closed sessions are transient. An error joining several errors, e.g. one
+// per session, is permanent only if all of them are.
+func IsPermanentError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, ErrSessionClosed) ||
+		errors.Is(err, ErrReceiveTimeout) ||
+		errors.Is(err, ErrConnectionLost) ||
+		errors.Is(err, ErrPublishTimeout) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := joined.Unwrap()
+		for _, e := range errs {
+			if !IsPermanentError(e) {
+				return false
+			}
+		}
+		return len(errs) > 0
+	}
+
+	switch {
+	case errors.Is(err, slim.ErrSlimErrorAuthError),
+		errors.Is(err, slim.ErrSlimErrorConfigError),
+		errors.Is(err, slim.ErrSlimErrorInvalidArgument):
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "mls") || strings.Contains(msg, "invalid name")
+}
+
+// slimSession adapts *slim.Session to the Session interface, wrapping the
+// errors of the message exchanges with the session lifecycle errors
+type slimSession struct {
+	*slim.Session
+}
+
+// PublishAndWait implements Session
+func (s *slimSession) PublishAndWait(data []byte, payloadType *string, metadata *map[string]string) error {
+	return wrapSessionError(s.Session.PublishAndWait(data, payloadType, metadata))
+}
+
+// SessionConfig implements Session
+func (s *slimSession) SessionConfig() (slim.SessionConfig, error) {
+	return s.Session.Config()
+}
+
+// GetMessage implements Session
+func (s *slimSession) GetMessage(timeout *time.Duration) (slim.ReceivedMessage, error) {
+	msg, err := s.Session.GetMessage(timeout)
+	return msg, wrapSessionError(err)
+}
@@ -0,0 +1,89 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	slim "github.com/agntcy/slim-bindings-go"
+)
+
+func TestWrapSessionError(t *testing.T) {
+	assert.NoError(t, wrapSessionError(nil))
+
+	tests := []struct {
+		msg  string
+		want error
+	}{
+		{msg: "session closed", want: ErrSessionClosed},
+		{msg: "Session already closed or dropped", want: ErrSessionClosed},
+		{msg: "receive timeout waiting for message", want: ErrReceiveTimeout},
+		{msg: "connection lost to the SLIM node", want: ErrConnectionLost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			original := errors.New(tt.msg)
+			err := wrapSessionError(original)
+			assert.ErrorIs(t, err, tt.want)
+			assert.ErrorIs(t, err, original, "the bindings error is kept")
+		})
+	}
+
+	other := errors.New("boom")
+	err := wrapSessionError(other)
+	assert.Equal(t, other, err)
+	assert.NotErrorIs(t, err, ErrSessionClosed)
+	assert.NotErrorIs(t, err, ErrReceiveTimeout)
+	assert.NotErrorIs(t, err, ErrConnectionLost)
+}
+
+func TestIsPermanentError(t *testing.T) {
+	assert.False(t, IsPermanentError(nil))
+
+	permanent := []error{
+		slim.NewSlimErrorAuthError("invalid shared secret"),
+		slim.NewSlimErrorConfigError("bad config"),
+		fmt.Errorf("publish: %w", slim.NewSlimErrorInvalidArgument("bad name")),
+		slim.NewSlimErrorSessionError("MLS commit failed"),
+	}
+	for _, err := range permanent {
+		assert.True(t, IsPermanentError(err), err.Error())
+	}
+
+	transient := []error{
+		slim.NewSlimErrorSendError("broken pipe"),
+		slim.NewSlimErrorTimeout(),
+		wrapSessionError(errors.New("session closed")),
+		wrapSessionError(errors.New("connection lost")),
+		fmt.Errorf("%w after 5s", ErrPublishTimeout),
+		fmt.Errorf("publish canceled: %w", context.Canceled),
+		errors.New("boom"),
+	}
+	for _, err := range transient {
+		assert.False(t, IsPermanentError(err), err.Error())
+	}
+
+	assert.True(t, IsPermanentError(errors.Join(permanent[0], permanent[1])))
+	assert.False(t, IsPermanentError(errors.Join(permanent[0], transient[0])),
+		"retrying helps the sessions that failed transiently")
+}
+
+func TestFailedSessions(t *testing.T) {
+	assert.Empty(t, FailedSessions(nil))
+	assert.Empty(t, FailedSessions(errors.New("boom")))
+
+	err := &SessionError{Session: "agntcy/otel/channel-1", Err: ErrPublishTimeout}
+	assert.Equal(t, "session agntcy/otel/channel-1: publish timeout", err.Error())
+	assert.ErrorIs(t, err, ErrPublishTimeout)
+	assert.Equal(t, []string{"agntcy/otel/channel-1"}, FailedSessions(err))
+
+	joined := errors.Join(err, errors.New("boom"),
+		fmt.Errorf("wrapped: %w", &SessionError{Session: "agntcy/otel/channel-2", Err: errors.New("boom")}))
+	assert.Equal(t, []string{"agntcy/otel/channel-1", "agntcy/otel/channel-2"}, FailedSessions(joined))
+}
@@ -0,0 +1,163 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// DefaultHealthCheckInterval is the default period of the connection health check
+const DefaultHealthCheckInterval = 5 * time.Second
+
+// Failover keeps an app connected to SLIM through one of several endpoints,
+// listed in order of preference. When the health check finds the connection
+// to the active endpoint down, the app is subscribed through the next
+// reachable endpoint, and the switch callback restores the state bound to
+// the connection, e.g. the routes. The sessions listener of the app keeps
+// receiving the invitations once the subscription is migrated, while the
+// sessions established through the failed endpoint are closed.
+type Failover struct {
+	connector Connector
+	endpoints []slimconfig.ConnectionConfig
+
+	mutex    sync.Mutex
+	active   int
+	connID   uint64
+	app      App
+	name     *slim.Name
+	onSwitch func(connID uint64) error
+}
+
+// NewFailover creates a Failover over the given endpoints, the first one
+// being the preferred one
+func NewFailover(connector Connector, endpoints []slimconfig.ConnectionConfig) *Failover {
+	return &Failover{
+		connector: connector,
+		endpoints: endpoints,
+	}
+}
+
+// Connect connects to the first reachable endpoint and returns the connection ID
+func (f *Failover) Connect(ctx context.Context) (uint64, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	active, connID, err := f.connectFrom(ctx, 0)
+	if err != nil {
+		return 0, err
+	}
+	f.active, f.connID = active, connID
+	return connID, nil
+}
+
+// Attach sets the app subscribed as name through the active endpoint, which
+// is migrated to the next endpoint on failure. onSwitch, if not nil, is
+// called with the new connection once the app is subscribed through it.
+func (f *Failover) Attach(app App, name *slim.Name, onSwitch func(connID uint64) error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.app = app
+	f.name = name
+	f.onSwitch = onSwitch
+}
+
+// ConnID returns the connection to the active endpoint
+func (f *Failover) ConnID() uint64 {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.connID
+}
+
+// Endpoint returns the address of the active endpoint
+func (f *Failover) Endpoint() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.endpoints[f.active].Address
+}
+
+// Run checks the connection to the active endpoint every interval, and fails
+// over when it is down, until ctx is done
+func (f *Failover) Run(ctx context.Context, interval time.Duration) {
+	logger := LoggerFromContextOrDefault(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := f.check(ctx); err != nil {
+				logger.Error("Failed to fail over to another SLIM endpoint", zap.Error(err))
+			}
+		}
+	}
+}
+
+// check fails over to the next reachable endpoint if the connection to the
+// active one is down. The active endpoint is tried last, so that a single
+// endpoint is reconnected.
+func (f *Failover) check(ctx context.Context) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	failed := f.endpoints[f.active].Address
+	if f.connector.Connected(failed) {
+		return nil
+	}
+
+	logger := LoggerFromContextOrDefault(ctx)
+	logger.Warn("Lost the connection to the SLIM endpoint", EndpointField(failed))
+
+	active, connID, err := f.connectFrom(ctx, (f.active+1)%len(f.endpoints))
+	if err != nil {
+		return err
+	}
+
+	if f.app != nil {
+		if err := f.app.Subscribe(f.name, connID); err != nil {
+			return fmt.Errorf("failed to subscribe through %s: %w", RedactedEndpoint(f.endpoints[active].Address), err)
+		}
+	}
+	f.active, f.connID = active, connID
+
+	logger.Info("Switched to another SLIM endpoint",
+		zap.String("failed_endpoint", RedactedEndpoint(failed)),
+		EndpointField(f.endpoints[active].Address))
+
+	if f.onSwitch != nil {
+		return f.onSwitch(connID)
+	}
+	return nil
+}
+
+// connectFrom connects to the first reachable endpoint, starting from first
+// and wrapping around, and returns its index and connection ID
+func (f *Failover) connectFrom(ctx context.Context, first int) (int, uint64, error) {
+	logger := LoggerFromContextOrDefault(ctx)
+
+	var errs error
+	for i := range f.endpoints {
+		idx := (first + i) % len(f.endpoints)
+		connID, err := f.connector.Connect(f.endpoints[idx])
+		if err == nil {
+			return idx, connID, nil
+		}
+		logger.Warn("Failed to connect to the SLIM endpoint",
+			EndpointField(f.endpoints[idx].Address), zap.Error(err))
+		errs = errors.Join(errs, err)
+	}
+	return 0, 0, fmt.Errorf("no SLIM endpoint reachable: %w", errs)
+}
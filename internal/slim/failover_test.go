@@ -0,0 +1,86 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestFailover(t *testing.T) {
+	endpoints := []slimconfig.ConnectionConfig{
+		{Address: "http://primary:46357"},
+		{Address: "http://backup:46357"},
+	}
+	name, err := slimcommon.SplitID("agntcy/otel/receiver")
+	require.NoError(t, err)
+
+	t.Run("connects to the first reachable endpoint", func(t *testing.T) {
+		connector := testutil.NewFakeConnector()
+		connector.SetDown(endpoints[0].Address, true)
+		failover := slimcommon.NewFailover(connector, endpoints)
+
+		connID, err := failover.Connect(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, connID, failover.ConnID())
+		assert.Equal(t, endpoints[1].Address, failover.Endpoint())
+	})
+
+	t.Run("no endpoint reachable", func(t *testing.T) {
+		connector := testutil.NewFakeConnector()
+		connector.SetDown(endpoints[0].Address, true)
+		connector.SetDown(endpoints[1].Address, true)
+
+		_, err := slimcommon.NewFailover(connector, endpoints).Connect(t.Context())
+		assert.ErrorContains(t, err, "no SLIM endpoint reachable")
+	})
+
+	t.Run("migrates the subscription on failure", func(t *testing.T) {
+		connector := testutil.NewFakeConnector()
+		app := testutil.NewFakeApp()
+		failover := slimcommon.NewFailover(connector, endpoints)
+		primaryID, err := failover.Connect(t.Context())
+		require.NoError(t, err)
+
+		var switched atomic.Uint64
+		failover.Attach(app, name, func(connID uint64) error {
+			switched.Store(connID)
+			return nil
+		})
+		go failover.Run(t.Context(), 10*time.Millisecond)
+
+		connector.SetDown(endpoints[0].Address, true)
+		require.Eventually(t, func() bool { return switched.Load() != 0 }, 5*time.Second, 10*time.Millisecond)
+
+		backupID := switched.Load()
+		assert.NotEqual(t, primaryID, backupID)
+		assert.Equal(t, backupID, failover.ConnID())
+		assert.Equal(t, endpoints[1].Address, failover.Endpoint())
+		assert.Equal(t, []uint64{backupID}, app.Subscriptions())
+	})
+
+	t.Run("reconnects a single endpoint", func(t *testing.T) {
+		connector := testutil.NewFakeConnector()
+		app := testutil.NewFakeApp()
+		failover := slimcommon.NewFailover(connector, endpoints[:1])
+		_, err := failover.Connect(t.Context())
+		require.NoError(t, err)
+		failover.Attach(app, name, nil)
+		go failover.Run(t.Context(), 10*time.Millisecond)
+
+		// the connection drops but the endpoint is reachable again
+		connector.SetDown(endpoints[0].Address, true)
+		connector.SetDown(endpoints[0].Address, false)
+		require.Eventually(t, func() bool { return len(app.Subscriptions()) == 1 }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, endpoints[0].Address, failover.Endpoint())
+	})
+}
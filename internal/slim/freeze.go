@@ -0,0 +1,73 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+// Freeze protocol between the channel manager and the participants of its
+// channels. To pause the publications on a channel, e.g. during an incident
+// of the backends downstream of its receivers, the channel manager publishes
+// on the channel an empty message of type PayloadTypeFreeze with
+// MetadataFrozen set to "true", and the same message with "false" to resume
+// them. The participants stay in the channel and the MLS group is kept. The
+// notification is published again to the participants invited while the
+// channel is frozen.
+const (
+	// PayloadTypeFreeze is the payload type of the freeze notifications
+	PayloadTypeFreeze = "slim-otel/freeze"
+	// MetadataFrozen is the message metadata key holding "true" when the
+	// channel is frozen and "false" when it is unfrozen
+	MetadataFrozen = "slim-otel.frozen"
+	// MetadataFreezeReason is the message metadata key holding the reason of
+	// the freeze given by the operator, if any
+	MetadataFreezeReason = "slim-otel.freeze-reason"
+)
+
+// SetFrozen freezes or unfreezes the session with the given id: the
+// publications skip a frozen session until it is unfrozen, and the session
+// stays in the list. It returns whether the state of the session changed,
+// false if the session is not in the list.
+func (s *SessionsList) SetFrozen(id uint32, frozen bool) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, exists := s.sessionsByID[id]; !exists {
+		return false
+	}
+	if _, was := s.frozen[id]; was == frozen {
+		return false
+	}
+	if !frozen {
+		delete(s.frozen, id)
+		return true
+	}
+	if s.frozen == nil {
+		s.frozen = make(map[uint32]struct{})
+	}
+	s.frozen[id] = struct{}{}
+	return true
+}
+
+// FreezeMetadata returns the metadata of the freeze notification of a
+// channel, reason is only sent when the channel is frozen
+func FreezeMetadata(frozen bool, reason string) map[string]string {
+	if !frozen {
+		return map[string]string{MetadataFrozen: "false"}
+	}
+	metadata := map[string]string{MetadataFrozen: "true"}
+	if reason != "" {
+		metadata[MetadataFreezeReason] = reason
+	}
+	return metadata
+}
+
+// MessageFrozen returns whether a freeze notification freezes or unfreezes
+// its channel, and false if the metadata does not carry the state
+func MessageFrozen(metadata map[string]string) (frozen, ok bool) {
+	switch metadata[MetadataFrozen] {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	default:
+		return false, false
+	}
+}
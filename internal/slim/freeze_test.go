@@ -0,0 +1,29 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageFrozen(t *testing.T) {
+	metadata := FreezeMetadata(true, "backend incident")
+	assert.Equal(t, "backend incident", metadata[MetadataFreezeReason])
+	frozen, ok := MessageFrozen(metadata)
+	assert.True(t, ok)
+	assert.True(t, frozen)
+
+	metadata = FreezeMetadata(false, "ignored")
+	assert.NotContains(t, metadata, MetadataFreezeReason)
+	frozen, ok = MessageFrozen(metadata)
+	assert.True(t, ok)
+	assert.False(t, frozen)
+
+	_, ok = MessageFrozen(nil)
+	assert.False(t, ok)
+	_, ok = MessageFrozen(map[string]string{MetadataFrozen: "yes"})
+	assert.False(t, ok)
+}
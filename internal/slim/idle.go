@@ -0,0 +1,97 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// maxIdleCheckInterval bounds the period at which the idle sessions are
+// looked for, see RunIdleReaper
+const maxIdleCheckInterval = time.Minute
+
+// Touch records activity on the session with the given id, e.g. a message
+// received or published, which postpones its reaping by ReapIdle. Publishing
+// through the list records it already.
+func (s *SessionsList) Touch(id uint32) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, exists := s.lastActivity[id]; exists {
+		s.lastActivity[id] = time.Now()
+	}
+}
+
+// ReapIdle removes the sessions without activity for longer than timeout
+// from the list and deletes them, and returns their names. The sessions keep
+// reports true for, if not nil, and the frozen sessions, which nothing is
+// published on until they are unfrozen, are left open.
+func (s *SessionsList) ReapIdle(
+	ctx context.Context,
+	app App,
+	timeout time.Duration,
+	keep func(name string) bool,
+) []string {
+	logger := LoggerFromContextOrDefault(ctx)
+	now := time.Now()
+
+	s.mutex.Lock()
+	idle := make(map[string]Session)
+	for id, last := range s.lastActivity {
+		name := s.idToName[id]
+		if now.Sub(last) <= timeout || (keep != nil && keep(name)) {
+			continue
+		}
+		if _, frozen := s.frozen[id]; frozen {
+			continue
+		}
+		idle[name] = s.sessionsByID[id]
+		s.forget(id, name)
+	}
+	s.mutex.Unlock()
+
+	// the sessions are deleted without holding the lock
+	names := make([]string, 0, len(idle))
+	for name, session := range idle {
+		logger.Info("Reaping idle session",
+			zap.String("signal", string(s.signalType)),
+			zap.String("session_name", name),
+			zap.Duration("idle_timeout", timeout))
+		if err := app.DeleteSessionAndWait(session); err != nil {
+			logger.Warn("Failed to delete idle session",
+				zap.String("session_name", name),
+				zap.Error(err))
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// RunIdleReaper reaps the idle sessions of the list with ReapIdle until ctx
+// is done, calling onReap with the name of every reaped session
+func (s *SessionsList) RunIdleReaper(
+	ctx context.Context,
+	app App,
+	timeout time.Duration,
+	keep func(name string) bool,
+	onReap func(name string),
+) {
+	ticker := time.NewTicker(max(min(timeout/2, maxIdleCheckInterval), time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, name := range s.ReapIdle(ctx, app, timeout, keep) {
+				if onReap != nil {
+					onReap(name)
+				}
+			}
+		}
+	}
+}
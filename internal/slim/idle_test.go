@@ -0,0 +1,82 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// sessionName returns the name of session in the list
+func sessionName(t *testing.T, session *testutil.FakeSession) string {
+	t.Helper()
+	name, err := session.Destination()
+	require.NoError(t, err)
+	return name.String()
+}
+
+func TestSessionsList_ReapIdle(t *testing.T) {
+	app := testutil.NewFakeApp()
+	ss := slimcommon.NewSessionsList(slimconfig.SignalTraces)
+
+	idle := testutil.NewFakeSession(1, "agntcy/otel/idle")
+	active := testutil.NewFakeSession(2, "agntcy/otel/active")
+	published := testutil.NewFakeSession(3, "agntcy/otel/published")
+	owned := testutil.NewFakeSession(4, "agntcy/otel/owned")
+	frozen := testutil.NewFakeSession(5, "agntcy/otel/frozen")
+	for _, session := range []*testutil.FakeSession{idle, active, published, owned, frozen} {
+		require.NoError(t, ss.AddSession(t.Context(), session))
+	}
+	ss.SetFrozen(5, true)
+
+	time.Sleep(60 * time.Millisecond)
+	ss.Touch(2)
+	_, _, err := ss.PublishToSessions(t.Context(), []string{sessionName(t, published)}, []byte("data"), nil)
+	require.NoError(t, err)
+	// a session removed meanwhile is not recorded again
+	ss.Touch(42)
+
+	ownedName := sessionName(t, owned)
+	keep := func(name string) bool { return name == ownedName }
+	reaped := ss.ReapIdle(t.Context(), app, 50*time.Millisecond, keep)
+
+	assert.Equal(t, []string{sessionName(t, idle)}, reaped)
+	assert.True(t, idle.Closed())
+	assert.Equal(t, []uint32{1}, app.DeletedSessions())
+	assert.ElementsMatch(t, []string{
+		sessionName(t, active), sessionName(t, published), sessionName(t, owned), sessionName(t, frozen),
+	}, ss.ListSessionNames(t.Context()))
+}
+
+func TestSessionsList_RunIdleReaper(t *testing.T) {
+	app := testutil.NewFakeApp()
+	ss := slimcommon.NewSessionsList(slimconfig.SignalLogs)
+	require.NoError(t, ss.AddSession(t.Context(), testutil.NewFakeSession(1, "agntcy/otel/idle")))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	reaped := make(chan string, 1)
+	done := make(chan struct{})
+	go func() {
+		ss.RunIdleReaper(ctx, app, 20*time.Millisecond, nil, func(name string) { reaped <- name })
+		close(done)
+	}()
+
+	select {
+	case name := <-reaped:
+		assert.Equal(t, sessionName(t, testutil.NewFakeSession(1, "agntcy/otel/idle")), name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the idle session was not reaped")
+	}
+	cancel()
+	<-done
+	assert.Empty(t, ss.ListSessions(t.Context()))
+}
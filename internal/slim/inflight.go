@@ -0,0 +1,100 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// InFlightWaitObserver is notified of the time a publication waited for an
+// in-flight slot of its session, see SetMaxInFlightPerSession. It may be
+// called concurrently for different sessions.
+type InFlightWaitObserver func(sessionName string, wait time.Duration)
+
+// inFlightLimiter bounds the number of concurrent publications to each
+// session. A nil inFlightLimiter does not bound them.
+type inFlightLimiter struct {
+	limit    int
+	observer InFlightWaitObserver
+
+	mutex sync.Mutex
+	// map of session ID to the semaphore of its in-flight publications
+	slots map[uint32]chan struct{}
+}
+
+// newInFlightLimiter creates an inFlightLimiter allowing limit concurrent
+// publications per session
+func newInFlightLimiter(limit int, observer InFlightWaitObserver) *inFlightLimiter {
+	return &inFlightLimiter{
+		limit:    limit,
+		observer: observer,
+		slots:    make(map[uint32]chan struct{}),
+	}
+}
+
+// SetMaxInFlightPerSession bounds the number of publications in progress on
+// each session to limit, across concurrent calls of PublishToSessions, so
+// that a slow session does not accumulate unbounded publications. The
+// publications over the limit wait for a slot until their context is done,
+// and observer, if not nil, is notified of the time they waited. A limit
+// lower than 1 removes the bound.
+func (s *SessionsList) SetMaxInFlightPerSession(limit int, observer InFlightWaitObserver) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if limit < 1 {
+		s.inFlight = nil
+		return
+	}
+	s.inFlight = newInFlightLimiter(limit, observer)
+}
+
+// acquire waits for an in-flight slot of the session with the given id and
+// returns the function releasing it, or an error if ctx is done first
+func (l *inFlightLimiter) acquire(ctx context.Context, id uint32, name string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	l.mutex.Lock()
+	slots, ok := l.slots[id]
+	if !ok {
+		slots = make(chan struct{}, l.limit)
+		l.slots[id] = slots
+	}
+	l.mutex.Unlock()
+
+	start := time.Now()
+	var err error
+	select {
+	case slots <- struct{}{}:
+	default:
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			err = fmt.Errorf("waiting for an in-flight publication slot: %w", ctx.Err())
+		}
+	}
+	if l.observer != nil {
+		l.observer(name, time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return func() { <-slots }, nil
+}
+
+// forget drops the semaphore of a removed session, the publications holding
+// one of its slots release it as usual
+func (l *inFlightLimiter) forget(id uint32) {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	delete(l.slots, id)
+}
@@ -0,0 +1,102 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// TestSessionsList_MaxInFlightPerSession tests that the publications to a
+// slow session are bounded across concurrent calls
+func TestSessionsList_MaxInFlightPerSession(t *testing.T) {
+	ss := slimcommon.NewSessionsList(slimconfig.SignalTraces)
+
+	var observed atomic.Int32
+	ss.SetMaxInFlightPerSession(1, func(sessionName string, wait time.Duration) {
+		assert.Equal(t, "agntcy/otel/slow", sessionName)
+		assert.GreaterOrEqual(t, wait, time.Duration(0))
+		observed.Add(1)
+	})
+
+	release := make(chan struct{})
+	var inFlight, maxInFlight atomic.Int32
+	slow := testutil.NewFakeSession(1, "agntcy/otel/slow")
+	slow.OnPublish = func(slim.ReceivedMessage) {
+		current := inFlight.Add(1)
+		for {
+			previous := maxInFlight.Load()
+			if current <= previous || maxInFlight.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		<-release
+		inFlight.Add(-1)
+	}
+	require.NoError(t, ss.AddSession(t.Context(), slow))
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Go(func() {
+			_, _, err := ss.PublishToAllWithMetadata(t.Context(), []byte("data"), nil)
+			assert.NoError(t, err)
+		})
+	}
+
+	require.Eventually(t, func() bool {
+		return inFlight.Load() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), maxInFlight.Load(), "only one publication may be in flight")
+	assert.Len(t, slow.PublishedMessages(), 3)
+	assert.Equal(t, int32(3), observed.Load(), "the wait of every publication is observed")
+}
+
+// TestSessionsList_MaxInFlightPerSession_Canceled tests that a publication
+// waiting for a slot fails when its context is done
+func TestSessionsList_MaxInFlightPerSession_Canceled(t *testing.T) {
+	ss := slimcommon.NewSessionsList(slimconfig.SignalLogs)
+	ss.SetMaxInFlightPerSession(1, nil)
+
+	release := make(chan struct{})
+	defer close(release)
+	slow := testutil.NewFakeSession(1, "agntcy/otel/slow")
+	slow.OnPublish = func(slim.ReceivedMessage) { <-release }
+	require.NoError(t, ss.AddSession(t.Context(), slow))
+
+	go func() {
+		_, _, _ = ss.PublishToAllWithMetadata(t.Context(), []byte("data"), nil)
+	}()
+	require.Eventually(t, func() bool {
+		return len(slow.PublishedMessages()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	published, _, err := ss.PublishToAllWithMetadata(ctx, []byte("data"), nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, published)
+
+	// no bound
+	ss.SetMaxInFlightPerSession(0, nil)
+	go func() {
+		_, _, _ = ss.PublishToAllWithMetadata(t.Context(), []byte("data"), nil)
+	}()
+	require.Eventually(t, func() bool {
+		return len(slow.PublishedMessages()) == 2
+	}, 5*time.Second, 10*time.Millisecond, "the publication must not wait for a slot")
+}
@@ -0,0 +1,26 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import "fmt"
+
+// MetadataInitiator is the session metadata key holding the name of the
+// participant that created a group session, e.g. the channel manager, so
+// that the invited participants can check who invited them. The participant
+// list of a group session cannot be used instead: any participant invited
+// to the channel is listed. The value is set by the initiator itself and is
+// not authenticated by SLIM, which does not expose the identity of the
+// inviter of a session: a check against it is advisory, not a security
+// control.
+const MetadataInitiator = "slim-otel.initiator"
+
+// SessionInitiator returns the name of the participant that created the
+// session, as advertised in the session metadata, empty if not advertised
+func SessionInitiator(session Session) (string, error) {
+	metadata, err := session.Metadata()
+	if err != nil {
+		return "", fmt.Errorf("failed to get session metadata: %w", err)
+	}
+	return metadata[MetadataInitiator], nil
+}
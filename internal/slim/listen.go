@@ -0,0 +1,72 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+)
+
+// streamPollInterval bounds the calls to the SLIM bindings made by the
+// session and message streams, which cannot be canceled: a stream ends at
+// most streamPollInterval after its context is done
+const streamPollInterval = time.Second
+
+// ListenSessions delivers the sessions app is invited to on the returned
+// channel until ctx is done. The channel is closed once the pending call to
+// the bindings returned: a session received meanwhile is still delivered, so
+// the channel must be read until it is closed.
+func ListenSessions(ctx context.Context, app SessionListener) <-chan Session {
+	sessions := make(chan Session)
+	go func() {
+		defer close(sessions)
+		timeout := streamPollInterval
+		for ctx.Err() == nil {
+			session, err := app.ListenForSession(&timeout)
+			if err != nil {
+				// no invitation within the timeout
+				continue
+			}
+			sessions <- session
+		}
+	}()
+	return sessions
+}
+
+// ReceiveMessages delivers the messages received on session on the returned
+// channel, which is closed once the session is closed or ctx is done. The
+// receive errors other than timeouts are logged. ctx must be canceled when
+// the channel is no longer read.
+func ReceiveMessages(ctx context.Context, session Session) <-chan slim.ReceivedMessage {
+	messages := make(chan slim.ReceivedMessage)
+	go func() {
+		defer close(messages)
+		logger := LoggerFromContextOrDefault(ctx)
+		timeout := streamPollInterval
+		for ctx.Err() == nil {
+			msg, err := session.GetMessage(&timeout)
+			switch {
+			case errors.Is(err, ErrSessionClosed):
+				return
+			case errors.Is(err, ErrReceiveTimeout):
+				continue
+			case err != nil:
+				logger.Error("Error getting message", zap.Error(err))
+				continue
+			}
+
+			select {
+			case messages <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return messages
+}
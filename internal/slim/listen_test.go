@@ -0,0 +1,77 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+)
+
+func TestListenSessions(t *testing.T) {
+	app := testutil.NewFakeApp()
+	ctx, cancel := context.WithCancel(t.Context())
+	sessions := slimcommon.ListenSessions(ctx, app)
+
+	invited := testutil.NewFakeSession(1, "agntcy/otel/channel")
+	app.Invite(invited)
+	select {
+	case session := <-sessions:
+		assert.Same(t, invited, session)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the session was not delivered")
+	}
+
+	// the channel is closed once the pending listen returns
+	cancel()
+	select {
+	case _, ok := <-sessions:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the channel was not closed")
+	}
+}
+
+func TestReceiveMessages(t *testing.T) {
+	t.Run("closed session", func(t *testing.T) {
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel")
+		messages := slimcommon.ReceiveMessages(t.Context(), session)
+
+		session.Deliver([]byte("first"))
+		session.Deliver([]byte("second"))
+		session.Close()
+
+		var payloads []string
+		for msg := range messages {
+			payloads = append(payloads, string(msg.Payload))
+		}
+		assert.Equal(t, []string{"first", "second"}, payloads)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel")
+		ctx, cancel := context.WithCancel(t.Context())
+		messages := slimcommon.ReceiveMessages(ctx, session)
+
+		// the stream does not wait for the unread message once canceled
+		session.Deliver([]byte("unread"))
+		cancel()
+
+		require.Eventually(t, func() bool {
+			select {
+			case _, ok := <-messages:
+				return !ok
+			default:
+				return false
+			}
+		}, 5*time.Second, 10*time.Millisecond, "the stream did not end")
+		assert.False(t, session.Closed())
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// Session metadata keys used to advertise the channel policy to the participants
+const (
+	MetadataMaxMessageSize = "slim-otel.max-message-size"
+	MetadataMaxMessageRate = "slim-otel.max-message-rate"
+	MetadataLogSeverity    = "slim-otel.default-log-severity"
+	MetadataSignals        = "slim-otel.signals"
+)
+
+// ChannelPolicy holds the limits of a channel. The channel manager advertises
+// them in the session metadata, exporters enforce them when publishing and
+// receivers validate the received messages against them. Zero values mean
+// no limit.
+type ChannelPolicy struct {
+	// Maximum size in bytes of a message published on the channel
+	MaxMessageSize int
+	// Maximum number of messages per second published on the channel by each exporter
+	MaxMessageRate float64
+	// Severity applied by the receivers to the log records without severity,
+	// see ParseLogSeverity for the valid names
+	DefaultLogSeverity string
+	// Signals carried by the channel, any signal if empty
+	Signals []slimconfig.SignalType
+}
+
+// IsZero reports whether the policy sets nothing
+func (p ChannelPolicy) IsZero() bool {
+	return p.MaxMessageSize <= 0 && p.MaxMessageRate <= 0 && p.DefaultLogSeverity == "" && len(p.Signals) == 0
+}
+
+// CarriesSignal reports whether the channel carries the signal
+func (p ChannelPolicy) CarriesSignal(signal slimconfig.SignalType) bool {
+	return len(p.Signals) == 0 || slices.Contains(p.Signals, signal)
+}
+
+// AddToMetadata stores the policy limits in the session metadata
+func (p ChannelPolicy) AddToMetadata(metadata map[string]string) {
+	if p.MaxMessageSize > 0 {
+		metadata[MetadataMaxMessageSize] = strconv.Itoa(p.MaxMessageSize)
+	}
+	if p.MaxMessageRate > 0 {
+		metadata[MetadataMaxMessageRate] = strconv.FormatFloat(p.MaxMessageRate, 'f', -1, 64)
+	}
+	if p.DefaultLogSeverity != "" {
+		metadata[MetadataLogSeverity] = p.DefaultLogSeverity
+	}
+	if len(p.Signals) > 0 {
+		names := make([]string, 0, len(p.Signals))
+		for _, signal := range p.Signals {
+			names = append(names, string(signal))
+		}
+		metadata[MetadataSignals] = strings.Join(names, ",")
+	}
+}
+
+// PolicyFromMetadata reads the channel policy from the session metadata.
+// Missing keys leave the corresponding limit unset.
+func PolicyFromMetadata(metadata map[string]string) (ChannelPolicy, error) {
+	var policy ChannelPolicy
+
+	if value, ok := metadata[MetadataMaxMessageSize]; ok {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 0 {
+			return ChannelPolicy{}, fmt.Errorf("invalid %s value: %s", MetadataMaxMessageSize, value)
+		}
+		policy.MaxMessageSize = size
+	}
+
+	if value, ok := metadata[MetadataMaxMessageRate]; ok {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 {
+			return ChannelPolicy{}, fmt.Errorf("invalid %s value: %s", MetadataMaxMessageRate, value)
+		}
+		policy.MaxMessageRate = rate
+	}
+
+	if value, ok := metadata[MetadataLogSeverity]; ok {
+		if _, err := ParseLogSeverity(value); err != nil {
+			return ChannelPolicy{}, fmt.Errorf("invalid %s value: %s", MetadataLogSeverity, value)
+		}
+		policy.DefaultLogSeverity = value
+	}
+
+	if value, ok := metadata[MetadataSignals]; ok && value != "" {
+		for _, name := range strings.Split(value, ",") {
+			signal, err := ParseSignal(name)
+			if err != nil {
+				return ChannelPolicy{}, fmt.Errorf("invalid %s value: %s", MetadataSignals, value)
+			}
+			policy.Signals = append(policy.Signals, signal)
+		}
+	}
+
+	return policy, nil
+}
+
+// SessionPolicy returns the channel policy advertised in the session metadata
+func SessionPolicy(session Session) (ChannelPolicy, error) {
+	metadata, err := session.Metadata()
+	if err != nil {
+		return ChannelPolicy{}, fmt.Errorf("failed to get session metadata: %w", err)
+	}
+	return PolicyFromMetadata(metadata)
+}
@@ -0,0 +1,68 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// TestChannelPolicy_Metadata tests the round trip of a policy through the session metadata
+func TestChannelPolicy_Metadata(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		policy := ChannelPolicy{
+			MaxMessageSize:     4096,
+			MaxMessageRate:     2.5,
+			DefaultLogSeverity: "WARN",
+			Signals:            []slimconfig.SignalType{slimconfig.SignalTraces, slimconfig.SignalLogs},
+		}
+		metadata := make(map[string]string)
+		policy.AddToMetadata(metadata)
+
+		assert.Equal(t, "4096", metadata[MetadataMaxMessageSize])
+		assert.Equal(t, "2.5", metadata[MetadataMaxMessageRate])
+		assert.Equal(t, "WARN", metadata[MetadataLogSeverity])
+		assert.Equal(t, "traces,logs", metadata[MetadataSignals])
+
+		parsed, err := PolicyFromMetadata(metadata)
+		require.NoError(t, err)
+		assert.Equal(t, policy, parsed)
+	})
+
+	t.Run("no limits", func(t *testing.T) {
+		metadata := make(map[string]string)
+		ChannelPolicy{}.AddToMetadata(metadata)
+		assert.Empty(t, metadata)
+
+		parsed, err := PolicyFromMetadata(nil)
+		require.NoError(t, err)
+		assert.True(t, parsed.IsZero())
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		_, err := PolicyFromMetadata(map[string]string{MetadataMaxMessageSize: "big"})
+		require.Error(t, err)
+
+		_, err = PolicyFromMetadata(map[string]string{MetadataMaxMessageRate: "-1"})
+		require.Error(t, err)
+
+		_, err = PolicyFromMetadata(map[string]string{MetadataLogSeverity: "LOUD"})
+		require.Error(t, err)
+
+		_, err = PolicyFromMetadata(map[string]string{MetadataSignals: "traces,profiles"})
+		require.Error(t, err)
+	})
+}
+
+func TestChannelPolicy_CarriesSignal(t *testing.T) {
+	assert.True(t, ChannelPolicy{}.CarriesSignal(slimconfig.SignalMetrics), "any signal by default")
+
+	policy := ChannelPolicy{Signals: []slimconfig.SignalType{slimconfig.SignalTraces}}
+	assert.True(t, policy.CarriesSignal(slimconfig.SignalTraces))
+	assert.False(t, policy.CarriesSignal(slimconfig.SignalMetrics))
+}
@@ -0,0 +1,61 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SetPublishTimeout bounds the time a publication to a session may take, so
+// that a hung session does not block the publications. A publication that
+// does not complete in time fails with ErrPublishTimeout, and one whose
+// context is done first fails with the error of the context. A timeout
+// lower than or equal to zero only bounds the publications by their context.
+func (s *SessionsList) SetPublishTimeout(timeout time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.publishTimeout = max(timeout, 0)
+}
+
+// publishAndWait publishes data to session until it completes, timeout
+// elapses or ctx is done. The SLIM bindings cannot cancel a publication, so
+// an abandoned publication completes in the background and release is only
+// called once it did, keeping its in-flight slot until then.
+func publishAndWait(
+	ctx context.Context,
+	session Session,
+	data []byte,
+	metadata *map[string]string,
+	timeout time.Duration,
+	release func(),
+) error {
+	if err := ctx.Err(); err != nil {
+		release()
+		return fmt.Errorf("publish canceled: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer release()
+		done <- session.PublishAndWait(data, nil, metadata)
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-expired:
+		return fmt.Errorf("%w after %s", ErrPublishTimeout, timeout)
+	case <-ctx.Done():
+		return fmt.Errorf("publish canceled: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// TestSessionsList_PublishTimeout tests that a hung session fails its
+// publication after the publish timeout without delaying the others
+func TestSessionsList_PublishTimeout(t *testing.T) {
+	ss := slimcommon.NewSessionsList(slimconfig.SignalTraces)
+	ss.SetPublishTimeout(50 * time.Millisecond)
+
+	release := make(chan struct{})
+	defer close(release)
+	hung := testutil.NewFakeSession(1, "agntcy/otel/hung")
+	hung.OnPublish = func(slim.ReceivedMessage) { <-release }
+	other := testutil.NewFakeSession(2, "agntcy/otel/other")
+	for _, session := range []*testutil.FakeSession{hung, other} {
+		require.NoError(t, ss.AddSession(t.Context(), session))
+	}
+
+	published, closed, err := ss.PublishToAllWithMetadata(t.Context(), []byte("data"), nil)
+	require.ErrorIs(t, err, slimcommon.ErrPublishTimeout)
+	assert.Contains(t, err.Error(), "agntcy/otel/hung")
+	assert.False(t, slimcommon.IsPermanentError(err), "the export is retried")
+	assert.Equal(t, []uint32{2}, published)
+	assert.Empty(t, closed)
+}
+
+// TestSessionsList_PublishCanceled tests that a publication stops waiting
+// for a hung session when its context is done, without counting it as a
+// failure of the session
+func TestSessionsList_PublishCanceled(t *testing.T) {
+	ss := slimcommon.NewSessionsList(slimconfig.SignalLogs)
+	ss.SetEvictionPolicy(1, nil)
+
+	release := make(chan struct{})
+	defer close(release)
+	hung := testutil.NewFakeSession(1, "agntcy/otel/hung")
+	hung.OnPublish = func(slim.ReceivedMessage) { <-release }
+	require.NoError(t, ss.AddSession(t.Context(), hung))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	published, closed, err := ss.PublishToAllWithMetadata(ctx, []byte("data"), nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, published)
+	assert.Empty(t, closed, "the session is not evicted")
+
+	// a context already done does not publish
+	canceled, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, _, err = ss.PublishToAllWithMetadata(canceled, []byte("data"), nil)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, hung.PublishedMessages(), 1)
+}
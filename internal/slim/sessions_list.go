@@ -5,47 +5,90 @@ package slimcommon
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"maps"
-	"strings"
+	"slices"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
-	slim "github.com/agntcy/slim-bindings-go"
 	"github.com/agntcy/slim-otel/slimconfig"
 )
 
+// ErrSessionExists is returned by AddSession when a session with the same
+// id or name is already in the list
+var ErrSessionExists = errors.New("already exists")
+
+// DefaultPublishConcurrency is the default maximum number of sessions a
+// message is published to concurrently
+const DefaultPublishConcurrency = 8
+
+// PublishObserver is notified of the outcome of every publication to a
+// session by PublishToAll. err is nil if the message was published. It may be
+// called concurrently for different sessions.
+type PublishObserver func(sessionName string, size int, err error)
+
 // SessionsList holds sessions related to a specific signal type
 type SessionsList struct {
 	mutex      sync.RWMutex
 	signalType slimconfig.SignalType
 	// map of session ID to Session
-	sessionsByID map[uint32]*slim.Session
+	sessionsByID map[uint32]Session
 	// map of session Name to Session
 	// used to check if there are duplicate sessions by name
-	sessionsByName map[string]*slim.Session
+	sessionsByName map[string]Session
 	// map of session ID to session name. Use this to get session name when session is closed
 	idToName map[uint32]string
+	// map of session ID to the time of its last activity, see Touch
+	lastActivity map[uint32]time.Time
+	// map of session ID to its publication statistics, see Snapshot
+	stats map[uint32]*sessionStats
+	// map of session name to the metadata added to the messages published
+	// on that session, see SetSessionMetadata
+	sessionMetadata map[string]map[string]string
+	// optional observer of the publications, see SetPublishObserver
+	observer PublishObserver
+	// maximum number of sessions published to concurrently
+	concurrency int
+	// consecutive publish failures after which a session is evicted, zero
+	// disables the eviction, see SetEvictionPolicy
+	maxFailures int
+	// optional handler of the evicted sessions
+	onEvict EvictionHandler
+	// optional bound of the publications in progress on each session, see
+	// SetMaxInFlightPerSession
+	inFlight *inFlightLimiter
+	// IDs of the frozen sessions, skipped by the publications, see SetFrozen
+	frozen map[uint32]struct{}
+	// maximum duration of a publication to a session, zero does not bound
+	// it, see SetPublishTimeout
+	publishTimeout time.Duration
 }
 
 // NewSessionsList creates a new SessionsList instance
 func NewSessionsList(signalType slimconfig.SignalType) *SessionsList {
 	return &SessionsList{
 		signalType:     signalType,
-		sessionsByID:   make(map[uint32]*slim.Session),
-		sessionsByName: make(map[string]*slim.Session),
+		sessionsByID:   make(map[uint32]Session),
+		sessionsByName: make(map[string]Session),
 		idToName:       make(map[uint32]string),
+		lastActivity:   make(map[uint32]time.Time),
+		stats:          make(map[uint32]*sessionStats),
+		concurrency:    DefaultPublishConcurrency,
 	}
 }
 
-func (s *SessionsList) AddSession(_ context.Context, session *slim.Session) error {
+func (s *SessionsList) AddSession(_ context.Context, session Session) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	if s.sessionsByID == nil {
-		s.sessionsByID = make(map[uint32]*slim.Session)
-		s.sessionsByName = make(map[string]*slim.Session)
+		s.sessionsByID = make(map[uint32]Session)
+		s.sessionsByName = make(map[string]Session)
 		s.idToName = make(map[uint32]string)
+		s.lastActivity = make(map[uint32]time.Time)
+		s.stats = make(map[uint32]*sessionStats)
 	}
 	id, err := session.SessionId()
 	if err != nil {
@@ -58,19 +101,21 @@ func (s *SessionsList) AddSession(_ context.Context, session *slim.Session) erro
 	}
 	// check if session with the same id or name already exists
 	if _, exists := s.sessionsByID[id]; exists {
-		return fmt.Errorf("session with id %d already exists", id)
+		return fmt.Errorf("session with id %d %w", id, ErrSessionExists)
 	}
 	if _, exists := s.sessionsByName[name.String()]; exists {
-		return fmt.Errorf("session with name %s already exists", name)
+		return fmt.Errorf("session with name %s %w", name, ErrSessionExists)
 	}
 	s.sessionsByID[id] = session
 	s.sessionsByName[name.String()] = session
 	s.idToName[id] = name.String()
+	s.lastActivity[id] = time.Now()
+	s.stats[id] = &sessionStats{created: s.lastActivity[id]}
 
 	return nil
 }
 
-func (s *SessionsList) GetSessionByID(_ context.Context, id uint32) (*slim.Session, error) {
+func (s *SessionsList) GetSessionByID(_ context.Context, id uint32) (Session, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 	if s.sessionsByID == nil {
@@ -84,7 +129,7 @@ func (s *SessionsList) GetSessionByID(_ context.Context, id uint32) (*slim.Sessi
 	return session, nil
 }
 
-func (s *SessionsList) GetSessionByName(_ context.Context, name string) (*slim.Session, error) {
+func (s *SessionsList) GetSessionByName(_ context.Context, name string) (Session, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 	if s.sessionsByName == nil {
@@ -98,7 +143,19 @@ func (s *SessionsList) GetSessionByName(_ context.Context, name string) (*slim.S
 	return session, nil
 }
 
-func (s *SessionsList) RemoveSessionByID(_ context.Context, id uint32) (*slim.Session, error) {
+// GetSessionName returns the name of the session with the given id
+func (s *SessionsList) GetSessionName(_ context.Context, id uint32) (string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	name, exists := s.idToName[id]
+	if !exists {
+		return "", fmt.Errorf("session with id %d not found", id)
+	}
+	return name, nil
+}
+
+func (s *SessionsList) RemoveSessionByID(_ context.Context, id uint32) (Session, error) {
 	session, err := s.GetSessionByID(context.Background(), id)
 	if err != nil {
 		return nil, err
@@ -114,13 +171,11 @@ func (s *SessionsList) RemoveSessionByID(_ context.Context, id uint32) (*slim.Se
 		return nil, fmt.Errorf("session name not found for id %d", id)
 	}
 
-	delete(s.sessionsByID, id)
-	delete(s.sessionsByName, name)
-	delete(s.idToName, id)
+	s.forget(id, name)
 	return session, nil
 }
 
-func (s *SessionsList) RemoveSessionByName(_ context.Context, name string) (*slim.Session, error) {
+func (s *SessionsList) RemoveSessionByName(_ context.Context, name string) (Session, error) {
 	session, err := s.GetSessionByName(context.Background(), name)
 	if err != nil {
 		return nil, err
@@ -134,10 +189,20 @@ func (s *SessionsList) RemoveSessionByName(_ context.Context, name string) (*sli
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	s.forget(id, name)
+	return session, nil
+}
+
+// forget removes the session with the given id and name from the maps of
+// the list. The caller must hold the write lock.
+func (s *SessionsList) forget(id uint32, name string) {
 	delete(s.sessionsByID, id)
 	delete(s.sessionsByName, name)
 	delete(s.idToName, id)
-	return session, nil
+	delete(s.lastActivity, id)
+	delete(s.stats, id)
+	delete(s.frozen, id)
+	s.inFlight.forget(id)
 }
 
 func (s *SessionsList) ListSessionNames(_ context.Context) []string {
@@ -160,7 +225,19 @@ func (s *SessionsList) ListSessionNames(_ context.Context) []string {
 	return sessionNames
 }
 
-func (s *SessionsList) DeleteAll(ctx context.Context, app *slim.App) {
+// ListSessions returns a snapshot of the sessions in the list
+func (s *SessionsList) ListSessions(_ context.Context) []Session {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	sessions := make([]Session, 0, len(s.sessionsByID))
+	for _, session := range s.sessionsByID {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+func (s *SessionsList) DeleteAll(ctx context.Context, app App) {
 	logger := LoggerFromContextOrDefault(ctx)
 	if app == nil {
 		logger.Warn("Cannot delete sessions, app is nil", zap.String("signal_type", string(s.signalType)))
@@ -182,6 +259,7 @@ func (s *SessionsList) DeleteAll(ctx context.Context, app *slim.App) {
 				zap.Uint32("session_id", id),
 				zap.Error(err))
 		}
+		s.inFlight.forget(id)
 	}
 
 	logger.Info("All sessions deleted for signal", zap.String("signal_type", string(s.signalType)))
@@ -189,14 +267,84 @@ func (s *SessionsList) DeleteAll(ctx context.Context, app *slim.App) {
 	s.sessionsByID = nil
 	s.sessionsByName = nil
 	s.idToName = nil
+	s.lastActivity = nil
+	s.stats = nil
+	s.frozen = nil
+	s.sessionMetadata = nil
+}
+
+// SetPublishObserver sets the observer notified by PublishToAll
+func (s *SessionsList) SetPublishObserver(observer PublishObserver) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.observer = observer
+}
+
+// SetSessionMetadata sets the metadata added to the messages published to
+// the session with the given name, on top of the metadata of the message.
+// It applies to the sessions later added with that name too, until DeleteAll
+// resets the metadata of all the names. Nil or empty metadata removes it.
+func (s *SessionsList) SetSessionMetadata(name string, metadata map[string]string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(metadata) == 0 {
+		delete(s.sessionMetadata, name)
+		return
+	}
+	if s.sessionMetadata == nil {
+		s.sessionMetadata = make(map[string]map[string]string)
+	}
+	s.sessionMetadata[name] = maps.Clone(metadata)
+}
+
+// SetPublishConcurrency sets the maximum number of sessions a message is
+// published to concurrently. 1 publishes to one session at a time, a value
+// lower than 1 restores DefaultPublishConcurrency.
+func (s *SessionsList) SetPublishConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = DefaultPublishConcurrency
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.concurrency = concurrency
 }
 
 // PublishToAll publishes data to all sessions and returns a list of closed session IDs
 func (s *SessionsList) PublishToAll(ctx context.Context, data []byte) ([]uint32, error) {
+	_, closedSessions, err := s.PublishToAllWithMetadata(ctx, data, nil)
+	return closedSessions, err
+}
+
+// PublishToAllWithMetadata publishes data with the given message metadata to
+// all sessions. It returns the IDs of the sessions the message was published
+// to and the IDs of the closed sessions.
+func (s *SessionsList) PublishToAllWithMetadata(
+	ctx context.Context,
+	data []byte,
+	metadata map[string]string,
+) ([]uint32, []uint32, error) {
+	return s.PublishToSessions(ctx, nil, data, metadata)
+}
+
+// PublishToSessions publishes data with the given message metadata to the
+// sessions named in targets, or to all sessions if targets is nil. Targets
+// without a session and frozen sessions, see SetFrozen, are ignored. The
+// sessions are published to concurrently, see SetPublishConcurrency, within
+// the in-flight bound of each session, see SetMaxInFlightPerSession, and each
+// publication is bounded by ctx and the publish timeout, see
+// SetPublishTimeout. It returns the IDs of the sessions the message was
+// published to and the IDs of the closed or evicted sessions, see
+// SetEvictionPolicy, along with the errors of the other sessions joined.
+func (s *SessionsList) PublishToSessions(
+	ctx context.Context,
+	targets []string,
+	data []byte,
+	metadata map[string]string,
+) ([]uint32, []uint32, error) {
 	logger := LoggerFromContextOrDefault(ctx)
 
 	if data == nil {
-		return nil, fmt.Errorf("missing data")
+		return nil, nil, fmt.Errorf("missing data")
 	}
 
 	s.mutex.RLock()
@@ -204,30 +352,123 @@ func (s *SessionsList) PublishToAll(ctx context.Context, data []byte) ([]uint32,
 		// nothing to do
 		logger.Debug("No sessions to publish to", zap.String("signal_name", string(s.signalType)))
 		s.mutex.RUnlock()
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	// Copy session pointers under the lock to avoid holding it during PublishAndWait (I/O).
 	// The snapshot may be stale: removed sessions are handled below, new ones are skipped.
-	snapshot := make(map[uint32]*slim.Session, len(s.sessionsByID))
+	snapshot := make(map[uint32]Session, len(s.sessionsByID))
+	sessionNames := make(map[uint32]string, len(s.sessionsByID))
+	sessionMetadata := make(map[uint32]*map[string]string)
 	for id, session := range s.sessionsByID {
+		if targets != nil && !slices.Contains(targets, s.idToName[id]) {
+			continue
+		}
+		if _, frozen := s.frozen[id]; frozen {
+			continue
+		}
 		snapshot[id] = session
+		sessionNames[id] = s.idToName[id]
+		if extra, ok := s.sessionMetadata[s.idToName[id]]; ok {
+			merged := maps.Clone(metadata)
+			if merged == nil {
+				merged = make(map[string]string, len(extra))
+			}
+			maps.Copy(merged, extra)
+			sessionMetadata[id] = &merged
+		}
 	}
+	observer := s.observer
+	concurrency := s.concurrency
+	onEvict := s.onEvict
+	inFlight := s.inFlight
+	publishTimeout := s.publishTimeout
 	s.mutex.RUnlock()
+	if concurrency < 1 {
+		concurrency = DefaultPublishConcurrency
+	}
 
-	var closedSessions []uint32
-	for id, session := range snapshot {
+	var messageMetadata *map[string]string
+	if metadata != nil {
+		messageMetadata = &metadata
+	}
 
-		if err := session.PublishAndWait(data, nil, nil); err != nil {
-			if strings.Contains(err.Error(), "Session already closed or dropped") {
-				logger.Info("Session closed, marking for removal", zap.Uint32("session_id", id))
-				closedSessions = append(closedSessions, id)
+	// Publish to the sessions with a bounded pool of workers, so that a slow
+	// participant does not delay the others
+	results := make(chan publishResult, len(snapshot))
+	jobs := make(chan uint32, len(snapshot))
+	for id := range snapshot {
+		jobs <- id
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for range min(concurrency, len(snapshot)) {
+		wg.Go(func() {
+			for id := range jobs {
+				sendMetadata := messageMetadata
+				if merged, ok := sessionMetadata[id]; ok {
+					sendMetadata = merged
+				}
+				release, err := inFlight.acquire(ctx, id, sessionNames[id])
+				if err == nil {
+					err = publishAndWait(ctx, snapshot[id], data, sendMetadata, publishTimeout, release)
+				}
+				if observer != nil {
+					observer(sessionNames[id], len(data), err)
+				}
+				results <- publishResult{id: id, err: err}
+			}
+		})
+	}
+	wg.Wait()
+	close(results)
+
+	var publishedSessions, closedSessions []uint32
+	var errs []error
+	for result := range results {
+		if result.err != nil {
+			if errors.Is(result.err, ErrSessionClosed) {
+				logger.Info("Session closed, marking for removal", zap.Uint32("session_id", result.id))
+				closedSessions = append(closedSessions, result.id)
+				continue
+			}
+			logger.Error("Error sending "+string(s.signalType)+" message",
+				zap.String("session_name", sessionNames[result.id]),
+				zap.Error(result.err))
+			if errors.Is(result.err, context.Canceled) || errors.Is(result.err, context.DeadlineExceeded) {
+				// the caller gave up, the session is not counted as failing
+				errs = append(errs, &SessionError{Session: sessionNames[result.id], Err: result.err})
 				continue
 			}
-			logger.Error("Error sending "+string(s.signalType)+" message", zap.Error(err))
-			return closedSessions, err
+			if s.recordPublish(result.id, len(data), result.err) {
+				// the session is removed like a closed one instead of
+				// failing every publication
+				logger.Warn("Evicting session after consecutive publish failures",
+					zap.String("signal_name", string(s.signalType)),
+					zap.String("session_name", sessionNames[result.id]))
+				closedSessions = append(closedSessions, result.id)
+				if onEvict != nil {
+					onEvict(ctx, sessionNames[result.id], snapshot[result.id])
+				}
+				continue
+			}
+			errs = append(errs, &SessionError{Session: sessionNames[result.id], Err: result.err})
+			continue
 		}
+		publishedSessions = append(publishedSessions, result.id)
+		s.recordPublish(result.id, len(data), nil)
+		logger.Debug("Published message",
+			zap.String("signal_name", string(s.signalType)),
+			zap.String("session_name", sessionNames[result.id]),
+			zap.Int("size", len(data)))
 	}
 
-	return closedSessions, nil
+	return publishedSessions, closedSessions, errors.Join(errs...)
+}
+
+// publishResult is the outcome of the publication of a message to a session
+type publishResult struct {
+	id  uint32
+	err error
 }
@@ -0,0 +1,163 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// TestSessionsList_PublishConcurrently tests that a slow session does not
+// delay the publication to the other sessions
+func TestSessionsList_PublishConcurrently(t *testing.T) {
+	ss := slimcommon.NewSessionsList(slimconfig.SignalTraces)
+	ss.SetPublishConcurrency(2)
+
+	release := make(chan struct{})
+	slow := testutil.NewFakeSession(1, "agntcy/otel/slow")
+	slow.OnPublish = func(slim.ReceivedMessage) { <-release }
+	fast := testutil.NewFakeSession(2, "agntcy/otel/fast")
+	require.NoError(t, ss.AddSession(t.Context(), slow))
+	require.NoError(t, ss.AddSession(t.Context(), fast))
+
+	type result struct {
+		published []uint32
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		published, _, err := ss.PublishToAllWithMetadata(t.Context(), []byte("data"), nil)
+		done <- result{published: published, err: err}
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(fast.PublishedMessages()) == 1
+	}, 5*time.Second, 10*time.Millisecond, "the fast session must not wait for the slow one")
+	close(release)
+
+	res := <-done
+	require.NoError(t, res.err)
+	assert.ElementsMatch(t, []uint32{1, 2}, res.published)
+}
+
+// TestSessionsList_PublishResults tests that the results of all the sessions
+// are aggregated
+func TestSessionsList_PublishResults(t *testing.T) {
+	for _, concurrency := range []int{1, 0, 3} {
+		ss := slimcommon.NewSessionsList(slimconfig.SignalLogs)
+		ss.SetPublishConcurrency(concurrency)
+
+		ok := testutil.NewFakeSession(1, "agntcy/otel/ok")
+		failing := testutil.NewFakeSession(2, "agntcy/otel/failing")
+		failing.PublishErr = errors.New("boom")
+		closed := testutil.NewFakeSession(3, "agntcy/otel/closed")
+		closed.Close()
+		for _, session := range []*testutil.FakeSession{ok, failing, closed} {
+			require.NoError(t, ss.AddSession(t.Context(), session))
+		}
+
+		published, closedSessions, err := ss.PublishToAllWithMetadata(t.Context(), []byte("data"), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "boom")
+		assert.Equal(t, []uint32{1}, published)
+		assert.Equal(t, []uint32{3}, closedSessions)
+		assert.Len(t, ok.PublishedMessages(), 1)
+	}
+}
+
+// TestSessionsList_SessionMetadata tests that the metadata of a session is
+// added to the messages published on that session only
+func TestSessionsList_SessionMetadata(t *testing.T) {
+	ss := slimcommon.NewSessionsList(slimconfig.SignalMetrics)
+
+	withTTL := testutil.NewFakeSession(1, "agntcy/otel/ttl")
+	plain := testutil.NewFakeSession(2, "agntcy/otel/plain")
+	require.NoError(t, ss.AddSession(t.Context(), withTTL))
+	require.NoError(t, ss.AddSession(t.Context(), plain))
+	ss.SetSessionMetadata(sessionName(t, withTTL), map[string]string{slimcommon.MetadataTTL: "1000"})
+
+	metadata := map[string]string{"env": "test"}
+	_, _, err := ss.PublishToAllWithMetadata(t.Context(), []byte("data"), metadata)
+	require.NoError(t, err)
+
+	require.Len(t, withTTL.PublishedMessages(), 1)
+	assert.Equal(t, map[string]string{"env": "test", slimcommon.MetadataTTL: "1000"},
+		withTTL.PublishedMessages()[0].Context.Metadata)
+	require.Len(t, plain.PublishedMessages(), 1)
+	assert.Equal(t, map[string]string{"env": "test"}, plain.PublishedMessages()[0].Context.Metadata)
+	assert.Equal(t, map[string]string{"env": "test"}, metadata, "the message metadata must not be modified")
+
+	// nil message metadata
+	_, _, err = ss.PublishToAllWithMetadata(t.Context(), []byte("data"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{slimcommon.MetadataTTL: "1000"},
+		withTTL.PublishedMessages()[1].Context.Metadata)
+
+	// removed metadata
+	ss.SetSessionMetadata(sessionName(t, withTTL), nil)
+	_, _, err = ss.PublishToAllWithMetadata(t.Context(), []byte("data"), metadata)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "test"}, withTTL.PublishedMessages()[2].Context.Metadata)
+}
+
+// TestSessionsList_Frozen tests that the frozen sessions are skipped until
+// they are unfrozen
+func TestSessionsList_Frozen(t *testing.T) {
+	ss := slimcommon.NewSessionsList(slimconfig.SignalTraces)
+
+	frozen := testutil.NewFakeSession(1, "agntcy/otel/frozen")
+	open := testutil.NewFakeSession(2, "agntcy/otel/open")
+	require.NoError(t, ss.AddSession(t.Context(), frozen))
+	require.NoError(t, ss.AddSession(t.Context(), open))
+
+	require.True(t, ss.SetFrozen(1, true))
+	assert.False(t, ss.SetFrozen(1, true), "already frozen")
+	assert.False(t, ss.SetFrozen(3, true), "unknown session")
+
+	published, closedSessions, err := ss.PublishToAllWithMetadata(t.Context(), []byte("data"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{2}, published)
+	assert.Empty(t, closedSessions)
+	assert.Empty(t, frozen.PublishedMessages())
+	assert.True(t, ss.Snapshot()[0].Frozen)
+	assert.False(t, ss.Snapshot()[1].Frozen)
+
+	require.True(t, ss.SetFrozen(1, false))
+	published, _, err = ss.PublishToAllWithMetadata(t.Context(), []byte("data"), nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint32{1, 2}, published)
+	assert.Len(t, frozen.PublishedMessages(), 1)
+}
+
+// TestSessionsList_DeleteAllForgetsState tests that the state of the deleted
+// sessions does not apply to the sessions added again with the same ID or
+// name
+func TestSessionsList_DeleteAllForgetsState(t *testing.T) {
+	ss := slimcommon.NewSessionsList(slimconfig.SignalTraces)
+	app := testutil.NewFakeApp()
+	require.NoError(t, ss.AddSession(t.Context(), testutil.NewFakeSession(1, "agntcy/otel/channel")))
+	require.True(t, ss.SetFrozen(1, true))
+	ss.SetSessionMetadata("agntcy/otel/channel", map[string]string{"key": "value"})
+
+	ss.DeleteAll(t.Context(), app)
+	assert.Equal(t, []uint32{1}, app.DeletedSessions())
+
+	session := testutil.NewFakeSession(1, "agntcy/otel/channel")
+	require.NoError(t, ss.AddSession(t.Context(), session))
+	assert.False(t, ss.SetFrozen(1, false), "the new session is not frozen")
+	_, _, err := ss.PublishToAllWithMetadata(t.Context(), []byte("data"), nil)
+	require.NoError(t, err)
+	published := session.PublishedMessages()
+	require.Len(t, published, 1)
+	assert.NotContains(t, published[0].Context.Metadata, "key")
+}
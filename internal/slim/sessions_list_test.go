@@ -133,6 +133,22 @@ func TestSessionsList_ListSessionNames(t *testing.T) {
 	})
 }
 
+// TestSessionsList_ListSessions tests taking a snapshot of the sessions
+func TestSessionsList_ListSessions(t *testing.T) {
+	t.Run("list from empty sessions", func(t *testing.T) {
+		ss := NewSessionsList(slimconfig.SignalLogs)
+		assert.Empty(t, ss.ListSessions(t.Context()))
+	})
+
+	t.Run("list from nil sessions map", func(t *testing.T) {
+		ss := &SessionsList{
+			signalType:   slimconfig.SignalLogs,
+			sessionsByID: nil,
+		}
+		assert.Empty(t, ss.ListSessions(t.Context()))
+	})
+}
+
 // TestSessionsList_DeleteAll tests removing all sessions
 func TestSessionsList_DeleteAll(t *testing.T) {
 	t.Run("delete all with nil app does not delete sessions", func(t *testing.T) {
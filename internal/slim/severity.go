@@ -0,0 +1,34 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// severityLevels are the OpenTelemetry log severity levels, each one covering
+// four severity numbers starting at 1 for TRACE
+var severityLevels = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+// ParseLogSeverity returns the OpenTelemetry severity number of a log
+// severity name: one of TRACE, DEBUG, INFO, WARN, ERROR and FATAL, optionally
+// followed by 2, 3 or 4 (e.g. INFO2). The name is case insensitive.
+func ParseLogSeverity(name string) (int32, error) {
+	upper := strings.ToUpper(name)
+	for i, level := range severityLevels {
+		suffix, ok := strings.CutPrefix(upper, level)
+		if !ok {
+			continue
+		}
+		if suffix == "" {
+			return int32(i*4 + 1), nil //nolint:gosec // bounded by the number of levels
+		}
+		if n, err := strconv.Atoi(suffix); err == nil && n >= 2 && n <= 4 {
+			return int32(i*4 + n), nil //nolint:gosec // bounded by the number of levels
+		}
+	}
+	return 0, fmt.Errorf("invalid log severity: %s", name)
+}
@@ -0,0 +1,44 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogSeverity(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    int32
+		wantErr bool
+	}{
+		{name: "TRACE", want: 1},
+		{name: "debug", want: 5},
+		{name: "INFO", want: 9},
+		{name: "Info2", want: 10},
+		{name: "WARN", want: 13},
+		{name: "ERROR4", want: 20},
+		{name: "FATAL", want: 21},
+		{name: "FATAL4", want: 24},
+		{name: "INFO5", wantErr: true},
+		{name: "INFO1", wantErr: true},
+		{name: "WARNING", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLogSeverity(tt.name)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// MetadataSignal is the message metadata key holding the signal type of the
+// data published by the exporter. The receivers use it to detect the
+// payloads of the signals they have no consumer for, which cannot be told
+// apart from the payload alone.
+const MetadataSignal = "slim-otel.signal"
+
+// AddSignal stores the signal type of the data in the message metadata
+func AddSignal(metadata map[string]string, signal slimconfig.SignalType) {
+	metadata[MetadataSignal] = string(signal)
+}
+
+// MessageSignal returns the signal type stored in the message metadata, and
+// false if the message does not carry a known one
+func MessageSignal(metadata map[string]string) (slimconfig.SignalType, bool) {
+	switch signal := slimconfig.SignalType(metadata[MetadataSignal]); signal {
+	case slimconfig.SignalTraces, slimconfig.SignalMetrics, slimconfig.SignalLogs:
+		return signal, true
+	default:
+		return slimconfig.SignalUnknown, false
+	}
+}
+
+// ParseSignal returns the signal named name: traces, metrics or logs
+func ParseSignal(name string) (slimconfig.SignalType, error) {
+	switch signal := slimconfig.SignalType(name); signal {
+	case slimconfig.SignalTraces, slimconfig.SignalMetrics, slimconfig.SignalLogs:
+		return signal, nil
+	default:
+		return "", fmt.Errorf("invalid signal %q, must be traces, metrics or logs", name)
+	}
+}
+
+// Placeholders of a signal name template
+const (
+	templateName   = "{name}"
+	templateSignal = "{signal}"
+)
+
+// DefaultSignalNameTemplate is the naming convention of the apps of a signal
+// derived from a base name, e.g. agntcy/otel/exporter-traces for the traces
+// of agntcy/otel/exporter
+const DefaultSignalNameTemplate = templateName + "-" + templateSignal
+
+// ValidateSignalNameTemplate checks that template holds the {name} and
+// {signal} placeholders once each. The template applies to the last
+// component of a SLIM name, so it cannot hold a slash.
+func ValidateSignalNameTemplate(template string) error {
+	if strings.Count(template, templateName) != 1 || strings.Count(template, templateSignal) != 1 {
+		return fmt.Errorf("signal name template %q must contain %s and %s once", template, templateName, templateSignal)
+	}
+	if strings.Contains(template, "/") {
+		return fmt.Errorf("signal name template %q cannot contain a slash", template)
+	}
+	return nil
+}
+
+// SignalName returns the name of the app of signal derived from the base
+// SLIM name by the template, DefaultSignalNameTemplate if empty. The
+// template applies to the last component of the name, e.g.
+// agntcy/otel/exporter-traces.
+func SignalName(base, template string, signal slimconfig.SignalType) (string, error) {
+	if template == "" {
+		template = DefaultSignalNameTemplate
+	}
+	if err := ValidateSignalNameTemplate(template); err != nil {
+		return "", err
+	}
+	parts := strings.Split(base, "/")
+	if len(parts) != 3 || parts[2] == "" {
+		return "", fmt.Errorf("base name must be in the format organization/namespace/app, got: %s", base)
+	}
+	parts[2] = strings.NewReplacer(templateName, parts[2], templateSignal, string(signal)).Replace(template)
+	return strings.Join(parts, "/"), nil
+}
+
+// ExtractSignalType returns the signal a SLIM name was derived for by the
+// template, DefaultSignalNameTemplate if empty, and false if the name does
+// not follow the template, e.g. an explicit name carrying no signal.
+func ExtractSignalType(name, template string) (slimconfig.SignalType, bool) {
+	if template == "" {
+		template = DefaultSignalNameTemplate
+	}
+	if ValidateSignalNameTemplate(template) != nil {
+		return slimconfig.SignalUnknown, false
+	}
+	parts := strings.Split(name, "/")
+	if len(parts) < 3 {
+		return slimconfig.SignalUnknown, false
+	}
+	app := parts[2]
+	for _, signal := range []slimconfig.SignalType{
+		slimconfig.SignalTraces, slimconfig.SignalMetrics, slimconfig.SignalLogs,
+	} {
+		prefix, suffix, _ := strings.Cut(strings.ReplaceAll(template, templateSignal, string(signal)), templateName)
+		if len(app) > len(prefix)+len(suffix) && strings.HasPrefix(app, prefix) && strings.HasSuffix(app, suffix) {
+			return signal, true
+		}
+	}
+	return slimconfig.SignalUnknown, false
+}
+
+// ResolveSignalName returns the name of the app of signal: the explicit
+// name of names if set, or the name derived from base by the template
+// otherwise. Both conventions can be mixed, e.g. to name the logs app apart.
+func ResolveSignalName(
+	names *slimconfig.SignalNames, base, template string, signal slimconfig.SignalType,
+) (string, error) {
+	if names != nil && names.IsSignalNameSet(string(signal)) {
+		return names.GetNameForSignal(string(signal))
+	}
+	if base == "" {
+		return "", errors.New("no name is set for the signal " + string(signal))
+	}
+	return SignalName(base, template, signal)
+}
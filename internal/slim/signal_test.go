@@ -0,0 +1,102 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestMessageSignal(t *testing.T) {
+	metadata := make(map[string]string)
+	AddSignal(metadata, slimconfig.SignalMetrics)
+
+	signal, ok := MessageSignal(metadata)
+	assert.True(t, ok)
+	assert.Equal(t, slimconfig.SignalMetrics, signal)
+
+	_, ok = MessageSignal(nil)
+	assert.False(t, ok)
+	_, ok = MessageSignal(map[string]string{MetadataSignal: "profiles"})
+	assert.False(t, ok)
+}
+
+func TestParseSignal(t *testing.T) {
+	signal, err := ParseSignal("logs")
+	assert.NoError(t, err)
+	assert.Equal(t, slimconfig.SignalLogs, signal)
+
+	_, err = ParseSignal("unknown")
+	assert.Error(t, err)
+}
+
+func TestSignalName(t *testing.T) {
+	name, err := SignalName("agntcy/otel/exporter", "", slimconfig.SignalTraces)
+	assert.NoError(t, err)
+	assert.Equal(t, "agntcy/otel/exporter-traces", name)
+
+	name, err = SignalName("agntcy/otel/exporter", "{signal}.{name}", slimconfig.SignalLogs)
+	assert.NoError(t, err)
+	assert.Equal(t, "agntcy/otel/logs.exporter", name)
+
+	_, err = SignalName("agntcy/otel", "", slimconfig.SignalLogs)
+	assert.Error(t, err)
+	_, err = SignalName("agntcy/otel/exporter", "{name}", slimconfig.SignalLogs)
+	assert.Error(t, err)
+	_, err = SignalName("agntcy/otel/exporter", "{name}/{signal}", slimconfig.SignalLogs)
+	assert.Error(t, err)
+}
+
+func TestExtractSignalType(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		template string
+		want     slimconfig.SignalType
+		ok       bool
+	}{
+		{name: "agntcy/otel/exporter-traces", want: slimconfig.SignalTraces, ok: true},
+		{name: "agntcy/otel/exporter-metrics/1", want: slimconfig.SignalMetrics, ok: true},
+		{name: "agntcy/otel/logs.exporter", template: "{signal}.{name}", want: slimconfig.SignalLogs, ok: true},
+		// the explicit names carrying no signal
+		{name: "agntcy/otel/exporter", want: slimconfig.SignalUnknown},
+		{name: "agntcy/otel/-traces", want: slimconfig.SignalUnknown},
+		{name: "agntcy/otel/exporter-traces", template: "{signal}.{name}", want: slimconfig.SignalUnknown},
+		{name: "agntcy/otel", want: slimconfig.SignalUnknown},
+		{name: "agntcy/otel/exporter-traces", template: "invalid", want: slimconfig.SignalUnknown},
+	} {
+		signal, ok := ExtractSignalType(tc.name, tc.template)
+		assert.Equal(t, tc.ok, ok, tc.name)
+		assert.Equal(t, tc.want, signal, tc.name)
+	}
+
+	// the derived names round trip
+	for _, signal := range []slimconfig.SignalType{
+		slimconfig.SignalTraces, slimconfig.SignalMetrics, slimconfig.SignalLogs,
+	} {
+		name, err := SignalName("agntcy/otel/exporter", "otel-{signal}-{name}", signal)
+		assert.NoError(t, err)
+		extracted, ok := ExtractSignalType(name, "otel-{signal}-{name}")
+		assert.True(t, ok)
+		assert.Equal(t, signal, extracted)
+	}
+}
+
+func TestResolveSignalName(t *testing.T) {
+	logs := "agntcy/otel/log-shipper"
+	names := &slimconfig.SignalNames{Logs: &logs}
+
+	name, err := ResolveSignalName(names, "agntcy/otel/exporter", "", slimconfig.SignalLogs)
+	assert.NoError(t, err)
+	assert.Equal(t, logs, name, "the explicit name wins")
+
+	name, err = ResolveSignalName(names, "agntcy/otel/exporter", "", slimconfig.SignalTraces)
+	assert.NoError(t, err)
+	assert.Equal(t, "agntcy/otel/exporter-traces", name)
+
+	_, err = ResolveSignalName(nil, "", "", slimconfig.SignalTraces)
+	assert.Error(t, err)
+}
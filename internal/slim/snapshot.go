@@ -0,0 +1,97 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// SessionInfo describes a session of a SessionsList, for diagnostics
+type SessionInfo struct {
+	// ID of the session
+	ID uint32
+	// Name of the session, its destination
+	Name string
+	// Signal of the list the session belongs to
+	Signal slimconfig.SignalType
+	// Time at which the session was added to the list
+	Created time.Time
+	// Time of the last activity on the session, see SessionsList.Touch
+	LastActivity time.Time
+	// Time of the last successful publication to the session, zero if
+	// nothing was published yet
+	LastPublish time.Time
+	// Number of messages published to the session
+	PublishedMessages int64
+	// Total size in bytes of the messages published to the session
+	PublishedBytes int64
+	// Number of publications to the session that failed
+	PublishErrors int
+	// Number of publications that failed since the last successful one
+	ConsecutiveFailures int
+	// Error of the last failed publication, nil if none failed
+	LastError error
+	// Whether the session is frozen, see SessionsList.SetFrozen
+	Frozen bool
+}
+
+// Snapshot returns the description of the sessions in the list, sorted by
+// name. It is a copy: later changes of the list are not reflected.
+func (s *SessionsList) Snapshot() []SessionInfo {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.snapshotLocked()
+}
+
+// snapshotLocked is Snapshot, the caller must hold the lock
+func (s *SessionsList) snapshotLocked() []SessionInfo {
+	infos := make([]SessionInfo, 0, len(s.sessionsByID))
+	for id := range s.sessionsByID {
+		info := SessionInfo{
+			ID:           id,
+			Name:         s.idToName[id],
+			Signal:       s.signalType,
+			LastActivity: s.lastActivity[id],
+		}
+		_, info.Frozen = s.frozen[id]
+		if stats, ok := s.stats[id]; ok {
+			info.Created = stats.created
+			info.LastPublish = stats.lastPublish
+			info.PublishedMessages = stats.publishedMessages
+			info.PublishedBytes = stats.publishedBytes
+			info.PublishErrors = stats.publishErrors
+			info.ConsecutiveFailures = stats.consecutiveFailures
+			info.LastError = stats.lastError
+		}
+		infos = append(infos, info)
+	}
+	slices.SortFunc(infos, func(a, b SessionInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return infos
+}
+
+// ForEach calls fn with the description of each session in the list and the
+// session itself, sorted by name, until fn returns false. fn is called
+// without holding the lock of the list, so it may use the list, e.g. to
+// remove the session; the sessions removed meanwhile are still visited.
+func (s *SessionsList) ForEach(fn func(info SessionInfo, session Session) bool) {
+	s.mutex.RLock()
+	infos := s.snapshotLocked()
+	sessions := make([]Session, len(infos))
+	for i, info := range infos {
+		sessions[i] = s.sessionsByID[info.ID]
+	}
+	s.mutex.RUnlock()
+
+	for i, info := range infos {
+		if !fn(info, sessions[i]) {
+			return
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestSessionsList_Snapshot(t *testing.T) {
+	ss := slimcommon.NewSessionsList(slimconfig.SignalLogs)
+	assert.Empty(t, ss.Snapshot())
+
+	before := time.Now()
+	ok := testutil.NewFakeSession(1, "agntcy/otel/b-ok")
+	failing := testutil.NewFakeSession(2, "agntcy/otel/a-failing")
+	failing.PublishErr = errors.New("boom")
+	require.NoError(t, ss.AddSession(t.Context(), ok))
+	require.NoError(t, ss.AddSession(t.Context(), failing))
+
+	_, _, err := ss.PublishToAllWithMetadata(t.Context(), []byte("data"), nil)
+	require.Error(t, err)
+
+	infos := ss.Snapshot()
+	require.Len(t, infos, 2)
+
+	assert.Equal(t, uint32(2), infos[0].ID, "the sessions are sorted by name")
+	assert.Equal(t, sessionName(t, failing), infos[0].Name)
+	assert.Equal(t, slimconfig.SignalLogs, infos[0].Signal)
+	assert.Equal(t, 1, infos[0].PublishErrors)
+	assert.True(t, infos[0].LastPublish.IsZero())
+
+	assert.Equal(t, uint32(1), infos[1].ID)
+	assert.Equal(t, sessionName(t, ok), infos[1].Name)
+	assert.Zero(t, infos[1].PublishErrors)
+	assert.False(t, infos[1].Created.Before(before))
+	assert.False(t, infos[1].LastPublish.Before(infos[1].Created))
+	assert.Equal(t, infos[1].LastPublish, infos[1].LastActivity)
+
+	// the snapshot is not affected by later changes
+	_, err = ss.RemoveSessionByID(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Len(t, infos, 2)
+	assert.Len(t, ss.Snapshot(), 1)
+}
+
+func TestSessionsList_ForEach(t *testing.T) {
+	ss := slimcommon.NewSessionsList(slimconfig.SignalTraces)
+	for id, name := range map[uint32]string{1: "agntcy/otel/a", 2: "agntcy/otel/b", 3: "agntcy/otel/c"} {
+		require.NoError(t, ss.AddSession(t.Context(), testutil.NewFakeSession(id, name)))
+	}
+
+	// the callback can use the list
+	var visited []uint32
+	ss.ForEach(func(info slimcommon.SessionInfo, session slimcommon.Session) bool {
+		id, err := session.SessionId()
+		require.NoError(t, err)
+		assert.Equal(t, info.ID, id)
+		visited = append(visited, id)
+		_, err = ss.RemoveSessionByID(t.Context(), id)
+		require.NoError(t, err)
+		return true
+	})
+	assert.Equal(t, []uint32{1, 2, 3}, visited)
+	assert.Empty(t, ss.Snapshot())
+
+	// returning false stops the iteration
+	for id, name := range map[uint32]string{4: "agntcy/otel/d", 5: "agntcy/otel/e"} {
+		require.NoError(t, ss.AddSession(t.Context(), testutil.NewFakeSession(id, name)))
+	}
+	visited = nil
+	ss.ForEach(func(info slimcommon.SessionInfo, _ slimcommon.Session) bool {
+		visited = append(visited, info.ID)
+		return false
+	})
+	assert.Equal(t, []uint32{4}, visited)
+}
@@ -0,0 +1,62 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"context"
+	"time"
+)
+
+// EvictionHandler is notified of a session evicted from a SessionsList after
+// too many consecutive publish failures, see SetEvictionPolicy. It is called
+// with the context of the publication, without holding the lock of the list.
+type EvictionHandler func(ctx context.Context, name string, session Session)
+
+// sessionStats holds the publication statistics of a session
+type sessionStats struct {
+	created             time.Time
+	lastPublish         time.Time
+	publishedMessages   int64
+	publishedBytes      int64
+	publishErrors       int
+	consecutiveFailures int
+	lastError           error
+}
+
+// SetEvictionPolicy evicts the sessions whose publications failed
+// maxFailures times in a row, so that a broken participant does not fail
+// every publication. PublishToSessions reports the evicted sessions along
+// with the closed ones, for the caller to remove them, and notifies handler
+// if not nil, e.g. to delete them. A maxFailures lower than 1 disables the
+// eviction.
+func (s *SessionsList) SetEvictionPolicy(maxFailures int, handler EvictionHandler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.maxFailures = max(maxFailures, 0)
+	s.onEvict = handler
+}
+
+// recordPublish records the outcome of the publication of size bytes to the
+// session with the given id, and touches it if the message was published. It
+// returns true if the session must be evicted.
+func (s *SessionsList) recordPublish(id uint32, size int, err error) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	stats, exists := s.stats[id]
+	if !exists {
+		return false
+	}
+	if err != nil {
+		stats.publishErrors++
+		stats.consecutiveFailures++
+		stats.lastError = err
+		return s.maxFailures > 0 && stats.consecutiveFailures >= s.maxFailures
+	}
+	stats.consecutiveFailures = 0
+	stats.publishedMessages++
+	stats.publishedBytes += int64(size)
+	stats.lastPublish = time.Now()
+	s.lastActivity[id] = stats.lastPublish
+	return false
+}
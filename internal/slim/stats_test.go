@@ -0,0 +1,90 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestSessionsList_PublishStatistics(t *testing.T) {
+	ss := slimcommon.NewSessionsList(slimconfig.SignalMetrics)
+	session := testutil.NewFakeSession(1, "agntcy/otel/flaky")
+	require.NoError(t, ss.AddSession(t.Context(), session))
+
+	publish := func(data string) {
+		_, _, _ = ss.PublishToAllWithMetadata(t.Context(), []byte(data), nil)
+	}
+	publish("12345")
+	publish("123")
+	boom := errors.New("boom")
+	session.PublishErr = boom
+	publish("1")
+	publish("1")
+
+	info := ss.Snapshot()[0]
+	assert.Equal(t, int64(2), info.PublishedMessages)
+	assert.Equal(t, int64(8), info.PublishedBytes)
+	assert.Equal(t, 2, info.PublishErrors)
+	assert.Equal(t, 2, info.ConsecutiveFailures)
+	assert.ErrorIs(t, info.LastError, boom)
+
+	// a successful publication resets the consecutive failures only
+	session.PublishErr = nil
+	publish("12")
+	info = ss.Snapshot()[0]
+	assert.Equal(t, int64(3), info.PublishedMessages)
+	assert.Equal(t, int64(10), info.PublishedBytes)
+	assert.Equal(t, 2, info.PublishErrors)
+	assert.Zero(t, info.ConsecutiveFailures)
+	assert.ErrorIs(t, info.LastError, boom)
+}
+
+func TestSessionsList_EvictionPolicy(t *testing.T) {
+	ss := slimcommon.NewSessionsList(slimconfig.SignalTraces)
+	healthy := testutil.NewFakeSession(1, "agntcy/otel/healthy")
+	broken := testutil.NewFakeSession(2, "agntcy/otel/broken")
+	broken.PublishErr = errors.New("participant unreachable")
+	require.NoError(t, ss.AddSession(t.Context(), healthy))
+	require.NoError(t, ss.AddSession(t.Context(), broken))
+
+	var evicted []string
+	ss.SetEvictionPolicy(3, func(_ context.Context, name string, session slimcommon.Session) {
+		assert.Same(t, broken, session)
+		evicted = append(evicted, name)
+	})
+
+	for range 2 {
+		published, closedSessions, err := ss.PublishToAllWithMetadata(t.Context(), []byte("data"), nil)
+		require.ErrorContains(t, err, "participant unreachable")
+		assert.Equal(t, []uint32{1}, published)
+		assert.Empty(t, closedSessions)
+	}
+	assert.Empty(t, evicted)
+
+	// the third failure in a row evicts the session instead of failing
+	published, closedSessions, err := ss.PublishToAllWithMetadata(t.Context(), []byte("data"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{1}, published)
+	assert.Equal(t, []uint32{2}, closedSessions)
+	assert.Equal(t, []string{sessionName(t, broken)}, evicted)
+
+	// disabled eviction
+	ss = slimcommon.NewSessionsList(slimconfig.SignalTraces)
+	require.NoError(t, ss.AddSession(t.Context(), broken))
+	ss.SetEvictionPolicy(0, nil)
+	for range 5 {
+		_, closedSessions, err = ss.PublishToAllWithMetadata(t.Context(), []byte("data"), nil)
+		require.Error(t, err)
+		assert.Empty(t, closedSessions)
+	}
+}
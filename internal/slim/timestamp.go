@@ -0,0 +1,33 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"strconv"
+	"time"
+)
+
+// MetadataSentAt is the message metadata key holding the time at which the
+// exporter published the message, in nanoseconds since the Unix epoch. The
+// receivers use it to measure the delivery latency.
+const MetadataSentAt = "slim-otel.sent-at"
+
+// AddSentAt stores the publication time in the message metadata
+func AddSentAt(metadata map[string]string, sentAt time.Time) {
+	metadata[MetadataSentAt] = strconv.FormatInt(sentAt.UnixNano(), 10)
+}
+
+// SentAt returns the publication time stored in the message metadata, and
+// false if the message does not carry a valid one
+func SentAt(metadata map[string]string) (time.Time, bool) {
+	value, ok := metadata[MetadataSentAt]
+	if !ok {
+		return time.Time{}, false
+	}
+	nanos, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || nanos <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, nanos), true
+}
@@ -0,0 +1,28 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSentAt(t *testing.T) {
+	now := time.Now()
+	metadata := make(map[string]string)
+	AddSentAt(metadata, now)
+
+	sentAt, ok := SentAt(metadata)
+	assert.True(t, ok)
+	assert.True(t, now.Equal(sentAt), "expected %s, got %s", now, sentAt)
+
+	_, ok = SentAt(nil)
+	assert.False(t, ok)
+	_, ok = SentAt(map[string]string{MetadataSentAt: "yesterday"})
+	assert.False(t, ok)
+	_, ok = SentAt(map[string]string{MetadataSentAt: "-5"})
+	assert.False(t, ok)
+}
@@ -0,0 +1,52 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"strconv"
+	"time"
+)
+
+// MetadataTTL is the message metadata key holding the time to live of the
+// message, in nanoseconds. The receivers drop the messages consumed after
+// their publication time, see MetadataSentAt, plus the time to live.
+const MetadataTTL = "slim-otel.ttl"
+
+// AddTTL stores the time to live of the message in the message metadata. A
+// ttl lower than or equal to zero is not stored, the message never expires.
+func AddTTL(metadata map[string]string, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	metadata[MetadataTTL] = strconv.FormatInt(int64(ttl), 10)
+}
+
+// TTL returns the time to live stored in the message metadata, and false if
+// the message does not carry a valid one
+func TTL(metadata map[string]string) (time.Duration, bool) {
+	value, ok := metadata[MetadataTTL]
+	if !ok {
+		return 0, false
+	}
+	nanos, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || nanos <= 0 {
+		return 0, false
+	}
+	return time.Duration(nanos), true
+}
+
+// Expired reports whether the message whose metadata is given outlived its
+// time to live at now. The messages without a valid publication time or time
+// to live never expire.
+func Expired(metadata map[string]string, now time.Time) bool {
+	ttl, ok := TTL(metadata)
+	if !ok {
+		return false
+	}
+	sentAt, ok := SentAt(metadata)
+	if !ok {
+		return false
+	}
+	return now.Sub(sentAt) > ttl
+}
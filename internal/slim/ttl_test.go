@@ -0,0 +1,47 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimcommon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTL(t *testing.T) {
+	metadata := make(map[string]string)
+	AddTTL(metadata, 0)
+	assert.NotContains(t, metadata, MetadataTTL)
+	AddTTL(metadata, -time.Second)
+	assert.NotContains(t, metadata, MetadataTTL)
+
+	AddTTL(metadata, 30*time.Second)
+	ttl, ok := TTL(metadata)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, ttl)
+
+	_, ok = TTL(nil)
+	assert.False(t, ok)
+	_, ok = TTL(map[string]string{MetadataTTL: "1m"})
+	assert.False(t, ok)
+	_, ok = TTL(map[string]string{MetadataTTL: "0"})
+	assert.False(t, ok)
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Now()
+	metadata := make(map[string]string)
+	AddSentAt(metadata, now.Add(-time.Minute))
+	assert.False(t, Expired(metadata, now), "messages without ttl never expire")
+
+	AddTTL(metadata, 2*time.Minute)
+	assert.False(t, Expired(metadata, now))
+
+	AddTTL(metadata, 30*time.Second)
+	assert.True(t, Expired(metadata, now))
+
+	assert.False(t, Expired(map[string]string{MetadataTTL: "1000"}, now),
+		"messages without publication time never expire")
+}
@@ -0,0 +1,65 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// FakeConnector is an in-memory implementation of slimcommon.Connector. All
+// the endpoints are reachable unless marked down with SetDown.
+type FakeConnector struct {
+	mutex sync.Mutex
+
+	nextID uint64
+	conns  map[string]uint64
+	down   map[string]bool
+}
+
+// NewFakeConnector creates a FakeConnector without connections
+func NewFakeConnector() *FakeConnector {
+	return &FakeConnector{
+		conns: make(map[string]uint64),
+		down:  make(map[string]bool),
+	}
+}
+
+// Connect implements slimcommon.Connector
+func (c *FakeConnector) Connect(cfg slimconfig.ConnectionConfig) (uint64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.down[cfg.Address] {
+		return 0, fmt.Errorf("endpoint %s unreachable", cfg.Address)
+	}
+	if id, ok := c.conns[cfg.Address]; ok {
+		return id, nil
+	}
+	c.nextID++
+	c.conns[cfg.Address] = c.nextID
+	return c.nextID, nil
+}
+
+// Connected implements slimcommon.Connector
+func (c *FakeConnector) Connected(address string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, ok := c.conns[address]
+	return ok
+}
+
+// SetDown marks the endpoint at address as unreachable, dropping its
+// connection, or as reachable again
+func (c *FakeConnector) SetDown(address string, down bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.down[address] = down
+	if down {
+		delete(c.conns, address)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"slices"
+	"sync"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// FakeNetwork is an in-memory SLIM transport connecting the FakeApps created
+// with NewApp, so that an exporter, a receiver and a channel manager can be
+// tested together without a SLIM node:
+//   - inviting the name of an app to a session creates a session of the same
+//     channel on that app, returned by its ListenForSession
+//   - the messages published on a session are delivered to the other
+//     sessions of the channel, with the name of the publishing app as source
+//   - removing a participant closes its session, and deleting the session
+//     of the app that created the channel closes the sessions of all the
+//     participants
+type FakeNetwork struct {
+	mutex sync.Mutex
+	// map of app name to app
+	apps map[string]*FakeApp
+	// map of channel name to the sessions of its members, the session of the
+	// app that created the channel first
+	channels map[string][]*FakeSession
+}
+
+// NewFakeNetwork creates an empty FakeNetwork
+func NewFakeNetwork() *FakeNetwork {
+	return &FakeNetwork{
+		apps:     make(map[string]*FakeApp),
+		channels: make(map[string][]*FakeSession),
+	}
+}
+
+// NewApp creates a FakeApp connected to the network with the given name, in
+// the org/namespace/app format, replacing the app with the same name if any
+func (n *FakeNetwork) NewApp(name string) *FakeApp {
+	app := NewFakeApp()
+	app.network = n
+	app.name = name
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.apps[name] = app
+	return app
+}
+
+// Members returns the names of the apps with a session on the channel
+func (n *FakeNetwork) Members(channel string) []string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	members := make([]string, 0, len(n.channels[channel]))
+	for _, session := range n.channels[channel] {
+		members = append(members, session.owner)
+	}
+	return members
+}
+
+// join adds session to the members of its channel
+func (n *FakeNetwork) join(session *FakeSession) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.channels[session.destination] = append(n.channels[session.destination], session)
+}
+
+// invite creates a session of the channel of from on the app named
+// participant, if it is connected to the network
+func (n *FakeNetwork) invite(from *FakeSession, participant string) {
+	n.mutex.Lock()
+	app, ok := n.apps[participant]
+	n.mutex.Unlock()
+	if !ok {
+		return
+	}
+
+	config, _ := from.SessionConfig()
+	app.mutex.Lock()
+	session := app.newSession(config, from.destination)
+	app.mutex.Unlock()
+
+	n.join(session)
+	app.Invite(session)
+}
+
+// deliver delivers msg, published on from, to the other members of its
+// channel
+func (n *FakeNetwork) deliver(from *FakeSession, msg slim.ReceivedMessage) {
+	n.mutex.Lock()
+	members := slices.Clone(n.channels[from.destination])
+	n.mutex.Unlock()
+
+	if source, err := slimcommon.SplitID(from.owner); err == nil {
+		msg.Context.SourceName = source
+	}
+	for _, member := range members {
+		if member != from {
+			member.DeliverMessage(msg)
+		}
+	}
+}
+
+// remove closes the session of the app named participant on the channel of
+// from
+func (n *FakeNetwork) remove(from *FakeSession, participant string) {
+	n.mutex.Lock()
+	members := n.channels[from.destination]
+	idx := slices.IndexFunc(members, func(member *FakeSession) bool {
+		return member != from && member.owner == participant
+	})
+	if idx < 0 {
+		n.mutex.Unlock()
+		return
+	}
+	removed := members[idx]
+	n.channels[from.destination] = slices.Delete(members, idx, idx+1)
+	n.mutex.Unlock()
+
+	removed.Close()
+}
+
+// leave removes session from the members of its channel. The channel is
+// closed for all the members if session created it.
+func (n *FakeNetwork) leave(session *FakeSession) {
+	n.mutex.Lock()
+	members := n.channels[session.destination]
+	idx := slices.Index(members, session)
+	if idx < 0 {
+		n.mutex.Unlock()
+		return
+	}
+	var closed []*FakeSession
+	if idx == 0 {
+		closed = members[1:]
+		delete(n.channels, session.destination)
+	} else {
+		n.channels[session.destination] = slices.Delete(members, idx, idx+1)
+	}
+	n.mutex.Unlock()
+
+	for _, member := range closed {
+		member.Close()
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+const (
+	testChannel  = "agntcy/otel/channel"
+	testExporter = "agntcy/otel/exporter"
+	testReceiver = "agntcy/otel/receiver"
+)
+
+// TestFakeNetwork tests that the messages published by an app are received
+// by the participants it invited
+func TestFakeNetwork(t *testing.T) {
+	network := testutil.NewFakeNetwork()
+	exporterApp := network.NewApp(testExporter)
+	receiverApp := network.NewApp(testReceiver)
+
+	channel, err := slimcommon.SplitID(testChannel)
+	require.NoError(t, err)
+	session, err := exporterApp.CreateSessionAndWait(slim.SessionConfig{}, channel)
+	require.NoError(t, err)
+	receiverName, err := slimcommon.SplitID(testReceiver)
+	require.NoError(t, err)
+	require.NoError(t, session.InviteAndWait(receiverName))
+
+	invited := <-slimcommon.ListenSessions(t.Context(), receiverApp)
+	require.NotNil(t, invited)
+	assert.Equal(t, []string{testExporter, testReceiver}, network.Members(testChannel))
+
+	sessions := slimcommon.NewSessionsList(slimconfig.SignalTraces)
+	require.NoError(t, sessions.AddSession(t.Context(), session))
+	published, _, err := sessions.PublishToAllWithMetadata(t.Context(), []byte("data"), map[string]string{"k": "v"})
+	require.NoError(t, err)
+	require.Len(t, published, 1)
+
+	timeout := time.Second
+	msg, err := invited.GetMessage(&timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), msg.Payload)
+	assert.Equal(t, map[string]string{"k": "v"}, msg.Context.Metadata)
+	require.NotNil(t, msg.Context.SourceName)
+	assert.Equal(t, testExporter, slimcommon.JoinID(msg.Context.SourceName))
+
+	// the exporter does not receive its own messages
+	_, err = session.GetMessage(&timeout)
+	require.ErrorIs(t, err, slimcommon.ErrReceiveTimeout)
+
+	// removing the participant closes its session
+	require.NoError(t, session.RemoveAndWait(receiverName))
+	_, err = invited.GetMessage(&timeout)
+	require.ErrorIs(t, err, slimcommon.ErrSessionClosed)
+	assert.Equal(t, []string{testExporter}, network.Members(testChannel))
+}
+
+// TestFakeNetwork_DeleteChannel tests that deleting the session of the app
+// that created a channel closes it for all the participants
+func TestFakeNetwork_DeleteChannel(t *testing.T) {
+	network := testutil.NewFakeNetwork()
+	exporterApp := network.NewApp(testExporter)
+	receiverApp := network.NewApp(testReceiver)
+
+	channel, err := slimcommon.SplitID(testChannel)
+	require.NoError(t, err)
+	session, err := exporterApp.CreateSessionAndWait(slim.SessionConfig{}, channel)
+	require.NoError(t, err)
+	receiverName, err := slimcommon.SplitID(testReceiver)
+	require.NoError(t, err)
+	require.NoError(t, session.InviteAndWait(receiverName))
+	// unknown participants are ignored
+	unknown, err := slimcommon.SplitID("agntcy/otel/unknown")
+	require.NoError(t, err)
+	require.NoError(t, session.InviteAndWait(unknown))
+
+	timeout := time.Second
+	invited, err := receiverApp.ListenForSession(&timeout)
+	require.NoError(t, err)
+
+	require.NoError(t, exporterApp.DeleteSessionAndWait(session))
+	_, err = invited.GetMessage(&timeout)
+	require.ErrorIs(t, err, slimcommon.ErrSessionClosed)
+	assert.Empty(t, network.Members(testChannel))
+}
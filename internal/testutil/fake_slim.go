@@ -0,0 +1,491 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+// Package testutil provides an in-memory fake of the SLIM app and session
+// APIs so that components depending on slimcommon.App can be unit tested
+// without a running SLIM node.
+package testutil
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+var (
+	// ErrListenTimeout is returned by FakeApp.ListenForSession when no session arrives in time
+	ErrListenTimeout = errors.New("timeout waiting for new session")
+	// ErrReceiveTimeout is returned by FakeSession.GetMessage when no message arrives in time
+	ErrReceiveTimeout = fmt.Errorf("%w: receive timeout waiting for message", slimcommon.ErrReceiveTimeout)
+	// ErrSessionClosed is returned by FakeSession.GetMessage once the session is closed
+	ErrSessionClosed = fmt.Errorf("%w: session closed", slimcommon.ErrSessionClosed)
+	// ErrSessionDropped is returned by FakeSession.PublishAndWait once the session is closed
+	ErrSessionDropped = fmt.Errorf("%w: Session already closed or dropped", slimcommon.ErrSessionClosed)
+)
+
+// FakeApp is an in-memory implementation of slimcommon.App. Errors can be
+// injected by setting the corresponding *Err fields before use.
+type FakeApp struct {
+	mutex sync.Mutex
+
+	nextID     uint32
+	sessions   map[uint32]*FakeSession
+	routes     []string
+	subscribed []uint64
+	incoming   chan slimcommon.Session
+	deleted    []uint32
+
+	destroyed bool
+
+	// network and name are set for the apps of a FakeNetwork
+	network *FakeNetwork
+	name    string
+
+	// CreateSessionErr is returned by CreateSessionAndWait when set
+	CreateSessionErr error
+	// DeleteSessionErr is returned by DeleteSessionAndWait when set
+	DeleteSessionErr error
+	// SetRouteErr is returned by SetRoute when set
+	SetRouteErr error
+	// SetRouteFailures, when positive, is the number of calls to SetRoute
+	// returning SetRouteErr before the route is set
+	SetRouteFailures int
+	// RemoveRouteErr is returned by RemoveRoute when set
+	RemoveRouteErr error
+	// SubscribeErr is returned by Subscribe when set
+	SubscribeErr error
+	// NewSession, when set, is used to customize every session created by the app
+	NewSession func(session *FakeSession)
+}
+
+// NewFakeApp creates an empty FakeApp
+func NewFakeApp() *FakeApp {
+	return &FakeApp{
+		sessions: make(map[uint32]*FakeSession),
+		incoming: make(chan slimcommon.Session, 16),
+	}
+}
+
+// CreateSessionAndWait implements slimcommon.App
+func (a *FakeApp) CreateSessionAndWait(config slim.SessionConfig, destination *slim.Name) (slimcommon.Session, error) {
+	a.mutex.Lock()
+	if a.CreateSessionErr != nil {
+		a.mutex.Unlock()
+		return nil, a.CreateSessionErr
+	}
+	session := a.newSession(config, destination.String())
+	a.mutex.Unlock()
+
+	if a.network != nil {
+		a.network.join(session)
+	}
+	return session, nil
+}
+
+// newSession adds a session towards destination to the app. The caller must
+// hold the lock of the app.
+func (a *FakeApp) newSession(config slim.SessionConfig, destination string) *FakeSession {
+	a.nextID++
+	session := NewFakeSession(a.nextID, destination)
+	session.Config = config
+	session.network = a.network
+	session.owner = a.name
+	if a.NewSession != nil {
+		a.NewSession(session)
+	}
+	a.sessions[session.id] = session
+	return session
+}
+
+// DeleteSessionAndWait implements slimcommon.App
+func (a *FakeApp) DeleteSessionAndWait(session slimcommon.Session) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.DeleteSessionErr != nil {
+		return a.DeleteSessionErr
+	}
+
+	id, err := session.SessionId()
+	if err != nil {
+		return err
+	}
+	if s, ok := session.(*FakeSession); ok {
+		s.Close()
+		if s.network != nil {
+			s.network.leave(s)
+		}
+	}
+	delete(a.sessions, id)
+	a.deleted = append(a.deleted, id)
+	return nil
+}
+
+// ListenForSession implements slimcommon.App. It returns the sessions
+// queued with Invite, or ErrListenTimeout if none arrives within timeout.
+func (a *FakeApp) ListenForSession(timeout *time.Duration) (slimcommon.Session, error) {
+	wait := time.Second
+	if timeout != nil {
+		wait = *timeout
+	}
+
+	select {
+	case session := <-a.incoming:
+		return session, nil
+	case <-time.After(wait):
+		return nil, ErrListenTimeout
+	}
+}
+
+// SetRoute implements slimcommon.App
+func (a *FakeApp) SetRoute(name *slim.Name, _ uint64) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.SetRouteErr != nil {
+		if a.SetRouteFailures == 0 {
+			return a.SetRouteErr
+		}
+		err := a.SetRouteErr
+		a.SetRouteFailures--
+		if a.SetRouteFailures == 0 {
+			a.SetRouteErr = nil
+		}
+		return err
+	}
+	a.routes = append(a.routes, name.String())
+	return nil
+}
+
+// RemoveRoute implements slimcommon.App
+func (a *FakeApp) RemoveRoute(name *slim.Name, _ uint64) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.RemoveRouteErr != nil {
+		return a.RemoveRouteErr
+	}
+	a.routes = slices.DeleteFunc(a.routes, func(route string) bool { return route == name.String() })
+	return nil
+}
+
+// Subscribe implements slimcommon.App
+func (a *FakeApp) Subscribe(_ *slim.Name, connID uint64) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.SubscribeErr != nil {
+		return a.SubscribeErr
+	}
+	a.subscribed = append(a.subscribed, connID)
+	return nil
+}
+
+// Destroy implements slimcommon.App
+func (a *FakeApp) Destroy() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.destroyed = true
+}
+
+// Invite simulates an invitation from a remote participant: the session is
+// returned by the next call to ListenForSession.
+func (a *FakeApp) Invite(session slimcommon.Session) {
+	a.incoming <- session
+}
+
+// Sessions returns the sessions currently open on the app
+func (a *FakeApp) Sessions() []*FakeSession {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	sessions := make([]*FakeSession, 0, len(a.sessions))
+	for _, s := range a.sessions {
+		sessions = append(sessions, s)
+	}
+	return sessions
+}
+
+// SessionByName returns the open session towards the given destination, or nil
+func (a *FakeApp) SessionByName(name string) *FakeSession {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for _, s := range a.sessions {
+		if s.destination == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Subscriptions returns the connections through which the app subscribed
+func (a *FakeApp) Subscriptions() []uint64 {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return slices.Clone(a.subscribed)
+}
+
+// Routes returns the names for which a route was set
+func (a *FakeApp) Routes() []string {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return slices.Clone(a.routes)
+}
+
+// DeletedSessions returns the IDs of the sessions deleted through the app
+func (a *FakeApp) DeletedSessions() []uint32 {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return slices.Clone(a.deleted)
+}
+
+// Destroyed reports whether Destroy was called
+func (a *FakeApp) Destroyed() bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.destroyed
+}
+
+// FakeSession is an in-memory implementation of slimcommon.Session. Errors
+// can be injected by setting the corresponding *Err fields before use.
+type FakeSession struct {
+	mutex sync.Mutex
+
+	id           uint32
+	destination  string
+	participants []string
+	published    []slim.ReceivedMessage
+	messages     chan slim.ReceivedMessage
+	closed       bool
+
+	// network and owner are set for the sessions of the apps of a
+	// FakeNetwork, owner being the name of the app
+	network *FakeNetwork
+	owner   string
+
+	// Config is the configuration the session was created with
+	Config slim.SessionConfig
+
+	// PublishErr is returned by PublishAndWait when set
+	PublishErr error
+	// InviteErr is returned by InviteAndWait when set
+	InviteErr error
+	// RemoveErr is returned by RemoveAndWait when set
+	RemoveErr error
+	// ParticipantsErr is returned by ParticipantsList when set
+	ParticipantsErr error
+	// OnPublish, when set, is called with every message published on the
+	// session, e.g. to deliver a reply
+	OnPublish func(msg slim.ReceivedMessage)
+}
+
+// NewFakeSession creates a session with the given ID towards destination
+func NewFakeSession(id uint32, destination string) *FakeSession {
+	return &FakeSession{
+		id:          id,
+		destination: destination,
+		messages:    make(chan slim.ReceivedMessage, 64),
+	}
+}
+
+// SessionId implements slimcommon.Session
+func (s *FakeSession) SessionId() (uint32, error) { //nolint:revive // mirrors the SLIM bindings API
+	return s.id, nil
+}
+
+// Destination implements slimcommon.Session
+func (s *FakeSession) Destination() (*slim.Name, error) {
+	return slimcommon.SplitID(s.destination)
+}
+
+// PublishAndWait implements slimcommon.Session
+func (s *FakeSession) PublishAndWait(data []byte, payloadType *string, metadata *map[string]string) error {
+	s.mutex.Lock()
+
+	if s.closed {
+		s.mutex.Unlock()
+		return ErrSessionDropped
+	}
+	if s.PublishErr != nil {
+		s.mutex.Unlock()
+		return s.PublishErr
+	}
+	msg := slim.ReceivedMessage{Payload: slices.Clone(data)}
+	if payloadType != nil {
+		msg.Context.PayloadType = *payloadType
+	}
+	if metadata != nil {
+		msg.Context.Metadata = maps.Clone(*metadata)
+	}
+	s.published = append(s.published, msg)
+	onPublish := s.OnPublish
+	s.mutex.Unlock()
+
+	if s.network != nil {
+		s.network.deliver(s, msg)
+	}
+	if onPublish != nil {
+		onPublish(msg)
+	}
+	return nil
+}
+
+// GetMessage implements slimcommon.Session. It returns the messages queued
+// with Deliver, ErrReceiveTimeout if none arrives within timeout, or
+// ErrSessionClosed once the session is closed and drained.
+func (s *FakeSession) GetMessage(timeout *time.Duration) (slim.ReceivedMessage, error) {
+	wait := time.Second
+	if timeout != nil {
+		wait = *timeout
+	}
+
+	select {
+	case msg, ok := <-s.messages:
+		if !ok {
+			return slim.ReceivedMessage{}, ErrSessionClosed
+		}
+		return msg, nil
+	case <-time.After(wait):
+		return slim.ReceivedMessage{}, ErrReceiveTimeout
+	}
+}
+
+// InviteAndWait implements slimcommon.Session
+func (s *FakeSession) InviteAndWait(participant *slim.Name) error {
+	s.mutex.Lock()
+	if s.InviteErr != nil {
+		s.mutex.Unlock()
+		return s.InviteErr
+	}
+	s.participants = append(s.participants, participant.String())
+	s.mutex.Unlock()
+
+	if s.network != nil {
+		s.network.invite(s, participant.String())
+	}
+	return nil
+}
+
+// RemoveAndWait implements slimcommon.Session
+func (s *FakeSession) RemoveAndWait(participant *slim.Name) error {
+	s.mutex.Lock()
+	if s.RemoveErr != nil {
+		s.mutex.Unlock()
+		return s.RemoveErr
+	}
+	name := participant.String()
+	idx := slices.Index(s.participants, name)
+	if idx < 0 {
+		s.mutex.Unlock()
+		return errors.New("participant " + name + " not found")
+	}
+	s.participants = slices.Delete(s.participants, idx, idx+1)
+	s.mutex.Unlock()
+
+	if s.network != nil {
+		s.network.remove(s, name)
+	}
+	return nil
+}
+
+// Metadata implements slimcommon.Session, it returns the metadata of Config
+func (s *FakeSession) Metadata() (map[string]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return maps.Clone(s.Config.Metadata), nil
+}
+
+// SessionConfig implements slimcommon.Session, it returns Config
+func (s *FakeSession) SessionConfig() (slim.SessionConfig, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.Config, nil
+}
+
+// ParticipantsList implements slimcommon.Session
+func (s *FakeSession) ParticipantsList() ([]*slim.Name, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.ParticipantsErr != nil {
+		return nil, s.ParticipantsErr
+	}
+	names := make([]*slim.Name, 0, len(s.participants))
+	for _, p := range s.participants {
+		name, err := slimcommon.SplitID(p)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// Deliver queues a message that will be returned by GetMessage. Messages
+// delivered after Close are dropped.
+func (s *FakeSession) Deliver(payload []byte) {
+	s.DeliverMessage(slim.ReceivedMessage{Payload: payload})
+}
+
+// DeliverMessage queues a message, including its context, that will be
+// returned by GetMessage. Messages delivered after Close are dropped.
+func (s *FakeSession) DeliverMessage(msg slim.ReceivedMessage) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.closed {
+		s.messages <- msg
+	}
+}
+
+// Close marks the session as closed: pending messages are still delivered,
+// then GetMessage returns ErrSessionClosed and PublishAndWait ErrSessionDropped.
+func (s *FakeSession) Close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.messages)
+	}
+}
+
+// Closed reports whether the session was closed
+func (s *FakeSession) Closed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.closed
+}
+
+// Participants returns the names of the participants invited to the session
+func (s *FakeSession) Participants() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return slices.Clone(s.participants)
+}
+
+// Published returns the payloads published on the session
+func (s *FakeSession) Published() [][]byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	payloads := make([][]byte, 0, len(s.published))
+	for _, msg := range s.published {
+		payloads = append(payloads, msg.Payload)
+	}
+	return payloads
+}
+
+// PublishedMessages returns the messages published on the session, with the
+// payload type and the metadata set by the publisher
+func (s *FakeSession) PublishedMessages() []slim.ReceivedMessage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return slices.Clone(s.published)
+}
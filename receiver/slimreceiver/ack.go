@@ -0,0 +1,33 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// acknowledge publishes on the session the acknowledgement of a consumed
+// message, if acknowledgements are enabled and the exporter requested one by
+// setting a message ID in the message metadata
+func acknowledge(ctx context.Context, r *slimReceiver, session slimcommon.Session, msg slim.ReceivedMessage) {
+	if !r.config.Acknowledgements {
+		return
+	}
+	id, ok := msg.Context.Metadata[slimcommon.MetadataMessageID]
+	if !ok {
+		return
+	}
+
+	payloadType := slimcommon.PayloadTypeAck
+	metadata := map[string]string{slimcommon.MetadataMessageID: id}
+	if err := session.PublishAndWait([]byte{}, &payloadType, &metadata); err != nil {
+		slimcommon.LoggerFromContextOrDefault(ctx).Warn("Failed to acknowledge message",
+			zap.String("message_id", id), zap.Error(err))
+	}
+}
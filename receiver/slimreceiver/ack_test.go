@@ -0,0 +1,105 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestHandleSession_Acknowledgements(t *testing.T) {
+	withID := func(payload []byte, id string) slim.ReceivedMessage {
+		msg := slim.ReceivedMessage{Payload: payload}
+		msg.Context.Metadata = map[string]string{slimcommon.MetadataMessageID: id}
+		return msg
+	}
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		err      error
+		wantAcks []string
+	}{
+		{
+			name:     "acknowledges consumed messages",
+			enabled:  true,
+			wantAcks: []string{"msg-1"},
+		},
+		{
+			name:    "disabled",
+			enabled: false,
+		},
+		{
+			name:    "consumer error is not acknowledged",
+			enabled: true,
+			err:     errors.New("consumer failure"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var consumed atomic.Int32
+			tracesConsumer, err := consumer.NewTraces(func(context.Context, ptrace.Traces) error {
+				consumed.Add(1)
+				return tt.err
+			})
+			require.NoError(t, err)
+
+			r := &slimReceiver{
+				config:         &Config{Acknowledgements: tt.enabled},
+				app:            testutil.NewFakeApp(),
+				sessions:       slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+				tracesConsumer: tracesConsumer,
+			}
+
+			session := testutil.NewFakeSession(1, "agntcy/otel/channel-traces")
+			require.NoError(t, r.sessions.AddSession(t.Context(), session))
+
+			ack := slim.ReceivedMessage{}
+			ack.Context.PayloadType = slimcommon.PayloadTypeAck
+			ack.Context.Metadata = map[string]string{slimcommon.MetadataMessageID: "other-exporter-1"}
+
+			session.DeliverMessage(withID(tracesPayload(t, "span"), "msg-1"))
+			// acknowledgements for other exporters are not consumed
+			session.DeliverMessage(ack)
+			// nor are the freeze notifications
+			freeze := slim.ReceivedMessage{}
+			freeze.Context.PayloadType = slimcommon.PayloadTypeFreeze
+			freeze.Context.Metadata = slimcommon.FreezeMetadata(true, "")
+			session.DeliverMessage(freeze)
+			// messages without ID are not acknowledged
+			session.Deliver(tracesPayload(t, "span"))
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go handleSession(t.Context(), &wg, r, session)
+
+			// the acknowledgements are published before the session is closed
+			assert.Eventually(t, func() bool { return consumed.Load() == 2 }, time.Second, 10*time.Millisecond)
+			session.Close()
+			wg.Wait()
+
+			var acks []string
+			for _, msg := range session.PublishedMessages() {
+				assert.Equal(t, slimcommon.PayloadTypeAck, msg.Context.PayloadType)
+				acks = append(acks, msg.Context.Metadata[slimcommon.MetadataMessageID])
+			}
+			assert.Equal(t, tt.wantAcks, acks)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"time"
+
+	slim "github.com/agntcy/slim-bindings-go"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+const (
+	// CatchUpOldestFirst consumes the messages in the order they are received
+	CatchUpOldestFirst = "oldest-first"
+	// CatchUpNewestFirst consumes the live messages first, then the backlog
+	// from the newest to the oldest message
+	CatchUpNewestFirst = "newest-first"
+
+	// defaultCatchUpLag is the default age above which a message belongs to
+	// the backlog
+	defaultCatchUpLag = 30 * time.Second
+	// defaultCatchUpMaxBacklog is the default maximum number of messages of a
+	// session read ahead to reorder them
+	defaultCatchUpMaxBacklog = 1000
+)
+
+// lag returns the age above which a message belongs to the backlog
+func (cfg *CatchUpConfig) lag() time.Duration {
+	if cfg.Lag > 0 {
+		return cfg.Lag
+	}
+	return defaultCatchUpLag
+}
+
+// maxBacklog returns the maximum number of messages read ahead
+func (cfg *CatchUpConfig) maxBacklog() int {
+	if cfg.MaxBacklog > 0 {
+		return cfg.MaxBacklog
+	}
+	return defaultCatchUpMaxBacklog
+}
+
+// isBacklog reports whether the message with the given metadata is older
+// than the lag at now. The messages without publication time are live.
+func (cfg *CatchUpConfig) isBacklog(metadata map[string]string, now time.Time) bool {
+	sentAt, ok := slimcommon.SentAt(metadata)
+	return ok && now.Sub(sentAt) > cfg.lag()
+}
+
+// stale reports whether the message with the given metadata is older than
+// the max age at now, and must be dropped
+func (cfg *CatchUpConfig) stale(metadata map[string]string, now time.Time) bool {
+	if cfg.MaxAge <= 0 {
+		return false
+	}
+	sentAt, ok := slimcommon.SentAt(metadata)
+	return ok && now.Sub(sentAt) > cfg.MaxAge
+}
+
+// newestFirst returns the messages of in, the live ones in order first and
+// then the backlog from the newest to the oldest message. Up to max backlog
+// messages are read ahead of the consumer, the next ones wait in SLIM. The
+// returned channel is closed once in is closed and the messages read ahead
+// are delivered, or when ctx is done.
+func (cfg *CatchUpConfig) newestFirst(
+	ctx context.Context,
+	in <-chan slim.ReceivedMessage,
+) <-chan slim.ReceivedMessage {
+	out := make(chan slim.ReceivedMessage)
+	go func() {
+		defer close(out)
+
+		var live, backlog []slim.ReceivedMessage
+		for in != nil || len(live)+len(backlog) > 0 {
+			var next slim.ReceivedMessage
+			var send chan<- slim.ReceivedMessage
+			switch {
+			case len(live) > 0:
+				next, send = live[0], out
+			case len(backlog) > 0:
+				next, send = backlog[len(backlog)-1], out
+			}
+			receive := in
+			if len(live)+len(backlog) >= cfg.maxBacklog() {
+				receive = nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-receive:
+				if !ok {
+					in = nil
+					continue
+				}
+				if cfg.isBacklog(msg.Context.Metadata, time.Now()) {
+					backlog = append(backlog, msg)
+				} else {
+					live = append(live, msg)
+				}
+			case send <- next:
+				if len(live) > 0 {
+					live = live[1:]
+				} else {
+					backlog = backlog[:len(backlog)-1]
+				}
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,105 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slim "github.com/agntcy/slim-bindings-go"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// sentMessage returns a message published age ago with the given payload
+func sentMessage(payload string, age time.Duration) slim.ReceivedMessage {
+	msg := slim.ReceivedMessage{Payload: []byte(payload)}
+	msg.Context.Metadata = make(map[string]string)
+	slimcommon.AddSentAt(msg.Context.Metadata, time.Now().Add(-age))
+	return msg
+}
+
+// payloads reads the messages of ch until it is closed and returns their payloads
+func payloads(t *testing.T, ch <-chan slim.ReceivedMessage) []string {
+	t.Helper()
+	var received []string
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return received
+			}
+			received = append(received, string(msg.Payload))
+		case <-timeout:
+			require.FailNow(t, "the channel was not closed")
+		}
+	}
+}
+
+func TestCatchUpConfig_Stale(t *testing.T) {
+	now := time.Now()
+	old := sentMessage("old", time.Hour).Context.Metadata
+
+	cfg := CatchUpConfig{}
+	assert.False(t, cfg.stale(old, now), "no max age keeps all the messages")
+
+	cfg.MaxAge = 10 * time.Minute
+	assert.True(t, cfg.stale(old, now))
+	assert.False(t, cfg.stale(sentMessage("new", time.Minute).Context.Metadata, now))
+	assert.False(t, cfg.stale(nil, now), "messages without publication time are kept")
+}
+
+func TestCatchUpConfig_NewestFirst(t *testing.T) {
+	t.Run("live messages first, then the backlog newest first", func(t *testing.T) {
+		cfg := CatchUpConfig{Lag: time.Minute}
+		in := make(chan slim.ReceivedMessage, 6)
+		in <- sentMessage("backlog-1", 3*time.Hour)
+		in <- sentMessage("backlog-2", 2*time.Hour)
+		in <- sentMessage("live-1", time.Second)
+		in <- sentMessage("backlog-3", time.Hour)
+		in <- slim.ReceivedMessage{Payload: []byte("live-2")}
+		in <- sentMessage("live-3", 0)
+		close(in)
+
+		out := cfg.newestFirst(t.Context(), in)
+		// wait for the messages to be read ahead before consuming them
+		require.Eventually(t, func() bool { return len(in) == 0 }, 5*time.Second, 10*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+
+		assert.Equal(t, []string{"live-1", "live-2", "live-3", "backlog-3", "backlog-2", "backlog-1"},
+			payloads(t, out))
+	})
+
+	t.Run("read ahead is bounded", func(t *testing.T) {
+		cfg := CatchUpConfig{MaxBacklog: 2}
+		in := make(chan slim.ReceivedMessage, 3)
+		for _, payload := range []string{"1", "2", "3"} {
+			in <- sentMessage(payload, time.Hour)
+		}
+
+		out := cfg.newestFirst(t.Context(), in)
+		require.Eventually(t, func() bool { return len(in) == 1 }, 5*time.Second, 10*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		assert.Len(t, in, 1, "only max backlog messages are read ahead")
+		close(in)
+
+		// consuming a message makes room for the next one
+		assert.Equal(t, "2", string((<-out).Payload))
+		require.Eventually(t, func() bool { return len(in) == 0 }, 5*time.Second, 10*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, []string{"3", "1"}, payloads(t, out))
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		out := (&CatchUpConfig{}).newestFirst(ctx, make(chan slim.ReceivedMessage))
+		cancel()
+		assert.Empty(t, payloads(t, out))
+	})
+}
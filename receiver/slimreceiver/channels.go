@@ -0,0 +1,135 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+const (
+	defaultMaxRetries = 10
+	defaultIntervalMs = 1000
+)
+
+// createSessionsAndInvite creates a group session for each configured channel,
+// invites its participants and adds it to the sessions list. It returns the
+// created sessions, which are handled like the sessions the receiver is
+// invited to.
+func createSessionsAndInvite(ctx context.Context, r *slimReceiver) ([]slimcommon.Session, error) {
+	var created []slimcommon.Session
+	for _, config := range r.config.Channels {
+		session, err := createChannel(ctx, r, config)
+		if err != nil {
+			return created, err
+		}
+		created = append(created, session)
+	}
+
+	return created, nil
+}
+
+// createChannel creates the group session of a configured channel, invites
+// its participants and adds it to the sessions list
+func createChannel(ctx context.Context, r *slimReceiver, config ChannelsConfig) (slimcommon.Session, error) {
+	session, err := createGroupSession(r, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.sessions.AddSession(ctx, session); err != nil {
+		_ = r.app.DeleteSessionAndWait(session)
+		return nil, fmt.Errorf("failed to add session for channel %s: %w", config.ChannelName, err)
+	}
+
+	slimcommon.LoggerFromContextOrDefault(ctx).Info("Created session and invited participants",
+		zap.String("channel", config.ChannelName),
+		zap.Strings("participants", config.Participants))
+	return session, nil
+}
+
+// createGroupSession creates a group session on the channel and invites all
+// the participants. The session is closed if an invitation fails.
+func createGroupSession(r *slimReceiver, config ChannelsConfig) (slimcommon.Session, error) {
+	channel := config.ChannelName
+	name, err := slimcommon.SplitID(channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse channel name: %w", err)
+	}
+
+	interval := time.Millisecond * defaultIntervalMs
+	sessionConfig := slim.SessionConfig{
+		SessionType: slim.SessionTypeGroup,
+		EnableMls:   config.MlsEnabled,
+		MaxRetries:  &[]uint32{defaultMaxRetries}[0],
+		Interval:    &interval,
+		Metadata:    map[string]string{slimcommon.MetadataInitiator: r.config.ReceiverName},
+	}
+
+	session, err := r.app.CreateSessionAndWait(sessionConfig, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the session for channel %s: %w", channel, err)
+	}
+
+	for _, participant := range config.Participants {
+		if err := inviteParticipant(r, session, participant); err != nil {
+			_ = r.app.DeleteSessionAndWait(session)
+			return nil, fmt.Errorf("failed to invite participant %s for channel %s: %w", participant, channel, err)
+		}
+	}
+
+	return session, nil
+}
+
+// inviteParticipant sets the route to the participant and invites it to the session
+func inviteParticipant(r *slimReceiver, session slimcommon.Session, participant string) error {
+	participantName, err := slimcommon.SplitID(participant)
+	if err != nil {
+		return err
+	}
+	if err := r.app.SetRoute(participantName, r.connID); err != nil {
+		return err
+	}
+	return session.InviteAndWait(participantName)
+}
+
+// restoreRoutes restores the channels created by the receiver through the
+// connection to the server it failed over to. The sessions of the channels
+// were established through the lost connection: they are closed and created
+// again, which sets the routes to the participants and invites them, and the
+// new sessions are handled until ctx is done.
+func (r *slimReceiver) restoreRoutes(ctx context.Context, connID uint64) error {
+	r.connID = connID
+
+	var errs error
+	for _, config := range r.config.Channels {
+		name, err := slimcommon.SplitID(config.ChannelName)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to parse channel name: %w", err))
+			continue
+		}
+		if session, err := r.sessions.RemoveSessionByName(ctx, name.String()); err == nil {
+			if err := r.app.DeleteSessionAndWait(session); err != nil {
+				slimcommon.LoggerFromContextOrDefault(ctx).Warn("Failed to close the channel",
+					zap.String("channel", config.ChannelName), zap.Error(err))
+			}
+		}
+
+		session, err := createChannel(ctx, r, config)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		r.handlers.Add(1)
+		go handleSession(ctx, &r.handlers, r, session)
+	}
+	return errs
+}
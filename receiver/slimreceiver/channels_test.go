@@ -0,0 +1,138 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// TestCreateSessionsAndInvite tests the creation of the channels owned by the receiver
+func TestCreateSessionsAndInvite(t *testing.T) {
+	newReceiver := func(channels ...ChannelsConfig) (*slimReceiver, *testutil.FakeApp) {
+		app := testutil.NewFakeApp()
+		return &slimReceiver{
+			config:   &Config{Channels: channels},
+			app:      app,
+			sessions: slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		}, app
+	}
+
+	t.Run("channels are created and exporters invited", func(t *testing.T) {
+		r, app := newReceiver(
+			ChannelsConfig{
+				ChannelName:  "agntcy/otel/channel-traces",
+				Participants: []string{"agntcy/otel/exporter-traces"},
+				MlsEnabled:   true,
+			},
+			ChannelsConfig{
+				ChannelName:  "agntcy/otel/channel-logs",
+				Participants: []string{"agntcy/otel/exporter-logs-1", "agntcy/otel/exporter-logs-2"},
+			},
+		)
+
+		created, err := createSessionsAndInvite(t.Context(), r)
+		require.NoError(t, err)
+		assert.Len(t, created, 2)
+
+		traces := app.SessionByName("agntcy/otel/channel-traces")
+		require.NotNil(t, traces)
+		assert.Equal(t, slim.SessionTypeGroup, traces.Config.SessionType)
+		assert.True(t, traces.Config.EnableMls)
+		assert.Equal(t, []string{"agntcy/otel/exporter-traces"}, traces.Participants())
+
+		logs := app.SessionByName("agntcy/otel/channel-logs")
+		require.NotNil(t, logs)
+		assert.ElementsMatch(t, []string{"agntcy/otel/exporter-logs-1", "agntcy/otel/exporter-logs-2"}, logs.Participants())
+
+		assert.ElementsMatch(t,
+			[]string{"agntcy/otel/channel-traces", "agntcy/otel/channel-logs"},
+			r.sessions.ListSessionNames(t.Context()))
+	})
+
+	t.Run("session creation fails", func(t *testing.T) {
+		r, app := newReceiver(ChannelsConfig{
+			ChannelName:  "agntcy/otel/channel",
+			Participants: []string{"agntcy/otel/exporter-traces"},
+		})
+		app.CreateSessionErr = errors.New("boom")
+
+		_, err := createSessionsAndInvite(t.Context(), r)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to create the session for channel agntcy/otel/channel")
+		assert.Empty(t, r.sessions.ListSessionNames(t.Context()))
+	})
+
+	t.Run("invalid participant closes the session", func(t *testing.T) {
+		r, app := newReceiver(ChannelsConfig{
+			ChannelName:  "agntcy/otel/channel",
+			Participants: []string{"invalid"},
+		})
+
+		_, err := createSessionsAndInvite(t.Context(), r)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to invite participant invalid")
+		assert.Len(t, app.DeletedSessions(), 1)
+		assert.Empty(t, r.sessions.ListSessionNames(t.Context()))
+	})
+}
+
+// TestRestoreRoutes tests that the channels created by the receiver are
+// created again through the connection of the server it failed over to
+func TestRestoreRoutes(t *testing.T) {
+	app := testutil.NewFakeApp()
+	r := &slimReceiver{
+		config: &Config{Channels: []ChannelsConfig{
+			{ChannelName: "agntcy/otel/channel-traces", Participants: []string{"agntcy/otel/exporter-traces"}},
+			{ChannelName: "agntcy/otel/channel-logs", Participants: []string{"agntcy/otel/exporter-logs"}},
+		}},
+		app:      app,
+		connID:   1,
+		sessions: slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+	}
+	created, err := createSessionsAndInvite(t.Context(), r)
+	require.NoError(t, err)
+	require.Len(t, created, 2)
+	invited := testutil.NewFakeSession(100, "agntcy/otel/channel-invited")
+	require.NoError(t, r.sessions.AddSession(t.Context(), invited))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer func() {
+		cancel()
+		r.handlers.Wait()
+	}()
+	require.NoError(t, r.restoreRoutes(ctx, 2))
+
+	assert.Equal(t, uint64(2), r.connID)
+	assert.Subset(t, app.DeletedSessions(), []uint32{1, 2}, "the sessions of the lost connection are closed")
+	assert.NotContains(t, app.DeletedSessions(), uint32(100), "the sessions the receiver is invited to are kept")
+	for _, channel := range []string{"agntcy/otel/channel-traces", "agntcy/otel/channel-logs"} {
+		session := app.SessionByName(channel)
+		require.NotNil(t, session, channel)
+		id, err := session.SessionId()
+		require.NoError(t, err)
+		assert.Greater(t, id, uint32(2), "the channel %s is created again", channel)
+		assert.Len(t, session.Participants(), 1)
+	}
+	assert.ElementsMatch(t,
+		[]string{"agntcy/otel/channel-traces", "agntcy/otel/channel-logs", "agntcy/otel/channel-invited"},
+		r.sessions.ListSessionNames(t.Context()))
+	assert.Equal(t, []string{
+		"agntcy/otel/exporter-traces", "agntcy/otel/exporter-logs",
+		"agntcy/otel/exporter-traces", "agntcy/otel/exporter-logs",
+	}, app.Routes())
+
+	app.SetRouteErr = errors.New("boom")
+	assert.ErrorContains(t, r.restoreRoutes(t.Context(), 3), "failed to invite participant agntcy/otel/exporter-traces")
+	assert.Equal(t, uint64(3), r.connID)
+}
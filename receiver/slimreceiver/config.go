@@ -5,39 +5,450 @@ package slimreceiver
 
 import (
 	"errors"
+	"fmt"
+	"path"
+	"time"
 
+	"go.opentelemetry.io/collector/component"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
 	"github.com/agntcy/slim-otel/slimconfig"
 )
 
 // Config represents the receiver config settings in the Collector config.yaml
 type Config struct {
+	// SLIM connection extension the app of the receiver is created on. When
+	// set, the connection config, the shared secret and the auth are taken
+	// from the extension, which does not fail over to backup servers
+	Connection *component.ID `mapstructure:"connection"`
+
 	// Connection configuration for the SLIM server
 	ConnectionConfig *slimconfig.ConnectionConfig `mapstructure:"connection-config"`
 
+	// Connection configurations of the SLIM servers to fail over to, in order
+	// of preference, when the connection-config server is unreachable
+	BackupConnections []slimconfig.ConnectionConfig `mapstructure:"backup-connections"`
+
+	// Period of the connection health check. Zero uses the default
+	HealthCheckInterval time.Duration `mapstructure:"health-check-interval"`
+
 	// Receiver name for different signals
 	ReceiverName string `mapstructure:"receiver-name"`
 
 	// Shared Secret
-	SharedSecret string `mapstructure:"shared-secret"`
+	SharedSecret slimconfig.Opaque `mapstructure:"shared-secret"`
+
+	// Identity of the receiver app towards the other participants. When not
+	// set, the shared secret is used
+	Auth *slimconfig.IdentityConfig `mapstructure:"auth"`
+
+	// Time window during which the payloads received on a session are merged
+	// into a single batch before being consumed. Zero disables merging
+	MergeWindow time.Duration `mapstructure:"merge-window"`
+
+	// Maximum number of payloads merged into a single batch. Zero means no
+	// limit other than the merge window
+	MergeMaxMessages int `mapstructure:"merge-max-messages"`
+
+	// Acknowledge the consumed messages to the exporters that request it
+	Acknowledgements bool `mapstructure:"acknowledgements"`
+
+	// Severity applied to the log records without severity, unless the
+	// channel advertises its own default. Empty leaves the records unchanged
+	DefaultLogSeverity string `mapstructure:"default-log-severity"`
+
+	// Naming convention of the exporters named for their signal, with the
+	// {name} and {signal} placeholders, telling the signal of the messages
+	// whose metadata does not. Defaults to {name}-{signal}
+	SignalNameTemplate string `mapstructure:"signal-name-template"`
+
+	// Channels created by the receiver, which invites the exporters to them.
+	// Empty leaves the receiver waiting for invitations only
+	Channels []ChannelsConfig `mapstructure:"channels"`
+
+	// Address of the HTTP endpoint draining the receiver before it shuts
+	// down, e.g. from a Kubernetes preStop hook. Empty disables the endpoint
+	DrainEndpoint string `mapstructure:"drain-endpoint"`
+
+	// Maximum time to wait for the receiver to leave its channels when
+	// drained. Zero uses the default
+	DrainTimeout time.Duration `mapstructure:"drain-timeout"`
+
+	// Address of the HTTP endpoint listing the producers of each channel on
+	// /debug/producers. Empty disables the endpoint
+	DebugEndpoint string `mapstructure:"debug-endpoint"`
+
+	// Number of workers decoding the received payloads, shared by all the
+	// sessions. Zero decodes the payloads in the session handlers
+	DecodeWorkers int `mapstructure:"decode-workers"`
+
+	// Decoding time each channel may spend per second in the decode workers,
+	// the payloads of a channel over its budget wait. Zero means no budget
+	ChannelDecodeBudget time.Duration `mapstructure:"channel-decode-budget"`
+
+	// Number of leading bytes of the payloads that cannot be decoded dumped
+	// in hexadecimal at debug level, to identify their producer. Zero
+	// disables the dump
+	DecodeFailureDumpBytes int `mapstructure:"decode-failure-dump-bytes"`
+
+	// Time the SLIM app is kept after the receiver shuts down, accepting the
+	// invitations, for a receiver restarted with the same name to take over
+	// its sessions. Zero closes them at shutdown
+	RestartGracePeriod time.Duration `mapstructure:"restart-grace-period"`
+
+	// Maximum number of messages processed at once across all the sessions,
+	// including the payloads merged within the merge window. Zero means no
+	// limit
+	MaxInFlightMessages int `mapstructure:"max-in-flight-messages"`
+
+	// Maximum total size in bytes of the messages processed at once across
+	// all the sessions. Zero means no limit
+	MaxInFlightBytes int `mapstructure:"max-in-flight-bytes"`
+
+	// Maximum number of sessions the receiver accepts, the channels it
+	// creates are not counted. Zero means no limit
+	MaxSessions int `mapstructure:"max-sessions"`
+
+	// Maximum number of sessions the receiver accepts with each peer. Zero
+	// means no limit
+	MaxSessionsPerPeer int `mapstructure:"max-sessions-per-peer"`
+
+	// Patterns of the sources the receiver accepts telemetry from, in
+	// org/namespace/app format with the path.Match wildcards, matched
+	// against the sender of every message and the peer of a point-to-point
+	// session. Empty accepts the telemetry of any source
+	AllowedSources []string `mapstructure:"allowed-sources"`
+
+	// Time after which a session without any received message is closed,
+	// the channels created by the receiver are kept. Zero keeps the idle
+	// sessions open
+	IdleTimeout time.Duration `mapstructure:"idle-timeout"`
+
+	// Processing of the backlog of messages received after the receiver
+	// rejoined a channel, e.g. after a reconnect
+	CatchUp CatchUpConfig `mapstructure:"catch-up"`
+
+	// Resource attributes identifying the SLIM transport added to the
+	// resources of the received data
+	ResourceAttributes ResourceAttributesConfig `mapstructure:"resource-attributes"`
+
+	// Report of the ingestion of each channel emitted when the receiver
+	// shuts down
+	ShutdownReport ShutdownReportConfig `mapstructure:"shutdown-report"`
 }
 
-// Validate checks if the receiver configuration is valid
-func (cfg *Config) Validate() error {
+// ShutdownReportConfig enables the report of the messages received on each
+// channel, emitted when the receiver shuts down, e.g. for short-lived
+// collectors of batch jobs to confirm that all the data was ingested
+type ShutdownReportConfig struct {
+	// Log the report at Info level, one entry per channel
+	Enabled bool `mapstructure:"enabled"`
+
+	// Also send the report as log records to the logs pipeline of the
+	// receiver
+	LogRecords bool `mapstructure:"log-records"`
+}
+
+// ResourceAttributesConfig selects the resource attributes identifying the
+// SLIM transport added to the received data. They replace the attributes of
+// the same name set by the sender.
+type ResourceAttributesConfig struct {
+	// Add the name of the channel as slim.channel
+	Channel bool `mapstructure:"channel"`
+
+	// Add the ID of the session as slim.session.id
+	SessionID bool `mapstructure:"session-id"`
+
+	// Add the name of the participant that sent the data as slim.source
+	Source bool `mapstructure:"source"`
+
+	// Add the name of the receiver as slim.receiver
+	Receiver bool `mapstructure:"receiver"`
+
+	// Add the ID of the exporter component that published the data as
+	// slim.component, when the exporter tags it
+	Component bool `mapstructure:"component"`
+
+	// Add the name of the exporter that produced the data as slim.producer,
+	// when the exporter wraps its payloads in the envelope
+	Producer bool `mapstructure:"producer"`
+
+	// Attributes added to the data of the senders whose SLIM identity
+	// matches, the first matching entry applies
+	Identities []IdentityAttributesConfig `mapstructure:"identities"`
+}
+
+// IdentityAttributesConfig maps the SLIM identities of the senders to the
+// resource attributes of their data, e.g. their team or environment, which
+// are then derived from the authenticated identity rather than trusted from
+// the sender
+type IdentityAttributesConfig struct {
+	// Pattern of the sender names, in org/namespace/app format with the
+	// path.Match wildcards, e.g. agntcy/team-a/*
+	Source string `mapstructure:"source"`
+
+	// Resource attributes added to the data of the matching senders
+	Attributes map[string]string `mapstructure:"attributes"`
+}
+
+// CatchUpConfig defines how the backlog of messages built up while the
+// receiver was away from a channel is consumed, so that the live telemetry
+// recovers quickly instead of waiting for hours of stale data
+type CatchUpConfig struct {
+	// Order in which the messages of the backlog are consumed: oldest-first
+	// (default) or newest-first, after the live messages
+	Order string `mapstructure:"order"`
+
+	// Age above which a received message belongs to the backlog. Zero uses
+	// the default
+	Lag time.Duration `mapstructure:"lag"`
+
+	// Age above which the received messages are dropped instead of consumed.
+	// Zero consumes them whatever their age
+	MaxAge time.Duration `mapstructure:"max-age"`
+
+	// Maximum number of messages of a session read ahead of their
+	// consumption to reorder them. Zero uses the default
+	MaxBacklog int `mapstructure:"max-backlog"`
+}
+
+// Validate checks if the resource attributes configuration is valid
+func (cfg *ResourceAttributesConfig) Validate() error {
+	for i, identity := range cfg.Identities {
+		if identity.Source == "" {
+			return fmt.Errorf("source pattern cannot be empty for identity %d", i)
+		}
+		if _, err := path.Match(identity.Source, ""); err != nil {
+			return fmt.Errorf("invalid source pattern '%s': %w", identity.Source, err)
+		}
+		if len(identity.Attributes) == 0 {
+			return fmt.Errorf("at least one attribute must be specified for identity %d", i)
+		}
+	}
+	return nil
+}
+
+// identityAttributes returns the attributes of the first identity matching
+// the sender name, nil if none matches
+func (cfg *ResourceAttributesConfig) identityAttributes(source string) map[string]string {
+	if source == "" {
+		return nil
+	}
+	for _, identity := range cfg.Identities {
+		if ok, _ := path.Match(identity.Source, source); ok {
+			return identity.Attributes
+		}
+	}
+	return nil
+}
+
+// Validate checks if the catch-up configuration is valid
+func (cfg *CatchUpConfig) Validate() error {
+	switch cfg.Order {
+	case "", CatchUpOldestFirst, CatchUpNewestFirst:
+	default:
+		return fmt.Errorf("invalid catch-up order '%s'", cfg.Order)
+	}
+	if cfg.Lag < 0 {
+		return errors.New("catch-up lag cannot be negative")
+	}
+	if cfg.MaxAge < 0 {
+		return errors.New("catch-up max age cannot be negative")
+	}
+	if cfg.MaxBacklog < 0 {
+		return errors.New("catch-up max backlog cannot be negative")
+	}
+	return nil
+}
+
+// ChannelsConfig defines a channel created by the receiver
+type ChannelsConfig struct {
+	// Channel name in the SLIM format
+	ChannelName string `mapstructure:"channel-name"`
+
+	// Exporters, or other participants, to invite to the channel
+	Participants []string `mapstructure:"participants"`
+
+	// Flag to enable or disable MLS for the channel
+	MlsEnabled bool `mapstructure:"mls-enabled"`
+}
+
+// endpoints returns the connection configurations of all the SLIM servers,
+// in order of preference
+func (cfg *Config) endpoints() []slimconfig.ConnectionConfig {
+	if cfg.ConnectionConfig == nil {
+		return nil
+	}
+	return append([]slimconfig.ConnectionConfig{*cfg.ConnectionConfig}, cfg.BackupConnections...)
+}
+
+// healthCheckInterval returns the period of the connection health check
+func (cfg *Config) healthCheckInterval() time.Duration {
+	if cfg.HealthCheckInterval > 0 {
+		return cfg.HealthCheckInterval
+	}
+	return slimcommon.DefaultHealthCheckInterval
+}
+
+// drainTimeout returns the maximum time to wait for the receiver to leave its
+// channels when drained
+func (cfg *Config) drainTimeout() time.Duration {
+	if cfg.DrainTimeout > 0 {
+		return cfg.DrainTimeout
+	}
+	return defaultDrainTimeout
+}
+
+// identity returns the identity of the receiver app, the auth config if set
+// and the shared secret otherwise
+func (cfg *Config) identity() slimconfig.IdentityConfig {
+	if cfg.Auth != nil {
+		return *cfg.Auth
+	}
+	return slimconfig.IdentityConfig{Type: "shared_secret", SharedSecret: cfg.SharedSecret}
+}
+
+// validateConnection checks the connection and identity of the receiver app
+// when it does not use a connection extension
+func (cfg *Config) validateConnection() error {
 	if cfg.ConnectionConfig == nil {
 		return errors.New("missing connection config")
 	}
 
 	if err := cfg.ConnectionConfig.Validate(); err != nil {
-		return errors.New("invalid connection config: " + err.Error())
+		return fmt.Errorf("invalid connection config: %w", err)
+	}
+
+	for i := range cfg.BackupConnections {
+		if err := cfg.BackupConnections[i].Validate(); err != nil {
+			return fmt.Errorf("invalid backup connection %d: %w", i, err)
+		}
 	}
 
-	if cfg.SharedSecret == "" {
+	if cfg.Auth != nil {
+		if err := cfg.Auth.Validate(); err != nil {
+			return fmt.Errorf("invalid auth config: %w", err)
+		}
+	} else if cfg.SharedSecret == "" {
 		return errors.New("shared secret cannot be empty")
 	}
+	return nil
+}
+
+// Validate checks if the receiver configuration is valid
+func (cfg *Config) Validate() error {
+	if cfg.Connection != nil {
+		if cfg.ConnectionConfig != nil || len(cfg.BackupConnections) > 0 || cfg.SharedSecret != "" || cfg.Auth != nil {
+			return errors.New(
+				"connection config, backup connections, shared secret and auth cannot be set with a connection extension")
+		}
+	} else if err := cfg.validateConnection(); err != nil {
+		return err
+	}
+
+	if cfg.HealthCheckInterval < 0 {
+		return errors.New("health check interval cannot be negative")
+	}
 
 	if cfg.ReceiverName == "" {
 		return errors.New("receiver name cannot be empty")
 	}
 
+	if cfg.MergeWindow < 0 {
+		return errors.New("merge window cannot be negative")
+	}
+
+	if cfg.MergeMaxMessages < 0 {
+		return errors.New("merge max messages cannot be negative")
+	}
+
+	// merged messages are consumed together, after the exporters stopped waiting
+	if cfg.Acknowledgements && cfg.MergeWindow > 0 {
+		return errors.New("acknowledgements cannot be enabled together with a merge window")
+	}
+
+	if cfg.DrainTimeout < 0 {
+		return errors.New("drain timeout cannot be negative")
+	}
+
+	if cfg.RestartGracePeriod < 0 {
+		return errors.New("restart grace period cannot be negative")
+	}
+
+	if cfg.DecodeWorkers < 0 {
+		return errors.New("decode workers cannot be negative")
+	}
+
+	if cfg.ChannelDecodeBudget < 0 {
+		return errors.New("channel decode budget cannot be negative")
+	}
+
+	// the budget is enforced by the decode workers
+	if cfg.ChannelDecodeBudget > 0 && cfg.DecodeWorkers == 0 {
+		return errors.New("channel decode budget requires decode workers")
+	}
+
+	if cfg.DecodeFailureDumpBytes < 0 {
+		return errors.New("decode failure dump bytes cannot be negative")
+	}
+
+	if cfg.MaxInFlightMessages < 0 {
+		return errors.New("max in-flight messages cannot be negative")
+	}
+
+	if cfg.MaxInFlightBytes < 0 {
+		return errors.New("max in-flight bytes cannot be negative")
+	}
+
+	if cfg.MaxSessions < 0 {
+		return errors.New("max sessions cannot be negative")
+	}
+
+	if cfg.MaxSessionsPerPeer < 0 {
+		return errors.New("max sessions per peer cannot be negative")
+	}
+
+	for i, source := range cfg.AllowedSources {
+		if source == "" {
+			return fmt.Errorf("allowed source %d cannot be empty", i)
+		}
+		if _, err := path.Match(source, ""); err != nil {
+			return fmt.Errorf("invalid allowed source pattern '%s': %w", source, err)
+		}
+	}
+
+	if cfg.IdleTimeout < 0 {
+		return errors.New("idle timeout cannot be negative")
+	}
+
+	if err := cfg.CatchUp.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.ResourceAttributes.Validate(); err != nil {
+		return err
+	}
+
+	if cfg.DefaultLogSeverity != "" {
+		if _, err := slimcommon.ParseLogSeverity(cfg.DefaultLogSeverity); err != nil {
+			return err
+		}
+	}
+
+	if cfg.SignalNameTemplate != "" {
+		if err := slimcommon.ValidateSignalNameTemplate(cfg.SignalNameTemplate); err != nil {
+			return err
+		}
+	}
+
+	for i, channel := range cfg.Channels {
+		if channel.ChannelName == "" {
+			return fmt.Errorf("channel name is required for channel %d", i)
+		}
+		if len(channel.Participants) == 0 {
+			return fmt.Errorf("at least one participant must be specified for channel '%d'", i)
+		}
+	}
+
 	return nil
 }
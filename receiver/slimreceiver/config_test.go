@@ -5,13 +5,19 @@ package slimreceiver
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
 	"github.com/agntcy/slim-otel/slimconfig"
 )
 
+func strPtr(s string) *string {
+	return &s
+}
+
 func TestConfigValidate(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -72,6 +78,53 @@ func TestConfigValidate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "missing connection config",
 		},
+		{
+			name: "connection extension",
+			config: &Config{
+				Connection:   &slimConnectionID,
+				ReceiverName: "agntcy/otel/test-receiver",
+			},
+			expectError: false,
+		},
+		{
+			name: "connection extension with backup connections returns error",
+			config: &Config{
+				Connection:        &slimConnectionID,
+				BackupConnections: []slimconfig.ConnectionConfig{{Address: "http://backup:46357"}},
+				ReceiverName:      "agntcy/otel/test-receiver",
+			},
+			expectError: true,
+			errorMsg:    "cannot be set with a connection extension",
+		},
+		{
+			name: "jwt auth without shared secret",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				Auth: &slimconfig.IdentityConfig{
+					Type:      "static_jwt",
+					StaticJwt: &slimconfig.StaticJwtAuthConfig{TokenFile: "/path/to/token"},
+				},
+			},
+			expectError: false,
+			checkFields: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "static_jwt", cfg.identity().Type)
+			},
+		},
+		{
+			name: "invalid auth config returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				Auth:         &slimconfig.IdentityConfig{Type: "basic"},
+			},
+			expectError: true,
+			errorMsg:    "invalid auth config: unsupported identity type: basic",
+		},
 		{
 			name: "missing shared secret returns error",
 			config: &Config{
@@ -95,6 +148,313 @@ func TestConfigValidate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "shared secret cannot be empty",
 		},
+		{
+			name: "valid config with merging enabled",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName:     "agntcy/otel/test-receiver",
+				SharedSecret:     "test-secret-0123456789-abcdefg",
+				MergeWindow:      100 * time.Millisecond,
+				MergeMaxMessages: 50,
+			},
+			expectError: false,
+		},
+		{
+			name: "negative merge window returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+				MergeWindow:  -time.Second,
+			},
+			expectError: true,
+			errorMsg:    "merge window cannot be negative",
+		},
+		{
+			name: "negative merge max messages returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName:     "agntcy/otel/test-receiver",
+				SharedSecret:     "test-secret-0123456789-abcdefg",
+				MergeMaxMessages: -1,
+			},
+			expectError: true,
+			errorMsg:    "merge max messages cannot be negative",
+		},
+		{
+			name: "negative max in-flight messages returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName:        "agntcy/otel/test-receiver",
+				SharedSecret:        "test-secret-0123456789-abcdefg",
+				MaxInFlightMessages: -1,
+			},
+			expectError: true,
+			errorMsg:    "max in-flight messages cannot be negative",
+		},
+		{
+			name: "negative max in-flight bytes returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName:     "agntcy/otel/test-receiver",
+				SharedSecret:     "test-secret-0123456789-abcdefg",
+				MaxInFlightBytes: -1,
+			},
+			expectError: true,
+			errorMsg:    "max in-flight bytes cannot be negative",
+		},
+		{
+			name: "negative max sessions returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+				MaxSessions:  -1,
+			},
+			expectError: true,
+			errorMsg:    "max sessions cannot be negative",
+		},
+		{
+			name: "negative max sessions per peer returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName:       "agntcy/otel/test-receiver",
+				SharedSecret:       "test-secret-0123456789-abcdefg",
+				MaxSessionsPerPeer: -1,
+			},
+			expectError: true,
+			errorMsg:    "max sessions per peer cannot be negative",
+		},
+		{
+			name: "invalid allowed source returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName:   "agntcy/otel/test-receiver",
+				SharedSecret:   "test-secret-0123456789-abcdefg",
+				AllowedSources: []string{"agntcy/otel/*", "agntcy/["},
+			},
+			expectError: true,
+			errorMsg:    "invalid allowed source pattern 'agntcy/['",
+		},
+		{
+			name: "negative idle timeout returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+				IdleTimeout:  -time.Second,
+			},
+			expectError: true,
+			errorMsg:    "idle timeout cannot be negative",
+		},
+		{
+			name: "invalid catch-up order returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+				CatchUp:      CatchUpConfig{Order: "random"},
+			},
+			expectError: true,
+			errorMsg:    "invalid catch-up order 'random'",
+		},
+		{
+			name: "negative catch-up lag returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+				CatchUp:      CatchUpConfig{Lag: -time.Second},
+			},
+			expectError: true,
+			errorMsg:    "catch-up lag cannot be negative",
+		},
+		{
+			name: "negative catch-up max age returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+				CatchUp:      CatchUpConfig{MaxAge: -time.Second},
+			},
+			expectError: true,
+			errorMsg:    "catch-up max age cannot be negative",
+		},
+		{
+			name: "negative catch-up max backlog returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+				CatchUp:      CatchUpConfig{MaxBacklog: -1},
+			},
+			expectError: true,
+			errorMsg:    "catch-up max backlog cannot be negative",
+		},
+		{
+			name: "identity without source returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+				ResourceAttributes: ResourceAttributesConfig{
+					Identities: []IdentityAttributesConfig{{Attributes: map[string]string{"team": "a"}}},
+				},
+			},
+			expectError: true,
+			errorMsg:    "source pattern cannot be empty for identity 0",
+		},
+		{
+			name: "invalid identity source pattern returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+				ResourceAttributes: ResourceAttributesConfig{
+					Identities: []IdentityAttributesConfig{{Source: "agntcy/[", Attributes: map[string]string{"team": "a"}}},
+				},
+			},
+			expectError: true,
+			errorMsg:    "invalid source pattern 'agntcy/['",
+		},
+		{
+			name: "identity without attributes returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+				ResourceAttributes: ResourceAttributesConfig{
+					Identities: []IdentityAttributesConfig{{Source: "agntcy/team-a/*"}},
+				},
+			},
+			expectError: true,
+			errorMsg:    "at least one attribute must be specified for identity 0",
+		},
+		{
+			name: "acknowledgements with merge window returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName:     "agntcy/otel/test-receiver",
+				SharedSecret:     "test-secret-0123456789-abcdefg",
+				MergeWindow:      time.Second,
+				Acknowledgements: true,
+			},
+			expectError: true,
+			errorMsg:    "acknowledgements cannot be enabled together with a merge window",
+		},
+		{
+			name: "invalid default log severity returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName:       "agntcy/otel/test-receiver",
+				SharedSecret:       "test-secret-0123456789-abcdefg",
+				DefaultLogSeverity: "WARNING",
+			},
+			expectError: true,
+			errorMsg:    "invalid log severity: WARNING",
+		},
+		{
+			name: "invalid signal name template returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName:       "agntcy/otel/test-receiver",
+				SharedSecret:       "test-secret-0123456789-abcdefg",
+				SignalNameTemplate: "{signal}/{name}",
+			},
+			expectError: true,
+			errorMsg:    "cannot contain a slash",
+		},
+		{
+			name: "valid config with mTLS connection",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "https://localhost:46357",
+					TLS: &slimconfig.TLSConfig{
+						CASource: &slimconfig.TLSCAConfig{
+							Path: strPtr("tests/certs/slim-node-ca-cert.pem"),
+						},
+						Source: &slimconfig.TLSCertKeySource{
+							CertFile: strPtr("tests/certs/receiver-cert.pem"),
+							KeyFile:  strPtr("tests/certs/receiver-key.pem"),
+						},
+					},
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+			},
+			expectError: false,
+			checkFields: func(t *testing.T, cfg *Config) {
+				require.NotNil(t, cfg.ConnectionConfig.TLS)
+				assert.Equal(t, "tests/certs/receiver-cert.pem", *cfg.ConnectionConfig.TLS.Source.CertFile)
+			},
+		},
+		{
+			name: "https address without TLS config returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "https://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+			},
+			expectError: true,
+			errorMsg:    "invalid connection config",
+		},
+		{
+			name: "TLS source without key returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "https://localhost:46357",
+					TLS: &slimconfig.TLSConfig{
+						Source: &slimconfig.TLSCertKeySource{
+							CertFile: strPtr("tests/certs/receiver-cert.pem"),
+						},
+					},
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+			},
+			expectError: true,
+			errorMsg:    "key_file is required",
+		},
 		{
 			name: "missing receiver name and connection config returns error",
 			config: &Config{
@@ -103,6 +463,138 @@ func TestConfigValidate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "missing connection config",
 		},
+		{
+			name: "valid config with backup connections",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				BackupConnections: []slimconfig.ConnectionConfig{
+					{Address: "http://backup:46357"},
+				},
+				HealthCheckInterval: time.Second,
+				ReceiverName:        "agntcy/otel/test-receiver",
+				SharedSecret:        "test-secret-0123456789-abcdefg",
+			},
+			expectError: false,
+			checkFields: func(t *testing.T, cfg *Config) {
+				endpoints := cfg.endpoints()
+				require.Len(t, endpoints, 2)
+				assert.Equal(t, "http://localhost:46357", endpoints[0].Address)
+				assert.Equal(t, "http://backup:46357", endpoints[1].Address)
+				assert.Equal(t, time.Second, cfg.healthCheckInterval())
+			},
+		},
+		{
+			name: "invalid backup connection returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				BackupConnections: []slimconfig.ConnectionConfig{
+					{},
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+			},
+			expectError: true,
+			errorMsg:    "invalid backup connection 0",
+		},
+		{
+			name: "negative health check interval returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				HealthCheckInterval: -time.Second,
+				ReceiverName:        "agntcy/otel/test-receiver",
+				SharedSecret:        "test-secret-0123456789-abcdefg",
+			},
+			expectError: true,
+			errorMsg:    "health check interval cannot be negative",
+		},
+		{
+			name: "negative drain timeout returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				DrainTimeout: -time.Second,
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+			},
+			expectError: true,
+			errorMsg:    "drain timeout cannot be negative",
+		},
+		{
+			name: "negative restart grace period returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				RestartGracePeriod: -time.Second,
+				ReceiverName:       "agntcy/otel/test-receiver",
+				SharedSecret:       "test-secret-0123456789-abcdefg",
+			},
+			expectError: true,
+			errorMsg:    "restart grace period cannot be negative",
+		},
+		{
+			name: "channel decode budget without decode workers returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ChannelDecodeBudget: 100 * time.Millisecond,
+				ReceiverName:        "agntcy/otel/test-receiver",
+				SharedSecret:        "test-secret-0123456789-abcdefg",
+			},
+			expectError: true,
+			errorMsg:    "channel decode budget requires decode workers",
+		},
+		{
+			name: "negative decode failure dump bytes returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				DecodeFailureDumpBytes: -1,
+				ReceiverName:           "agntcy/otel/test-receiver",
+				SharedSecret:           "test-secret-0123456789-abcdefg",
+			},
+			expectError: true,
+			errorMsg:    "decode failure dump bytes cannot be negative",
+		},
+		{
+			name: "channel without name returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+				Channels: []ChannelsConfig{
+					{Participants: []string{"agntcy/otel/exporter-traces"}},
+				},
+			},
+			expectError: true,
+			errorMsg:    "channel name is required for channel 0",
+		},
+		{
+			name: "channel without participants returns error",
+			config: &Config{
+				ConnectionConfig: &slimconfig.ConnectionConfig{
+					Address: "http://localhost:46357",
+				},
+				ReceiverName: "agntcy/otel/test-receiver",
+				SharedSecret: "test-secret-0123456789-abcdefg",
+				Channels: []ChannelsConfig{
+					{ChannelName: "agntcy/otel/channel"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "at least one participant must be specified",
+		},
 	}
 
 	for _, tt := range tests {
@@ -129,4 +621,6 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Nil(t, cfg.ConnectionConfig, "default config should not have connection config")
 	assert.Empty(t, cfg.ReceiverName, "default config should not have a receiver name")
 	assert.Empty(t, cfg.SharedSecret, "default config should not have a shared secret")
+	assert.Equal(t, slimcommon.DefaultHealthCheckInterval, cfg.healthCheckInterval())
+	assert.Equal(t, defaultDrainTimeout, cfg.drainTimeout())
 }
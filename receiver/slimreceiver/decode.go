@@ -0,0 +1,170 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// decodePool decodes the payloads in a bounded pool of workers, separate from
+// the session handlers, so that the total decoding work is capped whatever
+// the number of sessions. Each channel may also be given a budget of decoding
+// time per second: a channel sending payloads expensive to decode is slowed
+// down once its budget is spent instead of starving the other channels.
+// A nil decodePool decodes in the calling session handler.
+type decodePool struct {
+	workers int
+	// decoding time each channel may spend per second, unlimited if 0
+	budget time.Duration
+	jobs   chan func()
+
+	mutex sync.Mutex
+	usage map[string]*decodeUsage
+}
+
+// decodeUsage is the decoding time spent by a channel, which decreases by the
+// budget every second
+type decodeUsage struct {
+	spent   time.Duration
+	updated time.Time
+}
+
+// newDecodePool creates a pool of workers, or nil if workers is 0
+func newDecodePool(workers int, budget time.Duration) *decodePool {
+	if workers <= 0 {
+		return nil
+	}
+	return &decodePool{
+		workers: workers,
+		budget:  budget,
+		jobs:    make(chan func()),
+		usage:   make(map[string]*decodeUsage),
+	}
+}
+
+// start starts the workers, which stop when ctx is done
+func (p *decodePool) start(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	for range p.workers {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-p.jobs:
+					job()
+				}
+			}
+		}()
+	}
+}
+
+// run waits for the budget of the channel, then runs decode on a worker and
+// waits for it to complete. It returns the ctx error if ctx is done before a
+// worker picks decode up, and whether the channel was throttled.
+func (p *decodePool) run(ctx context.Context, channel string, decode func()) (bool, error) {
+	if p == nil {
+		decode()
+		return false, nil
+	}
+
+	throttled, err := p.waitBudget(ctx, channel)
+	if err != nil {
+		return throttled, err
+	}
+
+	done := make(chan struct{})
+	job := func() {
+		defer close(done)
+		start := time.Now()
+		decode()
+		p.charge(channel, time.Since(start))
+	}
+	select {
+	case <-ctx.Done():
+		return throttled, ctx.Err()
+	case p.jobs <- job:
+	}
+	<-done
+	return throttled, nil
+}
+
+// waitBudget waits until the channel has decoding time left in its budget.
+// It returns whether the channel had to wait.
+func (p *decodePool) waitBudget(ctx context.Context, channel string) (bool, error) {
+	if p.budget <= 0 {
+		return false, nil
+	}
+
+	throttled := false
+	for {
+		wait := p.overBudget(channel, time.Now())
+		if wait <= 0 {
+			return throttled, nil
+		}
+		throttled = true
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return throttled, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// overBudget returns how long the channel must wait for its decoding time to
+// fall below its budget, 0 if it can decode now
+func (p *decodePool) overBudget(channel string, now time.Time) time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	usage := p.refresh(channel, now)
+	if usage.spent < p.budget {
+		return 0
+	}
+	return time.Duration(float64(usage.spent-p.budget)/float64(p.budget)*float64(time.Second)) + time.Millisecond
+}
+
+// charge adds the decoding time of a payload to the time spent by its channel
+func (p *decodePool) charge(channel string, elapsed time.Duration) {
+	if p.budget <= 0 {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.refresh(channel, time.Now()).spent += elapsed
+}
+
+// refresh returns the usage of the channel with the budget of the time
+// elapsed since its last update deducted. It must be called with the mutex
+// held.
+func (p *decodePool) refresh(channel string, now time.Time) *decodeUsage {
+	usage, ok := p.usage[channel]
+	if !ok {
+		usage = &decodeUsage{updated: now}
+		p.usage[channel] = usage
+	}
+	refill := time.Duration(float64(p.budget) * now.Sub(usage.updated).Seconds())
+	usage.spent = max(usage.spent-refill, 0)
+	usage.updated = now
+	return usage
+}
+
+// forget drops the usage of a channel whose session ended
+func (p *decodePool) forget(channel string) {
+	if p == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.usage, channel)
+}
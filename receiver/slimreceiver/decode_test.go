@@ -0,0 +1,114 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestDecodePool(t *testing.T) {
+	t.Run("nil pool decodes inline", func(t *testing.T) {
+		var pool *decodePool
+		decoded := false
+		throttled, err := pool.run(t.Context(), "agntcy/otel/channel", func() { decoded = true })
+		require.NoError(t, err)
+		assert.False(t, throttled)
+		assert.True(t, decoded)
+	})
+
+	t.Run("bounds the concurrent decodings", func(t *testing.T) {
+		pool := newDecodePool(2, 0)
+		pool.start(t.Context())
+
+		var running, peak atomic.Int32
+		done := make(chan struct{})
+		for range 6 {
+			go func() {
+				_, _ = pool.run(t.Context(), "agntcy/otel/channel", func() {
+					n := running.Add(1)
+					for {
+						p := peak.Load()
+						if n <= p || peak.CompareAndSwap(p, n) {
+							break
+						}
+					}
+					time.Sleep(20 * time.Millisecond)
+					running.Add(-1)
+				})
+				done <- struct{}{}
+			}()
+		}
+		for range 6 {
+			<-done
+		}
+		assert.Equal(t, int32(2), peak.Load())
+	})
+
+	t.Run("throttles the channels over budget", func(t *testing.T) {
+		pool := newDecodePool(1, 10*time.Millisecond)
+		pool.start(t.Context())
+
+		// the first decoding spends five times the budget of a second
+		throttled, err := pool.run(t.Context(), "agntcy/otel/busy", func() { time.Sleep(50 * time.Millisecond) })
+		require.NoError(t, err)
+		assert.False(t, throttled)
+
+		// the other channels are not slowed down
+		throttled, err = pool.run(t.Context(), "agntcy/otel/quiet", func() {})
+		require.NoError(t, err)
+		assert.False(t, throttled)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+		defer cancel()
+		throttled, err = pool.run(ctx, "agntcy/otel/busy", func() {})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.True(t, throttled)
+	})
+
+	t.Run("forgets the usage of closed channels", func(t *testing.T) {
+		pool := newDecodePool(1, 10*time.Millisecond)
+		pool.start(t.Context())
+		_, err := pool.run(t.Context(), "agntcy/otel/channel", func() { time.Sleep(50 * time.Millisecond) })
+		require.NoError(t, err)
+
+		pool.forget("agntcy/otel/channel")
+		throttled, err := pool.run(t.Context(), "agntcy/otel/channel", func() {})
+		require.NoError(t, err)
+		assert.False(t, throttled)
+	})
+
+	t.Run("stopped pool", func(t *testing.T) {
+		pool := newDecodePool(1, 0)
+		ctx, cancel := context.WithCancel(t.Context())
+		pool.start(ctx)
+		cancel()
+
+		_, err := pool.run(ctx, "agntcy/otel/channel", func() {})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestUnmarshalPayload_DecodePool(t *testing.T) {
+	r := &slimReceiver{
+		config:         &Config{},
+		tracesConsumer: &consumertest.TracesSink{},
+		decoders:       newDecodePool(1, 0),
+	}
+	r.decoders.start(t.Context())
+
+	ctx := withMessageInfo(t.Context(), "agntcy/otel/channel", nil)
+	data, ok := unmarshalPayload(ctx, r, tracesPayload(t, "span"))
+	require.True(t, ok)
+	traces, isTraces := data.(ptrace.Traces)
+	require.True(t, isTraces)
+	assert.Equal(t, 1, traces.SpanCount())
+}
@@ -0,0 +1,172 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+const (
+	// defaultDrainTimeout fits in the default Kubernetes termination grace
+	// period of 30s
+	defaultDrainTimeout = 20 * time.Second
+	// drainCheckInterval is the period at which drain checks whether all
+	// the sessions are closed
+	drainCheckInterval = 100 * time.Millisecond
+	// drainPath is the path of the drain endpoint
+	drainPath = "/drain"
+)
+
+// drain stops the receiver from accepting new sessions and makes it leave
+// its channels once the in-flight messages are consumed. The channels
+// created by the receiver and the point-to-point sessions are closed, for
+// the other channels the channel manager is requested to remove the
+// receiver. It returns when all the sessions are
+// closed or when ctx is done.
+func (r *slimReceiver) drain(ctx context.Context) error {
+	r.drainOnce.Do(func() {
+		slimcommon.LoggerFromContextOrDefault(ctx).Info("Draining Slim receiver")
+		close(r.draining)
+	})
+
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+	for {
+		remaining := r.sessions.ListSessionNames(ctx)
+		if len(remaining) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to leave channels %v: %w", remaining, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// isDraining reports whether the receiver is being drained
+func (r *slimReceiver) isDraining() bool {
+	select {
+	case <-r.draining:
+		return true
+	default:
+		return false
+	}
+}
+
+// ownsChannel reports whether the session is on a channel created by the
+// receiver
+func (r *slimReceiver) ownsChannel(sessionName string) bool {
+	for _, config := range r.config.Channels {
+		name, err := slimcommon.SplitID(config.ChannelName)
+		if err == nil && name.String() == sessionName {
+			return true
+		}
+	}
+	return false
+}
+
+// closesOnDrain reports whether the receiver closes the session when it is
+// drained, rather than requesting the channel manager to remove it
+func (r *slimReceiver) closesOnDrain(session slimcommon.Session, sessionName string) bool {
+	if r.ownsChannel(sessionName) {
+		return true
+	}
+	config, err := session.SessionConfig()
+	return err == nil && config.SessionType == slim.SessionTypePointToPoint
+}
+
+// notifyClosing tells the other participants of the session that the
+// receiver is drained and closes the session, so that they stop publishing
+// on it
+func notifyClosing(ctx context.Context, r *slimReceiver, session slimcommon.Session) {
+	payloadType := slimcommon.PayloadTypeDrain
+	metadata := map[string]string{
+		slimcommon.MetadataDrainParticipant: r.config.ReceiverName,
+		slimcommon.MetadataDrainClosing:     "true",
+	}
+	if err := session.PublishAndWait([]byte{}, &payloadType, &metadata); err != nil {
+		slimcommon.LoggerFromContextOrDefault(ctx).Warn("Failed to notify the closing of the session", zap.Error(err))
+	}
+}
+
+// requestLeave asks the channel manager to remove the receiver from the
+// channel of the session
+func requestLeave(ctx context.Context, session slimcommon.Session) {
+	payloadType := slimcommon.PayloadTypeLeave
+	if err := session.PublishAndWait([]byte{}, &payloadType, nil); err != nil {
+		slimcommon.LoggerFromContextOrDefault(ctx).Warn("Failed to request to leave the channel", zap.Error(err))
+	}
+}
+
+// startDrainServer starts the HTTP server of the drain endpoint, if enabled
+func (r *slimReceiver) startDrainServer(ctx context.Context) error {
+	if r.config.DrainEndpoint == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", r.config.DrainEndpoint)
+	if err != nil {
+		return fmt.Errorf("failed to listen on the drain endpoint %s: %w", r.config.DrainEndpoint, err)
+	}
+
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	mux := http.NewServeMux()
+	mux.HandleFunc(drainPath, r.handleDrain(logger))
+	r.drainServer = &http.Server{Handler: mux, ReadHeaderTimeout: time.Second}
+
+	go func() {
+		if err := r.drainServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Drain endpoint stopped", zap.Error(err))
+		}
+	}()
+
+	logger.Info("Drain endpoint started", zap.String("address", listener.Addr().String()))
+	return nil
+}
+
+// handleDrain returns the handler of the drain endpoint, which responds once
+// the receiver has left its channels
+func (r *slimReceiver) handleDrain(logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(req.Context(), r.config.drainTimeout())
+		defer cancel()
+		ctx = slimcommon.InitContextWithLogger(ctx, logger)
+
+		if err := r.drain(ctx); err != nil {
+			logger.Warn("Failed to drain the receiver", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		logger.Info("Slim receiver drained")
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// stopDrainServer stops the HTTP server of the drain endpoint, if started
+func (r *slimReceiver) stopDrainServer(ctx context.Context) {
+	if r.drainServer == nil {
+		return
+	}
+	if err := r.drainServer.Shutdown(ctx); err != nil {
+		slimcommon.LoggerFromContextOrDefault(ctx).Warn("Failed to stop the drain endpoint", zap.Error(err))
+	}
+	r.drainServer = nil
+}
@@ -0,0 +1,168 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func newDrainReceiver(cfg *Config) *slimReceiver {
+	return &slimReceiver{
+		config:         cfg,
+		app:            testutil.NewFakeApp(),
+		sessions:       slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		tracesConsumer: &consumertest.TracesSink{},
+		draining:       make(chan struct{}),
+	}
+}
+
+func TestSlimReceiver_Drain(t *testing.T) {
+	t.Run("leaves the channels it is invited to", func(t *testing.T) {
+		r := newDrainReceiver(&Config{})
+		sink := r.tracesConsumer.(*consumertest.TracesSink)
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel-traces")
+		// the channel manager removes the receiver from the channel
+		session.OnPublish = func(msg slim.ReceivedMessage) {
+			if msg.Context.PayloadType == slimcommon.PayloadTypeLeave {
+				session.Close()
+			}
+		}
+		require.NoError(t, r.sessions.AddSession(t.Context(), session))
+		session.Deliver(tracesPayload(t, "span"))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go handleSession(t.Context(), &wg, r, session)
+		require.Eventually(t, func() bool { return sink.SpanCount() == 1 }, time.Second, 10*time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, r.drain(ctx))
+		wg.Wait()
+
+		published := session.PublishedMessages()
+		require.Len(t, published, 1)
+		assert.Equal(t, slimcommon.PayloadTypeLeave, published[0].Context.PayloadType)
+		assert.Empty(t, r.sessions.ListSessionNames(t.Context()))
+	})
+
+	closedSessions := []struct {
+		name    string
+		config  *Config
+		session func() *testutil.FakeSession
+	}{
+		{
+			name: "closes the channels it created",
+			config: &Config{
+				ReceiverName: "agntcy/otel/receiver",
+				Channels:     []ChannelsConfig{{ChannelName: "agntcy/otel/channel-traces"}},
+			},
+			session: func() *testutil.FakeSession { return testutil.NewFakeSession(1, "agntcy/otel/channel-traces") },
+		},
+		{
+			name:   "closes the point-to-point sessions",
+			config: &Config{ReceiverName: "agntcy/otel/receiver"},
+			session: func() *testutil.FakeSession {
+				session := testutil.NewFakeSession(1, "agntcy/otel/exporter")
+				session.Config.SessionType = slim.SessionTypePointToPoint
+				return session
+			},
+		},
+	}
+	for _, tt := range closedSessions {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newDrainReceiver(tt.config)
+			session := tt.session()
+			require.NoError(t, r.sessions.AddSession(t.Context(), session))
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go handleSession(t.Context(), &wg, r, session)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+			require.NoError(t, r.drain(ctx))
+			wg.Wait()
+
+			assert.True(t, session.Closed())
+			// the other participants are told that the session closes
+			published := session.PublishedMessages()
+			require.Len(t, published, 1)
+			assert.Equal(t, slimcommon.PayloadTypeDrain, published[0].Context.PayloadType)
+			assert.Equal(t, map[string]string{
+				slimcommon.MetadataDrainParticipant: "agntcy/otel/receiver",
+				slimcommon.MetadataDrainClosing:     "true",
+			}, published[0].Context.Metadata)
+		})
+	}
+
+	t.Run("times out while a channel is not left", func(t *testing.T) {
+		r := newDrainReceiver(&Config{})
+		require.NoError(t, r.sessions.AddSession(t.Context(), testutil.NewFakeSession(1, "agntcy/otel/channel-traces")))
+
+		ctx, cancel := context.WithTimeout(t.Context(), 200*time.Millisecond)
+		defer cancel()
+		err := r.drain(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, "agntcy/otel/channel-traces")
+		assert.True(t, r.isDraining())
+	})
+
+	t.Run("rejects new sessions", func(t *testing.T) {
+		r := newDrainReceiver(&Config{})
+		app := testutil.NewFakeApp()
+		r.app = app
+		require.NoError(t, r.drain(t.Context()))
+
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel-traces")
+		app.Invite(session)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		done := make(chan struct{})
+		go func() {
+			listenForSessions(ctx, r)
+			close(done)
+		}()
+		assert.Eventually(t, session.Closed, 2*time.Second, 10*time.Millisecond)
+		cancel()
+		<-done
+
+		assert.Empty(t, r.sessions.ListSessionNames(t.Context()))
+	})
+}
+
+func TestSlimReceiver_HandleDrain(t *testing.T) {
+	r := newDrainReceiver(&Config{})
+	handler := r.handleDrain(zap.NewNop())
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPut, drainPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.False(t, r.isDraining())
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, drainPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, r.isDraining())
+
+	// the endpoint can be called again, e.g. by a retried preStop hook
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, drainPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,56 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// Reasons of the envelopes rejected by the receiver
+const (
+	envelopeMalformed   = "malformed"
+	envelopeEncoding    = "encoding"
+	envelopeCompression = "compression"
+)
+
+// openEnvelope returns the envelope of a payload wrapped by the exporter.
+// The envelopes of newer versions are read as far as their fields are known,
+// the ones whose encoding or compression is unknown, or that are malformed,
+// are reported and rejected.
+func (r *slimReceiver) openEnvelope(ctx context.Context, sessionName string, payload []byte) (slimcommon.Envelope, bool) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	env, err := slimcommon.UnmarshalEnvelope(payload)
+	if err != nil {
+		r.telemetry.recordRejectedEnvelope(ctx, sessionName, envelopeRejectReason(err))
+		logger.Warn("Dropping message whose envelope cannot be read",
+			zap.String("channel", sessionName),
+			zap.Error(err))
+		return slimcommon.Envelope{}, false
+	}
+	if env.Version > slimcommon.EnvelopeVersion {
+		logger.Debug("Reading envelope of a newer version",
+			zap.String("channel", sessionName),
+			zap.Uint32("version", env.Version),
+			zap.Uint32("supported_version", slimcommon.EnvelopeVersion))
+	}
+	return env, true
+}
+
+// envelopeRejectReason returns the reason reported for an envelope that
+// cannot be read
+func envelopeRejectReason(err error) string {
+	switch {
+	case errors.Is(err, slimcommon.ErrUnsupportedEncoding):
+		return envelopeEncoding
+	case errors.Is(err, slimcommon.ErrUnsupportedCompression):
+		return envelopeCompression
+	default:
+		return envelopeMalformed
+	}
+}
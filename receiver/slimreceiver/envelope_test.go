@@ -0,0 +1,79 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+
+	slim "github.com/agntcy/slim-bindings-go"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestHandleSession_Envelope(t *testing.T) {
+	tracesSink := &consumertest.TracesSink{}
+	r := &slimReceiver{
+		config: &Config{
+			ResourceAttributes: ResourceAttributesConfig{Producer: true},
+		},
+		app:            testutil.NewFakeApp(),
+		sessions:       slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		tracesConsumer: tracesSink,
+	}
+
+	wrap := func(env slimcommon.Envelope) slim.ReceivedMessage {
+		payload, err := slimcommon.MarshalEnvelope(env)
+		require.NoError(t, err)
+		msg := slim.ReceivedMessage{Payload: payload}
+		msg.Context.Metadata = make(map[string]string)
+		slimcommon.AddEnvelope(msg.Context.Metadata)
+		return msg
+	}
+
+	session := testutil.NewFakeSession(3, "agntcy/otel/channel-traces")
+	require.NoError(t, r.sessions.AddSession(t.Context(), session))
+	// a raw payload, a wrapped one, and envelopes that cannot be read
+	session.DeliverMessage(slim.ReceivedMessage{Payload: tracesPayload(t, "raw")})
+	session.DeliverMessage(wrap(slimcommon.Envelope{
+		Compression: slimcommon.CompressionGzip,
+		Producer:    "agntcy/otel/exporter-traces",
+		Payload:     tracesPayload(t, "wrapped"),
+	}))
+	malformed := wrap(slimcommon.Envelope{Payload: tracesPayload(t, "malformed")})
+	malformed.Payload = malformed.Payload[:len(malformed.Payload)-1]
+	session.DeliverMessage(malformed)
+	session.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	handleSession(t.Context(), &wg, r, session)
+	wg.Wait()
+
+	traces := tracesSink.AllTraces()
+	require.Len(t, traces, 2)
+	raw := traces[0].ResourceSpans().At(0)
+	assert.Equal(t, "raw", raw.ScopeSpans().At(0).Spans().At(0).Name())
+	assert.NotContains(t, raw.Resource().Attributes().AsRaw(), attributeProducer)
+	wrapped := traces[1].ResourceSpans().At(0)
+	assert.Equal(t, "wrapped", wrapped.ScopeSpans().At(0).Spans().At(0).Name())
+	assert.Equal(t, "agntcy/otel/exporter-traces", wrapped.Resource().Attributes().AsRaw()[attributeProducer])
+}
+
+func TestEnvelopeRejectReason(t *testing.T) {
+	assert.Equal(t, envelopeEncoding,
+		envelopeRejectReason(fmt.Errorf("%w %q", slimcommon.ErrUnsupportedEncoding, "otlp_arrow")))
+	assert.Equal(t, envelopeCompression,
+		envelopeRejectReason(fmt.Errorf("%w %q", slimcommon.ErrUnsupportedCompression, "zstd")))
+	assert.Equal(t, envelopeMalformed, envelopeRejectReason(slimcommon.ErrMalformedEnvelope))
+	assert.Equal(t, envelopeMalformed, envelopeRejectReason(errors.New("other")))
+}
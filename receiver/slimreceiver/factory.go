@@ -6,6 +6,10 @@ package slimreceiver
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
@@ -23,14 +27,24 @@ const (
 	stability = component.StabilityLevelDevelopment
 )
 
+// factory holds the options of the receivers created by NewFactory
+type factory struct {
+	hooks []ConsumeHook
+}
+
 // NewFactory creates a factory for the Slim receiver
-func NewFactory() receiver.Factory {
+func NewFactory(opts ...FactoryOption) receiver.Factory {
+	f := &factory{}
+	for _, opt := range opts {
+		opt(f)
+	}
+
 	return receiver.NewFactory(
 		component.MustNewType(TypeStr),
 		createDefaultConfig,
-		receiver.WithTraces(createTracesReceiver, stability),
-		receiver.WithMetrics(createMetricsReceiver, stability),
-		receiver.WithLogs(createLogsReceiver, stability),
+		receiver.WithTraces(f.createTracesReceiver, stability),
+		receiver.WithMetrics(f.createMetricsReceiver, stability),
+		receiver.WithLogs(f.createLogsReceiver, stability),
 	)
 }
 
@@ -40,7 +54,7 @@ func createDefaultConfig() component.Config {
 }
 
 // createTracesReceiver creates a trace receiver based on the config
-func createTracesReceiver(
+func (f *factory) createTracesReceiver(
 	ctx context.Context,
 	set receiver.Settings,
 	cfg component.Config,
@@ -54,9 +68,9 @@ func createTracesReceiver(
 
 	ctx = slimcommon.InitContextWithLogger(ctx, set.Logger)
 	r := receivers.GetOrAdd(
-		cfg,
+		newSharedKey(set.ID, receiverConfig),
 		func() component.Component {
-			return newSlimReceiver(ctx, receiverConfig)
+			return newSlimReceiver(ctx, set, receiverConfig, f.hooks)
 		},
 	)
 
@@ -65,7 +79,7 @@ func createTracesReceiver(
 }
 
 // createMetricsReceiver creates a metrics receiver based on the config
-func createMetricsReceiver(
+func (f *factory) createMetricsReceiver(
 	ctx context.Context,
 	set receiver.Settings,
 	cfg component.Config,
@@ -79,9 +93,9 @@ func createMetricsReceiver(
 
 	ctx = slimcommon.InitContextWithLogger(ctx, set.Logger)
 	r := receivers.GetOrAdd(
-		cfg,
+		newSharedKey(set.ID, receiverConfig),
 		func() component.Component {
-			return newSlimReceiver(ctx, receiverConfig)
+			return newSlimReceiver(ctx, set, receiverConfig, f.hooks)
 		},
 	)
 
@@ -90,7 +104,7 @@ func createMetricsReceiver(
 }
 
 // createLogsReceiver creates a logs receiver based on the config
-func createLogsReceiver(
+func (f *factory) createLogsReceiver(
 	ctx context.Context,
 	set receiver.Settings,
 	cfg component.Config,
@@ -104,9 +118,9 @@ func createLogsReceiver(
 
 	ctx = slimcommon.InitContextWithLogger(ctx, set.Logger)
 	r := receivers.GetOrAdd(
-		cfg,
+		newSharedKey(set.ID, receiverConfig),
 		func() component.Component {
-			return newSlimReceiver(ctx, receiverConfig)
+			return newSlimReceiver(ctx, set, receiverConfig, f.hooks)
 		},
 	)
 
@@ -116,3 +130,86 @@ func createLogsReceiver(
 
 // receivers is a shared component to manage Slim receivers
 var receivers = sharedcomponent.NewSharedComponents()
+
+// sharedKey identifies the receiver shared by the traces, metrics and logs
+// pipelines. Besides the receiver ID, it holds the name, the connection
+// extension, the endpoints and the identity of the SLIM app, so that two
+// receivers connecting to the same SLIM node with different identities, e.g.
+// a shared secret and a JWT, never share an app.
+type sharedKey struct {
+	id         component.ID
+	name       string
+	connection string
+	endpoints  string
+	identity   string
+}
+
+// newSharedKey returns the key of the receiver id with cfg
+func newSharedKey(id component.ID, cfg *Config) sharedKey {
+	return sharedKey{
+		id:         id,
+		name:       cfg.ReceiverName,
+		connection: configKey(cfg.Connection),
+		endpoints:  configKey(cfg.endpoints()),
+		identity:   configKey(cfg.identity()),
+	}
+}
+
+// configKey encodes a configuration holding pointers and slices so that it
+// is compared by value. Unlike its JSON encoding, the key includes the
+// secrets, which are redacted when marshaled, so that a rotated secret
+// creates another receiver.
+func configKey(v any) string {
+	var b strings.Builder
+	writeConfigKey(&b, reflect.ValueOf(v))
+	return b.String()
+}
+
+func writeConfigKey(b *strings.Builder, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		b.WriteString("nil")
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		writeConfigKey(b, v.Elem())
+	case reflect.Struct:
+		b.WriteString("{")
+		for i := range v.NumField() {
+			fmt.Fprintf(b, "%s:", v.Type().Field(i).Name)
+			writeConfigKey(b, v.Field(i))
+			b.WriteString(",")
+		}
+		b.WriteString("}")
+	case reflect.Slice, reflect.Array:
+		b.WriteString("[")
+		for i := range v.Len() {
+			writeConfigKey(b, v.Index(i))
+			b.WriteString(",")
+		}
+		b.WriteString("]")
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]reflect.Value, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			key := configKey(iter.Key().Interface())
+			keys = append(keys, key)
+			values[key] = iter.Value()
+		}
+		slices.Sort(keys)
+		b.WriteString("map[")
+		for _, key := range keys {
+			b.WriteString(key + ":")
+			writeConfigKey(b, values[key])
+			b.WriteString(",")
+		}
+		b.WriteString("]")
+	case reflect.String:
+		// the raw value, an Opaque secret would print redacted
+		b.WriteString(strconv.Quote(v.String()))
+	default:
+		fmt.Fprint(b, v)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// slimConnectionID is the ID of a SLIM connection extension
+var slimConnectionID = component.MustNewIDWithName("slim", "shared")
+
+// newTestReceiverSettings returns the settings of the receiver named name
+func newTestReceiverSettings(name string) receiver.Settings {
+	return receiver.Settings{
+		ID:                component.MustNewIDWithName(TypeStr, name),
+		TelemetrySettings: componenttest.NewNopTelemetrySettings(),
+	}
+}
+
+// sharedSecretConfig returns a receiver config with a shared secret identity
+func sharedSecretConfig(secret slimconfig.Opaque) *Config {
+	return &Config{
+		ConnectionConfig: &slimconfig.ConnectionConfig{Address: "http://localhost:46357"},
+		ReceiverName:     "agntcy/otel/receiver",
+		SharedSecret:     secret,
+	}
+}
+
+// jwtConfig returns a receiver config with a static JWT identity
+func jwtConfig(tokenFile string) *Config {
+	return &Config{
+		ConnectionConfig: &slimconfig.ConnectionConfig{Address: "http://localhost:46357"},
+		ReceiverName:     "agntcy/otel/receiver",
+		Auth: &slimconfig.IdentityConfig{
+			Type:      "static_jwt",
+			StaticJwt: &slimconfig.StaticJwtAuthConfig{TokenFile: tokenFile},
+		},
+	}
+}
+
+// createShared creates the traces, metrics and logs receivers of set with cfg
+// and checks they share the same receiver, which is returned
+func createShared(t *testing.T, set receiver.Settings, cfg *Config) *slimReceiver {
+	t.Helper()
+	f := NewFactory()
+
+	traces, err := f.CreateTraces(t.Context(), set, cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	metrics, err := f.CreateMetrics(t.Context(), set, cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	logs, err := f.CreateLogs(t.Context(), set, cfg, consumertest.NewNop())
+	require.NoError(t, err)
+
+	assert.Same(t, traces, metrics)
+	assert.Same(t, traces, logs)
+	t.Cleanup(func() { require.NoError(t, traces.Shutdown(t.Context())) })
+
+	r := traces.(interface{ Unwrap() component.Component }).Unwrap().(*slimReceiver)
+	assert.NotNil(t, r.tracesConsumer)
+	assert.NotNil(t, r.metricsConsumer)
+	assert.NotNil(t, r.logsConsumer)
+	return r
+}
+
+func TestFactory_SharedReceivers(t *testing.T) {
+	t.Run("same config", func(t *testing.T) {
+		set := newTestReceiverSettings("same")
+		r := createShared(t, set, sharedSecretConfig("secret-0123456789-abcdefghijklmnop"))
+
+		// an equal config, e.g. decoded again, shares the receiver
+		f := NewFactory()
+		other, err := f.CreateTraces(t.Context(), set, sharedSecretConfig("secret-0123456789-abcdefghijklmnop"),
+			consumertest.NewNop())
+		require.NoError(t, err)
+		assert.Same(t, r, other.(interface{ Unwrap() component.Component }).Unwrap())
+	})
+
+	t.Run("shared secret and JWT receivers on the same endpoint", func(t *testing.T) {
+		secret := createShared(t, newTestReceiverSettings("secret"), sharedSecretConfig("secret-0123456789-abcdefghijklmnop"))
+		jwt := createShared(t, newTestReceiverSettings("jwt"), jwtConfig("/path/to/token"))
+
+		assert.NotSame(t, secret, jwt)
+		assert.Equal(t, "shared_secret", secret.config.identity().Type)
+		assert.Equal(t, "static_jwt", jwt.config.identity().Type)
+	})
+
+	t.Run("same receiver ID with another identity", func(t *testing.T) {
+		set := newTestReceiverSettings("reconfigured")
+		secret := createShared(t, set, sharedSecretConfig("secret-0123456789-abcdefghijklmnop"))
+		rotated := createShared(t, set, sharedSecretConfig("rotated-0123456789-abcdefghijklmn"))
+		jwt := createShared(t, set, jwtConfig("/path/to/token"))
+		otherToken := createShared(t, set, jwtConfig("/path/to/other-token"))
+
+		assert.NotSame(t, secret, rotated)
+		assert.NotSame(t, secret, jwt)
+		assert.NotSame(t, jwt, otherToken)
+	})
+}
+
+func TestNewSharedKey(t *testing.T) {
+	id := component.MustNewIDWithName(TypeStr, "key")
+	base := newSharedKey(id, jwtConfig("/path/to/token"))
+
+	assert.Equal(t, base, newSharedKey(id, jwtConfig("/path/to/token")))
+
+	cfg := jwtConfig("/path/to/token")
+	cfg.ConnectionConfig.Address = "http://other:46357"
+	assert.NotEqual(t, base, newSharedKey(id, cfg), "endpoint")
+
+	cfg = jwtConfig("/path/to/token")
+	cfg.BackupConnections = []slimconfig.ConnectionConfig{{Address: "http://backup:46357"}}
+	assert.NotEqual(t, base, newSharedKey(id, cfg), "backup endpoint")
+
+	cfg = jwtConfig("/path/to/token")
+	cfg.ReceiverName = "agntcy/otel/other-receiver"
+	assert.NotEqual(t, base, newSharedKey(id, cfg), "name")
+
+	cfg = jwtConfig("/path/to/token")
+	cfg.Auth.Verification = &slimconfig.JwtAuthConfig{Issuer: "issuer"}
+	assert.NotEqual(t, base, newSharedKey(id, cfg), "verification")
+
+	// the secrets are redacted in the JSON encoding, but not in the key
+	secret := newSharedKey(id, sharedSecretConfig("secret-0123456789-abcdefghijklmnop"))
+	assert.Equal(t, secret, newSharedKey(id, sharedSecretConfig("secret-0123456789-abcdefghijklmnop")))
+	assert.NotEqual(t, secret, newSharedKey(id, sharedSecretConfig("rotated-0123456789-abcdefghijklmn")), "secret")
+
+	other := component.MustNewIDWithName(TypeStr, "other")
+	assert.NotEqual(t, base, newSharedKey(other, jwtConfig("/path/to/token")), "ID")
+
+	shared := &Config{Connection: &slimConnectionID, ReceiverName: "agntcy/otel/receiver"}
+	assert.Equal(t, newSharedKey(id, shared), newSharedKey(id, shared))
+	otherConnection := component.MustNewIDWithName("slim", "other")
+	assert.NotEqual(t, newSharedKey(id, shared),
+		newSharedKey(id, &Config{Connection: &otherConnection, ReceiverName: "agntcy/otel/receiver"}), "connection")
+}
+
+func TestConfigKey(t *testing.T) {
+	secret := slimconfig.Opaque("secret")
+	assert.Equal(t, `{File:"",Data:"secret",}`, configKey(&slimconfig.JWTKeySource{Data: secret}))
+	assert.Equal(t, "nil", configKey((*slimconfig.JWTKeySource)(nil)))
+	assert.Equal(t, configKey(map[string]int{"a": 1, "b": 2}), configKey(map[string]int{"b": 2, "a": 1}))
+	assert.Equal(t, `["a",1,true,]`, configKey([]any{"a", 1, true}))
+}
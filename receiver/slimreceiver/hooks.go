@@ -0,0 +1,78 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// ConsumeInfo describes a batch of data successfully consumed by the next
+// consumer of the pipeline
+type ConsumeInfo struct {
+	// Signal of the data
+	Signal slimconfig.SignalType
+
+	// Name of the channel the data was received on
+	Channel string
+
+	// Number of spans, metric data points or log records
+	Count int
+
+	// Metadata of the SLIM message carrying the data, nil when the payloads
+	// of several messages were merged, see merge-window
+	Metadata map[string]string
+}
+
+// ConsumeHook is notified of the data consumed by the receiver, e.g. to add
+// accounting logic. OnConsume is called synchronously from the goroutine of
+// the channel the data was received on: it must be safe for concurrent use
+// and return quickly, since the channel is not read in the meantime.
+type ConsumeHook interface {
+	OnConsume(ctx context.Context, info ConsumeInfo)
+}
+
+// FactoryOption applies an option to the receivers created by the factory
+type FactoryOption func(*factory)
+
+// WithConsumeHook adds a hook notified of the data consumed by the receivers
+func WithConsumeHook(hook ConsumeHook) FactoryOption {
+	return func(f *factory) {
+		f.hooks = append(f.hooks, hook)
+	}
+}
+
+type messageInfoKey struct{}
+
+// messageInfo is the channel and metadata of the message being consumed
+type messageInfo struct {
+	channel  string
+	metadata map[string]string
+}
+
+// withMessageInfo returns a context carrying the channel and metadata of the
+// message being consumed, reported to the consume hooks
+func withMessageInfo(ctx context.Context, channel string, metadata map[string]string) context.Context {
+	return context.WithValue(ctx, messageInfoKey{}, messageInfo{channel: channel, metadata: metadata})
+}
+
+// notifyConsumed calls the consume hooks for count items of signal consumed
+// with the message info carried by ctx
+func (r *slimReceiver) notifyConsumed(ctx context.Context, signal slimconfig.SignalType, count int) {
+	if len(r.hooks) == 0 {
+		return
+	}
+
+	msgInfo, _ := ctx.Value(messageInfoKey{}).(messageInfo)
+	info := ConsumeInfo{
+		Signal:   signal,
+		Channel:  msgInfo.channel,
+		Count:    count,
+		Metadata: msgInfo.metadata,
+	}
+	for _, hook := range r.hooks {
+		hook.OnConsume(ctx, info)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// recordingHook is a ConsumeHook recording the notifications
+type recordingHook struct {
+	mutex sync.Mutex
+	infos []ConsumeInfo
+}
+
+func (h *recordingHook) OnConsume(_ context.Context, info ConsumeInfo) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.infos = append(h.infos, info)
+}
+
+func (h *recordingHook) consumed() []ConsumeInfo {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.infos
+}
+
+// runSession delivers the messages on a session handled by r until the
+// session is closed
+func runSession(t *testing.T, r *slimReceiver, channel string, msgs ...slim.ReceivedMessage) {
+	session := testutil.NewFakeSession(1, channel)
+	require.NoError(t, r.sessions.AddSession(t.Context(), session))
+	for _, msg := range msgs {
+		session.DeliverMessage(msg)
+	}
+	session.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	handleSession(t.Context(), &wg, r, session)
+	wg.Wait()
+}
+
+func TestConsumeHooks(t *testing.T) {
+	const channel = "agntcy/otel/channel-traces"
+
+	newReceiver := func(cfg *Config, hooks ...ConsumeHook) *slimReceiver {
+		return &slimReceiver{
+			config:   cfg,
+			app:      testutil.NewFakeApp(),
+			sessions: slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+			hooks:    hooks,
+		}
+	}
+
+	t.Run("consumed messages", func(t *testing.T) {
+		hook1, hook2 := &recordingHook{}, &recordingHook{}
+		r := newReceiver(&Config{}, hook1, hook2)
+		r.tracesConsumer = &consumertest.TracesSink{}
+		r.logsConsumer = &consumertest.LogsSink{}
+
+		traces := slim.ReceivedMessage{Payload: tracesPayload(t, "span")}
+		traces.Context.Metadata = map[string]string{"tenant": "acme"}
+		logs := slim.ReceivedMessage{Payload: logsPayload(t, "log")}
+		runSession(t, r, channel, traces, logs)
+
+		want := []ConsumeInfo{
+			{Signal: slimconfig.SignalTraces, Channel: channel, Count: 1, Metadata: map[string]string{"tenant": "acme"}},
+			{Signal: slimconfig.SignalLogs, Channel: channel, Count: 1},
+		}
+		assert.Equal(t, want, hook1.consumed())
+		assert.Equal(t, want, hook2.consumed())
+	})
+
+	t.Run("refused data", func(t *testing.T) {
+		hook := &recordingHook{}
+		r := newReceiver(&Config{}, hook)
+		r.tracesConsumer = consumertest.NewErr(errors.New("boom"))
+
+		runSession(t, r, channel, slim.ReceivedMessage{Payload: tracesPayload(t, "span")})
+		assert.Empty(t, hook.consumed())
+	})
+
+	t.Run("merged payloads", func(t *testing.T) {
+		hook := &recordingHook{}
+		r := newReceiver(&Config{MergeWindow: time.Hour}, hook)
+		r.tracesConsumer = &consumertest.TracesSink{}
+
+		msg := slim.ReceivedMessage{Payload: tracesPayload(t, "span")}
+		msg.Context.Metadata = map[string]string{"tenant": "acme"}
+		runSession(t, r, channel, msg, msg)
+
+		assert.Equal(t, []ConsumeInfo{
+			{Signal: slimconfig.SignalTraces, Channel: channel, Count: 2},
+		}, hook.consumed())
+	})
+}
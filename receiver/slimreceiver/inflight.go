@@ -0,0 +1,103 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"sync"
+)
+
+// inFlightLimiter bounds the number and the total size of the messages being
+// processed across all the sessions, including the payloads buffered in the
+// merge window. A session acquires each message it receives before
+// processing it, so that once the limit is reached the sessions stop reading
+// their messages, which wait in SLIM, instead of exhausting the collector
+// memory. A nil inFlightLimiter does not limit anything.
+type inFlightLimiter struct {
+	// limits, 0 means no limit
+	maxMessages int
+	maxBytes    int
+
+	mutex    sync.Mutex
+	messages int
+	bytes    int
+	// closed and replaced each time messages are released
+	released chan struct{}
+}
+
+// newInFlightLimiter creates a limiter, or nil if neither limit is set
+func newInFlightLimiter(maxMessages, maxBytes int) *inFlightLimiter {
+	if maxMessages <= 0 && maxBytes <= 0 {
+		return nil
+	}
+	return &inFlightLimiter{
+		maxMessages: maxMessages,
+		maxBytes:    maxBytes,
+		released:    make(chan struct{}),
+	}
+}
+
+// tryAcquire counts a message of size bytes in flight if it fits within the
+// limits, and reports whether it did
+func (l *inFlightLimiter) tryAcquire(size int) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.admit(size)
+}
+
+// acquire waits until a message of size bytes fits within the limits and
+// counts it in flight. It returns the ctx error if ctx is done first.
+func (l *inFlightLimiter) acquire(ctx context.Context, size int) error {
+	if l == nil {
+		return nil
+	}
+
+	for {
+		l.mutex.Lock()
+		if l.admit(size) {
+			l.mutex.Unlock()
+			return nil
+		}
+		released := l.released
+		l.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+// admit counts a message of size bytes in flight if it fits within the
+// limits. A message larger than the byte limit is admitted alone. It must be
+// called with the mutex held.
+func (l *inFlightLimiter) admit(size int) bool {
+	fits := l.messages == 0 ||
+		((l.maxMessages <= 0 || l.messages < l.maxMessages) && (l.maxBytes <= 0 || l.bytes+size <= l.maxBytes))
+	if fits {
+		l.messages++
+		l.bytes += size
+	}
+	return fits
+}
+
+// release removes messages of a total of bytes from the messages in flight
+// and wakes up the sessions waiting for them
+func (l *inFlightLimiter) release(messages, bytes int) {
+	if l == nil || messages == 0 {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.messages -= messages
+	l.bytes -= bytes
+	close(l.released)
+	l.released = make(chan struct{})
+}
@@ -0,0 +1,113 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestInFlightLimiter(t *testing.T) {
+	t.Run("no limits", func(t *testing.T) {
+		limiter := newInFlightLimiter(0, 0)
+		assert.Nil(t, limiter)
+		assert.True(t, limiter.tryAcquire(1<<30))
+		require.NoError(t, limiter.acquire(t.Context(), 1<<30))
+		limiter.release(1, 1<<30)
+	})
+
+	t.Run("max messages", func(t *testing.T) {
+		limiter := newInFlightLimiter(2, 0)
+		assert.True(t, limiter.tryAcquire(10))
+		assert.True(t, limiter.tryAcquire(10))
+		assert.False(t, limiter.tryAcquire(10))
+
+		limiter.release(1, 10)
+		assert.True(t, limiter.tryAcquire(10))
+	})
+
+	t.Run("max bytes", func(t *testing.T) {
+		limiter := newInFlightLimiter(0, 100)
+		assert.True(t, limiter.tryAcquire(60))
+		assert.False(t, limiter.tryAcquire(60))
+		assert.True(t, limiter.tryAcquire(40))
+
+		limiter.release(2, 100)
+		assert.True(t, limiter.tryAcquire(500), "a message larger than the limit is admitted alone")
+		assert.False(t, limiter.tryAcquire(1))
+	})
+
+	t.Run("acquire waits for a release", func(t *testing.T) {
+		limiter := newInFlightLimiter(1, 0)
+		require.True(t, limiter.tryAcquire(10))
+
+		acquired := make(chan error)
+		go func() {
+			acquired <- limiter.acquire(t.Context(), 10)
+		}()
+		select {
+		case <-acquired:
+			t.Fatal("acquired above the limit")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		limiter.release(1, 10)
+		select {
+		case err := <-acquired:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("not acquired after the release")
+		}
+	})
+
+	t.Run("acquire stops with the context", func(t *testing.T) {
+		limiter := newInFlightLimiter(1, 0)
+		require.True(t, limiter.tryAcquire(10))
+
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, limiter.acquire(ctx, 10), context.DeadlineExceeded)
+	})
+}
+
+func TestHandleSession_InFlightLimit(t *testing.T) {
+	for _, mergeWindow := range []time.Duration{0, time.Hour} {
+		t.Run("merge window "+mergeWindow.String(), func(t *testing.T) {
+			tracesSink := &consumertest.TracesSink{}
+			r := &slimReceiver{
+				config:         &Config{MergeWindow: mergeWindow},
+				app:            testutil.NewFakeApp(),
+				sessions:       slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+				tracesConsumer: tracesSink,
+				inFlight:       newInFlightLimiter(1, 0),
+			}
+
+			session := testutil.NewFakeSession(1, "agntcy/otel/channel-traces")
+			require.NoError(t, r.sessions.AddSession(t.Context(), session))
+			for range 3 {
+				session.Deliver(tracesPayload(t, "span"))
+			}
+			session.Close()
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			handleSession(t.Context(), &wg, r, session)
+			wg.Wait()
+
+			// the merged payloads are flushed instead of holding the limit
+			assert.Equal(t, 3, tracesSink.SpanCount())
+			assert.True(t, r.inFlight.tryAcquire(1), "all the messages are released")
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+const (
+	// decodeFailureReportInterval is the minimum interval between two
+	// reports of the malformed payloads with the same fingerprint
+	decodeFailureReportInterval = time.Minute
+
+	// fingerprintPrefixBytes is the number of leading bytes of a payload
+	// hashed in its fingerprint
+	fingerprintPrefixBytes = 16
+
+	// maxDecodeFailureKeys bounds the fingerprints tracked at once, so that a
+	// producer sending random data does not grow the memory of the receiver
+	maxDecodeFailureKeys = 1024
+)
+
+// payloadFingerprint identifies the malformed payloads of a producer without
+// logging their content: the payloads of a broken producer usually share
+// their size or their first bytes, e.g. a header of another format
+type payloadFingerprint struct {
+	size       int
+	prefixHash uint32
+}
+
+// fingerprintPayload returns the fingerprint of payload
+func fingerprintPayload(payload []byte) payloadFingerprint {
+	h := fnv.New32a()
+	_, _ = h.Write(payload[:min(len(payload), fingerprintPrefixBytes)])
+	return payloadFingerprint{size: len(payload), prefixHash: h.Sum32()}
+}
+
+// String returns the fingerprint as size:prefix-hash
+func (f payloadFingerprint) String() string {
+	return fmt.Sprintf("%d:%08x", f.size, f.prefixHash)
+}
+
+// decodeFailureKey groups the malformed payloads of a producer
+type decodeFailureKey struct {
+	channel     string
+	source      string
+	fingerprint payloadFingerprint
+}
+
+// decodeFailureCount is the number of malformed payloads of a key since its
+// last report
+type decodeFailureCount struct {
+	pending  uint64
+	reported time.Time
+}
+
+// decodeFailures aggregates the malformed payloads by channel, source and
+// fingerprint, so that each group is reported at most once per interval. A
+// nil decodeFailures reports every payload.
+type decodeFailures struct {
+	interval time.Duration
+
+	mutex    sync.Mutex
+	failures map[decodeFailureKey]*decodeFailureCount
+}
+
+// newDecodeFailures creates a decodeFailures reporting each group at most
+// once every interval
+func newDecodeFailures(interval time.Duration) *decodeFailures {
+	return &decodeFailures{
+		interval: interval,
+		failures: make(map[decodeFailureKey]*decodeFailureCount),
+	}
+}
+
+// record records a malformed payload and returns the number of payloads of
+// key to report now, including this one, or 0 if the report is deferred
+func (f *decodeFailures) record(key decodeFailureKey, now time.Time) uint64 {
+	if f == nil {
+		return 1
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	count, ok := f.failures[key]
+	if !ok {
+		if len(f.failures) >= maxDecodeFailureKeys {
+			f.prune(now)
+		}
+		if len(f.failures) >= maxDecodeFailureKeys {
+			// too many producers at once, the unmarshal failures metric
+			// still counts the payload
+			return 0
+		}
+		f.failures[key] = &decodeFailureCount{reported: now}
+		return 1
+	}
+
+	count.pending++
+	if now.Sub(count.reported) < f.interval {
+		return 0
+	}
+	reported := count.pending
+	count.pending = 0
+	count.reported = now
+	return reported
+}
+
+// prune drops the groups without pending payloads reported more than an
+// interval ago
+func (f *decodeFailures) prune(now time.Time) {
+	for key, count := range f.failures {
+		if count.pending == 0 && now.Sub(count.reported) >= f.interval {
+			delete(f.failures, key)
+		}
+	}
+}
+
+// forget drops the groups of a channel whose session ended
+func (f *decodeFailures) forget(channel string) {
+	if f == nil {
+		return
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for key := range f.failures {
+		if key.channel == channel {
+			delete(f.failures, key)
+		}
+	}
+}
+
+// reportDecodeFailure logs a payload that could not be decoded as any
+// signal, aggregated with the payloads of the same producer and fingerprint.
+// The first decode-failure-dump-bytes of the payload are dumped at debug level.
+func (r *slimReceiver) reportDecodeFailure(ctx context.Context, payload []byte) {
+	transport, _ := ctx.Value(transportKey{}).(slimTransport)
+	key := decodeFailureKey{
+		channel:     transport.channel,
+		source:      transport.source,
+		fingerprint: fingerprintPayload(payload),
+	}
+	count := r.decodeFailures.record(key, time.Now())
+	if count == 0 {
+		return
+	}
+
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	logger.Warn("Unable to decode payloads as OTLP data",
+		zap.String("channel", key.channel),
+		zap.String("source", key.source),
+		zap.Int("payloadSize", len(payload)),
+		zap.Stringer("fingerprint", key.fingerprint),
+		zap.Uint64("count", count))
+
+	if dumpBytes := r.config.DecodeFailureDumpBytes; dumpBytes > 0 && logger.Core().Enabled(zapcore.DebugLevel) {
+		logger.Debug("Malformed payload dump",
+			zap.Stringer("fingerprint", key.fingerprint),
+			zap.String("dump", hex.EncodeToString(payload[:min(len(payload), dumpBytes)])))
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+func TestFingerprintPayload(t *testing.T) {
+	payload := []byte("not an OTLP payload, but a JSON document")
+	fingerprint := fingerprintPayload(payload)
+	assert.Equal(t, len(payload), fingerprint.size)
+	assert.Equal(t, fingerprint, fingerprintPayload(payload), "the fingerprint must be stable")
+
+	sameStart := append([]byte(nil), payload[:fingerprintPrefixBytes]...)
+	sameStart = append(sameStart, []byte("with another end")...)
+	assert.Equal(t, fingerprint.prefixHash, fingerprintPayload(sameStart).prefixHash,
+		"only the first bytes are hashed")
+	assert.NotEqual(t, fingerprint.prefixHash, fingerprintPayload([]byte("another payload")).prefixHash)
+
+	assert.Equal(t, payloadFingerprint{size: 3, prefixHash: fingerprintPayload([]byte("abc")).prefixHash},
+		fingerprintPayload([]byte("abc")), "short payloads are fully hashed")
+}
+
+func TestDecodeFailures(t *testing.T) {
+	failures := newDecodeFailures(time.Minute)
+	now := time.Now()
+	key := decodeFailureKey{channel: "agntcy/otel/channel-1", fingerprint: fingerprintPayload([]byte("bad"))}
+	other := decodeFailureKey{channel: "agntcy/otel/channel-2", fingerprint: key.fingerprint}
+
+	assert.Equal(t, uint64(1), failures.record(key, now), "the first payload is reported")
+	assert.Equal(t, uint64(0), failures.record(key, now.Add(time.Second)))
+	assert.Equal(t, uint64(0), failures.record(key, now.Add(2*time.Second)))
+	assert.Equal(t, uint64(1), failures.record(other, now.Add(2*time.Second)), "the keys are aggregated separately")
+	assert.Equal(t, uint64(3), failures.record(key, now.Add(time.Minute)),
+		"the payloads since the last report are counted")
+
+	failures.forget(key.channel)
+	assert.Equal(t, uint64(1), failures.record(key, now.Add(time.Minute)))
+
+	var disabled *decodeFailures
+	assert.Equal(t, uint64(1), disabled.record(key, now))
+	assert.Equal(t, uint64(1), disabled.record(key, now))
+}
+
+func TestDecodeFailures_MaxKeys(t *testing.T) {
+	failures := newDecodeFailures(time.Minute)
+	now := time.Now()
+	for i := range maxDecodeFailureKeys {
+		key := decodeFailureKey{fingerprint: payloadFingerprint{size: i}}
+		require.Equal(t, uint64(1), failures.record(key, now))
+	}
+
+	overflow := decodeFailureKey{fingerprint: payloadFingerprint{size: maxDecodeFailureKeys}}
+	assert.Equal(t, uint64(0), failures.record(overflow, now.Add(time.Second)), "the tracked keys are bounded")
+	assert.Equal(t, uint64(1), failures.record(overflow, now.Add(time.Minute)),
+		"the keys reported an interval ago make room")
+}
+
+func TestReportDecodeFailure(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := slimcommon.InitContextWithLogger(t.Context(), zap.New(core))
+	ctx = withTransport(ctx, slimTransport{channel: "agntcy/otel/channel-1", source: "agntcy/otel/producer"})
+
+	r := &slimReceiver{
+		config:         &Config{DecodeFailureDumpBytes: 4},
+		tracesConsumer: &consumertest.TracesSink{},
+		decodeFailures: newDecodeFailures(time.Minute),
+	}
+	payload := []byte{0xde, 0xad, 0xbe, 0xef, 0x01, 0x02}
+	for range 3 {
+		_, ok := unmarshalPayload(ctx, r, payload)
+		assert.False(t, ok)
+	}
+
+	warnings := logs.FilterMessage("Unable to decode payloads as OTLP data").All()
+	require.Len(t, warnings, 1, "the payloads of the same producer are aggregated")
+	fields := warnings[0].ContextMap()
+	assert.Equal(t, "agntcy/otel/channel-1", fields["channel"])
+	assert.Equal(t, "agntcy/otel/producer", fields["source"])
+	assert.Equal(t, fingerprintPayload(payload).String(), fields["fingerprint"])
+
+	dumps := logs.FilterMessage("Malformed payload dump").All()
+	require.Len(t, dumps, 1)
+	assert.Equal(t, "deadbeef", dumps[0].ContextMap()["dump"], "only the first bytes are dumped")
+
+	// no dump by default
+	core, logs = observer.New(zapcore.DebugLevel)
+	ctx = slimcommon.InitContextWithLogger(t.Context(), zap.New(core))
+	r.config.DecodeFailureDumpBytes = 0
+	r.decodeFailures = nil
+	_, ok := unmarshalPayload(ctx, r, payload)
+	assert.False(t, ok)
+	assert.Equal(t, 1, logs.FilterMessage("Unable to decode payloads as OTLP data").Len())
+	assert.Zero(t, logs.FilterMessage("Malformed payload dump").Len())
+}
@@ -0,0 +1,135 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// messageMerger coalesces the payloads received on a single session within
+// a time window into one batch per signal type, so that chatty exporters
+// sending many tiny payloads do not produce one ConsumeX call per message.
+// A nil merger does not buffer anything.
+type messageMerger struct {
+	r           *slimReceiver
+	window      time.Duration
+	maxMessages int
+
+	traces  ptrace.Traces
+	metrics pmetric.Metrics
+	logs    plog.Logs
+	// encoding of the last payload buffered, the payloads of a session share
+	// the encoding of its exporter
+	format string
+	// number of payloads currently buffered
+	pending int
+	// total size of the payloads currently buffered
+	pendingBytes int
+	// time at which the buffered payloads must be flushed
+	deadline time.Time
+}
+
+// newMessageMerger creates a merger for a session, or nil if merging is disabled
+func newMessageMerger(r *slimReceiver) *messageMerger {
+	if r.config.MergeWindow <= 0 {
+		return nil
+	}
+	return &messageMerger{
+		r:           r,
+		window:      r.config.MergeWindow,
+		maxMessages: r.config.MergeMaxMessages,
+		traces:      ptrace.NewTraces(),
+		metrics:     pmetric.NewMetrics(),
+		logs:        plog.NewLogs(),
+	}
+}
+
+// addPayload decodes the payload and appends it to the pending batch of its
+// signal type. Returns false if the payload could not be decoded.
+func (m *messageMerger) addPayload(ctx context.Context, payload []byte) bool {
+	data, ok := unmarshalPayload(ctx, m.r, payload)
+	if !ok {
+		return false
+	}
+	if data == nil {
+		// empty payload, nothing to merge
+		m.r.inFlight.release(1, len(payload))
+		return true
+	}
+
+	switch d := data.(type) {
+	case ptrace.Traces:
+		d.ResourceSpans().MoveAndAppendTo(m.traces.ResourceSpans())
+	case pmetric.Metrics:
+		d.ResourceMetrics().MoveAndAppendTo(m.metrics.ResourceMetrics())
+	case plog.Logs:
+		d.ResourceLogs().MoveAndAppendTo(m.logs.ResourceLogs())
+	}
+
+	if m.pending == 0 {
+		m.deadline = time.Now().Add(m.window)
+	}
+	m.format = payloadFormat(payload)
+	m.pending++
+	m.pendingBytes += len(payload)
+
+	if m.maxMessages > 0 && m.pending >= m.maxMessages {
+		m.flush(ctx)
+	}
+	return true
+}
+
+// timeout returns how long to wait for the next message so that the pending
+// batches are flushed on time, capped to maxTimeout
+func (m *messageMerger) timeout(maxTimeout time.Duration) time.Duration {
+	if m == nil || m.pending == 0 {
+		return maxTimeout
+	}
+	return max(min(time.Until(m.deadline), maxTimeout), time.Millisecond)
+}
+
+// flushTimer returns a channel receiving when the pending batches are due,
+// or nil if there are none
+func (m *messageMerger) flushTimer() <-chan time.Time {
+	if m == nil || m.pending == 0 {
+		return nil
+	}
+	return time.After(m.timeout(m.r.config.MergeWindow))
+}
+
+// flushIfDue flushes the pending batches if the merge window has elapsed
+func (m *messageMerger) flushIfDue(ctx context.Context) {
+	if m == nil || m.pending == 0 || time.Now().Before(m.deadline) {
+		return
+	}
+	m.flush(ctx)
+}
+
+// flush sends the pending batches to the consumers
+func (m *messageMerger) flush(ctx context.Context) {
+	if m == nil || m.pending == 0 {
+		return
+	}
+
+	if m.traces.ResourceSpans().Len() > 0 {
+		_ = handleReceivedTraces(ctx, m.r, m.format, m.traces)
+		m.traces = ptrace.NewTraces()
+	}
+	if m.metrics.ResourceMetrics().Len() > 0 {
+		_ = handleReceivedMetrics(ctx, m.r, m.format, m.metrics)
+		m.metrics = pmetric.NewMetrics()
+	}
+	if m.logs.ResourceLogs().Len() > 0 {
+		_ = handleReceivedLogs(ctx, m.r, m.format, m.logs)
+		m.logs = plog.NewLogs()
+	}
+	m.r.inFlight.release(m.pending, m.pendingBytes)
+	m.pending = 0
+	m.pendingBytes = 0
+}
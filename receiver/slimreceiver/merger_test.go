@@ -0,0 +1,137 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func tracesPayload(t *testing.T, spanName string) []byte {
+	traces := ptrace.NewTraces()
+	traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName(spanName)
+	marshaler := &ptrace.ProtoMarshaler{}
+	payload, err := marshaler.MarshalTraces(traces)
+	require.NoError(t, err)
+	return payload
+}
+
+func logsPayload(t *testing.T, body string) []byte {
+	logs := plog.NewLogs()
+	logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr(body)
+	marshaler := &plog.ProtoMarshaler{}
+	payload, err := marshaler.MarshalLogs(logs)
+	require.NoError(t, err)
+	return payload
+}
+
+func TestMessageMerger_Disabled(t *testing.T) {
+	r := &slimReceiver{config: &Config{}}
+	merger := newMessageMerger(r)
+	assert.Nil(t, merger)
+
+	// a nil merger never changes the timeout and never flushes
+	assert.Equal(t, time.Second, merger.timeout(time.Second))
+	assert.Nil(t, merger.flushTimer())
+	merger.flushIfDue(t.Context())
+	merger.flush(t.Context())
+}
+
+func TestMessageMerger_MaxMessages(t *testing.T) {
+	tracesSink := &consumertest.TracesSink{}
+	r := &slimReceiver{
+		config:         &Config{MergeWindow: time.Hour, MergeMaxMessages: 3},
+		tracesConsumer: tracesSink,
+	}
+	merger := newMessageMerger(r)
+	require.NotNil(t, merger)
+
+	for _, name := range []string{"span-1", "span-2"} {
+		require.True(t, merger.addPayload(t.Context(), tracesPayload(t, name)))
+	}
+	assert.Empty(t, tracesSink.AllTraces(), "batch must not be consumed before the limit")
+	assert.LessOrEqual(t, merger.timeout(time.Second), time.Second)
+
+	require.True(t, merger.addPayload(t.Context(), tracesPayload(t, "span-3")))
+	require.Len(t, tracesSink.AllTraces(), 1)
+	assert.Equal(t, 3, tracesSink.AllTraces()[0].SpanCount())
+	assert.Equal(t, 3, tracesSink.AllTraces()[0].ResourceSpans().Len())
+}
+
+func TestMessageMerger_Window(t *testing.T) {
+	tracesSink := &consumertest.TracesSink{}
+	logsSink := &consumertest.LogsSink{}
+	r := &slimReceiver{
+		config:         &Config{MergeWindow: 50 * time.Millisecond},
+		tracesConsumer: tracesSink,
+		logsConsumer:   logsSink,
+	}
+	merger := newMessageMerger(r)
+
+	require.True(t, merger.addPayload(t.Context(), tracesPayload(t, "span-1")))
+	require.True(t, merger.addPayload(t.Context(), logsPayload(t, "log-1")))
+	require.True(t, merger.addPayload(t.Context(), tracesPayload(t, "span-2")))
+	assert.False(t, merger.addPayload(t.Context(), []byte("invalid payload")))
+
+	merger.flushIfDue(t.Context())
+	assert.Empty(t, tracesSink.AllTraces())
+	assert.LessOrEqual(t, merger.timeout(time.Second), 50*time.Millisecond)
+
+	select {
+	case <-merger.flushTimer():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the flush timer did not fire")
+	}
+	merger.flushIfDue(t.Context())
+
+	require.Len(t, tracesSink.AllTraces(), 1)
+	assert.Equal(t, 2, tracesSink.AllTraces()[0].SpanCount())
+	require.Len(t, logsSink.AllLogs(), 1)
+	assert.Equal(t, 1, logsSink.AllLogs()[0].LogRecordCount())
+
+	// nothing left to flush
+	merger.flush(t.Context())
+	assert.Len(t, tracesSink.AllTraces(), 1)
+	assert.Equal(t, time.Second, merger.timeout(time.Second))
+	assert.Nil(t, merger.flushTimer(), "no timer without pending batches")
+}
+
+func TestHandleSession_MergesMessages(t *testing.T) {
+	tracesSink := &consumertest.TracesSink{}
+	app := testutil.NewFakeApp()
+	r := &slimReceiver{
+		config:         &Config{MergeWindow: time.Hour},
+		app:            app,
+		sessions:       slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		tracesConsumer: tracesSink,
+	}
+
+	session := testutil.NewFakeSession(1, "agntcy/otel/channel-traces")
+	require.NoError(t, r.sessions.AddSession(t.Context(), session))
+	for _, name := range []string{"span-1", "span-2", "span-3"} {
+		session.Deliver(tracesPayload(t, name))
+	}
+	session.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	handleSession(t.Context(), &wg, r, session)
+	wg.Wait()
+
+	// the pending batch is flushed when the session closes
+	require.Len(t, tracesSink.AllTraces(), 1)
+	assert.Equal(t, 3, tracesSink.AllTraces()[0].SpanCount())
+	assert.Empty(t, r.sessions.ListSessionNames(t.Context()))
+}
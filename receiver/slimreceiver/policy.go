@@ -0,0 +1,82 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"time"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// policy violations reported by policyValidator.check
+const (
+	violationMessageSize = "max-message-size"
+	violationMessageRate = "max-message-rate"
+	violationSignals     = "signals"
+)
+
+// policyValidator checks the messages received on a session against the
+// channel policy advertised by the channel manager in the session metadata.
+// A nil validator accepts every message.
+type policyValidator struct {
+	policy slimcommon.ChannelPolicy
+
+	// token buckets used to check the message rate of each exporter, by
+	// message source, refilled at MaxMessageRate tokens per second with a
+	// burst of one second worth of messages
+	buckets map[string]*tokenBucket
+	burst   float64
+}
+
+// tokenBucket is the token bucket of the messages of an exporter
+type tokenBucket struct {
+	tokens     float64
+	lastUpdate time.Time
+}
+
+// newPolicyValidator creates a validator for the policy, or nil if the
+// policy sets no limit
+func newPolicyValidator(policy slimcommon.ChannelPolicy) *policyValidator {
+	if policy.IsZero() {
+		return nil
+	}
+	burst := max(policy.MaxMessageRate, 1)
+	return &policyValidator{
+		policy:  policy,
+		buckets: make(map[string]*tokenBucket),
+		burst:   burst,
+	}
+}
+
+// check returns the policy violated by a message of the given size received
+// from source at now, or an empty string if the message complies with the
+// policy. The message rate applies to each source, i.e. each exporter
+// publishing on the channel.
+func (v *policyValidator) check(size int, source string, now time.Time) string {
+	if v == nil {
+		return ""
+	}
+
+	if v.policy.MaxMessageSize > 0 && size > v.policy.MaxMessageSize {
+		return violationMessageSize
+	}
+
+	if v.policy.MaxMessageRate > 0 {
+		bucket, ok := v.buckets[source]
+		if !ok {
+			bucket = &tokenBucket{tokens: v.burst}
+			v.buckets[source] = bucket
+		} else {
+			elapsed := now.Sub(bucket.lastUpdate).Seconds()
+			bucket.tokens = min(bucket.tokens+elapsed*v.policy.MaxMessageRate, v.burst)
+		}
+		bucket.lastUpdate = now
+		if bucket.tokens < 1 {
+			return violationMessageRate
+		}
+		bucket.tokens--
+	}
+
+	return ""
+}
@@ -0,0 +1,128 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+
+	slim "github.com/agntcy/slim-bindings-go"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// testSource is the source of the messages checked by the policy validator
+const testSource = "agntcy/otel/exporter-1"
+
+func TestPolicyValidator(t *testing.T) {
+	t.Run("no policy", func(t *testing.T) {
+		validator := newPolicyValidator(slimcommon.ChannelPolicy{})
+		assert.Nil(t, validator)
+		assert.Empty(t, validator.check(1<<30, testSource, time.Now()))
+	})
+
+	t.Run("max message size", func(t *testing.T) {
+		validator := newPolicyValidator(slimcommon.ChannelPolicy{MaxMessageSize: 100})
+		assert.Empty(t, validator.check(100, testSource, time.Now()))
+		assert.Equal(t, violationMessageSize, validator.check(101, testSource, time.Now()))
+	})
+
+	t.Run("max message rate", func(t *testing.T) {
+		validator := newPolicyValidator(slimcommon.ChannelPolicy{MaxMessageRate: 2})
+		now := time.Now()
+
+		// a burst of one second worth of messages is allowed
+		assert.Empty(t, validator.check(10, testSource, now))
+		assert.Empty(t, validator.check(10, testSource, now))
+		assert.Equal(t, violationMessageRate, validator.check(10, testSource, now))
+
+		// tokens are refilled at the max rate
+		assert.Empty(t, validator.check(10, testSource, now.Add(500*time.Millisecond)))
+		assert.Equal(t, violationMessageRate, validator.check(10, testSource, now.Add(600*time.Millisecond)))
+	})
+
+	t.Run("max message rate of each exporter", func(t *testing.T) {
+		validator := newPolicyValidator(slimcommon.ChannelPolicy{MaxMessageRate: 1})
+		now := time.Now()
+
+		assert.Empty(t, validator.check(10, testSource, now))
+		assert.Equal(t, violationMessageRate, validator.check(10, testSource, now))
+		assert.Empty(t, validator.check(10, "agntcy/otel/exporter-2", now),
+			"the other exporters of the channel have their own rate")
+		assert.Equal(t, violationMessageRate, validator.check(10, "agntcy/otel/exporter-2", now))
+	})
+}
+
+func TestHandleSession_DropsOversizedMessages(t *testing.T) {
+	tracesSink := &consumertest.TracesSink{}
+	r := &slimReceiver{
+		config:         &Config{},
+		app:            testutil.NewFakeApp(),
+		sessions:       slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		tracesConsumer: tracesSink,
+	}
+
+	small := tracesPayload(t, "span")
+	large := tracesPayload(t, "a-span-with-a-much-longer-name-than-the-max-message-size-allows")
+
+	session := testutil.NewFakeSession(1, "agntcy/otel/channel-traces")
+	session.Config.Metadata = make(map[string]string)
+	slimcommon.ChannelPolicy{MaxMessageSize: len(small)}.AddToMetadata(session.Config.Metadata)
+	require.NoError(t, r.sessions.AddSession(t.Context(), session))
+
+	session.Deliver(small)
+	session.Deliver(large)
+	session.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	handleSession(t.Context(), &wg, r, session)
+	wg.Wait()
+
+	require.Len(t, tracesSink.AllTraces(), 1)
+	assert.Equal(t, "span", tracesSink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name())
+}
+
+func TestHandleSession_DropsSignalsNotCarried(t *testing.T) {
+	tracesSink := &consumertest.TracesSink{}
+	logsSink := &consumertest.LogsSink{}
+	r := &slimReceiver{
+		config:         &Config{},
+		app:            testutil.NewFakeApp(),
+		sessions:       slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		tracesConsumer: tracesSink,
+		logsConsumer:   logsSink,
+	}
+
+	session := testutil.NewFakeSession(1, "agntcy/otel/channel-traces")
+	session.Config.Metadata = make(map[string]string)
+	policy := slimcommon.ChannelPolicy{Signals: []slimconfig.SignalType{slimconfig.SignalTraces}}
+	policy.AddToMetadata(session.Config.Metadata)
+	require.NoError(t, r.sessions.AddSession(t.Context(), session))
+
+	deliver := func(payload []byte, signal slimconfig.SignalType) {
+		msg := slim.ReceivedMessage{Payload: payload}
+		msg.Context.Metadata = make(map[string]string)
+		slimcommon.AddSignal(msg.Context.Metadata, signal)
+		session.DeliverMessage(msg)
+	}
+	deliver(tracesPayload(t, "span"), slimconfig.SignalTraces)
+	deliver(logsPayload(t, "log"), slimconfig.SignalLogs)
+	session.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	handleSession(t.Context(), &wg, r, session)
+	wg.Wait()
+
+	assert.Len(t, tracesSink.AllTraces(), 1)
+	assert.Empty(t, logsSink.AllLogs(), "the channel does not carry logs")
+}
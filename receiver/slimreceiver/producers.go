@@ -0,0 +1,281 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+const (
+	// producersPath is the path of the debug endpoint listing the producers
+	// of each channel
+	producersPath = "/debug/producers"
+
+	// maxProducersPerChannel bounds the producers tracked per channel, the
+	// producer seen the longest ago is forgotten first
+	maxProducersPerChannel = 256
+)
+
+// Resource attributes of the OpenTelemetry SDK that produced the data, see
+// the telemetry.sdk semantic conventions
+const (
+	attributeServiceName = "service.name"
+	attributeSDKName     = "telemetry.sdk.name"
+	attributeSDKLanguage = "telemetry.sdk.language"
+	attributeSDKVersion  = "telemetry.sdk.version"
+)
+
+// producerInfo is what the receiver last received from a producer of a
+// channel
+type producerInfo struct {
+	// Source is the SLIM name of the participant that sent the data, empty if
+	// unknown
+	Source string `json:"source,omitempty"`
+	// ServiceName is the service.name resource attribute
+	ServiceName string `json:"serviceName,omitempty"`
+	// SchemaURL is the schema URL of the resource, or of its first scope
+	// with one if the resource has none
+	SchemaURL   string    `json:"schemaUrl,omitempty"`
+	SDKName     string    `json:"sdkName,omitempty"`
+	SDKLanguage string    `json:"sdkLanguage,omitempty"`
+	SDKVersion  string    `json:"sdkVersion,omitempty"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+// producerKey identifies a producer of a channel: the sender and the service
+// of the resources, several services may share an exporter
+type producerKey struct {
+	source      string
+	serviceName string
+}
+
+// channelProducers tracks the producers of each channel, with the schema URL
+// and the SDK of the last resource received from them, so that the operators
+// can tell which producers feed a channel and follow the schema upgrades. A
+// nil channelProducers does not record anything.
+type channelProducers struct {
+	mutex    sync.Mutex
+	channels map[string]map[producerKey]*producerInfo
+}
+
+// newChannelProducers creates an empty channelProducers
+func newChannelProducers() *channelProducers {
+	return &channelProducers{channels: make(map[string]map[producerKey]*producerInfo)}
+}
+
+// record records the producers of the resources of data, a ptrace.Traces, a
+// pmetric.Metrics or a plog.Logs, received on channel from source, and
+// returns them
+func (p *channelProducers) record(channel, source string, data any) []producerInfo {
+	if p == nil {
+		return nil
+	}
+	now := time.Now()
+	var received []producerInfo
+	forEachResourceSchema(data, func(resource pcommon.Resource, schemaURL string) {
+		attrs := resource.Attributes()
+		received = append(received, producerInfo{
+			Source:      source,
+			ServiceName: stringAttribute(attrs, attributeServiceName),
+			SchemaURL:   schemaURL,
+			SDKName:     stringAttribute(attrs, attributeSDKName),
+			SDKLanguage: stringAttribute(attrs, attributeSDKLanguage),
+			SDKVersion:  stringAttribute(attrs, attributeSDKVersion),
+			LastSeen:    now,
+		})
+	})
+	if len(received) == 0 {
+		return nil
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	producers, ok := p.channels[channel]
+	if !ok {
+		producers = make(map[producerKey]*producerInfo)
+		p.channels[channel] = producers
+	}
+	for i := range received {
+		info := received[i]
+		key := producerKey{source: info.Source, serviceName: info.ServiceName}
+		if _, known := producers[key]; !known && len(producers) >= maxProducersPerChannel {
+			evictOldest(producers)
+		}
+		producers[key] = &info
+	}
+	return received
+}
+
+// evictOldest forgets the producer seen the longest ago
+func evictOldest(producers map[producerKey]*producerInfo) {
+	var oldest producerKey
+	var oldestSeen time.Time
+	for key, info := range producers {
+		if oldestSeen.IsZero() || info.LastSeen.Before(oldestSeen) {
+			oldest, oldestSeen = key, info.LastSeen
+		}
+	}
+	delete(producers, oldest)
+}
+
+// forget forgets the producers of a channel whose session is closed
+func (p *channelProducers) forget(channel string) {
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.channels, channel)
+}
+
+// snapshot returns the producers of each channel, sorted by source and
+// service name
+func (p *channelProducers) snapshot() map[string][]producerInfo {
+	if p == nil {
+		return map[string][]producerInfo{}
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	channels := make(map[string][]producerInfo, len(p.channels))
+	for channel, producers := range p.channels {
+		infos := make([]producerInfo, 0, len(producers))
+		for _, info := range producers {
+			infos = append(infos, *info)
+		}
+		slices.SortFunc(infos, func(a, b producerInfo) int {
+			return cmp.Or(cmp.Compare(a.Source, b.Source), cmp.Compare(a.ServiceName, b.ServiceName))
+		})
+		channels[channel] = infos
+	}
+	return channels
+}
+
+// stringAttribute returns the value of a string attribute, empty if missing
+func stringAttribute(attrs pcommon.Map, key string) string {
+	if value, ok := attrs.Get(key); ok {
+		return value.AsString()
+	}
+	return ""
+}
+
+// forEachResourceSchema calls f with each resource of data, a ptrace.Traces,
+// a pmetric.Metrics or a plog.Logs, and its schema URL, or the one of its
+// first scope with one if the resource has none
+func forEachResourceSchema(data any, f func(pcommon.Resource, string)) {
+	switch d := data.(type) {
+	case ptrace.Traces:
+		for i := range d.ResourceSpans().Len() {
+			rs := d.ResourceSpans().At(i)
+			schemaURL := rs.SchemaUrl()
+			for j := 0; schemaURL == "" && j < rs.ScopeSpans().Len(); j++ {
+				schemaURL = rs.ScopeSpans().At(j).SchemaUrl()
+			}
+			f(rs.Resource(), schemaURL)
+		}
+	case pmetric.Metrics:
+		for i := range d.ResourceMetrics().Len() {
+			rm := d.ResourceMetrics().At(i)
+			schemaURL := rm.SchemaUrl()
+			for j := 0; schemaURL == "" && j < rm.ScopeMetrics().Len(); j++ {
+				schemaURL = rm.ScopeMetrics().At(j).SchemaUrl()
+			}
+			f(rm.Resource(), schemaURL)
+		}
+	case plog.Logs:
+		for i := range d.ResourceLogs().Len() {
+			rl := d.ResourceLogs().At(i)
+			schemaURL := rl.SchemaUrl()
+			for j := 0; schemaURL == "" && j < rl.ScopeLogs().Len(); j++ {
+				schemaURL = rl.ScopeLogs().At(j).SchemaUrl()
+			}
+			f(rl.Resource(), schemaURL)
+		}
+	}
+}
+
+// recordProducers records the producers of the decoded data, from the
+// transport carried by ctx, and counts their resources
+func (r *slimReceiver) recordProducers(ctx context.Context, data any) {
+	t, ok := ctx.Value(transportKey{}).(slimTransport)
+	if !ok {
+		return
+	}
+	for _, info := range r.producers.record(t.channel, t.source, data) {
+		r.telemetry.recordProducerResource(ctx, t.channel, info)
+	}
+}
+
+// startDebugServer starts the HTTP server of the debug endpoint, if enabled
+func (r *slimReceiver) startDebugServer(ctx context.Context) error {
+	if r.config.DebugEndpoint == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", r.config.DebugEndpoint)
+	if err != nil {
+		return fmt.Errorf("failed to listen on the debug endpoint %s: %w", r.config.DebugEndpoint, err)
+	}
+
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	mux := http.NewServeMux()
+	mux.HandleFunc(producersPath, r.handleProducers)
+	r.debugServer = &http.Server{Handler: mux, ReadHeaderTimeout: time.Second}
+
+	go func() {
+		if err := r.debugServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Debug endpoint stopped", zap.Error(err))
+		}
+	}()
+
+	logger.Info("Debug endpoint started", zap.String("address", listener.Addr().String()))
+	return nil
+}
+
+// handleProducers lists the producers of each channel as JSON, or of the
+// channel of the channel query parameter
+func (r *slimReceiver) handleProducers(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	channels := r.producers.snapshot()
+	if channel := req.URL.Query().Get("channel"); channel != "" {
+		producers := channels[channel]
+		if producers == nil {
+			producers = []producerInfo{}
+		}
+		channels = map[string][]producerInfo{channel: producers}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(channels)
+}
+
+// stopDebugServer stops the HTTP server of the debug endpoint, if started
+func (r *slimReceiver) stopDebugServer(ctx context.Context) {
+	if r.debugServer == nil {
+		return
+	}
+	if err := r.debugServer.Shutdown(ctx); err != nil {
+		slimcommon.LoggerFromContextOrDefault(ctx).Warn("Failed to stop the debug endpoint", zap.Error(err))
+	}
+	r.debugServer = nil
+}
@@ -0,0 +1,110 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// producerTraces returns traces of a resource of service, produced by the
+// Go SDK version, with the given resource and scope schema URLs
+func producerTraces(service, version, resourceSchema, scopeSchema string) ptrace.Traces {
+	traces := ptrace.NewTraces()
+	rs := traces.ResourceSpans().AppendEmpty()
+	rs.SetSchemaUrl(resourceSchema)
+	attrs := rs.Resource().Attributes()
+	attrs.PutStr(attributeServiceName, service)
+	attrs.PutStr(attributeSDKName, "opentelemetry")
+	attrs.PutStr(attributeSDKLanguage, "go")
+	attrs.PutStr(attributeSDKVersion, version)
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.SetSchemaUrl(scopeSchema)
+	ss.Spans().AppendEmpty().SetName("span")
+	return traces
+}
+
+func TestChannelProducers(t *testing.T) {
+	const (
+		channel = "agntcy/otel/channel-traces"
+		source  = "agntcy/otel/exporter-traces/1"
+	)
+	p := newChannelProducers()
+
+	received := p.record(channel, source,
+		producerTraces("checkout", "1.37.0", "https://opentelemetry.io/schemas/1.26.0", ""))
+	require.Len(t, received, 1)
+	assert.Equal(t, "https://opentelemetry.io/schemas/1.26.0", received[0].SchemaURL)
+
+	// the schema upgrade of a producer replaces its previous schema, the
+	// scope schema is used when the resource has none
+	p.record(channel, source, producerTraces("checkout", "1.38.0", "", "https://opentelemetry.io/schemas/1.37.0"))
+	p.record(channel, source, producerTraces("cart", "1.37.0", "https://opentelemetry.io/schemas/1.26.0", ""))
+	metrics := pmetric.NewMetrics()
+	metrics.ResourceMetrics().AppendEmpty().Resource().Attributes().PutStr(attributeServiceName, "cart")
+	p.record("agntcy/otel/channel-metrics", "", metrics)
+
+	producers := p.snapshot()
+	require.Len(t, producers[channel], 2)
+	cart, checkout := producers[channel][0], producers[channel][1]
+	assert.Equal(t, "cart", cart.ServiceName)
+	assert.Equal(t, "checkout", checkout.ServiceName)
+	assert.Equal(t, source, checkout.Source)
+	assert.Equal(t, "https://opentelemetry.io/schemas/1.37.0", checkout.SchemaURL)
+	assert.Equal(t, "opentelemetry", checkout.SDKName)
+	assert.Equal(t, "go", checkout.SDKLanguage)
+	assert.Equal(t, "1.38.0", checkout.SDKVersion)
+	assert.False(t, checkout.LastSeen.IsZero())
+	require.Len(t, producers["agntcy/otel/channel-metrics"], 1)
+	assert.Empty(t, producers["agntcy/otel/channel-metrics"][0].SDKName)
+
+	p.forget(channel)
+	assert.NotContains(t, p.snapshot(), channel)
+
+	// a nil channelProducers does not record anything
+	var disabled *channelProducers
+	assert.Nil(t, disabled.record(channel, source, producerTraces("checkout", "1.38.0", "", "")))
+	assert.Empty(t, disabled.snapshot())
+}
+
+func TestChannelProducers_Bounded(t *testing.T) {
+	p := newChannelProducers()
+	for i := range maxProducersPerChannel + 1 {
+		p.record("agntcy/otel/channel", "", producerTraces(fmt.Sprintf("service-%d", i), "1.0.0", "", ""))
+	}
+	producers := p.snapshot()["agntcy/otel/channel"]
+	assert.Len(t, producers, maxProducersPerChannel)
+}
+
+func TestSlimReceiver_HandleProducers(t *testing.T) {
+	r := &slimReceiver{producers: newChannelProducers()}
+	r.producers.record("agntcy/otel/channel-traces", "agntcy/otel/exporter",
+		producerTraces("checkout", "1.38.0", "https://opentelemetry.io/schemas/1.37.0", ""))
+
+	rec := httptest.NewRecorder()
+	r.handleProducers(rec, httptest.NewRequest(http.MethodPost, producersPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	r.handleProducers(rec, httptest.NewRequest(http.MethodGet, producersPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var producers map[string][]producerInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &producers))
+	require.Len(t, producers["agntcy/otel/channel-traces"], 1)
+	assert.Equal(t, "checkout", producers["agntcy/otel/channel-traces"][0].ServiceName)
+	assert.Equal(t, "1.38.0", producers["agntcy/otel/channel-traces"][0].SDKVersion)
+
+	rec = httptest.NewRecorder()
+	r.handleProducers(rec, httptest.NewRequest(http.MethodGet, producersPath+"?channel=agntcy/otel/other", nil))
+	assert.JSONEq(t, `{"agntcy/otel/other":[]}`, rec.Body.String())
+}
@@ -0,0 +1,162 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	slim "github.com/agntcy/slim-bindings-go"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// quotas reported by the rejected sessions metric
+const (
+	quotaSessions        = "max-sessions"
+	quotaSessionsPerPeer = "max-sessions-per-peer"
+)
+
+// errSessionQuota is returned by sessionQuota.admit for a session above one
+// of the quotas
+var errSessionQuota = errors.New("session quota exceeded")
+
+// quotaError is the error of a session above the named quota, it wraps
+// errSessionQuota
+type quotaError struct {
+	quota  string
+	reason string
+}
+
+func (e *quotaError) Error() string { return errSessionQuota.Error() + ": " + e.reason }
+
+func (e *quotaError) Unwrap() error { return errSessionQuota }
+
+// sessionQuota limits the number of sessions the receiver accepts, in total
+// and per peer, to protect the collector from session floods. The sessions
+// created by the receiver for its channels are not counted. A nil
+// sessionQuota accepts all the sessions.
+type sessionQuota struct {
+	// limits, 0 means no limit
+	maxSessions int
+	maxPerPeer  int
+
+	mutex sync.Mutex
+	// peers of the accepted sessions, by session name
+	peers map[string][]string
+}
+
+// newSessionQuota creates a quota, or nil if neither limit is set
+func newSessionQuota(maxSessions, maxPerPeer int) *sessionQuota {
+	if maxSessions <= 0 && maxPerPeer <= 0 {
+		return nil
+	}
+	return &sessionQuota{
+		maxSessions: maxSessions,
+		maxPerPeer:  maxPerPeer,
+		peers:       make(map[string][]string),
+	}
+}
+
+// admit checks the session named name with the given peers against the
+// quotas, and counts it if it is accepted. active are the names of the
+// sessions of the receiver: the sessions that ended since are no longer
+// counted, and a session on a channel already active, which the receiver
+// closes as a duplicate, is not counted. The error wraps errSessionQuota.
+func (q *sessionQuota) admit(name string, peers []string, active []string) error {
+	if q == nil {
+		return nil
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for accepted := range q.peers {
+		if !slices.Contains(active, accepted) {
+			delete(q.peers, accepted)
+		}
+	}
+	if slices.Contains(active, name) {
+		return nil
+	}
+
+	if q.maxSessions > 0 && len(q.peers) >= q.maxSessions {
+		return &quotaError{
+			quota:  quotaSessions,
+			reason: fmt.Sprintf("the receiver already accepted %d sessions", len(q.peers)),
+		}
+	}
+	if q.maxPerPeer > 0 {
+		for _, peer := range peers {
+			count := 0
+			for _, accepted := range q.peers {
+				if slices.Contains(accepted, peer) {
+					count++
+				}
+			}
+			if count >= q.maxPerPeer {
+				return &quotaError{
+					quota:  quotaSessionsPerPeer,
+					reason: fmt.Sprintf("%s already has %d sessions with the receiver", peer, count),
+				}
+			}
+		}
+	}
+
+	q.peers[name] = peers
+	return nil
+}
+
+// admitSession checks a new session against the session quotas. A session
+// whose peers cannot be determined is rejected when the per peer quota is
+// set.
+func (r *slimReceiver) admitSession(ctx context.Context, session slimcommon.Session) error {
+	if r.quota == nil {
+		return nil
+	}
+
+	destination, err := session.Destination()
+	if err != nil {
+		return fmt.Errorf("failed to get the session destination: %w", err)
+	}
+	var peers []string
+	if r.quota.maxPerPeer > 0 {
+		if peers, err = r.sessionPeers(session); err != nil {
+			return err
+		}
+	}
+	return r.quota.admit(destination.String(), peers, r.sessions.ListSessionNames(ctx))
+}
+
+// sessionPeers returns the peers of a session counted by the per peer quota:
+// the destination of a point-to-point session, and the participants of a
+// group session other than the receiver
+func (r *slimReceiver) sessionPeers(session slimcommon.Session) ([]string, error) {
+	config, err := session.SessionConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the session config: %w", err)
+	}
+	if config.SessionType == slim.SessionTypePointToPoint {
+		destination, err := session.Destination()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the session destination: %w", err)
+		}
+		return []string{slimcommon.JoinID(destination)}, nil
+	}
+
+	participants, err := session.ParticipantsList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the session participants: %w", err)
+	}
+	peers := make([]string, 0, len(participants))
+	for _, participant := range participants {
+		if peer := slimcommon.JoinID(participant); peer != r.config.ReceiverName && !slices.Contains(peers, peer) {
+			peers = append(peers, peer)
+		}
+	}
+	return peers, nil
+}
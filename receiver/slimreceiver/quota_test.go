@@ -0,0 +1,134 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slim "github.com/agntcy/slim-bindings-go"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// groupSession returns a group session on channel with the given participants
+func groupSession(t *testing.T, id uint32, channel string, participants ...string) *testutil.FakeSession {
+	t.Helper()
+	session := testutil.NewFakeSession(id, channel)
+	session.Config.SessionType = slim.SessionTypeGroup
+	for _, participant := range participants {
+		name, err := slimcommon.SplitID(participant)
+		require.NoError(t, err)
+		require.NoError(t, session.InviteAndWait(name))
+	}
+	return session
+}
+
+func TestSessionQuota_Admit(t *testing.T) {
+	t.Run("no limits", func(t *testing.T) {
+		quota := newSessionQuota(0, 0)
+		assert.Nil(t, quota)
+		require.NoError(t, quota.admit("channel-1", []string{"peer"}, nil))
+	})
+
+	t.Run("max sessions", func(t *testing.T) {
+		quota := newSessionQuota(2, 0)
+		require.NoError(t, quota.admit("channel-1", nil, nil))
+		require.NoError(t, quota.admit("channel-2", nil, []string{"channel-1"}))
+
+		err := quota.admit("channel-3", nil, []string{"channel-1", "channel-2"})
+		require.ErrorIs(t, err, errSessionQuota)
+		var quotaErr *quotaError
+		require.ErrorAs(t, err, &quotaErr)
+		assert.Equal(t, quotaSessions, quotaErr.quota)
+
+		// the sessions that ended are no longer counted
+		require.NoError(t, quota.admit("channel-3", nil, []string{"channel-2"}))
+	})
+
+	t.Run("duplicate sessions are not counted", func(t *testing.T) {
+		quota := newSessionQuota(1, 0)
+		require.NoError(t, quota.admit("channel-1", nil, nil))
+		require.NoError(t, quota.admit("channel-1", nil, []string{"channel-1"}))
+		require.ErrorIs(t, quota.admit("channel-2", nil, []string{"channel-1"}), errSessionQuota)
+	})
+
+	t.Run("max sessions per peer", func(t *testing.T) {
+		quota := newSessionQuota(0, 2)
+		require.NoError(t, quota.admit("channel-1", []string{"peer-a", "peer-b"}, nil))
+		require.NoError(t, quota.admit("channel-2", []string{"peer-a"}, []string{"channel-1"}))
+		require.NoError(t, quota.admit("channel-3", []string{"peer-b"}, []string{"channel-1", "channel-2"}))
+
+		active := []string{"channel-1", "channel-2", "channel-3"}
+		err := quota.admit("channel-4", []string{"peer-c", "peer-a"}, active)
+		var quotaErr *quotaError
+		require.ErrorAs(t, err, &quotaErr)
+		assert.Equal(t, quotaSessionsPerPeer, quotaErr.quota)
+		assert.ErrorContains(t, err, "peer-a already has 2 sessions")
+
+		require.NoError(t, quota.admit("channel-4", []string{"peer-c"}, active))
+	})
+}
+
+func TestSessionPeers(t *testing.T) {
+	r := &slimReceiver{config: &Config{ReceiverName: "agntcy/otel/receiver"}}
+
+	pointToPoint := testutil.NewFakeSession(1, "agntcy/otel/exporter")
+	pointToPoint.Config.SessionType = slim.SessionTypePointToPoint
+	peers, err := r.sessionPeers(pointToPoint)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agntcy/otel/exporter"}, peers)
+
+	group := groupSession(t, 2, "agntcy/otel/channel",
+		"agntcy/otel/channel-manager", "agntcy/otel/exporter", "agntcy/otel/receiver")
+	peers, err = r.sessionPeers(group)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agntcy/otel/channel-manager", "agntcy/otel/exporter"}, peers)
+}
+
+func TestListenForSessions_Quota(t *testing.T) {
+	app := testutil.NewFakeApp()
+	r := &slimReceiver{
+		config:   &Config{ReceiverName: "agntcy/otel/receiver", MaxSessions: 2, MaxSessionsPerPeer: 1},
+		app:      app,
+		sessions: slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		quota:    newSessionQuota(2, 1),
+		draining: make(chan struct{}),
+	}
+
+	accepted := groupSession(t, 1, "agntcy/otel/channel-1", "agntcy/otel/exporter-1", "agntcy/otel/receiver")
+	// a second channel with the same exporter is above its quota
+	samePeer := groupSession(t, 2, "agntcy/otel/channel-2", "agntcy/otel/exporter-1", "agntcy/otel/receiver")
+	otherPeer := groupSession(t, 3, "agntcy/otel/channel-3", "agntcy/otel/exporter-2", "agntcy/otel/receiver")
+	// the receiver accepts two sessions at most
+	aboveTotal := groupSession(t, 4, "agntcy/otel/channel-4", "agntcy/otel/exporter-3", "agntcy/otel/receiver")
+	for _, session := range []*testutil.FakeSession{accepted, samePeer, otherPeer, aboveTotal} {
+		app.Invite(session)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		listenForSessions(ctx, r)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(app.DeletedSessions()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	rejected := app.DeletedSessions()
+	names := r.sessions.ListSessionNames(t.Context())
+	cancel()
+	<-done
+	r.handlers.Wait()
+
+	assert.ElementsMatch(t, []uint32{2, 4}, rejected)
+	assert.ElementsMatch(t, []string{"agntcy/otel/channel-1", "agntcy/otel/channel-3"}, names)
+}
@@ -5,9 +5,12 @@ package slimreceiver
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
+	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/collector/component"
@@ -15,41 +18,66 @@ import (
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/receiver"
 	"go.uber.org/zap"
 
 	slim "github.com/agntcy/slim-bindings-go"
+	"github.com/agntcy/slim-otel/extension/slimconnection"
 	slimcommon "github.com/agntcy/slim-otel/internal/slim"
 	"github.com/agntcy/slim-otel/slimconfig"
 )
 
-const (
-	sessionTimeoutMs = 1000
-)
-
 // slimReceiver implements the receiver for traces, metrics, and logs
 type slimReceiver struct {
 	config          *Config
-	app             *slim.App
+	settings        receiver.Settings
+	app             slimcommon.App
 	connID          uint64
+	connector       slimcommon.Connector
+	failover        *slimcommon.Failover
 	sessions        *slimcommon.SessionsList
 	tracesConsumer  consumer.Traces
 	metricsConsumer consumer.Metrics
 	logsConsumer    consumer.Logs
-	cancelFunc      context.CancelFunc
+	hooks           []ConsumeHook
+	decoders        *decodePool
+	// bounds the messages processed at once across the sessions
+	inFlight *inFlightLimiter
+	// limits the sessions accepted, in total and per peer
+	quota *sessionQuota
+	// limits the warnings about the signals without consumer
+	unconsumedWarnings *warningLimiter
+	decodeFailures     *decodeFailures
+	telemetry          *receiverTelemetry
+	// ingestion of each channel reported at shutdown, nil if disabled
+	report *shutdownReport
+	// producers of each channel, listed by the debug endpoint
+	producers  *channelProducers
+	cancelFunc context.CancelFunc
+	// tracks the session handlers, stopped before the app is parked or
+	// destroyed
+	handlers sync.WaitGroup
+	// set when the app is parked, the handlers leave the sessions open
+	parked      atomic.Bool
+	draining    chan struct{}
+	drainOnce   sync.Once
+	drainServer *http.Server
+	debugServer *http.Server
 }
 
-// createApp creates a new slim application and connects to the SLIM server
-// if not done yet. Returns the app instance and connection ID.
+// createApp creates a new slim application and connects to the first
+// reachable SLIM server. Returns the app instance and connection ID.
 func CreateApp(
 	ctx context.Context,
 	cfg *Config,
-) (*slim.App, uint64, error) {
-	connID, err := slimcommon.InitAndConnect(*cfg.ConnectionConfig)
+	failover *slimcommon.Failover,
+) (slimcommon.App, uint64, error) {
+	connID, err := failover.Connect(ctx)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	app, err := slimcommon.CreateApp(cfg.ReceiverName, cfg.SharedSecret, connID, slim.DirectionRecv)
+	app, err := slimcommon.CreateAppWithIdentity(cfg.ReceiverName, cfg.identity(), connID, appDirection(cfg))
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to create app: %w", err)
 	}
@@ -58,20 +86,74 @@ func CreateApp(
 	return app, connID, nil
 }
 
+// createApp creates the app of the receiver, on the connection of the SLIM
+// connection extension if the config references one, and on the first
+// reachable SLIM server otherwise
+func (r *slimReceiver) createApp(ctx context.Context, host component.Host) error {
+	if r.config.Connection == nil {
+		failover := slimcommon.NewFailover(r.connector, r.config.endpoints())
+		app, connID, err := CreateApp(ctx, r.config, failover)
+		if err != nil {
+			return fmt.Errorf("failed to create/connect app: %w", err)
+		}
+		r.app = app
+		r.connID = connID
+		r.failover = failover
+		return nil
+	}
+
+	conn, err := slimconnection.GetConnection(host, *r.config.Connection)
+	if err != nil {
+		return err
+	}
+	app, err := conn.CreateApp(r.config.ReceiverName, appDirection(r.config))
+	if err != nil {
+		return fmt.Errorf("failed to create app: %w", err)
+	}
+	r.app = app
+	r.connID = conn.ConnID()
+
+	slimcommon.LoggerFromContextOrDefault(ctx).Info("created SLIM app",
+		zap.String("app_name", r.config.ReceiverName),
+		zap.String("connection", r.config.Connection.String()))
+	return nil
+}
+
+// appDirection returns the direction of the app of the receiver
+func appDirection(cfg *Config) slim.Direction {
+	// acknowledgements and leave requests are published back on the sessions
+	if cfg.Acknowledgements || cfg.DrainEndpoint != "" {
+		return slim.DirectionBidirectional
+	}
+	return slim.DirectionRecv
+}
+
 // newSlimReceiver creates a new SLIM receiver instance
 func newSlimReceiver(
 	_ context.Context,
+	set receiver.Settings,
 	cfg *Config,
+	hooks []ConsumeHook,
 ) *slimReceiver {
 
 	slim := &slimReceiver{
-		config:          cfg,
-		app:             nil,
-		connID:          0,
-		sessions:        slimcommon.NewSessionsList(slimconfig.SignalUnknown),
-		tracesConsumer:  nil,
-		metricsConsumer: nil,
-		logsConsumer:    nil,
+		config:             cfg,
+		settings:           set,
+		app:                nil,
+		connID:             0,
+		connector:          slimcommon.NewConnector(),
+		sessions:           slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		tracesConsumer:     nil,
+		metricsConsumer:    nil,
+		logsConsumer:       nil,
+		hooks:              hooks,
+		decoders:           newDecodePool(cfg.DecodeWorkers, cfg.ChannelDecodeBudget),
+		inFlight:           newInFlightLimiter(cfg.MaxInFlightMessages, cfg.MaxInFlightBytes),
+		quota:              newSessionQuota(cfg.MaxSessions, cfg.MaxSessionsPerPeer),
+		unconsumedWarnings: newWarningLimiter(unconsumedWarningInterval),
+		decodeFailures:     newDecodeFailures(decodeFailureReportInterval),
+		producers:          newChannelProducers(),
+		draining:           make(chan struct{}),
 	}
 
 	return slim
@@ -81,47 +163,171 @@ func newSlimReceiver(
 func listenForSessions(ctx context.Context, r *slimReceiver) {
 	logger := slimcommon.LoggerFromContextOrDefault(ctx)
 	logger.Info("Listener started, waiting for incoming sessions...")
-	// WaitGroup to track active sessions
-	var wg sync.WaitGroup
 
-	for {
-		select {
-		case <-ctx.Done():
-			logger.Info("Shutting down listener...")
-			return
+	for session := range slimcommon.ListenSessions(ctx, r.app) {
+		logger.Info("New session received")
 
-		default:
-			timeout := time.Millisecond * sessionTimeoutMs
-			session, err := r.app.ListenForSession(&timeout)
-			if err != nil {
-				// Timeout is expected while waiting for sessions
-				continue
+		// a draining receiver does not join new channels
+		if r.isDraining() {
+			logger.Info("Draining, closing the new session")
+			if err := r.app.DeleteSessionAndWait(session); err != nil {
+				logger.Warn("Failed to close the new session", zap.Error(err))
 			}
+			continue
+		}
 
-			logger.Info("New session received")
+		if err := r.checkSessionSource(session); err != nil {
+			handleRejectedSession(ctx, r, session, err)
+			continue
+		}
 
-			// add session to the list
-			err = r.sessions.AddSession(ctx, session)
-			if err != nil {
-				logger.Error("Failed to add new session", zap.Error(err))
-				continue
-			}
-			// Handle the session in a goroutine
-			wg.Add(1)
-			go handleSession(ctx, &wg, r, session)
+		if err := r.admitSession(ctx, session); err != nil {
+			handleRejectedSession(ctx, r, session, err)
+			continue
+		}
+
+		// add session to the list
+		if err := r.sessions.AddSession(ctx, session); err != nil {
+			handleRejectedSession(ctx, r, session, err)
+			continue
 		}
+		// Handle the session in a goroutine
+		r.handlers.Add(1)
+		go handleSession(ctx, &r.handlers, r, session)
 	}
+	logger.Info("Shutting down listener...")
 }
 
-// detectAndHandleMessage attempts to determine the signal type and handle accordingly
-func detectAndHandleMessage(ctx context.Context, r *slimReceiver, payload []byte) {
+// handleRejectedSession closes a session that could not be added to the
+// sessions list, so that it does not leak. A session on a channel that is
+// already handled is expected when the channel manager re-invites the
+// receiver, e.g. after a reconciliation, and is only counted.
+func handleRejectedSession(ctx context.Context, r *slimReceiver, session slimcommon.Session, err error) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	sessionName := ""
+	if name, nameErr := session.Destination(); nameErr == nil {
+		sessionName = name.String()
+	}
+	var quotaErr *quotaError
+	var sourceErr *sourceError
+	switch {
+	case errors.Is(err, slimcommon.ErrSessionExists):
+		logger.Info("Already joined the channel, closing the duplicate session",
+			zap.String("sessionName", sessionName))
+		r.telemetry.recordDuplicateSession(ctx, sessionName)
+	case errors.As(err, &quotaErr):
+		logger.Warn("Closing a session above the session quotas",
+			zap.String("sessionName", sessionName), zap.Error(err))
+		r.telemetry.recordRejectedSession(ctx, sessionName, quotaErr.quota)
+	case errors.As(err, &sourceErr):
+		logger.Warn("Closing a session from a source that is not allowed",
+			zap.String("sessionName", sessionName), zap.String("source", sourceErr.source))
+		r.telemetry.recordUnauthorizedSession(ctx, sessionName)
+	default:
+		logger.Error("Failed to add new session", zap.Error(err))
+	}
+
+	if err := r.app.DeleteSessionAndWait(session); err != nil {
+		logger.Warn("Failed to close the rejected session", zap.Error(err))
+	}
+}
+
+// detectAndHandleMessage attempts to determine the signal type and handle accordingly.
+// Returns false if the payload could not be decoded for any configured consumer,
+// and the consumer error otherwise.
+func detectAndHandleMessage(ctx context.Context, r *slimReceiver, payload []byte) (bool, error) {
+	data, ok := unmarshalPayload(ctx, r, payload)
+	if !ok {
+		return false, nil
+	}
+
+	format := payloadFormat(payload)
+	switch d := data.(type) {
+	case ptrace.Traces:
+		return true, handleReceivedTraces(ctx, r, format, d)
+	case pmetric.Metrics:
+		return true, handleReceivedMetrics(ctx, r, format, d)
+	case plog.Logs:
+		return true, handleReceivedLogs(ctx, r, format, d)
+	}
+	return true, nil
+}
+
+// payloadFormat returns the encoding of the payload, reported as the format
+// of the received data by the standard receiver metrics
+func payloadFormat(payload []byte) string {
+	if slimcommon.IsJSONPayload(payload) {
+		return slimcommon.EncodingJSON
+	}
+	return slimcommon.EncodingProto
+}
+
+// unmarshalPayload decodes the payload, in the decode pool if configured, as
+// the first signal type, among the ones with a configured consumer, that
+// accepts it. The returned value is a ptrace.Traces, a pmetric.Metrics or a
+// plog.Logs, or nil for a payload without any item, which is not consumed.
+func unmarshalPayload(ctx context.Context, r *slimReceiver, payload []byte) (any, bool) {
+	msgInfo, _ := ctx.Value(messageInfoKey{}).(messageInfo)
+
+	var data any
+	decoded := false
+	throttled, err := r.decoders.run(ctx, msgInfo.channel, func() {
+		data, decoded = decodePayload(r, payload)
+	})
+	if throttled {
+		r.telemetry.recordDecodeThrottled(ctx, msgInfo.channel)
+	}
+	if err != nil {
+		slimcommon.LoggerFromContextOrDefault(ctx).Debug("Payload not decoded", zap.Error(err))
+		return nil, false
+	}
+	if !decoded {
+		r.reportDecodeFailure(ctx, payload)
+		return nil, false
+	}
+	if payloadItems(data) == 0 {
+		// an empty payload decodes as any signal, there is nothing to consume
+		transport, _ := ctx.Value(transportKey{}).(slimTransport)
+		r.telemetry.recordEmptyPayload(ctx, transport.channel)
+		slimcommon.LoggerFromContextOrDefault(ctx).Debug("Skipping empty payload",
+			zap.String("channel", transport.channel),
+			zap.String("source", transport.source))
+		return nil, true
+	}
+	r.recordProducers(ctx, data)
+	r.addResourceAttributes(ctx, data)
+	return data, true
+}
+
+// payloadItems returns the number of spans, data points or log records of
+// the decoded data, zero for nil
+func payloadItems(data any) int {
+	switch d := data.(type) {
+	case ptrace.Traces:
+		return d.SpanCount()
+	case pmetric.Metrics:
+		return d.DataPointCount()
+	case plog.Logs:
+		return d.LogRecordCount()
+	}
+	return 0
+}
+
+// decodePayload decodes the payload as the first signal type, among the ones
+// with a configured consumer, that accepts it. An empty JSON object is
+// decoded as nil.
+func decodePayload(r *slimReceiver, payload []byte) (any, bool) {
+	if slimcommon.IsJSONPayload(payload) {
+		return decodeJSONPayload(r, payload)
+	}
+
 	// Try traces first if consumer is available
 	if r.tracesConsumer != nil {
 		unmarshaler := &ptrace.ProtoUnmarshaler{}
 		traces, err := unmarshaler.UnmarshalTraces(payload)
 		if err == nil {
-			handleReceivedTraces(ctx, r, traces)
-			return
+			return traces, true
 		}
 	}
 
@@ -130,8 +336,7 @@ func detectAndHandleMessage(ctx context.Context, r *slimReceiver, payload []byte
 		unmarshaler := &pmetric.ProtoUnmarshaler{}
 		metrics, err := unmarshaler.UnmarshalMetrics(payload)
 		if err == nil {
-			handleReceivedMetrics(ctx, r, metrics)
-			return
+			return metrics, true
 		}
 	}
 
@@ -140,40 +345,93 @@ func detectAndHandleMessage(ctx context.Context, r *slimReceiver, payload []byte
 		unmarshaler := &plog.ProtoUnmarshaler{}
 		logs, err := unmarshaler.UnmarshalLogs(payload)
 		if err == nil {
-			handleReceivedLogs(ctx, r, logs)
-			return
+			return logs, true
 		}
 	}
 
-	slimcommon.LoggerFromContextOrDefault(ctx).Warn("Unable to determine signal type for message",
-		zap.Int("payloadSize", len(payload)))
+	return nil, false
+}
+
+// decodeJSONPayload decodes an OTLP/JSON payload as the signal named by its
+// top-level field, if that signal has a configured consumer
+func decodeJSONPayload(r *slimReceiver, payload []byte) (any, bool) {
+	signal, ok := slimcommon.JSONPayloadSignal(payload)
+	if !ok {
+		// an empty request has no field telling its signal
+		return nil, isEmptyJSONObject(payload)
+	}
+
+	var (
+		data any
+		err  error
+	)
+	switch {
+	case signal == slimconfig.SignalTraces && r.tracesConsumer != nil:
+		data, err = (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(payload)
+	case signal == slimconfig.SignalMetrics && r.metricsConsumer != nil:
+		data, err = (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics(payload)
+	case signal == slimconfig.SignalLogs && r.logsConsumer != nil:
+		data, err = (&plog.JSONUnmarshaler{}).UnmarshalLogs(payload)
+	default:
+		return nil, false
+	}
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// isEmptyJSONObject reports whether the payload is a JSON object without
+// any field
+func isEmptyJSONObject(payload []byte) bool {
+	var fields map[string]json.RawMessage
+	return json.Unmarshal(payload, &fields) == nil && fields != nil && len(fields) == 0
 }
 
-// handleReceivedTraces processes a received trace message
-func handleReceivedTraces(ctx context.Context, r *slimReceiver, traces ptrace.Traces) {
-	if err := r.tracesConsumer.ConsumeTraces(ctx, traces); err != nil {
+// handleReceivedTraces processes a received trace message, decoded from format
+func handleReceivedTraces(ctx context.Context, r *slimReceiver, format string, traces ptrace.Traces) error {
+	ctx = r.telemetry.startTracesOp(ctx)
+	err := r.tracesConsumer.ConsumeTraces(ctx, traces)
+	r.telemetry.endTracesOp(ctx, format, traces.SpanCount(), err)
+	if err != nil {
 		logger := slimcommon.LoggerFromContextOrDefault(ctx)
 		logger.Error("Failed to consume traces",
 			zap.Error(err))
+		return err
 	}
+	r.notifyConsumed(ctx, slimconfig.SignalTraces, traces.SpanCount())
+	return nil
 }
 
-// handleReceivedMetrics processes a received metrics message
-func handleReceivedMetrics(ctx context.Context, r *slimReceiver, metrics pmetric.Metrics) {
-	if err := r.metricsConsumer.ConsumeMetrics(ctx, metrics); err != nil {
+// handleReceivedMetrics processes a received metrics message, decoded from format
+func handleReceivedMetrics(ctx context.Context, r *slimReceiver, format string, metrics pmetric.Metrics) error {
+	ctx = r.telemetry.startMetricsOp(ctx)
+	err := r.metricsConsumer.ConsumeMetrics(ctx, metrics)
+	r.telemetry.endMetricsOp(ctx, format, metrics.DataPointCount(), err)
+	if err != nil {
 		logger := slimcommon.LoggerFromContextOrDefault(ctx)
 		logger.Error("Failed to consume metrics",
 			zap.Error(err))
+		return err
 	}
+	r.notifyConsumed(ctx, slimconfig.SignalMetrics, metrics.DataPointCount())
+	return nil
 }
 
-// handleReceivedLogs processes a received logs message
-func handleReceivedLogs(ctx context.Context, r *slimReceiver, logs plog.Logs) {
-	if err := r.logsConsumer.ConsumeLogs(ctx, logs); err != nil {
+// handleReceivedLogs processes a received logs message, decoded from format
+func handleReceivedLogs(ctx context.Context, r *slimReceiver, format string, logs plog.Logs) error {
+	applyDefaultLogSeverity(ctx, logs)
+	ctx = r.telemetry.startLogsOp(ctx)
+	err := r.logsConsumer.ConsumeLogs(ctx, logs)
+	r.telemetry.endLogsOp(ctx, format, logs.LogRecordCount(), err)
+	if err != nil {
 		logger := slimcommon.LoggerFromContextOrDefault(ctx)
 		logger.Error("Failed to consume logs",
 			zap.Error(err))
+		return err
 	}
+	r.notifyConsumed(ctx, slimconfig.SignalLogs, logs.LogRecordCount())
+	return nil
 }
 
 // handleSession processes messages from a single session
@@ -181,7 +439,7 @@ func handleSession(
 	ctx context.Context,
 	wg *sync.WaitGroup,
 	r *slimReceiver,
-	session *slim.Session,
+	session slimcommon.Session,
 ) {
 	defer wg.Done()
 	logger := slimcommon.LoggerFromContextOrDefault(ctx)
@@ -202,62 +460,285 @@ func handleSession(
 
 	logger = logger.With(zap.Uint32("sessionID", id), zap.String("sessionName", sessionName))
 	ctx = slimcommon.InitContextWithLogger(ctx, logger)
+	// the merged payloads are reported to the consume hooks without metadata
+	ctx = withMessageInfo(ctx, sessionName, nil)
 
 	logger.Info("Handling new session")
 	defer func() {
+		// the sessions of a parked app are handed over to the restarted receiver
+		if r.parked.Load() {
+			logger.Info("Session kept for the restarted receiver")
+			return
+		}
 		// the session may be already removed from sessions.DeleteAll in Shutdown
 		_, _ = r.sessions.RemoveSessionByID(ctx, id)
 		_ = r.app.DeleteSessionAndWait(session)
+		r.decoders.forget(sessionName)
+		r.unconsumedWarnings.forget(sessionName + "\x00")
+		r.decodeFailures.forget(sessionName)
+		r.producers.forget(sessionName)
 		logger.Info("Session closed")
 	}()
 
+	// validate the messages against the channel policy, if any
+	policy, err := slimcommon.SessionPolicy(session)
+	if err != nil {
+		logger.Warn("Ignoring the channel policy", zap.Error(err))
+	}
+	validator := newPolicyValidator(policy)
+
+	// the channel default log severity takes precedence over the receiver one
+	defaultLogSeverity := policy.DefaultLogSeverity
+	if defaultLogSeverity == "" {
+		defaultLogSeverity = r.config.DefaultLogSeverity
+	}
+	ctx = withDefaultLogSeverity(ctx, defaultLogSeverity)
+
 	messageCount := 0
 
+	// merge small payloads if enabled, flushing what is left when the session ends
+	merger := newMessageMerger(r)
+	defer merger.flush(context.WithoutCancel(ctx))
+
+	// closed when the receiver is drained, set to nil once handled
+	drainCh := r.draining
+
+	// the messages are delivered until the session is closed or the
+	// handler returns
+	messagesCtx, stopMessages := context.WithCancel(ctx)
+	defer stopMessages()
+	messages := slimcommon.ReceiveMessages(messagesCtx, session)
+	if r.config.CatchUp.Order == CatchUpNewestFirst {
+		messages = r.config.CatchUp.newestFirst(messagesCtx, messages)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			logger.Info("Shutting down session",
 				zap.Int("totalMessages", messageCount))
 			return
-		default:
-			// Wait for message with timeout
-			timeout := time.Millisecond * 1000 // 1 sec
-			msg, err := session.GetMessage(&timeout)
-			if err != nil {
-				errMsg := err.Error()
-				switch {
-				case strings.Contains(errMsg, "session closed"):
-					return
-				case strings.Contains(errMsg, "receive timeout waiting for message"):
-					// Normal timeout, continue
+		case <-merger.flushTimer():
+			merger.flushIfDue(ctx)
+		case <-drainCh:
+			drainCh = nil
+			logger.Info("Draining session")
+			merger.flush(ctx)
+			// the channels created by the receiver and the point-to-point
+			// sessions are closed, the channel manager removes the receiver
+			// from the other ones, which closes the session
+			if r.closesOnDrain(session, sessionName) {
+				notifyClosing(ctx, r, session)
+				return
+			}
+			requestLeave(ctx, session)
+		case msg, ok := <-messages:
+			if !ok {
+				// the session is closed, or ctx is done
+				return
+			}
+
+			// acknowledgements and freeze notifications are addressed to
+			// the exporters and leave requests to the channel manager
+			if msg.Context.PayloadType == slimcommon.PayloadTypeAck ||
+				msg.Context.PayloadType == slimcommon.PayloadTypeFreeze ||
+				msg.Context.PayloadType == slimcommon.PayloadTypeLeave {
+				continue
+			}
+
+			// flush the merged payloads before the channel manager removes
+			// the receiver from the channel
+			if msg.Context.PayloadType == slimcommon.PayloadTypeDrain {
+				if msg.Context.Metadata[slimcommon.MetadataDrainParticipant] == r.config.ReceiverName {
+					logger.Info("Draining session")
+					merger.flush(ctx)
+				}
+				continue
+			}
+
+			// the sender of every message is checked, any participant of
+			// a group session can publish on it
+			if !r.messageSourceAllowed(msg) {
+				r.telemetry.recordUnauthorizedMessage(ctx, sessionName)
+				sender := ""
+				if msg.Context.SourceName != nil {
+					sender = slimcommon.JoinID(msg.Context.SourceName)
+				}
+				logger.Warn("Dropping message from a source that is not allowed", zap.String("source", sender))
+				continue
+			}
+
+			messageCount++
+			r.sessions.Touch(id)
+			r.telemetry.recordMessage(ctx, sessionName, len(msg.Payload))
+			if sentAt, ok := slimcommon.SentAt(msg.Context.Metadata); ok {
+				r.telemetry.recordDeliveryLatency(ctx, sessionName, time.Since(sentAt))
+			}
+
+			// oversized messages are dropped, rate violations are only
+			// reported, the rate being checked for each exporter
+			sender := ""
+			if msg.Context.SourceName != nil {
+				sender = msg.Context.SourceName.String()
+			}
+			if violation := validator.check(len(msg.Payload), sender, time.Now()); violation != "" {
+				r.telemetry.recordPolicyViolation(ctx, sessionName, violation)
+				if violation == violationMessageSize {
+					logger.Warn("Dropping message larger than the channel max message size",
+						zap.Int("size", len(msg.Payload)),
+						zap.Int("max_message_size", policy.MaxMessageSize))
 					continue
-				default:
-					logger.Error("Error getting message",
-						zap.Error(err))
+				}
+				logger.Debug("Message rate above the channel max message rate",
+					zap.Float64("max_message_rate", policy.MaxMessageRate))
+			}
+
+			// the exporters tell the signal of the payloads, which would
+			// otherwise fail to decode or be decoded as another signal
+			signal, hasSignal := slimcommon.MessageSignal(msg.Context.Metadata)
+			if !hasSignal && msg.Context.SourceName != nil {
+				// the exporters that do not tell it may be named for it
+				signal, hasSignal = slimcommon.ExtractSignalType(
+					slimcommon.JoinID(msg.Context.SourceName), r.config.SignalNameTemplate)
+			}
+			if hasSignal && !policy.CarriesSignal(signal) {
+				r.telemetry.recordPolicyViolation(ctx, sessionName, violationSignals)
+				logger.Warn("Dropping message of a signal the channel does not carry",
+					zap.String("signal", string(signal)))
+				continue
+			}
+			if hasSignal && !r.consumes(signal) {
+				r.dropUnconsumed(ctx, sessionName, signal)
+				continue
+			}
+
+			// the payloads wrapped by the exporter are unwrapped, the ones
+			// whose envelope cannot be read are dropped
+			producer := ""
+			if slimcommon.HasEnvelope(msg.Context.Metadata) {
+				env, ok := r.openEnvelope(ctx, sessionName, msg.Payload)
+				if !ok {
+					r.report.record(sessionName, len(msg.Payload), true)
 					continue
 				}
+				msg.Payload, producer = env.Payload, env.Producer
 			}
 
-			messageCount++
+			// the transport is added to the resources when the payload is
+			// decoded, before the payloads of several senders are merged
+			source, identity := "", ""
+			if msg.Context.SourceName != nil {
+				source = msg.Context.SourceName.String()
+				identity = slimcommon.JoinID(msg.Context.SourceName)
+			}
+			component, _ := slimcommon.MessageComponent(msg.Context.Metadata)
+			payloadCtx := withTransport(ctx, slimTransport{
+				channel: sessionName, sessionID: id, source: source, identity: identity, component: component,
+				producer: producer,
+			})
+
+			// wait for the messages in flight across the sessions to fall
+			// below the limits, the next messages wait in SLIM meanwhile
+			if !r.inFlight.tryAcquire(len(msg.Payload)) {
+				r.telemetry.recordInFlightWait(ctx, sessionName)
+				// the merged payloads must not hold the capacity the
+				// session waits for
+				merger.flush(ctx)
+				if err := r.inFlight.acquire(ctx, len(msg.Payload)); err != nil {
+					logger.Info("Shutting down session",
+						zap.Int("totalMessages", messageCount))
+					return
+				}
+			}
+
+			// the backlog flushed after an outage may be too old to be worth
+			// consuming, the exporter tells how long the data is relevant
+			if now := time.Now(); slimcommon.Expired(msg.Context.Metadata, now) ||
+				r.config.CatchUp.stale(msg.Context.Metadata, now) {
+				r.inFlight.release(1, len(msg.Payload))
+				r.telemetry.recordExpiredMessage(ctx, sessionName)
+				logger.Debug("Dropping message older than its time to live")
+				continue
+			}
 
-			// Detect signal type and handle message
-			detectAndHandleMessage(ctx, r, msg.Payload)
+			// Detect signal type and handle or merge the message, the merged
+			// payloads stay in flight until they are flushed
+			var handled bool
+			var consumeErr error
+			if merger != nil {
+				handled = merger.addPayload(payloadCtx, msg.Payload)
+				if !handled {
+					r.inFlight.release(1, len(msg.Payload))
+				}
+				merger.flushIfDue(ctx)
+			} else {
+				msgCtx := withMessageInfo(payloadCtx, sessionName, msg.Context.Metadata)
+				handled, consumeErr = detectAndHandleMessage(msgCtx, r, msg.Payload)
+				r.inFlight.release(1, len(msg.Payload))
+				if handled && consumeErr == nil {
+					acknowledge(ctx, r, session, msg)
+				}
+			}
+			if !handled {
+				r.telemetry.recordUnmarshalFailure(ctx, sessionName)
+			}
+			r.report.record(sessionName, len(msg.Payload), !handled || consumeErr != nil)
 		}
 	}
 }
 
 // Start implements the component.Component interface
-func (r *slimReceiver) Start(ctx context.Context, _ component.Host) error {
+func (r *slimReceiver) Start(ctx context.Context, host component.Host) error {
 	logger := slimcommon.LoggerFromContextOrDefault(ctx)
 	logger.Info("Starting Slim receiver")
 
-	app, connID, err := CreateApp(ctx, r.config)
+	telemetry, err := newReceiverTelemetry(r.settings, r.sessions)
 	if err != nil {
-		return fmt.Errorf("failed to create/connect app: %w", err)
+		return fmt.Errorf("failed to create receiver telemetry: %w", err)
 	}
 
-	r.app = app
-	r.connID = connID
+	// take over the app of the receiver before a restart of the pipelines,
+	// with the invitations it accepted in the meantime
+	var adopted []slimcommon.Session
+	if parked := takeParkedApp(ctx, r.config); parked != nil {
+		adopted = r.adopt(ctx, parked)
+	} else if err := r.createApp(ctx, host); err != nil {
+		_ = telemetry.shutdown()
+		return err
+	}
+
+	r.telemetry = telemetry
+	r.report = newShutdownReport(r.config.ShutdownReport)
+	app, failover := r.app, r.failover
+
+	if err := r.startDrainServer(ctx); err != nil {
+		r.sessions.DeleteAll(ctx, app)
+		app.Destroy()
+		_ = telemetry.shutdown()
+		r.app, r.telemetry = nil, nil
+		return err
+	}
+	if err := r.startDebugServer(ctx); err != nil {
+		r.sessions.DeleteAll(ctx, app)
+		r.stopDrainServer(ctx)
+		r.stopDebugServer(ctx)
+		app.Destroy()
+		_ = telemetry.shutdown()
+		r.app, r.telemetry = nil, nil
+		return err
+	}
+
+	// create the channels owned by the receiver, if any
+	created, err := createSessionsAndInvite(ctx, r)
+	if err != nil {
+		r.sessions.DeleteAll(ctx, app)
+		r.stopDrainServer(ctx)
+		r.stopDebugServer(ctx)
+		app.Destroy()
+		_ = telemetry.shutdown()
+		r.app, r.telemetry = nil, nil
+		return fmt.Errorf("failed to create the channels: %w", err)
+	}
 
 	// Create a background context for the listener goroutine
 	// The context passed to start() is short-lived and will be canceled after startup
@@ -265,6 +746,30 @@ func (r *slimReceiver) Start(ctx context.Context, _ component.Host) error {
 	// Copy logger from the original context to the new background context
 	listenerCtx = slimcommon.InitContextWithLogger(listenerCtx, logger)
 	r.cancelFunc = cancel
+	r.decoders.start(listenerCtx)
+
+	// handle the sessions taken over and the created channels like the ones
+	// the receiver is invited to
+	for _, session := range append(adopted, created...) {
+		r.handlers.Add(1)
+		go handleSession(listenerCtx, &r.handlers, r, session)
+	}
+
+	// migrate the subscription to a backup server if the connection is lost,
+	// the connection of an extension is not failed over
+	if failover != nil {
+		name, _ := slimcommon.SplitID(r.config.ReceiverName)
+		failover.Attach(app, name, func(connID uint64) error {
+			return r.restoreRoutes(listenerCtx, connID)
+		})
+		go failover.Run(listenerCtx, r.config.healthCheckInterval())
+	}
+
+	// close the sessions the exporters stopped sending on
+	if r.config.IdleTimeout > 0 {
+		go r.sessions.RunIdleReaper(listenerCtx, app, r.config.IdleTimeout, r.ownsChannel,
+			func(name string) { r.telemetry.recordIdleSession(listenerCtx, name) })
+	}
 
 	// start to listen for incoming sessions
 	logger.Info("Start to listen for new sessions")
@@ -273,21 +778,69 @@ func (r *slimReceiver) Start(ctx context.Context, _ component.Host) error {
 	return nil
 }
 
+// waitHandlers waits for the session handlers to return, and returns false
+// if ctx is done first
+func (r *slimReceiver) waitHandlers(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		r.handlers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // Shutdown implements the component.Component interface
 func (r *slimReceiver) Shutdown(ctx context.Context) error {
 	logger := slimcommon.LoggerFromContextOrDefault(ctx)
 	logger.Info("Shutting down Slim receiver")
 
+	// the handlers leave the sessions open if the app is kept for the
+	// restarted receiver
+	r.parked.Store(r.parkable())
+
 	// stop the receiver listener by canceling the background context
 	if r.cancelFunc != nil {
 		r.cancelFunc()
 	}
+	r.stopDrainServer(ctx)
+	r.stopDebugServer(ctx)
 
-	// remove all sessions
-	r.sessions.DeleteAll(ctx, r.app)
+	// nothing else to release if the receiver failed to start
+	if r.app == nil {
+		return nil
+	}
+
+	// the handlers may still be decoding or consuming a message, they stop
+	// at the next message since the background context is canceled
+	handlersDone := r.waitHandlers(ctx)
+	if r.parked.Load() {
+		if !handlersDone {
+			logger.Warn("Parking the SLIM app with messages still being handled", zap.Error(ctx.Err()))
+		}
+		r.park(ctx)
+	} else {
+		if !handlersDone {
+			logger.Warn("Destroying the SLIM app with messages still being handled", zap.Error(ctx.Err()))
+		}
+
+		// remove all sessions
+		r.sessions.DeleteAll(ctx, r.app)
 
-	// destroy the app
-	r.app.Destroy()
+		// destroy the app
+		r.app.Destroy()
+	}
+
+	// the handlers are stopped, unless the shutdown context is done
+	r.emitShutdownReport(ctx)
+
+	if err := r.telemetry.shutdown(); err != nil {
+		logger.Warn("Failed to unregister receiver telemetry", zap.Error(err))
+	}
 
 	return nil
 }
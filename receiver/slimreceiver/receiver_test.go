@@ -4,17 +4,29 @@
 package slimreceiver
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/consumertest"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 
+	slim "github.com/agntcy/slim-bindings-go"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
 	"github.com/agntcy/slim-otel/slimconfig"
 )
 
@@ -47,7 +59,7 @@ func TestHandleReceivedTraces(t *testing.T) {
 
 	// Handle the traces
 	ctx := t.Context()
-	handleReceivedTraces(ctx, r, traces)
+	_ = handleReceivedTraces(ctx, r, slimcommon.EncodingProto, traces)
 
 	// Verify the consumer received the traces
 	assert.Equal(t, 1, len(sink.AllTraces()))
@@ -85,7 +97,7 @@ func TestHandleReceivedMetrics(t *testing.T) {
 
 	// Handle the metrics
 	ctx := t.Context()
-	handleReceivedMetrics(ctx, r, metrics)
+	_ = handleReceivedMetrics(ctx, r, slimcommon.EncodingProto, metrics)
 
 	// Verify the consumer received the metrics
 	assert.Equal(t, 1, len(sink.AllMetrics()))
@@ -121,7 +133,7 @@ func TestHandleReceivedLogs(t *testing.T) {
 
 	// Handle the logs
 	ctx := t.Context()
-	handleReceivedLogs(ctx, r, logs)
+	_ = handleReceivedLogs(ctx, r, slimcommon.EncodingProto, logs)
 
 	// Verify the consumer received the logs
 	assert.Equal(t, 1, len(sink.AllLogs()))
@@ -161,7 +173,7 @@ func TestDetectAndHandleMessage_Traces(t *testing.T) {
 
 	// Detect and handle the message
 	ctx := t.Context()
-	detectAndHandleMessage(ctx, r, payload)
+	_, _ = detectAndHandleMessage(ctx, r, payload)
 
 	// Verify the consumer received the traces
 	assert.Equal(t, 1, len(sink.AllTraces()))
@@ -202,7 +214,7 @@ func TestDetectAndHandleMessage_Metrics(t *testing.T) {
 
 	// Detect and handle the message
 	ctx := t.Context()
-	detectAndHandleMessage(ctx, r, payload)
+	_, _ = detectAndHandleMessage(ctx, r, payload)
 
 	// Verify the consumer received the metrics
 	assert.Equal(t, 1, len(sink.AllMetrics()))
@@ -241,7 +253,7 @@ func TestDetectAndHandleMessage_Logs(t *testing.T) {
 
 	// Detect and handle the message
 	ctx := t.Context()
-	detectAndHandleMessage(ctx, r, payload)
+	_, _ = detectAndHandleMessage(ctx, r, payload)
 
 	// Verify the consumer received the logs
 	assert.Equal(t, 1, len(sink.AllLogs()))
@@ -274,7 +286,7 @@ func TestDetectAndHandleMessage_InvalidPayload(t *testing.T) {
 
 	// Detect and handle the message - should not panic
 	ctx := t.Context()
-	detectAndHandleMessage(ctx, r, invalidPayload)
+	_, _ = detectAndHandleMessage(ctx, r, invalidPayload)
 
 	// Verify no consumers received data
 	assert.Equal(t, 0, len(tracesSink.AllTraces()))
@@ -312,7 +324,7 @@ func TestDetectAndHandleMessage_NoConsumers(t *testing.T) {
 
 	// Detect and handle the message - should not panic even with no consumers
 	ctx := t.Context()
-	detectAndHandleMessage(ctx, r, payload)
+	_, _ = detectAndHandleMessage(ctx, r, payload)
 	// Should complete without error
 }
 
@@ -348,7 +360,7 @@ func TestReceiverMultipleSignalTypes(t *testing.T) {
 	span.SetName("test-span")
 	tracesMarshaler := &ptrace.ProtoMarshaler{}
 	tracesPayload, _ := tracesMarshaler.MarshalTraces(traces)
-	detectAndHandleMessage(ctx, r, tracesPayload)
+	_, _ = detectAndHandleMessage(ctx, r, tracesPayload)
 
 	// Send metrics
 	metrics := pmetric.NewMetrics()
@@ -358,7 +370,7 @@ func TestReceiverMultipleSignalTypes(t *testing.T) {
 	metric.SetName("test-metric")
 	metricsMarshaler := &pmetric.ProtoMarshaler{}
 	metricsPayload, _ := metricsMarshaler.MarshalMetrics(metrics)
-	detectAndHandleMessage(ctx, r, metricsPayload)
+	_, _ = detectAndHandleMessage(ctx, r, metricsPayload)
 
 	// Send logs
 	logs := plog.NewLogs()
@@ -368,10 +380,493 @@ func TestReceiverMultipleSignalTypes(t *testing.T) {
 	logRecord.Body().SetStr("test log")
 	logsMarshaler := &plog.ProtoMarshaler{}
 	logsPayload, _ := logsMarshaler.MarshalLogs(logs)
-	detectAndHandleMessage(ctx, r, logsPayload)
+	_, _ = detectAndHandleMessage(ctx, r, logsPayload)
 
 	// Verify all consumers received their respective data
 	assert.Equal(t, 1, len(tracesSink.AllTraces()))
 	assert.Equal(t, 1, len(metricsSink.AllMetrics()))
 	assert.Equal(t, 1, len(logsSink.AllLogs()))
 }
+
+func TestDetectAndHandleMessage_JSON(t *testing.T) {
+	tracesSink := &consumertest.TracesSink{}
+	logsSink := &consumertest.LogsSink{}
+	r := &slimReceiver{
+		config:         &Config{ReceiverName: "agntcy/otel/test"},
+		tracesConsumer: tracesSink,
+		logsConsumer:   logsSink,
+	}
+	ctx := t.Context()
+
+	traces := ptrace.NewTraces()
+	traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("test-span")
+	tracesPayload, err := (&ptrace.JSONMarshaler{}).MarshalTraces(traces)
+	require.NoError(t, err)
+	_, _ = detectAndHandleMessage(ctx, r, tracesPayload)
+
+	// the traces unmarshaler would accept the logs payload, skipping its fields
+	logs := plog.NewLogs()
+	logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("test log")
+	logsPayload, err := (&plog.JSONMarshaler{}).MarshalLogs(logs)
+	require.NoError(t, err)
+	_, _ = detectAndHandleMessage(ctx, r, logsPayload)
+
+	require.Len(t, tracesSink.AllTraces(), 1)
+	assert.Equal(t, "test-span",
+		tracesSink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name())
+	require.Len(t, logsSink.AllLogs(), 1)
+	assert.Equal(t, "test log",
+		logsSink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+
+	// a JSON payload of a signal without consumer is not decoded
+	metrics := pmetric.NewMetrics()
+	metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetName("test-metric")
+	metricsPayload, err := (&pmetric.JSONMarshaler{}).MarshalMetrics(metrics)
+	require.NoError(t, err)
+	_, ok := decodePayload(r, metricsPayload)
+	assert.False(t, ok)
+}
+
+// TestPayloadFormat tests that the format reported by the receiver metrics
+// is the encoding of the payload
+func TestPayloadFormat(t *testing.T) {
+	traces := ptrace.NewTraces()
+	traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("test-span")
+	protoPayload, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(traces)
+	require.NoError(t, err)
+	jsonPayload, err := (&ptrace.JSONMarshaler{}).MarshalTraces(traces)
+	require.NoError(t, err)
+
+	assert.Equal(t, "otlp_proto", payloadFormat(protoPayload))
+	assert.Equal(t, "otlp_json", payloadFormat(jsonPayload))
+}
+
+// TestDetectAndHandleMessage_Empty tests that the payloads without any item
+// are handled without being consumed nor reported as decode failures
+func TestDetectAndHandleMessage_Empty(t *testing.T) {
+	tracesSink := &consumertest.TracesSink{}
+	r := &slimReceiver{
+		config:         &Config{ReceiverName: "agntcy/otel/test"},
+		tracesConsumer: tracesSink,
+	}
+	ctx := withTransport(t.Context(), slimTransport{channel: "agntcy/otel/channel"})
+
+	traces := ptrace.NewTraces()
+	traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty()
+	protoPayload, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(traces)
+	require.NoError(t, err)
+	jsonPayload, err := (&ptrace.JSONMarshaler{}).MarshalTraces(ptrace.NewTraces())
+	require.NoError(t, err)
+
+	for _, payload := range [][]byte{nil, protoPayload, jsonPayload, []byte(" {} ")} {
+		handled, err := detectAndHandleMessage(ctx, r, payload)
+		require.NoError(t, err)
+		assert.True(t, handled, "payload %q", payload)
+	}
+	assert.Empty(t, tracesSink.AllTraces())
+
+	handled, _ := detectAndHandleMessage(ctx, r, []byte(`{"unknown": 1}`))
+	assert.False(t, handled, "a JSON object with unknown fields is not an empty request")
+}
+
+// TestSlimReceiver_Telemetry tests the receiver self-telemetry
+func TestSlimReceiver_Telemetry(t *testing.T) {
+	newReceiver := func(t *testing.T) (*slimReceiver, *componenttest.Telemetry) {
+		tt := componenttest.NewTelemetry()
+		t.Cleanup(func() { require.NoError(t, tt.Shutdown(context.Background())) })
+
+		sessions := slimcommon.NewSessionsList(slimconfig.SignalUnknown)
+		telemetry, err := newReceiverTelemetry(receiver.Settings{
+			ID:                component.MustNewID(TypeStr),
+			TelemetrySettings: tt.NewTelemetrySettings(),
+		}, sessions)
+		require.NoError(t, err)
+
+		return &slimReceiver{
+			sessions:  sessions,
+			telemetry: telemetry,
+		}, tt
+	}
+
+	sumValue := func(t *testing.T, tt *componenttest.Telemetry, name string) int64 {
+		m, err := tt.GetMetric(name)
+		require.NoError(t, err)
+		sum, ok := m.Data.(metricdata.Sum[int64])
+		require.True(t, ok)
+		var total int64
+		for _, dp := range sum.DataPoints {
+			total += dp.Value
+		}
+		return total
+	}
+
+	t.Run("empty payloads", func(t *testing.T) {
+		r, tt := newReceiver(t)
+		r.config = &Config{}
+		r.tracesConsumer = &consumertest.TracesSink{}
+		ctx := withTransport(t.Context(), slimTransport{channel: "agntcy/otel/channel"})
+
+		handled, err := detectAndHandleMessage(ctx, r, nil)
+		require.NoError(t, err)
+		assert.True(t, handled)
+		assert.Equal(t, int64(1), sumValue(t, tt, metricEmptyPayloads))
+	})
+
+	t.Run("accepted and refused spans", func(t *testing.T) {
+		r, tt := newReceiver(t)
+		traces := ptrace.NewTraces()
+		spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+		spans.AppendEmpty().SetName("span-1")
+		spans.AppendEmpty().SetName("span-2")
+
+		r.tracesConsumer = &consumertest.TracesSink{}
+		_ = handleReceivedTraces(t.Context(), r, slimcommon.EncodingProto, traces)
+		assert.Equal(t, int64(2), sumValue(t, tt, "otelcol_receiver_accepted_spans"))
+
+		r.tracesConsumer = consumertest.NewErr(errors.New("boom"))
+		_ = handleReceivedTraces(t.Context(), r, slimcommon.EncodingProto, traces)
+		assert.Equal(t, int64(2), sumValue(t, tt, "otelcol_receiver_refused_spans"))
+	})
+
+	t.Run("accepted metric points and log records", func(t *testing.T) {
+		r, tt := newReceiver(t)
+		r.metricsConsumer = &consumertest.MetricsSink{}
+		r.logsConsumer = &consumertest.LogsSink{}
+
+		metrics := pmetric.NewMetrics()
+		m := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+		m.SetName("test-metric")
+		m.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1)
+		_ = handleReceivedMetrics(t.Context(), r, slimcommon.EncodingProto, metrics)
+
+		logs := plog.NewLogs()
+		logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("log")
+		_ = handleReceivedLogs(t.Context(), r, slimcommon.EncodingProto, logs)
+
+		assert.Equal(t, int64(1), sumValue(t, tt, "otelcol_receiver_accepted_metric_points"))
+		assert.Equal(t, int64(1), sumValue(t, tt, "otelcol_receiver_accepted_log_records"))
+	})
+
+	t.Run("messages, unmarshal failures and active sessions", func(t *testing.T) {
+		r, tt := newReceiver(t)
+		require.NoError(t, r.sessions.AddSession(t.Context(), testutil.NewFakeSession(1, "agntcy/otel/channel-1")))
+
+		r.telemetry.recordMessage(t.Context(), "agntcy/otel/channel-1", 10)
+		r.telemetry.recordMessage(t.Context(), "agntcy/otel/channel-1", 5)
+		r.telemetry.recordUnmarshalFailure(t.Context(), "agntcy/otel/channel-1")
+
+		assert.Equal(t, int64(2), sumValue(t, tt, metricReceivedMessages))
+		assert.Equal(t, int64(15), sumValue(t, tt, metricReceivedBytes))
+		assert.Equal(t, int64(1), sumValue(t, tt, metricUnmarshalFailures))
+
+		m, err := tt.GetMetric(metricActiveSessions)
+		require.NoError(t, err)
+		gauge, ok := m.Data.(metricdata.Gauge[int64])
+		require.True(t, ok)
+		require.Len(t, gauge.DataPoints, 1)
+		assert.Equal(t, int64(1), gauge.DataPoints[0].Value)
+	})
+
+	t.Run("duplicate sessions are closed and counted", func(t *testing.T) {
+		r, tt := newReceiver(t)
+		app := testutil.NewFakeApp()
+		r.app = app
+		require.NoError(t, r.sessions.AddSession(t.Context(), testutil.NewFakeSession(1, "agntcy/otel/channel-1")))
+
+		// the channel manager re-invites the receiver to the same channel
+		duplicate := testutil.NewFakeSession(2, "agntcy/otel/channel-1")
+		app.Invite(duplicate)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		done := make(chan struct{})
+		go func() {
+			listenForSessions(ctx, r)
+			close(done)
+		}()
+
+		require.Eventually(t, func() bool {
+			return len(app.DeletedSessions()) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		cancel()
+		<-done
+
+		assert.Equal(t, []uint32{2}, app.DeletedSessions())
+		assert.True(t, duplicate.Closed())
+		assert.Equal(t, []string{"agntcy/otel/channel-1"}, r.sessions.ListSessionNames(t.Context()))
+		assert.Equal(t, int64(1), sumValue(t, tt, metricDuplicateSessions))
+	})
+
+	t.Run("delivery latency of timestamped messages", func(t *testing.T) {
+		r, tt := newReceiver(t)
+		r.config = &Config{}
+		r.app = testutil.NewFakeApp()
+		r.tracesConsumer = &consumertest.TracesSink{}
+
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel-traces")
+		require.NoError(t, r.sessions.AddSession(t.Context(), session))
+
+		msg := slim.ReceivedMessage{Payload: tracesPayload(t, "span")}
+		msg.Context.Metadata = make(map[string]string)
+		slimcommon.AddSentAt(msg.Context.Metadata, time.Now().Add(-100*time.Millisecond))
+		session.DeliverMessage(msg)
+		// messages of exporters that do not set the timestamp are not measured
+		session.Deliver(tracesPayload(t, "span"))
+		session.Close()
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		handleSession(t.Context(), &wg, r, session)
+		wg.Wait()
+
+		m, err := tt.GetMetric(metricDeliveryLatency)
+		require.NoError(t, err)
+		hist, ok := m.Data.(metricdata.Histogram[float64])
+		require.True(t, ok)
+		require.Len(t, hist.DataPoints, 1)
+		assert.Equal(t, uint64(1), hist.DataPoints[0].Count)
+		assert.GreaterOrEqual(t, hist.DataPoints[0].Sum, 0.1)
+	})
+
+	t.Run("messages older than their ttl are dropped", func(t *testing.T) {
+		r, tt := newReceiver(t)
+		r.config = &Config{}
+		r.app = testutil.NewFakeApp()
+		sink := &consumertest.TracesSink{}
+		r.tracesConsumer = sink
+
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel-traces")
+		require.NoError(t, r.sessions.AddSession(t.Context(), session))
+
+		expired := slim.ReceivedMessage{Payload: tracesPayload(t, "expired")}
+		expired.Context.Metadata = make(map[string]string)
+		slimcommon.AddSentAt(expired.Context.Metadata, time.Now().Add(-time.Hour))
+		slimcommon.AddTTL(expired.Context.Metadata, time.Minute)
+		session.DeliverMessage(expired)
+
+		fresh := slim.ReceivedMessage{Payload: tracesPayload(t, "fresh")}
+		fresh.Context.Metadata = make(map[string]string)
+		slimcommon.AddSentAt(fresh.Context.Metadata, time.Now())
+		slimcommon.AddTTL(fresh.Context.Metadata, time.Minute)
+		session.DeliverMessage(fresh)
+		session.Close()
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		handleSession(t.Context(), &wg, r, session)
+		wg.Wait()
+
+		assert.Equal(t, 1, sink.SpanCount())
+		assert.Equal(t, int64(1), sumValue(t, tt, metricExpiredMessages))
+	})
+
+	t.Run("messages older than the catch-up max age are dropped", func(t *testing.T) {
+		r, tt := newReceiver(t)
+		r.config = &Config{CatchUp: CatchUpConfig{Order: CatchUpNewestFirst, MaxAge: time.Hour}}
+		r.app = testutil.NewFakeApp()
+		sink := &consumertest.TracesSink{}
+		r.tracesConsumer = sink
+
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel-traces")
+		require.NoError(t, r.sessions.AddSession(t.Context(), session))
+
+		stale := sentMessage("", 2*time.Hour)
+		stale.Payload = tracesPayload(t, "stale")
+		session.DeliverMessage(stale)
+		backlog := sentMessage("", 10*time.Minute)
+		backlog.Payload = tracesPayload(t, "backlog")
+		session.DeliverMessage(backlog)
+		session.Close()
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		handleSession(t.Context(), &wg, r, session)
+		wg.Wait()
+
+		assert.Equal(t, 1, sink.SpanCount())
+		assert.Equal(t, int64(1), sumValue(t, tt, metricExpiredMessages))
+	})
+}
+
+func TestHandleSession_IdleTimeout(t *testing.T) {
+	tt := componenttest.NewTelemetry()
+	t.Cleanup(func() { require.NoError(t, tt.Shutdown(context.Background())) })
+
+	app := testutil.NewFakeApp()
+	sessions := slimcommon.NewSessionsList(slimconfig.SignalUnknown)
+	telemetry, err := newReceiverTelemetry(receiver.Settings{
+		ID:                component.MustNewID(TypeStr),
+		TelemetrySettings: tt.NewTelemetrySettings(),
+	}, sessions)
+	require.NoError(t, err)
+	r := &slimReceiver{
+		config: &Config{
+			IdleTimeout: 100 * time.Millisecond,
+			Channels:    []ChannelsConfig{{ChannelName: "agntcy/otel/owned"}},
+		},
+		app:            app,
+		sessions:       sessions,
+		tracesConsumer: &consumertest.TracesSink{},
+		telemetry:      telemetry,
+		draining:       make(chan struct{}),
+	}
+
+	idle := testutil.NewFakeSession(1, "agntcy/otel/idle")
+	active := testutil.NewFakeSession(2, "agntcy/otel/active")
+	owned := testutil.NewFakeSession(3, "agntcy/otel/owned")
+	ctx, cancel := context.WithCancel(t.Context())
+	var wg sync.WaitGroup
+	for _, session := range []*testutil.FakeSession{idle, active, owned} {
+		require.NoError(t, sessions.AddSession(t.Context(), session))
+		wg.Add(1)
+		go handleSession(ctx, &wg, r, session)
+	}
+	go sessions.RunIdleReaper(ctx, app, r.config.IdleTimeout, r.ownsChannel,
+		func(name string) { r.telemetry.recordIdleSession(ctx, name) })
+
+	// the messages received on the active session keep it open
+	require.Eventually(t, func() bool {
+		active.Deliver(tracesPayload(t, "span"))
+		return idle.Closed()
+	}, 5*time.Second, 20*time.Millisecond)
+	// the handlers delete all the sessions once canceled
+	deleted := app.DeletedSessions()
+	cancel()
+	wg.Wait()
+
+	assert.Contains(t, deleted, uint32(1))
+	assert.NotContains(t, deleted, uint32(2))
+	assert.NotContains(t, deleted, uint32(3), "the channels of the receiver are kept")
+
+	m, err := tt.GetMetric(metricIdleSessions)
+	require.NoError(t, err)
+	sum, ok := m.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, int64(1), sum.DataPoints[0].Value)
+}
+
+// fakeConnection is a SLIM connection extension creating fake apps
+type fakeConnection struct {
+	component.StartFunc
+	component.ShutdownFunc
+	app        *testutil.FakeApp
+	created    []string
+	directions []slim.Direction
+}
+
+func (c *fakeConnection) ConnID() uint64 {
+	return 7
+}
+
+func (c *fakeConnection) Address() string {
+	return "http://slim:46357"
+}
+
+func (c *fakeConnection) CreateApp(localID string, direction slim.Direction) (slimcommon.App, error) {
+	c.created = append(c.created, localID)
+	c.directions = append(c.directions, direction)
+	return c.app, nil
+}
+
+// extensionsHost is a component.Host holding extensions
+type extensionsHost map[component.ID]component.Component
+
+func (h extensionsHost) GetExtensions() map[component.ID]component.Component {
+	return h
+}
+
+// TestSlimReceiver_ConnectionExtension tests the receiver creating its app on
+// the connection of a SLIM connection extension
+func TestSlimReceiver_ConnectionExtension(t *testing.T) {
+	conn := &fakeConnection{app: testutil.NewFakeApp()}
+	cfg := &Config{
+		Connection:       &slimConnectionID,
+		ReceiverName:     "agntcy/otel/receiver",
+		Acknowledgements: true,
+	}
+	r := newSlimReceiver(t.Context(), newTestReceiverSettings("connection"), cfg, nil)
+
+	err := r.createApp(t.Context(), componenttest.NewNopHost())
+	require.ErrorContains(t, err, "extension slim/shared not found")
+
+	require.NoError(t, r.createApp(t.Context(), extensionsHost{slimConnectionID: conn}))
+	assert.Same(t, conn.app, r.app)
+	assert.Equal(t, uint64(7), r.connID)
+	assert.Nil(t, r.failover, "the connection of the extension is not failed over")
+	assert.Equal(t, []string{"agntcy/otel/receiver"}, conn.created)
+	assert.Equal(t, []slim.Direction{slim.DirectionBidirectional}, conn.directions)
+}
+
+// TestSlimReceiver_ShutdownWaitsForHandlers tests that the app is destroyed,
+// or parked, once the messages being handled are consumed, or when the
+// shutdown context is done
+func TestSlimReceiver_ShutdownWaitsForHandlers(t *testing.T) {
+	start := func(t *testing.T, cfg *Config) (*slimReceiver, *testutil.FakeApp, chan struct{}) {
+		t.Helper()
+		consuming := make(chan struct{})
+		release := make(chan struct{})
+		tracesConsumer, err := consumer.NewTraces(func(context.Context, ptrace.Traces) error {
+			close(consuming)
+			<-release
+			return nil
+		})
+		require.NoError(t, err)
+
+		app := testutil.NewFakeApp()
+		ctx, cancel := context.WithCancel(t.Context())
+		r := &slimReceiver{
+			config:         cfg,
+			app:            app,
+			sessions:       slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+			tracesConsumer: tracesConsumer,
+			cancelFunc:     cancel,
+		}
+		session := testutil.NewFakeSession(1, "agntcy/otel/channel")
+		require.NoError(t, r.sessions.AddSession(t.Context(), session))
+		session.DeliverMessage(slim.ReceivedMessage{Payload: tracesPayload(t, "span")})
+
+		r.handlers.Add(1)
+		go handleSession(ctx, &r.handlers, r, session)
+		<-consuming
+		return r, app, release
+	}
+
+	t.Run("waits for the handlers", func(t *testing.T) {
+		r, app, release := start(t, &Config{})
+
+		shutdown := make(chan error, 1)
+		go func() { shutdown <- r.Shutdown(t.Context()) }()
+		time.Sleep(50 * time.Millisecond)
+		assert.False(t, app.Destroyed(), "the app is kept while a message is consumed")
+
+		close(release)
+		require.NoError(t, <-shutdown)
+		assert.True(t, app.Destroyed())
+	})
+
+	t.Run("bounded by the context", func(t *testing.T) {
+		r, app, release := start(t, &Config{})
+		defer close(release)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+		require.NoError(t, r.Shutdown(ctx))
+		assert.True(t, app.Destroyed())
+	})
+
+	t.Run("parked app bounded by the context", func(t *testing.T) {
+		cfg := &Config{ReceiverName: "agntcy/otel/receiver-shutdown", RestartGracePeriod: time.Minute}
+		r, app, release := start(t, cfg)
+		defer close(release)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+		require.NoError(t, r.Shutdown(ctx))
+		assert.False(t, app.Destroyed(), "the app is kept for the restarted receiver")
+		assert.Equal(t, []string{"agntcy/otel/channel"}, parkedSessions(t, cfg.ReceiverName))
+
+		parked := takeParkedApp(t.Context(), cfg)
+		require.NotNil(t, parked)
+		parked.close(t.Context())
+	})
+}
@@ -0,0 +1,158 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// shutdownReportBody is the body of the log records of the shutdown report
+const shutdownReportBody = "SLIM receiver shutdown report"
+
+// channelIngestion is the ingestion of a channel since the receiver started
+type channelIngestion struct {
+	messages int
+	bytes    int
+	errors   int
+	// times of the first and of the last message received on the channel
+	first time.Time
+	last  time.Time
+}
+
+// duration returns the time between the first and the last message of the
+// channel
+func (c *channelIngestion) duration() time.Duration {
+	return c.last.Sub(c.first)
+}
+
+// shutdownReport accumulates the ingestion of each channel, reported when
+// the receiver shuts down so that short-lived collectors, e.g. in batch
+// jobs, can confirm that everything was ingested. A nil shutdownReport
+// does not record anything.
+type shutdownReport struct {
+	started time.Time
+
+	mutex    sync.Mutex
+	channels map[string]*channelIngestion
+}
+
+// newShutdownReport creates the report of the receiver if enabled, nil
+// otherwise
+func newShutdownReport(cfg ShutdownReportConfig) *shutdownReport {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &shutdownReport{
+		started:  time.Now(),
+		channels: make(map[string]*channelIngestion),
+	}
+}
+
+// record records a message of size bytes received on channel, failed if it
+// could not be decoded or consumed
+func (s *shutdownReport) record(channel string, size int, failed bool) {
+	if s == nil {
+		return
+	}
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ingestion, ok := s.channels[channel]
+	if !ok {
+		ingestion = &channelIngestion{first: now}
+		s.channels[channel] = ingestion
+	}
+	ingestion.last = now
+	if failed {
+		ingestion.errors++
+		return
+	}
+	ingestion.messages++
+	ingestion.bytes += size
+}
+
+// snapshot returns a copy of the ingestion of each channel
+func (s *shutdownReport) snapshot() map[string]channelIngestion {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	channels := make(map[string]channelIngestion, len(s.channels))
+	for name, ingestion := range s.channels {
+		channels[name] = *ingestion
+	}
+	return channels
+}
+
+// emitShutdownReport logs the report, one Info entry per channel, and sends
+// it to the logs pipeline if enabled
+func (r *slimReceiver) emitShutdownReport(ctx context.Context) {
+	if r.report == nil {
+		return
+	}
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+	channels := r.report.snapshot()
+	uptime := time.Since(r.report.started)
+	names := slices.Sorted(maps.Keys(channels))
+	for _, name := range names {
+		ingestion := channels[name]
+		logger.Info("Shutdown report",
+			zap.String("channel", name),
+			zap.Int("messages", ingestion.messages),
+			zap.Int("bytes", ingestion.bytes),
+			zap.Int("errors", ingestion.errors),
+			zap.Duration("duration", ingestion.duration()))
+	}
+	logger.Info("Shutdown report summary",
+		zap.Int("channels", len(channels)),
+		zap.Duration("uptime", uptime))
+
+	if !r.config.ShutdownReport.LogRecords {
+		return
+	}
+	if r.logsConsumer == nil {
+		logger.Warn("Shutdown report not sent as log records, the receiver is not in a logs pipeline")
+		return
+	}
+	if err := r.logsConsumer.ConsumeLogs(ctx, shutdownReportLogs(r.config.ReceiverName, names, channels)); err != nil {
+		logger.Warn("Failed to send the shutdown report as log records", zap.Error(err))
+	}
+}
+
+// shutdownReportLogs returns the report as one log record per channel, in
+// the order of names
+func shutdownReportLogs(receiverName string, names []string, channels map[string]channelIngestion) plog.Logs {
+	logs := plog.NewLogs()
+	resourceLogs := logs.ResourceLogs().AppendEmpty()
+	resourceLogs.Resource().Attributes().PutStr(attributeReceiver, receiverName)
+	scopeLogs := resourceLogs.ScopeLogs().AppendEmpty()
+	scopeLogs.Scope().SetName(scopeName)
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	for _, name := range names {
+		ingestion := channels[name]
+		record := scopeLogs.LogRecords().AppendEmpty()
+		record.SetTimestamp(now)
+		record.SetObservedTimestamp(now)
+		record.SetSeverityNumber(plog.SeverityNumberInfo)
+		record.SetSeverityText(plog.SeverityNumberInfo.String())
+		record.Body().SetStr(shutdownReportBody)
+		attributes := record.Attributes()
+		attributes.PutStr(attributeChannel, name)
+		attributes.PutInt("slim.messages", int64(ingestion.messages))
+		attributes.PutInt("slim.bytes", int64(ingestion.bytes))
+		attributes.PutInt("slim.errors", int64(ingestion.errors))
+		attributes.PutDouble("slim.duration", ingestion.duration().Seconds())
+	}
+	return logs
+}
@@ -0,0 +1,64 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+func TestShutdownReport(t *testing.T) {
+	assert.Nil(t, newShutdownReport(ShutdownReportConfig{}))
+
+	logsSink := &consumertest.LogsSink{}
+	r := &slimReceiver{
+		config: &Config{
+			ReceiverName:   "agntcy/otel/receiver",
+			ShutdownReport: ShutdownReportConfig{Enabled: true, LogRecords: true},
+		},
+		logsConsumer: logsSink,
+	}
+	r.report = newShutdownReport(r.config.ShutdownReport)
+	r.report.record("agntcy/otel/channel-2", 10, false)
+	r.report.record("agntcy/otel/channel-1", 100, false)
+	r.report.record("agntcy/otel/channel-1", 50, false)
+	r.report.record("agntcy/otel/channel-1", 20, true)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	r.emitShutdownReport(slimcommon.InitContextWithLogger(t.Context(), zap.New(core)))
+
+	entries := logs.FilterMessage("Shutdown report").All()
+	require.Len(t, entries, 2)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "agntcy/otel/channel-1", fields["channel"])
+	assert.Equal(t, int64(2), fields["messages"])
+	assert.Equal(t, int64(150), fields["bytes"])
+	assert.Equal(t, int64(1), fields["errors"])
+	assert.Equal(t, 1, logs.FilterMessage("Shutdown report summary").Len())
+
+	require.Len(t, logsSink.AllLogs(), 1)
+	sent := logsSink.AllLogs()[0]
+	receiver, _ := sent.ResourceLogs().At(0).Resource().Attributes().Get(attributeReceiver)
+	assert.Equal(t, "agntcy/otel/receiver", receiver.Str())
+	records := sent.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	require.Equal(t, 2, records.Len())
+	assert.Equal(t, shutdownReportBody, records.At(1).Body().Str())
+	channel, _ := records.At(1).Attributes().Get(attributeChannel)
+	assert.Equal(t, "agntcy/otel/channel-2", channel.Str())
+	messages, _ := records.At(1).Attributes().Get("slim.messages")
+	assert.Equal(t, int64(1), messages.Int())
+
+	// without a logs pipeline the report is only logged
+	r.logsConsumer = nil
+	r.emitShutdownReport(t.Context())
+	assert.Len(t, logsSink.AllLogs(), 1)
+}
@@ -0,0 +1,107 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Resource attributes identifying the SLIM transport of the received data
+const (
+	attributeChannel   = "slim.channel"
+	attributeSessionID = "slim.session.id"
+	attributeSource    = "slim.source"
+	attributeReceiver  = "slim.receiver"
+	attributeComponent = "slim.component"
+	attributeProducer  = "slim.producer"
+)
+
+type transportKey struct{}
+
+// slimTransport identifies the SLIM transport a payload was received with
+type slimTransport struct {
+	channel   string
+	sessionID uint32
+	// name of the participant that sent the payload, empty if unknown
+	source string
+	// org/namespace/app name of the participant that sent the payload,
+	// matched by the identity attributes, empty if unknown
+	identity string
+	// ID of the exporter component that published the payload, empty if
+	// not tagged
+	component string
+	// name of the exporter that produced the payload, told by its envelope,
+	// empty if the payload is not wrapped
+	producer string
+}
+
+// withTransport returns a context carrying the transport of the payload
+// being decoded
+func withTransport(ctx context.Context, t slimTransport) context.Context {
+	return context.WithValue(ctx, transportKey{}, t)
+}
+
+// addResourceAttributes adds the resource attributes enabled in the config,
+// from the transport carried by ctx if any, to the resources of data, a
+// ptrace.Traces, a pmetric.Metrics or a plog.Logs
+func (r *slimReceiver) addResourceAttributes(ctx context.Context, data any) {
+	t, ok := ctx.Value(transportKey{}).(slimTransport)
+	if !ok {
+		return
+	}
+	cfg := r.config.ResourceAttributes
+	identity := cfg.identityAttributes(t.identity)
+	if !cfg.Channel && !cfg.SessionID && !cfg.Source && !cfg.Receiver && !cfg.Component && !cfg.Producer && identity == nil {
+		return
+	}
+
+	forEachResource(data, func(resource pcommon.Resource) {
+		attrs := resource.Attributes()
+		if cfg.Channel {
+			attrs.PutStr(attributeChannel, t.channel)
+		}
+		if cfg.SessionID {
+			attrs.PutInt(attributeSessionID, int64(t.sessionID))
+		}
+		if cfg.Source && t.source != "" {
+			attrs.PutStr(attributeSource, t.source)
+		}
+		if cfg.Receiver {
+			attrs.PutStr(attributeReceiver, r.config.ReceiverName)
+		}
+		if cfg.Component && t.component != "" {
+			attrs.PutStr(attributeComponent, t.component)
+		}
+		if cfg.Producer && t.producer != "" {
+			attrs.PutStr(attributeProducer, t.producer)
+		}
+		for key, value := range identity {
+			attrs.PutStr(key, value)
+		}
+	})
+}
+
+// forEachResource calls f with each resource of data, a ptrace.Traces, a
+// pmetric.Metrics or a plog.Logs
+func forEachResource(data any, f func(pcommon.Resource)) {
+	switch d := data.(type) {
+	case ptrace.Traces:
+		for i := range d.ResourceSpans().Len() {
+			f(d.ResourceSpans().At(i).Resource())
+		}
+	case pmetric.Metrics:
+		for i := range d.ResourceMetrics().Len() {
+			f(d.ResourceMetrics().At(i).Resource())
+		}
+	case plog.Logs:
+		for i := range d.ResourceLogs().Len() {
+			f(d.ResourceLogs().At(i).Resource())
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	slim "github.com/agntcy/slim-bindings-go"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestAddResourceAttributes(t *testing.T) {
+	transport := slimTransport{
+		channel: "agntcy/otel/channel", sessionID: 7, source: "agntcy/otel/exporter/0", component: "slim/pipeline-a",
+		producer: "agntcy/otel/exporter-logs",
+	}
+
+	newLogs := func() plog.Logs {
+		logs := plog.NewLogs()
+		logs.ResourceLogs().AppendEmpty().Resource().Attributes().PutStr(attributeChannel, "spoofed")
+		logs.ResourceLogs().AppendEmpty()
+		return logs
+	}
+
+	t.Run("all attributes", func(t *testing.T) {
+		r := &slimReceiver{config: &Config{
+			ReceiverName: "agntcy/otel/receiver",
+			ResourceAttributes: ResourceAttributesConfig{
+				Channel: true, SessionID: true, Source: true, Receiver: true, Component: true, Producer: true,
+			},
+		}}
+		logs := newLogs()
+		r.addResourceAttributes(withTransport(t.Context(), transport), logs)
+
+		for i := range logs.ResourceLogs().Len() {
+			assert.Equal(t, map[string]any{
+				attributeChannel:   "agntcy/otel/channel",
+				attributeSessionID: int64(7),
+				attributeSource:    "agntcy/otel/exporter/0",
+				attributeReceiver:  "agntcy/otel/receiver",
+				attributeComponent: "slim/pipeline-a",
+				attributeProducer:  "agntcy/otel/exporter-logs",
+			}, logs.ResourceLogs().At(i).Resource().Attributes().AsRaw())
+		}
+	})
+
+	t.Run("selected attributes", func(t *testing.T) {
+		r := &slimReceiver{config: &Config{ResourceAttributes: ResourceAttributesConfig{SessionID: true}}}
+		logs := newLogs()
+		r.addResourceAttributes(withTransport(t.Context(), transport), logs)
+
+		assert.Equal(t, map[string]any{attributeChannel: "spoofed", attributeSessionID: int64(7)},
+			logs.ResourceLogs().At(0).Resource().Attributes().AsRaw())
+	})
+
+	t.Run("identity attributes", func(t *testing.T) {
+		r := &slimReceiver{config: &Config{ResourceAttributes: ResourceAttributesConfig{
+			Identities: []IdentityAttributesConfig{
+				{Source: "agntcy/team-b/*", Attributes: map[string]string{"team": "b"}},
+				{Source: "agntcy/otel/exporter*", Attributes: map[string]string{"team": "otel", "env": "prod"}},
+				{Source: "agntcy/*/*", Attributes: map[string]string{"team": "unknown"}},
+			},
+		}}}
+		transport := transport
+		transport.identity = "agntcy/otel/exporter"
+		logs := newLogs()
+		logs.ResourceLogs().At(1).Resource().Attributes().PutStr("team", "spoofed")
+		r.addResourceAttributes(withTransport(t.Context(), transport), logs)
+
+		assert.Equal(t, map[string]any{attributeChannel: "spoofed", "team": "otel", "env": "prod"},
+			logs.ResourceLogs().At(0).Resource().Attributes().AsRaw())
+		assert.Equal(t, map[string]any{"team": "otel", "env": "prod"},
+			logs.ResourceLogs().At(1).Resource().Attributes().AsRaw(), "the identity attributes are not trusted")
+
+		// no identity matches the unknown senders
+		transport.identity = ""
+		logs = newLogs()
+		r.addResourceAttributes(withTransport(t.Context(), transport), logs)
+		assert.Equal(t, 0, logs.ResourceLogs().At(1).Resource().Attributes().Len())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		r := &slimReceiver{config: &Config{}}
+		logs := newLogs()
+		r.addResourceAttributes(withTransport(t.Context(), transport), logs)
+		assert.Equal(t, 0, logs.ResourceLogs().At(1).Resource().Attributes().Len())
+	})
+}
+
+func TestHandleSession_ResourceAttributes(t *testing.T) {
+	for _, mergeWindow := range []time.Duration{0, time.Hour} {
+		t.Run("merge window "+mergeWindow.String(), func(t *testing.T) {
+			tracesSink := &consumertest.TracesSink{}
+			r := &slimReceiver{
+				config: &Config{
+					MergeWindow: mergeWindow,
+					ResourceAttributes: ResourceAttributesConfig{
+						Channel: true, SessionID: true, Source: true, Component: true,
+						Identities: []IdentityAttributesConfig{
+							{Source: "agntcy/otel/exporter-2", Attributes: map[string]string{"team": "b"}},
+						},
+					},
+				},
+				app:            testutil.NewFakeApp(),
+				sessions:       slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+				tracesConsumer: tracesSink,
+			}
+
+			session := testutil.NewFakeSession(3, "agntcy/otel/channel-traces")
+			require.NoError(t, r.sessions.AddSession(t.Context(), session))
+			for _, source := range []string{"agntcy/otel/exporter-1", "agntcy/otel/exporter-2"} {
+				name, err := slimcommon.SplitID(source)
+				require.NoError(t, err)
+				msg := slim.ReceivedMessage{Payload: tracesPayload(t, "span")}
+				msg.Context.SourceName = name
+				// only the first exporter tags its component
+				msg.Context.Metadata = make(map[string]string)
+				if source == "agntcy/otel/exporter-1" {
+					slimcommon.AddComponent(msg.Context.Metadata, "slim/pipeline-a")
+				}
+				session.DeliverMessage(msg)
+			}
+			session.Close()
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			handleSession(t.Context(), &wg, r, session)
+			wg.Wait()
+
+			var resources []ptrace.ResourceSpans
+			for _, traces := range tracesSink.AllTraces() {
+				for i := range traces.ResourceSpans().Len() {
+					resources = append(resources, traces.ResourceSpans().At(i))
+				}
+			}
+			require.Len(t, resources, 2)
+			for i, source := range []string{"agntcy/otel/exporter-1", "agntcy/otel/exporter-2"} {
+				attrs := resources[i].Resource().Attributes().AsRaw()
+				assert.Equal(t, "agntcy/otel/channel-traces", attrs[attributeChannel])
+				assert.Equal(t, int64(3), attrs[attributeSessionID])
+				assert.Contains(t, attrs[attributeSource], source, "each sender is kept when merged")
+			}
+			assert.Equal(t, "slim/pipeline-a", resources[0].Resource().Attributes().AsRaw()[attributeComponent])
+			assert.NotContains(t, resources[1].Resource().Attributes().AsRaw(), attributeComponent)
+			assert.NotContains(t, resources[0].Resource().Attributes().AsRaw(), "team")
+			assert.Equal(t, "b", resources[1].Resource().Attributes().AsRaw()["team"])
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// logSeverity is the severity applied to the log records without severity
+type logSeverity struct {
+	number plog.SeverityNumber
+	text   string
+}
+
+type logSeverityKey struct{}
+
+// withDefaultLogSeverity returns a context carrying the default log severity
+// of a session. An empty or invalid name leaves ctx unchanged.
+func withDefaultLogSeverity(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	number, err := slimcommon.ParseLogSeverity(name)
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, logSeverityKey{}, logSeverity{
+		number: plog.SeverityNumber(number),
+		text:   strings.ToUpper(name),
+	})
+}
+
+// applyDefaultLogSeverity sets the default log severity carried by ctx, if
+// any, on the log records that have neither a severity number nor a
+// severity text
+func applyDefaultLogSeverity(ctx context.Context, logs plog.Logs) {
+	severity, ok := ctx.Value(logSeverityKey{}).(logSeverity)
+	if !ok {
+		return
+	}
+
+	rls := logs.ResourceLogs()
+	for i := range rls.Len() {
+		sls := rls.At(i).ScopeLogs()
+		for j := range sls.Len() {
+			records := sls.At(j).LogRecords()
+			for k := range records.Len() {
+				record := records.At(k)
+				if record.SeverityNumber() == plog.SeverityNumberUnspecified && record.SeverityText() == "" {
+					record.SetSeverityNumber(severity.number)
+					record.SetSeverityText(severity.text)
+				}
+			}
+		}
+	}
+}
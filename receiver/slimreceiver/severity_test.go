@@ -0,0 +1,95 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestApplyDefaultLogSeverity(t *testing.T) {
+	newLogs := func() plog.Logs {
+		logs := plog.NewLogs()
+		records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+		records.AppendEmpty().Body().SetStr("untyped")
+		typed := records.AppendEmpty()
+		typed.SetSeverityNumber(plog.SeverityNumberError)
+		typed.SetSeverityText("ERROR")
+		textOnly := records.AppendEmpty()
+		textOnly.SetSeverityText("notice")
+		return logs
+	}
+
+	t.Run("default severity", func(t *testing.T) {
+		logs := newLogs()
+		applyDefaultLogSeverity(withDefaultLogSeverity(t.Context(), "warn"), logs)
+
+		records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		assert.Equal(t, plog.SeverityNumberWarn, records.At(0).SeverityNumber())
+		assert.Equal(t, "WARN", records.At(0).SeverityText())
+		// records with a severity are left unchanged
+		assert.Equal(t, plog.SeverityNumberError, records.At(1).SeverityNumber())
+		assert.Equal(t, plog.SeverityNumberUnspecified, records.At(2).SeverityNumber())
+		assert.Equal(t, "notice", records.At(2).SeverityText())
+	})
+
+	t.Run("no default severity", func(t *testing.T) {
+		logs := newLogs()
+		applyDefaultLogSeverity(withDefaultLogSeverity(t.Context(), ""), logs)
+		assert.Equal(t, plog.SeverityNumberUnspecified,
+			logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).SeverityNumber())
+	})
+}
+
+func TestHandleSession_DefaultLogSeverity(t *testing.T) {
+	tests := []struct {
+		name            string
+		configSeverity  string
+		channelSeverity string
+		want            plog.SeverityNumber
+	}{
+		{name: "none", want: plog.SeverityNumberUnspecified},
+		{name: "receiver config", configSeverity: "INFO", want: plog.SeverityNumberInfo},
+		{name: "channel metadata", channelSeverity: "DEBUG", want: plog.SeverityNumberDebug},
+		{name: "channel overrides config", configSeverity: "INFO", channelSeverity: "ERROR", want: plog.SeverityNumberError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logsSink := &consumertest.LogsSink{}
+			r := &slimReceiver{
+				config:       &Config{DefaultLogSeverity: tt.configSeverity},
+				app:          testutil.NewFakeApp(),
+				sessions:     slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+				logsConsumer: logsSink,
+			}
+
+			session := testutil.NewFakeSession(1, "agntcy/otel/channel-logs")
+			session.Config.Metadata = make(map[string]string)
+			slimcommon.ChannelPolicy{DefaultLogSeverity: tt.channelSeverity}.AddToMetadata(session.Config.Metadata)
+			require.NoError(t, r.sessions.AddSession(t.Context(), session))
+
+			session.Deliver(logsPayload(t, "untyped"))
+			session.Close()
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			handleSession(t.Context(), &wg, r, session)
+			wg.Wait()
+
+			require.Len(t, logsSink.AllLogs(), 1)
+			record := logsSink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+			assert.Equal(t, tt.want, record.SeverityNumber())
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	slim "github.com/agntcy/slim-bindings-go"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// errSourceNotAllowed is returned by checkSessionSource for a session whose
+// source does not match the allowed sources
+var errSourceNotAllowed = errors.New("session source not allowed")
+
+// sourceError is the error of a session from a source that is not allowed, it
+// wraps errSourceNotAllowed
+type sourceError struct {
+	source string
+}
+
+func (e *sourceError) Error() string { return errSourceNotAllowed.Error() + ": " + e.source }
+
+func (e *sourceError) Unwrap() error { return errSourceNotAllowed }
+
+// checkSessionSource filters the new sessions before any message is
+// received: the peer of a point-to-point session, its destination, must be
+// an allowed source. The name of a group session is chosen by whoever
+// creates the channel and tells nothing about the participants publishing
+// on it, the group sessions are accepted and their messages are checked by
+// messageSourceAllowed. The error wraps errSourceNotAllowed.
+func (r *slimReceiver) checkSessionSource(session slimcommon.Session) error {
+	if len(r.config.AllowedSources) == 0 {
+		return nil
+	}
+
+	config, err := session.SessionConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get the session config: %w", err)
+	}
+	if config.SessionType != slim.SessionTypePointToPoint {
+		return nil
+	}
+
+	destination, err := session.Destination()
+	if err != nil {
+		return fmt.Errorf("failed to get the session destination: %w", err)
+	}
+	source := slimcommon.JoinID(destination)
+	if !r.sourceAllowed(source) {
+		return &sourceError{source: source}
+	}
+	return nil
+}
+
+// messageSourceAllowed reports whether a received message was sent by an
+// allowed source, the sender authenticated by SLIM. A message without
+// sender is not allowed when the allowed sources are set.
+func (r *slimReceiver) messageSourceAllowed(msg slim.ReceivedMessage) bool {
+	if len(r.config.AllowedSources) == 0 {
+		return true
+	}
+	if msg.Context.SourceName == nil {
+		return false
+	}
+	return r.sourceAllowed(slimcommon.JoinID(msg.Context.SourceName))
+}
+
+// sourceAllowed reports whether source matches one of the allowed sources
+func (r *slimReceiver) sourceAllowed(source string) bool {
+	for _, pattern := range r.config.AllowedSources {
+		if ok, _ := path.Match(pattern, source); ok {
+			return true
+		}
+	}
+	return false
+}
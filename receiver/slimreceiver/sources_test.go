@@ -0,0 +1,133 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+
+	slim "github.com/agntcy/slim-bindings-go"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestCheckSessionSource(t *testing.T) {
+	pointToPoint := testutil.NewFakeSession(1, "agntcy/team-a/exporter")
+	pointToPoint.Config.SessionType = slim.SessionTypePointToPoint
+	group := groupSession(t, 2, "agntcy/otel/channel", "agntcy/untrusted/exporter")
+
+	tests := []struct {
+		name    string
+		allowed []string
+		session slimcommon.Session
+		err     bool
+	}{
+		{name: "no allowed sources", session: pointToPoint},
+		{name: "allowed peer", allowed: []string{"agntcy/team-a/*"}, session: pointToPoint},
+		{name: "peer not allowed", allowed: []string{"agntcy/team-b/*"}, session: pointToPoint, err: true},
+		{
+			name:    "group session checked by message",
+			allowed: []string{"agntcy/team-a/*"},
+			session: group,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &slimReceiver{config: &Config{AllowedSources: tt.allowed}}
+			err := r.checkSessionSource(tt.session)
+			if !tt.err {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, errSourceNotAllowed)
+			destination, destErr := tt.session.Destination()
+			require.NoError(t, destErr)
+			assert.Contains(t, err.Error(), slimcommon.JoinID(destination))
+		})
+	}
+}
+
+func TestListenForSessions_AllowedSources(t *testing.T) {
+	app := testutil.NewFakeApp()
+	r := &slimReceiver{
+		config: &Config{
+			ReceiverName:   "agntcy/otel/receiver",
+			AllowedSources: []string{"agntcy/otel/channel-*", "agntcy/team-a/*"},
+		},
+		app:      app,
+		sessions: slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		draining: make(chan struct{}),
+	}
+
+	channel := groupSession(t, 1, "agntcy/otel/channel-1", "agntcy/otel/receiver")
+	otherChannel := groupSession(t, 2, "agntcy/rogue/channel", "agntcy/otel/receiver")
+	allowedPeer := testutil.NewFakeSession(3, "agntcy/team-a/exporter")
+	allowedPeer.Config.SessionType = slim.SessionTypePointToPoint
+	unknownPeer := testutil.NewFakeSession(4, "agntcy/rogue/agent")
+	unknownPeer.Config.SessionType = slim.SessionTypePointToPoint
+	for _, session := range []*testutil.FakeSession{channel, otherChannel, allowedPeer, unknownPeer} {
+		app.Invite(session)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		listenForSessions(ctx, r)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(app.DeletedSessions()) == 1 && len(r.sessions.ListSessionNames(t.Context())) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	rejected := app.DeletedSessions()
+	names := r.sessions.ListSessionNames(t.Context())
+	cancel()
+	<-done
+	r.handlers.Wait()
+
+	assert.Equal(t, []uint32{4}, rejected, "the group sessions are checked by message")
+	assert.ElementsMatch(t, []string{"agntcy/otel/channel-1", "agntcy/rogue/channel", "agntcy/team-a/exporter"}, names)
+}
+
+func TestHandleSession_AllowedSources(t *testing.T) {
+	tracesSink := &consumertest.TracesSink{}
+	r := &slimReceiver{
+		config:         &Config{AllowedSources: []string{"agntcy/team-a/*"}},
+		app:            testutil.NewFakeApp(),
+		sessions:       slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		tracesConsumer: tracesSink,
+	}
+	session := groupSession(t, 1, "agntcy/otel/channel", "agntcy/team-a/exporter", "agntcy/rogue/agent")
+	require.NoError(t, r.sessions.AddSession(t.Context(), session))
+
+	deliver := func(source, spanName string) {
+		msg := slim.ReceivedMessage{Payload: tracesPayload(t, spanName)}
+		if source != "" {
+			name, err := slimcommon.SplitID(source)
+			require.NoError(t, err)
+			msg.Context.SourceName = name
+		}
+		session.DeliverMessage(msg)
+	}
+	deliver("agntcy/team-a/exporter", "allowed")
+	deliver("agntcy/rogue/agent", "injected")
+	deliver("", "anonymous")
+	session.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	handleSession(t.Context(), &wg, r, session)
+	wg.Wait()
+
+	require.Len(t, tracesSink.AllTraces(), 1)
+	assert.Equal(t, "allowed", tracesSink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name())
+}
@@ -0,0 +1,432 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/receiverhelper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+const (
+	// scopeName is the instrumentation scope of the receiver self-telemetry
+	scopeName = "github.com/agntcy/slim-otel/receiver/slimreceiver"
+
+	// transport reported by the standard receiver metrics
+	transport = "slim"
+
+	metricReceivedMessages  = "otelcol_receiver_slim_received_messages"
+	metricReceivedBytes     = "otelcol_receiver_slim_received_bytes"
+	metricUnmarshalFailures = "otelcol_receiver_slim_unmarshal_failures"
+	metricActiveSessions    = "otelcol_receiver_slim_active_sessions"
+	metricDuplicateSessions = "otelcol_receiver_slim_duplicate_sessions"
+	metricPolicyViolations  = "otelcol_receiver_slim_policy_violations"
+	metricDeliveryLatency   = "otelcol_receiver_slim_delivery_latency"
+	metricDecodeThrottles   = "otelcol_receiver_slim_decode_throttles"
+	metricUnconsumed        = "otelcol_receiver_slim_unconsumed_messages"
+	metricInFlightWaits     = "otelcol_receiver_slim_in_flight_waits"
+	metricRejectedSessions  = "otelcol_receiver_slim_rejected_sessions"
+	metricUnauthorized      = "otelcol_receiver_slim_unauthorized_sessions"
+	metricUnauthorizedMsgs  = "otelcol_receiver_slim_unauthorized_messages"
+	metricIdleSessions      = "otelcol_receiver_slim_idle_sessions"
+	metricExpiredMessages   = "otelcol_receiver_slim_expired_messages"
+	metricEmptyPayloads     = "otelcol_receiver_slim_empty_payloads"
+	metricRejectedEnvelopes = "otelcol_receiver_slim_rejected_envelopes"
+	metricProducerResources = "otelcol_receiver_slim_producer_resources"
+)
+
+// deliveryLatencyBuckets are the histogram boundaries, in seconds, of the
+// delivery latency
+var deliveryLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// receiverTelemetry holds the instruments used by the receiver to report its
+// own activity through the collector internal telemetry. Accepted and refused
+// items (spans, metric points, log records) are reported through obsreport.
+// All methods are safe to call on a nil receiver, in which case nothing is recorded.
+type receiverTelemetry struct {
+	obsrecv           *receiverhelper.ObsReport
+	receivedMessages  metric.Int64Counter
+	receivedBytes     metric.Int64Counter
+	unmarshalFailures metric.Int64Counter
+	duplicateSessions metric.Int64Counter
+	policyViolations  metric.Int64Counter
+	deliveryLatency   metric.Float64Histogram
+	decodeThrottles   metric.Int64Counter
+	unconsumed        metric.Int64Counter
+	inFlightWaits     metric.Int64Counter
+	rejectedSessions  metric.Int64Counter
+	unauthorized      metric.Int64Counter
+	unauthorizedMsgs  metric.Int64Counter
+	idleSessions      metric.Int64Counter
+	expiredMessages   metric.Int64Counter
+	emptyPayloads     metric.Int64Counter
+	rejectedEnvelopes metric.Int64Counter
+	producerResources metric.Int64Counter
+	activeSessions    metric.Int64ObservableGauge
+	registration      metric.Registration
+}
+
+// newReceiverTelemetry creates the receiver instruments and registers the
+// callback reporting the number of active sessions
+func newReceiverTelemetry(
+	set receiver.Settings,
+	sessions *slimcommon.SessionsList,
+) (*receiverTelemetry, error) {
+	obsrecv, err := receiverhelper.NewObsReport(receiverhelper.ObsReportSettings{
+		ReceiverID:             set.ID,
+		Transport:              transport,
+		LongLivedCtx:           true,
+		ReceiverCreateSettings: set,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	meter := set.MeterProvider.Meter(scopeName)
+	t := &receiverTelemetry{obsrecv: obsrecv}
+
+	var errs error
+	t.receivedMessages, err = meter.Int64Counter(metricReceivedMessages,
+		metric.WithDescription("Number of messages received from SLIM sessions"),
+		metric.WithUnit("{messages}"))
+	errs = errors.Join(errs, err)
+
+	t.receivedBytes, err = meter.Int64Counter(metricReceivedBytes,
+		metric.WithDescription("Size of the payloads received from SLIM sessions"),
+		metric.WithUnit("By"))
+	errs = errors.Join(errs, err)
+
+	t.unmarshalFailures, err = meter.Int64Counter(metricUnmarshalFailures,
+		metric.WithDescription("Number of payloads that could not be decoded as OTLP data"),
+		metric.WithUnit("{messages}"))
+	errs = errors.Join(errs, err)
+
+	t.duplicateSessions, err = meter.Int64Counter(metricDuplicateSessions,
+		metric.WithDescription("Number of invitations to an already joined channel whose redundant session was closed"),
+		metric.WithUnit("{sessions}"))
+	errs = errors.Join(errs, err)
+
+	t.policyViolations, err = meter.Int64Counter(metricPolicyViolations,
+		metric.WithDescription("Number of messages that do not comply with the channel policy"),
+		metric.WithUnit("{messages}"))
+	errs = errors.Join(errs, err)
+
+	t.deliveryLatency, err = meter.Float64Histogram(metricDeliveryLatency,
+		metric.WithDescription("Time between the publication of a message by the exporter and its reception"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(deliveryLatencyBuckets...))
+	errs = errors.Join(errs, err)
+
+	t.decodeThrottles, err = meter.Int64Counter(metricDecodeThrottles,
+		metric.WithDescription("Number of payloads whose decoding was delayed because the channel spent its decode budget"),
+		metric.WithUnit("{messages}"))
+	errs = errors.Join(errs, err)
+
+	t.unconsumed, err = meter.Int64Counter(metricUnconsumed,
+		metric.WithDescription("Number of messages dropped because no pipeline of the receiver consumes their signal"),
+		metric.WithUnit("{messages}"))
+	errs = errors.Join(errs, err)
+
+	t.inFlightWaits, err = meter.Int64Counter(metricInFlightWaits,
+		metric.WithDescription("Number of messages whose processing waited for the in-flight limits"),
+		metric.WithUnit("{messages}"))
+	errs = errors.Join(errs, err)
+
+	t.rejectedSessions, err = meter.Int64Counter(metricRejectedSessions,
+		metric.WithDescription("Number of sessions closed because they exceeded the session quotas"),
+		metric.WithUnit("{sessions}"))
+	errs = errors.Join(errs, err)
+
+	t.unauthorized, err = meter.Int64Counter(metricUnauthorized,
+		metric.WithDescription("Number of sessions closed because their source does not match the allowed sources"),
+		metric.WithUnit("{sessions}"))
+	errs = errors.Join(errs, err)
+
+	t.unauthorizedMsgs, err = meter.Int64Counter(metricUnauthorizedMsgs,
+		metric.WithDescription("Number of messages dropped because their source does not match the allowed sources"),
+		metric.WithUnit("{messages}"))
+	errs = errors.Join(errs, err)
+
+	t.idleSessions, err = meter.Int64Counter(metricIdleSessions,
+		metric.WithDescription("Number of sessions closed because they were idle for longer than the idle timeout"),
+		metric.WithUnit("{sessions}"))
+	errs = errors.Join(errs, err)
+
+	t.expiredMessages, err = meter.Int64Counter(metricExpiredMessages,
+		metric.WithDescription("Number of messages dropped because they outlived their time to live or the catch-up max age"),
+		metric.WithUnit("{messages}"))
+	errs = errors.Join(errs, err)
+
+	t.emptyPayloads, err = meter.Int64Counter(metricEmptyPayloads,
+		metric.WithDescription("Number of payloads without any span, data point or log record, not consumed"),
+		metric.WithUnit("{payloads}"))
+	errs = errors.Join(errs, err)
+
+	t.rejectedEnvelopes, err = meter.Int64Counter(metricRejectedEnvelopes,
+		metric.WithDescription("Number of messages dropped because their envelope cannot be read"),
+		metric.WithUnit("{messages}"))
+	errs = errors.Join(errs, err)
+
+	t.producerResources, err = meter.Int64Counter(metricProducerResources,
+		metric.WithDescription("Number of resources received, by schema URL and SDK of their producer"),
+		metric.WithUnit("{resources}"))
+	errs = errors.Join(errs, err)
+
+	t.activeSessions, err = meter.Int64ObservableGauge(metricActiveSessions,
+		metric.WithDescription("Number of SLIM sessions the receiver is currently handling"),
+		metric.WithUnit("{sessions}"))
+	errs = errors.Join(errs, err)
+	if errs != nil {
+		return nil, errs
+	}
+
+	t.registration, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(t.activeSessions, int64(len(sessions.ListSessionNames(ctx))))
+		return nil
+	}, t.activeSessions)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// recordMessage records a message received on the given session
+func (t *receiverTelemetry) recordMessage(ctx context.Context, sessionName string, size int) {
+	if t == nil {
+		return
+	}
+	attrs := metric.WithAttributeSet(attribute.NewSet(attribute.String("session", sessionName)))
+	t.receivedMessages.Add(ctx, 1, attrs)
+	t.receivedBytes.Add(ctx, int64(size), attrs)
+}
+
+// recordUnmarshalFailure records a payload that could not be decoded
+func (t *receiverTelemetry) recordUnmarshalFailure(ctx context.Context, sessionName string) {
+	if t == nil {
+		return
+	}
+	t.unmarshalFailures.Add(ctx, 1,
+		metric.WithAttributeSet(attribute.NewSet(attribute.String("session", sessionName))))
+}
+
+// recordDuplicateSession records a redundant session closed because the
+// receiver already handles a session on the same channel
+func (t *receiverTelemetry) recordDuplicateSession(ctx context.Context, sessionName string) {
+	if t == nil {
+		return
+	}
+	t.duplicateSessions.Add(ctx, 1,
+		metric.WithAttributeSet(attribute.NewSet(attribute.String("session", sessionName))))
+}
+
+// recordRejectedSession records a session closed because it exceeded the
+// given quota
+func (t *receiverTelemetry) recordRejectedSession(ctx context.Context, sessionName, quota string) {
+	if t == nil {
+		return
+	}
+	t.rejectedSessions.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("session", sessionName),
+		attribute.String("quota", quota),
+	)))
+}
+
+// recordUnauthorizedSession records a session closed because its source
+// does not match the allowed sources
+func (t *receiverTelemetry) recordUnauthorizedSession(ctx context.Context, sessionName string) {
+	if t == nil {
+		return
+	}
+	t.unauthorized.Add(ctx, 1,
+		metric.WithAttributeSet(attribute.NewSet(attribute.String("session", sessionName))))
+}
+
+// recordUnauthorizedMessage records a message dropped because its source
+// does not match the allowed sources
+func (t *receiverTelemetry) recordUnauthorizedMessage(ctx context.Context, sessionName string) {
+	if t == nil {
+		return
+	}
+	t.unauthorizedMsgs.Add(ctx, 1,
+		metric.WithAttributeSet(attribute.NewSet(attribute.String("session", sessionName))))
+}
+
+// recordIdleSession records a session closed because it was idle for longer
+// than the idle timeout
+func (t *receiverTelemetry) recordIdleSession(ctx context.Context, sessionName string) {
+	if t == nil {
+		return
+	}
+	t.idleSessions.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("session", sessionName),
+	)))
+}
+
+// recordExpiredMessage records a message received on the given session
+// dropped because it outlived its time to live
+func (t *receiverTelemetry) recordExpiredMessage(ctx context.Context, sessionName string) {
+	if t == nil {
+		return
+	}
+	t.expiredMessages.Add(ctx, 1,
+		metric.WithAttributeSet(attribute.NewSet(attribute.String("session", sessionName))))
+}
+
+// recordEmptyPayload records a payload without any item
+func (t *receiverTelemetry) recordEmptyPayload(ctx context.Context, sessionName string) {
+	if t == nil {
+		return
+	}
+	t.emptyPayloads.Add(ctx, 1,
+		metric.WithAttributeSet(attribute.NewSet(attribute.String("session", sessionName))))
+}
+
+// recordRejectedEnvelope records a message dropped because its envelope
+// cannot be read, for the given reason
+func (t *receiverTelemetry) recordRejectedEnvelope(ctx context.Context, sessionName, reason string) {
+	if t == nil {
+		return
+	}
+	t.rejectedEnvelopes.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("session", sessionName),
+		attribute.String("reason", reason),
+	)))
+}
+
+// recordProducerResource records a resource received on the given session
+// from the producer told by info
+func (t *receiverTelemetry) recordProducerResource(ctx context.Context, sessionName string, info producerInfo) {
+	if t == nil {
+		return
+	}
+	t.producerResources.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("session", sessionName),
+		attribute.String("schema_url", info.SchemaURL),
+		attribute.String("sdk_name", info.SDKName),
+		attribute.String("sdk_language", info.SDKLanguage),
+		attribute.String("sdk_version", info.SDKVersion),
+	)))
+}
+
+// recordPolicyViolation records a message violating the given channel policy
+func (t *receiverTelemetry) recordPolicyViolation(ctx context.Context, sessionName, policy string) {
+	if t == nil {
+		return
+	}
+	t.policyViolations.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("session", sessionName),
+		attribute.String("policy", policy),
+	)))
+}
+
+// recordDeliveryLatency records the time elapsed since the exporter published
+// a message received on the given session. The exporter and receiver clocks
+// are not synchronized, negative values caused by clock skew are recorded as 0.
+func (t *receiverTelemetry) recordDeliveryLatency(ctx context.Context, sessionName string, latency time.Duration) {
+	if t == nil {
+		return
+	}
+	t.deliveryLatency.Record(ctx, max(latency, 0).Seconds(),
+		metric.WithAttributeSet(attribute.NewSet(attribute.String("session", sessionName))))
+}
+
+// recordDecodeThrottled records a payload whose decoding waited for the
+// decode budget of its channel
+func (t *receiverTelemetry) recordDecodeThrottled(ctx context.Context, sessionName string) {
+	if t == nil {
+		return
+	}
+	t.decodeThrottles.Add(ctx, 1,
+		metric.WithAttributeSet(attribute.NewSet(attribute.String("session", sessionName))))
+}
+
+// recordInFlightWait records a message received on the given session whose
+// processing waited for the in-flight limits
+func (t *receiverTelemetry) recordInFlightWait(ctx context.Context, sessionName string) {
+	if t == nil {
+		return
+	}
+	t.inFlightWaits.Add(ctx, 1,
+		metric.WithAttributeSet(attribute.NewSet(attribute.String("session", sessionName))))
+}
+
+// recordUnconsumed records a message of a signal without consumer received
+// on the given session
+func (t *receiverTelemetry) recordUnconsumed(ctx context.Context, sessionName string, signal slimconfig.SignalType) {
+	if t == nil {
+		return
+	}
+	t.unconsumed.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("session", sessionName),
+		attribute.String("signal", string(signal)),
+	)))
+}
+
+// startTracesOp starts an obsreport operation for received traces
+func (t *receiverTelemetry) startTracesOp(ctx context.Context) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return t.obsrecv.StartTracesOp(ctx)
+}
+
+// endTracesOp reports the spans, received in format, accepted or refused by
+// the next consumer
+func (t *receiverTelemetry) endTracesOp(ctx context.Context, format string, numSpans int, err error) {
+	if t == nil {
+		return
+	}
+	t.obsrecv.EndTracesOp(ctx, format, numSpans, err)
+}
+
+// startMetricsOp starts an obsreport operation for received metrics
+func (t *receiverTelemetry) startMetricsOp(ctx context.Context) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return t.obsrecv.StartMetricsOp(ctx)
+}
+
+// endMetricsOp reports the data points, received in format, accepted or
+// refused by the next consumer
+func (t *receiverTelemetry) endMetricsOp(ctx context.Context, format string, numDataPoints int, err error) {
+	if t == nil {
+		return
+	}
+	t.obsrecv.EndMetricsOp(ctx, format, numDataPoints, err)
+}
+
+// startLogsOp starts an obsreport operation for received logs
+func (t *receiverTelemetry) startLogsOp(ctx context.Context) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return t.obsrecv.StartLogsOp(ctx)
+}
+
+// endLogsOp reports the log records, received in format, accepted or refused
+// by the next consumer
+func (t *receiverTelemetry) endLogsOp(ctx context.Context, format string, numLogRecords int, err error) {
+	if t == nil {
+		return
+	}
+	t.obsrecv.EndLogsOp(ctx, format, numLogRecords, err)
+}
+
+// shutdown unregisters the active sessions callback
+func (t *receiverTelemetry) shutdown() error {
+	if t == nil || t.registration == nil {
+		return nil
+	}
+	return t.registration.Unregister()
+}
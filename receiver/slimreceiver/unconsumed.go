@@ -0,0 +1,99 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// unconsumedWarningInterval is the minimum interval between two warnings
+// about the payloads of the same channel and signal without consumer
+const unconsumedWarningInterval = time.Minute
+
+// warningLimiter limits the warnings logged for each key to one per
+// interval. A nil warningLimiter allows all the warnings.
+type warningLimiter struct {
+	interval time.Duration
+
+	mutex  sync.Mutex
+	logged map[string]time.Time
+}
+
+// newWarningLimiter creates a warningLimiter allowing one warning per key
+// every interval
+func newWarningLimiter(interval time.Duration) *warningLimiter {
+	return &warningLimiter{
+		interval: interval,
+		logged:   make(map[string]time.Time),
+	}
+}
+
+// allow reports whether a warning for key can be logged now, and if so
+// records it
+func (l *warningLimiter) allow(key string, now time.Time) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if last, ok := l.logged[key]; ok && now.Sub(last) < l.interval {
+		return false
+	}
+	l.logged[key] = now
+	return true
+}
+
+// forget drops the warnings recorded for the keys with the given prefix,
+// e.g. of a channel whose session ended
+func (l *warningLimiter) forget(prefix string) {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for key := range l.logged {
+		if strings.HasPrefix(key, prefix) {
+			delete(l.logged, key)
+		}
+	}
+}
+
+// consumes reports whether the receiver has a consumer for the signal
+func (r *slimReceiver) consumes(signal slimconfig.SignalType) bool {
+	switch signal {
+	case slimconfig.SignalTraces:
+		return r.tracesConsumer != nil
+	case slimconfig.SignalMetrics:
+		return r.metricsConsumer != nil
+	case slimconfig.SignalLogs:
+		return r.logsConsumer != nil
+	default:
+		return false
+	}
+}
+
+// dropUnconsumed reports a payload received on the session of a signal the
+// receiver has no consumer for, e.g. metrics published on a channel of a
+// traces pipeline. The warnings are limited to one per channel and signal
+// every minute.
+func (r *slimReceiver) dropUnconsumed(ctx context.Context, sessionName string, signal slimconfig.SignalType) {
+	r.telemetry.recordUnconsumed(ctx, sessionName, signal)
+	if r.unconsumedWarnings.allow(sessionName+"\x00"+string(signal), time.Now()) {
+		slimcommon.LoggerFromContextOrDefault(ctx).Warn(
+			"Dropping the payloads of a signal without consumer in the pipelines of the receiver",
+			zap.String("channel", sessionName),
+			zap.String("signal", string(signal)))
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	slim "github.com/agntcy/slim-bindings-go"
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+func TestWarningLimiter(t *testing.T) {
+	limiter := newWarningLimiter(time.Minute)
+	now := time.Now()
+
+	assert.True(t, limiter.allow("channel-1", now))
+	assert.False(t, limiter.allow("channel-1", now.Add(30*time.Second)))
+	assert.True(t, limiter.allow("channel-2", now.Add(30*time.Second)), "the keys are limited separately")
+	assert.True(t, limiter.allow("channel-1", now.Add(time.Minute)))
+
+	limiter.forget("channel-2")
+	assert.True(t, limiter.allow("channel-2", now.Add(time.Minute)))
+
+	var disabled *warningLimiter
+	assert.True(t, disabled.allow("channel-1", now))
+	assert.True(t, disabled.allow("channel-1", now))
+}
+
+func TestHandleSession_UnconsumedSignals(t *testing.T) {
+	const channel = "agntcy/otel/channel-traces"
+
+	tt := componenttest.NewTelemetry()
+	t.Cleanup(func() { require.NoError(t, tt.Shutdown(context.Background())) })
+	sessions := slimcommon.NewSessionsList(slimconfig.SignalUnknown)
+	telemetry, err := newReceiverTelemetry(receiver.Settings{
+		ID:                component.MustNewID(TypeStr),
+		TelemetrySettings: tt.NewTelemetrySettings(),
+	}, sessions)
+	require.NoError(t, err)
+
+	sink := &consumertest.TracesSink{}
+	r := &slimReceiver{
+		config:             &Config{},
+		app:                testutil.NewFakeApp(),
+		sessions:           sessions,
+		tracesConsumer:     sink,
+		unconsumedWarnings: newWarningLimiter(unconsumedWarningInterval),
+		telemetry:          telemetry,
+	}
+
+	message := func(payload []byte, signal slimconfig.SignalType) slim.ReceivedMessage {
+		msg := slim.ReceivedMessage{Payload: payload}
+		msg.Context.Metadata = make(map[string]string)
+		slimcommon.AddSignal(msg.Context.Metadata, signal)
+		return msg
+	}
+	// the logs have no consumer
+	runSession(t, r, channel,
+		message(tracesPayload(t, "span"), slimconfig.SignalTraces),
+		message(logsPayload(t, "log-1"), slimconfig.SignalLogs),
+		message(logsPayload(t, "log-2"), slimconfig.SignalLogs),
+	)
+
+	assert.Equal(t, 1, sink.SpanCount())
+
+	m, err := tt.GetMetric(metricUnconsumed)
+	require.NoError(t, err)
+	sum, ok := m.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, int64(2), sum.DataPoints[0].Value)
+	signal, _ := sum.DataPoints[0].Attributes.Value(attribute.Key("signal"))
+	assert.Equal(t, "logs", signal.AsString())
+	_, err = tt.GetMetric(metricUnmarshalFailures)
+	assert.Error(t, err, "the unconsumed payloads are not unmarshal failures")
+}
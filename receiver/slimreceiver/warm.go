@@ -0,0 +1,190 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+)
+
+// parkedApps are the SLIM apps of the receivers shut down with a restart
+// grace period, by receiver name
+var (
+	parkedAppsMutex sync.Mutex
+	parkedApps      = make(map[string]*parkedApp)
+)
+
+// parkedApp keeps the SLIM app of a receiver that was shut down, with the
+// sessions it was invited to, and keeps accepting the invitations without
+// reading the sessions, until a receiver with the same name starts and takes
+// it over, e.g. when the collector pipelines restart on a configuration
+// reload, or until the grace period expires
+type parkedApp struct {
+	config   *Config
+	app      slimcommon.App
+	failover *slimcommon.Failover
+	sessions *slimcommon.SessionsList
+	logger   *zap.Logger
+
+	cancel context.CancelFunc
+	// closed when the listener stopped
+	done  chan struct{}
+	timer *time.Timer
+}
+
+// parkable reports whether the app is kept at shutdown for the restarted
+// receiver. A drained receiver leaves its channels for good, and the app of
+// a connection extension is destroyed with the extension.
+func (r *slimReceiver) parkable() bool {
+	return r.config.RestartGracePeriod > 0 && r.app != nil && !r.isDraining() && r.config.Connection == nil
+}
+
+// park keeps the app and the invited sessions of the receiver for the
+// restart grace period, instead of closing them. The handlers of the
+// sessions must be stopped. The channels created by the receiver are closed,
+// the restarted receiver creates them again.
+func (r *slimReceiver) park(ctx context.Context) {
+	logger := slimcommon.LoggerFromContextOrDefault(ctx)
+
+	for _, name := range r.sessions.ListSessionNames(ctx) {
+		if !r.ownsChannel(name) {
+			continue
+		}
+		if session, err := r.sessions.RemoveSessionByName(ctx, name); err == nil {
+			if err := r.app.DeleteSessionAndWait(session); err != nil {
+				logger.Warn("Failed to close the channel", zap.String("sessionName", name), zap.Error(err))
+			}
+		}
+	}
+
+	listenerCtx, cancel := context.WithCancel(context.Background())
+	p := &parkedApp{
+		config:   r.config,
+		app:      r.app,
+		failover: r.failover,
+		sessions: r.sessions,
+		logger:   logger,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	parkedAppsMutex.Lock()
+	// a receiver parked under the same name is replaced
+	previous := parkedApps[r.config.ReceiverName]
+	parkedApps[r.config.ReceiverName] = p
+	p.timer = time.AfterFunc(r.config.RestartGracePeriod, func() { p.expire(context.Background()) })
+	parkedAppsMutex.Unlock()
+	previous.close(ctx)
+
+	go p.listen(slimcommon.InitContextWithLogger(listenerCtx, logger))
+
+	logger.Info("SLIM app kept for the restart of the receiver",
+		zap.Duration("restart_grace_period", r.config.RestartGracePeriod),
+		zap.Int("sessions", len(r.sessions.ListSessionNames(ctx))))
+}
+
+// takeParkedApp returns the app parked by a receiver with the name of cfg,
+// with its listener stopped, or nil if there is none. A parked app that
+// does not match cfg, e.g. connected to other endpoints, is closed.
+func takeParkedApp(ctx context.Context, cfg *Config) *parkedApp {
+	parkedAppsMutex.Lock()
+	p, ok := parkedApps[cfg.ReceiverName]
+	if ok {
+		delete(parkedApps, cfg.ReceiverName)
+		p.timer.Stop()
+	}
+	parkedAppsMutex.Unlock()
+	if !ok {
+		return nil
+	}
+
+	if !p.matches(cfg) {
+		p.logger.Info("Closing the SLIM app kept for a receiver with another configuration")
+		p.close(ctx)
+		return nil
+	}
+
+	p.cancel()
+	<-p.done
+	return p
+}
+
+// matches reports whether the app can be taken over by a receiver with cfg
+func (p *parkedApp) matches(cfg *Config) bool {
+	return appDirection(p.config) == appDirection(cfg) &&
+		reflect.DeepEqual(p.config.endpoints(), cfg.endpoints()) &&
+		reflect.DeepEqual(p.config.identity(), cfg.identity())
+}
+
+// listen accepts the invitations until ctx is done, the sessions are handled
+// by the receiver taking the app over
+func (p *parkedApp) listen(ctx context.Context) {
+	defer close(p.done)
+
+	for session := range slimcommon.ListenSessions(ctx, p.app) {
+		if err := p.sessions.AddSession(ctx, session); err != nil {
+			p.logger.Info("Closing the session received while parked", zap.Error(err))
+			_ = p.app.DeleteSessionAndWait(session)
+			continue
+		}
+		p.logger.Info("Session received while the receiver restarts")
+	}
+}
+
+// expire closes the app if it was not taken over within the grace period
+func (p *parkedApp) expire(ctx context.Context) {
+	parkedAppsMutex.Lock()
+	if parkedApps[p.config.ReceiverName] != p {
+		parkedAppsMutex.Unlock()
+		return
+	}
+	delete(parkedApps, p.config.ReceiverName)
+	parkedAppsMutex.Unlock()
+
+	p.logger.Info("Restart grace period expired, closing the SLIM app")
+	p.close(ctx)
+}
+
+// close stops the listener, closes the sessions and destroys the app
+func (p *parkedApp) close(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+	p.sessions.DeleteAll(ctx, p.app)
+	p.app.Destroy()
+}
+
+// adopt takes over the app, the connection and the sessions of a parked
+// receiver, and returns the sessions to handle
+func (r *slimReceiver) adopt(ctx context.Context, p *parkedApp) []slimcommon.Session {
+	r.app = p.app
+	r.failover = p.failover
+	r.connID = p.failover.ConnID()
+
+	var sessions []slimcommon.Session
+	for _, session := range p.sessions.ListSessions(ctx) {
+		// the sessions received while parked are checked against the
+		// allowed sources of the new configuration
+		if err := r.checkSessionSource(session); err != nil {
+			handleRejectedSession(ctx, r, session, err)
+			continue
+		}
+		if err := r.sessions.AddSession(ctx, session); err != nil {
+			p.logger.Warn("Failed to take over a session", zap.Error(err))
+			_ = r.app.DeleteSessionAndWait(session)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	p.logger.Info("Took over the SLIM app of the restarted receiver", zap.Int("sessions", len(sessions)))
+	return sessions
+}
@@ -0,0 +1,121 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimreceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slimcommon "github.com/agntcy/slim-otel/internal/slim"
+	"github.com/agntcy/slim-otel/internal/testutil"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// newParkableReceiver returns a started receiver named name, with an invited
+// session and a channel it created
+func newParkableReceiver(t *testing.T, name string, gracePeriod time.Duration) (*slimReceiver, *testutil.FakeApp) {
+	t.Helper()
+	cfg := &Config{
+		ConnectionConfig:   &slimconfig.ConnectionConfig{Address: "http://127.0.0.1:46357"},
+		ReceiverName:       name,
+		SharedSecret:       "secret",
+		RestartGracePeriod: gracePeriod,
+		Channels: []ChannelsConfig{
+			{ChannelName: "agntcy/otel/owned", Participants: []string{"agntcy/otel/exporter"}},
+		},
+	}
+	app := testutil.NewFakeApp()
+	r := &slimReceiver{
+		config:   cfg,
+		app:      app,
+		failover: slimcommon.NewFailover(testutil.NewFakeConnector(), cfg.endpoints()),
+		sessions: slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+		draining: make(chan struct{}),
+	}
+	require.NoError(t, r.sessions.AddSession(t.Context(), testutil.NewFakeSession(1, "agntcy/otel/channel-1")))
+	require.NoError(t, r.sessions.AddSession(t.Context(), testutil.NewFakeSession(2, "agntcy/otel/owned")))
+	return r, app
+}
+
+// parkedSessions returns the names of the sessions of the app parked by the
+// receiver name
+func parkedSessions(t *testing.T, name string) []string {
+	t.Helper()
+	parkedAppsMutex.Lock()
+	defer parkedAppsMutex.Unlock()
+	if p, ok := parkedApps[name]; ok {
+		return p.sessions.ListSessionNames(t.Context())
+	}
+	return nil
+}
+
+func TestPark_TakeOver(t *testing.T) {
+	const name = "agntcy/otel/receiver-takeover"
+	r, app := newParkableReceiver(t, name, time.Minute)
+	require.True(t, r.parkable())
+	r.park(t.Context())
+
+	assert.Equal(t, []uint32{2}, app.DeletedSessions(), "the created channels are closed")
+
+	// invitations are accepted while the receiver restarts
+	app.Invite(testutil.NewFakeSession(3, "agntcy/otel/channel-2"))
+	require.Eventually(t, func() bool {
+		return len(parkedSessions(t, name)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	restarted := &slimReceiver{
+		config:   r.config,
+		sessions: slimcommon.NewSessionsList(slimconfig.SignalUnknown),
+	}
+	parked := takeParkedApp(t.Context(), restarted.config)
+	require.NotNil(t, parked)
+	adopted := restarted.adopt(t.Context(), parked)
+
+	assert.Len(t, adopted, 2)
+	assert.Same(t, app, restarted.app)
+	assert.ElementsMatch(t, []string{"agntcy/otel/channel-1", "agntcy/otel/channel-2"},
+		restarted.sessions.ListSessionNames(t.Context()))
+	assert.False(t, app.Destroyed())
+	assert.Nil(t, takeParkedApp(t.Context(), restarted.config), "the app is taken over once")
+}
+
+func TestPark_Expire(t *testing.T) {
+	const name = "agntcy/otel/receiver-expire"
+	r, app := newParkableReceiver(t, name, 50*time.Millisecond)
+	r.park(t.Context())
+
+	require.Eventually(t, app.Destroyed, 5*time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []uint32{1, 2}, app.DeletedSessions())
+	assert.Nil(t, takeParkedApp(t.Context(), r.config))
+}
+
+func TestTakeParkedApp_OtherConfig(t *testing.T) {
+	const name = "agntcy/otel/receiver-reconfigured"
+	r, app := newParkableReceiver(t, name, time.Minute)
+	r.park(t.Context())
+
+	cfg := *r.config
+	cfg.SharedSecret = "rotated"
+	assert.Nil(t, takeParkedApp(t.Context(), &cfg))
+	assert.True(t, app.Destroyed(), "an app the receiver cannot take over is closed")
+}
+
+func TestParkable(t *testing.T) {
+	r, _ := newParkableReceiver(t, "agntcy/otel/receiver-parkable", time.Minute)
+	assert.True(t, r.parkable())
+
+	r.config.RestartGracePeriod = 0
+	assert.False(t, r.parkable())
+
+	r.config.RestartGracePeriod = time.Minute
+	r.config.Connection = &slimConnectionID
+	assert.False(t, r.parkable(), "the app of a connection extension is destroyed with it")
+
+	r.config.Connection = nil
+	close(r.draining)
+	assert.False(t, r.parkable(), "a drained receiver leaves its channels")
+}
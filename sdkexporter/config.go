@@ -18,7 +18,7 @@ type Config struct {
 	ExporterNames *slimconfig.SignalNames `mapstructure:"exporter-names"`
 
 	// Shared Secret
-	SharedSecret string `mapstructure:"shared-secret"`
+	SharedSecret slimconfig.Opaque `mapstructure:"shared-secret"`
 }
 
 // Validate checks if the exporter configuration is valid
@@ -7,7 +7,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
 
 	sdklog "go.opentelemetry.io/otel/sdk/log"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
@@ -19,10 +18,6 @@ import (
 	"github.com/agntcy/slim-otel/slimconfig"
 )
 
-const (
-	sessionTimeoutMs = 1000
-)
-
 // Exporter coordinates trace, metric, and log exporters over a shared SLIM connection
 type Exporter struct {
 	config         *Config
@@ -41,14 +36,14 @@ func New(_ context.Context, config Config, opts ...Option) (*Exporter, error) {
 	}
 
 	// Initialize connection to SLIM
-	connID, err := slimcommon.InitAndConnect(*config.ConnectionConfig)
+	connID, err := slimcommon.NewConnector().Connect(*config.ConnectionConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to SLIM: %w", err)
 	}
 
 	// createdApps tracks apps that have been successfully created so they can
 	// be destroyed if a later step fails (resource leak prevention).
-	var createdApps []*slim.App
+	var createdApps []slimcommon.App
 	cleanup := func() {
 		for _, app := range createdApps {
 			app.Destroy()
@@ -56,7 +51,7 @@ func New(_ context.Context, config Config, opts ...Option) (*Exporter, error) {
 	}
 
 	// Create SLIM app for traces
-	traceApp, err := slimcommon.CreateApp(*config.ExporterNames.Traces, config.SharedSecret, connID, slim.DirectionSend)
+	traceApp, err := slimcommon.CreateApp(*config.ExporterNames.Traces, string(config.SharedSecret), connID, slim.DirectionSend)
 	if err != nil {
 		cleanup()
 		return nil, fmt.Errorf("failed to create SLIM app for traces: %w", err)
@@ -64,7 +59,7 @@ func New(_ context.Context, config Config, opts ...Option) (*Exporter, error) {
 	createdApps = append(createdApps, traceApp)
 
 	// Create SLIM app for metrics
-	metricApp, err := slimcommon.CreateApp(*config.ExporterNames.Metrics, config.SharedSecret, connID, slim.DirectionSend)
+	metricApp, err := slimcommon.CreateApp(*config.ExporterNames.Metrics, string(config.SharedSecret), connID, slim.DirectionSend)
 	if err != nil {
 		cleanup()
 		return nil, fmt.Errorf("failed to create SLIM app for metrics: %w", err)
@@ -72,7 +67,7 @@ func New(_ context.Context, config Config, opts ...Option) (*Exporter, error) {
 	createdApps = append(createdApps, metricApp)
 
 	// Create SLIM app for logs
-	logApp, err := slimcommon.CreateApp(*config.ExporterNames.Logs, config.SharedSecret, connID, slim.DirectionSend)
+	logApp, err := slimcommon.CreateApp(*config.ExporterNames.Logs, string(config.SharedSecret), connID, slim.DirectionSend)
 	if err != nil {
 		cleanup()
 		return nil, fmt.Errorf("failed to create SLIM app for logs: %w", err)
@@ -177,29 +172,15 @@ func (e *Exporter) Shutdown(ctx context.Context) error {
 }
 
 // startSessionListener starts a goroutine to listen for incoming sessions
-func (e *Exporter) startSessionListener(listenerCtx context.Context, app *slim.App, sessions *slimcommon.SessionsList) {
+func (e *Exporter) startSessionListener(listenerCtx context.Context, app slimcommon.App, sessions *slimcommon.SessionsList) {
 	go func() {
-		for {
-			select {
-			case <-listenerCtx.Done():
-				return
-			default:
-			}
-
-			timeout := time.Millisecond * sessionTimeoutMs
-			session, err := app.ListenForSession(&timeout)
-			if err != nil {
-				// Timeout is expected, just continue
-				continue
-			}
-
+		for session := range slimcommon.ListenSessions(listenerCtx, app) {
 			// Add the new session
 			if err := sessions.AddSession(listenerCtx, session); err != nil {
 				slimcommon.LoggerFromContextOrDefault(listenerCtx).Warn(
 					"failed to add session, continuing to listen",
 					zap.Error(err),
 				)
-				continue
 			}
 		}
 	}()
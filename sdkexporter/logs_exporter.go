@@ -12,14 +12,13 @@ import (
 	collectorlogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
 	"google.golang.org/protobuf/proto"
 
-	slim "github.com/agntcy/slim-bindings-go"
 	slimcommon "github.com/agntcy/slim-otel/internal/slim"
 	"github.com/agntcy/slim-otel/sdkexporter/internal/otlp/logtransform"
 )
 
 // LogExporter exports logs to SLIM
 type LogExporter struct {
-	app      *slim.App
+	app      slimcommon.App
 	sessions *slimcommon.SessionsList
 	provider *sdklog.LoggerProvider
 	mu       sync.RWMutex
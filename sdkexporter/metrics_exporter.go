@@ -14,14 +14,13 @@ import (
 	mpb "go.opentelemetry.io/proto/otlp/metrics/v1"
 	"google.golang.org/protobuf/proto"
 
-	slim "github.com/agntcy/slim-bindings-go"
 	slimcommon "github.com/agntcy/slim-otel/internal/slim"
 	"github.com/agntcy/slim-otel/sdkexporter/internal/otlp/metrictransform"
 )
 
 // MetricExporter exports metrics to SLIM
 type MetricExporter struct {
-	app                 *slim.App
+	app                 slimcommon.App
 	sessions            *slimcommon.SessionsList
 	provider            *sdkmetric.MeterProvider
 	temporalitySelector sdkmetric.TemporalitySelector
@@ -14,7 +14,6 @@ import (
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 	"google.golang.org/protobuf/proto"
 
-	slim "github.com/agntcy/slim-bindings-go"
 	slimcommon "github.com/agntcy/slim-otel/internal/slim"
 	"github.com/agntcy/slim-otel/slimconfig"
 )
@@ -22,7 +21,7 @@ import (
 // slimTraceClient implements otlptrace.Client.
 // It manages SLIM sessions and serializes trace data for export.
 type slimTraceClient struct {
-	app      *slim.App
+	app      slimcommon.App
 	sessions *slimcommon.SessionsList
 }
 
@@ -64,7 +63,7 @@ func (c *slimTraceClient) UploadTraces(ctx context.Context, protoSpans []*tracep
 }
 
 // newTraceExporter creates a TraceExporter backed by the given SLIM app.
-func newTraceExporter(app *slim.App) (*TraceExporter, error) {
+func newTraceExporter(app slimcommon.App) (*TraceExporter, error) {
 	client := &slimTraceClient{
 		app:      app,
 		sessions: slimcommon.NewSessionsList(slimconfig.SignalTraces),
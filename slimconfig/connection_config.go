@@ -104,7 +104,7 @@ type TLSCertKeySource struct {
 	CertData *string `mapstructure:"cert_data"`
 
 	// PEM encoded key data (for PEM-based source)
-	KeyData *string `mapstructure:"key_data"`
+	KeyData *Opaque `mapstructure:"key_data"`
 }
 
 // AuthConfig defines authentication configuration
@@ -128,7 +128,7 @@ type BasicAuthConfig struct {
 	Username string `mapstructure:"username"`
 
 	// Password for basic authentication
-	Password string `mapstructure:"password"`
+	Password Opaque `mapstructure:"password"`
 }
 
 // StaticJwtAuthConfig defines static JWT authentication configuration
@@ -176,7 +176,7 @@ type JWTKeySource struct {
 	File string `mapstructure:"file"`
 
 	// Inline key data (PEM encoded private key or secret)
-	Data string `mapstructure:"data"`
+	Data Opaque `mapstructure:"data"`
 }
 
 // KeepaliveConfig defines keepalive configuration
@@ -206,7 +206,7 @@ type ProxyConfig struct {
 	Username *string `mapstructure:"username"`
 
 	// Password for proxy authentication (optional)
-	Password *string `mapstructure:"password"`
+	Password *Opaque `mapstructure:"password"`
 
 	// Additional headers to send to the proxy
 	Headers map[string]string `mapstructure:"headers"`
@@ -689,7 +689,7 @@ func (cfg *TLSCertKeySource) toSlimTLSSource() (slim.TlsSource, error) {
 		}
 		return slim.TlsSourcePem{
 			Cert: *cfg.CertData,
-			Key:  *cfg.KeyData,
+			Key:  string(*cfg.KeyData),
 		}, nil
 	}
 
@@ -711,7 +711,7 @@ func (cfg *ProxyConfig) toSlimProxyConfig() (slim.ProxyConfig, error) {
 	proxyCfg := slim.ProxyConfig{
 		Url:      cfg.URL,
 		Username: cfg.Username,
-		Password: cfg.Password,
+		Password: opaquePtr(cfg.Password),
 		Headers:  cfg.Headers,
 	}
 
@@ -736,7 +736,7 @@ func (cfg *AuthConfig) toSlimAuthConfig() (slim.ClientAuthenticationConfig, erro
 		return slim.ClientAuthenticationConfigBasic{
 			Config: slim.BasicAuth{
 				Username: cfg.Basic.Username,
-				Password: cfg.Basic.Password,
+				Password: string(cfg.Basic.Password),
 			},
 		}, nil
 
@@ -755,50 +755,10 @@ func (cfg *AuthConfig) toSlimAuthConfig() (slim.ClientAuthenticationConfig, erro
 		if cfg.Jwt == nil {
 			return nil, errors.New("JWT configuration is required")
 		}
-		if cfg.Jwt.Key == nil {
-			return nil, errors.New("JWT key configuration is required for jwt auth")
-		}
-
-		// Parse JWT algorithm
-		algorithm, err := parseJWTAlgorithm(cfg.Jwt.Key.Algorithm)
-		if err != nil {
-			return nil, fmt.Errorf("invalid JWT algorithm: %w", err)
-		}
-
-		// Parse JWT key format
-		format, err := parseJWTKeyFormat(cfg.Jwt.Key.Format)
+		clientJwtAuth, err := cfg.Jwt.toSlimClientJwtAuth()
 		if err != nil {
-			return nil, fmt.Errorf("invalid JWT key format: %w", err)
+			return nil, err
 		}
-
-		// Parse JWT key data
-		keyData, err := cfg.Jwt.Key.Key.toSlimJWTKeyData()
-		if err != nil {
-			return nil, fmt.Errorf("invalid JWT key data: %w", err)
-		}
-
-		// Parse JWT key type (with JwtKeyConfig inside) - always use encoding
-		keyType, err := parseJWTKeyType("encoding", algorithm, format, keyData)
-		if err != nil {
-			return nil, fmt.Errorf("invalid JWT key type: %w", err)
-		}
-
-		clientJwtAuth := slim.ClientJwtAuth{
-			Duration: cfg.Jwt.Duration,
-			Key:      keyType,
-		}
-
-		// Add claims if provided
-		if len(cfg.Jwt.Audience) > 0 {
-			clientJwtAuth.Audience = &cfg.Jwt.Audience
-		}
-		if cfg.Jwt.Issuer != "" {
-			clientJwtAuth.Issuer = &cfg.Jwt.Issuer
-		}
-		if cfg.Jwt.Subject != "" {
-			clientJwtAuth.Subject = &cfg.Jwt.Subject
-		}
-
 		return slim.ClientAuthenticationConfigJwt{
 			Config: clientJwtAuth,
 		}, nil
@@ -811,6 +771,56 @@ func (cfg *AuthConfig) toSlimAuthConfig() (slim.ClientAuthenticationConfig, erro
 	}
 }
 
+// toSlimClientJwtAuth converts JwtAuthConfig to slim.ClientJwtAuth, signing
+// the tokens with the configured key
+func (cfg *JwtAuthConfig) toSlimClientJwtAuth() (slim.ClientJwtAuth, error) {
+	if cfg.Key == nil {
+		return slim.ClientJwtAuth{}, errors.New("JWT key configuration is required for jwt auth")
+	}
+
+	// Parse JWT algorithm
+	algorithm, err := parseJWTAlgorithm(cfg.Key.Algorithm)
+	if err != nil {
+		return slim.ClientJwtAuth{}, fmt.Errorf("invalid JWT algorithm: %w", err)
+	}
+
+	// Parse JWT key format
+	format, err := parseJWTKeyFormat(cfg.Key.Format)
+	if err != nil {
+		return slim.ClientJwtAuth{}, fmt.Errorf("invalid JWT key format: %w", err)
+	}
+
+	// Parse JWT key data
+	keyData, err := cfg.Key.Key.toSlimJWTKeyData()
+	if err != nil {
+		return slim.ClientJwtAuth{}, fmt.Errorf("invalid JWT key data: %w", err)
+	}
+
+	// Parse JWT key type (with JwtKeyConfig inside) - always use encoding
+	keyType, err := parseJWTKeyType("encoding", algorithm, format, keyData)
+	if err != nil {
+		return slim.ClientJwtAuth{}, fmt.Errorf("invalid JWT key type: %w", err)
+	}
+
+	clientJwtAuth := slim.ClientJwtAuth{
+		Duration: cfg.Duration,
+		Key:      keyType,
+	}
+
+	// Add claims if provided
+	if len(cfg.Audience) > 0 {
+		clientJwtAuth.Audience = &cfg.Audience
+	}
+	if cfg.Issuer != "" {
+		clientJwtAuth.Issuer = &cfg.Issuer
+	}
+	if cfg.Subject != "" {
+		clientJwtAuth.Subject = &cfg.Subject
+	}
+
+	return clientJwtAuth, nil
+}
+
 // parseJWTAlgorithm converts string algorithm to slim.JwtAlgorithm
 func parseJWTAlgorithm(algorithm string) (slim.JwtAlgorithm, error) {
 	switch algorithm {
@@ -893,7 +903,7 @@ func (cfg *JWTKeySource) toSlimJWTKeyData() (slim.JwtKeyData, error) {
 	}
 
 	if cfg.Data != "" {
-		return slim.JwtKeyDataData{Value: cfg.Data}, nil
+		return slim.JwtKeyDataData{Value: string(cfg.Data)}, nil
 	}
 
 	return nil, errors.New("either 'file' or 'data' must be specified for JWT key")
@@ -18,6 +18,10 @@ func strPtr(s string) *string {
 	return &s
 }
 
+func secretPtr(s Opaque) *Opaque {
+	return &s
+}
+
 func TestConnectionConfig_Validate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -220,7 +224,7 @@ func TestTLSConfig_Validate(t *testing.T) {
 				Insecure: false,
 				Source: &TLSCertKeySource{
 					CertData: strPtr("CERT PEM"),
-					KeyData:  strPtr("KEY PEM"),
+					KeyData:  secretPtr("KEY PEM"),
 				},
 			},
 			wantErr: false,
@@ -558,7 +562,7 @@ func TestProxyConfig_Validate(t *testing.T) {
 			config: ProxyConfig{
 				URL:      strPtr("http://proxy.example.com:8080"),
 				Username: strPtr("user"),
-				Password: strPtr("pass"),
+				Password: secretPtr("pass"),
 			},
 			wantErr: false,
 		},
@@ -566,7 +570,7 @@ func TestProxyConfig_Validate(t *testing.T) {
 			name: "proxy auth without URL",
 			config: ProxyConfig{
 				Username: strPtr("user"),
-				Password: strPtr("pass"),
+				Password: secretPtr("pass"),
 			},
 			wantErr: true,
 			errMsg:  "proxy URL is required when username or password is specified",
@@ -803,7 +807,7 @@ func TestTLSCertKeySource_ToSlimTLSSource(t *testing.T) {
 			name: "PEM source",
 			config: TLSCertKeySource{
 				CertData: strPtr("CERT PEM"),
-				KeyData:  strPtr("KEY PEM"),
+				KeyData:  secretPtr("KEY PEM"),
 			},
 			expected: slim.TlsSourcePem{
 				Cert: "CERT PEM",
@@ -839,7 +843,7 @@ func TestTLSCertKeySource_ToSlimTLSSource(t *testing.T) {
 		{
 			name: "missing cert data",
 			config: TLSCertKeySource{
-				KeyData: strPtr("KEY PEM"),
+				KeyData: secretPtr("KEY PEM"),
 			},
 			wantErr: true,
 			errMsg:  "cert_data is required",
@@ -1150,7 +1154,7 @@ func TestConnectionConfig_ToSlimClientConfig(t *testing.T) {
 			Proxy: &ProxyConfig{
 				URL:      &proxyURL,
 				Username: strPtr("proxyuser"),
-				Password: strPtr("proxypass"),
+				Password: secretPtr("proxypass"),
 			},
 		}
 
@@ -1351,7 +1355,7 @@ func TestTLSConfig_ToSlimTLSConfig(t *testing.T) {
 			Insecure: false,
 			Source: &TLSCertKeySource{
 				CertData: strPtr("CERT PEM DATA"),
-				KeyData:  strPtr("KEY PEM DATA"),
+				KeyData:  secretPtr("KEY PEM DATA"),
 			},
 		}
 
@@ -1393,7 +1397,7 @@ func TestProxyConfig_ToSlimProxyConfig(t *testing.T) {
 		config := ProxyConfig{
 			URL:      &url,
 			Username: strPtr("proxyuser"),
-			Password: strPtr("proxypass"),
+			Password: secretPtr("proxypass"),
 		}
 
 		result, err := config.toSlimProxyConfig()
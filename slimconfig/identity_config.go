@@ -0,0 +1,233 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimconfig
+
+import (
+	"errors"
+	"fmt"
+
+	slim "github.com/agntcy/slim-bindings-go"
+)
+
+// IdentityConfig defines how a SLIM app proves its identity to the other
+// participants and verifies theirs. Unlike AuthConfig, which authenticates
+// the connection to the SLIM node, it applies to the sessions of the app.
+type IdentityConfig struct {
+	// Type of identity: "shared_secret", "static_jwt", "jwt" or "spire"
+	Type string `mapstructure:"type"`
+
+	// Shared secret for the "shared_secret" type
+	SharedSecret Opaque `mapstructure:"shared_secret"`
+
+	// Token file for the "static_jwt" type
+	StaticJwt *StaticJwtAuthConfig `mapstructure:"static_jwt"`
+
+	// Signing key and claims for the "jwt" type
+	Jwt *JwtAuthConfig `mapstructure:"jwt"`
+
+	// Verification of the JWTs of the other participants, for the
+	// "static_jwt" and "jwt" types. Without a key, the verification key is
+	// resolved from the issuer
+	Verification *JwtAuthConfig `mapstructure:"verification"`
+
+	// SPIFFE Workload API settings for the "spire" type
+	Spire *SpireConfig `mapstructure:"spire"`
+}
+
+// SpireConfig defines the SPIRE identity configuration
+type SpireConfig struct {
+	// Path to the SPIFFE Workload API socket. Empty uses the
+	// SPIFFE_ENDPOINT_SOCKET environment variable
+	SocketPath string `mapstructure:"socket_path"`
+
+	// SPIFFE ID requested as the target of the JWT SVIDs (optional)
+	TargetSpiffeID string `mapstructure:"target_spiffe_id"`
+
+	// Audiences requested and verified for the JWT SVIDs
+	JwtAudiences []string `mapstructure:"jwt_audiences"`
+
+	// Trust domains of the X.509 bundles (optional)
+	TrustDomains []string `mapstructure:"trust_domains"`
+}
+
+// Validate checks if the identity configuration is valid
+func (cfg *IdentityConfig) Validate() error {
+	switch cfg.Type {
+	case "":
+		return errors.New("identity type is required")
+	case "shared_secret":
+		if cfg.SharedSecret == "" {
+			return errors.New("shared secret is required for shared_secret identity")
+		}
+	case "static_jwt":
+		if err := validateAuthConfig(&AuthConfig{Type: cfg.Type, StaticJwt: cfg.StaticJwt}); err != nil {
+			return err
+		}
+	case "jwt":
+		if err := validateAuthConfig(&AuthConfig{Type: cfg.Type, Jwt: cfg.Jwt}); err != nil {
+			return err
+		}
+	case "spire":
+		// the SPIRE settings are optional
+	default:
+		return fmt.Errorf("unsupported identity type: %s", cfg.Type)
+	}
+
+	if cfg.Verification != nil && cfg.Verification.Key != nil {
+		if cfg.Verification.Key.Algorithm == "" {
+			return errors.New("JWT verification key algorithm is required")
+		}
+		if cfg.Verification.Key.Key == nil {
+			return errors.New("JWT verification key source is required")
+		}
+	}
+
+	return nil
+}
+
+// ToIdentityProviderConfig converts the IdentityConfig to the identity
+// provider of the app named id
+func (cfg *IdentityConfig) ToIdentityProviderConfig(id string) (slim.IdentityProviderConfig, error) {
+	switch cfg.Type {
+	case "shared_secret":
+		return slim.IdentityProviderConfigSharedSecret{
+			Id:   id,
+			Data: string(cfg.SharedSecret),
+		}, nil
+
+	case "static_jwt":
+		if cfg.StaticJwt == nil {
+			return nil, errors.New("static JWT configuration is required")
+		}
+		return slim.IdentityProviderConfigStaticJwt{
+			Config: slim.StaticJwtAuth{
+				TokenFile: cfg.StaticJwt.TokenFile,
+				Duration:  cfg.StaticJwt.Duration,
+			},
+		}, nil
+
+	case "jwt":
+		if cfg.Jwt == nil {
+			return nil, errors.New("JWT configuration is required")
+		}
+		clientJwtAuth, err := cfg.Jwt.toSlimClientJwtAuth()
+		if err != nil {
+			return nil, err
+		}
+		return slim.IdentityProviderConfigJwt{
+			Config: clientJwtAuth,
+		}, nil
+
+	case "spire":
+		return slim.IdentityProviderConfigSpire{
+			Config: cfg.Spire.toSlimSpireConfig(),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown identity type: %s", cfg.Type)
+	}
+}
+
+// ToIdentityVerifierConfig converts the IdentityConfig to the identity
+// verifier of the app named id
+func (cfg *IdentityConfig) ToIdentityVerifierConfig(id string) (slim.IdentityVerifierConfig, error) {
+	switch cfg.Type {
+	case "shared_secret":
+		return slim.IdentityVerifierConfigSharedSecret{
+			Id:   id,
+			Data: string(cfg.SharedSecret),
+		}, nil
+
+	case "static_jwt", "jwt":
+		jwtAuth, err := cfg.Verification.toSlimJwtAuth()
+		if err != nil {
+			return nil, err
+		}
+		return slim.IdentityVerifierConfigJwt{
+			Config: jwtAuth,
+		}, nil
+
+	case "spire":
+		return slim.IdentityVerifierConfigSpire{
+			Config: cfg.Spire.toSlimSpireConfig(),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown identity type: %s", cfg.Type)
+	}
+}
+
+// toSlimJwtAuth converts JwtAuthConfig to slim.JwtAuth, verifying the tokens
+// with the configured key, or with the key resolved from the issuer if none
+func (cfg *JwtAuthConfig) toSlimJwtAuth() (slim.JwtAuth, error) {
+	if cfg == nil {
+		return slim.JwtAuth{Key: slim.JwtKeyTypeAutoresolve{}}, nil
+	}
+
+	jwtAuth := slim.JwtAuth{
+		Duration: cfg.Duration,
+		Key:      slim.JwtKeyTypeAutoresolve{},
+	}
+
+	if cfg.Key != nil {
+		algorithm, err := parseJWTAlgorithm(cfg.Key.Algorithm)
+		if err != nil {
+			return slim.JwtAuth{}, fmt.Errorf("invalid JWT algorithm: %w", err)
+		}
+
+		format, err := parseJWTKeyFormat(cfg.Key.Format)
+		if err != nil {
+			return slim.JwtAuth{}, fmt.Errorf("invalid JWT key format: %w", err)
+		}
+
+		keyData, err := cfg.Key.Key.toSlimJWTKeyData()
+		if err != nil {
+			return slim.JwtAuth{}, fmt.Errorf("invalid JWT key data: %w", err)
+		}
+
+		jwtAuth.Key, err = parseJWTKeyType("decoding", algorithm, format, keyData)
+		if err != nil {
+			return slim.JwtAuth{}, fmt.Errorf("invalid JWT key type: %w", err)
+		}
+	}
+
+	// Add the expected claims if provided
+	if len(cfg.Audience) > 0 {
+		jwtAuth.Audience = &cfg.Audience
+	}
+	if cfg.Issuer != "" {
+		jwtAuth.Issuer = &cfg.Issuer
+	}
+	if cfg.Subject != "" {
+		jwtAuth.Subject = &cfg.Subject
+	}
+
+	return jwtAuth, nil
+}
+
+// toSlimSpireConfig converts SpireConfig to slim.SpireConfig, a nil
+// configuration using the defaults
+func (cfg *SpireConfig) toSlimSpireConfig() slim.SpireConfig {
+	spireCfg := slim.SpireConfig{
+		JwtAudiences: []string{},
+		TrustDomains: []string{},
+	}
+	if cfg == nil {
+		return spireCfg
+	}
+
+	if cfg.SocketPath != "" {
+		spireCfg.SocketPath = &cfg.SocketPath
+	}
+	if cfg.TargetSpiffeID != "" {
+		spireCfg.TargetSpiffeId = &cfg.TargetSpiffeID
+	}
+	if len(cfg.JwtAudiences) > 0 {
+		spireCfg.JwtAudiences = cfg.JwtAudiences
+	}
+	if len(cfg.TrustDomains) > 0 {
+		spireCfg.TrustDomains = cfg.TrustDomains
+	}
+	return spireCfg
+}
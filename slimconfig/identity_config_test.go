@@ -0,0 +1,210 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slim "github.com/agntcy/slim-bindings-go"
+)
+
+func TestIdentityConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  IdentityConfig
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "valid shared secret",
+			config:  IdentityConfig{Type: "shared_secret", SharedSecret: "secret"},
+			wantErr: false,
+		},
+		{
+			name:    "shared secret missing secret",
+			config:  IdentityConfig{Type: "shared_secret"},
+			wantErr: true,
+			errMsg:  "shared secret is required",
+		},
+		{
+			name: "valid static jwt",
+			config: IdentityConfig{
+				Type:      "static_jwt",
+				StaticJwt: &StaticJwtAuthConfig{TokenFile: "/path/to/token"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "static jwt missing token file",
+			config:  IdentityConfig{Type: "static_jwt", StaticJwt: &StaticJwtAuthConfig{}},
+			wantErr: true,
+			errMsg:  "token file is required",
+		},
+		{
+			name: "valid jwt",
+			config: IdentityConfig{
+				Type: "jwt",
+				Jwt: &JwtAuthConfig{
+					Audience: []string{"slim"},
+					Key: &JWTKeyConfig{
+						Algorithm: "ES256",
+						Format:    "pem",
+						Key:       &JWTKeySource{File: "/path/to/key.pem"},
+					},
+				},
+				Verification: &JwtAuthConfig{
+					Key: &JWTKeyConfig{
+						Algorithm: "ES256",
+						Format:    "pem",
+						Key:       &JWTKeySource{File: "/path/to/pub.pem"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "jwt missing config",
+			config:  IdentityConfig{Type: "jwt"},
+			wantErr: true,
+			errMsg:  "JWT configuration is required",
+		},
+		{
+			name: "verification key missing algorithm",
+			config: IdentityConfig{
+				Type:         "static_jwt",
+				StaticJwt:    &StaticJwtAuthConfig{TokenFile: "/path/to/token"},
+				Verification: &JwtAuthConfig{Key: &JWTKeyConfig{Key: &JWTKeySource{File: "/path/to/pub.pem"}}},
+			},
+			wantErr: true,
+			errMsg:  "JWT verification key algorithm is required",
+		},
+		{
+			name:    "valid spire with defaults",
+			config:  IdentityConfig{Type: "spire"},
+			wantErr: false,
+		},
+		{
+			name:    "missing type",
+			config:  IdentityConfig{},
+			wantErr: true,
+			errMsg:  "identity type is required",
+		},
+		{
+			name:    "unsupported type",
+			config:  IdentityConfig{Type: "basic"},
+			wantErr: true,
+			errMsg:  "unsupported identity type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIdentityConfig_ToIdentityConfigs(t *testing.T) {
+	const id = "agntcy/otel/receiver"
+
+	t.Run("shared secret", func(t *testing.T) {
+		cfg := IdentityConfig{Type: "shared_secret", SharedSecret: "secret"}
+
+		provider, err := cfg.ToIdentityProviderConfig(id)
+		require.NoError(t, err)
+		assert.Equal(t, slim.IdentityProviderConfigSharedSecret{Id: id, Data: "secret"}, provider)
+
+		verifier, err := cfg.ToIdentityVerifierConfig(id)
+		require.NoError(t, err)
+		assert.Equal(t, slim.IdentityVerifierConfigSharedSecret{Id: id, Data: "secret"}, verifier)
+	})
+
+	t.Run("static jwt with resolved verification key", func(t *testing.T) {
+		cfg := IdentityConfig{
+			Type:         "static_jwt",
+			StaticJwt:    &StaticJwtAuthConfig{TokenFile: "/path/to/token", Duration: time.Minute},
+			Verification: &JwtAuthConfig{Issuer: "https://issuer"},
+		}
+
+		provider, err := cfg.ToIdentityProviderConfig(id)
+		require.NoError(t, err)
+		assert.Equal(t, slim.IdentityProviderConfigStaticJwt{
+			Config: slim.StaticJwtAuth{TokenFile: "/path/to/token", Duration: time.Minute},
+		}, provider)
+
+		verifier, err := cfg.ToIdentityVerifierConfig(id)
+		require.NoError(t, err)
+		jwtVerifier, ok := verifier.(slim.IdentityVerifierConfigJwt)
+		require.True(t, ok)
+		assert.Equal(t, slim.JwtKeyTypeAutoresolve{}, jwtVerifier.Config.Key)
+		require.NotNil(t, jwtVerifier.Config.Issuer)
+		assert.Equal(t, "https://issuer", *jwtVerifier.Config.Issuer)
+	})
+
+	t.Run("jwt", func(t *testing.T) {
+		cfg := IdentityConfig{
+			Type: "jwt",
+			Jwt: &JwtAuthConfig{
+				Audience: []string{"slim"},
+				Key: &JWTKeyConfig{
+					Algorithm: "ES256",
+					Format:    "pem",
+					Key:       &JWTKeySource{File: "/path/to/key.pem"},
+				},
+			},
+			Verification: &JwtAuthConfig{
+				Key: &JWTKeyConfig{
+					Algorithm: "ES256",
+					Format:    "pem",
+					Key:       &JWTKeySource{File: "/path/to/pub.pem"},
+				},
+			},
+		}
+
+		provider, err := cfg.ToIdentityProviderConfig(id)
+		require.NoError(t, err)
+		jwtProvider, ok := provider.(slim.IdentityProviderConfigJwt)
+		require.True(t, ok)
+		assert.IsType(t, slim.JwtKeyTypeEncoding{}, jwtProvider.Config.Key)
+
+		verifier, err := cfg.ToIdentityVerifierConfig(id)
+		require.NoError(t, err)
+		jwtVerifier, ok := verifier.(slim.IdentityVerifierConfigJwt)
+		require.True(t, ok)
+		assert.Equal(t, slim.JwtKeyTypeDecoding{Key: slim.JwtKeyConfig{
+			Algorithm: slim.JwtAlgorithmEs256,
+			Format:    slim.JwtKeyFormatPem,
+			Key:       slim.JwtKeyDataFile{Path: "/path/to/pub.pem"},
+		}}, jwtVerifier.Config.Key)
+	})
+
+	t.Run("spire", func(t *testing.T) {
+		cfg := IdentityConfig{
+			Type:  "spire",
+			Spire: &SpireConfig{SocketPath: "unix:///run/spire/agent.sock", JwtAudiences: []string{"slim"}},
+		}
+
+		provider, err := cfg.ToIdentityProviderConfig(id)
+		require.NoError(t, err)
+		spireProvider, ok := provider.(slim.IdentityProviderConfigSpire)
+		require.True(t, ok)
+		require.NotNil(t, spireProvider.Config.SocketPath)
+		assert.Equal(t, "unix:///run/spire/agent.sock", *spireProvider.Config.SocketPath)
+		assert.Equal(t, []string{"slim"}, spireProvider.Config.JwtAudiences)
+
+		verifier, err := cfg.ToIdentityVerifierConfig(id)
+		require.NoError(t, err)
+		assert.IsType(t, slim.IdentityVerifierConfigSpire{}, verifier)
+	})
+}
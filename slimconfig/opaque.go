@@ -0,0 +1,42 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimconfig
+
+import "fmt"
+
+// redacted replaces the value of an Opaque string when it is printed
+const redacted = "[REDACTED]"
+
+// Opaque is a configuration string holding a secret, e.g. a shared secret, a
+// password or a private key. Like the collector configopaque.String, its
+// value is redacted when it is printed, logged or marshaled as text, e.g. in
+// the effective configuration dumped by the collector, and it is decoded like
+// a string. slimconfig does not depend on the collector so that it can be
+// used by the SDK exporter.
+type Opaque string
+
+// String returns the redacted value
+func (s Opaque) String() string {
+	return redacted
+}
+
+// GoString returns the redacted value, for the %#v format
+func (s Opaque) GoString() string {
+	return fmt.Sprintf("%#v", redacted)
+}
+
+// MarshalText returns the redacted value
+func (s Opaque) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
+
+// opaquePtr converts an optional secret to the string pointer of the SLIM
+// bindings
+func opaquePtr(s *Opaque) *string {
+	if s == nil {
+		return nil
+	}
+	value := string(*s)
+	return &value
+}
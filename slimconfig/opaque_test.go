@@ -0,0 +1,36 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package slimconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpaque(t *testing.T) {
+	secret := Opaque("a-very-long-shared-secret")
+
+	for _, format := range []string{"%v", "%+v", "%s", "%q", "%x"} {
+		assert.NotContains(t, fmt.Sprintf(format, secret), "shared", format)
+	}
+	assert.Equal(t, "[REDACTED]", secret.String())
+	assert.Equal(t, `"[REDACTED]"`, fmt.Sprintf("%#v", secret))
+
+	cfg := IdentityConfig{Type: "shared_secret", SharedSecret: secret}
+	assert.NotContains(t, fmt.Sprintf("%+v", cfg), "shared-secret")
+	assert.NotContains(t, fmt.Sprintf("%#v", cfg), "shared-secret")
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"SharedSecret":"[REDACTED]"`)
+
+	// the value is kept for the SLIM bindings
+	assert.Equal(t, "a-very-long-shared-secret", string(secret))
+	assert.Nil(t, opaquePtr(nil))
+	assert.Equal(t, "a-very-long-shared-secret", *opaquePtr(&secret))
+}
@@ -0,0 +1,128 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build e2e
+
+package e2e
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// channel is the channel of the channel manager configuration
+	channel = "agntcy/otel/e2e-channel"
+	// receiverName is the name of the slim receiver of the collector
+	receiverName = "agntcy/otel/e2e-receiver"
+
+	// deliveryTimeout is the maximum wait for the delivery of the spans,
+	// including the time for the collector to join the channel
+	deliveryTimeout = time.Minute
+)
+
+func TestDelivery(t *testing.T) {
+	sink := newTraceSink(t)
+	startChannelManager(t)
+	startCollector(t, sink)
+
+	requireDelivery(t, sink, t.Name(), deliveryTimeout)
+
+	var details struct {
+		Channel      string `json:"channel"`
+		Participants []struct {
+			Name string `json:"name"`
+		} `json:"participants"`
+	}
+	cmctl(t, &details, "channel", "get", channel)
+	names := make([]string, 0, len(details.Participants))
+	for _, p := range details.Participants {
+		names = append(names, p.Name)
+	}
+	assert.Contains(t, names, receiverName)
+}
+
+func TestChannelLifecycle(t *testing.T) {
+	const lifecycleChannel = "agntcy/otel/e2e-lifecycle"
+	sink := newTraceSink(t)
+	startChannelManager(t)
+	startCollector(t, sink)
+
+	var channels struct {
+		Channels []string `json:"channels"`
+	}
+	var participants struct {
+		Participants []string `json:"participants"`
+	}
+
+	cmctl(t, nil, "channel", "create", lifecycleChannel)
+	cmctl(t, &channels, "channel", "list")
+	assert.ElementsMatch(t, []string{channel, lifecycleChannel}, channels.Channels)
+
+	cmctl(t, nil, "participant", "add", lifecycleChannel, receiverName)
+	cmctl(t, &participants, "participant", "list", lifecycleChannel)
+	assert.Contains(t, participants.Participants, receiverName)
+
+	cmctl(t, nil, "participant", "remove", lifecycleChannel, receiverName)
+	cmctl(t, &participants, "participant", "list", lifecycleChannel)
+	assert.NotContains(t, participants.Participants, receiverName)
+
+	cmctl(t, nil, "channel", "delete", lifecycleChannel)
+	cmctl(t, &channels, "channel", "list")
+	assert.Equal(t, []string{channel}, channels.Channels)
+
+	// the channel of the configuration still delivers the spans
+	requireDelivery(t, sink, t.Name(), deliveryTimeout)
+}
+
+func TestReconnection(t *testing.T) {
+	sink := newTraceSink(t)
+	startChannelManager(t)
+	startCollector(t, sink)
+	requireDelivery(t, sink, t.Name()+"/before", deliveryTimeout)
+
+	require.NoError(t, compose("restart", "slim"))
+	require.NoError(t, waitListening(slimAddress, startTimeout))
+
+	waitReady(t, startTimeout)
+	requireDelivery(t, sink, t.Name()+"/after", 2*deliveryTimeout)
+}
+
+func TestSoak(t *testing.T) {
+	reportFile := filepath.Join(t.TempDir(), "soak-report.json")
+	cmd := exec.Command(binaries.testapp, "soak",
+		"--slim-address", "http://"+slimAddress,
+		"--channel", "agntcy/otel/e2e-soak-channel",
+		"--exporter-name", "agntcy/otel/e2e-soak-exporter",
+		"--receiver-name", "agntcy/otel/e2e-soak-receiver",
+		"--duration", "20s",
+		"--rate", "20",
+		"--report-interval", "5s",
+		"--drain-timeout", "10s",
+		"--report-file", reportFile,
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "testapp soak failed:\n%s", out)
+
+	data, err := os.ReadFile(reportFile)
+	require.NoError(t, err)
+	var report struct {
+		SpansSent  uint64 `json:"spans_sent"`
+		Received   uint64 `json:"received"`
+		Lost       uint64 `json:"lost"`
+		Duplicates uint64 `json:"duplicates"`
+	}
+	require.NoError(t, json.Unmarshal(data, &report))
+
+	assert.Positive(t, report.SpansSent)
+	assert.Equal(t, report.SpansSent, report.Received)
+	assert.Zero(t, report.Lost)
+	assert.Zero(t, report.Duplicates)
+}
@@ -0,0 +1,208 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// markerAttribute is the span attribute identifying the spans sent by a test
+const markerAttribute = "e2e.marker"
+
+// configFile returns the absolute path of a configuration file of the tests
+func configFile(t *testing.T, name string) string {
+	t.Helper()
+	path, err := filepath.Abs(filepath.Join(configDir, name))
+	require.NoError(t, err)
+	return path
+}
+
+// startProcess starts a binary, stopped with SIGTERM at the end of the test.
+// Its output is written to a file, logged when the test fails.
+func startProcess(t *testing.T, env []string, binary string, args ...string) {
+	t.Helper()
+	logFile := filepath.Join(t.TempDir(), filepath.Base(binary)+".log")
+	out, err := os.Create(logFile)
+	require.NoError(t, err)
+
+	cmd := exec.Command(binary, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	require.NoError(t, cmd.Start())
+
+	t.Cleanup(func() {
+		_ = cmd.Process.Signal(syscall.SIGTERM)
+		done := make(chan struct{})
+		go func() {
+			_ = cmd.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(30 * time.Second):
+			_ = cmd.Process.Kill()
+			<-done
+		}
+		_ = out.Close()
+
+		if t.Failed() {
+			logs, _ := os.ReadFile(logFile)
+			t.Logf("%s output:\n%s", filepath.Base(binary), logs)
+		}
+	})
+}
+
+// startChannelManager starts the channel manager and waits until it is ready
+func startChannelManager(t *testing.T) {
+	t.Helper()
+	startProcess(t, nil, binaries.channelManager, "--config-file", configFile(t, "channel-manager.yaml"))
+	waitReady(t, startTimeout)
+}
+
+// startCollector starts the collector exporting the received traces to sink
+func startCollector(t *testing.T, sink *traceSink) {
+	t.Helper()
+	startProcess(t, []string{"E2E_SINK_ENDPOINT=" + sink.address},
+		binaries.collector, "--config", configFile(t, "collector.yaml"))
+	require.NoError(t, waitListening(collectorOTLPAddress, startTimeout))
+}
+
+// waitReady waits until the readiness service of the channel manager reports
+// serving
+func waitReady(t *testing.T, timeout time.Duration) {
+	t.Helper()
+	conn, err := grpc.NewClient(managerAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	require.Eventually(t, func() bool {
+		ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+		defer cancel()
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "readiness"})
+		return err == nil && resp.Status == healthpb.HealthCheckResponse_SERVING
+	}, timeout, 500*time.Millisecond, "the channel manager is not ready")
+}
+
+// cmctl runs a cmctl command against the channel manager and decodes its JSON
+// result in result, unless nil
+func cmctl(t *testing.T, result any, args ...string) {
+	t.Helper()
+	args = append([]string{"-server", managerAddress, "-output", "json"}, args...)
+	cmd := exec.Command(binaries.cmctl, args...)
+	// the contexts of the user are ignored
+	cmd.Env = append(os.Environ(), "CMCTL_CONFIG="+filepath.Join(t.TempDir(), "config"))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	require.NoError(t, cmd.Run(), "cmctl %v failed: %s", args, stderr.String())
+	if result != nil {
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), result), "cmctl %v output: %s", args, stdout.String())
+	}
+}
+
+// traceSink is an OTLP gRPC server recording the markers of the received spans
+type traceSink struct {
+	ptraceotlp.UnimplementedGRPCServer
+
+	address string
+
+	mu      sync.Mutex
+	markers map[string]int
+}
+
+// newTraceSink starts a sink on a free port, stopped at the end of the test
+func newTraceSink(t *testing.T) *traceSink {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	sink := &traceSink{address: lis.Addr().String(), markers: map[string]int{}}
+	server := grpc.NewServer()
+	ptraceotlp.RegisterGRPCServer(server, sink)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+	return sink
+}
+
+// Export records the markers of the spans of the request
+func (s *traceSink) Export(_ context.Context, req ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rss := req.Traces().ResourceSpans()
+	for i := range rss.Len() {
+		sss := rss.At(i).ScopeSpans()
+		for j := range sss.Len() {
+			spans := sss.At(j).Spans()
+			for k := range spans.Len() {
+				if marker, ok := spans.At(k).Attributes().Get(markerAttribute); ok {
+					s.markers[marker.Str()]++
+				}
+			}
+		}
+	}
+	return ptraceotlp.NewExportResponse(), nil
+}
+
+// received returns the number of spans received with marker
+func (s *traceSink) received(marker string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.markers[marker]
+}
+
+// sendTraces sends a span with marker to the OTLP receiver of the collector
+func sendTraces(t *testing.T, marker string) error {
+	t.Helper()
+	conn, err := grpc.NewClient(collectorOTLPAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	traces := ptrace.NewTraces()
+	span := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetName("e2e-span")
+	span.Attributes().PutStr(markerAttribute, marker)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	_, err = ptraceotlp.NewGRPCClient(conn).Export(ctx, ptraceotlp.NewExportRequestFromTraces(traces))
+	return err
+}
+
+// requireDelivery sends spans with marker until the sink receives one. The
+// first spans may be dropped while the collector joins the channel.
+func requireDelivery(t *testing.T, sink *traceSink, marker string, timeout time.Duration) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		if sink.received(marker) > 0 {
+			return true
+		}
+		if err := sendTraces(t, marker); err != nil {
+			t.Logf("failed to send the traces: %v", err)
+		}
+		return false
+	}, timeout, time.Second, "no span with marker %s delivered", marker)
+}
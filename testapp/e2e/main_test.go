@@ -0,0 +1,155 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build e2e
+
+// Package e2e runs the channel manager, a collector with the slim exporter
+// and receiver and the testapp against a SLIM node started with docker
+// compose, and checks the delivery of the telemetry, the channel lifecycle
+// and the reconnections.
+//
+// The collector is built with task collector:build, the other binaries are
+// built by the tests. Run them with:
+//
+//	task test:e2e
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	// slimAddress is the address of the SLIM node of the compose file
+	slimAddress = "127.0.0.1:46357"
+	// managerAddress is the service address of the channel manager
+	managerAddress = "127.0.0.1:46358"
+	// collectorOTLPAddress is the address of the OTLP receiver of the collector
+	collectorOTLPAddress = "127.0.0.1:14317"
+
+	// startTimeout is the maximum wait for a component to be ready
+	startTimeout = time.Minute
+)
+
+var (
+	// rootDir is the root of the repository
+	rootDir = filepath.Join("..", "..")
+	// configDir holds the compose file and the configurations of the tests
+	configDir = filepath.Join(rootDir, "tests", "e2e")
+	// composeFile starts the SLIM node
+	composeFile = filepath.Join(configDir, "docker-compose.yaml")
+)
+
+// binaries are the paths of the binaries under test, set by TestMain
+var binaries struct {
+	collector      string
+	channelManager string
+	cmctl          string
+	testapp        string
+}
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+// run builds the binaries and starts the SLIM node around the tests
+func run(m *testing.M) int {
+	binDir, err := os.MkdirTemp("", "slim-otel-e2e")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to create the binaries directory:", err)
+		return 1
+	}
+	defer os.RemoveAll(binDir)
+
+	if err := buildBinaries(binDir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := compose("up", "-d"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer func() {
+		if err := compose("down"); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
+	if err := waitListening(slimAddress, startTimeout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return m.Run()
+}
+
+// buildBinaries builds the channel manager, cmctl and the testapp in dir, and
+// checks the collector built with task collector:build, or set with the
+// E2E_COLLECTOR environment variable
+func buildBinaries(dir string) error {
+	binaries.collector = os.Getenv("E2E_COLLECTOR")
+	if binaries.collector == "" {
+		binaries.collector = filepath.Join(rootDir, "cmd", "slimotelcol", "slimotelcol")
+	}
+	collector, err := filepath.Abs(binaries.collector)
+	if err != nil {
+		return fmt.Errorf("invalid collector path: %w", err)
+	}
+	if _, err := os.Stat(collector); err != nil {
+		return fmt.Errorf("collector not found, build it with task collector:build: %w", err)
+	}
+	binaries.collector = collector
+
+	builds := []struct {
+		path   *string
+		name   string
+		module string
+		pkg    string
+	}{
+		{&binaries.channelManager, "channelmanager", "channelmanager", "./cmd/channelmanager"},
+		{&binaries.cmctl, "cmctl", "channelmanager", "./cmd/cmctl"},
+		{&binaries.testapp, "testapp", "testapp", "."},
+	}
+	for _, b := range builds {
+		*b.path = filepath.Join(dir, b.name)
+		cmd := exec.Command("go", "build", "-o", *b.path, b.pkg)
+		cmd.Dir = filepath.Join(rootDir, b.module)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to build %s: %w\n%s", b.name, err, out)
+		}
+	}
+	return nil
+}
+
+// compose runs a docker compose command on the compose file of the tests
+func compose(args ...string) error {
+	cmd := exec.Command("docker", append([]string{"compose", "-f", composeFile}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("docker compose %v failed: %w\n%s", args, err, out)
+	}
+	return nil
+}
+
+// waitListening waits until a TCP server listens on address
+func waitListening(address string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var dialer net.Dialer
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err == nil {
+			return conn.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("nothing listening on %s: %w", address, err)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+// testapp is a tool to test the SLIM exporter and receiver against a running
+// SLIM node.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// command is a testapp subcommand, it returns the process exit code
+type command struct {
+	description string
+	run         func(args []string) int
+}
+
+var commands = map[string]command{
+	"soak": {
+		description: "continuously exchange sequence-numbered traffic and report loss, duplicates and memory growth",
+		run:         runSoak,
+	},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: testapp <command> [flags]\n\nCommands:\n")
+	for name, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, cmd.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'testapp <command> -h' for the command flags.\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	os.Exit(cmd.run(os.Args[2:]))
+}
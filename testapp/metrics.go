@@ -0,0 +1,153 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/attribute"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.uber.org/zap"
+)
+
+const (
+	// scopeName is the instrumentation scope of the testapp metrics
+	scopeName = "github.com/agntcy/slim-otel/testapp"
+
+	metricSoakMessages     = "testapp_soak_messages"
+	metricSoakSpans        = "testapp_soak_spans"
+	metricSoakMissingSpans = "testapp_soak_missing_spans"
+	metricSoakHeap         = "testapp_soak_heap_bytes"
+)
+
+// startMetricsServer serves the testapp metrics, along with the metrics of
+// the SLIM exporter and receiver it runs, in the Prometheus format on address
+// until ctx is done. If address is empty, metrics are not collected and a
+// no-op meter provider is returned.
+func startMetricsServer(ctx context.Context, logger *zap.Logger, address string) (metric.MeterProvider, error) {
+	if address == "" {
+		return noop.NewMeterProvider(), nil
+	}
+
+	registry := prometheus.NewRegistry()
+	exporter, err := otelprom.New(otelprom.WithRegisterer(registry))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create prometheus exporter: %w", err)
+	}
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(exporter))
+
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on metrics address %s: %w", address, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	logger.Info("Starting metrics server", zap.String("address", lis.Addr().String()))
+	go func() {
+		if err := server.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Metrics server failed", zap.Error(err))
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+		_ = meterProvider.Shutdown(shutdownCtx)
+	}()
+
+	return meterProvider, nil
+}
+
+var (
+	attrSent       = metric.WithAttributes(attribute.String("state", "sent"))
+	attrFailed     = metric.WithAttributes(attribute.String("state", "failed"))
+	attrReceived   = metric.WithAttributes(attribute.String("state", "received"))
+	attrDuplicated = metric.WithAttributes(attribute.String("state", "duplicated"))
+	attrReordered  = metric.WithAttributes(attribute.String("state", "reordered"))
+)
+
+// soakTelemetry records the counters of a soak run
+type soakTelemetry struct {
+	messages  metric.Int64Counter
+	spansSent atomic.Uint64
+}
+
+// newSoakTelemetry creates the soak metrics, the received spans being
+// observed from tracker
+func newSoakTelemetry(meterProvider metric.MeterProvider, tracker *sequenceTracker) (*soakTelemetry, error) {
+	meter := meterProvider.Meter(scopeName)
+	t := &soakTelemetry{}
+
+	var errs, err error
+	t.messages, err = meter.Int64Counter(metricSoakMessages,
+		metric.WithDescription("Number of messages published by the soak test, by state"),
+		metric.WithUnit("{messages}"))
+	errs = errors.Join(errs, err)
+
+	spans, err := meter.Int64ObservableCounter(metricSoakSpans,
+		metric.WithDescription("Number of spans sent and received by the soak test, by state"),
+		metric.WithUnit("{spans}"))
+	errs = errors.Join(errs, err)
+
+	missing, err := meter.Int64ObservableGauge(metricSoakMissingSpans,
+		metric.WithDescription("Number of spans sent but not received yet"),
+		metric.WithUnit("{spans}"))
+	errs = errors.Join(errs, err)
+
+	heap, err := meter.Int64ObservableGauge(metricSoakHeap,
+		metric.WithDescription("Heap in use by the soak test"),
+		metric.WithUnit("By"))
+	errs = errors.Join(errs, err)
+	if errs != nil {
+		return nil, errs
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		sent := t.spansSent.Load()
+		stats := tracker.stats(sent)
+		o.ObserveInt64(spans, int64(sent), attrSent)                   //nolint:gosec // span counts fit in int64
+		o.ObserveInt64(spans, int64(stats.Received), attrReceived)     //nolint:gosec // span counts fit in int64
+		o.ObserveInt64(spans, int64(stats.Duplicates), attrDuplicated) //nolint:gosec // span counts fit in int64
+		o.ObserveInt64(spans, int64(stats.Reordered), attrReordered)   //nolint:gosec // span counts fit in int64
+		o.ObserveInt64(missing, int64(stats.Lost))                     //nolint:gosec // span counts fit in int64
+
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		o.ObserveInt64(heap, int64(mem.HeapInuse)) //nolint:gosec // the heap size fits in int64
+		return nil
+	}, spans, missing, heap)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// recordSent records a message of count spans published
+func (t *soakTelemetry) recordSent(ctx context.Context, count int) {
+	t.messages.Add(ctx, 1, attrSent)
+	t.spansSent.Add(uint64(count)) //nolint:gosec // count is positive
+}
+
+// recordFailed records a message that could not be published
+func (t *soakTelemetry) recordFailed(ctx context.Context) {
+	t.messages.Add(ctx, 1, attrFailed)
+}
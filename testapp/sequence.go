@@ -0,0 +1,74 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import "sync"
+
+// sequenceTracker records the sequence numbers of the received messages to
+// detect lost, duplicated and reordered messages. Sequence numbers start at 0
+// and are stored in a bitset, so that tracking a long run does not itself
+// cause a significant memory growth.
+type sequenceTracker struct {
+	mutex sync.Mutex
+
+	seen       []uint64
+	received   uint64
+	duplicates uint64
+	reordered  uint64
+	highest    uint64
+	any        bool
+}
+
+// record records the reception of seq
+func (t *sequenceTracker) record(seq uint64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	word, bit := seq/64, seq%64
+	for uint64(len(t.seen)) <= word {
+		t.seen = append(t.seen, 0)
+	}
+	if t.seen[word]&(1<<bit) != 0 {
+		t.duplicates++
+		return
+	}
+	t.seen[word] |= 1 << bit
+	t.received++
+
+	if t.any && seq < t.highest {
+		t.reordered++
+	}
+	if !t.any || seq > t.highest {
+		t.highest = seq
+		t.any = true
+	}
+}
+
+// sequenceStats is a snapshot of the sequenceTracker counters
+type sequenceStats struct {
+	// unique messages received
+	Received uint64 `json:"received"`
+	// messages sent but never received
+	Lost uint64 `json:"lost"`
+	// messages received more than once (extra copies)
+	Duplicates uint64 `json:"duplicates"`
+	// messages received after a message with a higher sequence number
+	Reordered uint64 `json:"reordered"`
+}
+
+// stats returns the counters given that the messages 0 to sent-1 were sent
+func (t *sequenceTracker) stats(sent uint64) sequenceStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	stats := sequenceStats{
+		Received:   t.received,
+		Duplicates: t.duplicates,
+		Reordered:  t.reordered,
+	}
+	if sent > t.received {
+		stats.Lost = sent - t.received
+	}
+	return stats
+}
@@ -0,0 +1,23 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequenceTracker(t *testing.T) {
+	tracker := &sequenceTracker{}
+	for _, seq := range []uint64{0, 1, 3, 2, 3, 200} {
+		tracker.record(seq)
+	}
+
+	stats := tracker.stats(201)
+	assert.Equal(t, uint64(5), stats.Received)
+	assert.Equal(t, uint64(196), stats.Lost)
+	assert.Equal(t, uint64(1), stats.Duplicates)
+	assert.Equal(t, uint64(1), stats.Reordered)
+}
@@ -0,0 +1,343 @@
+// Copyright AGNTCY Contributors (https://github.com/agntcy)
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+
+	"github.com/agntcy/slim-otel/exporter/slimexporter"
+	"github.com/agntcy/slim-otel/receiver/slimreceiver"
+	"github.com/agntcy/slim-otel/slimconfig"
+)
+
+// seqAttribute is the span attribute holding the sequence number of the span
+const seqAttribute = "soak.seq"
+
+// soakConfig holds the soak command flags
+type soakConfig struct {
+	address        string
+	sharedSecret   string
+	channel        string
+	exporterName   string
+	receiverName   string
+	duration       time.Duration
+	rate           float64
+	spansPerMsg    int
+	reportInterval time.Duration
+	drainTimeout   time.Duration
+	reportFile     string
+	metricsAddress string
+}
+
+// soakReport is the final report of a soak run
+type soakReport struct {
+	Duration     string `json:"duration"`
+	MessagesSent uint64 `json:"messages_sent"`
+	SpansSent    uint64 `json:"spans_sent"`
+	SendFailures uint64 `json:"send_failures"`
+	sequenceStats
+	HeapStartBytes uint64 `json:"heap_start_bytes"`
+	HeapEndBytes   uint64 `json:"heap_end_bytes"`
+	HeapMaxBytes   uint64 `json:"heap_max_bytes"`
+	GoroutinesEnd  int    `json:"goroutines_end"`
+}
+
+// passed reports whether the run completed without loss or duplicates
+func (r *soakReport) passed() bool {
+	return r.Lost == 0 && r.Duplicates == 0
+}
+
+func runSoak(args []string) int {
+	cfg := soakConfig{}
+	fs := flag.NewFlagSet("soak", flag.ExitOnError)
+	fs.StringVar(&cfg.address, "slim-address", "http://127.0.0.1:46357", "address of the SLIM node")
+	fs.StringVar(&cfg.sharedSecret, "shared-secret", "a-very-long-shared-secret-0123456789-abcdefg", "shared secret for MLS and identity provider")
+	fs.StringVar(&cfg.channel, "channel", "agntcy/otel/soak-channel", "name of the channel used for the test")
+	fs.StringVar(&cfg.exporterName, "exporter-name", "agntcy/otel/soak-exporter", "prefix of the exporter names")
+	fs.StringVar(&cfg.receiverName, "receiver-name", "agntcy/otel/soak-receiver", "name of the receiver")
+	fs.DurationVar(&cfg.duration, "duration", time.Hour, "duration of the test")
+	fs.Float64Var(&cfg.rate, "rate", 10, "messages published per second")
+	fs.IntVar(&cfg.spansPerMsg, "spans", 10, "spans per message")
+	fs.DurationVar(&cfg.reportInterval, "report-interval", time.Minute, "interval of the progress logs")
+	fs.DurationVar(&cfg.drainTimeout, "drain-timeout", 30*time.Second, "maximum time to wait for in-flight messages at the end")
+	fs.StringVar(&cfg.reportFile, "report-file", "", "optional file where the final report is written in JSON")
+	fs.StringVar(&cfg.metricsAddress, "metrics-address", "", "optional address serving the metrics in the Prometheus format on /metrics")
+	_ = fs.Parse(args)
+
+	if cfg.rate <= 0 || cfg.spansPerMsg <= 0 || cfg.duration <= 0 || cfg.reportInterval <= 0 {
+		fmt.Fprintln(os.Stderr, "duration, rate, spans and report-interval must be positive")
+		return 2
+	}
+
+	logger := zap.Must(zap.NewProduction())
+	defer logger.Sync() //nolint:errcheck
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	meterProvider, err := startMetricsServer(ctx, logger, cfg.metricsAddress)
+	if err != nil {
+		logger.Error("Failed to start the metrics server", zap.Error(err))
+		return 1
+	}
+
+	report, err := soak(ctx, logger, meterProvider, cfg)
+	if err != nil {
+		logger.Error("Soak test failed", zap.Error(err))
+		return 1
+	}
+
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+	if cfg.reportFile != "" {
+		if err := os.WriteFile(cfg.reportFile, out, 0o600); err != nil {
+			logger.Error("Failed to write the report", zap.Error(err))
+			return 1
+		}
+	}
+
+	if !report.passed() {
+		logger.Error("Soak test detected lost or duplicated messages")
+		return 1
+	}
+	return 0
+}
+
+// soak runs a slim exporter and a slim receiver connected through a channel
+// and publishes sequence-numbered spans until the duration elapses or ctx is
+// done. The metrics of the run and of the components go to meterProvider.
+func soak(
+	ctx context.Context,
+	logger *zap.Logger,
+	meterProvider metric.MeterProvider,
+	cfg soakConfig,
+) (*soakReport, error) {
+	tracker := &sequenceTracker{}
+	host := componenttest.NewNopHost()
+	telemetry, err := newSoakTelemetry(meterProvider, tracker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the soak metrics: %w", err)
+	}
+
+	rcv, err := newSoakReceiver(ctx, logger, meterProvider, cfg, tracker)
+	if err != nil {
+		return nil, err
+	}
+	if err := rcv.Start(ctx, host); err != nil {
+		return nil, fmt.Errorf("failed to start the receiver: %w", err)
+	}
+	defer func() {
+		if err := rcv.Shutdown(context.Background()); err != nil {
+			logger.Warn("Failed to shutdown the receiver", zap.Error(err))
+		}
+	}()
+
+	exp, err := newSoakExporter(ctx, logger, meterProvider, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := exp.Start(ctx, host); err != nil {
+		return nil, fmt.Errorf("failed to start the exporter: %w", err)
+	}
+	defer func() {
+		if err := exp.Shutdown(context.Background()); err != nil {
+			logger.Warn("Failed to shutdown the exporter", zap.Error(err))
+		}
+	}()
+
+	report := &soakReport{HeapStartBytes: heapInUse()}
+	report.HeapMaxBytes = report.HeapStartBytes
+	start := time.Now()
+
+	publishTicker := time.NewTicker(time.Duration(float64(time.Second) / cfg.rate))
+	defer publishTicker.Stop()
+	reportTicker := time.NewTicker(cfg.reportInterval)
+	defer reportTicker.Stop()
+	deadline := time.NewTimer(cfg.duration)
+	defer deadline.Stop()
+
+	logger.Info("Soak test started",
+		zap.Duration("duration", cfg.duration),
+		zap.Float64("rate", cfg.rate),
+		zap.Int("spans_per_message", cfg.spansPerMsg))
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Soak test interrupted")
+			break loop
+		case <-deadline.C:
+			break loop
+		case <-reportTicker.C:
+			heap := heapInUse()
+			report.HeapMaxBytes = max(report.HeapMaxBytes, heap)
+			stats := tracker.stats(report.SpansSent)
+			logger.Info("Soak test progress",
+				zap.Duration("elapsed", time.Since(start).Round(time.Second)),
+				zap.Uint64("messages_sent", report.MessagesSent),
+				zap.Uint64("send_failures", report.SendFailures),
+				zap.Uint64("spans_received", stats.Received),
+				zap.Uint64("spans_missing", stats.Lost),
+				zap.Uint64("duplicates", stats.Duplicates),
+				zap.Uint64("heap_bytes", heap))
+		case <-publishTicker.C:
+			td := sequencedTraces(report.SpansSent, cfg.spansPerMsg)
+			if err := exp.ConsumeTraces(ctx, td); err != nil {
+				report.SendFailures++
+				telemetry.recordFailed(ctx)
+				logger.Debug("Failed to publish message", zap.Error(err))
+				continue
+			}
+			report.MessagesSent++
+			report.SpansSent += uint64(cfg.spansPerMsg)
+			telemetry.recordSent(ctx, cfg.spansPerMsg)
+		}
+	}
+
+	// wait for the messages still in flight
+	drainDeadline := time.Now().Add(cfg.drainTimeout)
+	for tracker.stats(report.SpansSent).Lost > 0 && time.Now().Before(drainDeadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	report.Duration = time.Since(start).Round(time.Second).String()
+	report.sequenceStats = tracker.stats(report.SpansSent)
+	report.HeapEndBytes = heapInUse()
+	report.HeapMaxBytes = max(report.HeapMaxBytes, report.HeapEndBytes)
+	report.GoroutinesEnd = runtime.NumGoroutine()
+	return report, nil
+}
+
+// newSoakReceiver creates a slim receiver recording the sequence numbers of
+// the received spans in tracker
+func newSoakReceiver(
+	ctx context.Context,
+	logger *zap.Logger,
+	meterProvider metric.MeterProvider,
+	cfg soakConfig,
+	tracker *sequenceTracker,
+) (receiver.Traces, error) {
+	factory := slimreceiver.NewFactory()
+	rcfg := factory.CreateDefaultConfig().(*slimreceiver.Config)
+	rcfg.ConnectionConfig = &slimconfig.ConnectionConfig{Address: cfg.address}
+	rcfg.ReceiverName = cfg.receiverName
+	rcfg.SharedSecret = slimconfig.Opaque(cfg.sharedSecret)
+
+	sink, err := consumer.NewTraces(func(_ context.Context, td ptrace.Traces) error {
+		recordSequences(td, tracker)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	set := receiver.Settings{
+		ID:                component.NewID(factory.Type()),
+		TelemetrySettings: componenttest.NewNopTelemetrySettings(),
+		BuildInfo:         component.NewDefaultBuildInfo(),
+	}
+	set.Logger = logger.Named("receiver")
+	set.MeterProvider = meterProvider
+
+	rcv, err := factory.CreateTraces(ctx, set, rcfg, sink)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the receiver: %w", err)
+	}
+	return rcv, nil
+}
+
+// newSoakExporter creates a slim exporter that creates the test channel and
+// invites the receiver
+func newSoakExporter(
+	ctx context.Context,
+	logger *zap.Logger,
+	meterProvider metric.MeterProvider,
+	cfg soakConfig,
+) (exporter.Traces, error) {
+	factory := slimexporter.NewFactory()
+	ecfg := factory.CreateDefaultConfig().(*slimexporter.Config)
+	ecfg.ConnectionConfig = &slimconfig.ConnectionConfig{Address: cfg.address}
+	ecfg.SharedSecret = slimconfig.Opaque(cfg.sharedSecret)
+	// the name of each signal is derived from the exporter name
+	ecfg.ExporterName = cfg.exporterName
+	ecfg.Channels = []slimexporter.ChannelsConfig{{
+		ChannelName:  cfg.channel,
+		Signal:       string(slimconfig.SignalTraces),
+		Participants: []string{cfg.receiverName},
+	}}
+
+	set := exporter.Settings{
+		ID:                component.NewID(factory.Type()),
+		TelemetrySettings: componenttest.NewNopTelemetrySettings(),
+		BuildInfo:         component.NewDefaultBuildInfo(),
+	}
+	set.Logger = logger.Named("exporter")
+	set.MeterProvider = meterProvider
+
+	exp, err := factory.CreateTraces(ctx, set, ecfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the exporter: %w", err)
+	}
+	return exp, nil
+}
+
+// sequencedTraces creates a message of count spans numbered from first
+func sequencedTraces(first uint64, count int) ptrace.Traces {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "slim-soak-test")
+	spans := rs.ScopeSpans().AppendEmpty().Spans()
+	now := time.Now()
+	for i := range count {
+		span := spans.AppendEmpty()
+		span.SetName("soak-span")
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(now))
+		span.Attributes().PutInt(seqAttribute, int64(first)+int64(i)) //nolint:gosec // sequence numbers fit in int64
+	}
+	return td
+}
+
+// recordSequences records the sequence numbers of all the spans of td
+func recordSequences(td ptrace.Traces, tracker *sequenceTracker) {
+	rss := td.ResourceSpans()
+	for i := range rss.Len() {
+		sss := rss.At(i).ScopeSpans()
+		for j := range sss.Len() {
+			spans := sss.At(j).Spans()
+			for k := range spans.Len() {
+				if seq, ok := spans.At(k).Attributes().Get(seqAttribute); ok && seq.Int() >= 0 {
+					tracker.record(uint64(seq.Int()))
+				}
+			}
+		}
+	}
+}
+
+// heapInUse returns the heap in use after a garbage collection
+func heapInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapInuse
+}